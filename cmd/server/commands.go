@@ -12,6 +12,7 @@ import (
 	"github.com/urfave/cli/v2"
 	"gopkg.in/yaml.v3"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/service"
 )
@@ -64,6 +65,22 @@ func printPorts(c *cli.Context) error {
 	return nil
 }
 
+func validateDeployment(conf *config.Config) error {
+	fmt.Println("validating config, this binds the configured ports and contacts STUN, TURN and redis servers")
+	numErrors := 0
+	for _, issue := range service.ValidateDeployment(conf) {
+		fmt.Println(issue.String())
+		if !issue.Warning {
+			numErrors++
+		}
+	}
+	if numErrors != 0 {
+		return fmt.Errorf("found %d configuration errors", numErrors)
+	}
+	fmt.Println("config is valid")
+	return nil
+}
+
 func createToken(c *cli.Context) error {
 	room := c.String("room")
 	identity := c.String("identity")
@@ -18,6 +18,7 @@ import (
 	serverlogger "github.com/livekit/livekit-server/pkg/logger"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/telemetry"
 	"github.com/livekit/livekit-server/version"
 )
 
@@ -54,6 +55,11 @@ func main() {
 				Usage:   "region of the current node. Used by regionaware node selector",
 				EnvVars: []string{"LIVEKIT_REGION"},
 			},
+			&cli.StringFlag{
+				Name:    "role",
+				Usage:   "role of the current node, all or signal. Signal relays don't host rooms",
+				EnvVars: []string{"LIVEKIT_ROLE"},
+			},
 			&cli.StringFlag{
 				Name:    "node-ip",
 				Usage:   "IP address of the current node, used to advertise to clients. Automatically determined by default",
@@ -81,6 +87,10 @@ func main() {
 				Name:  "dev",
 				Usage: "sets log-level to debug, and console formatter",
 			},
+			&cli.BoolFlag{
+				Name:  "validate",
+				Usage: "checks config and the environment it's deployed in, then exits instead of starting the server",
+			},
 			&cli.StringFlag{
 				Name:    "turn-cert",
 				Usage:   "tls cert file for TURN server",
@@ -137,6 +147,7 @@ func main() {
 
 	if err := app.Run(os.Args); err != nil {
 		fmt.Println(err)
+		os.Exit(1)
 	}
 }
 
@@ -160,12 +171,29 @@ func startServer(c *cli.Context) error {
 		return err
 	}
 
+	if c.Bool("validate") {
+		return validateDeployment(conf)
+	}
+
 	if conf.Development {
 		serverlogger.InitDevelopment(conf.LogLevel)
 	} else {
 		serverlogger.InitProduction(conf.LogLevel)
 	}
 
+	issues := conf.Validate()
+	for _, issue := range issues {
+		if issue.Warning {
+			logger.Warnw("config issue", nil, "field", issue.Field, "issue", issue.Message)
+		}
+	}
+	if err := config.ValidationErr(issues); err != nil {
+		return err
+	}
+	if masker := telemetry.NewIdentityMasker(&conf.IdentityMasking); masker != nil {
+		serverlogger.MaskIdentities(masker.Mask)
+	}
+
 	if cpuProfile != "" {
 		if f, err := os.Create(cpuProfile); err != nil {
 			return err
@@ -200,6 +228,9 @@ func startServer(c *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	server.SetConfigLoader(func() (*config.Config, error) {
+		return getConfig(c)
+	})
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
@@ -210,6 +241,20 @@ func startServer(c *cli.Context) error {
 		server.Stop(false)
 	}()
 
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, syscall.SIGHUP)
+
+	go func() {
+		for range reloadChan {
+			res, err := server.ReloadConfig()
+			if err != nil {
+				logger.Errorw("could not reload config", err)
+				continue
+			}
+			logger.Infow("config reloaded", "reloaded", res.Reloaded, "restartRequired", res.RestartRequired)
+		}
+	}()
+
 	return server.Start()
 }
 
@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -13,3 +14,604 @@ func TestConfig_UnmarshalKeys(t *testing.T) {
 	require.NoError(t, conf.unmarshalKeys("key1: secret1"))
 	require.Equal(t, "secret1", conf.Keys["key1"])
 }
+
+func TestRoomConfig_NetworkEmulationFor(t *testing.T) {
+	conf := RoomConfig{
+		NetworkEmulation: []NetworkEmulationConfig{
+			{Rooms: []string{"qa-1", "qa-2"}, MaxBitrate: 500_000},
+			{Rooms: []string{"qa-3"}, MaxBitrate: 1_000_000},
+		},
+	}
+	require.Nil(t, conf.NetworkEmulationFor("room"))
+	require.Equal(t, uint64(500_000), conf.NetworkEmulationFor("qa-2").MaxBitrate)
+	require.Equal(t, uint64(1_000_000), conf.NetworkEmulationFor("qa-3").MaxBitrate)
+}
+
+func TestRoomPolicy_WithOverride(t *testing.T) {
+	dtx := false
+	policy := RoomPolicy{MaxPublishBitrate: 2_000_000, MaxSimulcastLayers: 3}
+	require.Equal(t, policy, policy.WithOverride(nil))
+	require.True(t, policy.DTXEnabled())
+
+	overridden := policy.WithOverride(&RoomPolicy{MaxSimulcastLayers: 1, AudioDTX: &dtx})
+	require.Equal(t, uint64(2_000_000), overridden.MaxPublishBitrate)
+	require.Equal(t, 1, overridden.MaxSimulcastLayers)
+	require.False(t, overridden.DTXEnabled())
+	require.Zero(t, overridden.MaxDuration)
+
+	policy.MaxDuration = 3600
+	require.Equal(t, uint32(600), policy.WithOverride(&RoomPolicy{MaxDuration: 600}).MaxDuration)
+	require.Equal(t, uint32(3600), policy.WithOverride(&RoomPolicy{}).MaxDuration)
+
+	reports := SenderReportConfig{AudioInterval: 500 * time.Millisecond, VideoInterval: 2 * time.Second, BatchSize: 10}
+	require.Equal(t, reports, policy.SenderReports(reports))
+	require.Equal(t, SenderReportConfig{
+		AudioInterval: DefaultAudioSenderReportInterval,
+		VideoInterval: DefaultVideoSenderReportInterval,
+		BatchSize:     DefaultSenderReportBatchSize,
+	}, policy.SenderReports(SenderReportConfig{}))
+	overridden = policy.WithOverride(&RoomPolicy{SenderReportIntervalMs: 1000, SenderReportBatchSize: 5})
+	require.Equal(t, SenderReportConfig{AudioInterval: time.Second, VideoInterval: time.Second, BatchSize: 5}, overridden.SenderReports(reports))
+
+	require.Equal(t, DefaultHeaderExtensions, policy.EnabledHeaderExtensions())
+	overridden = policy.WithOverride(&RoomPolicy{HeaderExtensions: []string{HeaderExtensionSDESMid}})
+	require.Equal(t, []string{HeaderExtensionSDESMid}, overridden.EnabledHeaderExtensions())
+	// none at all, unlike leaving them out
+	overridden = policy.WithOverride(&RoomPolicy{HeaderExtensions: []string{}})
+	require.Empty(t, overridden.EnabledHeaderExtensions())
+
+	waiting, open := true, false
+	require.False(t, policy.WaitingRoomEnabled())
+	policy.WaitingRoom = &waiting
+	require.True(t, policy.WithOverride(&RoomPolicy{}).WaitingRoomEnabled())
+	require.False(t, policy.WithOverride(&RoomPolicy{WaitingRoom: &open}).WaitingRoomEnabled())
+
+	require.False(t, policy.IsViewer("host"))
+	overridden = policy.WithOverride(&RoomPolicy{Broadcast: &BroadcastPolicy{Publishers: []string{"host"}}})
+	require.False(t, overridden.IsViewer("host"))
+	require.True(t, overridden.IsViewer("viewer"))
+}
+
+func TestRoomAudioConfig(t *testing.T) {
+	level, percentile, smooth := uint8(45), uint8(0), uint32(0)
+	audio := AudioConfig{ActiveLevel: 30, MinPercentile: 40, UpdateInterval: 500, SmoothIntervals: 2, ForceMono: true}
+	require.Equal(t, audio, audio.WithOverride(nil))
+	require.Equal(t, AudioConfig{ActiveLevel: 45, MinPercentile: 0, UpdateInterval: 500, SmoothIntervals: 2, ForceMono: true},
+		audio.WithOverride(&RoomAudioConfig{ActiveLevel: &level, MinPercentile: &percentile}))
+
+	// overrides of a room are merged with the ones it has
+	policy := RoomPolicy{}.WithOverride(&RoomPolicy{Audio: &RoomAudioConfig{ActiveLevel: &level}})
+	policy = policy.WithOverride(&RoomPolicy{Audio: &RoomAudioConfig{UpdateInterval: 200, SmoothIntervals: &smooth}})
+	require.Equal(t, &RoomAudioConfig{ActiveLevel: &level, UpdateInterval: 200, SmoothIntervals: &smooth}, policy.Audio)
+	require.Equal(t, AudioConfig{ActiveLevel: 45, MinPercentile: 40, UpdateInterval: 200, ForceMono: true},
+		audio.WithOverride(policy.Audio))
+
+	require.NoError(t, policy.Audio.Validate())
+	tooLoud, tooLong := uint8(128), uint8(101)
+	require.Error(t, (&RoomAudioConfig{ActiveLevel: &tooLoud}).Validate())
+	require.Error(t, (&RoomAudioConfig{MinPercentile: &tooLong}).Validate())
+	require.Error(t, (&RoomAudioConfig{UpdateInterval: 10}).Validate())
+}
+
+func TestConfig_Validate(t *testing.T) {
+	validConfig := func() *Config {
+		conf, err := NewConfig("", nil)
+		require.NoError(t, err)
+		conf.Keys = map[string]string{"key": "0123456789abcdef0123456789abcdef"}
+		return conf
+	}
+	fields := func(issues []*ValidationIssue) []string {
+		var f []string
+		for _, issue := range issues {
+			f = append(f, issue.Field)
+		}
+		return f
+	}
+
+	t.Run("defaults with keys are valid", func(t *testing.T) {
+		issues := validConfig().Validate()
+		require.Empty(t, issues)
+		require.NoError(t, ValidationErr(issues))
+	})
+
+	t.Run("keys are required", func(t *testing.T) {
+		conf := validConfig()
+		conf.Keys = nil
+		issues := conf.Validate()
+		require.Equal(t, []string{"keys"}, fields(issues))
+		require.Error(t, ValidationErr(issues))
+	})
+
+	t.Run("short secrets are a warning", func(t *testing.T) {
+		conf := validConfig()
+		conf.Keys["key"] = "secret"
+		issues := conf.Validate()
+		require.Equal(t, []string{"keys"}, fields(issues))
+		require.NoError(t, ValidationErr(issues))
+	})
+
+	t.Run("key scopes must be known", func(t *testing.T) {
+		conf := validConfig()
+		for key := range conf.Keys {
+			conf.KeyScopes = map[string][]string{key: {KeyScopeRoomCreate, KeyScopeEgress}}
+		}
+		require.Empty(t, conf.Validate())
+
+		conf.KeyScopes["unknown"] = []string{"rooms"}
+		issues := conf.Validate()
+		require.Equal(t, []string{"key_scopes", "key_scopes"}, fields(issues))
+		require.Error(t, ValidationErr(issues))
+	})
+
+	t.Run("keys can come from a command", func(t *testing.T) {
+		conf := validConfig()
+		conf.Keys = nil
+		conf.KeyRotation.Command = []string{"cat", "/run/secrets/livekit-keys"}
+		conf.KeyRotation.Interval = time.Minute
+		require.Empty(t, conf.Validate())
+
+		conf.KeyRotation.CommandTimeout = 0
+		require.Equal(t, []string{"key_rotation.command_timeout"}, fields(conf.Validate()))
+	})
+
+	t.Run("webhook key must be configured", func(t *testing.T) {
+		conf := validConfig()
+		conf.WebHook.URLs = []string{"https://example.com/webhook"}
+		conf.WebHook.APIKey = "other"
+		require.Equal(t, []string{"webhook.api_key"}, fields(conf.Validate()))
+	})
+
+	t.Run("port conflicts", func(t *testing.T) {
+		conf := validConfig()
+		conf.RTC.TCPPort = conf.Port
+		conf.TURN.Enabled = true
+		conf.TURN.UDPPort = 50500
+		issues := conf.Validate()
+		require.Equal(t, []string{"rtc.tcp_port", "turn.udp_port"}, fields(issues))
+		require.Contains(t, issues[0].Message, "port")
+	})
+
+	t.Run("webtransport needs a certificate", func(t *testing.T) {
+		conf := validConfig()
+		conf.WebTransport.Port = 7884
+		require.Equal(t, []string{"webtransport.cert_file", "webtransport.key_file"}, fields(conf.Validate()))
+
+		conf.WebTransport.CertFile = "cert.pem"
+		conf.WebTransport.KeyFile = "key.pem"
+		require.Empty(t, conf.Validate())
+	})
+
+	t.Run("empty port range", func(t *testing.T) {
+		conf := validConfig()
+		conf.RTC.ICEPortRangeEnd = conf.RTC.ICEPortRangeStart
+		require.Equal(t, []string{"rtc.port_range_end"}, fields(conf.Validate()))
+	})
+
+	t.Run("signal relay role", func(t *testing.T) {
+		conf := validConfig()
+		conf.Role = NodeRoleSignal
+		require.Equal(t, []string{"role"}, fields(conf.Validate()))
+
+		conf.Redis.Address = "localhost:6379"
+		require.Empty(t, conf.Validate())
+
+		conf.Role = "media"
+		require.Equal(t, []string{"role"}, fields(conf.Validate()))
+	})
+
+	t.Run("redis topologies", func(t *testing.T) {
+		conf := validConfig()
+		conf.Redis.SentinelAddresses = []string{"sentinel:26379"}
+		require.True(t, conf.HasRedis())
+		require.Equal(t, []string{"redis.sentinel_master_name"}, fields(conf.Validate()))
+		conf.Redis.SentinelMasterName = "mymaster"
+		require.Empty(t, conf.Validate())
+
+		conf.Redis.ClusterAddresses = []string{"redis-1:6379", "redis-2:6379"}
+		require.Equal(t, []string{"redis.cluster_addresses"}, fields(conf.Validate()))
+
+		conf = validConfig()
+		conf.Redis.ClusterAddresses = []string{"redis-1:6379"}
+		conf.Redis.Address = "redis:6379"
+		conf.Redis.DB = 1
+		issues := conf.Validate()
+		require.Equal(t, []string{"redis.db", "redis.address"}, fields(issues))
+		require.False(t, issues[0].Warning)
+		require.True(t, issues[1].Warning)
+	})
+
+	t.Run("node relay", func(t *testing.T) {
+		conf := validConfig()
+		conf.NodeRelay.Port = 7884
+		conf.NodeRelay.APIKey = "key"
+		issues := conf.Validate()
+		require.Equal(t, []string{"node_relay.port"}, fields(issues))
+		require.NoError(t, ValidationErr(issues))
+
+		conf.Redis.Address = "localhost:6379"
+		conf.NodeRelay.Address = "relay.internal"
+		conf.NodeRelay.APIKey = "other"
+		conf.NodeRelay.RetryInterval = 0
+		conf.NodeRelay.Port = conf.Port
+		require.Equal(t, []string{"node_relay.address", "node_relay.api_key", "node_relay.retry_interval", "node_relay.port"},
+			fields(conf.Validate()))
+
+		conf.NodeRelay.APIKey = ""
+		require.Contains(t, fields(conf.Validate()), "node_relay.api_key")
+	})
+
+	t.Run("room store", func(t *testing.T) {
+		conf := validConfig()
+		require.Equal(t, RoomStoreLocal, conf.RoomStore.StoreKind(conf.HasRedis()))
+		conf.RoomStore.Kind = RoomStoreRedis
+		require.Equal(t, []string{"room_store.kind"}, fields(conf.Validate()))
+
+		conf.RoomStore.Kind = RoomStoreEtcd
+		conf.RoomStore.Etcd.Endpoints = []string{"etcd-1:2379"}
+		issues := conf.Validate()
+		require.Equal(t, []string{"room_store.etcd.endpoints", "room_store.kind"}, fields(issues))
+		conf.RoomStore.Etcd.Endpoints = []string{"http://etcd-1:2379"}
+		issues = conf.Validate()
+		require.Equal(t, []string{"room_store.kind"}, fields(issues))
+		require.NoError(t, ValidationErr(issues))
+
+		conf.Redis.Address = "localhost:6379"
+		conf.RoomStore.Kind = RoomStorePostgres
+		require.Equal(t, []string{"room_store.postgres.url"}, fields(conf.Validate()))
+		conf.RoomStore.Postgres.URL = "postgres://localhost/livekit"
+		require.Empty(t, conf.Validate())
+
+		conf.RoomStore.Kind = "mysql"
+		require.Equal(t, []string{"room_store.kind"}, fields(conf.Validate()))
+	})
+
+	t.Run("degradation", func(t *testing.T) {
+		conf := validConfig()
+		conf.Degradation.Enabled = true
+		require.Empty(t, conf.Validate())
+
+		conf.Degradation.LowLoad = conf.Degradation.HighLoad
+		conf.Degradation.MaxSpatialLayer = 3
+		conf.Degradation.PLIThrottleFactor = 0.5
+		require.Equal(t, []string{
+			"degradation.low_load",
+			"degradation.max_spatial_layer",
+			"degradation.pli_throttle_factor",
+		}, fields(conf.Validate()))
+	})
+
+	t.Run("token refresh", func(t *testing.T) {
+		conf := validConfig()
+		conf.TokenRefresh.Before = time.Minute
+		require.Empty(t, conf.Validate())
+
+		conf.TokenRefresh.TTL = time.Minute
+		require.Equal(t, []string{"token_refresh.ttl"}, fields(conf.Validate()))
+		conf.TokenRefresh.Before = -time.Minute
+		require.Equal(t, []string{"token_refresh.before"}, fields(conf.Validate()))
+	})
+
+	t.Run("signal rate limit violation window", func(t *testing.T) {
+		conf := validConfig()
+		conf.RTC.SignalRateLimit.ViolationWindow = 0
+		require.Equal(t, []string{"rtc.signal_rate_limit.violation_window"}, fields(conf.Validate()))
+		conf.RTC.SignalRateLimit.MaxViolations = 0
+		require.Empty(t, conf.Validate())
+	})
+
+	t.Run("subscription limit policy", func(t *testing.T) {
+		conf := validConfig()
+		conf.RTC.SubscriptionLimit.Policy = "drop"
+		require.Equal(t, []string{"rtc.subscription_limit.policy"}, fields(conf.Validate()))
+	})
+
+	t.Run("pacer", func(t *testing.T) {
+		conf := validConfig()
+		conf.RTC.Pacer.Rate = 20_000_000
+		require.Empty(t, conf.Validate())
+
+		conf.RTC.Pacer.Rate = 500_000
+		conf.RTC.Pacer.Burst = 1000
+		require.Equal(t, []string{"rtc.pacer.rate", "rtc.pacer.burst"}, fields(conf.Validate()))
+	})
+
+	t.Run("forwarding", func(t *testing.T) {
+		conf := validConfig()
+		conf.RTC.Forwarding.Workers = 8
+		require.Empty(t, conf.Validate())
+
+		conf.RTC.Forwarding.QueueSize = 8
+		require.Equal(t, []string{"rtc.forwarding.queue_size"}, fields(conf.Validate()))
+
+		conf.RTC.Forwarding.Workers = -1
+		require.Equal(t, []string{"rtc.forwarding.workers"}, fields(conf.Validate()))
+	})
+
+	t.Run("candidates", func(t *testing.T) {
+		conf := validConfig()
+		conf.RTC.Candidates = CandidatesConfig{
+			NAT1To1IPs:           []string{"203.0.113.1", "203.0.113.2/10.0.0.2"},
+			NAT1To1CandidateType: CandidateTypeSrflx,
+			InterfaceIncludes:    []string{"eth*"},
+			ExcludeTypes:         []string{CandidateTypeHost},
+		}
+		require.Empty(t, conf.Validate())
+
+		conf.RTC.Candidates.NAT1To1IPs = []string{"203.0.113.1/internal"}
+		conf.RTC.Candidates.NAT1To1CandidateType = "relay"
+		conf.RTC.Candidates.InterfaceExcludes = []string{"eth["}
+		require.Equal(t, []string{
+			"rtc.candidates.nat_1to1_ips",
+			"rtc.candidates.nat_1to1_candidate_type",
+			"rtc.candidates",
+			"rtc.candidates.exclude_types",
+		}, fields(conf.Validate()))
+
+		// host candidates are the only ones without srflx mappings
+		conf = validConfig()
+		conf.RTC.Candidates.ExcludeTypes = []string{CandidateTypeHost, "prflx"}
+		require.Equal(t, []string{"rtc.candidates.exclude_types", "rtc.candidates.exclude_types"}, fields(conf.Validate()))
+	})
+
+	t.Run("key frame cache", func(t *testing.T) {
+		conf := validConfig()
+		conf.RTC.KeyFrameCache.MaxPackets = 1000
+		require.Empty(t, conf.Validate())
+
+		conf.RTC.KeyFrameCache.MaxPackets = 10
+		require.Equal(t, []string{"rtc.key_frame_cache.max_packets"}, fields(conf.Validate()))
+
+		conf.RTC.KeyFrameCache.MaxPackets = -1
+		require.Equal(t, []string{"rtc.key_frame_cache.max_packets"}, fields(conf.Validate()))
+	})
+
+	t.Run("pending track timeout", func(t *testing.T) {
+		conf := validConfig()
+		conf.RTC.PendingTrackTimeout = 0
+		require.Empty(t, conf.Validate())
+
+		conf.RTC.PendingTrackTimeout = time.Second
+		require.Equal(t, []string{"rtc.pending_track_timeout"}, fields(conf.Validate()))
+	})
+
+	t.Run("subscription audit sink", func(t *testing.T) {
+		conf := validConfig()
+		conf.SubscriptionAudit.Sink = "analytics"
+		require.Equal(t, []string{"subscription_audit.sink"}, fields(conf.Validate()))
+
+		conf.SubscriptionAudit.Sink = "file"
+		conf.SubscriptionAudit.FlushInterval = 0
+		require.Equal(t, []string{"subscription_audit.file_path", "subscription_audit.flush_interval"}, fields(conf.Validate()))
+	})
+
+	t.Run("agents api key", func(t *testing.T) {
+		conf := validConfig()
+		conf.Agents.Enabled = true
+		require.Equal(t, []string{"agents.api_key"}, fields(conf.Validate()))
+
+		conf.Agents.APIKey = "unknown"
+		conf.Agents.TokenTTL = 0
+		require.Equal(t, []string{"agents.api_key", "agents.token_ttl"}, fields(conf.Validate()))
+	})
+
+	t.Run("transcription url", func(t *testing.T) {
+		conf := validConfig()
+		conf.Transcription.Enabled = true
+		require.Equal(t, []string{"transcription.url"}, fields(conf.Validate()))
+
+		conf.Transcription.URL = "https://stt.example.com"
+		require.Equal(t, []string{"transcription.url"}, fields(conf.Validate()))
+
+		conf.Transcription.URL = "wss://stt.example.com/stream"
+		require.Empty(t, conf.Validate())
+	})
+
+	t.Run("capacity report", func(t *testing.T) {
+		conf := validConfig()
+		conf.CapacityReport.Enabled = true
+		conf.WebHook.URLs = []string{"https://example.com/webhook"}
+		conf.WebHook.APIKey = "key"
+		require.Empty(t, conf.Validate())
+
+		conf.CapacityReport.CheckInterval = 0
+		conf.CapacityReport.DegradedTrackRatio = 2
+		require.Equal(t, []string{"capacity_report.check_interval", "capacity_report.degraded_track_ratio"}, fields(conf.Validate()))
+	})
+
+	t.Run("raw dump", func(t *testing.T) {
+		conf := validConfig()
+		conf.RawDump.Enabled = true
+		require.Equal(t, []string{"raw_dump.directory"}, fields(conf.Validate()))
+
+		conf.RawDump.Directory = "/var/lib/livekit/dumps"
+		conf.RawDump.Format = "pcap"
+		conf.RawDump.MaxFileDuration = 0
+		require.Equal(t, []string{"raw_dump.format", "raw_dump.max_file_duration"}, fields(conf.Validate()))
+
+		conf.RawDump.Format = RawDumpFormatMedia
+		conf.RawDump.MaxFileDuration = time.Minute
+		require.Empty(t, conf.Validate())
+	})
+
+	t.Run("identity masking salt", func(t *testing.T) {
+		conf := validConfig()
+		conf.IdentityMasking.Enabled = true
+		require.Equal(t, []string{"identity_masking.salt"}, fields(conf.Validate()))
+
+		conf.IdentityMasking.Salt = "tenant-salt"
+		require.Empty(t, conf.Validate())
+	})
+
+	t.Run("publisher bitrate cap mode", func(t *testing.T) {
+		conf := validConfig()
+		conf.RTC.PublisherBitrateCap.Mode = "drop"
+		require.Equal(t, []string{"rtc.publisher_bitrate_cap.mode"}, fields(conf.Validate()))
+	})
+
+	t.Run("layer bitrates", func(t *testing.T) {
+		conf := validConfig()
+		conf.Room.LayerBitrateTargets = true
+		require.Empty(t, conf.Validate())
+
+		conf.Room.LayerBitrates = []uint64{500_000, 150_000, 1_500_000}
+		require.Equal(t, []string{"room.layer_bitrates"}, fields(conf.Validate()))
+		conf.Room.LayerBitrates = []uint64{150_000, 500_000}
+		require.Equal(t, []string{"room.layer_bitrates"}, fields(conf.Validate()))
+	})
+
+	t.Run("stalled track timeout", func(t *testing.T) {
+		conf := validConfig()
+		conf.Room.StalledTrackTimeout = 0
+		require.Empty(t, conf.Validate())
+		conf.Room.StalledTrackTimeout = time.Second
+		require.Equal(t, []string{"room.stalled_track_timeout"}, fields(conf.Validate()))
+	})
+
+	t.Run("track inactivity", func(t *testing.T) {
+		conf := validConfig()
+		conf.Room.AudioInactivity = TrackInactivityConfig{StalledTimeout: 20 * time.Second, CloseTimeout: time.Minute}
+		conf.Room.VideoInactivity = TrackInactivityConfig{CloseTimeout: 30 * time.Second}
+		require.Empty(t, conf.Validate())
+		require.Equal(t, 20*time.Second, conf.Room.TrackInactivity(true).StalledTimeout)
+		require.Equal(t, 10*time.Second, conf.Room.TrackInactivity(false).StalledTimeout)
+
+		conf.Room.AudioInactivity.StalledTimeout = time.Second
+		conf.Room.VideoInactivity.CloseTimeout = 5 * time.Second
+		require.Equal(t, []string{"room.audio_inactivity.stalled_timeout", "room.video_inactivity.close_timeout"}, fields(conf.Validate()))
+
+		// closing needs stalls to be detected
+		conf = validConfig()
+		conf.Room.StalledTrackTimeout = 0
+		conf.Room.VideoInactivity.CloseTimeout = time.Minute
+		require.Equal(t, []string{"room.video_inactivity.close_timeout"}, fields(conf.Validate()))
+	})
+
+	t.Run("participant update interval", func(t *testing.T) {
+		conf := validConfig()
+		conf.Room.ParticipantUpdateInterval = 500 * time.Millisecond
+		require.Empty(t, conf.Validate())
+		conf.Room.ParticipantUpdateInterval = 10 * time.Second
+		require.Equal(t, []string{"room.participant_update_interval"}, fields(conf.Validate()))
+	})
+
+	t.Run("large rooms", func(t *testing.T) {
+		conf := validConfig()
+		conf.Room.LargeRoom = LargeRoomConfig{ParticipantThreshold: 1000, MaxParticipantsPerUpdate: 200}
+		require.Empty(t, conf.Validate())
+		require.Equal(t, 200, conf.Room.LargeRoom.PageSize())
+		conf.Room.LargeRoom.MaxParticipantsPerUpdate = 0
+		require.Equal(t, DefaultParticipantPageSize, conf.Room.LargeRoom.PageSize())
+		conf.Room.LargeRoom.MaxParticipantsPerUpdate = -1
+		require.Equal(t, []string{"room.large_room.max_participants_per_update"}, fields(conf.Validate()))
+	})
+
+	t.Run("room policy", func(t *testing.T) {
+		conf := validConfig()
+		conf.Room.Policy.MaxSimulcastLayers = -1
+		require.Equal(t, []string{"room.policy.max_simulcast_layers"}, fields(conf.Validate()))
+	})
+
+	t.Run("sender reports", func(t *testing.T) {
+		conf := validConfig()
+		conf.Room.Policy.SenderReportIntervalMs = 1000
+		conf.Room.Policy.SenderReportBatchSize = 31
+		require.Empty(t, conf.Validate())
+
+		conf.Room.Policy.SenderReportIntervalMs = 100
+		conf.Room.Policy.SenderReportBatchSize = 32
+		require.Equal(t, []string{"room.policy.sender_report_interval_ms", "room.policy.sender_report_batch_size"}, fields(conf.Validate()))
+
+		conf = validConfig()
+		conf.RTC.SenderReports.AudioInterval = 100 * time.Millisecond
+		conf.RTC.SenderReports.VideoInterval = time.Minute
+		conf.RTC.SenderReports.BatchSize = 32
+		require.Equal(t, []string{"rtc.sender_reports.audio_interval", "rtc.sender_reports.video_interval", "rtc.sender_reports.batch_size"}, fields(conf.Validate()))
+	})
+
+	t.Run("reorder window", func(t *testing.T) {
+		conf := validConfig()
+		conf.RTC.ReorderWindow = 20 * time.Millisecond
+		require.Empty(t, conf.Validate())
+
+		conf.RTC.ReorderWindow = time.Second
+		require.Equal(t, []string{"rtc.reorder_window"}, fields(conf.Validate()))
+	})
+
+	t.Run("header extensions", func(t *testing.T) {
+		conf := validConfig()
+		conf.Room.Policy.HeaderExtensions = []string{HeaderExtensionSDESMid, HeaderExtensionSDESRid, HeaderExtensionVideoOrientation}
+		require.Empty(t, conf.Validate())
+
+		conf.Room.Policy.HeaderExtensions = []string{"color-space"}
+		require.Equal(t, []string{"room.policy.header_extensions"}, fields(conf.Validate()))
+
+		conf.Room.Policy.HeaderExtensions = []string{HeaderExtensionSDESRid}
+		require.Equal(t, []string{"room.policy.header_extensions"}, fields(conf.Validate()))
+	})
+
+	t.Run("track stats sink", func(t *testing.T) {
+		conf := validConfig()
+		conf.TrackStats.Sink = "http"
+		require.Equal(t, []string{"track_stats.url"}, fields(conf.Validate()))
+
+		conf.TrackStats.Sink = "s3"
+		conf.TrackStats.Interval = 0
+		require.Equal(t, []string{"track_stats.sink", "track_stats.interval"}, fields(conf.Validate()))
+	})
+
+	t.Run("TURN/TLS needs a domain and certificate", func(t *testing.T) {
+		conf := validConfig()
+		conf.TURN.Enabled = true
+		conf.TURN.TLSPort = 5349
+		require.Equal(t, []string{"turn.domain", "turn.cert_file"}, fields(conf.Validate()))
+	})
+
+	t.Run("regionaware selector", func(t *testing.T) {
+		conf := validConfig()
+		conf.Redis.Address = "localhost:6379"
+		conf.NodeSelector.Kind = "regionaware"
+		require.Equal(t, []string{"node_selector.regions", "region"}, fields(conf.Validate()))
+
+		conf.NodeSelector.Kind = "closest"
+		require.Equal(t, []string{"node_selector.kind"}, fields(conf.Validate()))
+	})
+
+	t.Run("RTT probes", func(t *testing.T) {
+		conf := validConfig()
+		conf.NodeSelector.ProbeRTT = true
+		require.Equal(t, []string{"node_selector.probe_rtt"}, fields(conf.Validate()))
+		require.Equal(t, "http://10.0.0.1:7880/rtc/ping", conf.NodePingURL("ND_1", "10.0.0.1", "us-west"))
+
+		conf.Redis.Address = "localhost:6379"
+		conf.NodeSelector.PingURL = "https://{region}.example.com/rtc/ping?node={node_id}"
+		require.Empty(t, conf.Validate())
+		require.Equal(t, "https://us-west.example.com/rtc/ping?node=ND_1", conf.NodePingURL("ND_1", "10.0.0.1", "us-west"))
+
+		conf.NodeSelector.PingURL = "/rtc/ping"
+		require.Equal(t, []string{"node_selector.ping_url"}, fields(conf.Validate()))
+	})
+}
+
+func TestConfig_Reloadable(t *testing.T) {
+	conf, err := NewConfig("", nil)
+	require.NoError(t, err)
+	next, err := NewConfig("", nil)
+	require.NoError(t, err)
+	require.Empty(t, conf.ChangedFields(next))
+
+	next.LogLevel = "debug"
+	next.RTC.SubscriptionLimit.MaxSubscriptions = 10
+	next.RTC.TCPPort = 7891
+	next.Room.EnabledCodecs = next.Room.EnabledCodecs[:1]
+	next.Diagnostics.Enabled = true
+	changed := conf.ChangedFields(next)
+	require.Equal(t, []string{"rtc.tcp_port", "rtc.subscription_limit.max_subscriptions", "room.enabled_codecs", "log_level", "diagnostics.enabled"}, changed)
+	require.False(t, IsReloadable("rtc.tcp_port"))
+	require.False(t, IsReloadable("diagnostics.port"))
+	require.True(t, IsReloadable("rtc.subscription_limit.max_subscriptions"))
+	require.False(t, IsReloadable("rooms"))
+
+	reloaded := conf.WithReloadable(next)
+	require.Equal(t, []string{"rtc.tcp_port"}, reloaded.ChangedFields(next))
+	require.Equal(t, "", conf.LogLevel)
+}
@@ -0,0 +1,587 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// ValidationIssue is a problem with the configuration, phrased so that it can be acted on
+type ValidationIssue struct {
+	// config key the issue is about
+	Field   string
+	Message string
+	// warnings don't prevent the server from starting
+	Warning bool
+}
+
+func (i *ValidationIssue) String() string {
+	level := "error"
+	if i.Warning {
+		level = "warning"
+	}
+	return fmt.Sprintf("%s: %s: %s", level, i.Field, i.Message)
+}
+
+// ValidationError is returned when the configuration has issues that aren't just warnings
+type ValidationError struct {
+	Issues []*ValidationIssue
+}
+
+func (e *ValidationError) Error() string {
+	lines := []string{"invalid configuration"}
+	for _, issue := range e.Issues {
+		if !issue.Warning {
+			lines = append(lines, "  "+issue.String())
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ValidationErr returns a ValidationError when issues contains errors, nil otherwise
+func ValidationErr(issues []*ValidationIssue) error {
+	for _, issue := range issues {
+		if !issue.Warning {
+			return &ValidationError{Issues: issues}
+		}
+	}
+	return nil
+}
+
+// Validate checks the configuration for mistakes that would otherwise only surface once clients
+// try to connect. It doesn't touch the network, see service.ValidateDeployment for that
+func (conf *Config) Validate() []*ValidationIssue {
+	var issues []*ValidationIssue
+	addError := func(field, format string, args ...interface{}) {
+		issues = append(issues, &ValidationIssue{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	addWarning := func(field, format string, args ...interface{}) {
+		issues = append(issues, &ValidationIssue{Field: field, Message: fmt.Sprintf(format, args...), Warning: true})
+	}
+
+	// keys
+	keysFromConfig := conf.KeyFile == "" && len(conf.KeyRotation.Command) == 0
+	switch {
+	case keysFromConfig && len(conf.Keys) == 0:
+		addError("keys", "no API keys, set keys, key_file or key_rotation.command. Create a pair with `livekit-server generate-keys`")
+	case len(conf.KeyRotation.Command) != 0 && conf.KeyFile != "":
+		addWarning("key_file", "key_rotation.command is set as well, key_file is ignored")
+	case !keysFromConfig && len(conf.Keys) != 0:
+		addWarning("keys", "key_file is set as well, keys are ignored")
+	}
+	for key, secret := range conf.Keys {
+		if len(secret) < 32 {
+			addWarning("keys", "secret of %s is shorter than 32 characters, it can be guessed", key)
+		}
+	}
+	for key, scopes := range conf.KeyScopes {
+		if keysFromConfig && conf.Keys[key] == "" {
+			addWarning("key_scopes", "%s is not one of the configured keys", key)
+		}
+		if len(scopes) == 0 {
+			addError("key_scopes", "%s has no scopes, it couldn't be used", key)
+		}
+		for _, scope := range scopes {
+			switch scope {
+			case KeyScopeAdmin, KeyScopeRoomCreate, KeyScopeJoin, KeyScopeEgress:
+			default:
+				addError("key_scopes", "unknown scope %s of %s, must be admin, room_create, join or egress", scope, key)
+			}
+		}
+	}
+	if conf.KeyRotation.Interval < 0 {
+		addError("key_rotation.interval", "must not be negative")
+	} else if conf.KeyRotation.Interval > 0 && conf.KeyFile == "" && len(conf.KeyRotation.Command) == 0 {
+		addWarning("key_rotation.interval", "keys are only rotated from key_file or key_rotation.command")
+	}
+	if len(conf.KeyRotation.Command) != 0 && conf.KeyRotation.CommandTimeout <= 0 {
+		addError("key_rotation.command_timeout", "must be positive")
+	}
+	if len(conf.WebHook.URLs) != 0 {
+		if conf.WebHook.APIKey == "" {
+			addError("webhook.api_key", "required to sign webhook requests")
+		} else if keysFromConfig && conf.Keys[conf.WebHook.APIKey] == "" {
+			addError("webhook.api_key", "%s is not one of the configured keys", conf.WebHook.APIKey)
+		}
+	}
+
+	if conf.Agents.Enabled {
+		if conf.Agents.APIKey == "" {
+			addError("agents.api_key", "required to sign join tokens of agents")
+		} else if keysFromConfig && conf.Keys[conf.Agents.APIKey] == "" {
+			addError("agents.api_key", "%s is not one of the configured keys", conf.Agents.APIKey)
+		}
+		if conf.Agents.TokenTTL <= 0 {
+			addError("agents.token_ttl", "must be positive")
+		}
+	}
+
+	if conf.Transcription.Enabled {
+		if u, err := url.Parse(conf.Transcription.URL); err != nil || (u.Scheme != "ws" && u.Scheme != "wss") {
+			addError("transcription.url", "must be a ws:// or wss:// URL of the speech-to-text service")
+		}
+		if conf.Transcription.Webhook && len(conf.WebHook.URLs) == 0 {
+			addWarning("transcription.webhook", "no webhook URLs are configured")
+		}
+	}
+
+	if conf.CapacityReport.Enabled {
+		if conf.CapacityReport.CheckInterval < time.Second {
+			addError("capacity_report.check_interval", "%v is too short, node stats are sampled at most once a second", conf.CapacityReport.CheckInterval)
+		}
+		if conf.CapacityReport.DegradedTrackRatio <= 0 || conf.CapacityReport.DegradedTrackRatio > 1 {
+			addError("capacity_report.degraded_track_ratio", "must be greater than 0 and at most 1")
+		}
+		if len(conf.WebHook.URLs) == 0 {
+			addWarning("capacity_report.enabled", "no webhook URLs are configured, reports are only logged")
+		}
+	}
+
+	if conf.Degradation.Enabled {
+		if conf.Degradation.CheckInterval < time.Second {
+			addError("degradation.check_interval", "%v is too short, node stats are sampled at most once a second", conf.Degradation.CheckInterval)
+		}
+		if conf.Degradation.LowLoad <= 0 || conf.Degradation.LowLoad >= conf.Degradation.HighLoad {
+			addError("degradation.low_load", "must be greater than 0 and below high_load")
+		}
+		if conf.Degradation.MaxSpatialLayer < 0 || conf.Degradation.MaxSpatialLayer > 2 {
+			addError("degradation.max_spatial_layer", "must be 0, 1 or 2")
+		}
+		if conf.Degradation.PLIThrottleFactor < 1 {
+			addError("degradation.pli_throttle_factor", "must be at least 1")
+		}
+	}
+
+	if conf.RawDump.Enabled {
+		if conf.RawDump.Directory == "" {
+			addError("raw_dump.directory", "required to write dumps to")
+		}
+		switch conf.RawDump.Format {
+		case RawDumpFormatRTPDump, RawDumpFormatMedia:
+		default:
+			addError("raw_dump.format", "unknown format %s, use rtpdump or media", conf.RawDump.Format)
+		}
+		if conf.RawDump.MaxFileSize <= 0 {
+			addError("raw_dump.max_file_size", "must be positive")
+		}
+		if conf.RawDump.MaxFileDuration < time.Second {
+			addError("raw_dump.max_file_duration", "%v is too short, files need to be rotated at most once a second", conf.RawDump.MaxFileDuration)
+		}
+		if conf.RawDump.Retention < 0 {
+			addError("raw_dump.retention", "must not be negative, use 0 to keep dumps")
+		}
+	}
+
+	if conf.WebTransport.Port != 0 {
+		if conf.WebTransport.CertFile == "" {
+			addError("webtransport.cert_file", "required, HTTP/3 requires TLS")
+		}
+		if conf.WebTransport.KeyFile == "" {
+			addError("webtransport.key_file", "required, HTTP/3 requires TLS")
+		}
+	}
+
+	if conf.Diagnostics.Enabled && conf.Diagnostics.Port == 0 {
+		addWarning("diagnostics.enabled", "diagnostics are only served on diagnostics.port")
+	}
+
+	switch conf.Role {
+	case "", NodeRoleAll:
+	case NodeRoleSignal:
+		if !conf.HasRedis() {
+			addError("role", "signal relays route to other nodes through Redis, it has to be configured")
+		}
+		if conf.TURN.Enabled {
+			addWarning("turn.enabled", "signal relays don't handle media, TURN is only started on nodes hosting rooms")
+		}
+	default:
+		addError("role", "unknown role %q, valid values: all, signal", conf.Role)
+	}
+
+	switch {
+	case conf.Redis.IsCluster() && conf.Redis.IsSentinel():
+		addError("redis.cluster_addresses", "set as well as sentinel_addresses, use either Redis Cluster or Sentinel")
+	case conf.Redis.IsCluster():
+		if conf.Redis.DB != 0 {
+			addError("redis.db", "Redis Cluster only has db 0")
+		}
+		if conf.Redis.Address != "" {
+			addWarning("redis.address", "set as well as cluster_addresses, it's ignored")
+		}
+	case conf.Redis.IsSentinel():
+		if conf.Redis.SentinelMasterName == "" {
+			addError("redis.sentinel_master_name", "required to find the master through sentinel_addresses")
+		}
+		if len(conf.Redis.SentinelAddresses) == 0 {
+			addError("redis.sentinel_addresses", "required to find the master %s", conf.Redis.SentinelMasterName)
+		}
+		if conf.Redis.Address != "" {
+			addWarning("redis.address", "set as well as sentinel_addresses, it's ignored")
+		}
+	}
+
+	switch conf.RoomStore.StoreKind(conf.HasRedis()) {
+	case RoomStoreLocal:
+	case RoomStoreRedis:
+		if !conf.HasRedis() {
+			addError("room_store.kind", "redis store requires redis to be configured")
+		}
+	case RoomStoreEtcd:
+		if len(conf.RoomStore.Etcd.Endpoints) == 0 {
+			addError("room_store.etcd.endpoints", "required for the etcd store")
+		}
+		for _, endpoint := range conf.RoomStore.Etcd.Endpoints {
+			if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				addError("room_store.etcd.endpoints", "%s must be an http:// or https:// URL", endpoint)
+			}
+		}
+	case RoomStorePostgres:
+		if conf.RoomStore.Postgres.URL == "" {
+			addError("room_store.postgres.url", "required for the postgres store")
+		}
+	default:
+		addError("room_store.kind", "unknown kind %s, use local, redis, etcd or postgres", conf.RoomStore.Kind)
+	}
+	if kind := conf.RoomStore.StoreKind(conf.HasRedis()); (kind == RoomStoreEtcd || kind == RoomStorePostgres) && !conf.HasRedis() {
+		addWarning("room_store.kind", "rooms are shared through %s, but signaling is only routed between nodes through redis", kind)
+	}
+
+	if conf.NodeRelay.Port != 0 {
+		if !conf.HasRedis() {
+			addWarning("node_relay.port", "redis isn't configured, there are no other nodes to relay to")
+		}
+		if conf.NodeRelay.Address != "" && net.ParseIP(conf.NodeRelay.Address) == nil {
+			addError("node_relay.address", "%s is not an IP address", conf.NodeRelay.Address)
+		}
+		if conf.NodeRelay.APIKey == "" {
+			addError("node_relay.api_key", "required to authenticate streams between nodes")
+		} else if keysFromConfig && conf.Keys[conf.NodeRelay.APIKey] == "" {
+			addError("node_relay.api_key", "%s is not one of the configured keys", conf.NodeRelay.APIKey)
+		}
+		if conf.NodeRelay.RetryInterval <= 0 {
+			addError("node_relay.retry_interval", "must be positive")
+		}
+	}
+
+	if conf.TokenRefresh.Before < 0 {
+		addError("token_refresh.before", "must not be negative")
+	}
+	if conf.TokenRefresh.Before > 0 && conf.TokenRefresh.TTL <= conf.TokenRefresh.Before {
+		addError("token_refresh.ttl", "must be longer than token_refresh.before, or tokens are refreshed as soon as they're sent")
+	}
+
+	// ports
+	if conf.RTC.ICEPortRangeStart != 0 || conf.RTC.ICEPortRangeEnd != 0 {
+		if conf.RTC.ICEPortRangeStart == 0 || conf.RTC.ICEPortRangeEnd <= conf.RTC.ICEPortRangeStart {
+			addError("rtc.port_range_end", "range %d-%d is empty, port_range_start must be below port_range_end",
+				conf.RTC.ICEPortRangeStart, conf.RTC.ICEPortRangeEnd)
+		}
+		if conf.RTC.UDPPort != 0 {
+			addWarning("rtc.udp_port", "set as well as port_range_start, the range is ignored")
+		}
+	}
+	tcpPorts := []namedPort{
+		{"port", conf.Port},
+		{"prometheus_port", conf.PrometheusPort},
+		{"grpc_port", conf.GRPCPort},
+		{"node_relay.port", conf.NodeRelay.Port},
+		{"rtc.tcp_port", conf.RTC.TCPPort},
+		{"diagnostics.port", conf.Diagnostics.Port},
+	}
+	udpPorts := []namedPort{
+		{"rtc.udp_port", conf.RTC.UDPPort},
+		{"webtransport.port", conf.WebTransport.Port},
+	}
+	if conf.TURN.Enabled {
+		tcpPorts = append(tcpPorts, namedPort{"turn.tls_port", uint32(conf.TURN.TLSPort)})
+		udpPorts = append(udpPorts, namedPort{"turn.udp_port", uint32(conf.TURN.UDPPort)})
+	}
+	for _, conflict := range portConflicts(tcpPorts) {
+		addError(conflict[1], "TCP port is already used by %s", conflict[0])
+	}
+	for _, conflict := range portConflicts(udpPorts) {
+		addError(conflict[1], "UDP port is already used by %s", conflict[0])
+	}
+	if conf.RTC.UDPPort == 0 {
+		for _, p := range udpPorts {
+			if p.port != 0 && p.port >= conf.RTC.ICEPortRangeStart && p.port <= conf.RTC.ICEPortRangeEnd {
+				addError(p.name, "UDP port %d is within the ICE port range", p.port)
+			}
+		}
+	}
+
+	candidates := conf.RTC.Candidates
+	for _, mapping := range candidates.NAT1To1IPs {
+		for _, ip := range strings.Split(mapping, "/") {
+			if net.ParseIP(ip) == nil {
+				addError("rtc.candidates.nat_1to1_ips", "%s is not an IP or an external/internal pair of IPs", mapping)
+				break
+			}
+		}
+	}
+	switch candidates.NAT1To1CandidateType {
+	case "", CandidateTypeHost, CandidateTypeSrflx:
+	default:
+		addError("rtc.candidates.nat_1to1_candidate_type", "unknown type %s, use host or srflx", candidates.NAT1To1CandidateType)
+	}
+	for _, pattern := range append(append([]string{}, candidates.InterfaceIncludes...), candidates.InterfaceExcludes...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			addError("rtc.candidates", "invalid interface pattern %s", pattern)
+		}
+	}
+	for _, candidateType := range candidates.ExcludeTypes {
+		switch candidateType {
+		case CandidateTypeHost:
+			if len(candidates.NAT1To1IPs) == 0 || candidates.NAT1To1CandidateType != CandidateTypeSrflx {
+				addError("rtc.candidates.exclude_types", "excluding host candidates requires nat_1to1_ips with the srflx type, clients would get no candidates")
+			}
+		case CandidateTypeSrflx:
+		default:
+			addError("rtc.candidates.exclude_types", "unknown type %s, use host or srflx", candidateType)
+		}
+	}
+
+	if conf.RTC.SignalRateLimit.MaxViolations > 0 && conf.RTC.SignalRateLimit.ViolationWindow <= 0 {
+		addError("rtc.signal_rate_limit.violation_window", "must be positive when max_violations is set")
+	}
+	switch conf.RTC.SubscriptionLimit.Policy {
+	case "", SubscriptionLimitPolicyReject, SubscriptionLimitPolicyEvict:
+	default:
+		addError("rtc.subscription_limit.policy", "unknown policy %s, use reject or evict", conf.RTC.SubscriptionLimit.Policy)
+	}
+	switch conf.RTC.PublisherBitrateCap.Mode {
+	case "", PublisherBitrateCapModeREMB, PublisherBitrateCapModeTWCC:
+	default:
+		addError("rtc.publisher_bitrate_cap.mode", "unknown mode %s, use remb or twcc", conf.RTC.PublisherBitrateCap.Mode)
+	}
+	if conf.RTC.Pacer.Rate != 0 && conf.RTC.Pacer.Rate < 1_000_000 {
+		addError("rtc.pacer.rate", "must be at least 1000000, video would be held back")
+	}
+	if conf.RTC.Pacer.Rate != 0 && conf.RTC.Pacer.Burst < 1500 {
+		addError("rtc.pacer.burst", "must be at least 1500, the size of a packet")
+	}
+	if conf.RTC.Forwarding.Workers < 0 {
+		addError("rtc.forwarding.workers", "cannot be negative")
+	}
+	if conf.RTC.Forwarding.Workers > 0 && conf.RTC.Forwarding.QueueSize < 16 {
+		addError("rtc.forwarding.queue_size", "must be at least 16, subscribers would drop packets of every key frame")
+	}
+	if conf.RTC.KeyFrameCache.MaxPackets < 0 {
+		addError("rtc.key_frame_cache.max_packets", "cannot be negative")
+	}
+	if conf.RTC.KeyFrameCache.MaxPackets > 0 && conf.RTC.KeyFrameCache.MaxPackets < 64 {
+		addError("rtc.key_frame_cache.max_packets", "must be at least 64, key frames alone take dozens of packets")
+	}
+	if conf.RTC.ReorderWindow < 0 || conf.RTC.ReorderWindow > MaxReorderWindow {
+		addError("rtc.reorder_window", "must be between 0 and %v, packets would be held back too long", MaxReorderWindow)
+	}
+	if conf.RTC.PendingTrackTimeout != 0 && conf.RTC.PendingTrackTimeout < 5*time.Second {
+		addError("rtc.pending_track_timeout", "must be at least 5s, publishers need time to negotiate their tracks")
+	}
+	for _, r := range []struct {
+		field    string
+		interval time.Duration
+	}{
+		{"rtc.sender_reports.audio_interval", conf.RTC.SenderReports.AudioInterval},
+		{"rtc.sender_reports.video_interval", conf.RTC.SenderReports.VideoInterval},
+	} {
+		if r.interval != 0 && !validSenderReportInterval(r.interval) {
+			addError(r.field, "must be between %v and %v", MinSenderReportInterval, MaxSenderReportInterval)
+		}
+	}
+	if size := conf.RTC.SenderReports.BatchSize; size < 0 || size > MaxSenderReportBatchSize {
+		addError("rtc.sender_reports.batch_size", "must be between 1 and %d, use 0 for the default", MaxSenderReportBatchSize)
+	}
+	if conf.Room.LayerBitrateTargets {
+		valid := len(conf.Room.LayerBitrates) == 3
+		for i, bitrate := range conf.Room.LayerBitrates {
+			if bitrate == 0 || (i > 0 && bitrate < conf.Room.LayerBitrates[i-1]) {
+				valid = false
+			}
+		}
+		if !valid {
+			addError("room.layer_bitrates", "needs an increasing, non-zero bitrate for each of the low, medium and high layers")
+		}
+	}
+	if conf.Room.StalledTrackTimeout != 0 && conf.Room.StalledTrackTimeout < 2*time.Second {
+		addError("room.stalled_track_timeout", "must be at least 2s, tracks pause briefly when publishers switch layers or networks")
+	}
+	for _, audio := range []bool{true, false} {
+		field, own := "room.video_inactivity", conf.Room.VideoInactivity
+		if audio {
+			field, own = "room.audio_inactivity", conf.Room.AudioInactivity
+		}
+		if own.StalledTimeout != 0 && own.StalledTimeout < 2*time.Second {
+			addError(field+".stalled_timeout", "must be at least 2s, tracks pause briefly when publishers switch layers or networks")
+		}
+		inactivity := conf.Room.TrackInactivity(audio)
+		if inactivity.CloseTimeout != 0 {
+			if inactivity.StalledTimeout == 0 {
+				addError(field+".close_timeout", "needs a stalled timeout, tracks are only closed once they're stalled")
+			} else if inactivity.CloseTimeout <= inactivity.StalledTimeout {
+				addError(field+".close_timeout", "must be longer than the stalled timeout, subscribers are told the track stalled before it's closed")
+			}
+		}
+	}
+	if conf.Room.ParticipantUpdateInterval < 0 || conf.Room.ParticipantUpdateInterval > 5*time.Second {
+		addError("room.participant_update_interval", "must be between 0 and 5s, participants would see others join late")
+	}
+	if conf.Room.LargeRoom.ParticipantThreshold < 0 {
+		addError("room.large_room.participant_threshold", "must not be negative, use 0 to disable")
+	}
+	if conf.Room.LargeRoom.MaxParticipantsPerUpdate < 0 {
+		addError("room.large_room.max_participants_per_update", "must not be negative, use 0 for no limit")
+	}
+	if conf.Room.Policy.MaxSimulcastLayers < 0 {
+		addError("room.policy.max_simulcast_layers", "must not be negative, use 0 for no limit")
+	}
+	if ms := conf.Room.Policy.SenderReportIntervalMs; ms != 0 && !validSenderReportInterval(time.Duration(ms)*time.Millisecond) {
+		addError("room.policy.sender_report_interval_ms", "must be between %d and %d",
+			MinSenderReportInterval.Milliseconds(), MaxSenderReportInterval.Milliseconds())
+	}
+	if size := conf.Room.Policy.SenderReportBatchSize; size < 0 || size > MaxSenderReportBatchSize {
+		addError("room.policy.sender_report_batch_size", "must be between 1 and %d, use 0 for the default", MaxSenderReportBatchSize)
+	}
+	if err := ValidateHeaderExtensions(conf.Room.Policy.HeaderExtensions); err != nil {
+		addError("room.policy.header_extensions", "%v", err)
+	}
+
+	switch conf.EventBus.Kind {
+	case "":
+	case "nats":
+		if conf.EventBus.NATSURL == "" {
+			addError("event_bus.nats_url", "required for the nats event bus")
+		}
+	case "redis":
+		if !conf.HasRedis() {
+			addError("event_bus.kind", "redis event bus requires redis to be configured")
+		}
+	case "kafka":
+		if len(conf.EventBus.KafkaBrokers) == 0 {
+			addError("event_bus.kafka_brokers", "required for the kafka event bus")
+		}
+	default:
+		addError("event_bus.kind", "unknown event bus %s, use redis, nats or kafka", conf.EventBus.Kind)
+	}
+	switch conf.EventBus.Encoding {
+	case "", "json", "protobuf":
+	default:
+		addError("event_bus.encoding", "unknown encoding %s, use json or protobuf", conf.EventBus.Encoding)
+	}
+
+	switch conf.TrackStats.Sink {
+	case "", "analytics":
+	case "file":
+		if conf.TrackStats.FilePath == "" {
+			addError("track_stats.file_path", "required for the file sink")
+		}
+	case "http":
+		if conf.TrackStats.URL == "" {
+			addError("track_stats.url", "required for the http sink")
+		}
+	default:
+		addError("track_stats.sink", "unknown sink %s, use file, http or analytics", conf.TrackStats.Sink)
+	}
+	if conf.TrackStats.Sink != "" && conf.TrackStats.Interval < time.Second {
+		addError("track_stats.interval", "%v is too short, stats are sampled at most once a second", conf.TrackStats.Interval)
+	}
+
+	switch conf.SubscriptionAudit.Sink {
+	case "":
+	case "analytics":
+		if conf.EventBus.Kind == "" {
+			addError("subscription_audit.sink", "the analytics sink publishes to the event bus, which isn't configured")
+		}
+		if conf.SubscriptionAudit.Topic == "" {
+			addError("subscription_audit.topic", "required for the analytics sink")
+		}
+	case "file":
+		if conf.SubscriptionAudit.FilePath == "" {
+			addError("subscription_audit.file_path", "required for the file sink")
+		}
+	case "http":
+		if conf.SubscriptionAudit.URL == "" {
+			addError("subscription_audit.url", "required for the http sink")
+		}
+	default:
+		addError("subscription_audit.sink", "unknown sink %s, use file, http or analytics", conf.SubscriptionAudit.Sink)
+	}
+	if conf.SubscriptionAudit.Sink != "" && conf.SubscriptionAudit.FlushInterval <= 0 {
+		addError("subscription_audit.flush_interval", "must be positive")
+	}
+
+	if conf.IdentityMasking.Enabled && conf.IdentityMasking.Salt == "" {
+		addError("identity_masking.salt", "required to mask identities, identities hashed without a secret can be guessed")
+	}
+
+	// TURN
+	if conf.TURN.Enabled {
+		if conf.TURN.TLSPort <= 0 && conf.TURN.UDPPort <= 0 {
+			addError("turn", "enabled without tls_port or udp_port")
+		}
+		if conf.TURN.TLSPort > 0 {
+			if conf.TURN.Domain == "" {
+				addError("turn.domain", "required for TURN/TLS, it must match the certificate")
+			}
+			if conf.TURN.CertFile == "" || conf.TURN.KeyFile == "" {
+				addError("turn.cert_file", "cert_file and key_file are required for TURN/TLS")
+			}
+		}
+	}
+
+	// multi-node
+	switch conf.NodeSelector.Kind {
+	case "", "random", "sysload":
+	case "regionaware":
+		if len(conf.NodeSelector.Regions) == 0 {
+			addError("node_selector.regions", "regionaware selector requires a list of regions")
+		}
+		if conf.Region == "" {
+			addError("region", "regionaware selector requires the region of this node")
+		}
+	default:
+		addError("node_selector.kind", "unknown selector %s, use random, sysload or regionaware", conf.NodeSelector.Kind)
+	}
+	if conf.NodeSelector.PingURL != "" {
+		pingURL := conf.NodePingURL("ND_validate", "127.0.0.1", "region")
+		if u, err := url.Parse(pingURL); err != nil || u.Host == "" {
+			addError("node_selector.ping_url", "invalid URL %s", conf.NodeSelector.PingURL)
+		}
+	}
+	if !conf.HasRedis() {
+		if conf.Region != "" || len(conf.NodeSelector.Regions) != 0 {
+			addWarning("redis.address", "regions are configured without redis, nodes won't be aware of each other")
+		}
+		if conf.NodeSelector.ProbeRTT {
+			addWarning("node_selector.probe_rtt", "RTT probes have no effect without redis, there's a single node")
+		}
+	}
+
+	return issues
+}
+
+type namedPort struct {
+	name string
+	port uint32
+}
+
+// portConflicts returns pairs of names of ports that are the same, ignoring unset ports
+func portConflicts(ports []namedPort) [][2]string {
+	var conflicts [][2]string
+	for i, p := range ports {
+		for _, other := range ports[:i] {
+			if p.port != 0 && p.port == other.port {
+				conflicts = append(conflicts, [2]string{other.name, p.name})
+				break
+			}
+		}
+	}
+	return conflicts
+}
+
+func validSenderReportInterval(interval time.Duration) bool {
+	return interval >= MinSenderReportInterval && interval <= MaxSenderReportInterval
+}
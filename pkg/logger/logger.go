@@ -14,8 +14,24 @@ import (
 var (
 	// pion/webrtc, pion/turn
 	defaultFactory logging.LoggerFactory
+
+	// level of the logger set up by InitProduction or InitDevelopment, and the level it started with
+	atomicLevel  zap.AtomicLevel
+	defaultLevel zapcore.Level
+
+	// logger set by SetLogger, before identities are masked
+	baseLogger logr.Logger
+	// replaces participant identities in logged values, nil when they are logged as they are
+	maskIdentity func(string) string
 )
 
+// keys of logged values that hold participant identities
+var identityKeys = map[string]bool{
+	"participant": true,
+	"identity":    true,
+	"publisher":   true,
+}
+
 func LoggerFactory() logging.LoggerFactory {
 	if defaultFactory == nil {
 		defaultFactory = logging.NewDefaultLoggerFactory()
@@ -29,6 +45,15 @@ func SetLoggerFactory(lf logging.LoggerFactory) {
 
 // Note: only pass in logr.Logger with default depth
 func SetLogger(l logr.Logger) {
+	baseLogger = l
+	if maskIdentity != nil && l.GetSink() != nil {
+		sink := l.GetSink()
+		// skip the frame of the masking sink when reporting callers
+		if cd, ok := sink.(logr.CallDepthLogSink); ok {
+			sink = cd.WithCallDepth(1)
+		}
+		l = l.WithSink(&identityMaskingSink{LogSink: sink, mask: maskIdentity})
+	}
 	logger.SetLogger(l, "livekit")
 	sfu.Logger = l.WithName("sfu")
 	buffer.Logger = sfu.Logger
@@ -44,14 +69,90 @@ func InitDevelopment(logLevel string) {
 
 // valid levels: debug, info, warn, error, fatal, panic
 func initLogger(config zap.Config, level string) {
+	defaultLevel = config.Level.Level()
 	if level != "" {
 		lvl := zapcore.Level(0)
 		if err := lvl.UnmarshalText([]byte(level)); err == nil {
 			config.Level = zap.NewAtomicLevelAt(lvl)
 		}
 	}
+	atomicLevel = config.Level
 
 	l, _ := config.Build()
 	zapLogger := zapr.NewLogger(l)
 	SetLogger(zapLogger)
 }
+
+// SetLevel changes the level of the running logger, empty for the level it defaults to
+func SetLevel(level string) error {
+	lvl := defaultLevel
+	if level != "" {
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			return err
+		}
+	}
+	if atomicLevel == (zap.AtomicLevel{}) {
+		// logger wasn't initialized
+		return nil
+	}
+	atomicLevel.SetLevel(lvl)
+	return nil
+}
+
+// MaskIdentities replaces participant identities in values logged from now on with the result of
+// mask, nil to log them as they are
+func MaskIdentities(mask func(string) string) {
+	maskIdentity = mask
+	SetLogger(baseLogger)
+}
+
+type identityMaskingSink struct {
+	logr.LogSink
+	mask func(string) string
+}
+
+func (s *identityMaskingSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.LogSink.Info(level, msg, s.maskValues(keysAndValues)...)
+}
+
+func (s *identityMaskingSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	s.LogSink.Error(err, msg, s.maskValues(keysAndValues)...)
+}
+
+func (s *identityMaskingSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &identityMaskingSink{LogSink: s.LogSink.WithValues(s.maskValues(keysAndValues)...), mask: s.mask}
+}
+
+func (s *identityMaskingSink) WithName(name string) logr.LogSink {
+	return &identityMaskingSink{LogSink: s.LogSink.WithName(name), mask: s.mask}
+}
+
+func (s *identityMaskingSink) WithCallDepth(depth int) logr.LogSink {
+	if cd, ok := s.LogSink.(logr.CallDepthLogSink); ok {
+		return &identityMaskingSink{LogSink: cd.WithCallDepth(depth), mask: s.mask}
+	}
+	return s
+}
+
+func (s *identityMaskingSink) maskValues(keysAndValues []interface{}) []interface{} {
+	var masked []interface{}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok || !identityKeys[key] {
+			continue
+		}
+		identity, ok := keysAndValues[i+1].(string)
+		if !ok {
+			continue
+		}
+		if masked == nil {
+			// don't modify the caller's values
+			masked = append([]interface{}(nil), keysAndValues...)
+		}
+		masked[i+1] = s.mask(identity)
+	}
+	if masked == nil {
+		return keysAndValues
+	}
+	return masked
+}
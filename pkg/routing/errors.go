@@ -12,4 +12,5 @@ var (
 	ErrInvalidRouterMessage = errors.New("invalid router message")
 	ErrChannelClosed        = errors.New("channel closed")
 	ErrChannelFull          = errors.New("channel is full")
+	ErrRelayUnavailable     = errors.New("no relay stream to the node")
 )
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/logger"
 	livekit "github.com/livekit/protocol/proto"
 	"google.golang.org/protobuf/proto"
@@ -36,6 +37,30 @@ type ParticipantInit struct {
 	AutoSubscribe bool
 	Hidden        bool
 	Client        *livekit.ClientInfo
+	// low power mode requested by the client, video it receives is capped or paused
+	LowPowerMode string
+	// kind of participant, empty for sessions started by nodes that don't send it
+	Kind string
+	// sources the participant may publish tracks from, all when empty
+	PublishSources []livekit.TrackSource
+	// the participant waits in the room's waiting room until its join is approved
+	AwaitApproval bool
+	// the participant can approve the joins of others waiting in the room
+	CanApproveJoins bool
+	// identities of the participants the participant may subscribe to, all when empty. Identities
+	// ending with * allow those they're a prefix of
+	CanSubscribeTo []string
+	// sequence number of the last signal response a resuming client received, the ones after it
+	// are sent again. Nil when the client doesn't track them
+	LastSignalSeq *uint32
+	// signal responses are numbered, and kept until the client acks them
+	SequenceSignal bool
+	// optional capabilities the client declared, comma separated
+	Capabilities string
+	// the participant joins without WebRTC transports, for presence and data only
+	SignalOnly bool
+	// display name from the token, it can be changed later unlike the identity
+	Name string
 }
 
 type NewParticipantCallback func(ctx context.Context, roomName string, pi ParticipantInit, requestSource MessageSource, responseSink MessageSink)
@@ -77,9 +102,13 @@ type MessageRouter interface {
 	OnRTCMessage(callback RTCMessageCallback)
 }
 
-func CreateRouter(conf *config.Config, rc *redis.Client, node LocalNode) Router {
+func CreateRouter(conf *config.Config, rc redis.UniversalClient, node LocalNode, keyProvider auth.KeyProvider) Router {
 	if rc != nil {
-		return NewRedisRouter(node, rc)
+		router := NewRedisRouter(node, rc)
+		if conf.NodeRelay.Port != 0 {
+			router.UseNodeRelay(NewNodeRelay(node, rc, conf.NodeRelay, keyProvider))
+		}
+		return router
 	}
 
 	// local routing and store
@@ -12,6 +12,7 @@ import (
 	"github.com/livekit/protocol/utils"
 
 	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing/selector"
 )
 
 type LocalNode *livekit.Node
@@ -24,17 +25,26 @@ func NewLocalNode(conf *config.Config) (LocalNode, error) {
 	if conf.RTC.NodeIP == "" {
 		return nil, ErrIPNotSet
 	}
-	return &livekit.Node{
+	nodeType := livekit.NodeType_SERVER
+	if conf.IsSignalRelay() {
+		// signal relays are controllers, rooms are never hosted on them
+		nodeType = livekit.NodeType_CONTROLLER
+	}
+	node := &livekit.Node{
 		Id:      fmt.Sprintf("%s%s", utils.NodePrefix, HashedID(hostname)[:8]),
 		Ip:      conf.RTC.NodeIP,
 		NumCpus: uint32(runtime.NumCPU()),
 		Region:  conf.Region,
 		State:   livekit.NodeState_SERVING,
+		Type:    nodeType,
 		Stats: &livekit.NodeStats{
 			StartedAt: time.Now().Unix(),
 			UpdatedAt: time.Now().Unix(),
 		},
-	}, nil
+	}
+	// other nodes check this one against its own limits
+	selector.SetNodeLimits(node.Stats, conf.Limit)
+	return node, nil
 }
 
 // Creates a hashed ID from a unique string
@@ -0,0 +1,425 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/logger"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// NodeRelay streams RTC and signal node messages directly to the other nodes over gRPC, instead of
+// publishing them to Redis. There's a stream per pair of nodes, opened by the first one that has a
+// message for the other and used in both directions. Messages are RTCNodeMessage or
+// SignalNodeMessage, sent as Any:
+//
+//	service NodeRelay {
+//	  rpc Relay(stream google.protobuf.Any) returns (stream google.protobuf.Any);
+//	}
+//
+// Nodes advertise the address of their relay in Redis, it listens on a private address. Streams are
+// opened with a token signed by the relay's API key, whose identity is the node opening it. They
+// aren't encrypted
+const (
+	nodeRelayServiceName      = "livekit.NodeRelay"
+	nodeRelayNodeIDKey        = "node_id"
+	nodeRelayAuthorizationKey = "authorization"
+	nodeRelayDialTimeout      = 2 * time.Second
+	// tokens are only checked when a stream is opened
+	nodeRelayTokenTTL = time.Minute
+)
+
+var nodeRelayServiceDesc = grpc.ServiceDesc{
+	ServiceName: nodeRelayServiceName,
+	HandlerType: (*interface{})(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "Relay",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				return srv.(*NodeRelay).serveStream(stream)
+			},
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+}
+
+type relayStream interface {
+	SendMsg(m interface{}) error
+	RecvMsg(m interface{}) error
+}
+
+type relayPeer struct {
+	nodeID string
+	stream relayStream
+	// set for streams this node opened
+	conn *grpc.ClientConn
+
+	// gRPC streams don't allow concurrent writes
+	sendLock sync.Mutex
+}
+
+func (p *relayPeer) send(msg *anypb.Any) error {
+	p.sendLock.Lock()
+	defer p.sendLock.Unlock()
+	return p.stream.SendMsg(msg)
+}
+
+type NodeRelay struct {
+	nodeID        string
+	address       string
+	rc            redis.UniversalClient
+	retryInterval time.Duration
+	apiKey        string
+	keyProvider   auth.KeyProvider
+	// returns the address of the relay of a node, from Redis unless overridden in tests
+	resolve func(nodeID string) (string, error)
+
+	onRTCMessage    func(rm *livekit.RTCNodeMessage)
+	onSignalMessage func(sm *livekit.SignalNodeMessage)
+
+	server *grpc.Server
+	ctx    context.Context
+	cancel func()
+
+	lock  sync.Mutex
+	peers map[string]*relayPeer
+	// nodes that are being connected to, or that couldn't be and aren't tried again until then
+	retryAt map[string]time.Time
+}
+
+// NewNodeRelay returns a relay that listens on the port and address of conf, the IP of the current
+// node when it has no address. Streams are authenticated with the secret of conf's API key
+func NewNodeRelay(currentNode LocalNode, rc redis.UniversalClient, conf config.NodeRelayConfig, keyProvider auth.KeyProvider) *NodeRelay {
+	host := conf.Address
+	if host == "" {
+		host = currentNode.Ip
+	}
+	r := &NodeRelay{
+		nodeID:        currentNode.Id,
+		address:       net.JoinHostPort(host, strconv.Itoa(int(conf.Port))),
+		rc:            rc,
+		retryInterval: conf.RetryInterval,
+		apiKey:        conf.APIKey,
+		keyProvider:   keyProvider,
+		peers:         make(map[string]*relayPeer),
+		retryAt:       make(map[string]time.Time),
+	}
+	r.resolve = r.resolveFromRedis
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	r.server = grpc.NewServer(grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+		MinTime:             5 * time.Second,
+		PermitWithoutStream: true,
+	}))
+	r.server.RegisterService(&nodeRelayServiceDesc, r)
+	return r
+}
+
+// OnRTCMessage is called with the RTC node messages relayed to this node
+func (r *NodeRelay) OnRTCMessage(f func(rm *livekit.RTCNodeMessage)) {
+	r.onRTCMessage = f
+}
+
+// OnSignalMessage is called with the signal node messages relayed to this node
+func (r *NodeRelay) OnSignalMessage(f func(sm *livekit.SignalNodeMessage)) {
+	r.onSignalMessage = f
+}
+
+func (r *NodeRelay) Start() error {
+	// not on all interfaces, the relay is only for the other nodes
+	ln, err := net.Listen("tcp", r.address)
+	if err != nil {
+		return errors.Wrap(err, "could not listen for node relay")
+	}
+	go func() {
+		if err := r.server.Serve(ln); err != nil {
+			logger.Errorw("could not serve node relay", err)
+		}
+	}()
+	if err := r.rc.HSet(r.ctx, NodeRelayKey, r.nodeID, r.address).Err(); err != nil {
+		r.server.Stop()
+		return errors.Wrap(err, "could not advertise node relay")
+	}
+	logger.Infow("relaying to other nodes directly", "address", r.address)
+	return nil
+}
+
+func (r *NodeRelay) Stop() {
+	// could be called after the router's context is canceled
+	_ = r.rc.HDel(context.Background(), NodeRelayKey, r.nodeID).Err()
+	r.cancel()
+	r.server.Stop()
+
+	r.lock.Lock()
+	peers := r.peers
+	r.peers = make(map[string]*relayPeer)
+	r.lock.Unlock()
+	for _, p := range peers {
+		if p.conn != nil {
+			_ = p.conn.Close()
+		}
+	}
+}
+
+// Send writes msg, an RTCNodeMessage or SignalNodeMessage, to the stream to the node. It returns
+// ErrRelayUnavailable when there's no stream, and starts connecting to the node unless it was
+// tried recently
+func (r *NodeRelay) Send(nodeID string, msg proto.Message) error {
+	if nodeID == r.nodeID {
+		// messages to itself go through Redis, like keep-alives
+		return ErrRelayUnavailable
+	}
+	p := r.getPeer(nodeID)
+	if p == nil {
+		return ErrRelayUnavailable
+	}
+	data, err := anypb.New(msg)
+	if err != nil {
+		return err
+	}
+	if err := p.send(data); err != nil {
+		logger.Warnw("could not relay to node", err, "nodeID", nodeID)
+		r.removePeer(p)
+		return ErrRelayUnavailable
+	}
+	return nil
+}
+
+func (r *NodeRelay) getPeer(nodeID string) *relayPeer {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if p := r.peers[nodeID]; p != nil {
+		return p
+	}
+	if r.ctx.Err() != nil || time.Now().Before(r.retryAt[nodeID]) {
+		return nil
+	}
+	// there's a single attempt at a time
+	r.retryAt[nodeID] = time.Now().Add(nodeRelayDialTimeout + r.retryInterval)
+	go r.connect(nodeID)
+	return nil
+}
+
+func (r *NodeRelay) connect(nodeID string) {
+	p, err := r.dial(nodeID)
+	r.lock.Lock()
+	if err != nil {
+		r.retryAt[nodeID] = time.Now().Add(r.retryInterval)
+		r.lock.Unlock()
+		logger.Debugw("could not connect to node relay", "error", err, "nodeID", nodeID)
+		return
+	}
+	delete(r.retryAt, nodeID)
+	if r.peers[nodeID] != nil {
+		// the node opened a stream in the meantime
+		r.lock.Unlock()
+		_ = p.conn.Close()
+		return
+	}
+	r.peers[nodeID] = p
+	r.lock.Unlock()
+
+	go func() {
+		err := r.readStream(p)
+		logger.Debugw("node relay stream closed", "error", err, "nodeID", nodeID)
+		r.removePeer(p)
+	}()
+}
+
+func (r *NodeRelay) dial(nodeID string) (*relayPeer, error) {
+	address, err := r.resolve(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(r.ctx, nodeRelayDialTimeout)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, address,
+		grpc.WithInsecure(),
+		grpc.WithBlock(),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                10 * time.Second,
+			Timeout:             5 * time.Second,
+			PermitWithoutStream: true,
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	token, err := r.token()
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	desc := &nodeRelayServiceDesc.Streams[0]
+	streamCtx := metadata.AppendToOutgoingContext(r.ctx,
+		nodeRelayNodeIDKey, r.nodeID,
+		nodeRelayAuthorizationKey, "Bearer "+token,
+	)
+	stream, err := conn.NewStream(streamCtx, desc, "/"+nodeRelayServiceName+"/"+desc.StreamName)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return &relayPeer{
+		nodeID: nodeID,
+		stream: stream,
+		conn:   conn,
+	}, nil
+}
+
+// token returns a token identifying this node to the one it opens a stream to
+func (r *NodeRelay) token() (string, error) {
+	secret := r.keyProvider.GetSecret(r.apiKey)
+	if secret == "" {
+		return "", fmt.Errorf("node relay API key %s is unknown", r.apiKey)
+	}
+	return auth.NewAccessToken(r.apiKey, secret).
+		SetIdentity(r.nodeID).
+		SetValidFor(nodeRelayTokenTTL).
+		ToJWT()
+}
+
+// authenticate verifies that the stream was opened by nodeID, with a token signed by the relay's
+// API key
+func (r *NodeRelay) authenticate(md metadata.MD, nodeID string) error {
+	values := md.Get(nodeRelayAuthorizationKey)
+	if len(values) == 0 || !strings.HasPrefix(values[0], "Bearer ") {
+		return errors.New("authorization is missing")
+	}
+	v, err := auth.ParseAPIToken(strings.TrimPrefix(values[0], "Bearer "))
+	if err != nil {
+		return errors.New("invalid authorization token")
+	}
+	// other keys could be those of clients
+	if v.APIKey() != r.apiKey {
+		return errors.New("invalid API key")
+	}
+	secret := r.keyProvider.GetSecret(r.apiKey)
+	if secret == "" {
+		return errors.New("invalid API key")
+	}
+	if _, err := v.Verify(secret); err != nil {
+		return errors.New("invalid authorization token")
+	}
+	if v.Identity() != nodeID {
+		return errors.New("token isn't the node's")
+	}
+	return nil
+}
+
+func (r *NodeRelay) resolveFromRedis(nodeID string) (string, error) {
+	address, err := r.rc.HGet(r.ctx, NodeRelayKey, nodeID).Result()
+	if err == redis.Nil {
+		// the node doesn't relay
+		return "", ErrNodeNotFound
+	}
+	return address, err
+}
+
+// serveStream handles a stream opened by another node. It's used to send to that node as well,
+// unless there's a stream to it already
+func (r *NodeRelay) serveStream(stream grpc.ServerStream) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	nodeIDs := md.Get(nodeRelayNodeIDKey)
+	if len(nodeIDs) == 0 {
+		return status.Error(codes.InvalidArgument, "node id is missing")
+	}
+	if err := r.authenticate(md, nodeIDs[0]); err != nil {
+		logger.Warnw("rejected node relay stream", err, "nodeID", nodeIDs[0])
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	p := &relayPeer{
+		nodeID: nodeIDs[0],
+		stream: stream,
+	}
+	r.lock.Lock()
+	if r.peers[p.nodeID] == nil {
+		r.peers[p.nodeID] = p
+		delete(r.retryAt, p.nodeID)
+	}
+	r.lock.Unlock()
+
+	err := r.readStream(p)
+	r.removePeer(p)
+	return err
+}
+
+func (r *NodeRelay) readStream(p *relayPeer) error {
+	for {
+		data := &anypb.Any{}
+		if err := p.stream.RecvMsg(data); err != nil {
+			return err
+		}
+		msg, err := data.UnmarshalNew()
+		if err != nil {
+			logger.Errorw("could not unmarshal relayed message", err, "nodeID", p.nodeID)
+			continue
+		}
+		switch m := msg.(type) {
+		case *livekit.RTCNodeMessage:
+			if r.onRTCMessage != nil {
+				r.onRTCMessage(m)
+			}
+		case *livekit.SignalNodeMessage:
+			if r.onSignalMessage != nil {
+				r.onSignalMessage(m)
+			}
+		default:
+			logger.Errorw("unexpected relayed message", ErrInvalidRouterMessage, "nodeID", p.nodeID, "type", data.TypeUrl)
+		}
+	}
+}
+
+func (r *NodeRelay) removePeer(p *relayPeer) {
+	r.lock.Lock()
+	if r.peers[p.nodeID] == p {
+		delete(r.peers, p.nodeID)
+	}
+	r.lock.Unlock()
+	if p.conn != nil {
+		_ = p.conn.Close()
+	}
+}
+
+// relayRoute sends the messages of a sink through the relay as long as it can, starting with the
+// first one. Switching to the relay later, or back to it, could reorder messages
+type relayRoute struct {
+	relay *NodeRelay
+
+	lock     sync.Mutex
+	disabled bool
+}
+
+// send returns false when msg has to be published to Redis instead
+func (t *relayRoute) send(nodeID string, msg proto.Message) bool {
+	if t.relay == nil {
+		return false
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.disabled {
+		return false
+	}
+	if t.relay.Send(nodeID, msg) != nil {
+		t.disabled = true
+		return false
+	}
+	return true
+}
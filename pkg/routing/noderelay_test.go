@@ -0,0 +1,141 @@
+package routing
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/livekit/protocol/auth"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const testRelaySecret = "0123456789abcdef0123456789abcdef"
+
+func newTestNodeRelay(t *testing.T, nodeID string, addresses map[string]string) *NodeRelay {
+	// nothing listens on the Redis port
+	rc := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"})
+	keyProvider := auth.NewFileBasedKeyProviderFromMap(map[string]string{"relay": testRelaySecret, "client": testRelaySecret})
+	r := NewNodeRelay(&livekit.Node{Id: nodeID, Ip: "127.0.0.1"}, rc, config.NodeRelayConfig{
+		APIKey:        "relay",
+		RetryInterval: time.Minute,
+	}, keyProvider)
+	r.resolve = func(nodeID string) (string, error) {
+		if address, ok := addresses[nodeID]; ok {
+			return address, nil
+		}
+		return "", ErrNodeNotFound
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addresses[nodeID] = ln.Addr().String()
+	go func() {
+		_ = r.server.Serve(ln)
+	}()
+	return r
+}
+
+func TestNodeRelay(t *testing.T) {
+	addresses := make(map[string]string)
+	signalNode := newTestNodeRelay(t, "ND_signal", addresses)
+	defer signalNode.Stop()
+	rtcNode := newTestNodeRelay(t, "ND_rtc", addresses)
+	defer rtcNode.Stop()
+
+	signalMessages := make(chan *livekit.SignalNodeMessage, 1)
+	signalNode.OnSignalMessage(func(sm *livekit.SignalNodeMessage) {
+		signalMessages <- sm
+	})
+	rtcMessages := make(chan *livekit.RTCNodeMessage, 1)
+	rtcNode.OnRTCMessage(func(rm *livekit.RTCNodeMessage) {
+		rtcMessages <- rm
+	})
+
+	// connects in the background
+	rm := &livekit.RTCNodeMessage{ParticipantKey: "room|alice"}
+	require.Equal(t, ErrRelayUnavailable, signalNode.Send("ND_rtc", rm))
+	require.Eventually(t, func() bool {
+		return signalNode.Send("ND_rtc", rm) == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, "room|alice", (<-rtcMessages).ParticipantKey)
+
+	// responses use the same stream, the signal node isn't dialed
+	require.NoError(t, rtcNode.Send("ND_signal", &livekit.SignalNodeMessage{ConnectionId: "CO_alice"}))
+	require.Equal(t, "CO_alice", (<-signalMessages).ConnectionId)
+	rtcNode.lock.Lock()
+	require.Nil(t, rtcNode.peers["ND_signal"].conn)
+	rtcNode.lock.Unlock()
+
+	// nodes without a relay aren't tried again until the retry interval passed
+	require.Equal(t, ErrRelayUnavailable, signalNode.Send("ND_other", rm))
+	require.Eventually(t, func() bool {
+		signalNode.lock.Lock()
+		defer signalNode.lock.Unlock()
+		return signalNode.retryAt["ND_other"].Sub(time.Now()) > 30*time.Second
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, ErrRelayUnavailable, signalNode.Send("ND_other", rm))
+
+	// sinks stay on Redis when the first message couldn't be relayed
+	route := relayRoute{relay: signalNode}
+	require.False(t, route.send("ND_other", rm))
+	require.True(t, route.disabled)
+	route = relayRoute{relay: signalNode}
+	require.True(t, route.send("ND_rtc", rm))
+	<-rtcMessages
+
+	// the stream is dropped when the node goes away
+	rtcNode.Stop()
+	require.Eventually(t, func() bool {
+		return signalNode.Send("ND_rtc", rm) == ErrRelayUnavailable
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestNodeRelayAuthentication(t *testing.T) {
+	addresses := make(map[string]string)
+	rtcNode := newTestNodeRelay(t, "ND_rtc", addresses)
+	defer rtcNode.Stop()
+	rtcMessages := make(chan *livekit.RTCNodeMessage, 1)
+	rtcNode.OnRTCMessage(func(rm *livekit.RTCNodeMessage) {
+		rtcMessages <- rm
+	})
+
+	withToken := func(key, identity string) metadata.MD {
+		token, err := auth.NewAccessToken(key, testRelaySecret).SetIdentity(identity).ToJWT()
+		require.NoError(t, err)
+		return metadata.Pairs(nodeRelayAuthorizationKey, "Bearer "+token)
+	}
+	require.NoError(t, rtcNode.authenticate(withToken("relay", "ND_signal"), "ND_signal"))
+	require.Error(t, rtcNode.authenticate(metadata.MD{}, "ND_signal"))
+	// keys other than the relay's could be those of clients
+	require.Error(t, rtcNode.authenticate(withToken("client", "ND_signal"), "ND_signal"))
+	require.Error(t, rtcNode.authenticate(withToken("relay", "ND_other"), "ND_signal"))
+	forged, err := auth.NewAccessToken("relay", "another secret, that isn't the relay's").SetIdentity("ND_signal").ToJWT()
+	require.NoError(t, err)
+	require.Error(t, rtcNode.authenticate(metadata.Pairs(nodeRelayAuthorizationKey, "Bearer "+forged), "ND_signal"))
+
+	// streams of nodes with another secret are rejected before any message is read
+	intruder := newTestNodeRelay(t, "ND_intruder", addresses)
+	defer intruder.Stop()
+	intruder.keyProvider = auth.NewFileBasedKeyProviderFromMap(map[string]string{"relay": "another secret, that isn't the relay's"})
+	rm := &livekit.RTCNodeMessage{ParticipantKey: "room|alice"}
+	deadline := time.Now().Add(500 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		_ = intruder.Send("ND_rtc", rm)
+		intruder.lock.Lock()
+		delete(intruder.retryAt, "ND_rtc")
+		intruder.lock.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case <-rtcMessages:
+		t.Fatal("message of an unauthenticated stream was relayed")
+	default:
+	}
+	rtcNode.lock.Lock()
+	require.Nil(t, rtcNode.peers["ND_intruder"])
+	rtcNode.lock.Unlock()
+}
@@ -2,6 +2,8 @@ package routing
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 
 	"github.com/go-redis/redis/v8"
 	livekit "github.com/livekit/protocol/proto"
@@ -15,6 +17,9 @@ const (
 
 	// hash of room_name => node_id
 	NodeRoomKey = "room_node_map"
+
+	// hash of node_id => address of the node's relay
+	NodeRelayKey = "node_relay_map"
 )
 
 var redisCtx = context.Background()
@@ -29,15 +34,132 @@ func participantSignalKey(connectionId string) string {
 	return "participant_signal:" + connectionId
 }
 
-func rtcNodeChannel(nodeId string) string {
-	return "rtc_channel:" + nodeId
+// low power mode requested by the participant, StartSession has no field for it
+func participantLowPowerKey(connectionId string) string {
+	return "participant_low_power:" + connectionId
+}
+
+// kind of participant, StartSession has no field for it
+func participantKindKey(connectionId string) string {
+	return "participant_kind:" + connectionId
+}
+
+// sources the participant may publish tracks from, StartSession has no field for it
+func participantPublishSourcesKey(connectionId string) string {
+	return "participant_publish_sources:" + connectionId
+}
+
+// identities the participant may subscribe to, StartSession has no field for them
+func participantSubscribeToKey(connectionId string) string {
+	return "participant_subscribe_to:" + connectionId
+}
+
+// whether the participant waits for approval, or approves the joins of others, StartSession has no
+// field for it
+func participantJoinApprovalKey(connectionId string) string {
+	return "participant_join_approval:" + connectionId
+}
+
+// sequence number of the last signal response a resuming client received, StartSession has no field
+// for it
+func participantSignalSeqKey(connectionId string) string {
+	return "participant_signal_seq:" + connectionId
+}
+
+// set when signal responses sent to the participant are numbered, StartSession has no field for it
+func participantSequenceSignalKey(connectionId string) string {
+	return "participant_sequence_signal:" + connectionId
+}
+
+// optional capabilities the client declared, StartSession has no field for them
+func participantCapabilitiesKey(connectionId string) string {
+	return "participant_capabilities:" + connectionId
+}
+
+// display name of the participant, StartSession has no field for it
+func participantNameKey(connectionId string) string {
+	return "participant_name:" + connectionId
+}
+
+// set when the participant joins without WebRTC transports, StartSession has no field for it
+func participantSignalOnlyKey(connectionId string) string {
+	return "participant_signal_only:" + connectionId
+}
+
+// values of participantJoinApprovalKey
+const (
+	joinApprovalAwait    = "await"
+	joinApprovalApprover = "approver"
+)
+
+// participants that approve joins don't wait for approval themselves
+func encodeJoinApproval(pi ParticipantInit) string {
+	switch {
+	case pi.CanApproveJoins:
+		return joinApprovalApprover
+	case pi.AwaitApproval:
+		return joinApprovalAwait
+	default:
+		return ""
+	}
+}
+
+// publish sources are stored by name, comma separated
+func encodePublishSources(sources []livekit.TrackSource) string {
+	names := make([]string, 0, len(sources))
+	for _, source := range sources {
+		names = append(names, source.String())
+	}
+	return strings.Join(names, ",")
+}
+
+// sources this node doesn't know are left out
+func decodePublishSources(value string) []livekit.TrackSource {
+	var sources []livekit.TrackSource
+	for _, name := range strings.Split(value, ",") {
+		if source, ok := livekit.TrackSource_value[name]; ok {
+			sources = append(sources, livekit.TrackSource(source))
+		}
+	}
+	return sources
+}
+
+// identities are stored JSON encoded, they can contain any character
+func encodeIdentities(identities []string) (string, error) {
+	b, err := json.Marshal(identities)
+	return string(b), err
+}
+
+func decodeIdentities(value string) ([]string, error) {
+	var identities []string
+	err := json.Unmarshal([]byte(value), &identities)
+	return identities, err
+}
+
+// IsRedisCluster returns true when rc is a Redis Cluster client. Keys that are written in the same
+// transaction, and channels subscribed to together, are hash tagged there to be in the same slot
+func IsRedisCluster(rc redis.UniversalClient) bool {
+	_, ok := rc.(*redis.ClusterClient)
+	return ok
+}
+
+// the channels of a node are in the slot of its id on Redis Cluster
+func nodeHashTag(rc redis.UniversalClient, nodeId string) string {
+	if IsRedisCluster(rc) {
+		return "{" + nodeId + "}"
+	}
+	return nodeId
+}
+
+func rtcNodeChannel(rc redis.UniversalClient, nodeId string) string {
+	return "rtc_channel:" + nodeHashTag(rc, nodeId)
 }
 
-func signalNodeChannel(nodeId string) string {
-	return "signal_channel:" + nodeId
+func signalNodeChannel(rc redis.UniversalClient, nodeId string) string {
+	return "signal_channel:" + nodeHashTag(rc, nodeId)
 }
 
-func publishRTCMessage(rc *redis.Client, nodeId string, participantKey string, msg proto.Message) error {
+func rtcNodeMessage(participantKey string, msg proto.Message) (*livekit.RTCNodeMessage, error) {
 	rm := &livekit.RTCNodeMessage{
 		ParticipantKey: participantKey,
 	}
@@ -54,19 +176,23 @@ func publishRTCMessage(rc *redis.Client, nodeId string, participantKey string, m
 		rm = o
 		rm.ParticipantKey = participantKey
 	default:
-		return ErrInvalidRouterMessage
+		return nil, ErrInvalidRouterMessage
 	}
+	return rm, nil
+}
+
+func publishRTCMessage(rc redis.UniversalClient, nodeId string, rm *livekit.RTCNodeMessage) error {
 	data, err := proto.Marshal(rm)
 	if err != nil {
 		return err
 	}
 
-	//logger.Debugw("publishing to rtc", "rtcChannel", rtcNodeChannel(nodeId),
+	//logger.Debugw("publishing to rtc", "rtcChannel", rtcNodeChannel(rc, nodeId),
 	//	"message", rm.Message)
-	return rc.Publish(redisCtx, rtcNodeChannel(nodeId), data).Err()
+	return rc.Publish(redisCtx, rtcNodeChannel(rc, nodeId), data).Err()
 }
 
-func publishSignalMessage(rc *redis.Client, nodeId string, connectionId string, msg proto.Message) error {
+func signalNodeMessage(connectionId string, msg proto.Message) (*livekit.SignalNodeMessage, error) {
 	rm := &livekit.SignalNodeMessage{
 		ConnectionId: connectionId,
 	}
@@ -80,29 +206,37 @@ func publishSignalMessage(rc *redis.Client, nodeId string, connectionId string,
 			EndSession: o,
 		}
 	default:
-		return ErrInvalidRouterMessage
+		return nil, ErrInvalidRouterMessage
 	}
+	return rm, nil
+}
+
+func publishSignalMessage(rc redis.UniversalClient, nodeId string, rm *livekit.SignalNodeMessage) error {
 	data, err := proto.Marshal(rm)
 	if err != nil {
 		return err
 	}
 
-	//logger.Debugw("publishing to signal", "signalChannel", signalNodeChannel(nodeId),
+	//logger.Debugw("publishing to signal", "signalChannel", signalNodeChannel(rc, nodeId),
 	//	"message", rm.Message)
-	return rc.Publish(redisCtx, signalNodeChannel(nodeId), data).Err()
+	return rc.Publish(redisCtx, signalNodeChannel(rc, nodeId), data).Err()
 }
 
+// RTCNodeSink writes messages to an RTC node, through the node relay when the first message could
+// be relayed, otherwise through Redis
 type RTCNodeSink struct {
-	rc             *redis.Client
+	rc             redis.UniversalClient
+	route          relayRoute
 	nodeId         string
 	participantKey string
 	isClosed       utils.AtomicFlag
 	onClose        func()
 }
 
-func NewRTCNodeSink(rc *redis.Client, nodeId, participantKey string) *RTCNodeSink {
+func NewRTCNodeSink(rc redis.UniversalClient, relay *NodeRelay, nodeId, participantKey string) *RTCNodeSink {
 	return &RTCNodeSink{
 		rc:             rc,
+		route:          relayRoute{relay: relay},
 		nodeId:         nodeId,
 		participantKey: participantKey,
 	}
@@ -112,7 +246,14 @@ func (s *RTCNodeSink) WriteMessage(msg proto.Message) error {
 	if s.isClosed.Get() {
 		return ErrChannelClosed
 	}
-	return publishRTCMessage(s.rc, s.nodeId, s.participantKey, msg)
+	rm, err := rtcNodeMessage(s.participantKey, msg)
+	if err != nil {
+		return err
+	}
+	if s.route.send(s.nodeId, rm) {
+		return nil
+	}
+	return publishRTCMessage(s.rc, s.nodeId, rm)
 }
 
 func (s *RTCNodeSink) Close() {
@@ -128,17 +269,21 @@ func (s *RTCNodeSink) OnClose(f func()) {
 	s.onClose = f
 }
 
+// SignalNodeSink writes messages to a signal node, through the node relay when the first message
+// could be relayed, otherwise through Redis
 type SignalNodeSink struct {
-	rc           *redis.Client
+	rc           redis.UniversalClient
+	route        relayRoute
 	nodeId       string
 	connectionId string
 	isClosed     utils.AtomicFlag
 	onClose      func()
 }
 
-func NewSignalNodeSink(rc *redis.Client, nodeId, connectionId string) *SignalNodeSink {
+func NewSignalNodeSink(rc redis.UniversalClient, relay *NodeRelay, nodeId, connectionId string) *SignalNodeSink {
 	return &SignalNodeSink{
 		rc:           rc,
+		route:        relayRoute{relay: relay},
 		nodeId:       nodeId,
 		connectionId: connectionId,
 	}
@@ -148,14 +293,25 @@ func (s *SignalNodeSink) WriteMessage(msg proto.Message) error {
 	if s.isClosed.Get() {
 		return ErrChannelClosed
 	}
-	return publishSignalMessage(s.rc, s.nodeId, s.connectionId, msg)
+	return s.write(msg)
+}
+
+func (s *SignalNodeSink) write(msg proto.Message) error {
+	rm, err := signalNodeMessage(s.connectionId, msg)
+	if err != nil {
+		return err
+	}
+	if s.route.send(s.nodeId, rm) {
+		return nil
+	}
+	return publishSignalMessage(s.rc, s.nodeId, rm)
 }
 
 func (s *SignalNodeSink) Close() {
 	if !s.isClosed.TrySet(true) {
 		return
 	}
-	publishSignalMessage(s.rc, s.nodeId, s.connectionId, &livekit.EndSession{})
+	_ = s.write(&livekit.EndSession{})
 	if s.onClose != nil {
 		s.onClose()
 	}
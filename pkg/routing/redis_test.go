@@ -0,0 +1,45 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeChannels(t *testing.T) {
+	rc := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"})
+	require.Equal(t, "rtc_channel:ND_a", rtcNodeChannel(rc, "ND_a"))
+	require.Equal(t, "signal_channel:ND_a", signalNodeChannel(rc, "ND_a"))
+
+	// in the slot of the node on Redis Cluster
+	cluster := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{"127.0.0.1:1"}})
+	require.True(t, IsRedisCluster(cluster))
+	require.Equal(t, "rtc_channel:{ND_a}", rtcNodeChannel(cluster, "ND_a"))
+	require.Equal(t, "signal_channel:{ND_a}", signalNodeChannel(cluster, "ND_a"))
+}
+
+func TestPublishSourcesEncoding(t *testing.T) {
+	sources := []livekit.TrackSource{livekit.TrackSource_CAMERA, livekit.TrackSource_MICROPHONE}
+	require.Equal(t, "CAMERA,MICROPHONE", encodePublishSources(sources))
+	require.Equal(t, sources, decodePublishSources("CAMERA,MICROPHONE"))
+	// sources added in later versions are left out
+	require.Equal(t, sources[1:], decodePublishSources("HOLOGRAM,MICROPHONE"))
+}
+
+func TestIdentitiesEncoding(t *testing.T) {
+	identities := []string{"host", "guest,1", "bots-*"}
+	value, err := encodeIdentities(identities)
+	require.NoError(t, err)
+	decoded, err := decodeIdentities(value)
+	require.NoError(t, err)
+	require.Equal(t, identities, decoded)
+}
+
+func TestJoinApprovalEncoding(t *testing.T) {
+	require.Equal(t, "", encodeJoinApproval(ParticipantInit{}))
+	require.Equal(t, joinApprovalAwait, encodeJoinApproval(ParticipantInit{AwaitApproval: true}))
+	require.Equal(t, joinApprovalApprover, encodeJoinApproval(ParticipantInit{CanApproveJoins: true}))
+	require.Equal(t, joinApprovalApprover, encodeJoinApproval(ParticipantInit{AwaitApproval: true, CanApproveJoins: true}))
+}
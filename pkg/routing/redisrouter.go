@@ -27,15 +27,18 @@ const (
 type RedisRouter struct {
 	LocalRouter
 
-	rc        *redis.Client
+	rc        redis.UniversalClient
 	ctx       context.Context
 	isStarted utils.AtomicFlag
 
 	pubsub *redis.PubSub
 	cancel func()
+
+	// set when messages to other nodes are relayed directly, falling back to Redis
+	relay *NodeRelay
 }
 
-func NewRedisRouter(currentNode LocalNode, rc *redis.Client) *RedisRouter {
+func NewRedisRouter(currentNode LocalNode, rc redis.UniversalClient) *RedisRouter {
 	rr := &RedisRouter{
 		LocalRouter: *NewLocalRouter(currentNode),
 		rc:          rc,
@@ -44,6 +47,14 @@ func NewRedisRouter(currentNode LocalNode, rc *redis.Client) *RedisRouter {
 	return rr
 }
 
+// UseNodeRelay relays messages to other nodes through relay once it's started with the router.
+// Nodes without one are still reached through Redis
+func (r *RedisRouter) UseNodeRelay(relay *NodeRelay) {
+	relay.OnRTCMessage(r.receiveRTCMessage)
+	relay.OnSignalMessage(r.receiveSignalMessage)
+	r.relay = relay
+}
+
 func (r *RedisRouter) RegisterNode() error {
 	data, err := proto.Marshal((*livekit.Node)(r.currentNode))
 	if err != nil {
@@ -70,6 +81,9 @@ func (r *RedisRouter) RemoveDeadNodes() error {
 			if err := r.rc.HDel(context.Background(), NodesKey, n.Id).Err(); err != nil {
 				return err
 			}
+			if err := r.rc.HDel(context.Background(), NodeRelayKey, n.Id).Err(); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -143,8 +157,72 @@ func (r *RedisRouter) StartParticipantSignal(ctx context.Context, roomName strin
 	if err = r.setParticipantSignalNode(connectionId, r.currentNode.Id); err != nil {
 		return
 	}
+	if pi.LowPowerMode != "" {
+		if err = r.rc.Set(r.ctx, participantLowPowerKey(connectionId), pi.LowPowerMode, participantMappingTTL).Err(); err != nil {
+			err = errors.Wrap(err, "could not set low power mode")
+			return
+		}
+	}
+	if pi.Kind != "" {
+		if err = r.rc.Set(r.ctx, participantKindKey(connectionId), pi.Kind, participantMappingTTL).Err(); err != nil {
+			err = errors.Wrap(err, "could not set participant kind")
+			return
+		}
+	}
+	if len(pi.PublishSources) != 0 {
+		if err = r.rc.Set(r.ctx, participantPublishSourcesKey(connectionId), encodePublishSources(pi.PublishSources), participantMappingTTL).Err(); err != nil {
+			err = errors.Wrap(err, "could not set publish sources")
+			return
+		}
+	}
+	if len(pi.CanSubscribeTo) != 0 {
+		var identities string
+		if identities, err = encodeIdentities(pi.CanSubscribeTo); err != nil {
+			return
+		}
+		if err = r.rc.Set(r.ctx, participantSubscribeToKey(connectionId), identities, participantMappingTTL).Err(); err != nil {
+			err = errors.Wrap(err, "could not set subscribe permissions")
+			return
+		}
+	}
+	if joinApproval := encodeJoinApproval(pi); joinApproval != "" {
+		if err = r.rc.Set(r.ctx, participantJoinApprovalKey(connectionId), joinApproval, participantMappingTTL).Err(); err != nil {
+			err = errors.Wrap(err, "could not set join approval")
+			return
+		}
+	}
+	if pi.SequenceSignal {
+		if err = r.rc.Set(r.ctx, participantSequenceSignalKey(connectionId), true, participantMappingTTL).Err(); err != nil {
+			err = errors.Wrap(err, "could not set signal sequencing")
+			return
+		}
+	}
+	if pi.Capabilities != "" {
+		if err = r.rc.Set(r.ctx, participantCapabilitiesKey(connectionId), pi.Capabilities, participantMappingTTL).Err(); err != nil {
+			err = errors.Wrap(err, "could not set capabilities")
+			return
+		}
+	}
+	if pi.Name != "" {
+		if err = r.rc.Set(r.ctx, participantNameKey(connectionId), pi.Name, participantMappingTTL).Err(); err != nil {
+			err = errors.Wrap(err, "could not set participant name")
+			return
+		}
+	}
+	if pi.SignalOnly {
+		if err = r.rc.Set(r.ctx, participantSignalOnlyKey(connectionId), true, participantMappingTTL).Err(); err != nil {
+			err = errors.Wrap(err, "could not set signal only")
+			return
+		}
+	}
+	if pi.LastSignalSeq != nil {
+		if err = r.rc.Set(r.ctx, participantSignalSeqKey(connectionId), *pi.LastSignalSeq, participantMappingTTL).Err(); err != nil {
+			err = errors.Wrap(err, "could not set signal sequence")
+			return
+		}
+	}
 
-	sink := NewRTCNodeSink(r.rc, rtcNode.Id, pKey)
+	sink := NewRTCNodeSink(r.rc, r.relay, rtcNode.Id, pKey)
 
 	// sends a message to start session
 	err = sink.WriteMessage(&livekit.StartSession{
@@ -175,7 +253,7 @@ func (r *RedisRouter) WriteParticipantRTC(ctx context.Context, roomName, identit
 		return err
 	}
 
-	rtcSink := NewRTCNodeSink(r.rc, rtcNode, pkey)
+	rtcSink := NewRTCNodeSink(r.rc, r.relay, rtcNode, pkey)
 	msg.ParticipantKey = participantKey(roomName, identity)
 	return r.writeRTCMessage(rtcSink, msg)
 }
@@ -190,7 +268,7 @@ func (r *RedisRouter) WriteRoomRTC(ctx context.Context, roomName, identity strin
 }
 
 func (r *RedisRouter) WriteNodeRTC(ctx context.Context, rtcNodeID string, msg *livekit.RTCNodeMessage) error {
-	rtcSink := NewRTCNodeSink(r.rc, rtcNodeID, msg.ParticipantKey)
+	rtcSink := NewRTCNodeSink(r.rc, r.relay, rtcNodeID, msg.ParticipantKey)
 	return r.writeRTCMessage(rtcSink, msg)
 }
 
@@ -245,9 +323,42 @@ func (r *RedisRouter) startParticipantRTC(ss *livekit.StartSession, participantK
 		AutoSubscribe: ss.AutoSubscribe,
 		Hidden:        ss.Hidden,
 	}
+	if pi.LowPowerMode, err = r.getParticipantLowPowerMode(ss.ConnectionId); err != nil {
+		return err
+	}
+	if pi.Kind, err = r.getParticipantKind(ss.ConnectionId); err != nil {
+		return err
+	}
+	if pi.PublishSources, err = r.getParticipantPublishSources(ss.ConnectionId); err != nil {
+		return err
+	}
+	if pi.CanSubscribeTo, err = r.getParticipantSubscribeTo(ss.ConnectionId); err != nil {
+		return err
+	}
+	joinApproval, err := r.getParticipantJoinApproval(ss.ConnectionId)
+	if err != nil {
+		return err
+	}
+	pi.AwaitApproval = joinApproval == joinApprovalAwait
+	pi.CanApproveJoins = joinApproval == joinApprovalApprover
+	if pi.LastSignalSeq, err = r.getParticipantSignalSeq(ss.ConnectionId); err != nil {
+		return err
+	}
+	if pi.SequenceSignal, err = r.getParticipantSequenceSignal(ss.ConnectionId); err != nil {
+		return err
+	}
+	if pi.Capabilities, err = r.getParticipantCapabilities(ss.ConnectionId); err != nil {
+		return err
+	}
+	if pi.SignalOnly, err = r.getParticipantSignalOnly(ss.ConnectionId); err != nil {
+		return err
+	}
+	if pi.Name, err = r.getParticipantName(ss.ConnectionId); err != nil {
+		return err
+	}
 
 	reqChan := r.getOrCreateMessageChannel(r.requestChannels, participantKey)
-	resSink := NewSignalNodeSink(r.rc, signalNode, ss.ConnectionId)
+	resSink := NewSignalNodeSink(r.rc, r.relay, signalNode, ss.ConnectionId)
 	r.onNewParticipant(
 		r.ctx,
 		ss.RoomName,
@@ -270,10 +381,14 @@ func (r *RedisRouter) Start() error {
 	// wait until worker is running
 	select {
 	case <-workerStarted:
-		return nil
 	case <-time.After(3 * time.Second):
 		return errors.New("Unable to start redis router")
 	}
+
+	if r.relay != nil {
+		return r.relay.Start()
+	}
+	return nil
 }
 
 func (r *RedisRouter) Drain() {
@@ -286,6 +401,9 @@ func (r *RedisRouter) Stop() {
 		return
 	}
 	logger.Debugw("stopping RedisRouter")
+	if r.relay != nil {
+		r.relay.Stop()
+	}
 	_ = r.pubsub.Close()
 	_ = r.UnregisterNode()
 	r.cancel()
@@ -322,6 +440,93 @@ func (r *RedisRouter) getParticipantSignalNode(connectionId string) (nodeId stri
 	return val, err
 }
 
+func (r *RedisRouter) getParticipantLowPowerMode(connectionId string) (string, error) {
+	val, err := r.rc.Get(r.ctx, participantLowPowerKey(connectionId)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return val, err
+}
+
+func (r *RedisRouter) getParticipantKind(connectionId string) (string, error) {
+	val, err := r.rc.Get(r.ctx, participantKindKey(connectionId)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return val, err
+}
+
+func (r *RedisRouter) getParticipantSequenceSignal(connectionId string) (bool, error) {
+	val, err := r.rc.Get(r.ctx, participantSequenceSignalKey(connectionId)).Bool()
+	if err == redis.Nil {
+		return false, nil
+	}
+	return val, err
+}
+
+func (r *RedisRouter) getParticipantCapabilities(connectionId string) (string, error) {
+	val, err := r.rc.Get(r.ctx, participantCapabilitiesKey(connectionId)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return val, err
+}
+
+func (r *RedisRouter) getParticipantName(connectionId string) (string, error) {
+	val, err := r.rc.Get(r.ctx, participantNameKey(connectionId)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return val, err
+}
+
+func (r *RedisRouter) getParticipantSignalOnly(connectionId string) (bool, error) {
+	val, err := r.rc.Get(r.ctx, participantSignalOnlyKey(connectionId)).Bool()
+	if err == redis.Nil {
+		return false, nil
+	}
+	return val, err
+}
+
+func (r *RedisRouter) getParticipantSignalSeq(connectionId string) (*uint32, error) {
+	val, err := r.rc.Get(r.ctx, participantSignalSeqKey(connectionId)).Uint64()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	seq := uint32(val)
+	return &seq, nil
+}
+
+func (r *RedisRouter) getParticipantPublishSources(connectionId string) ([]livekit.TrackSource, error) {
+	val, err := r.rc.Get(r.ctx, participantPublishSourcesKey(connectionId)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return decodePublishSources(val), nil
+}
+
+func (r *RedisRouter) getParticipantSubscribeTo(connectionId string) ([]string, error) {
+	val, err := r.rc.Get(r.ctx, participantSubscribeToKey(connectionId)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return decodeIdentities(val)
+}
+
+func (r *RedisRouter) getParticipantJoinApproval(connectionId string) (string, error) {
+	val, err := r.rc.Get(r.ctx, participantJoinApprovalKey(connectionId)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return val, err
+}
+
 // update node stats and cleanup
 func (r *RedisRouter) statsWorker() {
 	for r.ctx.Err() == nil {
@@ -345,8 +550,8 @@ func (r *RedisRouter) redisWorker(startedChan chan struct{}) {
 	}()
 	logger.Debugw("starting redisWorker", "nodeID", r.currentNode.Id)
 
-	sigChannel := signalNodeChannel(r.currentNode.Id)
-	rtcChannel := rtcNodeChannel(r.currentNode.Id)
+	sigChannel := signalNodeChannel(r.rc, r.currentNode.Id)
+	rtcChannel := rtcNodeChannel(r.rc, r.currentNode.Id)
 	r.pubsub = r.rc.Subscribe(r.ctx, sigChannel, rtcChannel)
 
 	close(startedChan)
@@ -362,12 +567,7 @@ func (r *RedisRouter) redisWorker(startedChan chan struct{}) {
 				prometheus.MessageCounter.WithLabelValues("signal", "failure").Add(1)
 				continue
 			}
-			if err := r.handleSignalMessage(&sm); err != nil {
-				logger.Errorw("error processing signal message", err)
-				prometheus.MessageCounter.WithLabelValues("signal", "failure").Add(1)
-				continue
-			}
-			prometheus.MessageCounter.WithLabelValues("signal", "success").Add(1)
+			r.receiveSignalMessage(&sm)
 		} else if msg.Channel == rtcChannel {
 			rm := livekit.RTCNodeMessage{}
 			if err := proto.Unmarshal([]byte(msg.Payload), &rm); err != nil {
@@ -375,16 +575,31 @@ func (r *RedisRouter) redisWorker(startedChan chan struct{}) {
 				prometheus.MessageCounter.WithLabelValues("rtc", "failure").Add(1)
 				continue
 			}
-			if err := r.handleRTCMessage(&rm); err != nil {
-				logger.Errorw("error processing RTC message", err)
-				prometheus.MessageCounter.WithLabelValues("rtc", "failure").Add(1)
-				continue
-			}
-			prometheus.MessageCounter.WithLabelValues("rtc", "success").Add(1)
+			r.receiveRTCMessage(&rm)
 		}
 	}
 }
 
+// receiveSignalMessage handles a message from Redis or the relay
+func (r *RedisRouter) receiveSignalMessage(sm *livekit.SignalNodeMessage) {
+	if err := r.handleSignalMessage(sm); err != nil {
+		logger.Errorw("error processing signal message", err)
+		prometheus.MessageCounter.WithLabelValues("signal", "failure").Add(1)
+		return
+	}
+	prometheus.MessageCounter.WithLabelValues("signal", "success").Add(1)
+}
+
+// receiveRTCMessage handles a message from Redis or the relay
+func (r *RedisRouter) receiveRTCMessage(rm *livekit.RTCNodeMessage) {
+	if err := r.handleRTCMessage(rm); err != nil {
+		logger.Errorw("error processing RTC message", err)
+		prometheus.MessageCounter.WithLabelValues("rtc", "failure").Add(1)
+		return
+	}
+	prometheus.MessageCounter.WithLabelValues("rtc", "success").Add(1)
+}
+
 func (r *RedisRouter) handleSignalMessage(sm *livekit.SignalNodeMessage) error {
 	connectionId := sm.ConnectionId
 
@@ -444,7 +659,7 @@ func (r *RedisRouter) handleRTCMessage(rm *livekit.RTCNodeMessage) error {
 		if err := prometheus.UpdateCurrentNodeStats(r.currentNode.Stats); err != nil {
 			logger.Errorw("could not update node stats", err)
 		}
-		// TODO: check stats against config.Limit values
+		selector.UpdateUtilization(r.currentNode.Stats)
 		if err := r.RegisterNode(); err != nil {
 			logger.Errorw("could not update node", err)
 		}
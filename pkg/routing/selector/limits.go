@@ -0,0 +1,240 @@
+package selector
+
+import (
+	"math"
+
+	livekit "github.com/livekit/protocol/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// limits a node can be over
+const (
+	LimitRooms            = "rooms"
+	LimitParticipants     = "participants"
+	LimitTracks           = "tracks"
+	LimitBandwidth        = "bandwidth"
+	LimitForwardedBitrate = "forwarded_bitrate"
+)
+
+const (
+	// nodeLimitsField is the field node stats carry the limits of the node in, so that other nodes check
+	// it against its own limits. The protocol doesn't define it
+	nodeLimitsField protowire.Number = 1000
+	// nodeUtilizationField carries the share of its limits the node uses, as a float
+	nodeUtilizationField protowire.Number = 1001
+)
+
+// fields of the limits message
+const (
+	limitNumTracksField        protowire.Number = 1
+	limitBytesPerSecField      protowire.Number = 2
+	limitNumRoomsField         protowire.Number = 3
+	limitNumParticipantsField  protowire.Number = 4
+	limitForwardedBitrateField protowire.Number = 5
+)
+
+// ExceededLimits returns the limits the node has reached, none when it can take more load. The node's own
+// limits are used when it publishes them, limitConfig otherwise
+func ExceededLimits(limitConfig config.LimitConfig, nodeStats *livekit.NodeStats) []string {
+	if nodeStats == nil {
+		return nil
+	}
+	if limits, ok := GetNodeLimits(nodeStats); ok {
+		limitConfig = limits
+	}
+
+	var exceeded []string
+	if limitConfig.NumRooms > 0 && limitConfig.NumRooms <= nodeStats.NumRooms {
+		exceeded = append(exceeded, LimitRooms)
+	}
+	if limitConfig.NumParticipants > 0 && limitConfig.NumParticipants <= nodeStats.NumClients {
+		exceeded = append(exceeded, LimitParticipants)
+	}
+	if limitConfig.NumTracks > 0 && limitConfig.NumTracks <= nodeStats.NumTracksIn+nodeStats.NumTracksOut {
+		exceeded = append(exceeded, LimitTracks)
+	}
+	if limitConfig.BytesPerSec > 0 && limitConfig.BytesPerSec <= nodeStats.BytesInPerSec+nodeStats.BytesOutPerSec {
+		exceeded = append(exceeded, LimitBandwidth)
+	}
+	if limitConfig.ForwardedBitrate > 0 && float64(limitConfig.ForwardedBitrate) <= float64(nodeStats.BytesOutPerSec)*8 {
+		exceeded = append(exceeded, LimitForwardedBitrate)
+	}
+	return exceeded
+}
+
+// LimitsReached returns true when the node is over one of its limits
+func LimitsReached(limitConfig config.LimitConfig, nodeStats *livekit.NodeStats) bool {
+	return len(ExceededLimits(limitConfig, nodeStats)) != 0
+}
+
+// FilterWithinLimits returns the nodes that are under their limits
+func FilterWithinLimits(limitConfig config.LimitConfig, nodes []*livekit.Node) []*livekit.Node {
+	filtered := make([]*livekit.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if !LimitsReached(limitConfig, node.Stats) {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered
+}
+
+// Utilization returns the share of its limits the node uses, that of the limit it's closest to. It's 1 or
+// more once the node is over a limit, 0 when it has none
+func Utilization(limitConfig config.LimitConfig, nodeStats *livekit.NodeStats) float32 {
+	var utilization float64
+	use := func(used, limit float64) {
+		if limit > 0 {
+			utilization = math.Max(utilization, used/limit)
+		}
+	}
+	use(float64(nodeStats.NumRooms), float64(limitConfig.NumRooms))
+	use(float64(nodeStats.NumClients), float64(limitConfig.NumParticipants))
+	use(float64(nodeStats.NumTracksIn+nodeStats.NumTracksOut), float64(limitConfig.NumTracks))
+	use(float64(nodeStats.BytesInPerSec+nodeStats.BytesOutPerSec), float64(limitConfig.BytesPerSec))
+	use(float64(nodeStats.BytesOutPerSec)*8, float64(limitConfig.ForwardedBitrate))
+	return float32(utilization)
+}
+
+// SetNodeLimits publishes the limits of the node in its stats
+func SetNodeLimits(nodeStats *livekit.NodeStats, limitConfig config.LimitConfig) {
+	var b []byte
+	b = appendVarintField(b, limitNumTracksField, int64(limitConfig.NumTracks))
+	b = protowire.AppendTag(b, limitBytesPerSecField, protowire.Fixed32Type)
+	b = protowire.AppendFixed32(b, math.Float32bits(limitConfig.BytesPerSec))
+	b = appendVarintField(b, limitNumRoomsField, int64(limitConfig.NumRooms))
+	b = appendVarintField(b, limitNumParticipantsField, int64(limitConfig.NumParticipants))
+	b = appendVarintField(b, limitForwardedBitrateField, limitConfig.ForwardedBitrate)
+
+	field := protowire.AppendTag(nil, nodeLimitsField, protowire.BytesType)
+	field = protowire.AppendBytes(field, b)
+	setUnknownField(nodeStats.ProtoReflect(), nodeLimitsField, field)
+}
+
+// GetNodeLimits returns the limits the node published, false for nodes that don't
+func GetNodeLimits(nodeStats *livekit.NodeStats) (config.LimitConfig, bool) {
+	b, ok := unknownField(nodeStats.ProtoReflect().GetUnknown(), nodeLimitsField, protowire.BytesType)
+	if !ok {
+		return config.LimitConfig{}, false
+	}
+	b, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return config.LimitConfig{}, false
+	}
+
+	limits := config.LimitConfig{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return config.LimitConfig{}, false
+		}
+		b = b[n:]
+		switch {
+		case num == limitBytesPerSecField && typ == protowire.Fixed32Type:
+			v, n := protowire.ConsumeFixed32(b)
+			if n < 0 {
+				return config.LimitConfig{}, false
+			}
+			limits.BytesPerSec = math.Float32frombits(v)
+			b = b[n:]
+		case typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return config.LimitConfig{}, false
+			}
+			switch num {
+			case limitNumTracksField:
+				limits.NumTracks = int32(v)
+			case limitNumRoomsField:
+				limits.NumRooms = int32(v)
+			case limitNumParticipantsField:
+				limits.NumParticipants = int32(v)
+			case limitForwardedBitrateField:
+				limits.ForwardedBitrate = int64(v)
+			}
+			b = b[n:]
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return config.LimitConfig{}, false
+			}
+			b = b[n:]
+		}
+	}
+	return limits, true
+}
+
+// UpdateUtilization records in the stats of the node the share of its published limits it uses
+func UpdateUtilization(nodeStats *livekit.NodeStats) {
+	limits, ok := GetNodeLimits(nodeStats)
+	if !ok {
+		return
+	}
+	field := protowire.AppendTag(nil, nodeUtilizationField, protowire.Fixed32Type)
+	field = protowire.AppendFixed32(field, math.Float32bits(Utilization(limits, nodeStats)))
+	setUnknownField(nodeStats.ProtoReflect(), nodeUtilizationField, field)
+}
+
+// GetUtilization returns the share of its limits the node used at its last stats update, false when
+// it doesn't publish it
+func GetUtilization(nodeStats *livekit.NodeStats) (float32, bool) {
+	b, ok := unknownField(nodeStats.ProtoReflect().GetUnknown(), nodeUtilizationField, protowire.Fixed32Type)
+	if !ok {
+		return 0, false
+	}
+	v, n := protowire.ConsumeFixed32(b)
+	if n < 0 {
+		return 0, false
+	}
+	return math.Float32frombits(v), true
+}
+
+func appendVarintField(b []byte, num protowire.Number, v int64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+// unknownField returns the encoded value of the unknown field num of the given type, false when there's none
+func unknownField(b protoreflect.RawFields, num protowire.Number, typ protowire.Type) ([]byte, bool) {
+	for len(b) > 0 {
+		fieldNum, fieldType, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, false
+		}
+		b = b[n:]
+		if fieldNum == num && fieldType == typ {
+			return b, true
+		}
+		n = protowire.ConsumeFieldValue(fieldNum, fieldType, b)
+		if n < 0 {
+			return nil, false
+		}
+		b = b[n:]
+	}
+	return nil, false
+}
+
+// setUnknownField replaces the unknown field num of m with field, encoded with its tag. The other
+// unknown fields are kept
+func setUnknownField(m protoreflect.Message, num protowire.Number, field []byte) {
+	var kept protoreflect.RawFields
+	b := m.GetUnknown()
+	for len(b) > 0 {
+		fieldNum, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			break
+		}
+		size := protowire.ConsumeFieldValue(fieldNum, typ, b[n:])
+		if size < 0 {
+			break
+		}
+		n += size
+		if fieldNum != num {
+			kept = append(kept, b[:n]...)
+		}
+		b = b[n:]
+	}
+	m.SetUnknown(append(kept, field...))
+}
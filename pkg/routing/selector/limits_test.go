@@ -0,0 +1,81 @@
+package selector_test
+
+import (
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing/selector"
+)
+
+func TestExceededLimits(t *testing.T) {
+	limits := config.LimitConfig{
+		NumTracks:        100,
+		BytesPerSec:      1000,
+		NumRooms:         10,
+		NumParticipants:  50,
+		ForwardedBitrate: 4000,
+	}
+
+	t.Run("under limits", func(t *testing.T) {
+		stats := &livekit.NodeStats{NumRooms: 9, NumClients: 49, NumTracksIn: 50, BytesOutPerSec: 400}
+		require.Empty(t, selector.ExceededLimits(limits, stats))
+		require.False(t, selector.LimitsReached(limits, stats))
+		require.InDelta(t, 0.98, selector.Utilization(limits, stats), 0.001)
+	})
+
+	t.Run("over limits", func(t *testing.T) {
+		stats := &livekit.NodeStats{NumRooms: 10, NumClients: 60, NumTracksOut: 100, BytesOutPerSec: 500}
+		require.Equal(t, []string{
+			selector.LimitRooms,
+			selector.LimitParticipants,
+			selector.LimitTracks,
+			selector.LimitForwardedBitrate,
+		}, selector.ExceededLimits(limits, stats))
+		require.InDelta(t, 1.2, selector.Utilization(limits, stats), 0.001)
+	})
+
+	t.Run("nodes are checked against their own limits", func(t *testing.T) {
+		stats := &livekit.NodeStats{NumRooms: 10}
+		selector.SetNodeLimits(stats, config.LimitConfig{NumRooms: 20})
+		require.Empty(t, selector.ExceededLimits(limits, stats))
+
+		selector.SetNodeLimits(stats, config.LimitConfig{NumRooms: 5})
+		require.Equal(t, []string{selector.LimitRooms}, selector.ExceededLimits(limits, stats))
+	})
+}
+
+func TestNodeLimits(t *testing.T) {
+	limits := config.LimitConfig{
+		NumTracks:        -1,
+		BytesPerSec:      1_000_000_000,
+		NumRooms:         10,
+		NumParticipants:  500,
+		ForwardedBitrate: 5_000_000_000,
+	}
+	stats := &livekit.NodeStats{NumRooms: 5}
+	_, ok := selector.GetNodeLimits(stats)
+	require.False(t, ok)
+	_, ok = selector.GetUtilization(stats)
+	require.False(t, ok)
+
+	selector.SetNodeLimits(stats, limits)
+	selector.UpdateUtilization(stats)
+
+	// published with the node
+	data, err := proto.Marshal(&livekit.Node{Id: "ND_1", Stats: stats})
+	require.NoError(t, err)
+	node := &livekit.Node{}
+	require.NoError(t, proto.Unmarshal(data, node))
+
+	published, ok := selector.GetNodeLimits(node.Stats)
+	require.True(t, ok)
+	require.Equal(t, limits, published)
+	utilization, ok := selector.GetUtilization(node.Stats)
+	require.True(t, ok)
+	require.Equal(t, float32(0.5), utilization)
+	require.Equal(t, int32(5), node.Stats.NumRooms)
+}
@@ -0,0 +1,31 @@
+package selector
+
+import (
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+)
+
+// SelectLowestRTT returns the available node with the lowest RTT measured by a client, rtts maps
+// node IDs to the RTTs. Nodes with a load above sysloadLimit are left out, unless all of them are.
+// nil is returned when none of the remaining nodes were measured
+func SelectLowestRTT(nodes []*livekit.Node, rtts map[string]time.Duration, sysloadLimit float32) *livekit.Node {
+	nodes, err := (&SystemLoadSelector{SysloadLimit: sysloadLimit}).filterNodes(nodes)
+	if err != nil {
+		return nil
+	}
+
+	var selected *livekit.Node
+	var lowest time.Duration
+	for _, node := range nodes {
+		rtt, ok := rtts[node.Id]
+		if !ok {
+			continue
+		}
+		if selected == nil || rtt < lowest {
+			selected = node
+			lowest = rtt
+		}
+	}
+	return selected
+}
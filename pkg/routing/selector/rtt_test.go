@@ -0,0 +1,43 @@
+package selector_test
+
+import (
+	"testing"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/routing/selector"
+)
+
+func TestSelectLowestRTT(t *testing.T) {
+	newNode := func(id string, load float32) *livekit.Node {
+		return &livekit.Node{
+			Id:    id,
+			State: livekit.NodeState_SERVING,
+			Stats: &livekit.NodeStats{
+				UpdatedAt:       time.Now().Unix(),
+				NumCpus:         1,
+				LoadAvgLast1Min: load,
+			},
+		}
+	}
+	near := newNode("near", 0)
+	far := newNode("far", 0)
+	busy := newNode("busy", 2)
+	nodes := []*livekit.Node{far, near, busy}
+
+	rtts := map[string]time.Duration{
+		"near": 20 * time.Millisecond,
+		"far":  120 * time.Millisecond,
+		"busy": 5 * time.Millisecond,
+	}
+	require.Equal(t, near, selector.SelectLowestRTT(nodes, rtts, 0.7))
+
+	// overloaded nodes are picked when there's nothing else
+	require.Equal(t, busy, selector.SelectLowestRTT([]*livekit.Node{busy}, rtts, 0.7))
+
+	// only nodes that were measured are considered
+	require.Nil(t, selector.SelectLowestRTT(nodes, map[string]time.Duration{"gone": time.Millisecond}, 0.7))
+	require.Nil(t, selector.SelectLowestRTT(nodes, nil, 0.7))
+}
@@ -28,3 +28,25 @@ func TestIsAvailable(t *testing.T) {
 		require.False(t, selector.IsAvailable(n))
 	})
 }
+
+func TestGetAvailableNodes(t *testing.T) {
+	newNode := func(id string, nodeType livekit.NodeType) *livekit.Node {
+		return &livekit.Node{
+			Id:    id,
+			State: livekit.NodeState_SERVING,
+			Type:  nodeType,
+			Stats: &livekit.NodeStats{
+				UpdatedAt: time.Now().Unix(),
+			},
+		}
+	}
+	media := newNode("media", livekit.NodeType_SERVER)
+	relay := newNode("relay", livekit.NodeType_CONTROLLER)
+
+	require.True(t, selector.HostsRooms(media))
+	require.False(t, selector.HostsRooms(relay))
+	require.Equal(t, []*livekit.Node{media}, selector.GetAvailableNodes([]*livekit.Node{media, relay}))
+
+	_, err := (&selector.RandomSelector{}).SelectNode([]*livekit.Node{relay})
+	require.ErrorIs(t, err, selector.ErrNoAvailableNodes)
+}
@@ -0,0 +1,197 @@
+package rtc
+
+import (
+	"sort"
+
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	// max attributes set on a participant, besides those the server derives
+	maxParticipantAttributes = 64
+	// max bytes of an attribute key and value
+	maxAttributeKeyLength   = 128
+	maxAttributeValueLength = 4096
+)
+
+// IsReservedAttribute returns true when the server derives the attribute, it can't be set
+func IsReservedAttribute(key string) bool {
+	return key == lowPowerModeAttribute || key == participantKindAttribute
+}
+
+// ValidateAttributes checks the keys and values of an attribute update, reserved attributes
+// can't be set
+func ValidateAttributes(attributes map[string]string) error {
+	for key, value := range attributes {
+		if key == "" || len(key) > maxAttributeKeyLength || len(value) > maxAttributeValueLength {
+			return ErrInvalidAttribute
+		}
+		if IsReservedAttribute(key) {
+			return ErrReservedAttribute
+		}
+	}
+	return nil
+}
+
+// Attributes returns the attributes set on the participant, and their version. The version goes up
+// with each update
+func (p *ParticipantImpl) Attributes() (map[string]string, uint32) {
+	p.attributesLock.Lock()
+	defer p.attributesLock.Unlock()
+
+	attributes := make(map[string]string, len(p.attributes))
+	for key, value := range p.attributes {
+		attributes[key] = value
+	}
+	return attributes, p.attributesVersion
+}
+
+// UpdateAttributes sets attributes of the participant, keeping the others. Attributes set to an
+// empty value are removed. The room is told which keys changed
+func (p *ParticipantImpl) UpdateAttributes(attributes map[string]string) error {
+	if err := ValidateAttributes(attributes); err != nil {
+		return err
+	}
+
+	p.attributesLock.Lock()
+	next := make(map[string]string, len(p.attributes)+len(attributes))
+	for key, value := range p.attributes {
+		next[key] = value
+	}
+	var changed []string
+	for key, value := range attributes {
+		if current, ok := next[key]; (ok && current == value) || (!ok && value == "") {
+			continue
+		}
+		if value == "" {
+			delete(next, key)
+		} else {
+			next[key] = value
+		}
+		changed = append(changed, key)
+	}
+	if len(next) > maxParticipantAttributes {
+		p.attributesLock.Unlock()
+		return ErrTooManyAttributes
+	}
+	if len(changed) == 0 {
+		p.attributesLock.Unlock()
+		return nil
+	}
+	p.attributes = next
+	p.attributesVersion++
+	onAttributesUpdate := p.onAttributesUpdate
+	p.attributesLock.Unlock()
+
+	sort.Strings(changed)
+	if onAttributesUpdate != nil {
+		onAttributesUpdate(p, changed)
+	}
+	return nil
+}
+
+// OnAttributesUpdate is called with the keys of the attributes that changed
+func (p *ParticipantImpl) OnAttributesUpdate(callback func(types.Participant, []string)) {
+	p.attributesLock.Lock()
+	defer p.attributesLock.Unlock()
+	p.onAttributesUpdate = callback
+}
+
+// onParticipantAttributesUpdate tells the room the attributes of p changed, and which
+func (r *Room) onParticipantAttributesUpdate(p types.Participant, changed []string) {
+	if p.Hidden() {
+		// nobody knows it's there
+		return
+	}
+
+	r.attributesLock.Lock()
+	defer r.attributesLock.Unlock()
+
+	attributes, version := participantAttributes(p)
+	dp, err := newParticipantAttributesPacket(p, attributes, version, changed)
+	if err != nil {
+		r.Logger.Warnw("could not encode participant attributes", err, "pID", p.ID())
+		return
+	}
+	for _, op := range r.GetParticipants() {
+		// participants that aren't active yet get them from the connection quality worker
+		if !op.Capabilities().DataPackets || op.State() != livekit.ParticipantInfo_ACTIVE || r.skipsUpdate(op, p) {
+			continue
+		}
+		if err := op.SendDataPacket(dp); err != nil {
+			r.Logger.Warnw("could not send participant attributes", err,
+				"participant", op.Identity(), "pID", p.ID())
+			continue
+		}
+		r.attributesSent(op)[p.ID()] = version
+	}
+}
+
+// sendParticipantAttributes sends each participant the attributes of the participants in the room
+// it wasn't sent yet, including its own, or that changed since they were sent
+func (r *Room) sendParticipantAttributes(participants []types.Participant) {
+	r.attributesLock.Lock()
+	defer r.attributesLock.Unlock()
+
+	type attributesVersion struct {
+		attributes map[string]string
+		version    uint32
+	}
+	attributes := make(map[types.Participant]*attributesVersion)
+	for _, p := range participants {
+		if p.Hidden() {
+			continue
+		}
+		if attrs, version := participantAttributes(p); attrs != nil || version > 0 {
+			attributes[p] = &attributesVersion{attributes: attrs, version: version}
+		}
+	}
+
+	sent := make(map[string]map[string]uint32, len(participants))
+	for _, op := range participants {
+		if !op.Capabilities().DataPackets || op.State() != livekit.ParticipantInfo_ACTIVE {
+			continue
+		}
+
+		prev := r.sentAttributes[op.Identity()]
+		next := make(map[string]uint32, len(attributes))
+		for p, attrs := range attributes {
+			if r.skipsUpdate(op, p) {
+				continue
+			}
+			if version, ok := prev[p.ID()]; ok && version == attrs.version {
+				next[p.ID()] = version
+				continue
+			}
+			dp, err := newParticipantAttributesPacket(p, attrs.attributes, attrs.version, nil)
+			if err == nil {
+				err = op.SendDataPacket(dp)
+			}
+			if err != nil {
+				// try again on the next update
+				r.Logger.Warnw("could not send participant attributes", err,
+					"participant", op.Identity(), "pID", p.ID())
+				continue
+			}
+			next[p.ID()] = attrs.version
+		}
+		sent[op.Identity()] = next
+	}
+	r.sentAttributes = sent
+}
+
+// attributesSent returns the versions of the attributes sent to op by participant ID. The caller
+// holds r.attributesLock
+func (r *Room) attributesSent(op types.Participant) map[string]uint32 {
+	if r.sentAttributes == nil {
+		r.sentAttributes = make(map[string]map[string]uint32)
+	}
+	sent := r.sentAttributes[op.Identity()]
+	if sent == nil {
+		sent = make(map[string]uint32)
+		r.sentAttributes[op.Identity()] = sent
+	}
+	return sent
+}
@@ -0,0 +1,116 @@
+package rtc
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestUpdateAttributes(t *testing.T) {
+	t.Run("updates are partial", func(t *testing.T) {
+		p := newParticipantForTest("alice")
+		var changes [][]string
+		p.OnAttributesUpdate(func(_ types.Participant, changed []string) {
+			changes = append(changes, changed)
+		})
+
+		require.NoError(t, p.UpdateAttributes(map[string]string{"role": "host", "seat": "1"}))
+		require.NoError(t, p.UpdateAttributes(map[string]string{"seat": "2", "role": "host"}))
+		attributes, version := p.Attributes()
+		require.Equal(t, map[string]string{"role": "host", "seat": "2"}, attributes)
+		require.EqualValues(t, 2, version)
+
+		// empty values remove attributes, unchanged ones aren't an update
+		require.NoError(t, p.UpdateAttributes(map[string]string{"seat": "", "gone": ""}))
+		require.NoError(t, p.UpdateAttributes(map[string]string{"role": "host"}))
+		attributes, version = p.Attributes()
+		require.Equal(t, map[string]string{"role": "host"}, attributes)
+		require.EqualValues(t, 3, version)
+		require.Equal(t, [][]string{{"role", "seat"}, {"seat"}, {"seat"}}, changes)
+
+		// the server's are merged in
+		p.params.Kind = ParticipantKindAgent
+		attributes, version = participantAttributes(p)
+		require.Equal(t, map[string]string{"role": "host", participantKindAttribute: ParticipantKindAgent}, attributes)
+		require.EqualValues(t, 3, version)
+	})
+
+	t.Run("invalid attributes are rejected", func(t *testing.T) {
+		p := newParticipantForTest("alice")
+		require.ErrorIs(t, p.UpdateAttributes(map[string]string{"": "a"}), ErrInvalidAttribute)
+		require.ErrorIs(t, p.UpdateAttributes(map[string]string{lowPowerModeAttribute: "a"}), ErrReservedAttribute)
+
+		attributes := make(map[string]string)
+		for i := 0; i <= maxParticipantAttributes; i++ {
+			attributes[fmt.Sprintf("key%d", i)] = "a"
+		}
+		require.ErrorIs(t, p.UpdateAttributes(attributes), ErrTooManyAttributes)
+		_, version := p.Attributes()
+		require.Zero(t, version)
+	})
+}
+
+func TestParticipantAttributesUpdates(t *testing.T) {
+	newParticipant := func(identity string) *typesfakes.FakeParticipant {
+		p := &typesfakes.FakeParticipant{}
+		p.IdentityReturns(identity)
+		p.IDReturns("PA_" + identity)
+		p.StateReturns(livekit.ParticipantInfo_ACTIVE)
+		p.CapabilitiesReturns(types.ProtocolVersion(3).Capabilities())
+		return p
+	}
+	alice := newParticipant("alice")
+	bob := newParticipant("bob")
+	r := &Room{
+		participants: map[string]types.Participant{
+			"alice": alice,
+			"bob":   bob,
+		},
+	}
+	participants := r.GetParticipants()
+
+	// nothing set yet
+	r.sendParticipantAttributes(participants)
+	require.Zero(t, bob.SendDataPacketCallCount())
+
+	// changes are sent right away, with the keys that changed
+	alice.AttributesReturns(map[string]string{"role": "host"}, 1)
+	r.onParticipantAttributesUpdate(alice, []string{"role"})
+	require.Equal(t, 1, alice.SendDataPacketCallCount())
+	require.Equal(t, 1, bob.SendDataPacketCallCount())
+	var msg participantAttributesMessage
+	require.NoError(t, json.Unmarshal(bob.SendDataPacketArgsForCall(0).GetUser().Payload, &msg))
+	require.Equal(t, participantAttributesMessageType, msg.Type)
+	require.Equal(t, "PA_alice", msg.ParticipantSid)
+	require.Equal(t, map[string]string{"role": "host"}, msg.Attributes)
+	require.EqualValues(t, 1, msg.Version)
+	require.Equal(t, []string{"role"}, msg.Changed)
+
+	// and not sent again
+	r.sendParticipantAttributes(participants)
+	require.Equal(t, 1, bob.SendDataPacketCallCount())
+
+	// participants that become active get the current ones
+	carol := newParticipant("carol")
+	carol.StateReturns(livekit.ParticipantInfo_JOINED)
+	r.participants["carol"] = carol
+	alice.AttributesReturns(nil, 2)
+	r.onParticipantAttributesUpdate(alice, []string{"role"})
+	require.Zero(t, carol.SendDataPacketCallCount())
+	carol.StateReturns(livekit.ParticipantInfo_ACTIVE)
+	r.sendParticipantAttributes(r.GetParticipants())
+	require.Equal(t, 1, carol.SendDataPacketCallCount())
+	require.Equal(t, 2, bob.SendDataPacketCallCount())
+	msg = participantAttributesMessage{}
+	require.NoError(t, json.Unmarshal(carol.SendDataPacketArgsForCall(0).GetUser().Payload, &msg))
+	require.Empty(t, msg.Attributes)
+	require.NotNil(t, msg.Attributes)
+	require.EqualValues(t, 2, msg.Version)
+	require.Empty(t, msg.Changed)
+}
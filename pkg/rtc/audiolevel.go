@@ -13,7 +13,8 @@ const (
 
 // keeps track of audio level for a participant
 type AudioLevel struct {
-	levelThreshold uint8
+	// set atomically, the room's audio config can change while frames are observed
+	levelThreshold uint32
 	currentLevel   uint32
 	// min duration to be considered active
 	minActiveDuration uint32
@@ -27,19 +28,24 @@ type AudioLevel struct {
 
 func NewAudioLevel(activeLevel uint8, minPercentile uint8) *AudioLevel {
 	l := &AudioLevel{
-		levelThreshold:    activeLevel,
-		minActiveDuration: uint32(minPercentile) * observeDuration / 100,
-		currentLevel:      silentAudioLevel,
-		observeLevel:      silentAudioLevel,
+		currentLevel: silentAudioLevel,
+		observeLevel: silentAudioLevel,
 	}
+	l.SetThresholds(activeLevel, minPercentile)
 	return l
 }
 
+// SetThresholds changes the level and the percentage of time above it that make a participant active
+func (l *AudioLevel) SetThresholds(activeLevel uint8, minPercentile uint8) {
+	atomic.StoreUint32(&l.levelThreshold, uint32(activeLevel))
+	atomic.StoreUint32(&l.minActiveDuration, uint32(minPercentile)*observeDuration/100)
+}
+
 // Observes a new frame, must be called from the same thread
 func (l *AudioLevel) Observe(level uint8, durationMs uint32) {
 	l.observedDuration += durationMs
 
-	if level <= l.levelThreshold {
+	if uint32(level) <= atomic.LoadUint32(&l.levelThreshold) {
 		l.activeDuration += durationMs
 		if l.observeLevel > level {
 			l.observeLevel = level
@@ -48,7 +54,7 @@ func (l *AudioLevel) Observe(level uint8, durationMs uint32) {
 
 	if l.observedDuration >= observeDuration {
 		// compute and reset
-		if l.activeDuration >= l.minActiveDuration {
+		if l.activeDuration >= atomic.LoadUint32(&l.minActiveDuration) {
 			level := uint32(l.observeLevel) - uint32(20*math.Log10(float64(l.activeDuration)/float64(observeDuration)))
 			atomic.StoreUint32(&l.currentLevel, level)
 		} else {
@@ -56,6 +56,20 @@ func TestAudioLevel(t *testing.T) {
 		require.Less(t, level, uint8(defaultActiveLevel))
 		require.Greater(t, level, uint8(25))
 	})
+
+	t.Run("thresholds apply from the next observe window when changed", func(t *testing.T) {
+		a := rtc.NewAudioLevel(defaultActiveLevel, defaultPercentile)
+		a.SetThresholds(20, defaultPercentile)
+
+		observeSamples(a, 25, samplesPerBatch)
+		_, noisy := a.GetLevel()
+		require.False(t, noisy)
+
+		a.SetThresholds(defaultActiveLevel, defaultPercentile)
+		observeSamples(a, 25, samplesPerBatch)
+		_, noisy = a.GetLevel()
+		require.True(t, noisy)
+	})
 }
 
 func observeSamples(a *rtc.AudioLevel, level uint8, count int) {
@@ -0,0 +1,193 @@
+package rtc
+
+import (
+	"sync"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// loss reported in twcc mode. Congestion control, as implemented by browsers, lowers the bitrate
+// when more than 10% of packets are lost and holds it between 2% and 10%
+const (
+	throttleDecreaseLoss = 0.12
+	throttleHoldLoss     = 0.05
+	throttleMaxLoss      = 0.5
+	// publishers within this ratio of their cap are held there
+	throttleHoldRatio = 0.9
+)
+
+// publisherBitrateCap keeps the video a participant publishes under the configured caps, by
+// steering the publisher's own bandwidth estimation. In remb mode, the publisher receives REMB
+// estimates at the cap. In twcc mode, a share of the packets of tracks over their cap is left out
+// of transport-cc feedback, so that the publisher sees them as lost and backs off.
+// Publishers that don't negotiate transport-cc rely on the REMB estimates sent by their buffers,
+// which are capped by ReceiverConfig
+type publisherBitrateCap struct {
+	config config.PublisherBitrateCapConfig
+
+	lock   sync.Mutex
+	tracks map[string]*cappedTrack
+}
+
+type cappedTrack struct {
+	transportCC bool
+	throttle    *twccThrottle
+}
+
+func newPublisherBitrateCap(conf config.PublisherBitrateCapConfig) *publisherBitrateCap {
+	return &publisherBitrateCap{
+		config: conf,
+		tracks: make(map[string]*cappedTrack),
+	}
+}
+
+// addTrack registers a published video track, returning the throttle of its transport-cc feedback
+// in twcc mode
+func (c *publisherBitrateCap) addTrack(trackID string, transportCC bool) *twccThrottle {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if t, ok := c.tracks[trackID]; ok {
+		return t.throttle
+	}
+	t := &cappedTrack{transportCC: transportCC}
+	if transportCC && c.config.Mode == config.PublisherBitrateCapModeTWCC {
+		t.throttle = &twccThrottle{}
+	}
+	c.tracks[trackID] = t
+	return t.throttle
+}
+
+func (c *publisherBitrateCap) removeTrack(trackID string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.tracks, trackID)
+}
+
+// update applies the caps to the current bitrates of the published video tracks. It returns the
+// REMB to send the publisher in remb mode, nil otherwise
+func (c *publisherBitrateCap) update(stats []*types.PublishedTrackStats) *rtcp.ReceiverEstimatedMaximumBitrate {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	bitrates := make(map[string]int64, len(stats))
+	var total int64
+	var ssrcs []uint32
+	numTracks := 0
+	for _, s := range stats {
+		t, ok := c.tracks[s.TrackID]
+		if !ok || !t.transportCC {
+			continue
+		}
+		var bitrate int64
+		for _, layer := range s.Layers {
+			bitrate += layer.Bitrate
+			ssrcs = append(ssrcs, layer.SSRC)
+		}
+		bitrates[s.TrackID] = bitrate
+		total += bitrate
+		numTracks++
+	}
+
+	if c.config.Mode == config.PublisherBitrateCapModeTWCC {
+		for trackID, bitrate := range bitrates {
+			if throttle := c.tracks[trackID].throttle; throttle != nil {
+				throttle.setLossRate(throttleLossRate(bitrate, c.trackLimit(bitrate, total)))
+			}
+		}
+		return nil
+	}
+
+	if numTracks == 0 {
+		return nil
+	}
+	// REMB caps everything the publisher sends
+	estimate := c.config.MaxParticipantBitrate
+	if trackCap := c.config.MaxTrackBitrate * uint64(numTracks); trackCap != 0 && (estimate == 0 || trackCap < estimate) {
+		estimate = trackCap
+	}
+	if estimate == 0 {
+		return nil
+	}
+	return &rtcp.ReceiverEstimatedMaximumBitrate{
+		Bitrate: float32(estimate),
+		SSRCs:   ssrcs,
+	}
+}
+
+// trackLimit returns the bitrate a track is allowed, 0 for no limit. Participants over their cap
+// share it in proportion to what each track sends
+func (c *publisherBitrateCap) trackLimit(bitrate, total int64) int64 {
+	limit := int64(c.config.MaxTrackBitrate)
+	if participantCap := int64(c.config.MaxParticipantBitrate); participantCap != 0 && total > participantCap {
+		share := participantCap * bitrate / total
+		if limit == 0 || share < limit {
+			limit = share
+		}
+	}
+	return limit
+}
+
+func hasTransportCC(feedback []webrtc.RTCPFeedback) bool {
+	for _, fb := range feedback {
+		if fb.Type == webrtc.TypeRTCPFBTransportCC {
+			return true
+		}
+	}
+	return false
+}
+
+// throttleLossRate returns the share of packets to report lost for a track sending bitrate
+func throttleLossRate(bitrate, limit int64) float64 {
+	switch {
+	case limit == 0 || bitrate <= 0:
+		return 0
+	case bitrate > limit:
+		loss := 1 - float64(limit)/float64(bitrate)
+		if loss < throttleDecreaseLoss {
+			loss = throttleDecreaseLoss
+		} else if loss > throttleMaxLoss {
+			loss = throttleMaxLoss
+		}
+		return loss
+	case float64(bitrate) > float64(limit)*throttleHoldRatio:
+		return throttleHoldLoss
+	default:
+		return 0
+	}
+}
+
+// twccThrottle leaves packets out of transport-cc feedback, evenly spread at the loss rate
+type twccThrottle struct {
+	lock     sync.Mutex
+	lossRate float64
+	debt     float64
+}
+
+func (t *twccThrottle) setLossRate(lossRate float64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.lossRate = lossRate
+	if lossRate == 0 {
+		t.debt = 0
+	}
+}
+
+// report returns whether the next packet is reported as received
+func (t *twccThrottle) report() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.lossRate == 0 {
+		return true
+	}
+	t.debt += t.lossRate
+	if t.debt >= 1 {
+		t.debt--
+		return false
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+func TestPublisherBitrateCap(t *testing.T) {
+	trackStats := func(trackID string, bitrates ...int64) *types.PublishedTrackStats {
+		stats := &types.PublishedTrackStats{TrackID: trackID}
+		for i, bitrate := range bitrates {
+			stats.Layers = append(stats.Layers, sfu.LayerStats{Layer: int32(i), SSRC: uint32(len(trackID)*10 + i), Bitrate: bitrate})
+		}
+		return stats
+	}
+
+	t.Run("remb caps the publisher", func(t *testing.T) {
+		c := newPublisherBitrateCap(config.PublisherBitrateCapConfig{
+			MaxTrackBitrate:       2_000_000,
+			MaxParticipantBitrate: 3_000_000,
+			Mode:                  config.PublisherBitrateCapModeREMB,
+		})
+		require.Nil(t, c.addTrack("TR_screen", true))
+		require.Nil(t, c.update([]*types.PublishedTrackStats{trackStats("TR_unknown", 100)}))
+
+		remb := c.update([]*types.PublishedTrackStats{trackStats("TR_screen", 4_000_000)})
+		require.NotNil(t, remb)
+		require.Equal(t, float32(2_000_000), remb.Bitrate)
+		require.Len(t, remb.SSRCs, 1)
+
+		c.addTrack("TR_camera", true)
+		remb = c.update([]*types.PublishedTrackStats{
+			trackStats("TR_screen", 4_000_000),
+			trackStats("TR_camera", 100_000, 300_000),
+		})
+		require.Equal(t, float32(3_000_000), remb.Bitrate)
+		require.Len(t, remb.SSRCs, 3)
+
+		// tracks without transport-cc are capped by the REMB of their buffers
+		c.removeTrack("TR_screen")
+		c.removeTrack("TR_camera")
+		c.addTrack("TR_legacy", false)
+		require.Nil(t, c.update([]*types.PublishedTrackStats{trackStats("TR_legacy", 4_000_000)}))
+	})
+
+	t.Run("twcc throttles tracks over their cap", func(t *testing.T) {
+		c := newPublisherBitrateCap(config.PublisherBitrateCapConfig{
+			MaxParticipantBitrate: 3_000_000,
+			Mode:                  config.PublisherBitrateCapModeTWCC,
+		})
+		screen := c.addTrack("TR_screen", true)
+		camera := c.addTrack("TR_camera", true)
+		require.NotNil(t, screen)
+		require.Same(t, screen, c.addTrack("TR_screen", true))
+		require.Nil(t, c.addTrack("TR_legacy", false))
+
+		require.Nil(t, c.update([]*types.PublishedTrackStats{
+			trackStats("TR_screen", 5_000_000),
+			trackStats("TR_camera", 1_000_000),
+		}))
+		// over the cap, the share of each track is 5/6 and 1/6 of it
+		require.InDelta(t, 0.5, screen.lossRate, 0.001)
+		require.InDelta(t, 0.5, camera.lossRate, 0.001)
+
+		c.update([]*types.PublishedTrackStats{
+			trackStats("TR_screen", 1_500_000),
+			trackStats("TR_camera", 1_000_000),
+		})
+		require.Zero(t, screen.lossRate)
+		require.Zero(t, camera.lossRate)
+	})
+}
+
+func TestThrottleLossRate(t *testing.T) {
+	require.Zero(t, throttleLossRate(5_000_000, 0))
+	require.Zero(t, throttleLossRate(1_000_000, 2_000_000))
+	require.Equal(t, throttleHoldLoss, throttleLossRate(1_900_000, 2_000_000))
+	require.Equal(t, throttleDecreaseLoss, throttleLossRate(2_100_000, 2_000_000))
+	require.InDelta(t, 0.2, throttleLossRate(2_500_000, 2_000_000), 0.001)
+	require.Equal(t, throttleMaxLoss, throttleLossRate(10_000_000, 2_000_000))
+}
+
+func TestTWCCThrottle(t *testing.T) {
+	throttle := &twccThrottle{}
+	for i := 0; i < 10; i++ {
+		require.True(t, throttle.report())
+	}
+
+	throttle.setLossRate(0.25)
+	reported := 0
+	for i := 0; i < 100; i++ {
+		if throttle.report() {
+			reported++
+		}
+	}
+	require.Equal(t, 75, reported)
+}
+
+func TestReceiverConfigREMBMaxBitrate(t *testing.T) {
+	conf := ReceiverConfig{maxBitrate: 3_000_000}
+	require.Equal(t, uint64(3_000_000), conf.rembMaxBitrate())
+
+	conf.BitrateCap.MaxParticipantBitrate = 5_000_000
+	require.Equal(t, uint64(3_000_000), conf.rembMaxBitrate())
+
+	conf.BitrateCap.MaxTrackBitrate = 1_000_000
+	require.Equal(t, uint64(1_000_000), conf.rembMaxBitrate())
+}
@@ -0,0 +1,30 @@
+package rtc
+
+import (
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// isViewer returns true when the room is in broadcast mode, and p only watches it
+func (r *Room) isViewer(p types.Participant) bool {
+	return r.roomConfig != nil && r.roomConfig.Policy.IsViewer(p.Identity())
+}
+
+// skipsUpdate returns true when op isn't sent the state of p. In broadcast mode viewers aren't told
+// about each other, there can be thousands of them
+func (r *Room) skipsUpdate(op, p types.Participant) bool {
+	return op.ID() != p.ID() && r.isViewer(op) && r.isViewer(p)
+}
+
+// participantsVisibleTo returns the participants whose state is sent to op
+func (r *Room) participantsVisibleTo(op types.Participant, participants []types.Participant) []types.Participant {
+	if !r.isViewer(op) {
+		return participants
+	}
+	visible := make([]types.Participant, 0, len(participants))
+	for _, p := range participants {
+		if !r.skipsUpdate(op, p) {
+			visible = append(visible, p)
+		}
+	}
+	return visible
+}
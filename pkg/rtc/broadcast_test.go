@@ -0,0 +1,55 @@
+package rtc_test
+
+import (
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestBroadcastRooms(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{
+		num:       4,
+		protocol:  types.DefaultProtocol,
+		broadcast: &config.BroadcastPolicy{Publishers: []string{"p0"}},
+	})
+	host := rm.GetParticipant("p0").(*typesfakes.FakeParticipant)
+	viewer := rm.GetParticipant("p1").(*typesfakes.FakeParticipant)
+	last := rm.GetParticipant("p3").(*typesfakes.FakeParticipant)
+
+	t.Run("viewers only know the publishers", func(t *testing.T) {
+		_, others, _ := last.SendJoinResponseArgsForCall(0)
+		require.Len(t, others, 1)
+
+		// the host knows everyone
+		hostUpdates := host.SendParticipantUpdateCallCount()
+		viewerUpdates := viewer.SendParticipantUpdateCallCount()
+		last.OnStateChangeArgsForCall(0)(last, livekit.ParticipantInfo_JOINED)
+		require.Equal(t, hostUpdates+1, host.SendParticipantUpdateCallCount())
+		require.Equal(t, viewerUpdates, viewer.SendParticipantUpdateCallCount())
+
+		// while viewers are told about the host
+		host.OnStateChangeArgsForCall(0)(host, livekit.ParticipantInfo_JOINED)
+		require.Equal(t, viewerUpdates+1, viewer.SendParticipantUpdateCallCount())
+	})
+
+	t.Run("resuming viewers only get the publishers", func(t *testing.T) {
+		updates := viewer.SendParticipantUpdateCallCount()
+		require.NoError(t, rm.ResumeParticipant(viewer, nil))
+		require.Equal(t, updates+1, viewer.SendParticipantUpdateCallCount())
+		infos, _ := viewer.SendParticipantUpdateArgsForCall(updates)
+		require.Len(t, infos, 2)
+	})
+
+	t.Run("only publishers are speakers", func(t *testing.T) {
+		host.GetAudioLevelReturns(10, true)
+		viewer.GetAudioLevelReturns(10, true)
+		speakers := rm.GetActiveSpeakers()
+		require.Len(t, speakers, 1)
+		require.Equal(t, host.ID(), speakers[0].Sid)
+	})
+}
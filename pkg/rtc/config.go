@@ -2,14 +2,20 @@ package rtc
 
 import (
 	"errors"
+	"hash/fnv"
 	"net"
+	"path"
+	"time"
 
+	"github.com/livekit/protocol/logger"
 	"github.com/pion/ice/v2"
 	"github.com/pion/webrtc/v3"
 
 	"github.com/livekit/livekit-server/pkg/config"
 	serverlogger "github.com/livekit/livekit-server/pkg/logger"
+	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
 const (
@@ -18,18 +24,96 @@ const (
 )
 
 type WebRTCConfig struct {
-	Configuration  webrtc.Configuration
-	SettingEngine  webrtc.SettingEngine
-	Receiver       ReceiverConfig
-	BufferFactory  *buffer.Factory
-	UDPMux         ice.UDPMux
-	UDPMuxConn     *net.UDPConn
-	TCPMuxListener *net.TCPListener
+	Configuration     webrtc.Configuration
+	SettingEngine     webrtc.SettingEngine
+	Receiver          ReceiverConfig
+	Sender            SenderConfig
+	CongestionControl CongestionControlConfig
+	BufferFactory     *buffer.Factory
+	UDPMux            ice.UDPMux
+	UDPMuxConn        *net.UDPConn
+	TCPMuxListener    *net.TCPListener
+	// types of local candidates that aren't sent to clients
+	ExcludedCandidateTypes []webrtc.ICECandidateType
+	// resolves the mDNS candidates of clients, nil when disabled or the node can't receive multicast
+	MDNSResolver *MDNSResolver
+
+	// set per participant in network emulation rooms
+	NetworkEmulation *config.NetworkEmulationConfig
 }
 
 type ReceiverConfig struct {
 	PacketBufferSize int
+	BitrateCap       config.PublisherBitrateCapConfig
 	maxBitrate       uint64
+	// writes packets to down tracks when set, shared by the tracks of the node
+	ForwardingPool *sfu.ForwardingPool
+	// packets of the latest key frame kept for each video layer, 0 to send PLIs instead
+	KeyFrameCachePackets int
+	// percentile of subscriber loss reported to publishers of audio, 0 for none
+	UpstreamLossPercentile uint8
+	// time packets wait for the ones missing before them, 0 to forward them as they arrive
+	ReorderWindow time.Duration
+}
+
+// rembMaxBitrate is the max estimate sent to publishers in REMB, which caps them at the lowest of
+// the configured bitrates
+func (c ReceiverConfig) rembMaxBitrate() uint64 {
+	maxBitrate := c.maxBitrate
+	for _, bitrateCap := range []uint64{c.BitrateCap.MaxTrackBitrate, c.BitrateCap.MaxParticipantBitrate} {
+		if bitrateCap != 0 && bitrateCap < maxBitrate {
+			maxBitrate = bitrateCap
+		}
+	}
+	return maxBitrate
+}
+
+type SenderConfig struct {
+	// time to wait for a subscriber to be ready before forwarding media, 0 to forward immediately
+	ReadyTimeout time.Duration
+	// pacing of the packets sent to each subscriber, disabled when the rate is 0
+	Pacer config.PacerConfig
+	// how often subscribers get sender reports, unless their room's policy overrides it
+	SenderReports config.SenderReportConfig
+}
+
+type CongestionControlConfig struct {
+	Algorithm            string
+	ExperimentAlgorithm  string
+	ExperimentPercentage int
+}
+
+// AlgorithmFor returns the congestion control algorithm to use for a subscriber. Assignment to the
+// experiment is based on identity, so that participants stay in the same group when reconnecting
+func (c CongestionControlConfig) AlgorithmFor(identity string) string {
+	if c.ExperimentAlgorithm == "" || c.ExperimentPercentage <= 0 {
+		return c.Algorithm
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(identity))
+	if int(h.Sum32()%100) < c.ExperimentPercentage {
+		return c.ExperimentAlgorithm
+	}
+	return c.Algorithm
+}
+
+// newInterfaceFilter returns a filter of the network interfaces to gather candidates on, those
+// matching one of includes, or all when it's empty, and none of excludes
+func newInterfaceFilter(includes, excludes []string) func(string) bool {
+	matches := func(patterns []string, name string) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return func(name string) bool {
+		if len(includes) != 0 && !matches(includes, name) {
+			return false
+		}
+		return !matches(excludes, name)
+	}
 }
 
 // number of packets to buffer up
@@ -37,6 +121,12 @@ const readBufferSize = 50
 
 func NewWebRTCConfig(conf *config.Config, externalIP string) (*WebRTCConfig, error) {
 	rtcConf := conf.RTC
+	for _, algorithm := range []string{rtcConf.CongestionControl.Algorithm, rtcConf.CongestionControl.ExperimentAlgorithm} {
+		if _, err := sfu.NewCongestionController(algorithm); err != nil {
+			return nil, err
+		}
+	}
+
 	c := webrtc.Configuration{
 		SDPSemantics: webrtc.SDPSemanticsUnifiedPlan,
 	}
@@ -44,9 +134,39 @@ func NewWebRTCConfig(conf *config.Config, externalIP string) (*WebRTCConfig, err
 		LoggerFactory: serverlogger.LoggerFactory(),
 	}
 
-	if externalIP != "" {
+	candidates := rtcConf.Candidates
+	if len(candidates.NAT1To1IPs) != 0 {
+		candidateType := webrtc.ICECandidateTypeHost
+		if candidates.NAT1To1CandidateType == config.CandidateTypeSrflx {
+			candidateType = webrtc.ICECandidateTypeSrflx
+		}
+		s.SetNAT1To1IPs(candidates.NAT1To1IPs, candidateType)
+	} else if externalIP != "" {
 		s.SetNAT1To1IPs([]string{externalIP}, webrtc.ICECandidateTypeHost)
 	}
+	if len(candidates.InterfaceIncludes) != 0 || len(candidates.InterfaceExcludes) != 0 {
+		s.SetInterfaceFilter(newInterfaceFilter(candidates.InterfaceIncludes, candidates.InterfaceExcludes))
+	}
+	// mDNS candidates of clients are resolved by the transports, with a socket shared by the node
+	// instead of one per PeerConnection
+	s.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+	var mdnsResolver *MDNSResolver
+	if !candidates.DisableMDNS {
+		resolver, err := NewMDNSResolver(s.LoggerFactory)
+		if err != nil {
+			logger.Warnw("could not start mDNS resolver, mDNS candidates of clients are dropped", err)
+		} else {
+			mdnsResolver = resolver
+		}
+	}
+	var excludedCandidateTypes []webrtc.ICECandidateType
+	for _, raw := range candidates.ExcludeTypes {
+		candidateType, err := webrtc.NewICECandidateType(raw)
+		if err != nil {
+			return nil, err
+		}
+		excludedCandidateTypes = append(excludedCandidateTypes, candidateType)
+	}
 
 	if rtcConf.PacketBufferSize == 0 {
 		rtcConf.PacketBufferSize = 500
@@ -112,16 +232,41 @@ func NewWebRTCConfig(conf *config.Config, externalIP string) (*WebRTCConfig, err
 	}
 	s.SetNetworkTypes(networkTypes)
 
+	var forwardingPool *sfu.ForwardingPool
+	if rtcConf.Forwarding.Workers > 0 {
+		forwardingPool = sfu.NewForwardingPool(rtcConf.Forwarding.Workers, rtcConf.Forwarding.QueueSize)
+		forwardingPool.OnPacketDropped(func(_ sfu.TrackSender) {
+			prometheus.IncrementForwardDropped()
+		})
+	}
+
 	return &WebRTCConfig{
 		Configuration: c,
 		SettingEngine: s,
 		Receiver: ReceiverConfig{
-			PacketBufferSize: rtcConf.PacketBufferSize,
-			maxBitrate:       rtcConf.MaxBitrate,
+			PacketBufferSize:       rtcConf.PacketBufferSize,
+			BitrateCap:             rtcConf.PublisherBitrateCap,
+			maxBitrate:             rtcConf.MaxBitrate,
+			ForwardingPool:         forwardingPool,
+			KeyFrameCachePackets:   rtcConf.KeyFrameCache.MaxPackets,
+			UpstreamLossPercentile: rtcConf.UpstreamFeedback.LossPercentile,
+			ReorderWindow:          rtcConf.ReorderWindow,
+		},
+		Sender: SenderConfig{
+			ReadyTimeout:  rtcConf.SubscriberReadyTimeout,
+			Pacer:         rtcConf.Pacer,
+			SenderReports: rtcConf.SenderReports,
+		},
+		CongestionControl: CongestionControlConfig{
+			Algorithm:            rtcConf.CongestionControl.Algorithm,
+			ExperimentAlgorithm:  rtcConf.CongestionControl.ExperimentAlgorithm,
+			ExperimentPercentage: rtcConf.CongestionControl.ExperimentPercentage,
 		},
-		UDPMux:         udpMux,
-		UDPMuxConn:     udpMuxConn,
-		TCPMuxListener: tcpListener,
+		UDPMux:                 udpMux,
+		UDPMuxConn:             udpMuxConn,
+		TCPMuxListener:         tcpListener,
+		ExcludedCandidateTypes: excludedCandidateTypes,
+		MDNSResolver:           mdnsResolver,
 	}, nil
 }
 
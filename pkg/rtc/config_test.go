@@ -0,0 +1,39 @@
+package rtc
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestInterfaceFilter(t *testing.T) {
+	filter := newInterfaceFilter(nil, []string{"docker*", "veth*"})
+	require.True(t, filter("eth0"))
+	require.False(t, filter("docker0"))
+	require.False(t, filter("vethab12"))
+
+	filter = newInterfaceFilter([]string{"eth*", "ens5"}, []string{"eth1"})
+	require.True(t, filter("eth0"))
+	require.True(t, filter("ens5"))
+	require.False(t, filter("eth1"))
+	require.False(t, filter("wlan0"))
+}
+
+func TestWebRTCConfigCandidates(t *testing.T) {
+	conf, err := config.NewConfig("", nil)
+	require.NoError(t, err)
+	conf.RTC.TCPPort = 0
+	conf.RTC.Candidates = config.CandidatesConfig{
+		NAT1To1IPs:           []string{"203.0.113.1/10.0.0.1"},
+		NAT1To1CandidateType: config.CandidateTypeSrflx,
+		ExcludeTypes:         []string{config.CandidateTypeHost},
+		DisableMDNS:          true,
+	}
+
+	rtcConf, err := NewWebRTCConfig(conf, "198.51.100.1")
+	require.NoError(t, err)
+	require.Equal(t, []webrtc.ICECandidateType{webrtc.ICECandidateTypeHost}, rtcConf.ExcludedCandidateTypes)
+}
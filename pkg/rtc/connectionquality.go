@@ -0,0 +1,189 @@
+package rtc
+
+import (
+	"math"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+// Connection quality is expressed as a mean opinion score (MOS) on the 1-5 scale, derived from a
+// simplified ITU-T G.107 E-model. The E-model tops out at 4.5 for a perfect connection.
+const (
+	minMOS = 1.0
+	maxMOS = 4.5
+
+	// R >= 80, users satisfied
+	excellentMOS = 4.0
+	// R < 60, many users dissatisfied
+	poorMOS = 3.1
+
+	// default transmission rating factor
+	baseR = 93.2
+	// codec independent delay added to every measurement, ms
+	baseDelayMs = 10.0
+	// packet loss robustness with loss concealment
+	lossRobustness = 10.0
+	// impairment for dropping video layers below what the subscriber asked for
+	layerDowngradeImpairment = 0.5
+	// maximum impairment when video is delivered below its expected bitrate
+	bitrateImpairment = 1.5
+)
+
+// trackQuality holds the measurements a track's score is derived from
+type trackQuality struct {
+	isVideo        bool
+	lossPercentage float64
+	jitterMs       float64
+	rttMs          float64
+	// bitrate delivered and bitrate the track would have at full quality, 0 if unknown
+	bitrate         int64
+	expectedBitrate int64
+	// quality was reduced by publishing or forwarding fewer layers
+	layerDowngraded bool
+}
+
+// score returns the track's MOS
+func (q trackQuality) score() float32 {
+	// one way delay, with jitter buffering at twice the jitter
+	delay := q.rttMs/2 + 2*q.jitterMs + baseDelayMs
+	var delayImpairment float64
+	if delay < 160 {
+		delayImpairment = delay / 40
+	} else {
+		delayImpairment = (delay - 120) / 10
+	}
+
+	loss := math.Max(q.lossPercentage, 0)
+	lossImpairment := 95 * loss / (loss + lossRobustness)
+
+	mos := rToMOS(baseR - delayImpairment - lossImpairment)
+
+	if q.isVideo {
+		var impairment float64
+		if q.layerDowngraded {
+			impairment = layerDowngradeImpairment
+		}
+		if q.expectedBitrate > 0 && q.bitrate < q.expectedBitrate {
+			shortfall := 1 - float64(q.bitrate)/float64(q.expectedBitrate)
+			impairment = math.Max(impairment, shortfall*bitrateImpairment)
+		}
+		mos -= impairment
+	}
+
+	return float32(math.Max(minMOS, math.Min(maxMOS, mos)))
+}
+
+func rToMOS(r float64) float64 {
+	switch {
+	case r <= 0:
+		return minMOS
+	case r >= 100:
+		return maxMOS
+	}
+	return 1 + 0.035*r + 7e-6*r*(r-60)*(100-r)
+}
+
+func scoreToConnectionQuality(score float32) livekit.ConnectionQuality {
+	switch {
+	case score >= excellentMOS:
+		return livekit.ConnectionQuality_EXCELLENT
+	case score < poorMOS:
+		return livekit.ConnectionQuality_POOR
+	}
+	return livekit.ConnectionQuality_GOOD
+}
+
+// upstreamStats holds the measurements of a participant's publisher connection, shared by the
+// tracks it publishes
+type upstreamStats struct {
+	// packets reported as lost in transport-cc feedback
+	transportLossPercentage float64
+	rttMs                   float64
+}
+
+// publishedTrackQuality takes the higher of the loss in the track's receiver reports and the
+// loss seen by transport-cc, which covers every packet sent on the connection, including those
+// of layers that aren't forwarded
+func publishedTrackQuality(t types.PublishedTrack, stats *types.PublishedTrackStats, upstream upstreamStats) trackQuality {
+	publishing, registered := t.NumUpTracks()
+	q := trackQuality{
+		isVideo:         t.Kind() == livekit.TrackType_VIDEO,
+		lossPercentage:  math.Max(float64(t.PublishLossPercentage()), upstream.transportLossPercentage),
+		rttMs:           upstream.rttMs,
+		layerDowngraded: registered > 0 && publishing != registered,
+	}
+	for _, layer := range stats.Layers {
+		q.jitterMs = math.Max(q.jitterMs, layer.JitterMs)
+	}
+	return q
+}
+
+func subscribedTrackQuality(t types.SubscribedTrack, stats *types.SubscribedTrackStats) trackQuality {
+	q := trackQuality{
+		isVideo:         stats.Kind == webrtc.RTPCodecTypeVideo.String(),
+		lossPercentage:  float64(t.SubscribeLossPercentage()),
+		jitterMs:        stats.JitterMs,
+		rttMs:           float64(stats.RTTMs),
+		bitrate:         stats.Bitrate,
+		expectedBitrate: stats.ExpectedBitrate,
+	}
+	if stats.ForwardingStatus != "" {
+		q.layerDowngraded = stats.ForwardingStatus != sfu.ForwardingStatusOptimal.String()
+	}
+	return q
+}
+
+// trackConnectionQualityMessage breaks the connection quality of participants down by the tracks
+// they publish. It's sent along with every ConnectionQualityUpdate, for the same participants
+type trackConnectionQualityMessage struct {
+	Type   string                    `json:"type"`
+	Tracks []*trackConnectionQuality `json:"tracks"`
+}
+
+type trackConnectionQuality struct {
+	ParticipantSid string  `json:"participant_sid"`
+	TrackSid       string  `json:"track_sid"`
+	Quality        string  `json:"quality"`
+	Score          float32 `json:"score"`
+	LossPercentage float32 `json:"loss_percentage"`
+	RTTMs          uint32  `json:"rtt_ms,omitempty"`
+	JitterMs       float64 `json:"jitter_ms,omitempty"`
+}
+
+func newTrackConnectionQualityPacket(tracks []*trackConnectionQuality) (*livekit.DataPacket, error) {
+	return newServerMessagePacket(&trackConnectionQualityMessage{
+		Type:   trackConnectionQualityMessageType,
+		Tracks: tracks,
+	})
+}
+
+// publishedTracksConnectionQuality returns the quality of the unmuted tracks p publishes
+func publishedTracksConnectionQuality(p types.Participant) []*trackConnectionQuality {
+	stats := p.GetStats()
+	if stats == nil {
+		return nil
+	}
+	var tracks []*trackConnectionQuality
+	for _, ts := range stats.PublishedTracks {
+		if ts.Muted {
+			continue
+		}
+		tq := &trackConnectionQuality{
+			ParticipantSid: p.ID(),
+			TrackSid:       ts.TrackID,
+			Quality:        scoreToConnectionQuality(ts.Score).String(),
+			Score:          ts.Score,
+			LossPercentage: ts.LossPercentage,
+			RTTMs:          ts.RTTMs,
+		}
+		for _, layer := range ts.Layers {
+			tq.JitterMs = math.Max(tq.JitterMs, layer.JitterMs)
+		}
+		tracks = append(tracks, tq)
+	}
+	return tracks
+}
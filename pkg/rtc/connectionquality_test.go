@@ -0,0 +1,112 @@
+package rtc
+
+import (
+	"encoding/json"
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+func TestTrackQualityScore(t *testing.T) {
+	t.Run("perfect connection", func(t *testing.T) {
+		score := trackQuality{}.score()
+		require.InDelta(t, 4.4, score, 0.1)
+		require.Equal(t, livekit.ConnectionQuality_EXCELLENT, scoreToConnectionQuality(score))
+	})
+
+	t.Run("score decreases with loss", func(t *testing.T) {
+		var prev float32 = maxMOS
+		for _, loss := range []float64{1, 2, 4, 10, 30} {
+			score := trackQuality{lossPercentage: loss}.score()
+			require.Less(t, score, prev)
+			prev = score
+		}
+		require.Equal(t, livekit.ConnectionQuality_EXCELLENT, scoreToConnectionQuality(trackQuality{lossPercentage: 1}.score()))
+		require.Equal(t, livekit.ConnectionQuality_GOOD, scoreToConnectionQuality(trackQuality{lossPercentage: 3}.score()))
+		require.Equal(t, livekit.ConnectionQuality_POOR, scoreToConnectionQuality(trackQuality{lossPercentage: 10}.score()))
+	})
+
+	t.Run("high latency", func(t *testing.T) {
+		score := trackQuality{rttMs: 800, jitterMs: 100}.score()
+		require.Equal(t, livekit.ConnectionQuality_POOR, scoreToConnectionQuality(score))
+	})
+
+	t.Run("video impairments", func(t *testing.T) {
+		base := trackQuality{isVideo: true}.score()
+		downgraded := trackQuality{isVideo: true, layerDowngraded: true}.score()
+		require.InDelta(t, base-layerDowngradeImpairment, downgraded, 0.01)
+
+		starved := trackQuality{isVideo: true, bitrate: 100_000, expectedBitrate: 1_000_000}.score()
+		require.Less(t, starved, downgraded)
+		require.Equal(t, livekit.ConnectionQuality_POOR, scoreToConnectionQuality(starved))
+
+		// bitrate is only a video concern
+		audio := trackQuality{bitrate: 1, expectedBitrate: 1_000_000}.score()
+		require.Equal(t, trackQuality{}.score(), audio)
+	})
+
+	t.Run("score is bounded", func(t *testing.T) {
+		require.Equal(t, float32(minMOS), trackQuality{lossPercentage: 100, rttMs: 5000, isVideo: true, layerDowngraded: true}.score())
+	})
+}
+
+func TestPublishedTrackQuality(t *testing.T) {
+	track := &typesfakes.FakePublishedTrack{}
+	track.KindReturns(livekit.TrackType_AUDIO)
+	track.PublishLossPercentageReturns(2)
+
+	q := publishedTrackQuality(track, &types.PublishedTrackStats{}, upstreamStats{})
+	require.Equal(t, 2.0, q.lossPercentage)
+
+	// loss seen by transport-cc wins when it's higher
+	q = publishedTrackQuality(track, &types.PublishedTrackStats{}, upstreamStats{transportLossPercentage: 8, rttMs: 300})
+	require.Equal(t, 8.0, q.lossPercentage)
+	require.Equal(t, 300.0, q.rttMs)
+	require.Equal(t, livekit.ConnectionQuality_POOR, scoreToConnectionQuality(q.score()))
+}
+
+func TestSendTrackConnectionQuality(t *testing.T) {
+	p := &typesfakes.FakeParticipant{}
+	p.IDReturns("PA_publisher")
+	p.GetStatsReturns(&types.ParticipantStats{
+		PublishedTracks: []*types.PublishedTrackStats{
+			{
+				TrackID:        "TR_camera",
+				Score:          3.5,
+				LossPercentage: 4,
+				RTTMs:          120,
+				Layers:         []sfu.LayerStats{{JitterMs: 10}, {JitterMs: 20}},
+			},
+			{TrackID: "TR_mic", Muted: true},
+		},
+	})
+	tracks := publishedTracksConnectionQuality(p)
+	require.Equal(t, []*trackConnectionQuality{{
+		ParticipantSid: "PA_publisher",
+		TrackSid:       "TR_camera",
+		Quality:        livekit.ConnectionQuality_GOOD.String(),
+		Score:          3.5,
+		LossPercentage: 4,
+		RTTMs:          120,
+		JitterMs:       20,
+	}}, tracks)
+
+	r := &Room{}
+	op := &typesfakes.FakeParticipant{}
+	op.CapabilitiesReturns(types.ProtocolVersion(3).Capabilities())
+	r.sendTrackConnectionQuality(op, tracks)
+	require.Equal(t, 1, op.SendDataPacketCallCount())
+	var msg trackConnectionQualityMessage
+	require.NoError(t, json.Unmarshal(op.SendDataPacketArgsForCall(0).GetUser().Payload, &msg))
+	require.Equal(t, trackConnectionQualityMessageType, msg.Type)
+	require.Equal(t, tracks, msg.Tracks)
+
+	// nothing to send
+	r.sendTrackConnectionQuality(op, nil)
+	require.Equal(t, 1, op.SendDataPacketCallCount())
+}
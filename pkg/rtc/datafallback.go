@@ -0,0 +1,178 @@
+package rtc
+
+import (
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+// reliable data packets are sent over the signal connection to participants whose reliable data
+// channel didn't open this long after they connected. Some proxies let media through, not SCTP
+const dataChannelOpenTimeout = 5 * time.Second
+
+// paths data packets are sent to participants on, from the one preferred
+const (
+	dataPathSubscriber = "subscriber"
+	dataPathPublisher  = "publisher"
+	dataPathSignal     = "signal"
+)
+
+// reliableDataChannel returns the data channel reliable packets are sent to the participant on, nil
+// until it's created
+func (p *ParticipantImpl) reliableDataChannel() *webrtc.DataChannel {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if p.usesSubscriberDataChannels() {
+		return p.reliableDCSub
+	}
+	return p.reliableDC
+}
+
+// lossyDataChannel returns the data channel lossy packets are sent to the participant on, nil until
+// it's created
+func (p *ParticipantImpl) lossyDataChannel() *webrtc.DataChannel {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if p.usesSubscriberDataChannels() {
+		return p.lossyDCSub
+	}
+	return p.lossyDC
+}
+
+func (p *ParticipantImpl) usesSubscriberDataChannels() bool {
+	return p.SubscriberAsPrimary() && !p.dataOnPublisher.Get()
+}
+
+// dataPath returns the path reliable data packets are sent to the participant on
+func (p *ParticipantImpl) dataPath() string {
+	switch {
+	case p.sendsDataOverSignal(livekit.DataPacket_RELIABLE):
+		return dataPathSignal
+	case p.usesSubscriberDataChannels():
+		return dataPathSubscriber
+	default:
+		return dataPathPublisher
+	}
+}
+
+// createSubscriberDataChannels creates the data channels of a subscriber primary participant on its
+// subscriber connection, replacing those that failed
+func (p *ParticipantImpl) createSubscriberDataChannels() error {
+	ordered := true
+	reliable, err := p.subscriber.pc.CreateDataChannel(reliableDataChannel, &webrtc.DataChannelInit{
+		Ordered: &ordered,
+	})
+	if err != nil {
+		return err
+	}
+	retransmits := uint16(0)
+	lossy, err := p.subscriber.pc.CreateDataChannel(lossyDataChannel, &webrtc.DataChannelInit{
+		Ordered:        &ordered,
+		MaxRetransmits: &retransmits,
+	})
+	if err != nil {
+		_ = reliable.Close()
+		return err
+	}
+	reliable.OnOpen(p.flushTrackErrors)
+	reliable.OnClose(func() {
+		if p.reliableDataChannel() == reliable {
+			p.fallBackFromSubscriberData("subscriber data channel closed")
+		}
+	})
+
+	p.lock.Lock()
+	prevReliable, prevLossy := p.reliableDCSub, p.lossyDCSub
+	p.reliableDCSub = reliable
+	p.lossyDCSub = lossy
+	p.lock.Unlock()
+
+	for _, dc := range []*webrtc.DataChannel{prevReliable, prevLossy} {
+		if dc != nil {
+			_ = dc.Close()
+		}
+	}
+	return nil
+}
+
+// checkDataChannels falls back from the reliable data channel when it didn't open
+func (p *ParticipantImpl) checkDataChannels() {
+	if isDataChannelOpen(p.reliableDataChannel()) {
+		return
+	}
+	if p.usesSubscriberDataChannels() {
+		p.fallBackFromSubscriberData("subscriber data channel did not open")
+		return
+	}
+	p.fallBackToSignalData("data channel did not open")
+}
+
+// fallBackFromSubscriberData stops sending data to a subscriber primary participant on its subscriber
+// data channels, which the client couldn't open: older clients don't accept them, and SCTP can fail on
+// the subscriber connection. The data channels the client created on its publisher connection are
+// used when they're open. Otherwise the subscriber data channels are created once more, negotiating
+// the subscriber connection again, and data falls back to the signal connection when they don't open
+// either
+func (p *ParticipantImpl) fallBackFromSubscriberData(reason string) {
+	if p.params.SignalOnly || p.State() == livekit.ParticipantInfo_DISCONNECTED {
+		return
+	}
+
+	p.lock.RLock()
+	publisherDC := p.reliableDC
+	p.lock.RUnlock()
+	if isDataChannelOpen(publisherDC) {
+		if p.dataOnPublisher.TrySet(true) {
+			p.params.Logger.Infow("sending data over the publisher data channels", "reason", reason)
+			prometheus.IncrementDataPathFallback(dataPathPublisher)
+			go p.flushTrackErrors()
+		}
+		return
+	}
+
+	if p.subscriber != nil && p.subscriberDCRetried.TrySet(true) {
+		if err := p.createSubscriberDataChannels(); err != nil {
+			p.params.Logger.Warnw("could not create subscriber data channels", err)
+		} else {
+			p.params.Logger.Infow("creating subscriber data channels again", "reason", reason)
+			p.subscriber.Negotiate()
+			time.AfterFunc(dataChannelOpenTimeout, p.checkDataChannels)
+			return
+		}
+	}
+	p.fallBackToSignalData(reason)
+}
+
+// fallBackToSignalData sends the participant reliable data packets over the signal connection from
+// now on, lossy ones are still dropped when the data channel isn't open
+func (p *ParticipantImpl) fallBackToSignalData(reason string) {
+	if p.params.SignalOnly || p.State() == livekit.ParticipantInfo_DISCONNECTED {
+		return
+	}
+	if p.dataOverSignal.TrySet(true) {
+		p.params.Logger.Infow("sending reliable data over the signal connection", "reason", reason)
+		prometheus.IncrementDataPathFallback(dataPathSignal)
+	}
+}
+
+// sendsDataOverSignal returns true when data packets of kind are sent to the participant over the
+// signal connection rather than its data channels
+func (p *ParticipantImpl) sendsDataOverSignal(kind livekit.DataPacket_Kind) bool {
+	return p.params.SignalOnly || (kind == livekit.DataPacket_RELIABLE && p.dataOverSignal.Get())
+}
+
+func (p *ParticipantImpl) sendSignalData(dp *livekit.DataPacket) error {
+	msg, err := NewSignalDataResponse(dp)
+	if err != nil {
+		return err
+	}
+	prometheus.IncrementDataPacketOverSignal(dp.Kind.String())
+	return p.writeMessage(msg)
+}
+
+func isDataChannelOpen(dc *webrtc.DataChannel) bool {
+	return dc != nil && dc.ReadyState() == webrtc.DataChannelStateOpen
+}
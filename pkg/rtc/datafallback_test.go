@@ -0,0 +1,97 @@
+package rtc
+
+import (
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+func TestDataOverSignalFallback(t *testing.T) {
+	reliable := &livekit.DataPacket{
+		Kind:  livekit.DataPacket_RELIABLE,
+		Value: &livekit.DataPacket_User{User: &livekit.UserPacket{Payload: []byte("hello")}},
+	}
+	lossy := &livekit.DataPacket{
+		Kind:  livekit.DataPacket_LOSSY,
+		Value: &livekit.DataPacket_User{User: &livekit.UserPacket{Payload: []byte("hello")}},
+	}
+
+	t.Run("reliable data falls back once the data channel didn't open", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		sink := p.params.Sink.(*routingfakes.FakeMessageSink)
+		p.updateState(livekit.ParticipantInfo_ACTIVE)
+		require.ErrorIs(t, p.SendDataPacket(reliable), ErrDataChannelUnavailable)
+
+		// the subscriber data channels were created again already
+		p.subscriberDCRetried.TrySet(true)
+		p.checkDataChannels()
+		require.NoError(t, p.SendDataPacket(reliable))
+		require.Equal(t, 1, sink.WriteMessageCallCount())
+		dp, err := SignalResponseDataPacket(sink.WriteMessageArgsForCall(0).(*livekit.SignalResponse))
+		require.NoError(t, err)
+		require.Equal(t, []byte("hello"), dp.GetUser().Payload)
+
+		// lossy data isn't worth the signal connection
+		require.ErrorIs(t, p.SendDataPacket(lossy), ErrDataChannelUnavailable)
+		require.Equal(t, 1, sink.WriteMessageCallCount())
+	})
+
+	t.Run("subscriber data channels are created again before falling back", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		defer p.Close()
+		p.updateState(livekit.ParticipantInfo_ACTIVE)
+		require.True(t, p.SubscriberAsPrimary())
+		require.Equal(t, dataPathSubscriber, p.DebugInfo()["DataPath"])
+
+		dc := p.reliableDataChannel()
+		require.NotNil(t, dc)
+		p.checkDataChannels()
+		require.NotSame(t, dc, p.reliableDataChannel())
+		require.False(t, p.sendsDataOverSignal(livekit.DataPacket_RELIABLE))
+
+		// they didn't open either, and there are no publisher data channels
+		p.checkDataChannels()
+		require.True(t, p.sendsDataOverSignal(livekit.DataPacket_RELIABLE))
+		require.Equal(t, dataPathSignal, p.DebugInfo()["DataPath"])
+	})
+
+	t.Run("data moves to the publisher data channels", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.dataOnPublisher.TrySet(true)
+		require.Nil(t, p.reliableDataChannel())
+		require.Nil(t, p.lossyDataChannel())
+		require.Equal(t, dataPathPublisher, p.DebugInfo()["DataPath"])
+	})
+
+	t.Run("clients that send reliable data over signal receive it there", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		var received []*livekit.DataPacket
+		p.OnDataPacket(func(_ types.Participant, dp *livekit.DataPacket) {
+			received = append(received, dp)
+		})
+
+		req, err := NewSignalDataRequest(lossy)
+		require.NoError(t, err)
+		p.HandleSignalData(SignalRequestData(req))
+		require.Empty(t, received)
+		require.False(t, p.sendsDataOverSignal(livekit.DataPacket_RELIABLE))
+
+		req, err = NewSignalDataRequest(reliable)
+		require.NoError(t, err)
+		p.HandleSignalData(SignalRequestData(req))
+		require.Len(t, received, 1)
+		require.True(t, p.sendsDataOverSignal(livekit.DataPacket_RELIABLE))
+		require.False(t, p.sendsDataOverSignal(livekit.DataPacket_LOSSY))
+	})
+
+	t.Run("closed participants don't fall back", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		require.NoError(t, p.Close())
+		p.checkDataChannels()
+		require.False(t, p.sendsDataOverSignal(livekit.DataPacket_RELIABLE))
+	})
+}
@@ -0,0 +1,106 @@
+package rtc
+
+import (
+	"sync"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// dataRateLimiter limits the user data packets a participant can publish, separately for each kind,
+// and the bytes published across both kinds
+type dataRateLimiter struct {
+	mu       sync.Mutex
+	reliable *tokenBucket
+	lossy    *tokenBucket
+	bytes    *tokenBucket
+}
+
+func newDataRateLimiter(conf config.DataRateLimitConfig) *dataRateLimiter {
+	return &dataRateLimiter{
+		reliable: newTokenBucket(conf.Reliable),
+		lossy:    newTokenBucket(conf.Lossy),
+		bytes:    newTokenBucket(conf.Bytes),
+	}
+}
+
+// setConfig replaces the limits, the new buckets start out full
+func (l *dataRateLimiter) setConfig(conf config.DataRateLimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.reliable = newTokenBucket(conf.Reliable)
+	l.lossy = newTokenBucket(conf.Lossy)
+	l.bytes = newTokenBucket(conf.Bytes)
+}
+
+func (l *dataRateLimiter) allow(kind livekit.DataPacket_Kind, size int) bool {
+	bucket := l.reliable
+	if kind == livekit.DataPacket_LOSSY {
+		bucket = l.lossy
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	// a packet rejected by either limit doesn't use up tokens of the other
+	if (bucket != nil && !bucket.available(now, 1)) || (l.bytes != nil && !l.bytes.available(now, float64(size))) {
+		return false
+	}
+	if bucket != nil {
+		bucket.take(now)
+	}
+	if l.bytes != nil {
+		l.bytes.takeN(now, float64(size))
+	}
+	return true
+}
+
+type tokenBucket struct {
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+// newTokenBucket returns nil when there's no limit
+func newTokenBucket(conf config.RateLimitConfig) *tokenBucket {
+	if conf.Rate <= 0 {
+		return nil
+	}
+	capacity := float64(conf.Burst)
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &tokenBucket{
+		rate:     conf.Rate,
+		capacity: capacity,
+		tokens:   capacity,
+		last:     time.Now(),
+	}
+}
+
+func (b *tokenBucket) take(now time.Time) bool {
+	return b.takeN(now, 1)
+}
+
+func (b *tokenBucket) takeN(now time.Time, n float64) bool {
+	if !b.available(now, n) {
+		return false
+	}
+	b.tokens -= n
+	return true
+}
+
+// available refills the bucket and returns whether n tokens can be taken
+func (b *tokenBucket) available(now time.Time, n float64) bool {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+		b.last = now
+	}
+	return b.tokens >= n
+}
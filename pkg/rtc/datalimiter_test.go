@@ -0,0 +1,53 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestTokenBucket(t *testing.T) {
+	require.Nil(t, newTokenBucket(config.RateLimitConfig{}))
+
+	b := newTokenBucket(config.RateLimitConfig{Rate: 10, Burst: 3})
+	now := b.last
+	for i := 0; i < 3; i++ {
+		require.True(t, b.take(now))
+	}
+	require.False(t, b.take(now))
+
+	// refills at rate
+	now = now.Add(100 * time.Millisecond)
+	require.True(t, b.take(now))
+	require.False(t, b.take(now))
+
+	// up to burst
+	now = now.Add(10 * time.Second)
+	for i := 0; i < 3; i++ {
+		require.True(t, b.take(now))
+	}
+	require.False(t, b.take(now))
+}
+
+func TestDataRateLimiterBytes(t *testing.T) {
+	l := newDataRateLimiter(config.DataRateLimitConfig{
+		Reliable: config.RateLimitConfig{Rate: 1, Burst: 2},
+		Bytes:    config.RateLimitConfig{Rate: 1, Burst: 1000},
+	})
+
+	// too large for what's left of the byte budget
+	require.True(t, l.allow(livekit.DataPacket_RELIABLE, 800))
+	require.False(t, l.allow(livekit.DataPacket_RELIABLE, 800))
+
+	// the rejected packet didn't use up a message token
+	require.True(t, l.allow(livekit.DataPacket_RELIABLE, 100))
+	require.False(t, l.allow(livekit.DataPacket_RELIABLE, 10))
+
+	// byte limit applies across kinds
+	require.True(t, l.allow(livekit.DataPacket_LOSSY, 50))
+	require.False(t, l.allow(livekit.DataPacket_LOSSY, 100))
+}
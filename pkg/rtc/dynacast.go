@@ -0,0 +1,92 @@
+package rtc
+
+import (
+	livekit "github.com/livekit/protocol/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+// subscribedQualityField carries the simulcast layers subscribers of a track receive, sent to
+// publishers that declared the dynacast capability
+const subscribedQualityField protowire.Number = 1007
+
+// SubscribedQualityUpdate tells a publisher up to which simulcast layer the subscribers of its
+// track receive, it can pause the layers above
+type SubscribedQualityUpdate struct {
+	TrackSid string
+	// highest layer received, sfu.InvalidSpatialLayer when no subscriber receives the track
+	MaxLayer int32
+}
+
+// NewSubscribedQualityResponse returns a signal response telling the publisher of a track which
+// layers are received
+func NewSubscribedQualityResponse(update *SubscribedQualityUpdate) *livekit.SignalResponse {
+	b := protowire.AppendTag(nil, 1, protowire.BytesType)
+	b = protowire.AppendString(b, update.TrackSid)
+	// shifted, the varint can't be negative
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(update.MaxLayer-sfu.InvalidSpatialLayer))
+	msg := &livekit.SignalResponse{}
+	setUnknownField(msg.ProtoReflect(), subscribedQualityField, bytesField(subscribedQualityField, b))
+	return msg
+}
+
+// SignalResponseSubscribedQuality returns the layers a signal response tells a publisher are
+// received, nil when it's another response
+func SignalResponseSubscribedQuality(msg *livekit.SignalResponse) *SubscribedQualityUpdate {
+	b := unknownBytesField(msg.ProtoReflect().GetUnknown(), subscribedQualityField)
+	if b == nil {
+		return nil
+	}
+	update := &SubscribedQualityUpdate{MaxLayer: sfu.InvalidSpatialLayer}
+	ok := decodeFields(b, func(num protowire.Number, s string, v uint64) {
+		switch num {
+		case 1:
+			update.TrackSid = s
+		case 2:
+			if v <= uint64(spatialLayerForQuality(livekit.VideoQuality_HIGH)-sfu.InvalidSpatialLayer) {
+				update.MaxLayer = int32(v) + sfu.InvalidSpatialLayer
+			}
+		}
+	})
+	if !ok {
+		return nil
+	}
+	return update
+}
+
+// OnSubscribedLayerChanged is called with the highest layer subscribers of the video track receive
+// when it changed, sfu.InvalidSpatialLayer once none receives it
+func (t *MediaTrack) OnSubscribedLayerChanged(fn func(maxLayer int32)) {
+	t.dynacastLock.Lock()
+	defer t.dynacastLock.Unlock()
+	t.onSubscribedLayerChanged = fn
+}
+
+// updateSubscribedLayer finds the highest layer subscribers receive, and tells the publisher when
+// it changed. Subscribers that disabled the track don't count
+func (t *MediaTrack) updateSubscribedLayer() {
+	if t.Kind() != livekit.TrackType_VIDEO {
+		return
+	}
+	t.dynacastLock.Lock()
+	defer t.dynacastLock.Unlock()
+
+	maxLayer := int32(sfu.InvalidSpatialLayer)
+	t.lock.RLock()
+	for _, subTrack := range t.subscribedTracks {
+		if layer := subTrack.SubscribedLayer(); layer > maxLayer {
+			maxLayer = layer
+		}
+	}
+	t.lock.RUnlock()
+
+	if maxLayer == t.subscribedLayer {
+		return
+	}
+	t.subscribedLayer = maxLayer
+	if t.onSubscribedLayerChanged != nil {
+		t.onSubscribedLayerChanged(maxLayer)
+	}
+}
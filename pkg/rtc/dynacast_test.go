@@ -0,0 +1,91 @@
+package rtc
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+func TestSubscribedQualityMessages(t *testing.T) {
+	for _, layer := range []int32{sfu.InvalidSpatialLayer, 0, 2} {
+		res := NewSubscribedQualityResponse(&SubscribedQualityUpdate{TrackSid: "TR_a", MaxLayer: layer})
+		require.Nil(t, res.Message)
+		data, err := proto.Marshal(res)
+		require.NoError(t, err)
+		received := &livekit.SignalResponse{}
+		require.NoError(t, proto.Unmarshal(data, received))
+		require.Equal(t, &SubscribedQualityUpdate{TrackSid: "TR_a", MaxLayer: layer}, SignalResponseSubscribedQuality(received))
+	}
+	require.Nil(t, SignalResponseSubscribedQuality(&livekit.SignalResponse{}))
+}
+
+func TestMediaTrackSubscribedLayer(t *testing.T) {
+	mt := &MediaTrack{
+		params:           MediaTrackParams{TrackInfo: &livekit.TrackInfo{Sid: "TR_a", Type: livekit.TrackType_VIDEO}},
+		subscribedTracks: make(map[string]*SubscribedTrack),
+		subscribedLayer:  spatialLayerForQuality(livekit.VideoQuality_HIGH),
+	}
+	var lock sync.Mutex
+	var changes []int32
+	mt.OnSubscribedLayerChanged(func(maxLayer int32) {
+		lock.Lock()
+		defer lock.Unlock()
+		changes = append(changes, maxLayer)
+	})
+	receivedChanges := func() []int32 {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]int32{}, changes...)
+	}
+	subscribe := func(t *testing.T, subID string, quality livekit.VideoQuality) *SubscribedTrack {
+		dt, err := sfu.NewDownTrack(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}, &stubTrackReceiver{trackID: "TR_a"}, nil, subID, 500)
+		require.NoError(t, err)
+		st := NewSubscribedTrack(&typesfakes.FakePublishedTrack{}, "pub", dt, "")
+		st.SetMaxQuality(quality)
+		st.OnSubscribedLayerChanged(func() {
+			go mt.updateSubscribedLayer()
+		})
+		mt.lock.Lock()
+		mt.subscribedTracks[subID] = st
+		mt.lock.Unlock()
+		mt.updateSubscribedLayer()
+		return st
+	}
+
+	// publishers send all layers until someone receives less
+	thumbnail := subscribe(t, "PA_a", livekit.VideoQuality_LOW)
+	require.Equal(t, []int32{0}, receivedChanges())
+	subscribe(t, "PA_b", livekit.VideoQuality_MEDIUM)
+	require.Equal(t, []int32{0, 1}, receivedChanges())
+	// below the highest layer received, nothing changes for the publisher
+	thumbnail.SetMaxQuality(livekit.VideoQuality_MEDIUM)
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, []int32{0, 1}, receivedChanges())
+
+	thumbnail.SetMaxQuality(livekit.VideoQuality_HIGH)
+	require.Eventually(t, func() bool {
+		return len(receivedChanges()) == 3
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, []int32{0, 1, 2}, receivedChanges())
+
+	// disabled tracks don't count, the others are received up to their layer
+	thumbnail.UpdateSubscriberSettings(false, livekit.VideoQuality_HIGH)
+	require.Eventually(t, func() bool {
+		return len(receivedChanges()) == 4
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, []int32{0, 1, 2, 1}, receivedChanges())
+
+	mt.lock.Lock()
+	delete(mt.subscribedTracks, "PA_b")
+	mt.lock.Unlock()
+	mt.updateSubscribedLayer()
+	require.Equal(t, []int32{0, 1, 2, 1, sfu.InvalidSpatialLayer}, receivedChanges())
+}
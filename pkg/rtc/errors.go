@@ -6,9 +6,26 @@ var (
 	ErrRoomClosed              = errors.New("room has already closed")
 	ErrPermissionDenied        = errors.New("no permissions to access the room")
 	ErrMaxParticipantsExceeded = errors.New("room has exceeded its max participants")
-	ErrLimitExceeded           = errors.New("node has exceeded its configured limit")
+	ErrRoomLocked              = errors.New("room is locked")
 	ErrAlreadyJoined           = errors.New("a participant with the same identity is already in the room")
 	ErrUnexpectedOffer         = errors.New("expected answer SDP, received offer")
 	ErrDataChannelUnavailable  = errors.New("data channel is not available")
+	ErrDataChannelBufferFull   = errors.New("data channel buffer is full")
+	ErrDataChannelCongested    = errors.New("data channel is congested")
+	ErrCannotPublish           = errors.New("participant does not have permission to publish")
+	ErrCannotPublishSource     = errors.New("participant does not have permission to publish from this source")
 	ErrCannotSubscribe         = errors.New("participant does not have permission to subscribe")
+	ErrSubscriptionLimit       = errors.New("participant has reached its subscription limit")
+	ErrSubscriptionBlocked     = errors.New("participant is blocked from subscribing to the publisher")
+	ErrCannotSubscribeTo       = errors.New("participant does not have permission to subscribe to the publisher")
+	ErrTrackNotFound           = errors.New("track does not exist")
+	ErrPendingTrackNotFound    = errors.New("track was not added before its media arrived, or it expired")
+	ErrNoPublisher             = errors.New("participant does not publish, it has no publisher transport")
+	ErrNoSubscriber            = errors.New("participant is signal only, it has no subscriber transport")
+	ErrNothingToRollBack       = errors.New("offer can't be rolled back, nothing was negotiated before it")
+	ErrInvalidAttribute        = errors.New("attribute keys can't be empty, keys and values are limited to 128 and 4096 bytes")
+	ErrReservedAttribute       = errors.New("attribute is set by the server, it can't be updated")
+	ErrTooManyAttributes       = errors.New("participants can have at most 64 attributes")
+	ErrInvalidName             = errors.New("participant names are limited to 256 bytes")
+	ErrInvalidTrackMetadata    = errors.New("track metadata is limited to 4096 bytes")
 )
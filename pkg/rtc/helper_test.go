@@ -14,7 +14,9 @@ func newMockParticipant(identity string, protocol types.ProtocolVersion, hidden
 	p.IdentityReturns(identity)
 	p.StateReturns(livekit.ParticipantInfo_JOINED)
 	p.ProtocolVersionReturns(protocol)
+	p.CapabilitiesReturns(protocol.Capabilities())
 	p.CanSubscribeReturns(true)
+	p.CanSubscribeToReturns(true)
 	p.CanPublishReturns(!hidden)
 	p.CanPublishDataReturns(!hidden)
 	p.HiddenReturns(hidden)
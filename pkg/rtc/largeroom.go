@@ -0,0 +1,220 @@
+package rtc
+
+import (
+	"sort"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	// participantPageField carries requests for a page of the room's participants, and the
+	// participant updates answering them
+	participantPageField protowire.Number = 1003
+	// largeRoomField flags the room info of join responses that list a page of the participants
+	largeRoomField protowire.Number = 1004
+)
+
+// ParticipantPageRequest asks for a page of the room's participants, ordered by sid. Clients of
+// large rooms fetch the participants their join response left out with it
+type ParticipantPageRequest struct {
+	// echoed in the page
+	RequestID string
+	// sid of the last participant of the previous page, empty for the first one
+	After string
+	// max participants in the page, the room's page size when 0 or larger
+	Limit int
+}
+
+// ParticipantPage answers a ParticipantPageRequest
+type ParticipantPage struct {
+	RequestID    string
+	Participants []*livekit.ParticipantInfo
+	// After of the request for the next page, empty on the last page
+	Next string
+}
+
+// NewParticipantPageRequest returns a signal request routing req to the node hosting the room
+func NewParticipantPageRequest(req *ParticipantPageRequest) *livekit.SignalRequest {
+	b := protowire.AppendTag(nil, 1, protowire.BytesType)
+	b = protowire.AppendString(b, req.RequestID)
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendString(b, req.After)
+	if req.Limit > 0 {
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(req.Limit))
+	}
+	msg := &livekit.SignalRequest{}
+	setUnknownField(msg.ProtoReflect(), participantPageField, bytesField(participantPageField, b))
+	return msg
+}
+
+// SignalRequestParticipantPage returns the page a signal request asks for, nil when it's another
+// request
+func SignalRequestParticipantPage(req *livekit.SignalRequest) *ParticipantPageRequest {
+	b := unknownBytesField(req.ProtoReflect().GetUnknown(), participantPageField)
+	if b == nil {
+		return nil
+	}
+	page := &ParticipantPageRequest{}
+	ok := decodeFields(b, func(num protowire.Number, s string, v uint64) {
+		switch num {
+		case 1:
+			page.RequestID = s
+		case 2:
+			page.After = s
+		case 3:
+			if v <= uint64(^uint32(0)>>1) {
+				page.Limit = int(v)
+			}
+		}
+	})
+	if !ok {
+		return nil
+	}
+	return page
+}
+
+// NewParticipantPageResponse returns a participant update with the page, sent to the participant
+// that asked for it
+func NewParticipantPageResponse(page *ParticipantPage) *livekit.SignalResponse {
+	b := protowire.AppendTag(nil, 1, protowire.BytesType)
+	b = protowire.AppendString(b, page.RequestID)
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendString(b, page.Next)
+	msg := &livekit.SignalResponse{
+		Message: &livekit.SignalResponse_Update{
+			Update: &livekit.ParticipantUpdate{
+				Participants: page.Participants,
+			},
+		},
+	}
+	setUnknownField(msg.ProtoReflect(), participantPageField, bytesField(participantPageField, b))
+	return msg
+}
+
+// SignalResponseParticipantPage returns the page a signal response answers a request with, nil
+// when it's another response
+func SignalResponseParticipantPage(msg *livekit.SignalResponse) *ParticipantPage {
+	b := unknownBytesField(msg.ProtoReflect().GetUnknown(), participantPageField)
+	if b == nil {
+		return nil
+	}
+	page := &ParticipantPage{Participants: msg.GetUpdate().GetParticipants()}
+	ok := decodeFields(b, func(num protowire.Number, s string, _ uint64) {
+		switch num {
+		case 1:
+			page.RequestID = s
+		case 2:
+			page.Next = s
+		}
+	})
+	if !ok {
+		return nil
+	}
+	return page
+}
+
+// IsLargeRoom returns true when the room info is of a large room, its join response lists a page of
+// the participants. The room's num_participants tells how many there are
+func IsLargeRoom(room *livekit.Room) bool {
+	return unknownBoolField(room.ProtoReflect().GetUnknown(), largeRoomField)
+}
+
+// isLarge returns true when the room has enough participants to only send pages of them. The
+// caller holds r.lock
+func (r *Room) isLarge() bool {
+	if r.roomConfig == nil {
+		return false
+	}
+	threshold := r.roomConfig.LargeRoom.ParticipantThreshold
+	return threshold > 0 && int(r.Room.NumParticipants) >= threshold
+}
+
+// firstParticipantPage returns the room info and participants of the join response of a large
+// room, publishers first
+func (r *Room) firstParticipantPage(participants []*livekit.ParticipantInfo) (*livekit.Room, []*livekit.ParticipantInfo) {
+	sort.SliceStable(participants, func(i, j int) bool {
+		return len(participants[i].GetTracks()) > 0 && len(participants[j].GetTracks()) == 0
+	})
+	if size := r.roomConfig.LargeRoom.PageSize(); len(participants) > size {
+		participants = participants[:size]
+	}
+	roomInfo := proto.Clone(r.Room).(*livekit.Room)
+	setUnknownField(roomInfo.ProtoReflect(), largeRoomField, boolField(largeRoomField))
+	return roomInfo, participants
+}
+
+// SendParticipantPage sends p the page of the other participants req asks for
+func (r *Room) SendParticipantPage(p types.Participant, req *ParticipantPageRequest) error {
+	participants := r.participantsVisibleTo(p, r.GetParticipants())
+	sort.Slice(participants, func(i, j int) bool {
+		return participants[i].ID() < participants[j].ID()
+	})
+
+	limit := config.DefaultParticipantPageSize
+	if r.roomConfig != nil {
+		limit = r.roomConfig.LargeRoom.PageSize()
+	}
+	if req.Limit > 0 && req.Limit < limit {
+		limit = req.Limit
+	}
+
+	page := &ParticipantPage{RequestID: req.RequestID, Participants: make([]*livekit.ParticipantInfo, 0, limit)}
+	last := ""
+	for _, op := range participants {
+		if op.ID() == p.ID() || op.Hidden() || op.ID() <= req.After {
+			continue
+		}
+		if len(page.Participants) == limit {
+			page.Next = last
+			break
+		}
+		page.Participants = append(page.Participants, op.ToProto())
+		last = op.ID()
+	}
+	return p.SendParticipantPage(page.RequestID, page.Participants, page.Next)
+}
+
+// sendParticipantUpdate sends op the updates, split when they list more participants than an update
+// may
+func (r *Room) sendParticipantUpdate(op types.Participant, updates []*livekit.ParticipantInfo, updatedAt time.Time) error {
+	max := 0
+	if r.roomConfig != nil {
+		max = r.roomConfig.LargeRoom.MaxParticipantsPerUpdate
+	}
+	for max > 0 && len(updates) > max {
+		if err := op.SendParticipantUpdate(updates[:max], updatedAt); err != nil {
+			return err
+		}
+		updates = updates[max:]
+	}
+	return op.SendParticipantUpdate(updates, updatedAt)
+}
+
+// sentParticipantInfo is the state participants were last sent about a participant
+type sentParticipantInfo struct {
+	info *livekit.ParticipantInfo
+	// whether the participant itself was sent it too
+	toSource bool
+}
+
+// unchangedUpdate returns true when large rooms can drop an update with info, participants were
+// last sent the same. skipSource when the participant itself is left out. The caller holds r.lock
+func (r *Room) unchangedUpdate(info *livekit.ParticipantInfo, skipSource bool) bool {
+	last := r.sentInfos[info.GetSid()]
+	if last != nil && (last.toSource || skipSource) && r.isLarge() && proto.Equal(last.info, info) {
+		return true
+	}
+	if r.sentInfos == nil {
+		r.sentInfos = make(map[string]*sentParticipantInfo)
+	}
+	// track infos are updated in place, what was sent is kept as it was
+	r.sentInfos[info.GetSid()] = &sentParticipantInfo{info: proto.Clone(info).(*livekit.ParticipantInfo), toSource: !skipSource}
+	return false
+}
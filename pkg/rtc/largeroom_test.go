@@ -0,0 +1,194 @@
+package rtc_test
+
+import (
+	"testing"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/testutils"
+)
+
+func TestParticipantPageMessages(t *testing.T) {
+	req := rtc.NewParticipantPageRequest(&rtc.ParticipantPageRequest{RequestID: "1", After: "PA_a", Limit: 20})
+	require.Nil(t, req.Message)
+	data, err := proto.Marshal(req)
+	require.NoError(t, err)
+	received := &livekit.SignalRequest{}
+	require.NoError(t, proto.Unmarshal(data, received))
+	require.Equal(t, &rtc.ParticipantPageRequest{RequestID: "1", After: "PA_a", Limit: 20}, rtc.SignalRequestParticipantPage(received))
+	require.Nil(t, rtc.SignalRequestParticipantPage(&livekit.SignalRequest{}))
+
+	page := &rtc.ParticipantPage{
+		RequestID:    "1",
+		Participants: []*livekit.ParticipantInfo{{Sid: "PA_b", Identity: "b"}},
+		Next:         "PA_b",
+	}
+	res := rtc.NewParticipantPageResponse(page)
+	// clients that don't know pages still apply it as an update
+	require.Len(t, res.GetUpdate().Participants, 1)
+	sent := rtc.SignalResponseParticipantPage(res)
+	require.Equal(t, "1", sent.RequestID)
+	require.Equal(t, "PA_b", sent.Next)
+	require.Equal(t, "b", sent.Participants[0].Identity)
+	require.Nil(t, rtc.SignalResponseParticipantPage(&livekit.SignalResponse{}))
+}
+
+func TestLargeRooms(t *testing.T) {
+	newLargeRoom := func(t *testing.T) *rtc.Room {
+		rm := newRoomWithParticipants(t, testRoomOpts{
+			num:       5,
+			protocol:  types.DefaultProtocol,
+			largeRoom: config.LargeRoomConfig{ParticipantThreshold: 4, MaxParticipantsPerUpdate: 2},
+		})
+		for _, p := range rm.GetParticipants() {
+			fp := p.(*typesfakes.FakeParticipant)
+			fp.ToProtoReturns(&livekit.ParticipantInfo{Sid: fp.ID(), Identity: fp.Identity()})
+		}
+		return rm
+	}
+
+	t.Run("join response lists a page, publishers first", func(t *testing.T) {
+		rm := newLargeRoom(t)
+		publisher := rm.GetParticipant("p4").(*typesfakes.FakeParticipant)
+		publisher.ToProtoReturns(&livekit.ParticipantInfo{Sid: publisher.ID(), Tracks: []*livekit.TrackInfo{{Sid: "TR_a"}}})
+
+		pNew := newMockParticipant("new", types.DefaultProtocol, false)
+		require.NoError(t, rm.Join(pNew, &rtc.ParticipantOptions{}, iceServersForRoom))
+		roomInfo, others, _ := pNew.SendJoinResponseArgsForCall(0)
+		require.True(t, rtc.IsLargeRoom(roomInfo))
+		require.False(t, rtc.IsLargeRoom(rm.Room))
+		require.EqualValues(t, 6, roomInfo.NumParticipants)
+		require.Len(t, others, 2)
+		require.Equal(t, publisher.ID(), others[0].Sid)
+	})
+
+	t.Run("small rooms list everyone", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{
+			num:       2,
+			protocol:  types.DefaultProtocol,
+			largeRoom: config.LargeRoomConfig{ParticipantThreshold: 4},
+		})
+		pNew := newMockParticipant("new", types.DefaultProtocol, false)
+		require.NoError(t, rm.Join(pNew, &rtc.ParticipantOptions{}, iceServersForRoom))
+		roomInfo, others, _ := pNew.SendJoinResponseArgsForCall(0)
+		require.False(t, rtc.IsLargeRoom(roomInfo))
+		require.Len(t, others, 2)
+	})
+
+	t.Run("participants are fetched a page at a time", func(t *testing.T) {
+		rm := newLargeRoom(t)
+		p := rm.GetParticipant("p0").(*typesfakes.FakeParticipant)
+
+		var fetched []string
+		after := ""
+		for i := 0; ; i++ {
+			require.NoError(t, rm.SendParticipantPage(p, &rtc.ParticipantPageRequest{RequestID: "r", After: after}))
+			requestID, participants, next := p.SendParticipantPageArgsForCall(i)
+			require.Equal(t, "r", requestID)
+			require.LessOrEqual(t, len(participants), 2)
+			for _, pi := range participants {
+				fetched = append(fetched, pi.Sid)
+			}
+			if next == "" {
+				break
+			}
+			after = next
+		}
+		// everyone but itself, once
+		require.Len(t, fetched, 4)
+		require.NotContains(t, fetched, p.ID())
+		require.IsIncreasing(t, fetched)
+
+		require.NoError(t, rm.SendParticipantPage(p, &rtc.ParticipantPageRequest{Limit: 1}))
+		_, participants, next := p.SendParticipantPageArgsForCall(p.SendParticipantPageCallCount() - 1)
+		require.Len(t, participants, 1)
+		require.Equal(t, participants[0].Sid, next)
+	})
+
+	t.Run("updates are split", func(t *testing.T) {
+		rm := newLargeRoom(t)
+		p := rm.GetParticipant("p0").(*typesfakes.FakeParticipant)
+		updates := p.SendParticipantUpdateCallCount()
+		require.NoError(t, rm.ResumeParticipant(p, nil))
+		require.Equal(t, updates+3, p.SendParticipantUpdateCallCount())
+		for i := updates; i < updates+3; i++ {
+			participants, _ := p.SendParticipantUpdateArgsForCall(i)
+			require.LessOrEqual(t, len(participants), 2)
+		}
+	})
+
+	t.Run("unchanged participants aren't sent again", func(t *testing.T) {
+		rm := newLargeRoom(t)
+		source := rm.GetParticipant("p1").(*typesfakes.FakeParticipant)
+		op := rm.GetParticipant("p0").(*typesfakes.FakeParticipant)
+		onStateChange := source.OnStateChangeArgsForCall(0)
+
+		updates := op.SendParticipantUpdateCallCount()
+		onStateChange(source, livekit.ParticipantInfo_JOINED)
+		require.Equal(t, updates+1, op.SendParticipantUpdateCallCount())
+		onStateChange(source, livekit.ParticipantInfo_JOINED)
+		require.Equal(t, updates+1, op.SendParticipantUpdateCallCount())
+
+		source.ToProtoReturns(&livekit.ParticipantInfo{Sid: source.ID(), Identity: source.Identity(), Metadata: "changed"})
+		onStateChange(source, livekit.ParticipantInfo_JOINED)
+		require.Equal(t, updates+2, op.SendParticipantUpdateCallCount())
+	})
+
+	t.Run("tracks updated in place are sent again", func(t *testing.T) {
+		rm := newLargeRoom(t)
+		source := rm.GetParticipant("p1").(*typesfakes.FakeParticipant)
+		op := rm.GetParticipant("p0").(*typesfakes.FakeParticipant)
+		onStateChange := source.OnStateChangeArgsForCall(0)
+		track := &livekit.TrackInfo{Sid: "TR_webcam"}
+		source.ToProtoReturns(&livekit.ParticipantInfo{Sid: source.ID(), Identity: source.Identity(), Tracks: []*livekit.TrackInfo{track}})
+
+		updates := op.SendParticipantUpdateCallCount()
+		onStateChange(source, livekit.ParticipantInfo_JOINED)
+		require.Equal(t, updates+1, op.SendParticipantUpdateCallCount())
+		// like media tracks do
+		track.Muted = true
+		onStateChange(source, livekit.ParticipantInfo_JOINED)
+		require.Equal(t, updates+2, op.SendParticipantUpdateCallCount())
+	})
+
+	t.Run("batched updates are split and skip unchanged participants", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{
+			num:            5,
+			protocol:       types.DefaultProtocol,
+			updateInterval: 10 * time.Millisecond,
+			largeRoom:      config.LargeRoomConfig{ParticipantThreshold: 4, MaxParticipantsPerUpdate: 2},
+		})
+		defer rm.Close()
+		var participants []*typesfakes.FakeParticipant
+		for _, p := range rm.GetParticipants() {
+			fp := p.(*typesfakes.FakeParticipant)
+			fp.ToProtoReturns(&livekit.ParticipantInfo{Sid: fp.ID(), Identity: fp.Identity()})
+			participants = append(participants, fp)
+		}
+		op := rm.GetParticipant("p0").(*typesfakes.FakeParticipant)
+		for _, p := range participants {
+			if p != op {
+				p.OnStateChangeArgsForCall(0)(p, livekit.ParticipantInfo_JOINED)
+			}
+		}
+		// four participants in updates of two
+		testutils.WithTimeout(t, "participant should receive split updates", func() bool {
+			return op.SendParticipantUpdateCallCount() == 2
+		})
+
+		for _, p := range participants {
+			if p != op {
+				p.OnStateChangeArgsForCall(0)(p, livekit.ParticipantInfo_JOINED)
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		require.Equal(t, 2, op.SendParticipantUpdateCallCount())
+	})
+}
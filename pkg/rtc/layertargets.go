@@ -0,0 +1,158 @@
+package rtc
+
+import (
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// qualities of simulcast layers, low to high
+var simulcastLayerQualities = []livekit.VideoQuality{
+	livekit.VideoQuality_LOW,
+	livekit.VideoQuality_MEDIUM,
+	livekit.VideoQuality_HIGH,
+}
+
+// layerTargetsMessage tells a publisher the bitrate to encode each layer of its simulcast tracks at.
+// Layers with a target of 0 aren't received by anyone, or aren't allowed by the room policy, and
+// can be paused
+type layerTargetsMessage struct {
+	Type   string               `json:"type"`
+	Tracks []*trackLayerTargets `json:"tracks"`
+}
+
+type trackLayerTargets struct {
+	TrackSid string         `json:"track_sid"`
+	Layers   []*layerTarget `json:"layers"`
+}
+
+type layerTarget struct {
+	Quality string `json:"quality"`
+	Bitrate uint64 `json:"bitrate"`
+}
+
+func newLayerTargetsPacket(targets []*trackLayerTargets) (*livekit.DataPacket, error) {
+	return newServerMessagePacket(&layerTargetsMessage{
+		Type:   layerTargetsMessageType,
+		Tracks: targets,
+	})
+}
+
+// layerBitrateTargets returns the target bitrate of each layer, low to high. Layers above the
+// policy's max_simulcast_layers or above maxSubscribed, the highest layer that subscribers want,
+// are turned off. The low layer is kept on, so new subscribers get video right away. The layers that
+// are on are scaled down to fit the policy's max_publish_bitrate
+func layerBitrateTargets(bitrates []uint64, policy config.RoomPolicy, maxSubscribed int32) []uint64 {
+	active := len(bitrates)
+	if policy.MaxSimulcastLayers > 0 && policy.MaxSimulcastLayers < active {
+		active = policy.MaxSimulcastLayers
+	}
+	if int(maxSubscribed)+1 < active {
+		active = int(maxSubscribed) + 1
+	}
+	if active < 1 {
+		active = 1
+	}
+
+	targets := make([]uint64, len(bitrates))
+	var total uint64
+	for i := 0; i < active; i++ {
+		targets[i] = bitrates[i]
+		total += bitrates[i]
+	}
+	if policy.MaxPublishBitrate != 0 && total > policy.MaxPublishBitrate {
+		for i := 0; i < active; i++ {
+			targets[i] = targets[i] * policy.MaxPublishBitrate / total
+		}
+	}
+	return targets
+}
+
+// maxSubscribedLayers returns the highest simulcast layer subscribers want of each video track they
+// receive, by track ID. Tracks that subscribers disabled don't count
+func maxSubscribedLayers(participants []types.Participant) map[string]int32 {
+	layers := make(map[string]int32)
+	for _, p := range participants {
+		for _, st := range p.GetSubscribedTracks() {
+			dt := st.DownTrack()
+			if dt == nil || dt.Kind() != webrtc.RTPCodecTypeVideo || st.IsMuted() {
+				continue
+			}
+			layer := dt.MaxSpatialLayer()
+			if current, ok := layers[st.ID()]; !ok || layer > current {
+				layers[st.ID()] = layer
+			}
+		}
+	}
+	return layers
+}
+
+// sendLayerTargets sends publishers the layer targets of their simulcast tracks that changed since
+// they were last sent, returning the targets sent so far, by track ID
+func (r *Room) sendLayerTargets(participants []types.Participant, lastSent map[string][]uint64) map[string][]uint64 {
+	subscribed := maxSubscribedLayers(participants)
+	policy := r.Policy()
+
+	sent := make(map[string][]uint64)
+	for _, p := range participants {
+		if !p.Capabilities().DataPackets || p.State() != livekit.ParticipantInfo_ACTIVE {
+			continue
+		}
+
+		var changed []*trackLayerTargets
+		for _, track := range p.GetPublishedTracks() {
+			info := track.ToProto()
+			if track.Kind() != livekit.TrackType_VIDEO || info == nil || !info.Simulcast {
+				continue
+			}
+			maxSubscribed, ok := subscribed[track.ID()]
+			if !ok {
+				maxSubscribed = -1
+			}
+			targets := layerBitrateTargets(r.roomConfig.LayerBitrates, policy, maxSubscribed)
+			sent[track.ID()] = targets
+			if equalBitrates(lastSent[track.ID()], targets) {
+				continue
+			}
+
+			trackTargets := &trackLayerTargets{TrackSid: track.ID()}
+			for i, bitrate := range targets {
+				trackTargets.Layers = append(trackTargets.Layers, &layerTarget{
+					Quality: simulcastLayerQualities[i].String(),
+					Bitrate: bitrate,
+				})
+			}
+			changed = append(changed, trackTargets)
+		}
+		if len(changed) == 0 {
+			continue
+		}
+
+		dp, err := newLayerTargetsPacket(changed)
+		if err == nil {
+			err = p.SendDataPacket(dp)
+		}
+		if err != nil {
+			r.Logger.Warnw("could not send layer targets", err, "participant", p.Identity())
+			// try again on the next update
+			for _, t := range changed {
+				sent[t.TrackSid] = lastSent[t.TrackSid]
+			}
+		}
+	}
+	return sent
+}
+
+func equalBitrates(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
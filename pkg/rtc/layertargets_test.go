@@ -0,0 +1,66 @@
+package rtc
+
+import (
+	"encoding/json"
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestLayerBitrateTargets(t *testing.T) {
+	bitrates := []uint64{150_000, 500_000, 1_500_000}
+
+	// all layers are subscribed to
+	require.Equal(t, bitrates, layerBitrateTargets(bitrates, config.RoomPolicy{}, 2))
+	// nobody wants the high layer
+	require.Equal(t, []uint64{150_000, 500_000, 0}, layerBitrateTargets(bitrates, config.RoomPolicy{}, 1))
+	// the low layer is kept on without subscribers
+	require.Equal(t, []uint64{150_000, 0, 0}, layerBitrateTargets(bitrates, config.RoomPolicy{}, -1))
+	// policy limits
+	require.Equal(t, []uint64{150_000, 500_000, 0}, layerBitrateTargets(bitrates, config.RoomPolicy{MaxSimulcastLayers: 2}, 2))
+	require.Equal(t, []uint64{60_000, 200_000, 600_000}, layerBitrateTargets(bitrates, config.RoomPolicy{MaxPublishBitrate: 860_000}, 2))
+}
+
+func TestSendLayerTargets(t *testing.T) {
+	track := &typesfakes.FakePublishedTrack{}
+	track.IDReturns("TR_camera")
+	track.KindReturns(livekit.TrackType_VIDEO)
+	track.ToProtoReturns(&livekit.TrackInfo{Sid: "TR_camera", Simulcast: true})
+	publisher := &typesfakes.FakeParticipant{}
+	publisher.IdentityReturns("publisher")
+	publisher.StateReturns(livekit.ParticipantInfo_ACTIVE)
+	publisher.CapabilitiesReturns(types.ProtocolVersion(3).Capabilities())
+	publisher.GetPublishedTracksReturns([]types.PublishedTrack{track})
+
+	r := &Room{
+		roomConfig: &config.RoomConfig{
+			LayerBitrateTargets: true,
+			LayerBitrates:       []uint64{150_000, 500_000, 1_500_000},
+		},
+		participants: map[string]types.Participant{"publisher": publisher},
+	}
+	participants := r.GetParticipants()
+
+	sent := r.sendLayerTargets(participants, nil)
+	require.Equal(t, 1, publisher.SendDataPacketCallCount())
+	var msg layerTargetsMessage
+	require.NoError(t, json.Unmarshal(publisher.SendDataPacketArgsForCall(0).GetUser().Payload, &msg))
+	require.Equal(t, layerTargetsMessageType, msg.Type)
+	require.Equal(t, []*trackLayerTargets{{
+		TrackSid: "TR_camera",
+		Layers: []*layerTarget{
+			{Quality: "LOW", Bitrate: 150_000},
+			{Quality: "MEDIUM"},
+			{Quality: "HIGH"},
+		},
+	}}, msg.Tracks)
+
+	// unchanged targets aren't sent again
+	r.sendLayerTargets(participants, sent)
+	require.Equal(t, 1, publisher.SendDataPacketCallCount())
+}
@@ -0,0 +1,30 @@
+package rtc
+
+import (
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+// withValues returns l with keysAndValues added to everything it logs, the default logger when l
+// isn't set
+func withValues(l logger.Logger, keysAndValues ...interface{}) logger.Logger {
+	lr := logr.Logger(l)
+	if lr.GetSink() == nil {
+		lr = logger.GetLogger()
+	}
+	return logger.Logger(lr.WithValues(keysAndValues...))
+}
+
+// sampled returns l logging each message at most once per interval, for warnings that would be
+// logged for every packet of a bad connection
+func sampled(l logger.Logger, interval time.Duration) logger.Logger {
+	lr := logr.Logger(l)
+	if lr.GetSink() == nil {
+		lr = logger.GetLogger()
+	}
+	return logger.Logger(sfu.NewSampledLogger(lr, interval))
+}
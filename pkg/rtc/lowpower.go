@@ -0,0 +1,87 @@
+package rtc
+
+import (
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// Low power modes a client can request when it joins, for devices that can't decode much video.
+// Audio is forwarded as usual in all modes
+const (
+	// video is capped to the lowest spatial layer
+	LowPowerModeLowestLayer = "lowest_layer"
+	// VP8 video is capped to the base temporal layer, halving the frame rate and bitrate of streams
+	// encoded with two temporal layers without switching spatial layers
+	LowPowerModeReducedFrameRate = "reduced_frame_rate"
+	// video is paused
+	LowPowerModeAudioOnly = "audio_only"
+
+	// attribute the low power mode of a participant is surfaced with
+	lowPowerModeAttribute = "low_power_mode"
+)
+
+// ParseLowPowerMode validates the low power mode hint of a client. Boolean values enable the
+// lowest layer mode or disable low power
+func ParseLowPowerMode(value string) (string, bool) {
+	switch value {
+	case "", "0", "false":
+		return "", true
+	case "1", "true", LowPowerModeLowestLayer:
+		return LowPowerModeLowestLayer, true
+	case LowPowerModeReducedFrameRate, LowPowerModeAudioOnly:
+		return value, true
+	default:
+		return "", false
+	}
+}
+
+// participantAttributes returns the attributes of p, with those the server derives, and their
+// version. The attributes are nil when it has none
+func participantAttributes(p types.Participant) (map[string]string, uint32) {
+	attributes, version := p.Attributes()
+	if len(attributes) == 0 {
+		attributes = nil
+	}
+	if mode := p.LowPowerMode(); mode != "" {
+		if attributes == nil {
+			attributes = make(map[string]string)
+		}
+		attributes[lowPowerModeAttribute] = mode
+	}
+	// ParticipantInfo only tells whether a participant is hidden, other kinds are attributes
+	if kind := p.Kind(); kind == ParticipantKindIngress || kind == ParticipantKindAgent {
+		if attributes == nil {
+			attributes = make(map[string]string)
+		}
+		attributes[participantKindAttribute] = kind
+	}
+	return attributes, version
+}
+
+// participantAttributesMessage tells participants all the attributes of a participant
+type participantAttributesMessage struct {
+	Type           string            `json:"type"`
+	ParticipantSid string            `json:"participant_sid"`
+	Identity       string            `json:"identity"`
+	Attributes     map[string]string `json:"attributes"`
+	// goes up with each update, older messages can be dropped
+	Version uint32 `json:"version,omitempty"`
+	// keys that changed, removed ones included. Empty when the attributes aren't an update
+	Changed []string `json:"changed,omitempty"`
+}
+
+func newParticipantAttributesPacket(p types.Participant, attributes map[string]string, version uint32, changed []string) (*livekit.DataPacket, error) {
+	if attributes == nil {
+		// all were removed
+		attributes = map[string]string{}
+	}
+	return newServerMessagePacket(&participantAttributesMessage{
+		Type:           participantAttributesMessageType,
+		ParticipantSid: p.ID(),
+		Identity:       p.Identity(),
+		Attributes:     attributes,
+		Version:        version,
+		Changed:        changed,
+	})
+}
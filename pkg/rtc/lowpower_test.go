@@ -0,0 +1,122 @@
+package rtc
+
+import (
+	"encoding/json"
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+func TestParseLowPowerMode(t *testing.T) {
+	for value, expected := range map[string]string{
+		"":                           "",
+		"false":                      "",
+		"1":                          LowPowerModeLowestLayer,
+		"true":                       LowPowerModeLowestLayer,
+		"lowest_layer":               LowPowerModeLowestLayer,
+		LowPowerModeReducedFrameRate: LowPowerModeReducedFrameRate,
+		LowPowerModeAudioOnly:        LowPowerModeAudioOnly,
+	} {
+		mode, ok := ParseLowPowerMode(value)
+		require.True(t, ok, value)
+		require.Equal(t, expected, mode, value)
+	}
+
+	_, ok := ParseLowPowerMode("video_only")
+	require.False(t, ok)
+}
+
+func TestLowPowerSubscribedTrack(t *testing.T) {
+	newDownTrack := func(t *testing.T, mimeType string) *sfu.DownTrack {
+		dt, err := sfu.NewDownTrack(webrtc.RTPCodecCapability{MimeType: mimeType}, &stubTrackReceiver{trackID: "TR_1"}, nil, "sub", 500)
+		require.NoError(t, err)
+		return dt
+	}
+
+	t.Run("video is capped to the lowest layer", func(t *testing.T) {
+		lowest := newDownTrack(t, webrtc.MimeTypeVP8)
+		lowest.SetMaxSpatialLayer(0)
+
+		st := NewSubscribedTrack(&typesfakes.FakePublishedTrack{}, "pub", newDownTrack(t, webrtc.MimeTypeVP8), LowPowerModeLowestLayer)
+		st.SetPublisherMuted(false)
+		require.Equal(t, lowest.MaxLayers(), st.DownTrack().MaxLayers())
+		require.False(t, st.DownTrack().IsForwarderMuted())
+	})
+
+	t.Run("vp8 is capped to the base temporal layer", func(t *testing.T) {
+		st := NewSubscribedTrack(&typesfakes.FakePublishedTrack{}, "pub", newDownTrack(t, webrtc.MimeTypeVP8), LowPowerModeReducedFrameRate)
+		st.SetPublisherMuted(false)
+		require.Equal(t, int32(0), st.DownTrack().MaxTemporalLayer())
+		require.Equal(t, int32(2), st.DownTrack().MaxSpatialLayer())
+		require.False(t, st.DownTrack().IsForwarderMuted())
+
+		st = NewSubscribedTrack(&typesfakes.FakePublishedTrack{}, "pub", newDownTrack(t, webrtc.MimeTypeH264), LowPowerModeReducedFrameRate)
+		require.Equal(t, int32(2), st.DownTrack().MaxTemporalLayer())
+	})
+
+	t.Run("video is paused in audio only mode", func(t *testing.T) {
+		var reasons []string
+		st := NewSubscribedTrack(&typesfakes.FakePublishedTrack{}, "pub", newDownTrack(t, webrtc.MimeTypeVP8), LowPowerModeAudioOnly)
+		st.OnMutedChanged(func(muted bool, reason string) {
+			reasons = append(reasons, reason)
+		})
+		st.SetPublisherMuted(false)
+		require.True(t, st.DownTrack().IsForwarderMuted())
+		require.Equal(t, []string{telemetry.SubscriptionPausedLowPower}, reasons)
+	})
+
+	t.Run("audio is forwarded as usual", func(t *testing.T) {
+		st := NewSubscribedTrack(&typesfakes.FakePublishedTrack{}, "pub", newDownTrack(t, webrtc.MimeTypeOpus), LowPowerModeAudioOnly)
+		st.SetPublisherMuted(false)
+		require.False(t, st.DownTrack().IsForwarderMuted())
+	})
+}
+
+func TestSendParticipantAttributes(t *testing.T) {
+	newParticipant := func(identity string, lowPowerMode string) *typesfakes.FakeParticipant {
+		p := &typesfakes.FakeParticipant{}
+		p.IdentityReturns(identity)
+		p.IDReturns("PA_" + identity)
+		p.StateReturns(livekit.ParticipantInfo_ACTIVE)
+		p.CapabilitiesReturns(types.ProtocolVersion(3).Capabilities())
+		p.LowPowerModeReturns(lowPowerMode)
+		return p
+	}
+	mobile := newParticipant("mobile", LowPowerModeAudioOnly)
+	desktop := newParticipant("desktop", "")
+	joining := newParticipant("joining", "")
+	joining.StateReturns(livekit.ParticipantInfo_JOINED)
+
+	r := &Room{
+		participants: map[string]types.Participant{
+			"mobile":  mobile,
+			"desktop": desktop,
+			"joining": joining,
+		},
+	}
+	participants := r.GetParticipants()
+
+	r.sendParticipantAttributes(participants)
+	require.Equal(t, 1, mobile.SendDataPacketCallCount())
+	require.Equal(t, 1, desktop.SendDataPacketCallCount())
+	require.Zero(t, joining.SendDataPacketCallCount())
+
+	var msg participantAttributesMessage
+	require.NoError(t, json.Unmarshal(desktop.SendDataPacketArgsForCall(0).GetUser().Payload, &msg))
+	require.Equal(t, participantAttributesMessageType, msg.Type)
+	require.Equal(t, "PA_mobile", msg.ParticipantSid)
+	require.Equal(t, map[string]string{"low_power_mode": LowPowerModeAudioOnly}, msg.Attributes)
+
+	// attributes are sent once, participants get them when they become active
+	joining.StateReturns(livekit.ParticipantInfo_ACTIVE)
+	r.sendParticipantAttributes(participants)
+	require.Equal(t, 1, desktop.SendDataPacketCallCount())
+	require.Equal(t, 1, joining.SendDataPacketCallCount())
+}
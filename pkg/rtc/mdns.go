@@ -0,0 +1,86 @@
+package rtc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pion/logging"
+	"github.com/pion/mdns"
+	"golang.org/x/net/ipv4"
+)
+
+// time to wait for a client to answer an mDNS query, the candidate is dropped after that
+const mdnsResolveTimeout = 5 * time.Second
+
+var errNotMDNSCandidate = errors.New("not an mDNS candidate")
+
+// MDNSResolver resolves the .local hostnames that browsers put in place of their IPs in host
+// candidates, through a single multicast socket shared by the PeerConnections of the node
+type MDNSResolver struct {
+	conn *mdns.Conn
+}
+
+// NewMDNSResolver joins the mDNS multicast group. It fails on nodes that can't receive multicast,
+// like most containers and cloud instances
+func NewMDNSResolver(loggerFactory logging.LoggerFactory) (*MDNSResolver, error) {
+	addr, err := net.ResolveUDPAddr("udp4", mdns.DefaultAddress)
+	if err != nil {
+		return nil, err
+	}
+	l, err := net.ListenUDP("udp4", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := mdns.Server(ipv4.NewPacketConn(l), &mdns.Config{LoggerFactory: loggerFactory})
+	if err != nil {
+		_ = l.Close()
+		return nil, err
+	}
+	return &MDNSResolver{conn: conn}, nil
+}
+
+// Resolve returns the IP of the client that answers for name
+func (r *MDNSResolver) Resolve(ctx context.Context, name string) (net.IP, error) {
+	ctx, cancel := context.WithTimeout(ctx, mdnsResolveTimeout)
+	defer cancel()
+
+	_, src, err := r.conn.Query(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	switch addr := src.(type) {
+	case *net.UDPAddr:
+		return addr.IP, nil
+	case *net.IPAddr:
+		return addr.IP, nil
+	default:
+		return nil, errors.New("unexpected mDNS answer from " + src.String())
+	}
+}
+
+func (r *MDNSResolver) Close() {
+	_ = r.conn.Close()
+}
+
+// mdnsCandidateHost returns the .local hostname of an mDNS candidate
+func mdnsCandidateHost(candidate string) (string, error) {
+	fields := strings.Fields(strings.TrimPrefix(candidate, "candidate:"))
+	if len(fields) < 8 || !strings.HasSuffix(fields[4], ".local") {
+		return "", errNotMDNSCandidate
+	}
+	return fields[4], nil
+}
+
+// replaceCandidateHost returns candidate with its address replaced by ip
+func replaceCandidateHost(candidate string, ip net.IP) string {
+	prefix := ""
+	if strings.HasPrefix(candidate, "candidate:") {
+		prefix = "candidate:"
+	}
+	fields := strings.Fields(strings.TrimPrefix(candidate, "candidate:"))
+	fields[4] = ip.String()
+	return prefix + strings.Join(fields, " ")
+}
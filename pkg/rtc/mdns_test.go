@@ -0,0 +1,46 @@
+package rtc
+
+import (
+	"net"
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMDNSCandidateHost(t *testing.T) {
+	const candidate = "candidate:842163049 1 udp 1677729535 4f0e8a3c-6b9d-4f5e-9c1a-2d3e4f5a6b7c.local 53706 typ host generation 0"
+	host, err := mdnsCandidateHost(candidate)
+	require.NoError(t, err)
+	require.Equal(t, "4f0e8a3c-6b9d-4f5e-9c1a-2d3e4f5a6b7c.local", host)
+	require.Equal(t, "candidate:842163049 1 udp 1677729535 192.168.1.20 53706 typ host generation 0",
+		replaceCandidateHost(candidate, net.ParseIP("192.168.1.20")))
+
+	_, err = mdnsCandidateHost("candidate:842163049 1 udp 1677729535 192.168.1.20 53706 typ host generation 0")
+	require.Equal(t, errNotMDNSCandidate, err)
+	_, err = mdnsCandidateHost("")
+	require.Equal(t, errNotMDNSCandidate, err)
+}
+
+func TestDropMDNSCandidates(t *testing.T) {
+	transport, err := NewPCTransport(TransportParams{
+		ParticipantID:       "id",
+		ParticipantIdentity: "identity",
+		Target:              livekit.SignalTarget_PUBLISHER,
+		Config:              &WebRTCConfig{},
+	})
+	require.NoError(t, err)
+	defer transport.Close()
+
+	// without a resolver, mDNS candidates are dropped instead of queued for the remote description
+	require.NoError(t, transport.AddICECandidate(webrtc.ICECandidateInit{
+		Candidate: "candidate:842163049 1 udp 1677729535 4f0e8a3c.local 53706 typ host generation 0",
+	}))
+	require.Empty(t, transport.pendingCandidates)
+
+	require.NoError(t, transport.AddICECandidate(webrtc.ICECandidateInit{
+		Candidate: "candidate:842163049 1 udp 1677729535 192.168.1.20 53706 typ host generation 0",
+	}))
+	require.Len(t, transport.pendingCandidates, 1)
+}
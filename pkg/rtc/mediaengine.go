@@ -1,18 +1,27 @@
 package rtc
 
 import (
+	"fmt"
 	"strings"
 
 	livekit "github.com/livekit/protocol/proto"
 	"github.com/pion/sdp/v3"
 	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/sfu"
 )
 
 const (
-	frameMarking = "urn:ietf:params:rtp-hdrext:framemarking"
+	frameMarking        = "urn:ietf:params:rtp-hdrext:framemarking"
+	repairedRTPStreamID = "urn:ietf:params:rtp-hdrext:sdes:repaired-rtp-stream-id"
+	mimeTypeRTX         = "video/rtx"
 )
 
-func registerCodecs(me *webrtc.MediaEngine, codecs []*livekit.Codec) error {
+// registerCodecs registers the enabled codecs. With rtx, each video codec is paired with a RTX
+// codec at the payload type following it, so that publishers retransmit lost packets on a separate
+// stream instead of relying on keyframes
+func registerCodecs(me *webrtc.MediaEngine, codecs []*livekit.Codec, rtx bool) error {
 	opusCodec := webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2, SDPFmtpLine: "minptime=10;useinbandfec=1", RTCPFeedback: nil}
 	if isCodecEnabled(codecs, opusCodec) {
 		if err := me.RegisterCodec(webrtc.RTPCodecParameters{
@@ -54,55 +63,97 @@ func registerCodecs(me *webrtc.MediaEngine, codecs []*livekit.Codec) error {
 			PayloadType:        123,
 		},
 	} {
-		if isCodecEnabled(codecs, codec.RTPCodecCapability) {
-			if err := me.RegisterCodec(codec, webrtc.RTPCodecTypeVideo); err != nil {
-				return err
-			}
+		if !isCodecEnabled(codecs, codec.RTPCodecCapability) {
+			continue
+		}
+		if err := me.RegisterCodec(codec, webrtc.RTPCodecTypeVideo); err != nil {
+			return err
+		}
+		if !rtx {
+			continue
+		}
+		if err := me.RegisterCodec(webrtc.RTPCodecParameters{
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: mimeTypeRTX, ClockRate: 90000, SDPFmtpLine: fmt.Sprintf("apt=%d", codec.PayloadType)},
+			PayloadType:        codec.PayloadType + 1,
+		}, webrtc.RTPCodecTypeVideo); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-func createPubMediaEngine(codecs []*livekit.Codec) (*webrtc.MediaEngine, error) {
-	me := &webrtc.MediaEngine{}
-	if err := registerCodecs(me, codecs); err != nil {
-		return nil, err
-	}
-	for _, extension := range []string{
-		sdp.SDESMidURI,
-		sdp.SDESRTPStreamIDURI,
-		sdp.TransportCCURI,
-		frameMarking,
-	} {
-		if err := me.RegisterHeaderExtension(webrtc.RTPHeaderExtensionCapability{URI: extension}, webrtc.RTPCodecTypeVideo); err != nil {
-			return nil, err
+// headerExtension is a RTP header extension negotiated for a kind of media, with publishers or
+// with subscribers, in rooms that enable it by name
+type headerExtension struct {
+	name      string
+	uri       string
+	kind      webrtc.RTPCodecType
+	publisher bool
+}
+
+// headerExtensions are registered in order. Publishers send the ones negotiated with them, the
+// server only writes abs-send-time to subscribers, and forwards the values of the ones in
+// sfu.forwardedHeaderExtensions
+var headerExtensions = []headerExtension{
+	{config.HeaderExtensionSDESMid, sdp.SDESMidURI, webrtc.RTPCodecTypeVideo, true},
+	{config.HeaderExtensionSDESRid, sdp.SDESRTPStreamIDURI, webrtc.RTPCodecTypeVideo, true},
+	// RTX packets carry the rid of the stream they repair
+	{config.HeaderExtensionSDESRid, repairedRTPStreamID, webrtc.RTPCodecTypeVideo, true},
+	{config.HeaderExtensionTransportCC, sdp.TransportCCURI, webrtc.RTPCodecTypeVideo, true},
+	{config.HeaderExtensionFrameMarking, frameMarking, webrtc.RTPCodecTypeVideo, true},
+	{config.HeaderExtensionVideoOrientation, sfu.VideoOrientationURI, webrtc.RTPCodecTypeVideo, true},
+	{config.HeaderExtensionPlayoutDelay, sfu.PlayoutDelayURI, webrtc.RTPCodecTypeVideo, true},
+	{config.HeaderExtensionSDESMid, sdp.SDESMidURI, webrtc.RTPCodecTypeAudio, true},
+	{config.HeaderExtensionSDESRid, sdp.SDESRTPStreamIDURI, webrtc.RTPCodecTypeAudio, true},
+	{config.HeaderExtensionAudioLevel, sdp.AudioLevelURI, webrtc.RTPCodecTypeAudio, true},
+
+	{config.HeaderExtensionAbsSendTime, sdp.ABSSendTimeURI, webrtc.RTPCodecTypeVideo, false},
+	{config.HeaderExtensionVideoOrientation, sfu.VideoOrientationURI, webrtc.RTPCodecTypeVideo, false},
+	{config.HeaderExtensionPlayoutDelay, sfu.PlayoutDelayURI, webrtc.RTPCodecTypeVideo, false},
+}
+
+// registerHeaderExtensions registers the header extensions of the enabled names, for publishers or
+// for subscribers
+func registerHeaderExtensions(me *webrtc.MediaEngine, enabled []string, publisher bool) error {
+	for _, extension := range headerExtensions {
+		if extension.publisher != publisher || !isHeaderExtensionEnabled(enabled, extension.name) {
+			continue
+		}
+		if err := me.RegisterHeaderExtension(webrtc.RTPHeaderExtensionCapability{URI: extension.uri}, extension.kind); err != nil {
+			return err
 		}
 	}
-	for _, extension := range []string{
-		sdp.SDESMidURI,
-		sdp.SDESRTPStreamIDURI,
-		sdp.AudioLevelURI,
-	} {
-		if err := me.RegisterHeaderExtension(webrtc.RTPHeaderExtensionCapability{URI: extension}, webrtc.RTPCodecTypeAudio); err != nil {
-			return nil, err
+	return nil
+}
+
+func isHeaderExtensionEnabled(enabled []string, name string) bool {
+	for _, enabledName := range enabled {
+		if enabledName == name {
+			return true
 		}
 	}
+	return false
+}
+
+func createPubMediaEngine(codecs []*livekit.Codec, extensions []string) (*webrtc.MediaEngine, error) {
+	me := &webrtc.MediaEngine{}
+	if err := registerCodecs(me, codecs, true); err != nil {
+		return nil, err
+	}
+	if err := registerHeaderExtensions(me, extensions, true); err != nil {
+		return nil, err
+	}
 
 	return me, nil
 }
 
-func createSubMediaEngine(codecs []*livekit.Codec) (*webrtc.MediaEngine, error) {
+func createSubMediaEngine(codecs []*livekit.Codec, extensions []string) (*webrtc.MediaEngine, error) {
 	me := &webrtc.MediaEngine{}
-	if err := registerCodecs(me, codecs); err != nil {
+	if err := registerCodecs(me, codecs, false); err != nil {
 		return nil, err
 	}
-
-	for _, extension := range []string{
-		sdp.ABSSendTimeURI,
-	} {
-		if err := me.RegisterHeaderExtension(webrtc.RTPHeaderExtensionCapability{URI: extension}, webrtc.RTPCodecTypeVideo); err != nil {
-			return nil, err
-		}
+	if err := registerHeaderExtensions(me, extensions, false); err != nil {
+		return nil, err
 	}
 
 	return me, nil
@@ -119,3 +170,159 @@ func isCodecEnabled(codecs []*livekit.Codec, cap webrtc.RTPCodecCapability) bool
 	}
 	return false
 }
+
+// opusSection describes an audio media section of a session description that negotiates Opus
+type opusSection struct {
+	mid string
+	// msid track ID, which TrackRemote.ID returns
+	trackID string
+	stereo  bool
+}
+
+// parseOpusSections returns the Opus audio sections of desc, with whether each signals stereo
+// in its fmtp line
+func parseOpusSections(desc webrtc.SessionDescription) ([]opusSection, error) {
+	parsed, err := desc.Unmarshal()
+	if err != nil {
+		return nil, err
+	}
+
+	var sections []opusSection
+	for _, media := range parsed.MediaDescriptions {
+		if !strings.EqualFold(media.MediaName.Media, "audio") {
+			continue
+		}
+
+		section := opusSection{}
+		section.mid, _ = media.Attribute(sdp.AttrKeyMID)
+		if msid, ok := media.Attribute(sdp.AttrKeyMsid); ok {
+			if parts := strings.Fields(msid); len(parts) == 2 {
+				section.trackID = parts[1]
+			}
+		}
+
+		var opusPT string
+		for _, attr := range media.Attributes {
+			if attr.Key != "rtpmap" {
+				continue
+			}
+			parts := strings.Fields(attr.Value)
+			if len(parts) == 2 && strings.HasPrefix(strings.ToLower(parts[1]), "opus/") {
+				opusPT = parts[0]
+				break
+			}
+		}
+		if opusPT == "" {
+			continue
+		}
+		for _, attr := range media.Attributes {
+			if attr.Key != "fmtp" {
+				continue
+			}
+			parts := strings.SplitN(attr.Value, " ", 2)
+			if len(parts) == 2 && parts[0] == opusPT {
+				section.stereo = isStereoFmtp(parts[1])
+			}
+		}
+		sections = append(sections, section)
+	}
+	return sections, nil
+}
+
+// isStereoFmtp returns true when an Opus fmtp line indicates stereo, either that stereo is
+// preferred when receiving or that it's likely to be sent
+func isStereoFmtp(fmtpLine string) bool {
+	for _, param := range strings.Split(fmtpLine, ";") {
+		switch strings.ToLower(strings.TrimSpace(param)) {
+		case "stereo=1", "sprop-stereo=1":
+			return true
+		}
+	}
+	return false
+}
+
+// opusFmtpWithStereo returns the Opus fmtp line with its stereo parameters set or removed
+func opusFmtpWithStereo(fmtpLine string, stereo bool) string {
+	var params []string
+	for _, param := range strings.Split(fmtpLine, ";") {
+		param = strings.TrimSpace(param)
+		key := strings.ToLower(strings.SplitN(param, "=", 2)[0])
+		if param == "" || key == "stereo" || key == "sprop-stereo" {
+			continue
+		}
+		params = append(params, param)
+	}
+	if stereo {
+		params = append(params, "stereo=1", "sprop-stereo=1")
+	}
+	return strings.Join(params, ";")
+}
+
+// opusFmtpWithDTX returns the Opus fmtp line with usedtx set or removed
+func opusFmtpWithDTX(fmtpLine string, dtx bool) string {
+	var params []string
+	for _, param := range strings.Split(fmtpLine, ";") {
+		param = strings.TrimSpace(param)
+		if param == "" || strings.ToLower(strings.SplitN(param, "=", 2)[0]) == "usedtx" {
+			continue
+		}
+		params = append(params, param)
+	}
+	if dtx {
+		params = append(params, "usedtx=1")
+	}
+	return strings.Join(params, ";")
+}
+
+// setOpusPreferences sets the transceiver's Opus fmtp to negotiate stereo or mono, with or
+// without DTX. Both have to be set at once, codec preferences replace the ones set before
+func setOpusPreferences(transceiver *webrtc.RTPTransceiver, codecs []webrtc.RTPCodecParameters, stereo bool, dtx bool) error {
+	preferences := make([]webrtc.RTPCodecParameters, 0, len(codecs))
+	for _, codec := range codecs {
+		if strings.EqualFold(codec.MimeType, webrtc.MimeTypeOpus) {
+			codec.SDPFmtpLine = opusFmtpWithDTX(opusFmtpWithStereo(codec.SDPFmtpLine, stereo), dtx)
+		}
+		preferences = append(preferences, codec)
+	}
+	return transceiver.SetCodecPreferences(preferences)
+}
+
+// pendingAudioTrack is an audio track the publisher added, that has no media yet
+type pendingAudioTrack struct {
+	cid  string
+	info *livekit.TrackInfo
+}
+
+// matchAudioSections pairs audio sections of an offer with the pending audio tracks they carry,
+// by mid. A section whose msid track ID is the cid a track was added with carries that track. The
+// others are paired in SDP order with the remaining tracks, which have to be in the order they
+// were added: clients add tracks in the order they add transceivers
+func matchAudioSections(sections []opusSection, pending []pendingAudioTrack) map[string]pendingAudioTrack {
+	matched := make(map[string]pendingAudioTrack, len(sections))
+	used := make(map[string]bool, len(pending))
+	for _, section := range sections {
+		for _, track := range pending {
+			if track.cid == section.trackID && !used[track.cid] {
+				matched[section.mid] = track
+				used[track.cid] = true
+				break
+			}
+		}
+	}
+
+	next := 0
+	for _, section := range sections {
+		if _, ok := matched[section.mid]; ok {
+			continue
+		}
+		for next < len(pending) && used[pending[next].cid] {
+			next++
+		}
+		if next == len(pending) {
+			break
+		}
+		matched[section.mid] = pending[next]
+		used[pending[next].cid] = true
+	}
+	return matched
+}
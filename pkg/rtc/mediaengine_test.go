@@ -1,11 +1,15 @@
 package rtc
 
 import (
+	"strings"
 	"testing"
 
 	livekit "github.com/livekit/protocol/proto"
 	"github.com/pion/webrtc/v3"
 	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/sfu"
 )
 
 func TestIsCodecEnabled(t *testing.T) {
@@ -23,3 +27,102 @@ func TestIsCodecEnabled(t *testing.T) {
 		require.False(t, isCodecEnabled(enabledCodecs, webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}))
 	})
 }
+
+func TestOpusFmtpWithStereo(t *testing.T) {
+	require.Equal(t, "minptime=10;useinbandfec=1;stereo=1;sprop-stereo=1", opusFmtpWithStereo("minptime=10;useinbandfec=1", true))
+	require.Equal(t, "minptime=10;useinbandfec=1", opusFmtpWithStereo("minptime=10;stereo=1;useinbandfec=1;sprop-stereo=1", false))
+	// not duplicated when already set
+	require.Equal(t, "useinbandfec=1;stereo=1;sprop-stereo=1", opusFmtpWithStereo("stereo=1; useinbandfec=1", true))
+	require.Equal(t, "", opusFmtpWithStereo("stereo=0", false))
+}
+
+func TestParseOpusSections(t *testing.T) {
+	offer := strings.Join([]string{
+		"v=0",
+		"o=- 0 0 IN IP4 127.0.0.1",
+		"s=-",
+		"t=0 0",
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111",
+		"a=mid:0",
+		"a=msid:stream mic",
+		"a=rtpmap:111 opus/48000/2",
+		"a=fmtp:111 minptime=10;useinbandfec=1;stereo=1",
+		"m=video 9 UDP/TLS/RTP/SAVPF 96",
+		"a=mid:1",
+		"a=msid:stream camera",
+		"a=rtpmap:96 VP8/90000",
+		"m=audio 9 UDP/TLS/RTP/SAVPF 100 111",
+		"a=mid:2",
+		"a=msid:stream music",
+		"a=rtpmap:100 red/48000/2",
+		"a=fmtp:100 111/111",
+		"a=rtpmap:111 opus/48000/2",
+		"a=fmtp:111 minptime=10;sprop-stereo=0",
+		"",
+	}, "\r\n")
+
+	sections, err := parseOpusSections(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: offer})
+	require.NoError(t, err)
+	require.Equal(t, []opusSection{
+		{mid: "0", trackID: "mic", stereo: true},
+		{mid: "2", trackID: "music", stereo: false},
+	}, sections)
+}
+
+func TestOpusFmtpWithDTX(t *testing.T) {
+	require.Equal(t, "minptime=10;useinbandfec=1;usedtx=1", opusFmtpWithDTX("minptime=10;useinbandfec=1", true))
+	require.Equal(t, "minptime=10;useinbandfec=1", opusFmtpWithDTX("minptime=10;usedtx=1;useinbandfec=1", false))
+	// not duplicated when already set
+	require.Equal(t, "useinbandfec=1;usedtx=1", opusFmtpWithDTX("usedtx=1; useinbandfec=1", true))
+	require.Equal(t, "", opusFmtpWithDTX("usedtx=0", false))
+}
+
+func TestMatchAudioSections(t *testing.T) {
+	mic := pendingAudioTrack{cid: "mic", info: &livekit.TrackInfo{Name: "mic"}}
+	screen := pendingAudioTrack{cid: "screen", info: &livekit.TrackInfo{Name: "screen", DisableDtx: true}}
+
+	t.Run("matched by msid track id", func(t *testing.T) {
+		sections := []opusSection{{mid: "0", trackID: "screen"}, {mid: "1", trackID: "mic"}}
+		require.Equal(t, map[string]pendingAudioTrack{"0": screen, "1": mic}, matchAudioSections(sections, []pendingAudioTrack{mic, screen}))
+	})
+
+	t.Run("matched in order otherwise", func(t *testing.T) {
+		sections := []opusSection{{mid: "0", trackID: "{a1}"}, {mid: "1", trackID: "{b2}"}}
+		require.Equal(t, map[string]pendingAudioTrack{"0": mic, "1": screen}, matchAudioSections(sections, []pendingAudioTrack{mic, screen}))
+	})
+
+	t.Run("ordered sections skip tracks matched by msid", func(t *testing.T) {
+		sections := []opusSection{{mid: "0", trackID: "{a1}"}, {mid: "1", trackID: "mic"}}
+		require.Equal(t, map[string]pendingAudioTrack{"0": screen, "1": mic}, matchAudioSections(sections, []pendingAudioTrack{mic, screen}))
+	})
+
+	t.Run("sections without pending tracks are left out", func(t *testing.T) {
+		sections := []opusSection{{mid: "0", trackID: "mic"}, {mid: "1"}}
+		require.Equal(t, map[string]pendingAudioTrack{"0": mic}, matchAudioSections(sections, []pendingAudioTrack{mic}))
+	})
+}
+
+func TestSubMediaEngineHeaderExtensions(t *testing.T) {
+	offer := func(t *testing.T, extensions []string) string {
+		me, err := createSubMediaEngine([]*livekit.Codec{{Mime: webrtc.MimeTypeVP8}}, extensions)
+		require.NoError(t, err)
+		pc, err := webrtc.NewAPI(webrtc.WithMediaEngine(me)).NewPeerConnection(webrtc.Configuration{})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = pc.Close() })
+
+		_, err = pc.AddTransceiverFromKind(webrtc.RTPCodecTypeVideo, webrtc.RTPTransceiverInit{Direction: webrtc.RTPTransceiverDirectionSendonly})
+		require.NoError(t, err)
+		desc, err := pc.CreateOffer(nil)
+		require.NoError(t, err)
+		return desc.SDP
+	}
+
+	sdp := offer(t, config.DefaultHeaderExtensions)
+	require.Contains(t, sdp, "abs-send-time")
+	require.NotContains(t, sdp, sfu.VideoOrientationURI)
+
+	sdp = offer(t, []string{config.HeaderExtensionVideoOrientation, config.HeaderExtensionPlayoutDelay})
+	require.NotContains(t, sdp, "abs-send-time")
+	require.Contains(t, sdp, sfu.VideoOrientationURI)
+	require.Contains(t, sdp, sfu.PlayoutDelayURI)
+}
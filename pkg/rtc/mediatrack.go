@@ -7,6 +7,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/livekit/protocol/logger"
 	livekit "github.com/livekit/protocol/proto"
 	"github.com/livekit/protocol/utils"
@@ -20,6 +21,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/twcc"
 	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
 var (
@@ -44,6 +46,8 @@ type MediaTrack struct {
 	numUpTracks uint32
 	simulcasted utils.AtomicFlag
 	buffer      *buffer.Buffer
+	// logs RTCP that couldn't be read, sampled since it's read for every packet
+	rtcpLogger logger.Logger
 
 	// channel to send RTCP packets to the source
 	lock sync.RWMutex
@@ -54,13 +58,21 @@ type MediaTrack struct {
 	receiver         sfu.Receiver
 	lastPLI          time.Time
 
+	// RTP header extensions negotiated with the publisher
+	headerExtensions []webrtc.RTPHeaderExtensionParameter
+
 	// track audio fraction lost
-	fracLostLock      sync.Mutex
-	maxDownFracLost   uint8
-	maxDownFracLostTs time.Time
-	currentUpFracLost uint32
-	maxUpFracLost     uint8
-	maxUpFracLostTs   time.Time
+	fracLostLock        sync.Mutex
+	downFracLost        *sfu.LossAggregator
+	downFracLostUpdated time.Time
+	currentUpFracLost   uint32
+	maxUpFracLost       uint8
+	maxUpFracLostTs     time.Time
+
+	// highest layer subscribers receive, for dynacast
+	dynacastLock             sync.Mutex
+	subscribedLayer          int32
+	onSubscribedLayerChanged func(maxLayer int32)
 
 	onClose []func()
 }
@@ -71,12 +83,18 @@ type MediaTrackParams struct {
 	SdpCid              string
 	ParticipantID       string
 	ParticipantIdentity string
-	RTCPChan            chan []rtcp.Packet
+	RTCPQueue           *sfu.RTCPQueue
 	BufferFactory       *buffer.Factory
 	ReceiverConfig      ReceiverConfig
+	SenderConfig        SenderConfig
 	AudioConfig         config.AudioConfig
 	Telemetry           telemetry.TelemetryService
 	Logger              logger.Logger
+
+	// publisher negotiated stereo Opus, which is offered to subscribers in turn
+	Stereo bool
+	// leaves packets out of transport-cc feedback when the publisher is over its bitrate cap
+	TWCCThrottle *twccThrottle
 }
 
 func NewMediaTrack(track *webrtc.TrackRemote, params MediaTrackParams) *MediaTrack {
@@ -86,6 +104,10 @@ func NewMediaTrack(track *webrtc.TrackRemote, params MediaTrackParams) *MediaTra
 		streamID:         track.StreamID(),
 		codec:            track.Codec(),
 		subscribedTracks: make(map[string]*SubscribedTrack),
+		rtcpLogger:       sampled(params.Logger, sfu.DefaultLogSampleInterval),
+		downFracLost:     sfu.NewLossAggregator(params.ReceiverConfig.UpstreamLossPercentile),
+		// publishers send all layers until told otherwise
+		subscribedLayer: spatialLayerForQuality(livekit.VideoQuality_HIGH),
 	}
 
 	if params.TrackInfo.Muted {
@@ -157,6 +179,10 @@ func (t *MediaTrack) AddSubscriber(sub types.Participant) error {
 	if !sub.CanSubscribe() {
 		return ErrPermissionDenied
 	}
+	if !sub.CanSubscribeTo(t.params.ParticipantIdentity) {
+		return ErrCannotSubscribeTo
+	}
+	subscribedAt := time.Now()
 
 	t.lock.Lock()
 	defer t.lock.Unlock()
@@ -172,10 +198,21 @@ func (t *MediaTrack) AddSubscriber(sub types.Participant) error {
 		return errors.New("cannot subscribe without a receiver in place")
 	}
 
+	if err := sub.ReserveSubscription(t.ID()); err != nil {
+		return err
+	}
+	reserved := true
+	defer func() {
+		// subscribing failed
+		if reserved {
+			sub.ReleaseSubscription(t.ID())
+		}
+	}()
+
 	codec := t.receiver.Codec()
 	// using DownTrack from ion-sfu
 	streamId := t.params.ParticipantID
-	if sub.ProtocolVersion().SupportsPackedStreamId() {
+	if sub.Capabilities().PackedStreamID {
 		// when possible, pack both IDs in streamID to allow new streams to be generated
 		// react-native-webrtc still uses stream based APIs and require this
 		streamId = PackStreamID(t.params.ParticipantID, t.ID())
@@ -191,11 +228,29 @@ func (t *MediaTrack) AddSubscriber(sub types.Participant) error {
 	if err != nil {
 		return err
 	}
-	subTrack := NewSubscribedTrack(t.params.ParticipantIdentity, downTrack)
+	downTrack.SetLogger(logr.Logger(sub.GetLogger()))
+	if t.params.SenderConfig.ReadyTimeout > 0 {
+		downTrack.WaitForReady(t.params.SenderConfig.ReadyTimeout)
+	}
+	if delay := sub.SubscriberMediaDelay(); delay > 0 {
+		downTrack.SetEmulatedDelay(delay)
+	}
+	if pacer := sub.SubscriberPacer(); pacer != nil {
+		downTrack.SetPacer(pacer)
+	}
+	subTrack := NewSubscribedTrack(t, t.params.ParticipantIdentity, downTrack, sub.LowPowerMode())
+	subTrack.CapSpatialLayer(sub.SpatialLayerCap())
+	if quality, ok := sub.SubscriberQuality(t.ID()); ok {
+		subTrack.SetMaxQuality(quality)
+	} else if sub.Capabilities().AdaptiveStream {
+		// the client reports the size of the video element once it's attached, the lowest layer
+		// does until then
+		subTrack.SetMaxQuality(livekit.VideoQuality_LOW)
+	}
 
 	var transceiver *webrtc.RTPTransceiver
 	var sender *webrtc.RTPSender
-	if sub.ProtocolVersion().SupportsTransceiverReuse() {
+	if sub.Capabilities().TransceiverReuse {
 		//
 		// AddTrack will create a new transceiver or re-use an unused one
 		// if the attributes match. This prevents SDP from bloating
@@ -233,6 +288,14 @@ func (t *MediaTrack) AddSubscriber(sub types.Participant) error {
 	}
 
 	sendParameters := sender.GetParameters()
+	if t.Kind() == livekit.TrackType_AUDIO {
+		// also when reusing a transceiver, so that a previous track's setting doesn't carry over
+		stereo := t.params.Stereo && !t.params.AudioConfig.ForceMono
+		if err = setOpusPreferences(transceiver, sendParameters.Codecs, stereo, false); err != nil {
+			t.params.Logger.Warnw("failed to SetCodecPreferences", err)
+		}
+	}
+	downTrack.SetUpstreamRTPHeaderExtensions(t.headerExtensions)
 	downTrack.SetRTPHeaderExtensions(sendParameters.HeaderExtensions)
 
 	downTrack.SetTransceiver(transceiver)
@@ -246,14 +309,24 @@ func (t *MediaTrack) AddSubscriber(sub types.Participant) error {
 	downTrack.OnRTCP(func(pkts []rtcp.Packet) {
 		t.params.Telemetry.HandleRTCP(livekit.StreamType_DOWNSTREAM, sub.ID(), pkts)
 	})
+	downTrack.OnFirstFrame(func(_ *sfu.DownTrack) {
+		t.params.Telemetry.TrackFirstFrame(context.Background(), t.Kind(), time.Since(subscribedAt), &telemetry.SubscriptionEvent{
+			SubscriberID: sub.ID(),
+			PublisherID:  t.params.ParticipantID,
+			TrackID:      t.ID(),
+		})
+	})
 
 	downTrack.OnCloseHandler(func() {
 		go func() {
 			t.lock.Lock()
 			delete(t.subscribedTracks, sub.ID())
 			t.lock.Unlock()
+			t.downFracLost.Remove(sub.ID())
+			t.updateSubscribedLayer()
 
 			t.params.Telemetry.TrackUnsubscribed(context.Background(), sub.ID(), t.ToProto())
+			t.auditSubscription(sub.ID(), telemetry.SubscriptionEventUnsubscribed, "", nil)
 
 			// ignore if the subscribing sub is not connected
 			if sub.SubscriberPC().ConnectionState() == webrtc.PeerConnectionStateClosed {
@@ -290,14 +363,34 @@ func (t *MediaTrack) AddSubscriber(sub types.Participant) error {
 		}()
 	})
 	if t.Kind() == livekit.TrackType_AUDIO {
-		downTrack.AddReceiverReportListener(t.handleMaxLossFeedback)
+		if t.params.ReceiverConfig.UpstreamLossPercentile > 0 {
+			downTrack.AddReceiverReportListener(t.handleLossFeedback)
+		}
+	} else {
+		downTrack.OnLayerSwitched(func(_ *sfu.DownTrack, layer int32) {
+			t.auditSubscription(sub.ID(), telemetry.SubscriptionEventLayerChanged, "", &layer)
+		})
 	}
+	subTrack.OnMutedChanged(func(muted bool, reason string) {
+		if muted {
+			t.auditSubscription(sub.ID(), telemetry.SubscriptionEventPaused, reason, nil)
+		} else {
+			t.auditSubscription(sub.ID(), telemetry.SubscriptionEventResumed, "", nil)
+		}
+	})
+	// the track's lock is held while the subscription is set up
+	subTrack.OnSubscribedLayerChanged(func() {
+		go t.updateSubscribedLayer()
+	})
+	t.auditSubscription(sub.ID(), telemetry.SubscriptionEventSubscribed, "", nil)
 
 	t.subscribedTracks[sub.ID()] = subTrack
 	subTrack.SetPublisherMuted(t.IsMuted())
 
 	t.receiver.AddDownTrack(downTrack)
 	// since sub will lock, run it in a goroutine to avoid deadlocks
+	reserved = false
+	go t.updateSubscribedLayer()
 	go func() {
 		sub.AddSubscribedTrack(subTrack)
 		sub.Negotiate()
@@ -322,6 +415,17 @@ func (t *MediaTrack) NumUpTracks() (uint32, uint32) {
 }
 
 // AddReceiver adds a new RTP receiver to the track
+// SetAudioConfig changes the thresholds of the track's audio level to those of the room's audio config
+func (t *MediaTrack) SetAudioConfig(conf config.AudioConfig) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.params.AudioConfig.ActiveLevel = conf.ActiveLevel
+	t.params.AudioConfig.MinPercentile = conf.MinPercentile
+	if t.audioLevel != nil {
+		t.audioLevel.SetThresholds(conf.ActiveLevel, conf.MinPercentile)
+	}
+}
+
 func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.TrackRemote, twcc *twcc.Responder) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
@@ -343,16 +447,23 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 		})
 	} else if t.Kind() == livekit.TrackType_VIDEO {
 		if twcc != nil {
+			throttle := t.params.TWCCThrottle
 			buff.OnTransportWideCC(func(sn uint16, timeNS int64, marker bool) {
+				if throttle != nil && !throttle.report() {
+					return
+				}
 				twcc.Push(sn, timeNS, marker)
 			})
 		}
+		buff.OnRecovered(prometheus.IncrementPacketRecovered)
 	}
+	buff.OnReordered(prometheus.ObservePacketReorderDepth)
+	buff.OnLate(prometheus.IncrementPacketLate)
 
 	rtcpReader.OnPacket(func(bytes []byte) {
 		pkts, err := rtcp.Unmarshal(bytes)
 		if err != nil {
-			t.params.Logger.Errorw("could not unmarshal RTCP", err)
+			t.rtcpLogger.Errorw("could not unmarshal RTCP", err)
 			return
 		}
 
@@ -367,23 +478,31 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 	})
 
 	if t.receiver == nil {
-		t.receiver = sfu.NewWebRTCReceiver(receiver, track, t.params.ParticipantID,
+		opts := []sfu.ReceiverOpts{
 			sfu.WithPliThrottle(0),
 			sfu.WithLoadBalanceThreshold(20),
-			sfu.WithStreamTrackers())
-		t.receiver.SetRTCPCh(t.params.RTCPChan)
+			sfu.WithStreamTrackers(),
+		}
+		if t.params.ReceiverConfig.ForwardingPool != nil {
+			opts = append(opts, sfu.WithForwardingPool(t.params.ReceiverConfig.ForwardingPool))
+		}
+		if t.params.ReceiverConfig.KeyFrameCachePackets > 0 {
+			opts = append(opts, sfu.WithKeyFrameCache(t.params.ReceiverConfig.KeyFrameCachePackets))
+		}
+		t.receiver = sfu.NewWebRTCReceiver(receiver, track, t.params.ParticipantID, opts...)
+		t.receiver.SetRTCPQueue(t.params.RTCPQueue)
 		t.receiver.OnCloseHandler(func() {
 			t.lock.Lock()
 			t.receiver = nil
 			onclose := t.onClose
 			t.lock.Unlock()
 			t.RemoveAllSubscribers()
-			t.params.Telemetry.TrackUnpublished(context.Background(), t.params.ParticipantID, t.ToProto(), uint32(track.SSRC()))
+			t.params.Telemetry.TrackUnpublished(context.Background(), t.params.ParticipantID, t.ToProto(), t.codec.MimeType, uint32(track.SSRC()))
 			for _, f := range onclose {
 				f()
 			}
 		})
-		t.params.Telemetry.TrackPublished(context.Background(), t.params.ParticipantID, t.ToProto())
+		t.params.Telemetry.TrackPublished(context.Background(), t.params.ParticipantID, t.ToProto(), t.codec.MimeType)
 		if t.Kind() == livekit.TrackType_AUDIO {
 			t.buffer = buff
 		}
@@ -398,8 +517,23 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 		t.simulcasted.TrySet(true)
 	}
 
-	buff.Bind(receiver.GetParameters(), track.Codec().RTPCodecCapability, buffer.Options{
-		MaxBitRate: t.params.ReceiverConfig.maxBitrate,
+	params := receiver.GetParameters()
+	t.headerExtensions = params.HeaderExtensions
+	buff.Bind(params, track.Codec().RTPCodecCapability, buffer.Options{
+		MaxBitRate:    t.params.ReceiverConfig.rembMaxBitrate(),
+		ReorderWindow: t.params.ReceiverConfig.ReorderWindow,
+	})
+}
+
+// auditSubscription records a change of what a subscriber receives of the track
+func (t *MediaTrack) auditSubscription(subscriberID string, eventType string, reason string, layer *int32) {
+	t.params.Telemetry.TrackSubscriptionChanged(context.Background(), &telemetry.SubscriptionEvent{
+		Type:         eventType,
+		SubscriberID: subscriberID,
+		PublisherID:  t.params.ParticipantID,
+		TrackID:      t.ID(),
+		Reason:       reason,
+		Layer:        layer,
 	})
 }
 
@@ -414,6 +548,16 @@ func (t *MediaTrack) RemoveSubscriber(participantId string) {
 	}
 }
 
+// Close stops receiving the track, the close callbacks are called once its receiver has closed
+func (t *MediaTrack) Close() {
+	t.lock.RLock()
+	receiver := t.receiver
+	t.lock.RUnlock()
+	if receiver != nil {
+		receiver.Close()
+	}
+}
+
 func (t *MediaTrack) RemoveAllSubscribers() {
 	t.params.Logger.Debugw("removing all subscribers", "track", t.ID())
 	t.lock.Lock()
@@ -535,34 +679,32 @@ func (t *MediaTrack) handlePublisherFeedback(packets []rtcp.Packet) {
 
 	// also look for sender reports
 	// feedback for the source RTCP
-	t.params.RTCPChan <- packets
+	t.params.RTCPQueue.Enqueue(packets)
 }
 
-// handles max loss for audio packets
-func (t *MediaTrack) handleMaxLossFeedback(_ *sfu.DownTrack, report *rtcp.ReceiverReport) {
-	var (
-		shouldUpdate bool
-		maxLost      uint8
-	)
-	t.fracLostLock.Lock()
+// handleLossFeedback reports the loss of the track's typical subscriber to the publisher, so that
+// audio encoders add redundancy when subscribers need it. Audio only
+func (t *MediaTrack) handleLossFeedback(dt *sfu.DownTrack, report *rtcp.ReceiverReport) {
+	var maxLost uint8
 	for _, rr := range report.Reports {
-		if t.maxDownFracLost < rr.FractionLost {
-			t.maxDownFracLost = rr.FractionLost
+		if maxLost < rr.FractionLost {
+			maxLost = rr.FractionLost
 		}
 	}
 
 	now := time.Now()
-	if now.Sub(t.maxDownFracLostTs) > lostUpdateDelta {
-		shouldUpdate = true
-		maxLost = t.maxDownFracLost
-		t.maxDownFracLost = 0
-		t.maxDownFracLostTs = now
+	t.downFracLost.Report(dt.PeerID(), maxLost, now)
+
+	t.fracLostLock.Lock()
+	shouldUpdate := now.Sub(t.downFracLostUpdated) > lostUpdateDelta
+	if shouldUpdate {
+		t.downFracLostUpdated = now
 	}
 	t.fracLostLock.Unlock()
 
 	if shouldUpdate && t.buffer != nil {
 		// ok to access buffer since receivers are added before subscribers
-		t.buffer.SetLastFractionLostReport(maxLost)
+		t.buffer.SetLastFractionLostReport(t.downFracLost.FractionLost(now))
 	}
 }
 
@@ -595,6 +737,23 @@ func (t *MediaTrack) DebugInfo() map[string]interface{} {
 	return info
 }
 
+func (t *MediaTrack) GetStats() *types.PublishedTrackStats {
+	stats := &types.PublishedTrackStats{
+		TrackID: t.ID(),
+		Name:    t.Name(),
+		Kind:    t.Kind().String(),
+		Muted:   t.IsMuted(),
+	}
+
+	t.lock.RLock()
+	receiver := t.receiver
+	t.lock.RUnlock()
+	if receiver != nil {
+		stats.Layers = receiver.GetLayerStats()
+	}
+	return stats
+}
+
 func (t *MediaTrack) Receiver() sfu.TrackReceiver {
 	return t.receiver
 }
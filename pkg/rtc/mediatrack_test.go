@@ -6,6 +6,8 @@ import (
 	livekit "github.com/livekit/protocol/proto"
 	"github.com/pion/webrtc/v3"
 	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
 )
 
 func TestTrackInfo(t *testing.T) {
@@ -70,3 +72,17 @@ func TestGetQualityForDimension(t *testing.T) {
 		require.Equal(t, livekit.VideoQuality_HIGH, mt.GetQualityForDimension(600, 900))
 	})
 }
+
+func TestAddSubscriberRestricted(t *testing.T) {
+	mt := NewMediaTrack(&webrtc.TrackRemote{}, MediaTrackParams{
+		TrackInfo:           &livekit.TrackInfo{Sid: "testsid", Type: livekit.TrackType_VIDEO},
+		ParticipantIdentity: "publisher",
+	})
+	sub := &typesfakes.FakeParticipant{}
+	sub.CanSubscribeReturns(true)
+	sub.CanSubscribeToStub = func(identity string) bool {
+		return identity != "publisher"
+	}
+	require.Equal(t, ErrCannotSubscribeTo, mt.AddSubscriber(sub))
+	require.False(t, mt.IsSubscriber(sub.ID()))
+}
@@ -1,9 +1,11 @@
 package rtc
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"strings"
+	"math"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -14,6 +16,7 @@ import (
 	"github.com/livekit/protocol/logger"
 	livekit "github.com/livekit/protocol/proto"
 	"github.com/livekit/protocol/utils"
+	"github.com/pion/interceptor"
 	"github.com/pion/rtcp"
 	"github.com/pion/webrtc/v3"
 	"github.com/pkg/errors"
@@ -30,7 +33,10 @@ import (
 const (
 	lossyDataChannel    = "_lossy"
 	reliableDataChannel = "_reliable"
-	sdBatchSize         = 20
+	// number of connection quality samples kept per participant
+	qualityHistorySize = 60
+	// batches of RTCP feedback queued for the publisher, besides keyframe requests
+	rtcpQueueSize = 50
 )
 
 type ParticipantParams struct {
@@ -39,24 +45,61 @@ type ParticipantParams struct {
 	Sink            routing.MessageSink
 	AudioConfig     config.AudioConfig
 	ProtocolVersion types.ProtocolVersion
-	Telemetry       telemetry.TelemetryService
-	ThrottleConfig  config.PLIThrottleConfig
-	EnabledCodecs   []*livekit.Codec
-	Hidden          bool
-	Logger          logger.Logger
+	// negotiated when the client joined, those of the protocol version when not set
+	Capabilities      types.Capabilities
+	Telemetry         telemetry.TelemetryService
+	ThrottleConfig    config.PLIThrottleConfig
+	DataRateLimit     config.DataRateLimitConfig
+	DataBackpressure  config.DataBackpressureConfig
+	SubscriptionLimit config.SubscriptionLimitConfig
+	EnabledCodecs     []*livekit.Codec
+	Policy            config.RoomPolicy
+	LowPowerMode      string
+	Kind              string
+	// sources tracks can be published from, all when empty
+	PublishSources []livekit.TrackSource
+	// approves the joins of participants in the room's waiting room
+	CanApproveJoins bool
+	// identities of the participants whose tracks can be subscribed to, all when empty. Identities
+	// ending with * allow those they're a prefix of
+	CanSubscribeTo []string
+	// joins without WebRTC transports, for presence and data only. Data packets are sent over the
+	// signal connection
+	SignalOnly bool
+	// signal responses are numbered and kept until the client acks them, to be sent again when it
+	// resumes. Clients that don't ack get them as they are
+	SequenceSignal bool
+	Logger         logger.Logger
+
+	// tracks added without receiving media for this long are dropped, 0 to keep them
+	PendingTrackTimeout time.Duration
+	// how video is degraded while the node is overloaded
+	Degradation config.DegradationConfig
 }
 
 type ParticipantImpl struct {
-	params      ParticipantParams
-	id          string
-	publisher   *PCTransport
-	subscriber  *PCTransport
-	isClosed    utils.AtomicFlag
-	permission  *livekit.ParticipantPermission
-	state       atomic.Value // livekit.ParticipantInfo_State
-	rtcpCh      chan []rtcp.Packet
-	pliThrottle *pliThrottle
+	params     ParticipantParams
+	id         string
+	publisher  *PCTransport
+	subscriber *PCTransport
+	isClosed   utils.AtomicFlag
+	// reliable data is sent over the signal connection, the data channel failed
+	dataOverSignal utils.AtomicFlag
+	// the node is overloaded, video forwarded to the participant is capped
+	degraded   utils.AtomicFlag
+	permission *livekit.ParticipantPermission
+	state      atomic.Value // livekit.ParticipantInfo_State
+	rtcpQueue  *sfu.RTCPQueue
+	// combines the REMB of each stream the participant publishes
+	rembAggregator *sfu.REMBAggregator
+	closed         chan struct{}
+	pliThrottle    *pliThrottle
+	// nil when publishers aren't capped
+	bitrateCap  *publisherBitrateCap
+	dataLimiter *dataRateLimiter
 	updateCache *lru.Cache
+	// logs RTCP that couldn't be written, sampled since it's written for every packet
+	rtcpLogger logger.Logger
 
 	// reliable and unreliable data channels
 	reliableDC    *webrtc.DataChannel
@@ -64,21 +107,52 @@ type ParticipantImpl struct {
 	lossyDC       *webrtc.DataChannel
 	lossyDCSub    *webrtc.DataChannel
 
+	// the subscriber data channels of a subscriber primary participant failed, data is sent on the
+	// publisher-side channels the client created
+	dataOnPublisher utils.AtomicFlag
+	// the subscriber data channels were created again after they failed to open
+	subscriberDCRetried utils.AtomicFlag
+
 	// when first connected
 	connectedAt time.Time
 
 	// JSON encoded metadata to pass to clients
 	metadata string
+	// display name, guarded by lock
+	name string
+	// attributes set by the server and the client, the version goes up with each update
+	attributesLock     sync.Mutex
+	attributes         map[string]string
+	attributesVersion  uint32
+	onAttributesUpdate func(types.Participant, []string)
 
 	// hold reference for MediaTrack
 	twcc *twcc.Responder
+	// pairs RTX streams with the streams they repair, nil without a buffer factory
+	rtxPairing *rtxPairingFactory
 
 	// tracks the current participant is subscribed to, map of sid => DownTrack
 	subscribedTracks map[string]types.SubscribedTrack
+	// sids of tracks being subscribed to, they count towards the subscription limit until added
+	reservedSubscriptions map[string]struct{}
 	// publishedTracks that participant is publishing
 	publishedTracks map[string]types.PublishedTrack
 	// client intended to publish, yet to be reconciled
 	pendingTracks map[string]*livekit.TrackInfo
+	// when each pending track was added, by client ID
+	pendingTracksAddedAt map[string]time.Time
+	// client IDs of pending audio tracks, by the msid track ID of the section they were matched to
+	pendingTrackIDs map[string]string
+	// publish and subscription errors waiting for the data channel to open
+	heldTrackErrors []*livekit.DataPacket
+	// sdp cids of published audio tracks negotiated as stereo
+	stereoTracks map[string]bool
+	// a subscriber offer couldn't be sent while the signal connection was down
+	offerQueued bool
+	// signal responses sent, for clients that resume from the last one they received
+	signals signalHistory
+	// highest quality the participant asked to receive tracks at, by track sid
+	subscriberQuality map[string]livekit.VideoQuality
 	// keep track of other publishers identities that we are subscribed to
 	subscribedTo sync.Map // string => struct{}
 
@@ -86,6 +160,15 @@ type ParticipantImpl struct {
 	once       sync.Once
 	updateLock sync.Mutex
 
+	// recent connection quality samples, oldest first
+	qualityLock    sync.Mutex
+	qualityHistory []types.ConnectionQualitySample
+	// transport-cc counters at the last sample, and the share of packets lost upstream since the
+	// one before
+	twccReceived  uint64
+	twccLost      uint64
+	transportLoss float64
+
 	// callbacks & handlers
 	onTrackPublished func(types.Participant, types.PublishedTrack)
 	onTrackUpdated   func(types.Participant, types.PublishedTrack)
@@ -97,35 +180,74 @@ type ParticipantImpl struct {
 
 func NewParticipant(params ParticipantParams) (*ParticipantImpl, error) {
 	// TODO: check to ensure params are valid, id and identity can't be empty
+	if params.Kind == "" {
+		params.Kind = ParticipantKindStandard
+	}
+	if params.Capabilities == (types.Capabilities{}) {
+		params.Capabilities = params.ProtocolVersion.Capabilities()
+	}
 
 	p := &ParticipantImpl{
-		params:           params,
-		id:               utils.NewGuid(utils.ParticipantPrefix),
-		rtcpCh:           make(chan []rtcp.Packet, 50),
-		pliThrottle:      newPLIThrottle(params.ThrottleConfig),
-		subscribedTracks: make(map[string]types.SubscribedTrack),
-		publishedTracks:  make(map[string]types.PublishedTrack, 0),
-		pendingTracks:    make(map[string]*livekit.TrackInfo),
-		connectedAt:      time.Now(),
-	}
+		params:                params,
+		id:                    utils.NewGuid(utils.ParticipantPrefix),
+		rtcpQueue:             sfu.NewRTCPQueue(rtcpQueueSize),
+		rembAggregator:        sfu.NewREMBAggregator(params.Config.Receiver.BitrateCap.MaxParticipantBitrate),
+		closed:                make(chan struct{}),
+		pliThrottle:           newPLIThrottle(params.ThrottleConfig),
+		dataLimiter:           newDataRateLimiter(params.DataRateLimit),
+		subscribedTracks:      make(map[string]types.SubscribedTrack),
+		reservedSubscriptions: make(map[string]struct{}),
+		publishedTracks:       make(map[string]types.PublishedTrack, 0),
+		pendingTracks:         make(map[string]*livekit.TrackInfo),
+		pendingTracksAddedAt:  make(map[string]time.Time),
+		pendingTrackIDs:       make(map[string]string),
+		stereoTracks:          make(map[string]bool),
+		connectedAt:           time.Now(),
+		subscriberQuality:     make(map[string]livekit.VideoQuality),
+	}
+	// everything logged about the participant says who it is
+	p.params.Logger = withValues(params.Logger, "participant", params.Identity, "pID", p.id)
+	p.rtcpLogger = sampled(p.params.Logger, sfu.DefaultLogSampleInterval)
+	p.rtcpQueue.OnDropped(func(pkts []rtcp.Packet) {
+		for _, pkt := range pkts {
+			prometheus.IncrementRTCPDropped(rtcpPacketType(pkt))
+		}
+	})
 	p.state.Store(livekit.ParticipantInfo_JOINING)
+	if params.Config.Receiver.BitrateCap.Enabled() {
+		p.bitrateCap = newPublisherBitrateCap(params.Config.Receiver.BitrateCap)
+	}
 
 	var err error
 	// keep last participants and when updates were sent
 	if p.updateCache, err = lru.New(32); err != nil {
 		return nil, err
 	}
-	p.publisher, err = NewPCTransport(TransportParams{
-		ParticipantID:       p.id,
-		ParticipantIdentity: p.params.Identity,
-		Target:              livekit.SignalTarget_PUBLISHER,
-		Config:              params.Config,
-		Telemetry:           p.params.Telemetry,
-		EnabledCodecs:       p.params.EnabledCodecs,
-		Logger:              params.Logger,
-	})
-	if err != nil {
-		return nil, err
+	if params.SignalOnly {
+		return p, nil
+	}
+	var publisherInterceptors []interceptor.Factory
+	if params.Config.BufferFactory != nil {
+		p.rtxPairing = newRTXPairingFactory(params.Config.BufferFactory)
+		publisherInterceptors = append(publisherInterceptors, p.rtxPairing)
+	}
+	// participants that never publish don't need a publisher transport, as long as the subscriber
+	// transport is the primary one
+	if !p.subscribeOnly() || !params.Capabilities.SubscriberPrimary {
+		p.publisher, err = NewPCTransport(TransportParams{
+			ParticipantID:       p.id,
+			ParticipantIdentity: p.params.Identity,
+			Target:              livekit.SignalTarget_PUBLISHER,
+			Config:              params.Config,
+			Telemetry:           p.params.Telemetry,
+			EnabledCodecs:       p.params.EnabledCodecs,
+			HeaderExtensions:    p.params.Policy.EnabledHeaderExtensions(),
+			Interceptors:        publisherInterceptors,
+			Logger:              p.params.Logger,
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 	p.subscriber, err = NewPCTransport(TransportParams{
 		ParticipantID:       p.id,
@@ -134,51 +256,49 @@ func NewParticipant(params ParticipantParams) (*ParticipantImpl, error) {
 		Config:              params.Config,
 		Telemetry:           p.params.Telemetry,
 		EnabledCodecs:       p.params.EnabledCodecs,
-		Logger:              params.Logger,
+		HeaderExtensions:    p.params.Policy.EnabledHeaderExtensions(),
+		Logger:              p.params.Logger,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	p.publisher.pc.OnICECandidate(func(c *webrtc.ICECandidate) {
-		if c == nil || p.State() == livekit.ParticipantInfo_DISCONNECTED {
-			return
-		}
-		p.sendIceCandidate(c, livekit.SignalTarget_PUBLISHER)
-	})
-	p.subscriber.pc.OnICECandidate(func(c *webrtc.ICECandidate) {
+	if p.publisher != nil {
+		p.publisher.OnICECandidate(func(c *webrtc.ICECandidate) {
+			if c == nil || p.State() == livekit.ParticipantInfo_DISCONNECTED {
+				return
+			}
+			p.sendIceCandidate(c, livekit.SignalTarget_PUBLISHER)
+		})
+		p.publisher.pc.OnTrack(p.onMediaTrack)
+		p.pliThrottle.onCoalescedRequest(func(pkt rtcp.Packet) {
+			if err := p.publisher.pc.WriteRTCP([]rtcp.Packet{pkt}); err != nil {
+				p.rtcpLogger.Debugw("could not write keyframe request to participant", "error", err)
+			}
+		})
+		p.publisher.pc.OnDataChannel(p.onDataChannel)
+	}
+	p.subscriber.OnICECandidate(func(c *webrtc.ICECandidate) {
 		if c == nil || p.State() == livekit.ParticipantInfo_DISCONNECTED {
 			return
 		}
 		p.sendIceCandidate(c, livekit.SignalTarget_SUBSCRIBER)
 	})
 
-	primaryPC := p.publisher.pc
-
+	var primaryPC *webrtc.PeerConnection
 	if p.SubscriberAsPrimary() {
 		primaryPC = p.subscriber.pc
-		ordered := true
 		// also create data channels for subs
-		p.reliableDCSub, err = primaryPC.CreateDataChannel(reliableDataChannel, &webrtc.DataChannelInit{
-			Ordered: &ordered,
-		})
-		if err != nil {
-			return nil, err
-		}
-		retransmits := uint16(0)
-		p.lossyDCSub, err = primaryPC.CreateDataChannel(lossyDataChannel, &webrtc.DataChannelInit{
-			Ordered:        &ordered,
-			MaxRetransmits: &retransmits,
-		})
-		if err != nil {
+		if err = p.createSubscriberDataChannels(); err != nil {
 			return nil, err
 		}
+	} else {
+		primaryPC = p.publisher.pc
 	}
 	primaryPC.OnICEConnectionStateChange(p.handlePrimaryICEStateChange)
-	p.publisher.pc.OnTrack(p.onMediaTrack)
-	p.publisher.pc.OnDataChannel(p.onDataChannel)
 
 	p.subscriber.OnOffer(p.onOffer)
+	p.subscriber.OnNegotiationFailed(p.onSubscriberNegotiationFailed)
 
 	p.subscriber.OnStreamedTracksChange(p.onStreamedTracksChange)
 
@@ -201,6 +321,15 @@ func (p *ParticipantImpl) ProtocolVersion() types.ProtocolVersion {
 	return p.params.ProtocolVersion
 }
 
+// GetLogger returns the logger of the participant, logging who it is along with everything else
+func (p *ParticipantImpl) GetLogger() logger.Logger {
+	return p.params.Logger
+}
+
+func (p *ParticipantImpl) Capabilities() types.Capabilities {
+	return p.params.Capabilities
+}
+
 func (p *ParticipantImpl) IsReady() bool {
 	state := p.State()
 	return state == livekit.ParticipantInfo_JOINED || state == livekit.ParticipantInfo_ACTIVE
@@ -219,12 +348,80 @@ func (p *ParticipantImpl) SetMetadata(metadata string) {
 	}
 }
 
+// Name returns the display name of the participant
+func (p *ParticipantImpl) Name() string {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.name
+}
+
+// SetName renames the participant. The room is told like about metadata updates
+func (p *ParticipantImpl) SetName(name string) {
+	p.lock.Lock()
+	changed := p.name != name
+	p.name = name
+	p.lock.Unlock()
+
+	if changed && p.onMetadataUpdate != nil {
+		p.onMetadataUpdate(p)
+	}
+}
+
+// SetPermission updates what the participant is allowed to do. Tracks are unpublished or
+// unsubscribed when the permission for them is revoked
 func (p *ParticipantImpl) SetPermission(permission *livekit.ParticipantPermission) {
+	couldPublish := p.CanPublish()
+	couldSubscribe := p.CanSubscribe()
 	p.permission = permission
+
+	if couldPublish && !p.CanPublish() {
+		p.unpublishTracks()
+	}
+	if couldSubscribe && !p.CanSubscribe() {
+		p.unsubscribeTracks()
+	}
 }
 
-func (p *ParticipantImpl) RTCPChan() chan []rtcp.Packet {
-	return p.rtcpCh
+// UpdateLimits applies changes to the RTC limits of a reloaded config. Tracks that are already
+// published keep their PLI throttle periods
+func (p *ParticipantImpl) UpdateLimits(conf *config.RTCConfig) {
+	p.pliThrottle.setConfig(conf.PLIThrottle)
+	p.dataLimiter.setConfig(conf.DataRateLimit)
+
+	p.lock.Lock()
+	p.params.DataBackpressure = conf.DataBackpressure
+	p.params.SubscriptionLimit = conf.SubscriptionLimit
+	p.lock.Unlock()
+}
+
+// SetDegraded caps the video forwarded to the participant at the degraded layer, and throttles the
+// keyframe requests sent for the tracks it publishes further, while the node is overloaded
+func (p *ParticipantImpl) SetDegraded(degraded bool) {
+	if !p.degraded.TrySet(degraded) {
+		return
+	}
+	factor := 1.0
+	if degraded {
+		factor = p.params.Degradation.PLIThrottleFactor
+	}
+	p.pliThrottle.setFactor(factor)
+
+	layer := p.SpatialLayerCap()
+	for _, st := range p.GetSubscribedTracks() {
+		st.CapSpatialLayer(layer)
+	}
+}
+
+func (p *ParticipantImpl) SpatialLayerCap() int32 {
+	if !p.degraded.Get() {
+		return sfu.InvalidSpatialLayer
+	}
+	return p.params.Degradation.MaxSpatialLayer
+}
+
+// RTCPQueue returns the queue of the RTCP feedback written to the publisher
+func (p *ParticipantImpl) RTCPQueue() *sfu.RTCPQueue {
+	return p.rtcpQueue
 }
 
 func (p *ParticipantImpl) ToProto() *livekit.ParticipantInfo {
@@ -236,8 +433,14 @@ func (p *ParticipantImpl) ToProto() *livekit.ParticipantInfo {
 		JoinedAt: p.ConnectedAt().Unix(),
 		Hidden:   p.Hidden(),
 	}
+	if p.params.SignalOnly {
+		setSignalOnly(info)
+	}
 
 	p.lock.RLock()
+	if p.name != "" {
+		setParticipantName(info, p.name)
+	}
 	for _, t := range p.publishedTracks {
 		info.Tracks = append(info.Tracks, t.ToProto())
 	}
@@ -249,14 +452,65 @@ func (p *ParticipantImpl) GetResponseSink() routing.MessageSink {
 	return p.params.Sink
 }
 
+// SetResponseSink attaches the signal connection of a reconnected client. Responses it missed are
+// sent again when it resumed from the last one it received, and subscriber offers that were lost
+// while it was down are replayed as one, the latest
 func (p *ParticipantImpl) SetResponseSink(sink routing.MessageSink) {
-	p.params.Sink = sink
+	queued := false
+	replayed, dropped, offered := p.signals.attach(func() {
+		p.lock.Lock()
+		p.params.Sink = sink
+		queued = p.offerQueued
+		p.offerQueued = false
+		p.lock.Unlock()
+	}, p.writeToSink)
+	if replayed != 0 || dropped != 0 {
+		p.params.Logger.Debugw("replayed signal responses",
+			"replayed", replayed, "dropped", dropped)
+	}
+
+	if (queued || offered) && sink != nil {
+		p.params.Logger.Debugw("replaying queued server offer")
+		p.subscriber.ResendOffer()
+	}
+}
+
+// AckSignal drops the signal responses up to seq, the client received them
+func (p *ParticipantImpl) AckSignal(seq uint32) {
+	p.signals.ack(seq)
+}
+
+// ResumeSignal sends the signal responses after seq again once the next response sink is set,
+// the client missed them while its signal connection was down
+func (p *ParticipantImpl) ResumeSignal(seq uint32) {
+	p.signals.resume(seq)
 }
 
 func (p *ParticipantImpl) SubscriberMediaEngine() *webrtc.MediaEngine {
+	if p.subscriber == nil {
+		return nil
+	}
 	return p.subscriber.me
 }
 
+func (p *ParticipantImpl) SubscriberPacer() *sfu.Pacer {
+	if p.subscriber == nil {
+		return nil
+	}
+	return p.subscriber.pacer
+}
+
+func (p *ParticipantImpl) SubscriberMediaDelay() time.Duration {
+	if emulation := p.params.Config.NetworkEmulation; emulation != nil {
+		return emulation.Delay
+	}
+	return 0
+}
+
+func (p *ParticipantImpl) LowPowerMode() string {
+	return p.params.LowPowerMode
+}
+
 // callbacks for clients
 
 func (p *ParticipantImpl) OnTrackPublished(callback func(types.Participant, types.PublishedTrack)) {
@@ -285,17 +539,24 @@ func (p *ParticipantImpl) OnClose(callback func(types.Participant)) {
 
 // HandleOffer an offer from remote participant, used when clients make the initial connection
 func (p *ParticipantImpl) HandleOffer(sdp webrtc.SessionDescription) (answer webrtc.SessionDescription, err error) {
-	p.params.Logger.Debugw("answering pub offer", "state", p.State().String(),
-		"participant", p.Identity(), "pID", p.ID(),
-		//"sdp", sdp.SDP,
-	)
+	p.params.Logger.Debugw("answering pub offer", "state", p.State().String()) //"sdp", sdp.SDP,
 
+	if p.publisher == nil {
+		err = ErrNoPublisher
+		return
+	}
 	if err = p.publisher.SetRemoteDescription(sdp); err != nil {
 		prometheus.ServiceOperationCounter.WithLabelValues("answer", "error", "remote_description").Add(1)
 		return
 	}
 
-	p.configureReceiverDTX()
+	p.closeRemovedTracks(sdp)
+	p.configureReceiverOpus(sdp)
+	if p.rtxPairing != nil {
+		if err := p.rtxPairing.addFIDGroups(sdp); err != nil {
+			p.params.Logger.Warnw("could not parse offer for RTX streams", err)
+		}
+	}
 
 	answer, err = p.publisher.pc.CreateAnswer(nil)
 	if err != nil {
@@ -303,6 +564,11 @@ func (p *ParticipantImpl) HandleOffer(sdp webrtc.SessionDescription) (answer web
 		err = errors.Wrap(err, "could not create answer")
 		return
 	}
+	if answer, err = applyPublishPolicy(answer, p.params.Policy); err != nil {
+		prometheus.ServiceOperationCounter.WithLabelValues("answer", "error", "policy").Add(1)
+		err = errors.Wrap(err, "could not apply publish policy")
+		return
+	}
 
 	if err = p.publisher.pc.SetLocalDescription(answer); err != nil {
 		prometheus.ServiceOperationCounter.WithLabelValues("answer", "error", "local_description").Add(1)
@@ -310,10 +576,8 @@ func (p *ParticipantImpl) HandleOffer(sdp webrtc.SessionDescription) (answer web
 		return
 	}
 
-	p.params.Logger.Debugw("sending answer to client",
-		"participant", p.Identity(), "pID", p.ID(),
-		//"answer sdp", answer.SDP,
-	)
+	p.params.Logger.Debugw("sending answer to client") //"answer sdp", answer.SDP,
+
 	err = p.writeMessage(&livekit.SignalResponse{
 		Message: &livekit.SignalResponse_Answer{
 			Answer: ToProtoSessionDescription(answer),
@@ -335,6 +599,17 @@ func (p *ParticipantImpl) HandleOffer(sdp webrtc.SessionDescription) (answer web
 // AddTrack is called when client intends to publish track.
 // records track details and lets client know it's ok to proceed
 func (p *ParticipantImpl) AddTrack(req *livekit.AddTrackRequest) {
+	if !p.CanPublish() {
+		p.params.Logger.Warnw("no permission to publish track", nil)
+		p.sendTrackPublishFailed(req.Cid, ErrCannotPublish)
+		return
+	}
+	if !p.CanPublishSource(req.Source) {
+		p.params.Logger.Warnw("no permission to publish track source", nil, "source", req.Source.String())
+		p.sendTrackPublishFailed(req.Cid, ErrCannotPublishSource)
+		return
+	}
+
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
@@ -347,12 +622,6 @@ func (p *ParticipantImpl) AddTrack(req *livekit.AddTrackRequest) {
 		return
 	}
 
-	if !p.CanPublish() {
-		p.params.Logger.Warnw("no permission to publish track", nil,
-			"participant", p.Identity(), "pID", p.ID())
-		return
-	}
-
 	ti := &livekit.TrackInfo{
 		Type:       req.Type,
 		Name:       req.Name,
@@ -363,7 +632,7 @@ func (p *ParticipantImpl) AddTrack(req *livekit.AddTrackRequest) {
 		DisableDtx: req.DisableDtx,
 		Source:     req.Source,
 	}
-	p.pendingTracks[req.Cid] = ti
+	p.addPendingTrack(req.Cid, ti)
 
 	_ = p.writeMessage(&livekit.SignalResponse{
 		Message: &livekit.SignalResponse_TrackPublished{
@@ -381,11 +650,11 @@ func (p *ParticipantImpl) HandleAnswer(sdp webrtc.SessionDescription) error {
 	if sdp.Type != webrtc.SDPTypeAnswer {
 		return ErrUnexpectedOffer
 	}
-	p.params.Logger.Debugw("setting subPC answer",
-		"participant", p.Identity(), "pID", p.ID(),
-		//"sdp", sdp.SDP,
-	)
+	p.params.Logger.Debugw("setting subPC answer") //"sdp", sdp.SDP,
 
+	if p.subscriber == nil {
+		return ErrNoSubscriber
+	}
 	if err := p.subscriber.SetRemoteDescription(sdp); err != nil {
 		return errors.Wrap(err, "could not set remote description")
 	}
@@ -397,8 +666,14 @@ func (p *ParticipantImpl) HandleAnswer(sdp webrtc.SessionDescription) error {
 func (p *ParticipantImpl) AddICECandidate(candidate webrtc.ICECandidateInit, target livekit.SignalTarget) error {
 	var err error
 	if target == livekit.SignalTarget_PUBLISHER {
+		if p.publisher == nil {
+			return ErrNoPublisher
+		}
 		err = p.publisher.AddICECandidate(candidate)
 	} else {
+		if p.subscriber == nil {
+			return ErrNoSubscriber
+		}
 		err = p.subscriber.AddICECandidate(candidate)
 	}
 	return err
@@ -407,11 +682,27 @@ func (p *ParticipantImpl) AddICECandidate(candidate webrtc.ICECandidateInit, tar
 func (p *ParticipantImpl) Start() {
 	p.once.Do(func() {
 		go p.rtcpSendWorker()
-		go p.downTracksRTCPWorker()
+		if p.subscriber != nil {
+			go p.downTracksRTCPWorker()
+		}
+		if p.bitrateCap != nil && p.publisher != nil {
+			go p.publisherBitrateCapWorker()
+		}
+		if p.params.PendingTrackTimeout > 0 {
+			go p.pendingTracksWorker()
+		}
+		if !p.params.SignalOnly {
+			time.AfterFunc(dataChannelOpenTimeout, p.checkDataChannels)
+		}
 	})
 }
 
 func (p *ParticipantImpl) Close() error {
+	return p.close(&livekit.LeaveRequest{})
+}
+
+// close disconnects the participant, sending it leave
+func (p *ParticipantImpl) close(leave *livekit.LeaveRequest) error {
 	if !p.isClosed.TrySet(true) {
 		// already closed
 		return nil
@@ -420,7 +711,7 @@ func (p *ParticipantImpl) Close() error {
 	// send leave message
 	_ = p.writeMessage(&livekit.SignalResponse{
 		Message: &livekit.SignalResponse_Leave{
-			Leave: &livekit.LeaveRequest{},
+			Leave: leave,
 		},
 	})
 
@@ -431,6 +722,10 @@ func (p *ParticipantImpl) Close() error {
 		t.RemoveAllSubscribers()
 	}
 
+	for cid := range p.pendingTracks {
+		p.removePendingTrack(cid)
+	}
+
 	var downtracksToClose []*sfu.DownTrack
 	for _, st := range p.subscribedTracks {
 		downtracksToClose = append(downtracksToClose, st.DownTrack())
@@ -451,19 +746,28 @@ func (p *ParticipantImpl) Close() error {
 	if onClose != nil {
 		onClose(p)
 	}
-	p.publisher.Close()
-	p.subscriber.Close()
-	close(p.rtcpCh)
+	if p.publisher != nil {
+		p.publisher.Close()
+	}
+	if p.subscriber != nil {
+		p.subscriber.Close()
+	}
+	p.pliThrottle.close()
+	p.rtcpQueue.Close()
+	close(p.closed)
 	return nil
 }
 
 func (p *ParticipantImpl) Negotiate() {
+	if p.subscriber == nil {
+		return
+	}
 	p.subscriber.Negotiate()
 }
 
 // ICERestart restarts subscriber ICE connections
 func (p *ParticipantImpl) ICERestart() error {
-	if p.subscriber.pc.RemoteDescription() == nil {
+	if p.subscriber == nil || p.subscriber.pc.RemoteDescription() == nil {
 		// not connected, skip
 		return nil
 	}
@@ -488,6 +792,7 @@ func (p *ParticipantImpl) AddSubscriber(op types.Participant) (int, error) {
 	n := 0
 	for _, track := range tracks {
 		if err := track.AddSubscriber(op); err != nil {
+			op.SendSubscriptionError(track.ID(), err)
 			return n, err
 		}
 		n += 1
@@ -512,7 +817,7 @@ func (p *ParticipantImpl) SendJoinResponse(
 	iceServers []*livekit.ICEServer,
 ) error {
 	// send Join response
-	return p.writeMessage(&livekit.SignalResponse{
+	err := p.writeMessage(&livekit.SignalResponse{
 		Message: &livekit.SignalResponse_Join{
 			Join: &livekit.JoinResponse{
 				Room:              roomInfo,
@@ -525,28 +830,79 @@ func (p *ParticipantImpl) SendJoinResponse(
 			},
 		},
 	})
+	if err == nil && p.params.SignalOnly {
+		// there's no connection to establish, the participant is active once it knows the room.
+		// The room is still joining it, state changes are handled once it's done
+		go p.updateState(livekit.ParticipantInfo_ACTIVE)
+	}
+	return err
+}
+
+// SendParticipantPage sends the participants of a page the participant asked for, as a participant
+// update. next is the sid to ask for the following page after, empty on the last page
+func (p *ParticipantImpl) SendParticipantPage(requestID string, participants []*livekit.ParticipantInfo, next string) error {
+	return p.writeMessage(NewParticipantPageResponse(&ParticipantPage{
+		RequestID:    requestID,
+		Participants: participants,
+		Next:         next,
+	}))
 }
 
+// SendTextMessage sends a JSON text message the signal protocol has no message for, like the
+// response to a text request
+func (p *ParticipantImpl) SendTextMessage(key string, value []byte) error {
+	return p.writeMessage(NewSignalTextResponse(key, value))
+}
+
+// sendSubscribedQuality tells the participant up to which layer subscribers receive its track, so
+// that it can pause the layers above
+func (p *ParticipantImpl) sendSubscribedQuality(trackID string, maxLayer int32) {
+	p.params.Logger.Debugw("subscribed layer changed", "track", trackID, "maxLayer", maxLayer)
+	_ = p.writeMessage(NewSubscribedQualityResponse(&SubscribedQualityUpdate{
+		TrackSid: trackID,
+		MaxLayer: maxLayer,
+	}))
+}
+
+// SendParticipantUpdate sends the state of participants as of updatedAt. Participants whose more
+// recent state was already sent are left out of the update
 func (p *ParticipantImpl) SendParticipantUpdate(participantsToUpdate []*livekit.ParticipantInfo, updatedAt time.Time) error {
-	if len(participantsToUpdate) == 1 {
-		p.updateLock.Lock()
-		defer p.updateLock.Unlock()
-		pi := participantsToUpdate[0]
+	p.updateLock.Lock()
+	defer p.updateLock.Unlock()
+
+	// the slice is shared with the other participants updated, it's copied when some are left out
+	var updates []*livekit.ParticipantInfo
+	filtered := false
+	for i, pi := range participantsToUpdate {
 		if val, ok := p.updateCache.Get(pi.Sid); ok {
 			if lastUpdatedAt, ok := val.(time.Time); ok {
 				// this is a message delivered out of order, a more recent version of the message had already been
 				// sent.
 				if lastUpdatedAt.After(updatedAt) {
-					return nil
+					if !filtered {
+						updates = append(updates, participantsToUpdate[:i]...)
+						filtered = true
+					}
+					continue
 				}
 			}
 		}
 		p.updateCache.Add(pi.Sid, updatedAt)
+		if filtered {
+			updates = append(updates, pi)
+		}
 	}
+	if !filtered {
+		updates = participantsToUpdate
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
 	return p.writeMessage(&livekit.SignalResponse{
 		Message: &livekit.SignalResponse_Update{
 			Update: &livekit.ParticipantUpdate{
-				Participants: participantsToUpdate,
+				Participants: updates,
 			},
 		},
 	})
@@ -571,6 +927,9 @@ func (p *ParticipantImpl) SendDataPacket(dp *livekit.DataPacket) error {
 	if p.State() != livekit.ParticipantInfo_ACTIVE {
 		return ErrDataChannelUnavailable
 	}
+	if p.sendsDataOverSignal(dp.Kind) {
+		return p.sendSignalData(dp)
+	}
 
 	data, err := proto.Marshal(dp)
 	if err != nil {
@@ -579,22 +938,29 @@ func (p *ParticipantImpl) SendDataPacket(dp *livekit.DataPacket) error {
 
 	var dc *webrtc.DataChannel
 	if dp.Kind == livekit.DataPacket_RELIABLE {
-		if p.SubscriberAsPrimary() {
-			dc = p.reliableDCSub
-		} else {
-			dc = p.reliableDC
-		}
+		dc = p.reliableDataChannel()
 	} else {
-		if p.SubscriberAsPrimary() {
-			dc = p.lossyDCSub
-		} else {
-			dc = p.lossyDC
-		}
+		dc = p.lossyDataChannel()
 	}
 
-	if dc == nil {
+	if !isDataChannelOpen(dc) {
 		return ErrDataChannelUnavailable
 	}
+
+	// don't let a participant that isn't keeping up accumulate unbounded buffers
+	p.lock.RLock()
+	backpressure := p.params.DataBackpressure
+	p.lock.RUnlock()
+	buffered := dc.BufferedAmount()
+	if dp.Kind == livekit.DataPacket_LOSSY {
+		if limit := backpressure.LossyBufferedAmount; limit > 0 && buffered > limit {
+			prometheus.IncrementDataPacketDropped(dp.Kind.String(), prometheus.DataDropReasonCongested)
+			return ErrDataChannelCongested
+		}
+	} else if limit := backpressure.MaxBufferedAmount; limit > 0 && buffered+uint64(len(data)) > limit {
+		prometheus.IncrementDataPacketDropped(dp.Kind.String(), prometheus.DataDropReasonBufferFull)
+		return ErrDataChannelBufferFull
+	}
 	return dc.Send(data)
 }
 
@@ -651,14 +1017,85 @@ func (p *ParticipantImpl) SetTrackMuted(trackId string, muted bool, fromAdmin bo
 
 	if currentMuted != track.IsMuted() && p.onTrackUpdated != nil {
 		p.params.Logger.Debugw("mute status changed",
-			"participant", p.Identity(),
-			"pID", p.ID(),
 			"track", trackId,
 			"muted", track.IsMuted())
 		p.onTrackUpdated(p, track)
 	}
 }
 
+// UnpublishTrack removes a published track, closing its receiver and the down tracks of its
+// subscribers. The participant is told, so that it stops sending the track
+func (p *ParticipantImpl) UnpublishTrack(trackSid string) error {
+	p.lock.Lock()
+	track := p.publishedTracks[trackSid]
+	if track == nil {
+		p.lock.Unlock()
+		return ErrTrackNotFound
+	}
+	delete(p.publishedTracks, trackSid)
+	p.lock.Unlock()
+
+	p.params.Logger.Infow("unpublishing track",
+		"track", trackSid)
+	p.closePublishedTrack(track)
+
+	if p.Capabilities().DataPackets {
+		dp, err := newServerMessagePacket(&trackUnpublishedMessage{
+			Type:     trackUnpublishedMessageType,
+			TrackSid: trackSid,
+		})
+		if err == nil {
+			err = p.SendDataPacket(dp)
+		}
+		if err != nil {
+			p.params.Logger.Debugw("could not send unpublished track", "error", err, "track", trackSid)
+		}
+	}
+	return nil
+}
+
+// closePublishedTrack stops forwarding a track that was removed from the published tracks, and
+// tells the room that it's gone
+func (p *ParticipantImpl) closePublishedTrack(track types.PublishedTrack) {
+	track.RemoveAllSubscribers()
+	track.Close()
+	if p.onTrackUpdated != nil {
+		p.onTrackUpdated(p, track)
+	}
+}
+
+// closeRemovedTracks closes the published tracks that the publisher no longer sends according to
+// its offer, instead of waiting for their RTP to time out
+func (p *ParticipantImpl) closeRemovedTracks(offer webrtc.SessionDescription) {
+	sent, ok, err := sentTrackIDs(offer)
+	if err != nil {
+		p.params.Logger.Warnw("could not parse offer for removed tracks", err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	p.lock.Lock()
+	var removed []types.PublishedTrack
+	for sid, track := range p.publishedTracks {
+		if track.SdpCid() == "" {
+			continue
+		}
+		if _, ok := sent[track.SdpCid()]; !ok {
+			removed = append(removed, track)
+			delete(p.publishedTracks, sid)
+		}
+	}
+	p.lock.Unlock()
+
+	for _, track := range removed {
+		p.params.Logger.Infow("unpublishing track, removed from offer",
+			"track", track.ID())
+		p.closePublishedTrack(track)
+	}
+}
+
 func (p *ParticipantImpl) GetAudioLevel() (level uint8, active bool) {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
@@ -680,50 +1117,130 @@ func (p *ParticipantImpl) GetAudioLevel() (level uint8, active bool) {
 	return
 }
 
-func (p *ParticipantImpl) GetConnectionQuality() livekit.ConnectionQuality {
-	// avg loss across all tracks, weigh published the same as subscribed
-	var pubLoss, subLoss uint32
-	var reducedQualityPub bool
-	var reducedQualitySub bool
-	p.lock.RLock()
-	defer p.lock.RUnlock()
-	for _, pubTrack := range p.publishedTracks {
-		if pubTrack.IsMuted() {
-			continue
-		}
-		pubLoss += pubTrack.PublishLossPercentage()
-		publishing, registered := pubTrack.NumUpTracks()
-		if registered > 0 && publishing != registered {
-			reducedQualityPub = true
+// SetAudioConfig changes the thresholds of the audio levels of the participant's audio tracks, to
+// those of the room's audio config
+func (p *ParticipantImpl) SetAudioConfig(conf config.AudioConfig) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.params.AudioConfig.ActiveLevel = conf.ActiveLevel
+	p.params.AudioConfig.MinPercentile = conf.MinPercentile
+	for _, pt := range p.publishedTracks {
+		if mt, ok := pt.(*MediaTrack); ok {
+			mt.SetAudioConfig(conf)
 		}
 	}
-	numTracks := uint32(len(p.publishedTracks))
-	if numTracks > 0 {
-		pubLoss /= numTracks
+}
+
+// GetConnectionQuality computes the current connection quality and records it in the quality history
+func (p *ParticipantImpl) GetConnectionQuality() livekit.ConnectionQuality {
+	p.sampleTransportLoss()
+	quality := p.connectionQuality()
+
+	p.qualityLock.Lock()
+	p.qualityHistory = append(p.qualityHistory, types.ConnectionQualitySample{
+		Time:    time.Now(),
+		Quality: quality.String(),
+	})
+	if len(p.qualityHistory) > qualityHistorySize {
+		p.qualityHistory = p.qualityHistory[len(p.qualityHistory)-qualityHistorySize:]
 	}
+	p.qualityLock.Unlock()
+
+	return quality
+}
+
+func (p *ParticipantImpl) connectionQuality() livekit.ConnectionQuality {
+	score, _, _ := p.scoreTracks()
+	return scoreToConnectionQuality(score)
+}
 
-	for _, subTrack := range p.subscribedTracks {
-		if subTrack.IsMuted() {
+// scoreTracks scores each unmuted track, returning the participant's overall score along with the
+// stats it was computed from. Published and subscribed tracks are weighed the same
+func (p *ParticipantImpl) scoreTracks() (float32, []*types.PublishedTrackStats, []*types.SubscribedTrackStats) {
+	var pubScore, subScore float32
+	var numPub, numSub int
+
+	upstream := p.upstreamStats()
+	var published []*types.PublishedTrackStats
+	for _, t := range p.GetPublishedTracks() {
+		stats := t.GetStats()
+		if stats == nil {
+			stats = &types.PublishedTrackStats{TrackID: t.ID(), Kind: t.Kind().String(), Muted: t.IsMuted()}
+		}
+		published = append(published, stats)
+		if t.IsMuted() {
 			continue
 		}
-		if subTrack.DownTrack().GetForwardingStatus() != sfu.ForwardingStatusOptimal {
-			reducedQualitySub = true
+		q := publishedTrackQuality(t, stats, upstream)
+		stats.LossPercentage = float32(q.lossPercentage)
+		stats.RTTMs = uint32(q.rttMs)
+		stats.Score = q.score()
+		pubScore += stats.Score
+		numPub++
+	}
+
+	var subscribed []*types.SubscribedTrackStats
+	for _, t := range p.GetSubscribedTracks() {
+		stats := t.GetStats()
+		if stats == nil {
+			stats = &types.SubscribedTrackStats{TrackID: t.ID(), PublisherIdentity: t.PublisherIdentity()}
+		}
+		subscribed = append(subscribed, stats)
+		if t.IsMuted() || stats.Muted {
+			continue
 		}
-		subLoss += subTrack.SubscribeLossPercentage()
+		stats.Score = subscribedTrackQuality(t, stats).score()
+		subScore += stats.Score
+		numSub++
 	}
-	numTracks = uint32(len(p.subscribedTracks))
-	if numTracks > 0 {
-		subLoss /= numTracks
+
+	switch {
+	case numPub > 0 && numSub > 0:
+		return (pubScore/float32(numPub) + subScore/float32(numSub)) / 2, published, subscribed
+	case numPub > 0:
+		return pubScore / float32(numPub), published, subscribed
+	case numSub > 0:
+		return subScore / float32(numSub), published, subscribed
 	}
+	return maxMOS, published, subscribed
+}
 
-	avgLoss := (pubLoss + subLoss) / 2
-	if avgLoss >= 4 {
-		return livekit.ConnectionQuality_POOR
-	} else if avgLoss <= 2 && !reducedQualityPub && !reducedQualitySub {
-		return livekit.ConnectionQuality_EXCELLENT
+// sampleTransportLoss updates the share of packets the participant published that were reported
+// as lost in transport-cc feedback since the last sample
+func (p *ParticipantImpl) sampleTransportLoss() {
+	p.lock.RLock()
+	responder := p.twcc
+	p.lock.RUnlock()
+	if responder == nil {
+		return
 	}
+	received, lost := responder.Stats()
+
+	p.qualityLock.Lock()
+	defer p.qualityLock.Unlock()
+	p.transportLoss = 0
+	if total := received - p.twccReceived + lost - p.twccLost; total > 0 {
+		p.transportLoss = float64(lost-p.twccLost) * 100 / float64(total)
+	}
+	p.twccReceived = received
+	p.twccLost = lost
+}
+
+// upstreamStats returns the loss and round trip time of the participant's publisher connection.
+// Publishers don't send reports the round trip time can be computed from, it's estimated from the
+// receiver reports of the participant's subscriptions, which go over the same network path
+func (p *ParticipantImpl) upstreamStats() upstreamStats {
+	var upstream upstreamStats
+	p.qualityLock.Lock()
+	upstream.transportLossPercentage = p.transportLoss
+	p.qualityLock.Unlock()
 
-	return livekit.ConnectionQuality_GOOD
+	for _, t := range p.GetSubscribedTracks() {
+		if dt := t.DownTrack(); dt != nil {
+			upstream.rttMs = math.Max(upstream.rttMs, float64(dt.RTT()))
+		}
+	}
+	return upstream
 }
 
 func (p *ParticipantImpl) IsSubscribedTo(identity string) bool {
@@ -743,10 +1260,33 @@ func (p *ParticipantImpl) GetSubscribedParticipants() []string {
 }
 
 func (p *ParticipantImpl) CanPublish() bool {
+	if p.subscribeOnly() || p.params.SignalOnly {
+		return false
+	}
 	return p.permission == nil || p.permission.CanPublish
 }
 
+// CanPublishSource returns true when the participant may publish tracks from source
+func (p *ParticipantImpl) CanPublishSource(source livekit.TrackSource) bool {
+	return isPublishSourceAllowed(p.params.PublishSources, source)
+}
+
+// CanApproveJoins returns true when the participant approves the joins of others waiting in the
+// room's waiting room
+func (p *ParticipantImpl) CanApproveJoins() bool {
+	return p.params.CanApproveJoins
+}
+
+// CanSubscribeTo returns true when the participant may subscribe to the tracks of the publisher with
+// the given identity
+func (p *ParticipantImpl) CanSubscribeTo(identity string) bool {
+	return isIdentityAllowed(p.params.CanSubscribeTo, identity)
+}
+
 func (p *ParticipantImpl) CanSubscribe() bool {
+	if p.params.SignalOnly {
+		return false
+	}
 	return p.permission == nil || p.permission.CanSubscribe
 }
 
@@ -755,14 +1295,30 @@ func (p *ParticipantImpl) CanPublishData() bool {
 }
 
 func (p *ParticipantImpl) Hidden() bool {
-	return p.params.Hidden
+	return IsHiddenKind(p.params.Kind)
+}
+
+func (p *ParticipantImpl) Kind() string {
+	return p.params.Kind
+}
+
+// SignalOnly returns true when the participant has no WebRTC transports, it's in the room for
+// presence and data only
+func (p *ParticipantImpl) SignalOnly() bool {
+	return p.params.SignalOnly
 }
 
 func (p *ParticipantImpl) SubscriberAsPrimary() bool {
-	return p.ProtocolVersion().SubscriberAsPrimary() && p.CanSubscribe()
+	if p.publisher == nil {
+		return true
+	}
+	return p.Capabilities().SubscriberPrimary && p.CanSubscribe()
 }
 
 func (p *ParticipantImpl) SubscriberPC() *webrtc.PeerConnection {
+	if p.subscriber == nil {
+		return nil
+	}
 	return p.subscriber.pc
 }
 
@@ -772,6 +1328,42 @@ func (p *ParticipantImpl) GetPublishedTrack(sid string) types.PublishedTrack {
 	return p.publishedTracks[sid]
 }
 
+func (p *ParticipantImpl) SetSubscriberQuality(trackSid string, quality livekit.VideoQuality) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.subscriberQuality[trackSid] = quality
+}
+
+func (p *ParticipantImpl) SubscriberQuality(trackSid string) (livekit.VideoQuality, bool) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	quality, ok := p.subscriberQuality[trackSid]
+	return quality, ok
+}
+
+// GetStats returns live media stats for all of the participant's published and subscribed tracks
+func (p *ParticipantImpl) GetStats() *types.ParticipantStats {
+	score, published, subscribed := p.scoreTracks()
+	attributes, _ := participantAttributes(p)
+	stats := &types.ParticipantStats{
+		Identity:          p.Identity(),
+		ParticipantID:     p.ID(),
+		ConnectionQuality: scoreToConnectionQuality(score).String(),
+		Score:             score,
+		PublishedTracks:   published,
+		SubscribedTracks:  subscribed,
+		Name:              p.Name(),
+		Kind:              p.Kind(),
+		Attributes:        attributes,
+	}
+
+	p.qualityLock.Lock()
+	stats.QualityHistory = append([]types.ConnectionQualitySample{}, p.qualityHistory...)
+	p.qualityLock.Unlock()
+
+	return stats
+}
+
 func (p *ParticipantImpl) GetPublishedTracks() []types.PublishedTrack {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
@@ -798,12 +1390,56 @@ func (p *ParticipantImpl) GetSubscribedTracks() []types.SubscribedTrack {
 	return subscribed
 }
 
+func (p *ParticipantImpl) ReserveSubscription(trackID string) error {
+	p.lock.Lock()
+	limit := p.params.SubscriptionLimit
+	if limit.MaxSubscriptions <= 0 || len(p.subscribedTracks)+len(p.reservedSubscriptions) < limit.MaxSubscriptions {
+		p.reservedSubscriptions[trackID] = struct{}{}
+		p.lock.Unlock()
+		return nil
+	}
+	if limit.Policy != config.SubscriptionLimitPolicyEvict {
+		p.lock.Unlock()
+		return ErrSubscriptionLimit
+	}
+
+	// tracks the client shows aren't evicted, their last visible time is now
+	now := time.Now()
+	var evicted types.SubscribedTrack
+	for _, st := range p.subscribedTracks {
+		if lastVisible := st.LastVisible(); lastVisible.Before(now) && (evicted == nil || lastVisible.Before(evicted.LastVisible())) {
+			evicted = st
+		}
+	}
+	if evicted == nil {
+		p.lock.Unlock()
+		return ErrSubscriptionLimit
+	}
+	// removed right away rather than when the down track has closed, so that it isn't picked again
+	delete(p.subscribedTracks, evicted.ID())
+	p.reservedSubscriptions[trackID] = struct{}{}
+	p.lock.Unlock()
+
+	p.params.Logger.Infow("subscription limit reached, unsubscribing least recently visible track",
+		"track", evicted.ID(),
+		"publisher", evicted.PublisherIdentity(),
+		"lastVisible", evicted.LastVisible())
+	evicted.DownTrack().Close()
+	return nil
+}
+
+func (p *ParticipantImpl) ReleaseSubscription(trackID string) {
+	p.lock.Lock()
+	delete(p.reservedSubscriptions, trackID)
+	p.lock.Unlock()
+}
+
 // AddSubscribedTrack adds a track to the participant's subscribed list
 func (p *ParticipantImpl) AddSubscribedTrack(subTrack types.SubscribedTrack) {
-	p.params.Logger.Debugw("added subscribedTrack", "publisher", subTrack.PublisherIdentity(),
-		"participant", p.Identity(), "track", subTrack.ID())
+	p.params.Logger.Debugw("added subscribedTrack", "publisher", subTrack.PublisherIdentity(), "track", subTrack.ID())
 	p.lock.Lock()
 	p.subscribedTracks[subTrack.ID()] = subTrack
+	delete(p.reservedSubscriptions, subTrack.ID())
 	p.lock.Unlock()
 
 	p.subscriber.AddTrack(subTrack)
@@ -813,7 +1449,7 @@ func (p *ParticipantImpl) AddSubscribedTrack(subTrack types.SubscribedTrack) {
 // RemoveSubscribedTrack removes a track to the participant's subscribed list
 func (p *ParticipantImpl) RemoveSubscribedTrack(subTrack types.SubscribedTrack) {
 	p.params.Logger.Debugw("removed subscribedTrack", "publisher", subTrack.PublisherIdentity(),
-		"participant", p.Identity(), "track", subTrack.ID(), "kind", subTrack.DownTrack().Kind())
+		"track", subTrack.ID(), "kind", subTrack.DownTrack().Kind())
 
 	p.subscriber.RemoveTrack(subTrack)
 
@@ -837,8 +1473,6 @@ func (p *ParticipantImpl) sendIceCandidate(c *webrtc.ICECandidate, target liveki
 
 	// write candidate
 	p.params.Logger.Debugw("sending ice candidates",
-		"participant", p.Identity(),
-		"pID", p.ID(),
 		"candidate", c.String())
 	trickle := ToProtoTrickle(ci)
 	trickle.Target = target
@@ -855,7 +1489,10 @@ func (p *ParticipantImpl) updateState(state livekit.ParticipantInfo_State) {
 		return
 	}
 	p.state.Store(state)
-	p.params.Logger.Debugw("updating participant state", "state", state.String(), "participant", p.Identity(), "pID", p.ID())
+	p.params.Logger.Debugw("updating participant state", "state", state.String())
+	if state == livekit.ParticipantInfo_ACTIVE {
+		go p.flushTrackErrors()
+	}
 	p.lock.RLock()
 	onStateChange := p.onStateChange
 	p.lock.RUnlock()
@@ -871,6 +1508,13 @@ func (p *ParticipantImpl) writeMessage(msg *livekit.SignalResponse) error {
 	if p.State() == livekit.ParticipantInfo_DISCONNECTED {
 		return nil
 	}
+	if !p.params.SequenceSignal {
+		return p.writeToSink(msg)
+	}
+	return p.signals.send(msg, p.writeToSink)
+}
+
+func (p *ParticipantImpl) writeToSink(msg *livekit.SignalResponse) error {
 	sink := p.params.Sink
 	if sink == nil {
 		return nil
@@ -878,8 +1522,6 @@ func (p *ParticipantImpl) writeMessage(msg *livekit.SignalResponse) error {
 	err := sink.WriteMessage(msg)
 	if err != nil {
 		p.params.Logger.Warnw("could not send message to participant", err,
-			"pID", p.ID(),
-			"participant", p.Identity(),
 			"message", fmt.Sprintf("%T", msg.Message))
 		return err
 	}
@@ -889,28 +1531,56 @@ func (p *ParticipantImpl) writeMessage(msg *livekit.SignalResponse) error {
 // when the server has an offer for participant
 func (p *ParticipantImpl) onOffer(offer webrtc.SessionDescription) {
 	if p.State() == livekit.ParticipantInfo_DISCONNECTED {
-		p.params.Logger.Debugw("skipping server offer", "participant", p.Identity(), "pID", p.ID())
+		p.params.Logger.Debugw("skipping server offer")
 		// skip when disconnected
 		return
 	}
 
-	p.params.Logger.Debugw("sending server offer to participant",
-		"participant", p.Identity(), "pID", p.ID(),
-		//"sdp", offer.SDP,
-	)
+	p.params.Logger.Debugw("sending server offer to participant") //"sdp", offer.SDP,
 
-	err := p.writeMessage(&livekit.SignalResponse{
-		Message: &livekit.SignalResponse_Offer{
-			Offer: ToProtoSessionDescription(offer),
-		},
-	})
-	if err != nil {
+	p.lock.RLock()
+	sink := p.params.Sink
+	p.lock.RUnlock()
+	var err error
+	if sink != nil {
+		err = p.writeMessage(&livekit.SignalResponse{
+			Message: &livekit.SignalResponse_Offer{
+				Offer: ToProtoSessionDescription(offer),
+			},
+		})
+	}
+	if sink == nil || err != nil {
+		// the client answers the latest offer once it reconnects
+		p.params.Logger.Debugw("queueing server offer until the signal connection is back")
+		p.lock.Lock()
+		// the client may have reconnected in the meantime
+		reconnected := p.params.Sink != sink && p.params.Sink != nil
+		p.offerQueued = !reconnected
+		p.lock.Unlock()
+		if reconnected {
+			p.subscriber.ResendOffer()
+		}
 		prometheus.ServiceOperationCounter.WithLabelValues("offer", "error", "write_message").Add(1)
 	} else {
 		prometheus.ServiceOperationCounter.WithLabelValues("offer", "success", "").Add(1)
 	}
 }
 
+// onSubscriberNegotiationFailed asks the client to join again, its subscriber PeerConnection can't
+// be renegotiated anymore
+func (p *ParticipantImpl) onSubscriberNegotiationFailed() {
+	p.lock.RLock()
+	queued := p.offerQueued
+	p.lock.RUnlock()
+	if queued {
+		// the client never received the offer, it's sent again once it reconnects
+		return
+	}
+
+	p.params.Logger.Warnw("subscriber negotiation failed, asking client to reconnect", nil)
+	_ = p.close(&livekit.LeaveRequest{CanReconnect: true})
+}
+
 // when a new remoteTrack is created, creates a Track and adds it to room
 func (p *ParticipantImpl) onMediaTrack(track *webrtc.TrackRemote, rtpReceiver *webrtc.RTPReceiver) {
 	if p.State() == livekit.ParticipantInfo_DISCONNECTED {
@@ -919,15 +1589,12 @@ func (p *ParticipantImpl) onMediaTrack(track *webrtc.TrackRemote, rtpReceiver *w
 
 	p.params.Logger.Debugw("mediaTrack added",
 		"kind", track.Kind().String(),
-		"participant", p.Identity(),
-		"pID", p.ID(),
 		"track", track.ID(),
 		"rid", track.RID(),
 		"SSRC", track.SSRC())
 
 	if !p.CanPublish() {
-		p.params.Logger.Warnw("no permission to publish mediaTrack", nil,
-			"participant", p.Identity(), "pID", p.ID())
+		p.params.Logger.Warnw("no permission to publish mediaTrack", nil)
 		return
 	}
 
@@ -940,26 +1607,47 @@ func (p *ParticipantImpl) onMediaTrack(track *webrtc.TrackRemote, rtpReceiver *w
 		signalCid, ti := p.getPendingTrack(track.ID(), ToProtoTrackKind(track.Kind()))
 		if ti == nil {
 			p.lock.Unlock()
+			// usually media that arrived after its pending track expired, the client has to add it again
+			p.sendTrackPublishFailed(track.ID(), ErrPendingTrackNotFound)
+			return
+		}
+		if !p.CanPublishSource(ti.Source) {
+			p.removePendingTrack(signalCid)
+			p.lock.Unlock()
+			p.params.Logger.Warnw("no permission to publish mediaTrack source", nil, "source", ti.Source.String())
+			p.sendTrackPublishFailed(signalCid, ErrCannotPublishSource)
 			return
 		}
 
+		var throttle *twccThrottle
+		if p.bitrateCap != nil && track.Kind() == webrtc.RTPCodecTypeVideo {
+			throttle = p.bitrateCap.addTrack(ti.Sid, hasTransportCC(track.Codec().RTCPFeedback))
+		}
 		mt = NewMediaTrack(track, MediaTrackParams{
 			TrackInfo:           ti,
 			SignalCid:           signalCid,
 			SdpCid:              track.ID(),
 			ParticipantID:       p.id,
 			ParticipantIdentity: p.Identity(),
-			RTCPChan:            p.rtcpCh,
+			RTCPQueue:           p.rtcpQueue,
 			BufferFactory:       p.params.Config.BufferFactory,
 			ReceiverConfig:      p.params.Config.Receiver,
+			SenderConfig:        p.params.Config.Sender,
 			AudioConfig:         p.params.AudioConfig,
+			Stereo:              p.stereoTracks[track.ID()],
+			TWCCThrottle:        throttle,
 			Telemetry:           p.params.Telemetry,
 			Logger:              p.params.Logger,
 		})
+		if p.Capabilities().Dynacast {
+			mt.OnSubscribedLayerChanged(func(maxLayer int32) {
+				p.sendSubscribedQuality(mt.ID(), maxLayer)
+			})
+		}
 
 		// add to published and clean up pending
 		p.publishedTracks[mt.ID()] = mt
-		delete(p.pendingTracks, signalCid)
+		p.removePendingTrack(signalCid)
 
 		newTrack = true
 	}
@@ -974,6 +1662,16 @@ func (p *ParticipantImpl) onMediaTrack(track *webrtc.TrackRemote, rtpReceiver *w
 	}
 	p.lock.Unlock()
 
+	// simulcast layers are paired with their RTX streams by RID
+	if p.rtxPairing != nil && track.Kind() == webrtc.RTPCodecTypeVideo {
+		for _, transceiver := range p.publisher.pc.GetTransceivers() {
+			if transceiver.Receiver() == rtpReceiver {
+				p.rtxPairing.addPrimary(transceiver.Mid(), track.RID(), ssrc)
+				break
+			}
+		}
+	}
+
 	mt.AddReceiver(rtpReceiver, track, p.twcc)
 
 	if newTrack {
@@ -987,17 +1685,30 @@ func (p *ParticipantImpl) onDataChannel(dc *webrtc.DataChannel) {
 	}
 	switch dc.Label() {
 	case reliableDataChannel:
+		p.lock.Lock()
 		p.reliableDC = dc
+		p.lock.Unlock()
 		dc.OnMessage(func(msg webrtc.DataChannelMessage) {
 			p.handleDataMessage(livekit.DataPacket_RELIABLE, msg.Data)
 		})
+		dc.OnClose(func() {
+			// the subscriber data channels are used while they work
+			if p.reliableDataChannel() == dc {
+				p.fallBackToSignalData("data channel closed")
+			}
+		})
+		if !p.SubscriberAsPrimary() {
+			dc.OnOpen(p.flushTrackErrors)
+		}
 	case lossyDataChannel:
+		p.lock.Lock()
 		p.lossyDC = dc
+		p.lock.Unlock()
 		dc.OnMessage(func(msg webrtc.DataChannelMessage) {
 			p.handleDataMessage(livekit.DataPacket_LOSSY, msg.Data)
 		})
 	default:
-		p.params.Logger.Warnw("unsupported datachannel added", nil, "participant", p.Identity(), "pID", p.ID(), "label", dc.Label())
+		p.params.Logger.Warnw("unsupported datachannel added", nil, "label", dc.Label())
 	}
 }
 
@@ -1023,11 +1734,41 @@ func (p *ParticipantImpl) getPublishedTrackBySdpCid(clientId string) types.Publi
 	return nil
 }
 
+// should be called with lock held
+func (p *ParticipantImpl) addPendingTrack(cid string, ti *livekit.TrackInfo) {
+	p.pendingTracks[cid] = ti
+	p.pendingTracksAddedAt[cid] = time.Now()
+	prometheus.AddPendingTrack()
+}
+
+// should be called with lock held
+func (p *ParticipantImpl) removePendingTrack(cid string) {
+	if _, ok := p.pendingTracks[cid]; !ok {
+		return
+	}
+	delete(p.pendingTracks, cid)
+	delete(p.pendingTracksAddedAt, cid)
+	for trackID, pendingCid := range p.pendingTrackIDs {
+		if pendingCid == cid {
+			delete(p.pendingTrackIDs, trackID)
+		}
+	}
+	prometheus.SubPendingTrack()
+}
+
 // should be called with lock held
 func (p *ParticipantImpl) getPendingTrack(clientId string, kind livekit.TrackType) (string, *livekit.TrackInfo) {
 	signalCid := clientId
 	ti := p.pendingTracks[clientId]
 
+	// then the one matched to its offer section, see configureReceiverOpus
+	if ti == nil {
+		if cid, ok := p.pendingTrackIDs[clientId]; ok {
+			ti = p.pendingTracks[cid]
+			signalCid = cid
+		}
+	}
+
 	// then find the first one that matches type. with MediaStreamTrack, it's possible for the client id to
 	// change after being added to SubscriberPC
 	if ti == nil {
@@ -1048,6 +1789,10 @@ func (p *ParticipantImpl) getPendingTrack(clientId string, kind livekit.TrackTyp
 }
 
 func (p *ParticipantImpl) handleDataMessage(kind livekit.DataPacket_Kind, data []byte) {
+	if !p.allowDataMessage(kind, len(data)) {
+		return
+	}
+
 	dp := livekit.DataPacket{}
 	if err := proto.Unmarshal(data, &dp); err != nil {
 		p.params.Logger.Warnw("could not parse data packet", err)
@@ -1056,19 +1801,87 @@ func (p *ParticipantImpl) handleDataMessage(kind livekit.DataPacket_Kind, data [
 
 	// trust the channel that it came in as the source of truth
 	dp.Kind = kind
+	p.forwardDataPacket(&dp)
+}
+
+// HandleSignalData handles a data packet the participant sent over the signal connection, its kind
+// is the one it was sent with. Participants with data channels only send reliable packets there,
+// when their data channel failed
+func (p *ParticipantImpl) HandleSignalData(data []byte) {
+	dp := livekit.DataPacket{}
+	if err := proto.Unmarshal(data, &dp); err != nil {
+		p.params.Logger.Warnw("could not parse data packet", err)
+		return
+	}
+	if !p.params.SignalOnly {
+		if dp.Kind != livekit.DataPacket_RELIABLE {
+			p.params.Logger.Debugw("dropping lossy data packet sent over the signal connection")
+			return
+		}
+		// the client's data channel failed, it reads data from the signal connection too
+		p.fallBackToSignalData("client sent data over the signal connection")
+	}
+	if !p.allowDataMessage(dp.Kind, len(data)) {
+		return
+	}
+	p.forwardDataPacket(&dp)
+}
+
+func (p *ParticipantImpl) allowDataMessage(kind livekit.DataPacket_Kind, size int) bool {
+	if !p.CanPublishData() {
+		p.params.Logger.Debugw("dropping data packet, participant cannot publish data")
+		return false
+	}
+	if !p.dataLimiter.allow(kind, size) {
+		p.params.Logger.Debugw("dropping data packet, rate limit exceeded", "kind", kind.String())
+		prometheus.IncrementDataPacketDropped(kind.String(), prometheus.DataDropReasonRateLimited)
+		return false
+	}
+	return true
+}
 
+func (p *ParticipantImpl) forwardDataPacket(dp *livekit.DataPacket) {
 	// only forward on user payloads
 	switch payload := dp.Value.(type) {
 	case *livekit.DataPacket_User:
 		if p.onDataPacket != nil {
 			payload.User.ParticipantSid = p.id
-			p.onDataPacket(p, &dp)
+			p.onDataPacket(p, dp)
 		}
 	default:
 		p.params.Logger.Warnw("received unsupported data packet", nil, "payload", payload)
 	}
 }
 
+// unpublishTracks stops forwarding all published tracks and drops pending ones
+func (p *ParticipantImpl) unpublishTracks() {
+	p.lock.Lock()
+	tracks := make([]types.PublishedTrack, 0, len(p.publishedTracks))
+	for _, track := range p.publishedTracks {
+		tracks = append(tracks, track)
+	}
+	p.publishedTracks = make(map[string]types.PublishedTrack)
+	for cid := range p.pendingTracks {
+		p.removePendingTrack(cid)
+	}
+	p.lock.Unlock()
+
+	for _, track := range tracks {
+		p.params.Logger.Infow("unpublishing track, publish permission revoked",
+			"track", track.ID())
+		p.closePublishedTrack(track)
+	}
+}
+
+// unsubscribeTracks removes all subscribed tracks
+func (p *ParticipantImpl) unsubscribeTracks() {
+	for _, st := range p.GetSubscribedTracks() {
+		p.params.Logger.Infow("unsubscribing from track, subscribe permission revoked",
+			"track", st.ID())
+		st.DownTrack().Close()
+	}
+}
+
 func (p *ParticipantImpl) handleTrackPublished(track types.PublishedTrack) {
 	p.lock.Lock()
 	if _, ok := p.publishedTracks[track.ID()]; !ok {
@@ -1081,10 +1894,15 @@ func (p *ParticipantImpl) handleTrackPublished(track types.PublishedTrack) {
 	track.AddOnClose(func() {
 		// cleanup
 		p.lock.Lock()
+		// unpublished tracks were already removed, and the room told
+		_, published := p.publishedTracks[track.ID()]
 		delete(p.publishedTracks, track.ID())
 		p.lock.Unlock()
+		if p.bitrateCap != nil {
+			p.bitrateCap.removeTrack(track.ID())
+		}
 		// only send this when client is in a ready state
-		if p.IsReady() && p.onTrackUpdated != nil {
+		if published && p.IsReady() && p.onTrackUpdated != nil {
 			p.onTrackUpdated(p, track)
 		}
 	})
@@ -1109,11 +1927,25 @@ func (p *ParticipantImpl) handlePrimaryICEStateChange(state webrtc.ICEConnection
 }
 
 // downTracksRTCPWorker sends SenderReports periodically when the participant is subscribed to
-// other publishedTracks in the room.
+// other publishedTracks in the room. The interval and batch size are set by the room policy
 func (p *ParticipantImpl) downTracksRTCPWorker() {
 	defer Recover()
+
+	conf := p.params.Policy.SenderReports(p.params.Config.Sender.SenderReports)
+	audioTicker := time.NewTicker(conf.AudioInterval)
+	defer audioTicker.Stop()
+	videoTicker := time.NewTicker(conf.VideoInterval)
+	defer videoTicker.Stop()
 	for {
-		time.Sleep(5 * time.Second)
+		var kind webrtc.RTPCodecType
+		select {
+		case <-p.closed:
+			return
+		case <-audioTicker.C:
+			kind = webrtc.RTPCodecTypeAudio
+		case <-videoTicker.C:
+			kind = webrtc.RTPCodecTypeVideo
+		}
 
 		if p.State() == livekit.ParticipantInfo_DISCONNECTED {
 			return
@@ -1126,6 +1958,9 @@ func (p *ParticipantImpl) downTracksRTCPWorker() {
 		var sd []rtcp.SourceDescriptionChunk
 		p.lock.RLock()
 		for _, subTrack := range p.subscribedTracks {
+			if subTrack.DownTrack().Kind() != kind {
+				continue
+			}
 			sr := subTrack.DownTrack().CreateSenderReport()
 			chunks := subTrack.DownTrack().CreateSourceDescriptionChunks()
 			if sr == nil || chunks == nil {
@@ -1136,40 +1971,69 @@ func (p *ParticipantImpl) downTracksRTCPWorker() {
 		}
 		p.lock.RUnlock()
 
-		// now send in batches of sdBatchSize
-		var batch []rtcp.SourceDescriptionChunk
-		var pkts []rtcp.Packet
-		batchSize := 0
-		for len(sd) > 0 || len(srs) > 0 {
-			numSRs := len(srs)
-			if numSRs > 0 {
-				if numSRs > sdBatchSize {
-					numSRs = sdBatchSize
+		for _, pkts := range senderReportBatches(srs, sd, conf.BatchSize) {
+			if err := p.subscriber.pc.WriteRTCP(pkts); err != nil {
+				if err == io.EOF || err == io.ErrClosedPipe {
+					return
 				}
-				pkts = append(pkts, srs[:numSRs]...)
-				srs = srs[numSRs:]
+				p.rtcpLogger.Errorw("could not send downtrack reports", err)
 			}
+		}
+	}
+}
 
-			size := len(sd)
-			spaceRemain := sdBatchSize - batchSize
-			if spaceRemain > 0 && size > 0 {
-				if size > spaceRemain {
-					size = spaceRemain
-				}
-				batch = sd[:size]
-				sd = sd[size:]
-				pkts = append(pkts, &rtcp.SourceDescription{Chunks: batch})
-				if err := p.subscriber.pc.WriteRTCP(pkts); err != nil {
-					if err == io.EOF || err == io.ErrClosedPipe {
-						return
-					}
-					logger.Errorw("could not send downtrack reports", err,
-						"participant", p.Identity(), "pID", p.ID())
-				}
+// senderReportBatches groups sender reports and source description chunks into compound RTCP
+// packets of up to batchSize of each
+func senderReportBatches(srs []rtcp.Packet, sd []rtcp.SourceDescriptionChunk, batchSize int) [][]rtcp.Packet {
+	var batches [][]rtcp.Packet
+	for len(srs) > 0 || len(sd) > 0 {
+		var pkts []rtcp.Packet
+		numSRs := len(srs)
+		if numSRs > batchSize {
+			numSRs = batchSize
+		}
+		pkts = append(pkts, srs[:numSRs]...)
+		srs = srs[numSRs:]
+
+		numChunks := len(sd)
+		if numChunks > batchSize {
+			numChunks = batchSize
+		}
+		if numChunks > 0 {
+			pkts = append(pkts, &rtcp.SourceDescription{Chunks: sd[:numChunks]})
+			sd = sd[numChunks:]
+		}
+		batches = append(batches, pkts)
+	}
+	return batches
+}
+
+// publisherBitrateCapWorker applies the bitrate caps to the video the participant publishes
+func (p *ParticipantImpl) publisherBitrateCapWorker() {
+	defer Recover()
+	for {
+		time.Sleep(time.Second)
+
+		if p.State() == livekit.ParticipantInfo_DISCONNECTED {
+			return
+		}
+		if p.publisher.pc.ConnectionState() != webrtc.PeerConnectionStateConnected {
+			continue
+		}
+
+		var stats []*types.PublishedTrackStats
+		p.lock.RLock()
+		for _, track := range p.publishedTracks {
+			if track.Kind() == livekit.TrackType_VIDEO {
+				stats = append(stats, track.GetStats())
 			}
+		}
+		p.lock.RUnlock()
 
-			pkts = pkts[:0]
-			batchSize = 0
+		if remb := p.bitrateCap.update(stats); remb != nil {
+			if err := p.publisher.pc.WriteRTCP([]rtcp.Packet{remb}); err != nil {
+				p.rtcpLogger.Warnw("could not write REMB to participant", err)
+			}
 		}
 	}
 }
@@ -1177,9 +2041,9 @@ func (p *ParticipantImpl) downTracksRTCPWorker() {
 func (p *ParticipantImpl) rtcpSendWorker() {
 	defer Recover()
 
-	// read from rtcpChan
-	for pkts := range p.rtcpCh {
-		if pkts == nil {
+	for {
+		pkts, ok := p.rtcpQueue.Dequeue()
+		if !ok {
 			return
 		}
 
@@ -1188,14 +2052,18 @@ func (p *ParticipantImpl) rtcpSendWorker() {
 			switch pkt.(type) {
 			case *rtcp.PictureLossIndication:
 				mediaSSRC := pkt.(*rtcp.PictureLossIndication).MediaSSRC
-				if p.pliThrottle.canSend(mediaSSRC) {
+				if p.pliThrottle.request(mediaSSRC, pkt) {
 					fwdPkts = append(fwdPkts, pkt)
 				}
 			case *rtcp.FullIntraRequest:
 				mediaSSRC := pkt.(*rtcp.FullIntraRequest).MediaSSRC
-				if p.pliThrottle.canSend(mediaSSRC) {
+				if p.pliThrottle.request(mediaSSRC, pkt) {
 					fwdPkts = append(fwdPkts, pkt)
 				}
+			case *rtcp.ReceiverEstimatedMaximumBitrate:
+				// buffers estimate the stream they receive, the publisher takes a REMB for its
+				// whole connection
+				fwdPkts = append(fwdPkts, p.rembAggregator.Update(pkt.(*rtcp.ReceiverEstimatedMaximumBitrate), time.Now()))
 			default:
 				fwdPkts = append(fwdPkts, pkt)
 			}
@@ -1203,110 +2071,110 @@ func (p *ParticipantImpl) rtcpSendWorker() {
 
 		if len(fwdPkts) > 0 {
 			if err := p.publisher.pc.WriteRTCP(fwdPkts); err != nil {
-				p.params.Logger.Errorw("could not write RTCP to participant", err,
-					"participant", p.Identity(), "pID", p.ID())
+				p.rtcpLogger.Errorw("could not write RTCP to participant", err)
 			}
 		}
 	}
 }
 
-func (p *ParticipantImpl) configureReceiverDTX() {
-	//
-	// DTX (Discontinuous Transmission) allows audio bandwidth saving
-	// by not sending packets during silence periods.
-	//
-	// Publisher side DTX can enabled by included `usedtx=1` in
-	// the `fmtp` line corresponding to audio codec (Opus) in SDP.
-	// By doing this in the SDP `answer`, it can be controlled from
-	// server side and avoid doing it in all the client SDKs.
-	//
-	// Ideally, a publisher should be able to specify per audio
-	// track if DTX should be enabled. But, translating the
-	// DTX preference of publisher to the correct transceiver
-	// is non-deterministic due to the lack of a synchronizing id
-	// like the track id. The codec preference to set DTX needs
-	// to be done
-	//   - after calling `SetRemoteDescription` which sets up
-	//     the transceivers, but there are no tracks in the
-	//     transceiver yet
-	//   - before calling `CreateAnswer`
-	// Due to the absensce of tracks when it is required to set DTX,
-	// it is not possible to cross reference against a pending track
-	// with the same track id.
-	//
-	// Due to the restriction above and given that in practice
-	// most of the time there is going to be only one audio track
-	// that is published, do the following
-	//    - if there is no pending audio track, no-op
-	//    - if there are no audio transceivers without tracks, no-op
-	//    - else, apply the DTX setting from pending audio track
-	//      to the audio transceiver without no tracks
-	//
-	// NOTE: The above logic will fail if there is an `offer` SDP with
-	// multiple audio tracks. At that point, there might be a need to
-	// rely on something like order of tracks. TODO
-	//
-	enableDTX := false
-
-	p.lock.RLock()
-	var pendingTrack *livekit.TrackInfo
-	for _, track := range p.pendingTracks {
-		if track.Type == livekit.TrackType_AUDIO {
-			pendingTrack = track
-			break
-		}
+// rtcpPacketType labels RTCP feedback dropped before it was written to the publisher
+func rtcpPacketType(pkt rtcp.Packet) string {
+	switch pkt.(type) {
+	case *rtcp.TransportLayerNack:
+		return "nack"
+	case *rtcp.ReceiverReport:
+		return "receiver_report"
+	case *rtcp.ReceiverEstimatedMaximumBitrate:
+		return "remb"
+	case *rtcp.PictureLossIndication:
+		return "pli"
+	case *rtcp.FullIntraRequest:
+		return "fir"
+	default:
+		return "other"
 	}
+}
 
-	if pendingTrack == nil {
-		p.lock.RUnlock()
+// configureReceiverOpus sets the Opus settings each new audio section of the offer is answered
+// with, before the answer is created. Stereo is answered when the publisher offers it, unless mono
+// is forced.
+//
+// DTX (Discontinuous Transmission) saves audio bandwidth by not sending packets during silence.
+// It's enabled by `usedtx=1` in the answer's Opus fmtp line, so that it's controlled by the server
+// and not by each client SDK, per track unless the publisher disabled it. The transceivers have
+// no tracks yet, so the pending tracks are matched to the sections by msid, then in order, see
+// matchAudioSections.
+func (p *ParticipantImpl) configureReceiverOpus(offer webrtc.SessionDescription) {
+	sections, err := parseOpusSections(offer)
+	if err != nil {
+		p.params.Logger.Warnw("could not parse offer for opus settings", err)
 		return
 	}
 
-	enableDTX = !pendingTrack.DisableDtx
-	p.lock.RUnlock()
-
-	transceivers := p.publisher.pc.GetTransceivers()
-	for _, transceiver := range transceivers {
+	// sections negotiated before have tracks already
+	newTransceivers := make(map[string]*webrtc.RTPTransceiver)
+	for _, transceiver := range p.publisher.pc.GetTransceivers() {
 		if transceiver.Kind() != webrtc.RTPCodecTypeAudio {
 			continue
 		}
-
 		receiver := transceiver.Receiver()
 		if receiver == nil || receiver.Track() != nil {
 			continue
 		}
-
-		var modifiedReceiverCodecs []webrtc.RTPCodecParameters
-
-		receiverCodecs := receiver.GetParameters().Codecs
-		for _, receiverCodec := range receiverCodecs {
-			if receiverCodec.MimeType == webrtc.MimeTypeOpus {
-				fmtpUseDTX := "usedtx=1"
-				// remove occurrence in the middle
-				sdpFmtpLine := strings.ReplaceAll(receiverCodec.SDPFmtpLine, fmtpUseDTX+";", "")
-				// remove occurrence at the end
-				sdpFmtpLine = strings.ReplaceAll(sdpFmtpLine, fmtpUseDTX, "")
-				if enableDTX {
-					sdpFmtpLine += ";" + fmtpUseDTX
-				}
-				receiverCodec.SDPFmtpLine = sdpFmtpLine
-			}
-			modifiedReceiverCodecs = append(modifiedReceiverCodecs, receiverCodec)
+		newTransceivers[transceiver.Mid()] = transceiver
+	}
+	newSections := make([]opusSection, 0, len(sections))
+	for _, section := range sections {
+		if newTransceivers[section.mid] != nil {
+			newSections = append(newSections, section)
 		}
+	}
 
-		//
-		// As `SetCodecPreferences` on a transceiver replaces all codecs,
-		// cycle through sender codecs also and add them before calling
-		// `SetCodecPreferences`
-		//
-		var senderCodecs []webrtc.RTPCodecParameters
-		sender := transceiver.Sender()
-		if sender != nil {
-			senderCodecs = sender.GetParameters().Codecs
+	p.lock.Lock()
+	pending := make([]pendingAudioTrack, 0, len(p.pendingTracks))
+	for cid, ti := range p.pendingTracks {
+		if ti.Type == livekit.TrackType_AUDIO {
+			pending = append(pending, pendingAudioTrack{cid: cid, info: ti})
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		addedAt, otherAddedAt := p.pendingTracksAddedAt[pending[i].cid], p.pendingTracksAddedAt[pending[j].cid]
+		if !addedAt.Equal(otherAddedAt) {
+			return addedAt.Before(otherAddedAt)
 		}
+		return pending[i].cid < pending[j].cid
+	})
+	matched := matchAudioSections(newSections, pending)
+
+	stereoByMid := make(map[string]bool, len(newSections))
+	dtxByMid := make(map[string]bool, len(newSections))
+	for _, section := range newSections {
+		stereo := section.stereo && !p.params.AudioConfig.ForceMono
+		stereoByMid[section.mid] = stereo
+		track, ok := matched[section.mid]
+		dtxByMid[section.mid] = ok && !track.info.DisableDtx && p.params.Policy.DTXEnabled()
+		if section.trackID == "" {
+			continue
+		}
+		if stereo {
+			p.stereoTracks[section.trackID] = true
+		} else {
+			delete(p.stereoTracks, section.trackID)
+		}
+		if ok && track.cid != section.trackID {
+			// so that the track's media is matched to the same pending track
+			p.pendingTrackIDs[section.trackID] = track.cid
+		}
+	}
+	p.lock.Unlock()
 
-		err := transceiver.SetCodecPreferences(append(modifiedReceiverCodecs, senderCodecs...))
-		if err != nil {
+	for mid, transceiver := range newTransceivers {
+		stereo, ok := stereoByMid[mid]
+		if !ok {
+			continue
+		}
+		codecs := transceiver.Receiver().GetParameters().Codecs
+		if err := setOpusPreferences(transceiver, codecs, stereo, dtxByMid[mid]); err != nil {
 			p.params.Logger.Warnw("failed to SetCodecPreferences", err)
 		}
 	}
@@ -1323,12 +2191,25 @@ func (p *ParticipantImpl) onStreamedTracksChange(update *sfu.StreamedTracksUpdat
 			ParticipantSid: streamedTrack.ParticipantSid,
 			TrackSid:       streamedTrack.TrackSid,
 		})
+		p.params.Telemetry.TrackSubscriptionChanged(context.Background(), &telemetry.SubscriptionEvent{
+			Type:         telemetry.SubscriptionEventPaused,
+			SubscriberID: p.ID(),
+			PublisherID:  streamedTrack.ParticipantSid,
+			TrackID:      streamedTrack.TrackSid,
+			Reason:       telemetry.SubscriptionPausedBandwidth,
+		})
 	}
 	for _, streamedTrack := range update.Resumed {
 		streamedTracksUpdate.Resumed = append(streamedTracksUpdate.Resumed, &livekit.StreamedTrack{
 			ParticipantSid: streamedTrack.ParticipantSid,
 			TrackSid:       streamedTrack.TrackSid,
 		})
+		p.params.Telemetry.TrackSubscriptionChanged(context.Background(), &telemetry.SubscriptionEvent{
+			Type:         telemetry.SubscriptionEventResumed,
+			SubscriberID: p.ID(),
+			PublisherID:  streamedTrack.ParticipantSid,
+			TrackID:      streamedTrack.TrackSid,
+		})
 	}
 
 	return p.writeMessage(&livekit.SignalResponse{
@@ -1379,6 +2260,7 @@ func (p *ParticipantImpl) DebugInfo() map[string]interface{} {
 	info["PublishedTracks"] = publishedTrackInfo
 	info["SubscribedTracks"] = subscribedTrackInfo
 	info["PendingTracks"] = pendingTrackInfo
+	info["DataPath"] = p.dataPath()
 
 	return info
 }
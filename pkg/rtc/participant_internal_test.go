@@ -1,18 +1,30 @@
 package rtc
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/livekit/protocol/logger"
 	livekit "github.com/livekit/protocol/proto"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
 	"github.com/pion/webrtc/v3"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/testutils"
 )
 
 func TestIsReady(t *testing.T) {
@@ -113,6 +125,33 @@ func TestTrackPublishing(t *testing.T) {
 		require.Equal(t, uint32(768), published.Track.Height)
 	})
 
+	t.Run("rejected tracks are held until the data channel opens", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.params.Logger = logger.Logger(logger.GetLogger())
+		p.SetPermission(&livekit.ParticipantPermission{CanSubscribe: true})
+		sink := p.params.Sink.(*routingfakes.FakeMessageSink)
+		p.AddTrack(&livekit.AddTrackRequest{Cid: "cid", Name: "webcam", Type: livekit.TrackType_VIDEO})
+		require.Zero(t, sink.WriteMessageCallCount())
+		require.Empty(t, p.pendingTracks)
+
+		require.Len(t, p.heldTrackErrors, 1)
+		var msg map[string]interface{}
+		require.NoError(t, json.Unmarshal(p.heldTrackErrors[0].GetUser().Payload, &msg))
+		require.Equal(t, "track_publish_failed", msg["type"])
+		require.Equal(t, "cid", msg["cid"])
+		require.Equal(t, "permission_denied", msg["reason"])
+
+		// errors past the limit are dropped
+		for i := 0; i < maxHeldTrackErrors; i++ {
+			p.SendSubscriptionError(fmt.Sprintf("TR_%d", i), ErrSubscriptionLimit)
+		}
+		require.Len(t, p.heldTrackErrors, maxHeldTrackErrors)
+		require.NoError(t, json.Unmarshal(p.heldTrackErrors[1].GetUser().Payload, &msg))
+		require.Equal(t, "subscription_error", msg["type"])
+		require.Equal(t, "TR_0", msg["track_sid"])
+		require.Equal(t, "subscription_limit", msg["reason"])
+	})
+
 	t.Run("should not allow adding of duplicate tracks", func(t *testing.T) {
 		p := newParticipantForTest("test")
 		//track := &typesfakes.FakePublishedTrack{}
@@ -167,6 +206,173 @@ func TestTrackPublishing(t *testing.T) {
 	})
 }
 
+func TestUnpublishTrack(t *testing.T) {
+	p := newParticipantForTest("test")
+	p.params.Logger = logger.Logger(logger.GetLogger())
+	p.state.Store(livekit.ParticipantInfo_ACTIVE)
+	track := &typesfakes.FakePublishedTrack{}
+	track.IDReturns("TR_webcam")
+	updates := 0
+	p.OnTrackUpdated(func(p types.Participant, track types.PublishedTrack) {
+		updates++
+	})
+	p.handleTrackPublished(track)
+
+	require.Equal(t, ErrTrackNotFound, p.UnpublishTrack("TR_mic"))
+	require.NoError(t, p.UnpublishTrack("TR_webcam"))
+	require.Empty(t, p.publishedTracks)
+	require.Equal(t, 1, track.RemoveAllSubscribersCallCount())
+	require.Equal(t, 1, track.CloseCallCount())
+	require.Equal(t, 1, updates)
+
+	// the room isn't told again once the receiver has closed
+	track.AddOnCloseArgsForCall(0)()
+	require.Equal(t, 1, updates)
+	require.Equal(t, ErrTrackNotFound, p.UnpublishTrack("TR_webcam"))
+}
+
+func TestCloseRemovedTracks(t *testing.T) {
+	p := newParticipantForTest("test")
+	p.params.Logger = logger.Logger(logger.GetLogger())
+	p.state.Store(livekit.ParticipantInfo_ACTIVE)
+	updates := 0
+	p.OnTrackUpdated(func(p types.Participant, track types.PublishedTrack) {
+		updates++
+	})
+	mic := &typesfakes.FakePublishedTrack{}
+	mic.IDReturns("TR_mic")
+	mic.SdpCidReturns("mic")
+	webcam := &typesfakes.FakePublishedTrack{}
+	webcam.IDReturns("TR_webcam")
+	webcam.SdpCidReturns("webcam")
+	p.handleTrackPublished(mic)
+	p.handleTrackPublished(webcam)
+
+	p.closeRemovedTracks(offerWithSections(
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111\r\nc=IN IP4 0.0.0.0\r\na=mid:0\r\na=sendonly\r\na=msid:stream mic\r\na=rtpmap:111 opus/48000/2\r\n",
+		"m=video 9 UDP/TLS/RTP/SAVPF 96\r\nc=IN IP4 0.0.0.0\r\na=mid:1\r\na=recvonly\r\na=rtpmap:96 VP8/90000\r\n",
+	))
+	require.Equal(t, map[string]types.PublishedTrack{"TR_mic": mic}, p.publishedTracks)
+	require.Zero(t, mic.CloseCallCount())
+	require.Equal(t, 1, webcam.RemoveAllSubscribersCallCount())
+	require.Equal(t, 1, webcam.CloseCallCount())
+	require.Equal(t, 1, updates)
+
+	// the room isn't told again once the receiver has closed
+	webcam.AddOnCloseArgsForCall(0)()
+	require.Equal(t, 1, updates)
+}
+
+func TestQueuedSubscriberOffer(t *testing.T) {
+	p := newParticipantForTest("test")
+	p.params.Logger = logger.Logger(logger.GetLogger())
+	p.state.Store(livekit.ParticipantInfo_ACTIVE)
+	defer p.Close()
+	_, err := p.subscriber.pc.CreateDataChannel("test", nil)
+	require.NoError(t, err)
+
+	// the signal connection dropped
+	lost := p.GetResponseSink().(*routingfakes.FakeMessageSink)
+	lost.WriteMessageReturns(routing.ErrChannelClosed)
+	require.NoError(t, p.subscriber.CreateAndSendOffer(nil))
+	testutils.WithTimeout(t, "offer queued", func() bool {
+		p.lock.RLock()
+		defer p.lock.RUnlock()
+		return p.offerQueued
+	})
+	// later offers wait for the queued one to be answered
+	require.NoError(t, p.subscriber.CreateAndSendOffer(nil))
+
+	sink := &routingfakes.FakeMessageSink{}
+	p.SetResponseSink(sink)
+	testutils.WithTimeout(t, "offer replayed", func() bool {
+		return sink.WriteMessageCallCount() == 1
+	})
+	offer := sink.WriteMessageArgsForCall(0).(*livekit.SignalResponse).GetOffer()
+	require.NotNil(t, offer)
+	require.Equal(t, "offer", offer.Type)
+	pending := p.subscriber.pc.PendingLocalDescription().SDP
+	require.True(t, strings.HasPrefix(offer.Sdp, pending[:strings.Index(pending, "m=")]))
+	require.False(t, p.offerQueued)
+
+	// replayed once
+	p.SetResponseSink(sink)
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, 1, sink.WriteMessageCallCount())
+}
+
+func TestResumeSignal(t *testing.T) {
+	p := newParticipantForTest("test")
+	p.params.SequenceSignal = true
+	p.state.Store(livekit.ParticipantInfo_ACTIVE)
+	lost := p.GetResponseSink().(*routingfakes.FakeMessageSink)
+	for _, name := range []string{"a", "b", "c"} {
+		require.NoError(t, p.SendRoomUpdate(&livekit.Room{Name: name}))
+	}
+	require.Equal(t, 3, lost.WriteMessageCallCount())
+	seq := SignalSeq(lost.WriteMessageArgsForCall(2).(*livekit.SignalResponse))
+	require.Equal(t, uint32(3), seq)
+
+	// the signal connection dropped after the first response reached the client
+	lost.WriteMessageReturns(routing.ErrChannelClosed)
+	require.Error(t, p.SendRoomUpdate(&livekit.Room{Name: "d"}))
+	p.AckSignal(1)
+
+	sink := &routingfakes.FakeMessageSink{}
+	p.ResumeSignal(1)
+	p.SetResponseSink(sink)
+	require.Equal(t, 3, sink.WriteMessageCallCount())
+	for i, name := range []string{"b", "c", "d"} {
+		msg := sink.WriteMessageArgsForCall(i).(*livekit.SignalResponse)
+		require.Equal(t, name, msg.GetRoomUpdate().Room.Name)
+		require.Equal(t, uint32(i+2), SignalSeq(msg))
+	}
+
+	// clients that don't resume from a response get nothing again
+	other := &routingfakes.FakeMessageSink{}
+	p.SetResponseSink(other)
+	require.Zero(t, other.WriteMessageCallCount())
+}
+
+func TestUnsequencedSignal(t *testing.T) {
+	p := newParticipantForTest("test")
+	p.state.Store(livekit.ParticipantInfo_ACTIVE)
+	sink := p.GetResponseSink().(*routingfakes.FakeMessageSink)
+	update := &livekit.SignalResponse{
+		Message: &livekit.SignalResponse_RoomUpdate{RoomUpdate: &livekit.RoomUpdate{Room: &livekit.Room{Name: "a"}}},
+	}
+	require.NoError(t, p.writeMessage(update))
+
+	// written as is, and not kept
+	require.Equal(t, 1, sink.WriteMessageCallCount())
+	require.Same(t, update, sink.WriteMessageArgsForCall(0))
+	require.Zero(t, SignalSeq(update))
+	require.Empty(t, p.signals.messages)
+}
+
+func TestSubscriberNegotiationFailed(t *testing.T) {
+	t.Run("client is asked to reconnect", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.params.Logger = logger.Logger(logger.GetLogger())
+		sink := p.GetResponseSink().(*routingfakes.FakeMessageSink)
+
+		p.onSubscriberNegotiationFailed()
+		require.Equal(t, livekit.ParticipantInfo_DISCONNECTED, p.State())
+		require.Equal(t, 1, sink.WriteMessageCallCount())
+		leave := sink.WriteMessageArgsForCall(0).(*livekit.SignalResponse).GetLeave()
+		require.True(t, leave.CanReconnect)
+	})
+
+	t.Run("offers that weren't delivered are sent again", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		defer p.Close()
+		p.offerQueued = true
+
+		p.onSubscriberNegotiationFailed()
+		require.NotEqual(t, livekit.ParticipantInfo_DISCONNECTED, p.State())
+	})
+}
+
 func TestOutOfOrderUpdates(t *testing.T) {
 	p := newParticipantForTest("test")
 	sink := p.GetResponseSink().(*routingfakes.FakeMessageSink)
@@ -191,6 +397,15 @@ func TestOutOfOrderUpdates(t *testing.T) {
 	require.Equal(t, 1, sink.WriteMessageCallCount())
 	sent := sink.WriteMessageArgsForCall(0).(*livekit.SignalResponse)
 	require.Equal(t, "123", sent.GetUpdate().Participants[0].Metadata)
+
+	// stale participants are left out of batched updates
+	other := &livekit.ParticipantInfo{Sid: "PA_test3", Identity: "test3"}
+	batch := []*livekit.ParticipantInfo{pi, other}
+	require.NoError(t, p.SendParticipantUpdate(batch, earlierTs))
+	require.Equal(t, 2, sink.WriteMessageCallCount())
+	sent = sink.WriteMessageArgsForCall(1).(*livekit.SignalResponse)
+	require.Equal(t, []*livekit.ParticipantInfo{other}, sent.GetUpdate().Participants)
+	require.Len(t, batch, 2)
 }
 
 // after disconnection, things should continue to function and not panic
@@ -244,6 +459,43 @@ func TestMuteSetting(t *testing.T) {
 	})
 }
 
+func TestSetPermission(t *testing.T) {
+	t.Run("revoking publish unpublishes tracks", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		track := &typesfakes.FakePublishedTrack{}
+		p.publishedTracks["track"] = track
+		p.pendingTracks["cid"] = &livekit.TrackInfo{Sid: "pending"}
+		updated := 0
+		p.OnTrackUpdated(func(_ types.Participant, _ types.PublishedTrack) {
+			updated++
+		})
+
+		p.SetPermission(&livekit.ParticipantPermission{CanSubscribe: true})
+		require.Equal(t, 1, track.RemoveAllSubscribersCallCount())
+		require.Equal(t, 1, track.CloseCallCount())
+		require.Empty(t, p.GetPublishedTracks())
+		require.Empty(t, p.pendingTracks)
+		require.Equal(t, 1, updated)
+
+		// no change when permission is kept
+		p.publishedTracks["track"] = track
+		p.SetPermission(&livekit.ParticipantPermission{CanSubscribe: true, CanPublishData: true})
+		require.Equal(t, 1, track.RemoveAllSubscribersCallCount())
+		require.Equal(t, 1, track.CloseCallCount())
+	})
+
+	t.Run("granting publish keeps tracks", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.SetPermission(&livekit.ParticipantPermission{})
+		track := &typesfakes.FakePublishedTrack{}
+		p.publishedTracks["track"] = track
+
+		p.SetPermission(&livekit.ParticipantPermission{CanPublish: true})
+		require.Zero(t, track.RemoveAllSubscribersCallCount())
+		require.Len(t, p.GetPublishedTracks(), 1)
+	})
+}
+
 func TestConnectionQuality(t *testing.T) {
 	testPublishedTrack := func(loss, numPublishing, numRegistered uint32) *typesfakes.FakePublishedTrack {
 		t := &typesfakes.FakePublishedTrack{}
@@ -270,6 +522,82 @@ func TestConnectionQuality(t *testing.T) {
 
 		require.Equal(t, livekit.ConnectionQuality_GOOD, p.GetConnectionQuality())
 	})
+
+	t.Run("lossy publishing", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.publishedTracks["video"] = testPublishedTrack(10, 3, 3)
+		p.publishedTracks["audio"] = testPublishedTrack(8, 1, 1)
+
+		require.Equal(t, livekit.ConnectionQuality_POOR, p.GetConnectionQuality())
+	})
+
+	t.Run("history is recorded and bounded", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.publishedTracks["audio"] = testPublishedTrack(0, 1, 1)
+
+		for i := 0; i < qualityHistorySize+5; i++ {
+			p.GetConnectionQuality()
+		}
+		stats := p.GetStats()
+		require.Len(t, stats.QualityHistory, qualityHistorySize)
+		require.Equal(t, livekit.ConnectionQuality_EXCELLENT.String(), stats.ConnectionQuality)
+		require.Len(t, stats.PublishedTracks, 1)
+	})
+}
+
+func TestDataPacketLimits(t *testing.T) {
+	packet, _ := proto.Marshal(&livekit.DataPacket{
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{Payload: []byte("hello")},
+		},
+	})
+
+	t.Run("forwards user packets", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		var received []*livekit.DataPacket
+		p.OnDataPacket(func(_ types.Participant, dp *livekit.DataPacket) {
+			received = append(received, dp)
+		})
+
+		p.handleDataMessage(livekit.DataPacket_LOSSY, packet)
+		require.Len(t, received, 1)
+		require.Equal(t, livekit.DataPacket_LOSSY, received[0].Kind)
+		require.Equal(t, p.ID(), received[0].GetUser().ParticipantSid)
+	})
+
+	t.Run("drops when not allowed to publish data", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.SetPermission(&livekit.ParticipantPermission{CanSubscribe: true, CanPublish: true})
+		numReceived := 0
+		p.OnDataPacket(func(_ types.Participant, _ *livekit.DataPacket) {
+			numReceived++
+		})
+
+		p.handleDataMessage(livekit.DataPacket_RELIABLE, packet)
+		require.Zero(t, numReceived)
+	})
+
+	t.Run("rate limits each kind separately", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.dataLimiter = newDataRateLimiter(config.DataRateLimitConfig{
+			Reliable: config.RateLimitConfig{Rate: 1, Burst: 2},
+		})
+		numReceived := 0
+		p.OnDataPacket(func(_ types.Participant, _ *livekit.DataPacket) {
+			numReceived++
+		})
+
+		for i := 0; i < 5; i++ {
+			p.handleDataMessage(livekit.DataPacket_RELIABLE, packet)
+		}
+		require.Equal(t, 2, numReceived)
+
+		// lossy is unlimited
+		for i := 0; i < 5; i++ {
+			p.handleDataMessage(livekit.DataPacket_LOSSY, packet)
+		}
+		require.Equal(t, 7, numReceived)
+	})
 }
 
 func TestSubscriberAsPrimary(t *testing.T) {
@@ -284,7 +612,7 @@ func TestSubscriberAsPrimary(t *testing.T) {
 
 	t.Run("protocol 2 uses pub as primary", func(t *testing.T) {
 		p := newParticipantForTest("test")
-		p.params.ProtocolVersion = 2
+		p.params.Capabilities = types.ProtocolVersion(2).Capabilities()
 		p.SetPermission(&livekit.ParticipantPermission{
 			CanSubscribe: true,
 			CanPublish:   true,
@@ -302,7 +630,224 @@ func TestSubscriberAsPrimary(t *testing.T) {
 	})
 }
 
+func TestStereoOpus(t *testing.T) {
+	publish := func(t *testing.T, p *ParticipantImpl) (string, webrtc.SessionDescription) {
+		pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = pc.Close() })
+
+		track, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus}, "music", "stream")
+		require.NoError(t, err)
+		_, err = pc.AddTrack(track)
+		require.NoError(t, err)
+
+		offer, err := pc.CreateOffer(nil)
+		require.NoError(t, err)
+		offer.SDP = strings.ReplaceAll(offer.SDP, "useinbandfec=1", "useinbandfec=1;stereo=1;sprop-stereo=1")
+
+		p.AddTrack(&livekit.AddTrackRequest{Cid: "music", Name: "music", Type: livekit.TrackType_AUDIO})
+		answer, err := p.HandleOffer(offer)
+		require.NoError(t, err)
+		return track.ID(), answer
+	}
+
+	t.Run("stereo offer is answered with stereo", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		trackID, answer := publish(t, p)
+		require.Contains(t, answer.SDP, "stereo=1;sprop-stereo=1")
+		require.True(t, p.stereoTracks[trackID])
+	})
+
+	t.Run("mono is forced when configured", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.params.AudioConfig.ForceMono = true
+		trackID, answer := publish(t, p)
+		require.NotContains(t, answer.SDP, "stereo=1")
+		require.False(t, p.stereoTracks[trackID])
+	})
+}
+
+func TestOpusDTX(t *testing.T) {
+	// publishes a mic and a screen share audio track in one offer, and returns the answer's
+	// Opus fmtp line by msid track id
+	publish := func(t *testing.T, p *ParticipantImpl, micCid, screenCid string) map[string]string {
+		pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = pc.Close() })
+
+		for _, id := range []string{"mic", "screen"} {
+			track, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus}, id, "stream")
+			require.NoError(t, err)
+			_, err = pc.AddTrack(track)
+			require.NoError(t, err)
+		}
+		offer, err := pc.CreateOffer(nil)
+		require.NoError(t, err)
+		sections, err := parseOpusSections(offer)
+		require.NoError(t, err)
+
+		p.AddTrack(&livekit.AddTrackRequest{Cid: micCid, Name: "mic", Type: livekit.TrackType_AUDIO})
+		p.AddTrack(&livekit.AddTrackRequest{Cid: screenCid, Name: "screen", Type: livekit.TrackType_AUDIO, DisableDtx: true})
+		answer, err := p.HandleOffer(offer)
+		require.NoError(t, err)
+
+		parsed, err := answer.Unmarshal()
+		require.NoError(t, err)
+		fmtps := make(map[string]string)
+		for i, media := range parsed.MediaDescriptions {
+			for _, attr := range media.Attributes {
+				if attr.Key == "fmtp" && strings.Contains(attr.Value, "useinbandfec") {
+					fmtps[sections[i].trackID] = attr.Value
+				}
+			}
+		}
+		require.Len(t, fmtps, 2)
+		return fmtps
+	}
+
+	t.Run("set per track by track id", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		fmtps := publish(t, p, "mic", "screen")
+		require.Contains(t, fmtps["mic"], "usedtx=1")
+		require.NotContains(t, fmtps["screen"], "usedtx=1")
+	})
+
+	t.Run("set per track in order when track ids differ", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		fmtps := publish(t, p, "TR_mic", "TR_screen")
+		require.Contains(t, fmtps["mic"], "usedtx=1")
+		require.NotContains(t, fmtps["screen"], "usedtx=1")
+
+		// media arriving on either section resolves to the track it was matched to
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		cid, ti := p.getPendingTrack("screen", livekit.TrackType_AUDIO)
+		require.Equal(t, "TR_screen", cid)
+		require.Equal(t, "screen", ti.Name)
+	})
+
+	t.Run("disabled by policy", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		dtx := false
+		p.params.Policy.AudioDTX = &dtx
+		fmtps := publish(t, p, "mic", "screen")
+		require.NotContains(t, fmtps["mic"], "usedtx=1")
+		require.NotContains(t, fmtps["screen"], "usedtx=1")
+	})
+}
+
+func TestReserveSubscription(t *testing.T) {
+	newSubscribedTrack := func(t *testing.T, trackID string, hiddenFor time.Duration) *SubscribedTrack {
+		dt, err := sfu.NewDownTrack(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}, &stubTrackReceiver{trackID: trackID}, nil, "sub", 500)
+		require.NoError(t, err)
+		st := NewSubscribedTrack(&typesfakes.FakePublishedTrack{}, "pub", dt, "")
+		if hiddenFor != 0 {
+			st.hiddenSince = time.Now().Add(-hiddenFor).UnixNano()
+		}
+		return st
+	}
+
+	t.Run("unlimited by default", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.subscribedTracks["TR_1"] = &typesfakes.FakeSubscribedTrack{}
+		require.NoError(t, p.ReserveSubscription("TR_2"))
+	})
+
+	t.Run("rejects over the limit", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.params.SubscriptionLimit = config.SubscriptionLimitConfig{MaxSubscriptions: 2, Policy: config.SubscriptionLimitPolicyReject}
+		p.subscribedTracks["TR_1"] = &typesfakes.FakeSubscribedTrack{}
+		require.NoError(t, p.ReserveSubscription("TR_2"))
+
+		// the reservation counts until it's released
+		require.Equal(t, ErrSubscriptionLimit, p.ReserveSubscription("TR_3"))
+		p.ReleaseSubscription("TR_2")
+		require.NoError(t, p.ReserveSubscription("TR_3"))
+		require.Len(t, p.subscribedTracks, 1)
+	})
+
+	t.Run("adding the track takes the reservation", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.params.SubscriptionLimit = config.SubscriptionLimitConfig{MaxSubscriptions: 1, Policy: config.SubscriptionLimitPolicyReject}
+		require.NoError(t, p.ReserveSubscription("TR_1"))
+		p.AddSubscribedTrack(newSubscribedTrack(t, "TR_1", 0))
+		require.Empty(t, p.reservedSubscriptions)
+		require.Equal(t, ErrSubscriptionLimit, p.ReserveSubscription("TR_2"))
+	})
+
+	t.Run("concurrent reservations stay within the limit", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.params.SubscriptionLimit = config.SubscriptionLimitConfig{MaxSubscriptions: 3, Policy: config.SubscriptionLimitPolicyReject}
+		var wg sync.WaitGroup
+		var reserved int32
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if p.ReserveSubscription(fmt.Sprintf("TR_%d", i)) == nil {
+					atomic.AddInt32(&reserved, 1)
+				}
+			}(i)
+		}
+		wg.Wait()
+		require.Equal(t, int32(3), reserved)
+	})
+
+	t.Run("evicts the least recently visible track", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.params.SubscriptionLimit = config.SubscriptionLimitConfig{MaxSubscriptions: 3, Policy: config.SubscriptionLimitPolicyEvict}
+		visible := newSubscribedTrack(t, "TR_visible", 0)
+		hiddenLong := newSubscribedTrack(t, "TR_hidden_long", time.Minute)
+		hiddenRecently := newSubscribedTrack(t, "TR_hidden_recently", time.Second)
+		for _, st := range []*SubscribedTrack{visible, hiddenLong, hiddenRecently} {
+			p.subscribedTracks[st.ID()] = st
+		}
+
+		closed := false
+		hiddenLong.DownTrack().OnCloseHandler(func() {
+			closed = true
+		})
+		require.NoError(t, p.ReserveSubscription("TR_new"))
+		require.True(t, closed)
+		require.Len(t, p.subscribedTracks, 2)
+		require.Nil(t, p.subscribedTracks["TR_hidden_long"])
+
+		// at the limit again, the recently hidden track goes next
+		p.AddSubscribedTrack(newSubscribedTrack(t, "TR_new", 0))
+		require.NoError(t, p.ReserveSubscription("TR_newer"))
+		require.Nil(t, p.subscribedTracks["TR_hidden_recently"])
+		require.NotNil(t, p.subscribedTracks["TR_visible"])
+
+		// tracks the client shows aren't evicted
+		p.AddSubscribedTrack(newSubscribedTrack(t, "TR_newer", 0))
+		require.Equal(t, ErrSubscriptionLimit, p.ReserveSubscription("TR_newest"))
+		require.Len(t, p.subscribedTracks, 3)
+	})
+}
+
+// stubTrackReceiver is enough of a receiver to create down tracks that aren't bound
+type stubTrackReceiver struct {
+	sfu.TrackReceiver
+	trackID string
+}
+
+func (r *stubTrackReceiver) TrackID() string {
+	return r.trackID
+}
+
+func (r *stubTrackReceiver) StreamID() string {
+	return "stream"
+}
+
+func (r *stubTrackReceiver) GetBitrateTemporalCumulative() [3][4]int64 {
+	return [3][4]int64{}
+}
+
 func newParticipantForTest(identity string) *ParticipantImpl {
+	return newParticipantOfKindForTest(identity, "")
+}
+
+func newParticipantOfKindForTest(identity string, kind string) *ParticipantImpl {
 	conf, _ := config.NewConfig("", nil)
 	// disable mux, it doesn't play too well with unit test
 	conf.RTC.UDPPort = 0
@@ -317,6 +862,125 @@ func newParticipantForTest(identity string) *ParticipantImpl {
 		Sink:            &routingfakes.FakeMessageSink{},
 		ProtocolVersion: 4,
 		ThrottleConfig:  conf.RTC.PLIThrottle,
+		EnabledCodecs:   []*livekit.Codec{{Mime: webrtc.MimeTypeOpus}, {Mime: webrtc.MimeTypeVP8}},
+		Kind:            kind,
 	})
 	return p
 }
+
+func TestSenderReportBatches(t *testing.T) {
+	var srs []rtcp.Packet
+	var sd []rtcp.SourceDescriptionChunk
+	for i := uint32(0); i < 5; i++ {
+		srs = append(srs, &rtcp.SenderReport{SSRC: i})
+		sd = append(sd, rtcp.SourceDescriptionChunk{Source: i})
+	}
+	// tracks can have more than one chunk
+	sd = append(sd, rtcp.SourceDescriptionChunk{Source: 5})
+
+	batches := senderReportBatches(srs, sd, 2)
+	require.Len(t, batches, 3)
+	require.Equal(t, []rtcp.Packet{srs[0], srs[1], &rtcp.SourceDescription{Chunks: sd[0:2]}}, batches[0])
+	require.Equal(t, []rtcp.Packet{srs[2], srs[3], &rtcp.SourceDescription{Chunks: sd[2:4]}}, batches[1])
+	require.Equal(t, []rtcp.Packet{srs[4], &rtcp.SourceDescription{Chunks: sd[4:6]}}, batches[2])
+
+	require.Len(t, senderReportBatches(srs, sd, config.DefaultSenderReportBatchSize), 1)
+	require.Empty(t, senderReportBatches(nil, nil, config.DefaultSenderReportBatchSize))
+}
+
+func TestExpirePendingTracks(t *testing.T) {
+	p := newParticipantForTest("test")
+	p.params.PendingTrackTimeout = 30 * time.Second
+	p.params.Telemetry = telemetry.NewTelemetryService(nil, nil, nil, nil)
+	p.params.Logger = logger.Logger(logger.GetLogger())
+
+	p.AddTrack(&livekit.AddTrackRequest{Cid: "camera", Type: livekit.TrackType_VIDEO})
+	p.AddTrack(&livekit.AddTrackRequest{Cid: "mic", Type: livekit.TrackType_AUDIO})
+	p.lock.Lock()
+	p.pendingTracksAddedAt["camera"] = time.Now().Add(-time.Minute)
+	p.lock.Unlock()
+
+	p.expirePendingTracks(time.Now())
+	require.Len(t, p.pendingTracks, 1)
+	require.NotNil(t, p.pendingTracks["mic"])
+	require.Len(t, p.pendingTracksAddedAt, 1)
+
+	// the track can be added again
+	p.AddTrack(&livekit.AddTrackRequest{Cid: "camera", Type: livekit.TrackType_VIDEO})
+	require.Len(t, p.pendingTracks, 2)
+
+	p.expirePendingTracks(time.Now().Add(time.Minute))
+	require.Empty(t, p.pendingTracks)
+	require.Empty(t, p.pendingTracksAddedAt)
+}
+
+func TestMediaOfExpiredPendingTrack(t *testing.T) {
+	p := newParticipantForTest("test")
+	p.params.PendingTrackTimeout = 30 * time.Second
+	p.params.Telemetry = telemetry.NewTelemetryService(nil, nil, nil, nil)
+	p.params.Logger = logger.Logger(logger.GetLogger())
+	t.Cleanup(func() { _ = p.Close() })
+
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pc.Close() })
+	track, err := webrtc.NewTrackLocalStaticRTP(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus}, "mic", "stream")
+	require.NoError(t, err)
+	_, err = pc.AddTrack(track)
+	require.NoError(t, err)
+
+	// the track expires before its media arrives
+	p.AddTrack(&livekit.AddTrackRequest{Cid: "mic", Type: livekit.TrackType_AUDIO})
+	p.lock.Lock()
+	p.pendingTracksAddedAt["mic"] = time.Now().Add(-time.Minute)
+	p.lock.Unlock()
+	p.expirePendingTracks(time.Now())
+	require.Empty(t, p.pendingTracks)
+
+	// candidates are sent with the offer, the participant's are found as peer reflexive
+	offer, err := pc.CreateOffer(nil)
+	require.NoError(t, err)
+	gathered := webrtc.GatheringCompletePromise(pc)
+	require.NoError(t, pc.SetLocalDescription(offer))
+	<-gathered
+	answer, err := p.HandleOffer(*pc.LocalDescription())
+	require.NoError(t, err)
+	require.NoError(t, pc.SetRemoteDescription(answer))
+
+	// the data channel isn't open, so the error is held
+	heldErrors := func() []*livekit.DataPacket {
+		p.lock.RLock()
+		defer p.lock.RUnlock()
+		return p.heldTrackErrors
+	}
+	sn := uint16(0)
+	require.Eventually(t, func() bool {
+		sn++
+		_ = track.WriteRTP(&rtp.Packet{
+			Header:  rtp.Header{Version: 2, PayloadType: 111, SequenceNumber: sn, Timestamp: uint32(sn) * 960},
+			Payload: []byte{0xf8, 0xff, 0xfe},
+		})
+		return len(heldErrors()) > 0
+	}, 10*time.Second, 20*time.Millisecond)
+
+	held := heldErrors()
+	require.Len(t, held, 1)
+	var msg map[string]interface{}
+	require.NoError(t, json.Unmarshal(held[0].GetUser().Payload, &msg))
+	require.Equal(t, "track_publish_failed", msg["type"])
+	require.Equal(t, "mic", msg["cid"])
+	require.Equal(t, "track_not_found", msg["reason"])
+	require.Equal(t, ErrPendingTrackNotFound.Error(), msg["message"])
+	require.Empty(t, p.GetPublishedTracks())
+}
+
+func TestCanSubscribeTo(t *testing.T) {
+	p := newParticipantForTest("test")
+	require.True(t, p.CanSubscribeTo("anyone"))
+
+	p.params.CanSubscribeTo = []string{"host", "guest-*"}
+	require.True(t, p.CanSubscribeTo("host"))
+	require.True(t, p.CanSubscribeTo("guest-1"))
+	require.False(t, p.CanSubscribeTo("hostess"))
+	require.False(t, p.CanSubscribeTo("other"))
+}
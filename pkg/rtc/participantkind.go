@@ -0,0 +1,51 @@
+package rtc
+
+// Kinds of participants. Hidden and recorder participants aren't visible to others, and only
+// subscribe
+const (
+	ParticipantKindStandard = "standard"
+	ParticipantKindHidden   = "hidden"
+	ParticipantKindRecorder = "recorder"
+	// publishes a stream ingested from outside of WebRTC
+	ParticipantKindIngress = "ingress"
+	// a backend service taking part in the room
+	ParticipantKindAgent = "agent"
+
+	// attribute the kind of a participant is surfaced with
+	participantKindAttribute = "kind"
+)
+
+// ParseParticipantKind validates the kind a client joins as. Hidden and recorder participants need
+// a hidden grant, participants with a hidden grant are hidden unless they join as recorders
+func ParseParticipantKind(value string, hidden bool) (string, bool) {
+	switch value {
+	case "", ParticipantKindStandard:
+		if hidden {
+			return ParticipantKindHidden, true
+		}
+		return ParticipantKindStandard, true
+	case ParticipantKindHidden, ParticipantKindRecorder:
+		return value, hidden
+	case ParticipantKindIngress, ParticipantKindAgent:
+		return value, !hidden
+	default:
+		return "", false
+	}
+}
+
+// IsHiddenKind returns true for kinds of participants that aren't visible to others
+func IsHiddenKind(kind string) bool {
+	return kind == ParticipantKindHidden || kind == ParticipantKindRecorder
+}
+
+// isSubscribeOnlyKind returns true for kinds of participants that never publish, no publisher
+// transport is created for them
+func isSubscribeOnlyKind(kind string) bool {
+	return IsHiddenKind(kind)
+}
+
+// subscribeOnly returns true when the participant never publishes, because of its kind or because
+// it watches a room in broadcast mode
+func (p *ParticipantImpl) subscribeOnly() bool {
+	return isSubscribeOnlyKind(p.params.Kind) || p.params.Policy.IsViewer(p.params.Identity)
+}
@@ -0,0 +1,87 @@
+package rtc
+
+import (
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+func TestParseParticipantKind(t *testing.T) {
+	for _, tc := range []struct {
+		value  string
+		hidden bool
+		kind   string
+		ok     bool
+	}{
+		{"", false, ParticipantKindStandard, true},
+		{"", true, ParticipantKindHidden, true},
+		{ParticipantKindStandard, true, ParticipantKindHidden, true},
+		{ParticipantKindRecorder, true, ParticipantKindRecorder, true},
+		{ParticipantKindRecorder, false, "", false},
+		{ParticipantKindHidden, false, "", false},
+		{ParticipantKindAgent, false, ParticipantKindAgent, true},
+		{ParticipantKindIngress, true, "", false},
+		{"bot", false, "", false},
+	} {
+		kind, ok := ParseParticipantKind(tc.value, tc.hidden)
+		require.Equal(t, tc.ok, ok, tc.value)
+		if ok {
+			require.Equal(t, tc.kind, kind, tc.value)
+		}
+	}
+}
+
+func TestSubscribeOnlyParticipants(t *testing.T) {
+	t.Run("recorders have no publisher transport", func(t *testing.T) {
+		p := newParticipantOfKindForTest("recorder", ParticipantKindRecorder)
+		require.Nil(t, p.publisher)
+		require.True(t, p.Hidden())
+		require.False(t, p.CanPublish())
+		require.True(t, p.SubscriberAsPrimary())
+
+		_, err := p.HandleOffer(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer})
+		require.ErrorIs(t, err, ErrNoPublisher)
+		require.ErrorIs(t, p.AddICECandidate(webrtc.ICECandidateInit{}, livekit.SignalTarget_PUBLISHER), ErrNoPublisher)
+		require.NoError(t, p.Close())
+	})
+
+	t.Run("publisher is kept for clients using it as primary", func(t *testing.T) {
+		p := newParticipantOfKindForTest("recorder", ParticipantKindRecorder)
+		p.params.Capabilities = types.ProtocolVersion(2).Capabilities()
+		p2, err := NewParticipant(p.params)
+		require.NoError(t, err)
+		require.NotNil(t, p2.publisher)
+		require.False(t, p2.CanPublish())
+		require.False(t, p2.SubscriberAsPrimary())
+	})
+
+	t.Run("viewers of a broadcast have no publisher transport", func(t *testing.T) {
+		p := newParticipantOfKindForTest("viewer", "")
+		p.params.Policy.Broadcast = &config.BroadcastPolicy{Publishers: []string{"host"}}
+		viewer, err := NewParticipant(p.params)
+		require.NoError(t, err)
+		require.Nil(t, viewer.publisher)
+		require.False(t, viewer.CanPublish())
+		require.True(t, viewer.CanSubscribe())
+
+		p.params.Identity = "host"
+		host, err := NewParticipant(p.params)
+		require.NoError(t, err)
+		require.NotNil(t, host.publisher)
+		require.True(t, host.CanPublish())
+	})
+
+	t.Run("agents publish", func(t *testing.T) {
+		p := newParticipantOfKindForTest("agent", ParticipantKindAgent)
+		require.NotNil(t, p.publisher)
+		require.False(t, p.Hidden())
+		require.True(t, p.CanPublish())
+		attributes, _ := participantAttributes(p)
+		require.Equal(t, map[string]string{participantKindAttribute: ParticipantKindAgent}, attributes)
+	})
+}
@@ -0,0 +1,30 @@
+package rtc
+
+import (
+	livekit "github.com/livekit/protocol/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// participantNameField carries the display name in participant infos, the protocol has no field
+// for it
+const participantNameField protowire.Number = 1005
+
+// max bytes of a display name
+const maxParticipantNameLength = 256
+
+// ValidateParticipantName returns an error when name can't be a display name
+func ValidateParticipantName(name string) error {
+	if len(name) > maxParticipantNameLength {
+		return ErrInvalidName
+	}
+	return nil
+}
+
+// ParticipantName returns the display name in a participant info, empty when it has none
+func ParticipantName(info *livekit.ParticipantInfo) string {
+	return string(unknownBytesField(info.ProtoReflect().GetUnknown(), participantNameField))
+}
+
+func setParticipantName(info *livekit.ParticipantInfo, name string) {
+	setUnknownField(info.ProtoReflect(), participantNameField, bytesField(participantNameField, []byte(name)))
+}
@@ -0,0 +1,38 @@
+package rtc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+func TestParticipantName(t *testing.T) {
+	p := newParticipantForTest("alice")
+	require.Empty(t, ParticipantName(p.ToProto()))
+
+	updates := 0
+	p.OnMetadataUpdate(func(types.Participant) {
+		updates++
+	})
+	p.SetName("Alice")
+	require.Equal(t, "Alice", p.Name())
+	info := p.ToProto()
+	require.Equal(t, "Alice", ParticipantName(info))
+	require.Equal(t, "alice", info.Identity)
+	require.Equal(t, "Alice", p.GetStats().Name)
+	require.Equal(t, 1, updates)
+
+	// renaming to the same name isn't an update
+	p.SetName("Alice")
+	require.Equal(t, 1, updates)
+
+	p.SetName("")
+	require.Empty(t, ParticipantName(p.ToProto()))
+	require.Equal(t, 2, updates)
+
+	require.NoError(t, ValidateParticipantName(strings.Repeat("a", maxParticipantNameLength)))
+	require.Equal(t, ErrInvalidName, ValidateParticipantName(strings.Repeat("a", maxParticipantNameLength+1)))
+}
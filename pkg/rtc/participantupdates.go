@@ -0,0 +1,104 @@
+package rtc
+
+import (
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// pendingParticipantUpdate is a participant whose state changed since updates were last sent
+type pendingParticipantUpdate struct {
+	participant types.Participant
+	// whether the participant itself is left out, only when all of its changes left it out
+	skipSource bool
+}
+
+// queueParticipantUpdate batches an update about participant p, to be sent with the next updates.
+// Later changes to p supersede the ones already queued, its state is read when updates are sent
+func (r *Room) queueParticipantUpdate(p types.Participant, skipSource bool) {
+	r.updateLock.Lock()
+	defer r.updateLock.Unlock()
+
+	if u := r.pendingUpdates[p.ID()]; u != nil {
+		u.skipSource = u.skipSource && skipSource
+		return
+	}
+	if r.pendingUpdates == nil {
+		r.pendingUpdates = make(map[string]*pendingParticipantUpdate)
+	}
+	r.pendingUpdates[p.ID()] = &pendingParticipantUpdate{participant: p, skipSource: skipSource}
+	r.pendingUpdateOrder = append(r.pendingUpdateOrder, p.ID())
+}
+
+func (r *Room) participantUpdateWorker() {
+	ticker := time.NewTicker(r.roomConfig.ParticipantUpdateInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.closed:
+			return
+		case <-ticker.C:
+			r.sendParticipantUpdates()
+		}
+	}
+}
+
+// sendParticipantUpdates sends each participant one update, with the state of the participants
+// that changed since the last updates were sent
+func (r *Room) sendParticipantUpdates() {
+	r.updateLock.Lock()
+	pending := make([]*pendingParticipantUpdate, 0, len(r.pendingUpdateOrder))
+	for _, id := range r.pendingUpdateOrder {
+		pending = append(pending, r.pendingUpdates[id])
+	}
+	r.pendingUpdates = nil
+	r.pendingUpdateOrder = nil
+	r.updateLock.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	r.lock.Lock()
+	updatedAt := time.Now()
+	changed := pending[:0]
+	infos := make([]*livekit.ParticipantInfo, 0, len(pending))
+	for _, u := range pending {
+		info := u.participant.ToProto()
+		if !u.participant.Hidden() && r.unchangedUpdate(info, u.skipSource) {
+			continue
+		}
+		changed = append(changed, u)
+		infos = append(infos, info)
+	}
+	pending = changed
+	r.lock.Unlock()
+
+	for _, op := range r.GetParticipants() {
+		if op.State() == livekit.ParticipantInfo_DISCONNECTED {
+			continue
+		}
+
+		var updates []*livekit.ParticipantInfo
+		for i, u := range pending {
+			isSource := u.participant.ID() == op.ID()
+			if (isSource && u.skipSource) || (!isSource && u.participant.Hidden()) {
+				// hidden participants are only sent their own updates
+				continue
+			}
+			if r.skipsUpdate(op, u.participant) {
+				continue
+			}
+			updates = append(updates, infos[i])
+		}
+		if len(updates) == 0 {
+			continue
+		}
+
+		if err := r.sendParticipantUpdate(op, updates, updatedAt); err != nil {
+			r.Logger.Errorw("could not send update to participant", err,
+				"participant", op.Identity(), "pID", op.ID())
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package rtc
+
+import (
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// PendingJoin is a participant waiting in the waiting room for its join to be approved
+type PendingJoin struct {
+	Identity string `json:"identity"`
+	Metadata string `json:"metadata,omitempty"`
+	// unix time the participant asked to join
+	RequestedAt int64 `json:"requested_at"`
+}
+
+// pendingJoinsMessage tells participants that approve joins who is waiting in the waiting room
+type pendingJoinsMessage struct {
+	Type         string         `json:"type"`
+	Participants []*PendingJoin `json:"participants"`
+}
+
+// SetPendingJoins replaces the participants waiting to join the room. Participants that approve
+// joins receive the list when it changes, and the room isn't closed as empty while it's not empty
+func (r *Room) SetPendingJoins(pending []*PendingJoin) {
+	if pending == nil {
+		pending = []*PendingJoin{}
+	}
+	r.pendingJoinsLock.Lock()
+	r.pendingJoins = pending
+	r.pendingJoinsVersion++
+	r.pendingJoinsLock.Unlock()
+}
+
+func (r *Room) getPendingJoins() ([]*PendingJoin, uint64) {
+	r.pendingJoinsLock.Lock()
+	defer r.pendingJoinsLock.Unlock()
+	return r.pendingJoins, r.pendingJoinsVersion
+}
+
+// sendPendingJoins sends the participants that approve joins the pending joins, when they changed
+// since they were last sent, returning the version each participant received
+func (r *Room) sendPendingJoins(participants []types.Participant, lastSent map[string]uint64) map[string]uint64 {
+	pending, version := r.getPendingJoins()
+	if version == 0 {
+		// there never were pending joins
+		return lastSent
+	}
+
+	sent := make(map[string]uint64, len(lastSent))
+	for _, op := range participants {
+		if !op.CanApproveJoins() {
+			continue
+		}
+		if lastSent[op.Identity()] == version {
+			sent[op.Identity()] = version
+			continue
+		}
+		if !op.Capabilities().DataPackets || op.State() != livekit.ParticipantInfo_ACTIVE {
+			continue
+		}
+		dp, err := newServerMessagePacket(&pendingJoinsMessage{
+			Type:         pendingJoinsMessageType,
+			Participants: pending,
+		})
+		if err == nil {
+			err = op.SendDataPacket(dp)
+		}
+		if err != nil {
+			// try again on the next update
+			r.Logger.Warnw("could not send pending joins", err, "participant", op.Identity())
+			continue
+		}
+		sent[op.Identity()] = version
+	}
+	return sent
+}
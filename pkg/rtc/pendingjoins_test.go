@@ -0,0 +1,57 @@
+package rtc
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/livekit/protocol/logger"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestSendPendingJoins(t *testing.T) {
+	newParticipant := func(identity string, approver bool) *typesfakes.FakeParticipant {
+		p := &typesfakes.FakeParticipant{}
+		p.IdentityReturns(identity)
+		p.StateReturns(livekit.ParticipantInfo_ACTIVE)
+		p.CapabilitiesReturns(types.ProtocolVersion(3).Capabilities())
+		p.CanApproveJoinsReturns(approver)
+		return p
+	}
+	host := newParticipant("host", true)
+	guest := newParticipant("guest", false)
+	r := &Room{Logger: logger.Logger(logger.GetLogger())}
+	participants := []types.Participant{host, guest}
+
+	// nothing to send before anyone waited
+	sent := r.sendPendingJoins(participants, nil)
+	require.Zero(t, host.SendDataPacketCallCount())
+
+	r.SetPendingJoins([]*PendingJoin{{Identity: "bob", RequestedAt: 1700000000}})
+	sent = r.sendPendingJoins(participants, sent)
+	require.Equal(t, 1, host.SendDataPacketCallCount())
+	require.Zero(t, guest.SendDataPacketCallCount())
+
+	var msg pendingJoinsMessage
+	require.NoError(t, json.Unmarshal(host.SendDataPacketArgsForCall(0).GetUser().Payload, &msg))
+	require.Equal(t, pendingJoinsMessageType, msg.Type)
+	require.Equal(t, []*PendingJoin{{Identity: "bob", RequestedAt: 1700000000}}, msg.Participants)
+
+	// not sent again until the list changes
+	sent = r.sendPendingJoins(participants, sent)
+	require.Equal(t, 1, host.SendDataPacketCallCount())
+
+	// an empty list is sent once the last one is approved, and retried when sending fails
+	r.SetPendingJoins(nil)
+	host.SendDataPacketReturnsOnCall(1, errors.New("data channel closed"))
+	sent = r.sendPendingJoins(participants, sent)
+	require.Equal(t, 2, host.SendDataPacketCallCount())
+	r.sendPendingJoins(participants, sent)
+	require.Equal(t, 3, host.SendDataPacketCallCount())
+	require.JSONEq(t, `{"type": "pending_joins", "participants": []}`,
+		string(host.SendDataPacketArgsForCall(2).GetUser().Payload))
+}
@@ -0,0 +1,91 @@
+package rtc
+
+import (
+	"context"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+// how often pending tracks are checked for expiry
+const pendingTrackCheckInterval = time.Second
+
+// pendingTrackExpiredMessage tells a participant that a track it asked to publish was dropped,
+// because its media never arrived. The client can publish the track again
+type pendingTrackExpiredMessage struct {
+	Type     string `json:"type"`
+	Cid      string `json:"cid"`
+	TrackSid string `json:"track_sid"`
+}
+
+func newPendingTrackExpiredPacket(cid string, trackSid string) (*livekit.DataPacket, error) {
+	return newServerMessagePacket(&pendingTrackExpiredMessage{
+		Type:     pendingTrackExpiredMessageType,
+		Cid:      cid,
+		TrackSid: trackSid,
+	})
+}
+
+// pendingTracksWorker drops the tracks that were added but didn't receive media within the
+// pending_track_timeout
+func (p *ParticipantImpl) pendingTracksWorker() {
+	defer Recover()
+
+	ticker := time.NewTicker(pendingTrackCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.closed:
+			return
+		case now := <-ticker.C:
+			p.expirePendingTracks(now)
+		}
+	}
+}
+
+// expirePendingTracks drops the pending tracks added before the timeout, reporting them to the
+// client and to webhooks. A publish intent that never completes usually points at a client bug
+func (p *ParticipantImpl) expirePendingTracks(now time.Time) {
+	type expiredTrack struct {
+		cid        string
+		info       *livekit.TrackInfo
+		pendingFor time.Duration
+	}
+
+	var expired []expiredTrack
+	p.lock.Lock()
+	for cid, addedAt := range p.pendingTracksAddedAt {
+		if pendingFor := now.Sub(addedAt); pendingFor >= p.params.PendingTrackTimeout {
+			expired = append(expired, expiredTrack{cid: cid, info: p.pendingTracks[cid], pendingFor: pendingFor})
+			p.removePendingTrack(cid)
+		}
+	}
+	p.lock.Unlock()
+
+	for _, t := range expired {
+		p.params.Logger.Warnw("pending track expired, no media received", nil,
+			"track", t.info.Sid, "cid", t.cid, "kind", t.info.Type.String(), "pendingFor", t.pendingFor)
+
+		p.params.Telemetry.PendingTrackExpired(context.Background(), &telemetry.PendingTrackExpiredEvent{
+			ParticipantSid:      p.ID(),
+			ParticipantIdentity: p.Identity(),
+			TrackSid:            t.info.Sid,
+			TrackType:           t.info.Type.String(),
+			TrackSource:         t.info.Source.String(),
+			PendingFor:          t.pendingFor.Milliseconds(),
+		})
+
+		if !p.Capabilities().DataPackets {
+			continue
+		}
+		dp, err := newPendingTrackExpiredPacket(t.cid, t.info.Sid)
+		if err == nil {
+			err = p.SendDataPacket(dp)
+		}
+		if err != nil {
+			p.params.Logger.Debugw("could not send expired pending track", "error", err, "track", t.info.Sid)
+		}
+	}
+}
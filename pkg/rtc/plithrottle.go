@@ -4,14 +4,35 @@ import (
 	"sync"
 	"time"
 
+	"github.com/pion/rtcp"
+
 	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
+// pliThrottle coalesces the keyframe requests of subscribers into at most one PLI or FIR per
+// simulcast layer of a publisher each throttle period. The first request of a period is sent right
+// away. Requests made during the rest of the period, like the burst of requests when many
+// subscribers switch layers at once, are sent upstream as a single request once the period is over
 type pliThrottle struct {
-	config   config.PLIThrottleConfig
-	mu       sync.RWMutex
-	periods  map[uint32]int64
-	lastSent map[uint32]int64
+	config config.PLIThrottleConfig
+	mu     sync.Mutex
+	layers map[uint32]*pliLayer
+	closed bool
+	// throttle periods are multiplied by it, more than 1 while the node is degraded
+	factor float64
+	// sends the coalesced request of a layer at the end of its period
+	onSend func(pkt rtcp.Packet)
+}
+
+// pliLayer throttles the keyframe requests of a layer, by its SSRC
+type pliLayer struct {
+	quality  string
+	period   time.Duration
+	lastSent time.Time
+	// the last request made since then, sent when the period is over
+	pending rtcp.Packet
+	timer   *time.Timer
 }
 
 // github.com/livekit/livekit-server/pkg/sfu/simulcast.go
@@ -21,44 +42,125 @@ const (
 	quarterResolution = "q"
 )
 
+// layer labels of throttled keyframe requests
+const (
+	pliQualityLow  = "low"
+	pliQualityMid  = "mid"
+	pliQualityHigh = "high"
+)
+
 func newPLIThrottle(conf config.PLIThrottleConfig) *pliThrottle {
 	return &pliThrottle{
-		config:   conf,
-		periods:  make(map[uint32]int64),
-		lastSent: make(map[uint32]int64),
+		config: conf,
+		layers: make(map[uint32]*pliLayer),
+		factor: 1,
 	}
 }
 
+// setConfig changes the throttle periods of tracks added from now on
+func (t *pliThrottle) setConfig(conf config.PLIThrottleConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.config = conf
+}
+
+// setFactor multiplies the throttle periods of all tracks by factor, 1 for the configured periods
+func (t *pliThrottle) setFactor(factor float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.factor = factor
+}
+
+// onCoalescedRequest sets the function coalesced requests are sent with
+func (t *pliThrottle) onCoalescedRequest(fn func(pkt rtcp.Packet)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onSend = fn
+}
+
 func (t *pliThrottle) addTrack(ssrc uint32, rid string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	var duration time.Duration
+	layer := &pliLayer{}
 	switch rid {
 	case fullResolution:
-		duration = t.config.HighQuality
+		layer.quality, layer.period = pliQualityHigh, t.config.HighQuality
 	case halfResolution:
-		duration = t.config.MidQuality
+		layer.quality, layer.period = pliQualityMid, t.config.MidQuality
 	case quarterResolution:
-		duration = t.config.LowQuality
+		layer.quality, layer.period = pliQualityLow, t.config.LowQuality
 	default:
-		duration = t.config.MidQuality
+		layer.quality, layer.period = pliQualityMid, t.config.MidQuality
 	}
 
-	t.periods[ssrc] = duration.Nanoseconds()
+	if prev := t.layers[ssrc]; prev != nil && prev.timer != nil {
+		prev.timer.Stop()
+	}
+	t.layers[ssrc] = layer
+}
+
+// request returns whether the keyframe request pkt for the layer with ssrc can be sent now. When
+// it can't, it's sent with the requests coalesced into it at the end of the throttle period
+func (t *pliThrottle) request(ssrc uint32, pkt rtcp.Packet) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return false
+	}
+	layer, ok := t.layers[ssrc]
+	if !ok {
+		return true
+	}
+
+	now := time.Now()
+	period := time.Duration(float64(layer.period) * t.factor)
+	wait := layer.lastSent.Add(period).Sub(now)
+	if layer.pending == nil && wait < 0 {
+		layer.lastSent = now
+		return true
+	}
+
+	if layer.pending != nil {
+		// already coalesced into the pending request
+		prometheus.IncrementKeyframeRequestSuppressed(layer.quality)
+	} else {
+		layer.timer = time.AfterFunc(wait, func() {
+			t.sendPending(ssrc, layer)
+		})
+	}
+	layer.pending = pkt
+	return false
+}
+
+func (t *pliThrottle) sendPending(ssrc uint32, layer *pliLayer) {
+	t.mu.Lock()
+	pkt := layer.pending
+	onSend := t.onSend
+	layer.pending = nil
+	layer.timer = nil
+	if t.closed || t.layers[ssrc] != layer || pkt == nil {
+		t.mu.Unlock()
+		return
+	}
+	layer.lastSent = time.Now()
+	t.mu.Unlock()
+
+	if onSend != nil {
+		onSend(pkt)
+	}
 }
 
-func (t *pliThrottle) canSend(ssrc uint32) bool {
+// close drops the pending requests
+func (t *pliThrottle) close() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if period, ok := t.periods[ssrc]; ok {
-		if n := time.Now().UnixNano(); n-t.lastSent[ssrc] > period {
-			t.lastSent[ssrc] = n
-			return true
-		} else {
-			return false
+	t.closed = true
+	for _, layer := range t.layers {
+		if layer.timer != nil {
+			layer.timer.Stop()
 		}
 	}
-	return true
 }
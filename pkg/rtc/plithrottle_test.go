@@ -0,0 +1,93 @@
+package rtc
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestPLIThrottle(t *testing.T) {
+	const period = 100 * time.Millisecond
+	throttle := newPLIThrottle(config.PLIThrottleConfig{
+		LowQuality:  period,
+		MidQuality:  period,
+		HighQuality: period,
+	})
+	var mu sync.Mutex
+	var sent []rtcp.Packet
+	throttle.onCoalescedRequest(func(pkt rtcp.Packet) {
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, pkt)
+	})
+	sentCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(sent)
+	}
+	throttle.addTrack(1, quarterResolution)
+	throttle.addTrack(2, fullResolution)
+	pli := func(ssrc uint32) rtcp.Packet {
+		return &rtcp.PictureLossIndication{MediaSSRC: ssrc}
+	}
+
+	t.Run("first request of a period is sent", func(t *testing.T) {
+		require.True(t, throttle.request(1, pli(1)))
+		// layers are throttled separately
+		require.True(t, throttle.request(2, pli(2)))
+		// unknown tracks aren't throttled
+		require.True(t, throttle.request(3, pli(3)))
+	})
+
+	t.Run("a burst is coalesced into one request", func(t *testing.T) {
+		fir := &rtcp.FullIntraRequest{MediaSSRC: 1}
+		require.False(t, throttle.request(1, pli(1)))
+		require.False(t, throttle.request(1, pli(1)))
+		require.False(t, throttle.request(1, fir))
+		require.Zero(t, sentCount())
+
+		require.Eventually(t, func() bool { return sentCount() == 1 }, time.Second, 10*time.Millisecond)
+		mu.Lock()
+		require.Equal(t, fir, sent[0])
+		mu.Unlock()
+
+		// the coalesced request starts a new period
+		require.False(t, throttle.request(1, pli(1)))
+	})
+
+	t.Run("pending requests are dropped when closed", func(t *testing.T) {
+		throttle.close()
+		time.Sleep(2 * period)
+		require.Equal(t, 1, sentCount())
+		require.False(t, throttle.request(2, pli(2)))
+	})
+}
+
+func TestPLIThrottle_Factor(t *testing.T) {
+	const period = 50 * time.Millisecond
+	throttle := newPLIThrottle(config.PLIThrottleConfig{
+		LowQuality:  period,
+		MidQuality:  period,
+		HighQuality: period,
+	})
+	defer throttle.close()
+	throttle.addTrack(1, fullResolution)
+	throttle.addTrack(2, fullResolution)
+	pli := func(ssrc uint32) rtcp.Packet {
+		return &rtcp.PictureLossIndication{MediaSSRC: ssrc}
+	}
+
+	require.True(t, throttle.request(1, pli(1)))
+	throttle.setFactor(4)
+	require.True(t, throttle.request(2, pli(2)))
+	time.Sleep(2 * period)
+
+	// periods of all tracks are longer while degraded
+	require.False(t, throttle.request(1, pli(1)))
+	require.False(t, throttle.request(2, pli(2)))
+}
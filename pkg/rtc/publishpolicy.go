@@ -0,0 +1,124 @@
+package rtc
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// simulcast layers by quality, as clients name them
+var simulcastRidQuality = map[string]int{
+	"q": 0,
+	"h": 1,
+	"f": 2,
+}
+
+// applyPublishPolicy limits the video that the publisher sends, by setting the max bitrate and
+// removing the highest simulcast layers of each video section of the answer
+func applyPublishPolicy(answer webrtc.SessionDescription, policy config.RoomPolicy) (webrtc.SessionDescription, error) {
+	if policy.MaxPublishBitrate == 0 && policy.MaxSimulcastLayers == 0 {
+		return answer, nil
+	}
+
+	parsed, err := answer.Unmarshal()
+	if err != nil {
+		return answer, err
+	}
+	for _, media := range parsed.MediaDescriptions {
+		if !strings.EqualFold(media.MediaName.Media, "video") {
+			continue
+		}
+		if policy.MaxPublishBitrate != 0 {
+			setMaxBitrate(media, policy.MaxPublishBitrate)
+		}
+		if policy.MaxSimulcastLayers != 0 {
+			limitSimulcastLayers(media, policy.MaxSimulcastLayers)
+		}
+	}
+
+	munged, err := parsed.Marshal()
+	if err != nil {
+		return answer, err
+	}
+	return webrtc.SessionDescription{Type: answer.Type, SDP: string(munged)}, nil
+}
+
+// setMaxBitrate sets the max bitrate of the section with b=AS, in kbps. TIAS isn't used, pion
+// can't parse it
+func setMaxBitrate(media *sdp.MediaDescription, bitrate uint64) {
+	bandwidths := make([]sdp.Bandwidth, 0, len(media.Bandwidth)+1)
+	for _, b := range media.Bandwidth {
+		if b.Type != "AS" {
+			bandwidths = append(bandwidths, b)
+		}
+	}
+	kbps := bitrate / 1000
+	if kbps == 0 {
+		kbps = 1
+	}
+	media.Bandwidth = append(bandwidths, sdp.Bandwidth{Type: "AS", Bandwidth: kbps})
+}
+
+// limitSimulcastLayers removes the highest quality layers from the simulcast streams the section
+// receives, so that the publisher stops sending them
+func limitSimulcastLayers(media *sdp.MediaDescription, maxLayers int) {
+	value, ok := media.Attribute("simulcast")
+	if !ok {
+		return
+	}
+	// a=simulcast:recv q;h;f
+	parts := strings.Fields(value)
+	if len(parts) != 2 || parts[0] != "recv" {
+		return
+	}
+	streams := strings.Split(parts[1], ";")
+	if len(streams) <= maxLayers {
+		return
+	}
+
+	byQuality := make([]string, len(streams))
+	copy(byQuality, streams)
+	sort.SliceStable(byQuality, func(i, j int) bool {
+		return ridQuality(byQuality[i]) < ridQuality(byQuality[j])
+	})
+	dropped := make(map[string]bool)
+	for _, stream := range byQuality[maxLayers:] {
+		// alternatives are separated by commas, paused streams start with ~
+		for _, rid := range strings.Split(stream, ",") {
+			dropped[strings.TrimPrefix(rid, "~")] = true
+		}
+	}
+
+	kept := make([]string, 0, maxLayers)
+	for _, stream := range streams {
+		if !dropped[strings.TrimPrefix(strings.Split(stream, ",")[0], "~")] {
+			kept = append(kept, stream)
+		}
+	}
+	attributes := make([]sdp.Attribute, 0, len(media.Attributes))
+	for _, attr := range media.Attributes {
+		switch attr.Key {
+		case "simulcast":
+			attr.Value = "recv " + strings.Join(kept, ";")
+		case "rid":
+			if fields := strings.Fields(attr.Value); len(fields) > 0 && dropped[fields[0]] {
+				continue
+			}
+		}
+		attributes = append(attributes, attr)
+	}
+	media.Attributes = attributes
+}
+
+// ridQuality ranks the quality of a simulcast stream, unknown ones rank above the known layers
+func ridQuality(stream string) int {
+	rid := strings.TrimPrefix(strings.Split(stream, ",")[0], "~")
+	if quality, ok := simulcastRidQuality[rid]; ok {
+		return quality
+	}
+	return len(simulcastRidQuality)
+}
@@ -0,0 +1,69 @@
+package rtc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestApplyPublishPolicy(t *testing.T) {
+	answer := webrtc.SessionDescription{
+		Type: webrtc.SDPTypeAnswer,
+		SDP: strings.Join([]string{
+			"v=0",
+			"o=- 0 0 IN IP4 127.0.0.1",
+			"s=-",
+			"t=0 0",
+			"m=audio 9 UDP/TLS/RTP/SAVPF 111",
+			"c=IN IP4 0.0.0.0",
+			"a=mid:0",
+			"a=rtpmap:111 opus/48000/2",
+			"m=video 9 UDP/TLS/RTP/SAVPF 96",
+			"c=IN IP4 0.0.0.0",
+			"a=mid:1",
+			"a=rtpmap:96 VP8/90000",
+			"a=rid:f recv",
+			"a=rid:h recv",
+			"a=rid:q recv",
+			"a=simulcast:recv f;h;q",
+			"",
+		}, "\r\n"),
+	}
+
+	t.Run("no policy leaves the answer as is", func(t *testing.T) {
+		munged, err := applyPublishPolicy(answer, config.RoomPolicy{})
+		require.NoError(t, err)
+		require.Equal(t, answer, munged)
+	})
+
+	t.Run("video is limited", func(t *testing.T) {
+		munged, err := applyPublishPolicy(answer, config.RoomPolicy{
+			MaxPublishBitrate:  1_500_000,
+			MaxSimulcastLayers: 2,
+		})
+		require.NoError(t, err)
+		parsed, err := munged.Unmarshal()
+		require.NoError(t, err)
+
+		audio, video := parsed.MediaDescriptions[0], parsed.MediaDescriptions[1]
+		require.Empty(t, audio.Bandwidth)
+		require.Len(t, video.Bandwidth, 1)
+		require.Equal(t, "AS", video.Bandwidth[0].Type)
+		require.Equal(t, uint64(1500), video.Bandwidth[0].Bandwidth)
+
+		// the highest layer is dropped
+		simulcast, _ := video.Attribute("simulcast")
+		require.Equal(t, "recv h;q", simulcast)
+		var rids []string
+		for _, attr := range video.Attributes {
+			if attr.Key == "rid" {
+				rids = append(rids, attr.Value)
+			}
+		}
+		require.Equal(t, []string{"h recv", "q recv"}, rids)
+	})
+}
@@ -0,0 +1,35 @@
+package rtc
+
+import (
+	"strings"
+
+	livekit "github.com/livekit/protocol/proto"
+)
+
+// ParsePublishSources validates the sources a grant allows publishing from: camera, microphone,
+// screen_share or screen_share_audio. Empty allows all of them
+func ParsePublishSources(names []string) ([]livekit.TrackSource, bool) {
+	var sources []livekit.TrackSource
+	for _, name := range names {
+		value, ok := livekit.TrackSource_value[strings.ToUpper(name)]
+		if !ok || livekit.TrackSource(value) == livekit.TrackSource_UNKNOWN {
+			return nil, false
+		}
+		sources = append(sources, livekit.TrackSource(value))
+	}
+	return sources, true
+}
+
+// isPublishSourceAllowed returns true when source is one of allowed, or allowed is empty. Tracks
+// of unknown source can't be published once sources are restricted
+func isPublishSourceAllowed(allowed []livekit.TrackSource, source livekit.TrackSource) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, s := range allowed {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package rtc
+
+import (
+	"encoding/json"
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+)
+
+func TestParsePublishSources(t *testing.T) {
+	sources, ok := ParsePublishSources(nil)
+	require.True(t, ok)
+	require.Empty(t, sources)
+
+	sources, ok = ParsePublishSources([]string{"microphone", "screen_share_audio"})
+	require.True(t, ok)
+	require.Equal(t, []livekit.TrackSource{livekit.TrackSource_MICROPHONE, livekit.TrackSource_SCREEN_SHARE_AUDIO}, sources)
+
+	_, ok = ParsePublishSources([]string{"camera", "unknown"})
+	require.False(t, ok)
+	_, ok = ParsePublishSources([]string{"webcam"})
+	require.False(t, ok)
+}
+
+func TestPublishSources(t *testing.T) {
+	p := newParticipantForTest("test")
+	p.params.PublishSources = []livekit.TrackSource{livekit.TrackSource_MICROPHONE}
+	sink := p.params.Sink.(*routingfakes.FakeMessageSink)
+
+	p.AddTrack(&livekit.AddTrackRequest{Cid: "mic", Type: livekit.TrackType_AUDIO, Source: livekit.TrackSource_MICROPHONE})
+	require.Equal(t, 1, sink.WriteMessageCallCount())
+	require.NotNil(t, p.pendingTracks["mic"])
+
+	// screen shares and tracks without a source are rejected
+	p.AddTrack(&livekit.AddTrackRequest{Cid: "screen", Type: livekit.TrackType_VIDEO, Source: livekit.TrackSource_SCREEN_SHARE})
+	p.AddTrack(&livekit.AddTrackRequest{Cid: "unknown", Type: livekit.TrackType_VIDEO})
+	require.Equal(t, 1, sink.WriteMessageCallCount())
+	require.Nil(t, p.pendingTracks["screen"])
+	require.Nil(t, p.pendingTracks["unknown"])
+
+	require.Len(t, p.heldTrackErrors, 2)
+	var msg map[string]interface{}
+	require.NoError(t, json.Unmarshal(p.heldTrackErrors[0].GetUser().Payload, &msg))
+	require.Equal(t, "track_publish_failed", msg["type"])
+	require.Equal(t, "screen", msg["cid"])
+	require.Equal(t, "permission_denied", msg["reason"])
+	require.Equal(t, ErrCannotPublishSource.Error(), msg["message"])
+}
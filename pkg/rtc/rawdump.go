@@ -0,0 +1,389 @@
+package rtc
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media/ivfwriter"
+	"github.com/pion/webrtc/v3/pkg/media/oggwriter"
+	"github.com/pion/webrtc/v3/pkg/media/rtpdump"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+const (
+	// peer ID the packets of a track are teed to its dump with, added to the receiver like the
+	// down track of a subscriber
+	rawDumpPeerID = "raw_dump"
+	// packets waiting to be written, packets are dropped when the disk can't keep up
+	rawDumpQueueSize = 500
+	// files that hold all simulcast layers of a track
+	rawDumpAllLayers int32 = -1
+)
+
+// StartRawDump writes the media of the tracks published to the room to disk, including tracks
+// published later, until StopRawDump is called
+func (r *Room) StartRawDump(conf *config.RawDumpConfig) error {
+	dir := filepath.Join(conf.Directory, url.PathEscape(r.Room.Name))
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	if r.rawDump != nil {
+		r.lock.Unlock()
+		return nil
+	}
+	r.rawDump = conf
+	r.rawDumpDir = dir
+	r.rawDumpTaps = make(map[string]*rawDumpTap)
+	r.lock.Unlock()
+
+	r.Logger.Infow("writing media to disk", "directory", dir)
+	for _, p := range r.GetParticipants() {
+		for _, track := range p.GetPublishedTracks() {
+			r.startRawDump(p, track)
+		}
+	}
+	return nil
+}
+
+// StopRawDump stops writing the media of the room to disk, closing the files being written
+func (r *Room) StopRawDump() {
+	r.lock.Lock()
+	taps := r.rawDumpTaps
+	r.rawDump = nil
+	r.rawDumpTaps = nil
+	r.lock.Unlock()
+
+	for _, tap := range taps {
+		tap.receiver.DeleteDownTrack(rawDumpPeerID)
+		tap.Close()
+	}
+	if taps != nil {
+		r.Logger.Infow("stopped writing media to disk")
+	}
+}
+
+func (r *Room) IsRawDumping() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.rawDump != nil
+}
+
+// startRawDump tees the packets of track into its dump, when the room is being dumped
+func (r *Room) startRawDump(p types.Participant, track types.PublishedTrack) {
+	receiver := track.Receiver()
+	if receiver == nil {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.rawDump == nil || r.rawDumpTaps[track.ID()] != nil {
+		return
+	}
+	tap := newRawDumpTap(r.rawDump, r.Logger, receiver, track.ID(), r.rawDumpDir, p.ID()+"_"+track.ID())
+	tap.onClose = func() {
+		r.lock.Lock()
+		if r.rawDumpTaps[track.ID()] == tap {
+			delete(r.rawDumpTaps, track.ID())
+		}
+		r.lock.Unlock()
+	}
+	r.rawDumpTaps[track.ID()] = tap
+	receiver.AddDownTrack(tap)
+}
+
+type rawDumpPacket struct {
+	layer   int32
+	raw     []byte
+	arrival time.Time
+}
+
+// rawDumpTap writes the packets a receiver forwards to files on disk, which are rotated once they
+// reach max_file_size or max_file_duration. Files are written by a worker, so a slow disk doesn't
+// hold up subscribers. The tap is closed with the receiver
+type rawDumpTap struct {
+	conf     *config.RawDumpConfig
+	logger   logger.Logger
+	receiver sfu.TrackReceiver
+	id       string
+	codec    webrtc.RTPCodecCapability
+	// files are named <prefix>[_<layer>]_<time>.<ext> in dir
+	dir    string
+	prefix string
+
+	packets   chan *rawDumpPacket
+	closed    chan struct{}
+	closeOnce sync.Once
+	onClose   func()
+
+	// only accessed by the worker
+	files map[int32]*rawDumpFile
+	err   error
+}
+
+func newRawDumpTap(conf *config.RawDumpConfig, l logger.Logger, receiver sfu.TrackReceiver, trackID, dir, prefix string) *rawDumpTap {
+	t := &rawDumpTap{
+		conf:     conf,
+		logger:   l,
+		receiver: receiver,
+		id:       trackID,
+		codec:    receiver.Codec(),
+		dir:      dir,
+		prefix:   prefix,
+		packets:  make(chan *rawDumpPacket, rawDumpQueueSize),
+		closed:   make(chan struct{}),
+		files:    make(map[int32]*rawDumpFile),
+	}
+	go t.writeWorker()
+	return t
+}
+
+func (t *rawDumpTap) WriteRTP(p *buffer.ExtPacket, layer int32) error {
+	pkt := &rawDumpPacket{
+		layer:   layer,
+		raw:     make([]byte, len(p.RawPacket)),
+		arrival: time.Unix(0, p.Arrival),
+	}
+	copy(pkt.raw, p.RawPacket)
+
+	select {
+	case <-t.closed:
+	case t.packets <- pkt:
+	default:
+		// the disk can't keep up
+	}
+	return nil
+}
+
+func (t *rawDumpTap) Close() {
+	t.closeOnce.Do(func() {
+		close(t.closed)
+	})
+}
+
+func (t *rawDumpTap) ID() string { return t.id }
+
+func (t *rawDumpTap) PeerID() string { return rawDumpPeerID }
+
+func (t *rawDumpTap) Codec() webrtc.RTPCodecCapability { return t.codec }
+
+func (t *rawDumpTap) SetTrackType(_ bool) {}
+
+func (t *rawDumpTap) UptrackLayersChange(_ []uint16) {}
+
+func (t *rawDumpTap) writeWorker() {
+	defer func() {
+		for _, f := range t.files {
+			t.closeFile(f)
+		}
+		if t.onClose != nil {
+			t.onClose()
+		}
+	}()
+
+	for {
+		select {
+		case <-t.closed:
+			return
+		case pkt := <-t.packets:
+			t.write(pkt)
+		}
+	}
+}
+
+func (t *rawDumpTap) write(pkt *rawDumpPacket) {
+	if t.err != nil {
+		return
+	}
+
+	kind := t.fileKind()
+	layer := rawDumpAllLayers
+	if kind == rawDumpKindIVF {
+		layer = pkt.layer
+	}
+
+	f := t.files[layer]
+	if f != nil && (f.out.n >= t.conf.MaxFileSize || pkt.arrival.Sub(f.start) >= t.conf.MaxFileDuration) {
+		t.closeFile(f)
+		f = nil
+	}
+	if f == nil {
+		var err error
+		if f, err = t.openFile(kind, layer, pkt.arrival); err != nil {
+			// the dump is incomplete from here on, don't keep trying on every packet
+			t.err = err
+			t.logger.Errorw("could not write media to disk", err, "track", t.id)
+			return
+		}
+		t.files[layer] = f
+	}
+
+	if err := f.writer.writePacket(pkt); err != nil {
+		t.logger.Debugw("could not write packet to disk", "error", err, "track", t.id)
+	}
+}
+
+const (
+	rawDumpKindRTPDump = "rtpdump"
+	rawDumpKindOgg     = "ogg"
+	rawDumpKindIVF     = "ivf"
+)
+
+func (t *rawDumpTap) fileKind() string {
+	if t.conf.Format == config.RawDumpFormatMedia {
+		switch {
+		case strings.EqualFold(t.codec.MimeType, webrtc.MimeTypeOpus):
+			return rawDumpKindOgg
+		case strings.EqualFold(t.codec.MimeType, webrtc.MimeTypeVP8):
+			return rawDumpKindIVF
+		}
+	}
+	return rawDumpKindRTPDump
+}
+
+func (t *rawDumpTap) openFile(kind string, layer int32, start time.Time) (*rawDumpFile, error) {
+	name := t.prefix
+	if layer != rawDumpAllLayers {
+		name += fmt.Sprintf("_%d", layer)
+	}
+	name += "_" + start.UTC().Format("20060102T150405.000Z") + "." + kind
+
+	// the directory of the room is removed by the retention cleanup while it's empty
+	if err := os.MkdirAll(t.dir, 0o750); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filepath.Join(t.dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	f := &rawDumpFile{
+		out:   &countingWriter{file: file},
+		start: start,
+	}
+
+	switch kind {
+	case rawDumpKindOgg:
+		channels := t.codec.Channels
+		if channels == 0 {
+			channels = 2
+		}
+		var w *oggwriter.OggWriter
+		if w, err = oggwriter.NewWith(f.out, t.codec.ClockRate, channels); err == nil {
+			f.writer = &mediaDumpWriter{writer: w}
+		}
+	case rawDumpKindIVF:
+		var w *ivfwriter.IVFWriter
+		if w, err = ivfwriter.NewWith(f.out); err == nil {
+			f.writer = &mediaDumpWriter{writer: w}
+			// frames are written from the next keyframe
+			t.receiver.SendPLI(layer)
+		}
+	default:
+		var w *rtpdump.Writer
+		if w, err = rtpdump.NewWriter(f.out, rtpdump.Header{Start: start, Source: net.IPv4zero}); err == nil {
+			f.writer = &rtpDumpWriter{writer: w, out: f.out, start: start}
+		}
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (t *rawDumpTap) closeFile(f *rawDumpFile) {
+	if err := f.writer.Close(); err != nil {
+		t.logger.Warnw("could not close dump", err, "track", t.id, "file", f.out.file.Name())
+	}
+	for layer, open := range t.files {
+		if open == f {
+			delete(t.files, layer)
+		}
+	}
+}
+
+type rawDumpFile struct {
+	out    *countingWriter
+	writer rawDumpWriter
+	start  time.Time
+}
+
+type rawDumpWriter interface {
+	writePacket(pkt *rawDumpPacket) error
+	Close() error
+}
+
+// rtpDumpWriter writes packets as they're received, in the rtpdump format of rtptools
+type rtpDumpWriter struct {
+	writer *rtpdump.Writer
+	out    io.Closer
+	start  time.Time
+}
+
+func (w *rtpDumpWriter) writePacket(pkt *rawDumpPacket) error {
+	return w.writer.WritePacket(rtpdump.Packet{
+		Offset:  pkt.arrival.Sub(w.start),
+		Payload: pkt.raw,
+	})
+}
+
+func (w *rtpDumpWriter) Close() error {
+	return w.out.Close()
+}
+
+// mediaDumpWriter depacketizes packets into a media container
+type mediaDumpWriter struct {
+	writer interface {
+		WriteRTP(packet *rtp.Packet) error
+		Close() error
+	}
+}
+
+func (w *mediaDumpWriter) writePacket(pkt *rawDumpPacket) error {
+	packet := &rtp.Packet{}
+	if err := packet.Unmarshal(pkt.raw); err != nil {
+		return err
+	}
+	return w.writer.WriteRTP(packet)
+}
+
+func (w *mediaDumpWriter) Close() error {
+	return w.writer.Close()
+}
+
+// countingWriter counts the bytes written to a file. Seeking lets IVF headers be completed when
+// files are closed
+type countingWriter struct {
+	file *os.File
+	n    int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+func (w *countingWriter) Seek(offset int64, whence int) (int64, error) {
+	return w.file.Seek(offset, whence)
+}
+
+func (w *countingWriter) Close() error {
+	return w.file.Close()
+}
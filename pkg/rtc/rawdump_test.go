@@ -0,0 +1,99 @@
+package rtc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media/rtpdump"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+func TestRawDump(t *testing.T) {
+	receiver := &tapTrackReceiver{codec: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2}}
+	track := &typesfakes.FakePublishedTrack{}
+	track.IDReturns("TR_mic")
+	track.ReceiverReturns(receiver)
+	publisher := &typesfakes.FakeParticipant{}
+	publisher.IDReturns("PA_publisher")
+	publisher.GetPublishedTracksReturns([]types.PublishedTrack{track})
+
+	r := &Room{
+		Room:         &livekit.Room{Name: "room/1"},
+		Logger:       logger.Logger(logger.GetLogger()),
+		participants: map[string]types.Participant{"publisher": publisher},
+	}
+	conf := &config.RawDumpConfig{
+		Directory:       t.TempDir(),
+		Format:          config.RawDumpFormatRTPDump,
+		MaxFileSize:     1 << 20,
+		MaxFileDuration: time.Second,
+	}
+	require.NoError(t, r.StartRawDump(conf))
+	require.True(t, r.IsRawDumping())
+	// tracks that are already published are dumped
+	require.Len(t, receiver.downTracks, 1)
+	tap := receiver.downTracks[0]
+	require.Equal(t, rawDumpPeerID, tap.PeerID())
+
+	start := time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC)
+	for i, offset := range []time.Duration{0, 500 * time.Millisecond, 1500 * time.Millisecond} {
+		raw, err := (&rtp.Packet{
+			Header:  rtp.Header{Version: 2, SequenceNumber: uint16(i), SSRC: 1},
+			Payload: []byte{byte(i)},
+		}).Marshal()
+		require.NoError(t, err)
+		require.NoError(t, tap.WriteRTP(&buffer.ExtPacket{RawPacket: raw, Arrival: start.Add(offset).UnixNano()}, 0))
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// files are closed by the worker
+	closed := make(chan struct{})
+	onClose := tap.(*rawDumpTap).onClose
+	tap.(*rawDumpTap).onClose = func() {
+		onClose()
+		close(closed)
+	}
+	r.StopRawDump()
+	require.False(t, r.IsRawDumping())
+	require.Empty(t, receiver.downTracks)
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("dump was not closed")
+	}
+
+	// rotated after max_file_duration
+	dir := filepath.Join(conf.Directory, "room%2F1")
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "PA_publisher_TR_mic_20211101T120000.000Z.rtpdump", entries[0].Name())
+	require.Equal(t, "PA_publisher_TR_mic_20211101T120001.500Z.rtpdump", entries[1].Name())
+
+	file, err := os.Open(filepath.Join(dir, entries[0].Name()))
+	require.NoError(t, err)
+	defer file.Close()
+	reader, header, err := rtpdump.NewReader(file)
+	require.NoError(t, err)
+	require.True(t, header.Start.Equal(start))
+	var offsets []time.Duration
+	for {
+		pkt, err := reader.Next()
+		if err != nil {
+			break
+		}
+		offsets = append(offsets, pkt.Offset)
+	}
+	require.Equal(t, []time.Duration{0, 500 * time.Millisecond}, offsets)
+}
@@ -0,0 +1,69 @@
+package rtc
+
+import (
+	"strings"
+
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v3"
+)
+
+// sentTrackIDs returns the IDs of the tracks a client sends according to its offer, the track part
+// of the msid of each media section it sends in. ok is false when a section is sent without an
+// msid, the tracks it carries can't be told apart then
+func sentTrackIDs(offer webrtc.SessionDescription) (ids map[string]struct{}, ok bool, err error) {
+	parsed, err := offer.Unmarshal()
+	if err != nil {
+		return nil, false, err
+	}
+
+	ids = make(map[string]struct{})
+	for _, media := range parsed.MediaDescriptions {
+		if media.MediaName.Media == "application" || !isSendingSection(media) {
+			continue
+		}
+
+		found := false
+		for _, attr := range media.Attributes {
+			var msid string
+			switch attr.Key {
+			case sdp.AttrKeyMsid:
+				msid = attr.Value
+			case sdp.AttrKeySSRC:
+				// a=ssrc:<ssrc> msid:<stream> <track>, sent by older clients
+				parts := strings.SplitN(attr.Value, " ", 2)
+				if len(parts) < 2 || !strings.HasPrefix(parts[1], "msid:") {
+					continue
+				}
+				msid = strings.TrimPrefix(parts[1], "msid:")
+			default:
+				continue
+			}
+			if parts := strings.Fields(msid); len(parts) == 2 {
+				ids[parts[1]] = struct{}{}
+				found = true
+			}
+		}
+		if !found {
+			return nil, false, nil
+		}
+	}
+	return ids, true, nil
+}
+
+// isSendingSection returns true when the offerer sends media in the section, rejected sections
+// have their port set to 0
+func isSendingSection(media *sdp.MediaDescription) bool {
+	if _, bundleOnly := media.Attribute("bundle-only"); media.MediaName.Port.Value == 0 && !bundleOnly {
+		return false
+	}
+	for _, attr := range media.Attributes {
+		switch attr.Key {
+		case "recvonly", "inactive":
+			return false
+		case "sendrecv", "sendonly":
+			return true
+		}
+	}
+	// sendrecv by default
+	return true
+}
@@ -0,0 +1,52 @@
+package rtc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func offerWithSections(sections ...string) webrtc.SessionDescription {
+	sdp := "v=0\r\no=- 0 0 IN IP4 127.0.0.1\r\ns=-\r\nt=0 0\r\n" + strings.Join(sections, "")
+	return webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: sdp}
+}
+
+func TestSentTrackIDs(t *testing.T) {
+	audio := "m=audio 9 UDP/TLS/RTP/SAVPF 111\r\nc=IN IP4 0.0.0.0\r\na=mid:0\r\na=sendonly\r\na=msid:stream mic\r\na=rtpmap:111 opus/48000/2\r\n"
+	video := "m=video 9 UDP/TLS/RTP/SAVPF 96\r\nc=IN IP4 0.0.0.0\r\na=mid:1\r\na=sendonly\r\na=msid:stream webcam\r\na=rtpmap:96 VP8/90000\r\n"
+	data := "m=application 9 UDP/DTLS/SCTP webrtc-datachannel\r\nc=IN IP4 0.0.0.0\r\na=mid:2\r\na=sctp-port:5000\r\n"
+
+	t.Run("sent tracks", func(t *testing.T) {
+		ids, ok, err := sentTrackIDs(offerWithSections(audio, video, data))
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, map[string]struct{}{"mic": {}, "webcam": {}}, ids)
+	})
+
+	t.Run("removed tracks", func(t *testing.T) {
+		// removeTrack leaves the transceiver receiving only, without msid
+		removed := "m=video 9 UDP/TLS/RTP/SAVPF 96\r\nc=IN IP4 0.0.0.0\r\na=mid:1\r\na=recvonly\r\na=rtpmap:96 VP8/90000\r\n"
+		rejected := strings.Replace(audio, "m=audio 9", "m=audio 0", 1)
+		ids, ok, err := sentTrackIDs(offerWithSections(rejected, removed, data))
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Empty(t, ids)
+	})
+
+	t.Run("ssrc msid", func(t *testing.T) {
+		legacy := "m=video 9 UDP/TLS/RTP/SAVPF 96\r\nc=IN IP4 0.0.0.0\r\na=mid:1\r\na=sendrecv\r\na=rtpmap:96 VP8/90000\r\na=ssrc:1234 cname:abc\r\na=ssrc:1234 msid:stream webcam\r\n"
+		ids, ok, err := sentTrackIDs(offerWithSections(legacy))
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, map[string]struct{}{"webcam": {}}, ids)
+	})
+
+	t.Run("sections without msid", func(t *testing.T) {
+		noMsid := strings.Replace(video, "a=msid:stream webcam\r\n", "", 1)
+		_, ok, err := sentTrackIDs(offerWithSections(audio, noMsid))
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
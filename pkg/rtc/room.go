@@ -10,6 +10,7 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/livekit/protocol/logger"
 	livekit "github.com/livekit/protocol/proto"
+	"github.com/livekit/protocol/utils"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/livekit/livekit-server/pkg/config"
@@ -32,9 +33,14 @@ type Room struct {
 	Room   *livekit.Room
 	Logger logger.Logger
 
-	config      WebRTCConfig
-	audioConfig *config.AudioConfig
-	telemetry   telemetry.TelemetryService
+	config     WebRTCConfig
+	roomConfig *config.RoomConfig
+	telemetry  telemetry.TelemetryService
+	// the room's audio config, it can be updated while the room is open
+	audioLock   sync.RWMutex
+	audioConfig config.AudioConfig
+	// when participants were speaking, for their talk time and the dominant speaker
+	speakers *speakerHistory
 
 	// map of identity -> Participant
 	participants    map[string]types.Participant
@@ -47,26 +53,57 @@ type Room struct {
 	leftAt    atomic.Value
 	closed    chan struct{}
 	closeOnce sync.Once
-
-	onParticipantChanged func(p types.Participant)
-	onMetadataUpdate     func(metadata string)
-	onClose              func()
+	// locked rooms don't accept new participants
+	locked utils.AtomicFlag
+	// version of the metadata last set, updates of earlier versions are skipped
+	metadataLock    sync.Mutex
+	metadataVersion uint64
+	// transcribes published audio tracks, nil when transcription is disabled
+	transcription        TranscriptionProvider
+	transcriptionWebhook bool
+	// writes the media of published tracks to disk, nil when the room isn't dumped
+	rawDump     *config.RawDumpConfig
+	rawDumpDir  string
+	rawDumpTaps map[string]*rawDumpTap
+	// participants whose updates are waiting to be sent by ID, when updates are batched
+	updateLock         sync.Mutex
+	pendingUpdates     map[string]*pendingParticipantUpdate
+	pendingUpdateOrder []string
+	// participant sid -> state last sent about it, large rooms drop updates that don't change it
+	sentInfos map[string]*sentParticipantInfo
+	// participants waiting for their join to be approved, set by the room's manager
+	pendingJoinsLock    sync.Mutex
+	pendingJoins        []*PendingJoin
+	pendingJoinsVersion uint64
+	// identity -> participant ID -> version of the attributes last sent to that participant
+	attributesLock sync.Mutex
+	sentAttributes map[string]map[string]uint32
+	// subscriber identity -> identities of the publishers it may not receive tracks of
+	blocksLock sync.RWMutex
+	blocks     map[string]map[string]bool
+
+	onParticipantChanged        func(p types.Participant)
+	onParticipantTrackPublished func(p types.Participant, track types.PublishedTrack)
+	onMetadataUpdate            func(metadata string)
+	onClose                     func()
 }
 
 type ParticipantOptions struct {
 	AutoSubscribe bool
 }
 
-func NewRoom(room *livekit.Room, config WebRTCConfig, audioConfig *config.AudioConfig, telemetry telemetry.TelemetryService) *Room {
+func NewRoom(room *livekit.Room, config WebRTCConfig, roomConfig *config.RoomConfig, audioConfig *config.AudioConfig, telemetry telemetry.TelemetryService) *Room {
 	r := &Room{
 		Room:            proto.Clone(room).(*livekit.Room),
 		Logger:          logger.Logger(logger.GetLogger().WithValues("room", room.Name)),
 		config:          config,
-		audioConfig:     audioConfig,
+		roomConfig:      roomConfig,
+		audioConfig:     *audioConfig,
 		telemetry:       telemetry,
 		participants:    make(map[string]types.Participant),
 		participantOpts: make(map[string]*ParticipantOptions),
 		bufferFactory:   buffer.NewBufferFactory(config.Receiver.PacketBufferSize, logr.Logger{}),
+		speakers:        newSpeakerHistory(),
 		closed:          make(chan struct{}),
 	}
 	if r.Room.EmptyTimeout == 0 {
@@ -78,6 +115,9 @@ func NewRoom(room *livekit.Room, config WebRTCConfig, audioConfig *config.AudioC
 
 	go r.audioUpdateWorker()
 	go r.connectionQualityWorker()
+	if roomConfig != nil && roomConfig.ParticipantUpdateInterval > 0 {
+		go r.participantUpdateWorker()
+	}
 
 	return r
 }
@@ -98,10 +138,48 @@ func (r *Room) GetParticipants() []types.Participant {
 	return participants
 }
 
+// ParticipantSummaries returns the participants of the room, sorted by identity, with their
+// connection quality and the layers of each track they're subscribed to
+func (r *Room) ParticipantSummaries() []*types.ParticipantSummary {
+	participants := r.GetParticipants()
+	sort.Slice(participants, func(i, j int) bool {
+		return participants[i].Identity() < participants[j].Identity()
+	})
+
+	summaries := make([]*types.ParticipantSummary, 0, len(participants))
+	for _, p := range participants {
+		summary := &types.ParticipantSummary{Info: p.ToProto()}
+		if stats := p.GetStats(); stats != nil {
+			summary.ConnectionQuality = stats.ConnectionQuality
+			summary.Score = stats.Score
+			for _, st := range stats.SubscribedTracks {
+				summary.Subscriptions = append(summary.Subscriptions, &types.SubscriptionSummary{
+					TrackID:              st.TrackID,
+					PublisherIdentity:    st.PublisherIdentity,
+					Kind:                 st.Kind,
+					Muted:                st.Muted,
+					Score:                st.Score,
+					CurrentSpatialLayer:  st.CurrentSpatialLayer,
+					CurrentTemporalLayer: st.CurrentTemporalLayer,
+					MaxSpatialLayer:      st.MaxSpatialLayer,
+					ForwardingStatus:     st.ForwardingStatus,
+					QualityLabel:         st.QualityLabel,
+				})
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
 func (r *Room) GetActiveSpeakers() []*livekit.SpeakerInfo {
 	participants := r.GetParticipants()
 	speakers := make([]*livekit.SpeakerInfo, 0, len(participants))
 	for _, p := range participants {
+		// only publishers of a broadcast speak, don't go through its viewers
+		if r.isViewer(p) {
+			continue
+		}
 		level, active := p.GetAudioLevel()
 		if !active {
 			continue
@@ -119,6 +197,25 @@ func (r *Room) GetActiveSpeakers() []*livekit.SpeakerInfo {
 	return speakers
 }
 
+// AudioConfig returns how the audio levels of the room's participants are measured and sent
+func (r *Room) AudioConfig() config.AudioConfig {
+	r.audioLock.RLock()
+	defer r.audioLock.RUnlock()
+	return r.audioConfig
+}
+
+// SetAudioConfig changes the audio config of the room. The thresholds of audio levels apply to
+// the tracks that are already published
+func (r *Room) SetAudioConfig(conf config.AudioConfig) {
+	r.audioLock.Lock()
+	r.audioConfig = conf
+	r.audioLock.Unlock()
+
+	for _, p := range r.GetParticipants() {
+		p.SetAudioConfig(conf)
+	}
+}
+
 func (r *Room) GetBufferFactor() *buffer.Factory {
 	return r.bufferFactory
 }
@@ -153,7 +250,18 @@ func (r *Room) Join(participant types.Participant, opts *ParticipantOptions, ice
 		return ErrAlreadyJoined
 	}
 
-	if r.Room.MaxParticipants > 0 && int(r.Room.MaxParticipants) == len(r.participants) {
+	// hidden participants, such as recorders, can still join
+	if r.IsLocked() && !participant.Hidden() {
+		prometheus.ServiceOperationCounter.WithLabelValues("participant_join", "error", "locked").Add(1)
+		return ErrRoomLocked
+	}
+
+	if r.MaxDurationExceeded() {
+		prometheus.ServiceOperationCounter.WithLabelValues("participant_join", "error", "max_duration").Add(1)
+		return ErrRoomClosed
+	}
+
+	if r.Room.MaxParticipants > 0 && len(r.participants) >= int(r.Room.MaxParticipants) {
 		prometheus.ServiceOperationCounter.WithLabelValues("participant_join", "error", "max_exceeded").Add(1)
 		return ErrMaxParticipantsExceeded
 	}
@@ -190,6 +298,7 @@ func (r *Room) Join(participant types.Participant, opts *ParticipantOptions, ice
 	})
 	participant.OnTrackUpdated(r.onTrackUpdated)
 	participant.OnMetadataUpdate(r.onParticipantMetadataUpdate)
+	participant.OnAttributesUpdate(r.onParticipantAttributesUpdate)
 	participant.OnDataPacket(r.onDataPacket)
 	r.Logger.Infow("new participant joined",
 		"pID", participant.ID(),
@@ -204,10 +313,15 @@ func (r *Room) Join(participant types.Participant, opts *ParticipantOptions, ice
 	// gather other participants and send join response
 	otherParticipants := make([]*livekit.ParticipantInfo, 0, len(r.participants))
 	for _, p := range r.participants {
-		if p.ID() != participant.ID() && !p.Hidden() {
+		if p.ID() != participant.ID() && !p.Hidden() && !r.skipsUpdate(participant, p) {
 			otherParticipants = append(otherParticipants, p.ToProto())
 		}
 	}
+	roomInfo := r.Room
+	if r.isLarge() {
+		// clients fetch the rest of the participants a page at a time
+		roomInfo, otherParticipants = r.firstParticipantPage(otherParticipants)
+	}
 
 	if r.onParticipantChanged != nil {
 		r.onParticipantChanged(participant)
@@ -220,7 +334,7 @@ func (r *Room) Join(participant types.Participant, opts *ParticipantOptions, ice
 		}
 	})
 
-	if err := participant.SendJoinResponse(r.Room, otherParticipants, iceServers); err != nil {
+	if err := participant.SendJoinResponse(roomInfo, otherParticipants, iceServers); err != nil {
 		prometheus.ServiceOperationCounter.WithLabelValues("participant_join", "error", "send_response").Add(1)
 		return err
 	}
@@ -242,8 +356,8 @@ func (r *Room) ResumeParticipant(p types.Participant, responseSink routing.Messa
 	}
 	p.SetResponseSink(responseSink)
 
-	updates := ToProtoParticipants(r.GetParticipants())
-	if err := p.SendParticipantUpdate(updates, time.Now()); err != nil {
+	updates := ToProtoParticipants(r.participantsVisibleTo(p, r.GetParticipants()))
+	if err := r.sendParticipantUpdate(p, updates, time.Now()); err != nil {
 		return err
 	}
 
@@ -259,6 +373,7 @@ func (r *Room) RemoveParticipant(identity string) {
 	if ok {
 		delete(r.participants, identity)
 		delete(r.participantOpts, identity)
+		delete(r.sentInfos, p.ID())
 		if !p.Hidden() {
 			r.Room.NumParticipants--
 		}
@@ -276,6 +391,7 @@ func (r *Room) RemoveParticipant(identity string) {
 	p.OnTrackPublished(nil)
 	p.OnStateChange(nil)
 	p.OnMetadataUpdate(nil)
+	p.OnAttributesUpdate(nil)
 	p.OnDataPacket(nil)
 
 	// close participant as well
@@ -295,35 +411,90 @@ func (r *Room) RemoveParticipant(identity string) {
 	}
 }
 
+// GetPublishedTrack returns the track with the sid published by any participant, nil if there's none
+func (r *Room) GetPublishedTrack(sid string) types.PublishedTrack {
+	for _, p := range r.GetParticipants() {
+		if track := p.GetPublishedTrack(sid); track != nil {
+			return track
+		}
+	}
+	return nil
+}
+
+// UpdateSubscriptions subscribes the participant to the tracks, or unsubscribes it. Each track it
+// couldn't be subscribed to is reported to it, the first error is returned
 func (r *Room) UpdateSubscriptions(participant types.Participant, trackIds []string, subscribe bool) error {
 	if !participant.CanSubscribe() {
+		if subscribe {
+			for _, sid := range trackIds {
+				participant.SendSubscriptionError(sid, ErrCannotSubscribe)
+			}
+		}
 		return ErrCannotSubscribe
 	}
 
 	// find all matching tracks
 	var tracks []types.PublishedTrack
+	found := make(map[string]bool, len(trackIds))
+	// tracks of publishers the participant is blocked from
+	blocked := make(map[string]bool)
 	participants := r.GetParticipants()
 	for _, p := range participants {
+		isBlocked := r.isSubscriptionBlocked(participant, p)
 		for _, sid := range trackIds {
 			for _, track := range p.GetPublishedTracks() {
 				if sid == track.ID() {
 					tracks = append(tracks, track)
+					found[sid] = true
+					blocked[sid] = isBlocked
 				}
 			}
 		}
 	}
 
-	// handle subscription changes
+	if !subscribe {
+		for _, track := range tracks {
+			track.RemoveSubscriber(participant.ID())
+		}
+		return nil
+	}
+
+	var firstErr error
+	for _, sid := range trackIds {
+		if !found[sid] {
+			participant.SendSubscriptionError(sid, ErrTrackNotFound)
+		}
+	}
 	for _, track := range tracks {
-		if subscribe {
-			if err := track.AddSubscriber(participant); err != nil {
-				return err
+		if blocked[track.ID()] {
+			participant.SendSubscriptionError(track.ID(), ErrSubscriptionBlocked)
+			if firstErr == nil {
+				firstErr = ErrSubscriptionBlocked
+			}
+			continue
+		}
+		if err := track.AddSubscriber(participant); err != nil {
+			participant.SendSubscriptionError(track.ID(), err)
+			if firstErr == nil {
+				firstErr = err
 			}
-		} else {
-			track.RemoveSubscriber(participant.ID())
 		}
 	}
-	return nil
+	return firstErr
+}
+
+// Policy returns the limits on what participants of the room publish
+func (r *Room) Policy() config.RoomPolicy {
+	return r.roomConfig.Policy
+}
+
+// SetLocked locks the room, or unlocks it. Participants that already joined stay connected
+func (r *Room) SetLocked(locked bool) {
+	r.locked.TrySet(locked)
+}
+
+func (r *Room) IsLocked() bool {
+	return r.locked.Get()
 }
 
 func (r *Room) IsClosed() bool {
@@ -350,7 +521,8 @@ func (r *Room) CloseIfEmpty() {
 	}
 	r.lock.RUnlock()
 
-	if visibleParticipants > 0 {
+	// waiting participants would be dropped
+	if pending, _ := r.getPendingJoins(); visibleParticipants > 0 || len(pending) > 0 {
 		return
 	}
 
@@ -367,14 +539,25 @@ func (r *Room) CloseIfEmpty() {
 	}
 
 	if elapsed >= int64(timeout) {
+		prometheus.RoomClosed("empty")
 		r.Close()
 	}
 }
 
+// MaxDurationExceeded returns true once the room has lasted longer than the max duration of its policy
+func (r *Room) MaxDurationExceeded() bool {
+	maxDuration := r.roomConfig.Policy.MaxDuration
+	if maxDuration == 0 {
+		return false
+	}
+	return time.Now().Unix()-r.Room.CreationTime >= int64(maxDuration)
+}
+
 func (r *Room) Close() {
 	r.closeOnce.Do(func() {
 		close(r.closed)
 		r.Logger.Infow("closing room", "roomID", r.Room.Sid, "room", r.Room.Name)
+		r.reportSpeakerStats()
 		if r.onClose != nil {
 			r.onClose()
 		}
@@ -389,6 +572,18 @@ func (r *Room) OnParticipantChanged(f func(participant types.Participant)) {
 	r.onParticipantChanged = f
 }
 
+// OnTrackPublished is called when a participant publishes a new track
+func (r *Room) OnTrackPublished(f func(participant types.Participant, track types.PublishedTrack)) {
+	r.onParticipantTrackPublished = f
+}
+
+// EnableTranscription transcribes the audio tracks published to the room with provider. Set before
+// participants join. Final transcripts are sent to webhooks as well when webhook is set
+func (r *Room) EnableTranscription(provider TranscriptionProvider, webhook bool) {
+	r.transcription = provider
+	r.transcriptionWebhook = webhook
+}
+
 func (r *Room) SendDataPacket(up *livekit.UserPacket, kind livekit.DataPacket_Kind) {
 	dp := &livekit.DataPacket{
 		Kind: kind,
@@ -399,6 +594,49 @@ func (r *Room) SendDataPacket(up *livekit.UserPacket, kind livekit.DataPacket_Ki
 	r.onDataPacket(nil, dp)
 }
 
+// SetParticipantPermission updates p's permissions and informs participants of the change.
+// p is subscribed to existing tracks when it gains permission to subscribe
+func (r *Room) SetParticipantPermission(p types.Participant, permission *livekit.ParticipantPermission) {
+	couldSubscribe := p.CanSubscribe()
+	p.SetPermission(permission)
+	if !couldSubscribe && p.CanSubscribe() {
+		go r.subscribeToExistingTracks(p)
+	}
+
+	r.broadcastParticipantState(p, false)
+
+	if !p.Capabilities().DataPackets {
+		return
+	}
+	dp, err := newServerMessagePacket(&permissionUpdateMessage{
+		Type:           permissionUpdateMessageType,
+		CanSubscribe:   p.CanSubscribe(),
+		CanPublish:     p.CanPublish(),
+		CanPublishData: p.CanPublishData(),
+	})
+	if err == nil {
+		err = p.SendDataPacket(dp)
+	}
+	if err != nil {
+		r.Logger.Warnw("could not send permission update", err,
+			"participant", p.Identity())
+	}
+}
+
+// SetMetadataVersion sets the metadata like SetMetadata, unless the version isn't newer than the
+// last one set. It returns false when the update is skipped
+func (r *Room) SetMetadataVersion(metadata string, version uint64) bool {
+	r.metadataLock.Lock()
+	defer r.metadataLock.Unlock()
+
+	if version <= r.metadataVersion {
+		return false
+	}
+	r.metadataVersion = version
+	r.SetMetadata(metadata)
+	return true
+}
+
 func (r *Room) SetMetadata(metadata string) {
 	r.Room.Metadata = metadata
 
@@ -442,6 +680,16 @@ func (r *Room) onTrackPublished(participant types.Participant, track types.Publi
 	// publish participant update, since track state is changed
 	r.broadcastParticipantState(participant, true)
 
+	if r.onParticipantTrackPublished != nil {
+		r.onParticipantTrackPublished(participant, track)
+	}
+
+	if r.transcribes(participant, track) {
+		// connecting to the provider takes a while
+		go r.startTranscription(participant, track)
+	}
+	r.startRawDump(participant, track)
+
 	r.lock.RLock()
 	defer r.lock.RUnlock()
 
@@ -455,7 +703,8 @@ func (r *Room) onTrackPublished(participant types.Participant, track types.Publi
 			// not fully joined. don't subscribe yet
 			continue
 		}
-		if !r.autoSubscribe(existingParticipant) {
+		if !r.autoSubscribe(existingParticipant) || r.isSubscriptionBlocked(existingParticipant, participant) ||
+			!existingParticipant.CanSubscribeTo(participant.Identity()) {
 			continue
 		}
 
@@ -468,6 +717,7 @@ func (r *Room) onTrackPublished(participant types.Participant, track types.Publi
 				"participants", []string{participant.Identity(), existingParticipant.Identity()},
 				"pIDs", []string{participant.ID(), existingParticipant.ID()},
 				"track", track.ID())
+			existingParticipant.SendSubscriptionError(track.ID(), err)
 		}
 	}
 
@@ -496,7 +746,15 @@ func (r *Room) onDataPacket(source types.Participant, dp *livekit.DataPacket) {
 	if source != nil && !source.CanPublishData() {
 		return
 	}
-	dest := dp.GetUser().GetDestinationSids()
+
+	// when destinations are given, only those participants receive the packet
+	var dest map[string]bool
+	if sids := dp.GetUser().GetDestinationSids(); len(sids) > 0 {
+		dest = make(map[string]bool, len(sids))
+		for _, sid := range sids {
+			dest[sid] = true
+		}
+	}
 
 	for _, op := range r.GetParticipants() {
 		if op.State() != livekit.ParticipantInfo_ACTIVE {
@@ -505,18 +763,11 @@ func (r *Room) onDataPacket(source types.Participant, dp *livekit.DataPacket) {
 		if source != nil && op.ID() == source.ID() {
 			continue
 		}
-		if len(dest) > 0 {
-			found := false
-			for _, dSid := range dest {
-				if op.ID() == dSid {
-					found = true
-					break
-				}
-			}
-			if !found {
-				continue
-			}
+		if dest != nil && !dest[op.ID()] {
+			continue
 		}
+		// sent over the participant's data channel, or its signal connection when it has none or
+		// it failed
 		_ = op.SendDataPacket(dp)
 	}
 }
@@ -535,6 +786,9 @@ func (r *Room) subscribeToExistingTracks(p types.Participant) {
 			// don't send to itself
 			continue
 		}
+		if r.isSubscriptionBlocked(p, op) || !p.CanSubscribeTo(op.Identity()) {
+			continue
+		}
 		if n, err := op.AddSubscriber(p); err != nil {
 			// TODO: log error? or disconnect?
 			r.Logger.Errorw("could not subscribe to participant", err,
@@ -551,9 +805,15 @@ func (r *Room) subscribeToExistingTracks(p types.Participant) {
 
 // broadcast an update about participant p
 func (r *Room) broadcastParticipantState(p types.Participant, skipSource bool) {
+	if r.roomConfig != nil && r.roomConfig.ParticipantUpdateInterval > 0 {
+		r.queueParticipantUpdate(p, skipSource)
+		return
+	}
+
 	r.lock.Lock()
 	updatedAt := time.Now()
 	updates := ToProtoParticipants([]types.Participant{p})
+	unchanged := !p.Hidden() && r.unchangedUpdate(updates[0], skipSource)
 	r.lock.Unlock()
 	if p.Hidden() {
 		if !skipSource {
@@ -566,6 +826,9 @@ func (r *Room) broadcastParticipantState(p types.Participant, skipSource bool) {
 		}
 		return
 	}
+	if unchanged {
+		return
+	}
 
 	participants := r.GetParticipants()
 	for _, op := range participants {
@@ -573,6 +836,9 @@ func (r *Room) broadcastParticipantState(p types.Participant, skipSource bool) {
 		if (skipSource && p.ID() == op.ID()) || op.State() == livekit.ParticipantInfo_DISCONNECTED {
 			continue
 		}
+		if r.skipsUpdate(op, p) {
+			continue
+		}
 
 		err := op.SendParticipantUpdate(updates, updatedAt)
 		if err != nil {
@@ -594,7 +860,7 @@ func (r *Room) sendActiveSpeakers(speakers []*livekit.SpeakerInfo) {
 	}
 
 	for _, p := range r.GetParticipants() {
-		if p.ProtocolVersion().HandlesDataPackets() && !p.ProtocolVersion().SupportsSpeakerChanged() {
+		if p.Capabilities().DataPackets && !p.Capabilities().SpeakerChanged {
 			_ = p.SendDataPacket(dp)
 		}
 	}
@@ -603,7 +869,7 @@ func (r *Room) sendActiveSpeakers(speakers []*livekit.SpeakerInfo) {
 // for protocol 3, send only changed updates
 func (r *Room) sendSpeakerChanges(speakers []*livekit.SpeakerInfo) {
 	for _, p := range r.GetParticipants() {
-		if p.ProtocolVersion().SupportsSpeakerChanged() {
+		if p.Capabilities().SpeakerChanged {
 			_ = p.SendSpeakerUpdate(speakers)
 		}
 	}
@@ -613,12 +879,8 @@ func (r *Room) audioUpdateWorker() {
 	var smoothValues map[string]float32
 	var smoothFactor float32
 	var activeThreshold float32
-	if ss := r.audioConfig.SmoothIntervals; ss > 1 {
-		smoothValues = make(map[string]float32)
-		// exponential moving average (EMA), same center of mass with simple moving average (SMA)
-		smoothFactor = 2 / float32(ss+1)
-		activeThreshold = ConvertAudioLevel(r.audioConfig.ActiveLevel)
-	}
+	// audio config the smoothing was set up for
+	var smoothed *config.AudioConfig
 
 	lastActiveMap := make(map[string]*livekit.SpeakerInfo)
 	for {
@@ -626,6 +888,18 @@ func (r *Room) audioUpdateWorker() {
 			return
 		}
 
+		audioConfig := r.AudioConfig()
+		if smoothed == nil || audioConfig.SmoothIntervals != smoothed.SmoothIntervals || audioConfig.ActiveLevel != smoothed.ActiveLevel {
+			smoothValues = nil
+			if ss := audioConfig.SmoothIntervals; ss > 1 {
+				smoothValues = make(map[string]float32)
+				// exponential moving average (EMA), same center of mass with simple moving average (SMA)
+				smoothFactor = 2 / float32(ss+1)
+				activeThreshold = ConvertAudioLevel(audioConfig.ActiveLevel)
+			}
+			smoothed = &audioConfig
+		}
+
 		activeSpeakers := r.GetActiveSpeakers()
 		if smoothValues != nil {
 			for _, speaker := range activeSpeakers {
@@ -660,6 +934,8 @@ func (r *Room) audioUpdateWorker() {
 			})
 		}
 
+		r.observeSpeakers(activeSpeakers, time.Now())
+
 		const invAudioLevelQuantization = 1.0 / AudioLevelQuantization
 		for _, speaker := range activeSpeakers {
 			speaker.Level = float32(math.Ceil(float64(speaker.Level*AudioLevelQuantization)) * invAudioLevelQuantization)
@@ -691,29 +967,58 @@ func (r *Room) audioUpdateWorker() {
 
 		lastActiveMap = nextActiveMap
 
-		time.Sleep(time.Duration(r.audioConfig.UpdateInterval) * time.Millisecond)
+		time.Sleep(time.Duration(audioConfig.UpdateInterval) * time.Millisecond)
 	}
 }
 
 func (r *Room) connectionQualityWorker() {
+	// identity -> track ID -> quality label last sent to that participant
+	var sentLabels map[string]map[string]string
+	// identity -> track ID -> preview last sent to that participant
+	var sentPreviews map[string]map[string]*trackPreview
+	// track ID -> layer targets last sent to its publisher
+	var sentTargets map[string][]uint64
+	// track ID -> media received on the track
+	var activity map[string]*trackActivity
+	// identity -> track ID -> publisher ID of the stalled tracks sent to that participant
+	var sentStalls map[string]map[string]string
+	// identity -> version of the pending joins last sent to that participant
+	var sentPendingJoins map[string]uint64
 	// send updates to only users that are subscribed to each other
 	for {
 		if r.IsClosed() {
+			// end the stalls still going on
+			r.detectStalledTracks(nil, activity, time.Now())
 			return
 		}
 
 		participants := r.GetParticipants()
+		if r.roomConfig != nil && r.roomConfig.TrackQualityLabels {
+			sentLabels = r.sendTrackQualityLabels(participants, sentLabels)
+		}
+		r.sendParticipantAttributes(participants)
+		sentPreviews = r.sendTrackPreviews(participants, sentPreviews)
+		sentPendingJoins = r.sendPendingJoins(participants, sentPendingJoins)
+		if r.roomConfig != nil && r.roomConfig.LayerBitrateTargets {
+			sentTargets = r.sendLayerTargets(participants, sentTargets)
+		}
+		if r.roomConfig != nil && (r.roomConfig.TrackInactivity(true).StalledTimeout > 0 || r.roomConfig.TrackInactivity(false).StalledTimeout > 0) {
+			activity = r.detectStalledTracks(participants, activity, time.Now())
+			sentStalls = r.sendTrackStalls(participants, activity, sentStalls)
+		}
 		connectionInfos := make(map[string]*livekit.ConnectionQualityInfo, len(participants))
+		trackQualities := make(map[string][]*trackConnectionQuality, len(participants))
 
 		for _, p := range participants {
 			connectionInfos[p.Identity()] = &livekit.ConnectionQualityInfo{
 				ParticipantSid: p.ID(),
 				Quality:        p.GetConnectionQuality(),
 			}
+			trackQualities[p.Identity()] = publishedTracksConnectionQuality(p)
 		}
 
 		for _, op := range participants {
-			if !op.ProtocolVersion().SupportsConnectionQuality() {
+			if !op.Capabilities().ConnectionQuality {
 				continue
 			}
 			update := &livekit.ConnectionQualityUpdate{}
@@ -722,23 +1027,137 @@ func (r *Room) connectionQualityWorker() {
 			if info, ok := connectionInfos[op.Identity()]; ok {
 				update.Updates = append(update.Updates, info)
 			}
+			tracks := trackQualities[op.Identity()]
 
 			// send to other participants its subscribed to
 			for _, identity := range op.GetSubscribedParticipants() {
 				if info, ok := connectionInfos[identity]; ok {
 					update.Updates = append(update.Updates, info)
+					tracks = append(tracks, trackQualities[identity]...)
 				}
 			}
 			if err := op.SendConnectionQualityUpdate(update); err != nil {
 				r.Logger.Warnw("could not send connection quality update", err,
 					"participant", op.Identity())
 			}
+			r.sendTrackConnectionQuality(op, tracks)
 		}
 
 		time.Sleep(time.Second * 5)
 	}
 }
 
+// sendTrackConnectionQuality sends op the quality of the published tracks its connection quality
+// update covered
+func (r *Room) sendTrackConnectionQuality(op types.Participant, tracks []*trackConnectionQuality) {
+	if len(tracks) == 0 || !op.Capabilities().DataPackets {
+		return
+	}
+	dp, err := newTrackConnectionQualityPacket(tracks)
+	if err == nil {
+		err = op.SendDataPacket(dp)
+	}
+	if err != nil {
+		r.Logger.Warnw("could not send track connection quality", err, "participant", op.Identity())
+	}
+}
+
+// sendTrackQualityLabels sends each participant the quality labels of its subscribed video tracks
+// that changed since they were last sent, returning the labels sent so far
+func (r *Room) sendTrackQualityLabels(participants []types.Participant, lastSent map[string]map[string]string) map[string]map[string]string {
+	sent := make(map[string]map[string]string, len(participants))
+	for _, op := range participants {
+		if !op.Capabilities().DataPackets {
+			continue
+		}
+
+		prev := lastSent[op.Identity()]
+		labels := make(map[string]string)
+		var changed []*trackQualityLabel
+		for _, st := range op.GetSubscribedTracks() {
+			label := st.QualityLabel()
+			if label == "" {
+				continue
+			}
+			labels[st.ID()] = label
+			if prev[st.ID()] == label {
+				continue
+			}
+			publisher := r.GetParticipant(st.PublisherIdentity())
+			if publisher == nil {
+				continue
+			}
+			changed = append(changed, &trackQualityLabel{
+				ParticipantSid: publisher.ID(),
+				TrackSid:       st.ID(),
+				Quality:        label,
+			})
+		}
+		sent[op.Identity()] = labels
+
+		if len(changed) == 0 {
+			continue
+		}
+		dp, err := newTrackQualityPacket(changed)
+		if err == nil {
+			err = op.SendDataPacket(dp)
+		}
+		if err != nil {
+			r.Logger.Warnw("could not send track quality labels", err,
+				"participant", op.Identity())
+			// try again on the next update
+			sent[op.Identity()] = prev
+		}
+	}
+	return sent
+}
+
+// sendTrackPreviews sends each participant the previews of tracks published by others that changed
+// since they were last sent, returning the previews sent so far
+func (r *Room) sendTrackPreviews(participants []types.Participant, lastSent map[string]map[string]*trackPreview) map[string]map[string]*trackPreview {
+	var previews []*trackPreview
+	for _, p := range participants {
+		for _, track := range p.GetPublishedTracks() {
+			previews = append(previews, publishedTrackPreview(p, track))
+		}
+	}
+
+	sent := make(map[string]map[string]*trackPreview, len(participants))
+	for _, op := range participants {
+		if !op.Capabilities().DataPackets || op.State() != livekit.ParticipantInfo_ACTIVE {
+			continue
+		}
+
+		prev := lastSent[op.Identity()]
+		next := make(map[string]*trackPreview, len(previews))
+		var changed []*trackPreview
+		for _, preview := range previews {
+			if preview.ParticipantSid == op.ID() {
+				continue
+			}
+			if last := prev[preview.TrackSid]; !preview.changed(last) {
+				next[preview.TrackSid] = last
+				continue
+			}
+			changed = append(changed, preview)
+			next[preview.TrackSid] = preview
+		}
+		if len(changed) != 0 {
+			dp, err := newTrackPreviewsPacket(changed)
+			if err == nil {
+				err = op.SendDataPacket(dp)
+			}
+			if err != nil {
+				// try again on the next update
+				r.Logger.Warnw("could not send track previews", err, "participant", op.Identity())
+				next = prev
+			}
+		}
+		sent[op.Identity()] = next
+	}
+	return sent
+}
+
 func (r *Room) DebugInfo() map[string]interface{} {
 	info := map[string]interface{}{
 		"Name":      r.Room.Name,
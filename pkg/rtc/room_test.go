@@ -1,6 +1,7 @@
 package rtc_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/rtc"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/telemetry"
 	"github.com/livekit/livekit-server/pkg/testutils"
 )
@@ -99,6 +101,36 @@ func TestRoomJoin(t *testing.T) {
 		}
 	})
 
+	t.Run("participants without auto subscribe are only subscribed on request", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		p := newMockParticipant("new", types.DefaultProtocol, false)
+		require.NoError(t, rm.Join(p, &rtc.ParticipantOptions{AutoSubscribe: false}, iceServersForRoom))
+
+		subscribed := make(map[*typesfakes.FakeParticipant]int)
+		for _, op := range rm.GetParticipants() {
+			if op != p {
+				subscribed[op.(*typesfakes.FakeParticipant)] = op.(*typesfakes.FakeParticipant).AddSubscriberCallCount()
+			}
+		}
+		p.StateReturns(livekit.ParticipantInfo_ACTIVE)
+		p.OnStateChangeArgsForCall(0)(p, livekit.ParticipantInfo_JOINED)
+		for op, n := range subscribed {
+			require.Equal(t, n, op.AddSubscriberCallCount())
+		}
+
+		// nor to tracks published later
+		pub := rm.GetParticipant("p0").(*typesfakes.FakeParticipant)
+		track := newMockTrack(livekit.TrackType_VIDEO, "webcam")
+		pub.GetPublishedTracksReturns([]types.PublishedTrack{track})
+		pub.OnTrackPublishedArgsForCall(0)(pub, track)
+		for i := 0; i < track.AddSubscriberCallCount(); i++ {
+			require.NotEqual(t, p, track.AddSubscriberArgsForCall(i))
+		}
+
+		require.NoError(t, rm.UpdateSubscriptions(p, []string{track.ID()}, true))
+		require.Equal(t, p, track.AddSubscriberArgsForCall(track.AddSubscriberCallCount()-1))
+	})
+
 	t.Run("participant state change is broadcasted to others", func(t *testing.T) {
 		rm := newRoomWithParticipants(t, testRoomOpts{num: numParticipants})
 		var changedParticipant types.Participant
@@ -136,6 +168,21 @@ func TestRoomJoin(t *testing.T) {
 		err := rm.Join(p, nil, iceServersForRoom)
 		require.Equal(t, rtc.ErrMaxParticipantsExceeded, err)
 	})
+
+	t.Run("cannot join locked room", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		rm.SetLocked(true)
+		p := newMockParticipant("second", types.ProtocolVersion(0), false)
+		require.Equal(t, rtc.ErrRoomLocked, rm.Join(p, nil, iceServersForRoom))
+
+		// recorders join hidden
+		hidden := newMockParticipant("recorder", types.ProtocolVersion(0), false)
+		hidden.HiddenReturns(true)
+		require.NoError(t, rm.Join(hidden, nil, iceServersForRoom))
+
+		rm.SetLocked(false)
+		require.NoError(t, rm.Join(p, nil, iceServersForRoom))
+	})
 }
 
 // various state changes to participant and that others are receiving update
@@ -196,6 +243,79 @@ func TestParticipantUpdate(t *testing.T) {
 	}
 }
 
+func TestBatchedParticipantUpdates(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 4, numHidden: 1, updateInterval: 50 * time.Millisecond})
+	defer rm.Close()
+	participants := rm.GetParticipants()
+	var hidden *typesfakes.FakeParticipant
+	var changed []*typesfakes.FakeParticipant
+	for _, p := range participants {
+		if p.Hidden() {
+			hidden = p.(*typesfakes.FakeParticipant)
+		} else if len(changed) < 2 {
+			changed = append(changed, p.(*typesfakes.FakeParticipant))
+		}
+	}
+
+	// a burst of changes
+	for i := 0; i < 5; i++ {
+		for _, p := range changed {
+			p.SetMetadata(fmt.Sprintf("metadata %d", i))
+		}
+	}
+	hidden.SetMetadata("hidden")
+
+	// coalesced into one update for each participant
+	testutils.WithTimeout(t, "participants should receive batched updates", func() bool {
+		for _, p := range participants {
+			if p.(*typesfakes.FakeParticipant).SendParticipantUpdateCallCount() == 0 {
+				return false
+			}
+		}
+		return true
+	})
+	time.Sleep(100 * time.Millisecond)
+	for _, p := range participants {
+		fp := p.(*typesfakes.FakeParticipant)
+		require.Equal(t, 1, fp.SendParticipantUpdateCallCount())
+		updates, _ := fp.SendParticipantUpdateArgsForCall(0)
+		if p == hidden {
+			// hidden participants are only sent their own updates
+			require.Len(t, updates, 3)
+		} else {
+			require.Len(t, updates, 2)
+		}
+	}
+}
+
+func TestSetParticipantPermission(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 2, protocol: types.DefaultProtocol})
+	participants := rm.GetParticipants()
+	p := participants[0].(*typesfakes.FakeParticipant)
+	other := participants[1].(*typesfakes.FakeParticipant)
+	p.CanSubscribeReturns(false)
+	p.SetPermissionStub = func(permission *livekit.ParticipantPermission) {
+		p.CanSubscribeReturns(permission.CanSubscribe)
+	}
+	subscribeCount := other.AddSubscriberCallCount()
+	updateCount := other.SendParticipantUpdateCallCount()
+
+	rm.SetParticipantPermission(p, &livekit.ParticipantPermission{CanSubscribe: true})
+
+	// gaining subscribe permission subscribes to existing tracks
+	testutils.WithTimeout(t, "participant should subscribe to existing tracks", func() bool {
+		return other.AddSubscriberCallCount() > subscribeCount
+	})
+	require.Equal(t, updateCount+1, other.SendParticipantUpdateCallCount())
+
+	// participant is told about its new permissions
+	require.Equal(t, 1, p.SendDataPacketCallCount())
+	var msg map[string]interface{}
+	require.NoError(t, json.Unmarshal(p.SendDataPacketArgsForCall(0).GetUser().Payload, &msg))
+	require.Equal(t, "permission_update", msg["type"])
+	require.Equal(t, true, msg["can_subscribe"])
+}
+
 func TestRoomClosure(t *testing.T) {
 	t.Run("room closes after participant leaves", func(t *testing.T) {
 		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
@@ -240,6 +360,16 @@ func TestRoomClosure(t *testing.T) {
 		rm.CloseIfEmpty()
 		require.True(t, isClosed)
 	})
+
+	t.Run("cannot join after max duration", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1, maxDuration: 60})
+		require.False(t, rm.MaxDurationExceeded())
+
+		rm.Room.CreationTime -= 60
+		require.True(t, rm.MaxDurationExceeded())
+		p := newMockParticipant("second", types.ProtocolVersion(0), false)
+		require.Equal(t, rtc.ErrRoomClosed, rm.Join(p, nil, iceServersForRoom))
+	})
 }
 
 func TestNewTrack(t *testing.T) {
@@ -264,6 +394,60 @@ func TestNewTrack(t *testing.T) {
 	})
 }
 
+func TestUpdateSubscriptions(t *testing.T) {
+	setup := func() (*rtc.Room, *typesfakes.FakeParticipant, *typesfakes.FakePublishedTrack, *typesfakes.FakePublishedTrack) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		participants := rm.GetParticipants()
+		sub := participants[0].(*typesfakes.FakeParticipant)
+		pub := participants[1].(*typesfakes.FakeParticipant)
+		audio := newMockTrack(livekit.TrackType_AUDIO, "mic")
+		video := newMockTrack(livekit.TrackType_VIDEO, "webcam")
+		pub.GetPublishedTracksReturns([]types.PublishedTrack{audio, video})
+		return rm, sub, audio, video
+	}
+
+	t.Run("missing tracks and failed subscriptions are reported", func(t *testing.T) {
+		rm, sub, audio, video := setup()
+		video.AddSubscriberReturns(rtc.ErrSubscriptionLimit)
+
+		err := rm.UpdateSubscriptions(sub, []string{audio.ID(), "TR_missing", video.ID()}, true)
+		require.Equal(t, rtc.ErrSubscriptionLimit, err)
+		require.Equal(t, 1, audio.AddSubscriberCallCount())
+		require.Equal(t, 1, video.AddSubscriberCallCount())
+
+		require.Equal(t, 2, sub.SendSubscriptionErrorCallCount())
+		sid, err := sub.SendSubscriptionErrorArgsForCall(0)
+		require.Equal(t, "TR_missing", sid)
+		require.Equal(t, rtc.ErrTrackNotFound, err)
+		sid, err = sub.SendSubscriptionErrorArgsForCall(1)
+		require.Equal(t, video.ID(), sid)
+		require.Equal(t, rtc.ErrSubscriptionLimit, err)
+	})
+
+	t.Run("subscribing without permission is reported", func(t *testing.T) {
+		rm, sub, audio, video := setup()
+		sub.CanSubscribeReturns(false)
+
+		err := rm.UpdateSubscriptions(sub, []string{audio.ID(), video.ID()}, true)
+		require.Equal(t, rtc.ErrCannotSubscribe, err)
+		require.Zero(t, audio.AddSubscriberCallCount())
+		require.Equal(t, 2, sub.SendSubscriptionErrorCallCount())
+		for i, track := range []types.PublishedTrack{audio, video} {
+			sid, err := sub.SendSubscriptionErrorArgsForCall(i)
+			require.Equal(t, track.ID(), sid)
+			require.Equal(t, rtc.ErrCannotSubscribe, err)
+		}
+	})
+
+	t.Run("unsubscribing isn't reported", func(t *testing.T) {
+		rm, sub, audio, _ := setup()
+
+		require.NoError(t, rm.UpdateSubscriptions(sub, []string{audio.ID(), "TR_missing"}, false))
+		require.Equal(t, 1, audio.RemoveSubscriberCallCount())
+		require.Zero(t, sub.SendSubscriptionErrorCallCount())
+	})
+}
+
 func TestActiveSpeakers(t *testing.T) {
 	t.Parallel()
 	getActiveSpeakerUpdates := func(p *typesfakes.FakeParticipant) []*livekit.ActiveSpeakerUpdate {
@@ -535,6 +719,93 @@ func TestRoomUpdate(t *testing.T) {
 			require.Equal(t, 1, fp.SendRoomUpdateCallCount())
 		}
 	})
+
+	t.Run("metadata versions are applied in order", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		defer rm.Close()
+		fp := rm.GetParticipants()[0].(*typesfakes.FakeParticipant)
+
+		require.True(t, rm.SetMetadataVersion("second", 2))
+		require.False(t, rm.SetMetadataVersion("first", 1))
+		require.False(t, rm.SetMetadataVersion("second again", 2))
+		require.Equal(t, "second", rm.Room.Metadata)
+		require.Equal(t, 1, fp.SendRoomUpdateCallCount())
+
+		require.True(t, rm.SetMetadataVersion("third", 3))
+		require.Equal(t, "third", rm.Room.Metadata)
+		require.Equal(t, 2, fp.SendRoomUpdateCallCount())
+	})
+}
+
+func TestRoomAudioConfig(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+	defer rm.Close()
+
+	conf := rm.AudioConfig()
+	conf.ActiveLevel = 40
+	conf.MinPercentile = 20
+	rm.SetAudioConfig(conf)
+	require.Equal(t, conf, rm.AudioConfig())
+	for _, op := range rm.GetParticipants() {
+		fp := op.(*typesfakes.FakeParticipant)
+		require.Equal(t, 1, fp.SetAudioConfigCallCount())
+		require.Equal(t, conf, fp.SetAudioConfigArgsForCall(0))
+	}
+}
+
+func TestParticipantSummaries(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+	defer rm.Close()
+
+	var viewer, publisher *typesfakes.FakeParticipant
+	for _, p := range rm.GetParticipants() {
+		fp := p.(*typesfakes.FakeParticipant)
+		fp.ToProtoReturns(&livekit.ParticipantInfo{Identity: p.Identity()})
+		if p.Identity() == "p0" {
+			viewer = fp
+		} else {
+			publisher = fp
+		}
+	}
+	viewer.GetStatsReturns(&types.ParticipantStats{
+		ConnectionQuality: livekit.ConnectionQuality_GOOD.String(),
+		Score:             3.8,
+		SubscribedTracks: []*types.SubscribedTrackStats{{
+			TrackID:           "TR_camera",
+			PublisherIdentity: "p1",
+			Kind:              "video",
+			Score:             3.6,
+			QualityLabel:      "SD",
+			DownTrackStats: sfu.DownTrackStats{
+				CurrentSpatialLayer:  1,
+				CurrentTemporalLayer: 2,
+				MaxSpatialLayer:      2,
+				ForwardingStatus:     sfu.ForwardingStatusPartial.String(),
+			},
+		}},
+	})
+
+	summaries := rm.ParticipantSummaries()
+	require.Len(t, summaries, 2)
+	require.Equal(t, &types.ParticipantSummary{
+		Info:              &livekit.ParticipantInfo{Identity: "p0"},
+		ConnectionQuality: livekit.ConnectionQuality_GOOD.String(),
+		Score:             3.8,
+		Subscriptions: []*types.SubscriptionSummary{{
+			TrackID:              "TR_camera",
+			PublisherIdentity:    "p1",
+			Kind:                 "video",
+			Score:                3.6,
+			CurrentSpatialLayer:  1,
+			CurrentTemporalLayer: 2,
+			MaxSpatialLayer:      2,
+			ForwardingStatus:     sfu.ForwardingStatusPartial.String(),
+			QualityLabel:         "SD",
+		}},
+	}, summaries[0])
+	// no stats available
+	require.Equal(t, publisher.ToProto(), summaries[1].Info)
+	require.Empty(t, summaries[1].Subscriptions)
 }
 
 type testRoomOpts struct {
@@ -542,17 +813,26 @@ type testRoomOpts struct {
 	numHidden            int
 	protocol             types.ProtocolVersion
 	audioSmoothIntervals uint32
+	maxDuration          uint32
+	updateInterval       time.Duration
+	broadcast            *config.BroadcastPolicy
+	largeRoom            config.LargeRoomConfig
 }
 
 func newRoomWithParticipants(t *testing.T, opts testRoomOpts) *rtc.Room {
 	rm := rtc.NewRoom(
 		&livekit.Room{Name: "room"},
 		rtc.WebRTCConfig{},
+		&config.RoomConfig{
+			ParticipantUpdateInterval: opts.updateInterval,
+			LargeRoom:                 opts.largeRoom,
+			Policy:                    config.RoomPolicy{MaxDuration: opts.maxDuration, Broadcast: opts.broadcast},
+		},
 		&config.AudioConfig{
 			UpdateInterval:  audioUpdateInterval,
 			SmoothIntervals: opts.audioSmoothIntervals,
 		},
-		telemetry.NewTelemetryService(nil, nil),
+		telemetry.NewTelemetryService(nil, nil, nil, nil),
 	)
 	for i := 0; i < opts.num+opts.numHidden; i++ {
 		identity := fmt.Sprintf("p%d", i)
@@ -0,0 +1,146 @@
+package rtc
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+// rtxPairingFactory pairs the RTX streams of a publisher with the streams they repair, so that
+// retransmissions reach the buffers of those streams.
+//
+// Repair streams declared in the SDP with a FID group are paired when the offer is handled.
+// Simulcast layers aren't declared there, their repair streams carry the RID of the layer in the
+// repaired-rtp-stream-id header extension instead, and are paired by this interceptor
+type rtxPairingFactory struct {
+	bufferFactory *buffer.Factory
+
+	lock sync.RWMutex
+	// mid + rid => SSRC of the layer
+	primaries map[string]uint32
+}
+
+func newRTXPairingFactory(bufferFactory *buffer.Factory) *rtxPairingFactory {
+	return &rtxPairingFactory{
+		bufferFactory: bufferFactory,
+		primaries:     make(map[string]uint32),
+	}
+}
+
+// addPrimary records the SSRC of the stream of the transceiver mid and layer rid
+func (f *rtxPairingFactory) addPrimary(mid, rid string, ssrc uint32) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.primaries[mid+"/"+rid] = ssrc
+}
+
+// addFIDGroups pairs the repair streams declared in desc
+func (f *rtxPairingFactory) addFIDGroups(desc webrtc.SessionDescription) error {
+	pairs, err := parseFIDGroups(desc)
+	if err != nil {
+		return err
+	}
+	for rtxSSRC, primarySSRC := range pairs {
+		f.bufferFactory.SetRTXPair(rtxSSRC, primarySSRC)
+	}
+	return nil
+}
+
+func (f *rtxPairingFactory) getPrimary(mid, rid string) (uint32, bool) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	ssrc, ok := f.primaries[mid+"/"+rid]
+	return ssrc, ok
+}
+
+func (f *rtxPairingFactory) NewInterceptor(_ string) (interceptor.Interceptor, error) {
+	return &rtxPairingInterceptor{f: f}, nil
+}
+
+type rtxPairingInterceptor struct {
+	interceptor.NoOp
+
+	f *rtxPairingFactory
+}
+
+func (i *rtxPairingInterceptor) BindRemoteStream(info *interceptor.StreamInfo, reader interceptor.RTPReader) interceptor.RTPReader {
+	if !strings.EqualFold(info.MimeType, mimeTypeRTX) {
+		return reader
+	}
+
+	var midID, rridID uint8
+	for _, ext := range info.RTPHeaderExtensions {
+		switch ext.URI {
+		case sdp.SDESMidURI:
+			midID = uint8(ext.ID)
+		case repairedRTPStreamID:
+			rridID = uint8(ext.ID)
+		}
+	}
+	if midID == 0 || rridID == 0 {
+		return reader
+	}
+
+	// packets are read by a single goroutine
+	paired := false
+	return interceptor.RTPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+		n, a, err := reader.Read(b, a)
+		if err != nil || paired {
+			return n, a, err
+		}
+
+		var header rtp.Header
+		if _, err := header.Unmarshal(b[:n]); err != nil {
+			return n, a, nil
+		}
+		mid, rid := header.GetExtension(midID), header.GetExtension(rridID)
+		if len(mid) == 0 || len(rid) == 0 {
+			return n, a, nil
+		}
+		if primarySSRC, ok := i.f.getPrimary(string(mid), string(rid)); ok {
+			i.f.bufferFactory.SetRTXPair(info.SSRC, primarySSRC)
+			paired = true
+		}
+		return n, a, nil
+	})
+}
+
+// parseFIDGroups returns the repair streams declared in desc, RTX SSRC => SSRC of the stream it
+// repairs
+func parseFIDGroups(desc webrtc.SessionDescription) (map[uint32]uint32, error) {
+	parsed, err := desc.Unmarshal()
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := make(map[uint32]uint32)
+	for _, media := range parsed.MediaDescriptions {
+		for _, attr := range media.Attributes {
+			if attr.Key != sdp.AttrKeySSRCGroup {
+				continue
+			}
+			// a=ssrc-group:FID <primary> <rtx>
+			parts := strings.Fields(attr.Value)
+			if len(parts) != 3 || parts[0] != "FID" {
+				continue
+			}
+			primarySSRC, err := strconv.ParseUint(parts[1], 10, 32)
+			if err != nil {
+				continue
+			}
+			rtxSSRC, err := strconv.ParseUint(parts[2], 10, 32)
+			if err != nil {
+				continue
+			}
+			pairs[uint32(rtxSSRC)] = uint32(primarySSRC)
+		}
+	}
+	return pairs, nil
+}
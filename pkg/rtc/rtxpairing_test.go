@@ -0,0 +1,91 @@
+package rtc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
+	"github.com/pion/transport/packetio"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+func TestParseFIDGroups(t *testing.T) {
+	offer := strings.Join([]string{
+		"v=0",
+		"o=- 0 0 IN IP4 127.0.0.1",
+		"s=-",
+		"t=0 0",
+		"m=video 9 UDP/TLS/RTP/SAVPF 96 97",
+		"a=mid:0",
+		"a=rtpmap:96 VP8/90000",
+		"a=rtpmap:97 rtx/90000",
+		"a=fmtp:97 apt=96",
+		"a=ssrc-group:FID 1111 2222",
+		"a=ssrc:1111 cname:camera",
+		"a=ssrc:2222 cname:camera",
+		"m=video 9 UDP/TLS/RTP/SAVPF 96",
+		"a=mid:1",
+		"a=rtpmap:96 VP8/90000",
+		"a=ssrc-group:SIM 3333 4444",
+		"a=ssrc-group:FID 5555",
+		"",
+	}, "\r\n")
+
+	pairs, err := parseFIDGroups(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: offer})
+	require.NoError(t, err)
+	require.Equal(t, map[uint32]uint32{2222: 1111}, pairs)
+}
+
+func TestRTXPairingInterceptor(t *testing.T) {
+	bufferFactory := buffer.NewBufferFactory(500, buffer.Logger)
+	primary := bufferFactory.GetOrNew(packetio.RTPBufferPacket, 1111).(*buffer.Buffer)
+	rtxBuff := bufferFactory.GetOrNew(packetio.RTPBufferPacket, 2222).(*buffer.Buffer)
+	codec := webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000}
+	primary.Bind(webrtc.RTPParameters{}, codec, buffer.Options{})
+	for _, sn := range []uint16{1, 3} {
+		raw, err := (&rtp.Packet{
+			Header:  rtp.Header{Version: 2, SSRC: 1111, PayloadType: 96, SequenceNumber: sn},
+			Payload: []byte{1, 2, 3},
+		}).Marshal()
+		require.NoError(t, err)
+		_, err = primary.Write(raw)
+		require.NoError(t, err)
+	}
+
+	f := newRTXPairingFactory(bufferFactory)
+	f.addPrimary("1", "h", 3333)
+	f.addPrimary("1", "f", 1111)
+	i, err := f.NewInterceptor("")
+	require.NoError(t, err)
+
+	rtx := &rtp.Packet{
+		Header:  rtp.Header{Version: 2, SSRC: 2222, PayloadType: 97, SequenceNumber: 1},
+		Payload: []byte{0, 2, 1, 2, 3},
+	}
+	require.NoError(t, rtx.SetExtension(1, []byte("1")))
+	require.NoError(t, rtx.SetExtension(2, []byte("f")))
+	raw, err := rtx.Marshal()
+	require.NoError(t, err)
+	reader := i.BindRemoteStream(&interceptor.StreamInfo{
+		SSRC:     2222,
+		MimeType: "video/rtx",
+		RTPHeaderExtensions: []interceptor.RTPHeaderExtension{
+			{URI: sdp.SDESMidURI, ID: 1},
+			{URI: repairedRTPStreamID, ID: 2},
+		},
+	}, interceptor.RTPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+		return copy(b, raw), a, nil
+	}))
+	_, _, err = reader.Read(make([]byte, 1500), nil)
+	require.NoError(t, err)
+
+	// the retransmission reaches the layer with the same RID
+	_, err = rtxBuff.Write(raw)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), primary.GetStats().RTXRecovered)
+}
@@ -0,0 +1,65 @@
+package rtc
+
+import (
+	"encoding/json"
+
+	livekit "github.com/livekit/protocol/proto"
+)
+
+// Updates the signal protocol has no message for are sent to participants as reliable user data
+// packets without a source participant. The payload is a JSON object, identified by its type field.
+const (
+	trackQualityMessageType     = "track_quality"
+	permissionUpdateMessageType = "permission_update"
+	// attributes the server set on a participant, sent to everyone in the room
+	participantAttributesMessageType = "participant_attributes"
+	// dimensions, frame rate and bitrate of tracks published by others
+	trackPreviewsMessageType = "track_previews"
+	// transcribed speech of a participant
+	transcriptionMessageType = "transcription"
+	// bitrates a publisher should encode the layers of its simulcast tracks at
+	layerTargetsMessageType = "layer_targets"
+	// connection quality of each published track
+	trackConnectionQualityMessageType = "track_connection_quality"
+	// published tracks that stopped receiving media, or resumed
+	trackStallsMessageType = "track_stalls"
+	// a track the participant added was dropped, its media never arrived
+	pendingTrackExpiredMessageType = "pending_track_expired"
+	// a track the participant asked to publish was rejected
+	trackPublishFailedMessageType = "track_publish_failed"
+	// the participant couldn't be subscribed to a track
+	subscriptionErrorMessageType = "subscription_error"
+	// a track the participant published was unpublished, it should stop sending the track
+	trackUnpublishedMessageType = "track_unpublished"
+	// participants waiting in the waiting room, sent to participants that approve joins
+	pendingJoinsMessageType = "pending_joins"
+)
+
+// permissionUpdateMessage tells a participant its permissions were changed through the API
+type permissionUpdateMessage struct {
+	Type           string `json:"type"`
+	CanSubscribe   bool   `json:"can_subscribe"`
+	CanPublish     bool   `json:"can_publish"`
+	CanPublishData bool   `json:"can_publish_data"`
+}
+
+// trackUnpublishedMessage tells a participant one of its tracks was unpublished
+type trackUnpublishedMessage struct {
+	Type     string `json:"type"`
+	TrackSid string `json:"track_sid"`
+}
+
+func newServerMessagePacket(msg interface{}) (*livekit.DataPacket, error) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return &livekit.DataPacket{
+		Kind: livekit.DataPacket_RELIABLE,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{
+				Payload: payload,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,137 @@
+package rtc
+
+import (
+	"sync"
+
+	livekit "github.com/livekit/protocol/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// signal responses a client hasn't acked are kept up to this many, the oldest are dropped past it
+const maxSignalHistory = 256
+
+// signalSeqField is the field signal responses carry their sequence number in. The protocol doesn't
+// define it, so it travels between nodes with the response, and protobuf clients skip it
+const signalSeqField protowire.Number = 1000
+
+// SignalSeq returns the sequence number of a signal response sent to a participant, 0 when it
+// has none
+func SignalSeq(msg *livekit.SignalResponse) uint32 {
+	b := msg.ProtoReflect().GetUnknown()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return 0
+		}
+		b = b[n:]
+		if num == signalSeqField && typ == protowire.VarintType {
+			seq, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return 0
+			}
+			return uint32(seq)
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return 0
+		}
+		b = b[n:]
+	}
+	return 0
+}
+
+func setSignalSeq(msg *livekit.SignalResponse, seq uint32) {
+	b := protowire.AppendTag(nil, signalSeqField, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(seq))
+	setUnknownField(msg.ProtoReflect(), signalSeqField, b)
+}
+
+// signalHistory numbers the signal responses sent to a participant, and keeps those the client
+// hasn't acked, to send them again when it resumes
+type signalHistory struct {
+	lock sync.Mutex
+	seq  uint32
+	// unacked responses, oldest first
+	messages []*livekit.SignalResponse
+	// responses after it are sent again to the next response sink, nil when the client didn't
+	// resume with one
+	resumeAfter *uint32
+}
+
+// send numbers msg and writes a copy of it, responses are written in the order they're numbered
+func (h *signalHistory) send(msg *livekit.SignalResponse, write func(*livekit.SignalResponse) error) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	// the same response can be sent to many participants
+	msg = proto.Clone(msg).(*livekit.SignalResponse)
+	h.seq++
+	setSignalSeq(msg, h.seq)
+	h.messages = append(h.messages, msg)
+	if len(h.messages) > maxSignalHistory {
+		h.messages = h.messages[len(h.messages)-maxSignalHistory:]
+	}
+	return write(msg)
+}
+
+// ack drops the responses up to seq, the client received them
+func (h *signalHistory) ack(seq uint32) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.ackLocked(seq)
+}
+
+func (h *signalHistory) ackLocked(seq uint32) {
+	i := 0
+	for i < len(h.messages) && SignalSeq(h.messages[i]) <= seq {
+		i++
+	}
+	h.messages = h.messages[i:]
+}
+
+// resume sends the responses after seq again once the next response sink is attached
+func (h *signalHistory) resume(seq uint32) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.resumeAfter = &seq
+}
+
+// attach runs attach, which swaps the response sink, then writes the responses the client is
+// missing when it resumed, before any response sent after them. Offers and ICE candidates aren't
+// written again, the transports renegotiate instead; offered is true when an offer was missing.
+// dropped counts the missing responses that were no longer kept
+func (h *signalHistory) attach(attach func(), write func(*livekit.SignalResponse) error) (replayed, dropped int, offered bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	attach()
+	if h.resumeAfter == nil {
+		return
+	}
+	after := *h.resumeAfter
+	h.resumeAfter = nil
+	h.ackLocked(after)
+
+	next := h.seq + 1
+	if len(h.messages) != 0 {
+		next = SignalSeq(h.messages[0])
+	}
+	if after < h.seq && next > after+1 {
+		dropped = int(next - after - 1)
+	}
+	for _, msg := range h.messages {
+		switch msg.Message.(type) {
+		case *livekit.SignalResponse_Offer:
+			offered = true
+			continue
+		case *livekit.SignalResponse_Trickle:
+			continue
+		}
+		if err := write(msg); err != nil {
+			return
+		}
+		replayed++
+	}
+	return
+}
@@ -0,0 +1,117 @@
+package rtc
+
+import (
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSignalSeq(t *testing.T) {
+	msg := &livekit.SignalResponse{
+		Message: &livekit.SignalResponse_RoomUpdate{RoomUpdate: &livekit.RoomUpdate{Room: &livekit.Room{Name: "room"}}},
+	}
+	require.Zero(t, SignalSeq(msg))
+
+	setSignalSeq(msg, 300)
+	require.Equal(t, uint32(300), SignalSeq(msg))
+
+	// carried over the wire, between nodes
+	b, err := proto.Marshal(msg)
+	require.NoError(t, err)
+	received := &livekit.SignalResponse{}
+	require.NoError(t, proto.Unmarshal(b, received))
+	require.Equal(t, uint32(300), SignalSeq(received))
+	require.Equal(t, "room", received.GetRoomUpdate().Room.Name)
+}
+
+func TestSignalHistory(t *testing.T) {
+	update := func(name string) *livekit.SignalResponse {
+		return &livekit.SignalResponse{
+			Message: &livekit.SignalResponse_RoomUpdate{RoomUpdate: &livekit.RoomUpdate{Room: &livekit.Room{Name: name}}},
+		}
+	}
+	var written []*livekit.SignalResponse
+	write := func(msg *livekit.SignalResponse) error {
+		written = append(written, msg)
+		return nil
+	}
+	names := func(msgs []*livekit.SignalResponse) []string {
+		var names []string
+		for _, msg := range msgs {
+			names = append(names, msg.GetRoomUpdate().Room.Name)
+		}
+		return names
+	}
+
+	t.Run("numbers responses", func(t *testing.T) {
+		written = nil
+		h := &signalHistory{}
+		msg := update("a")
+		require.NoError(t, h.send(msg, write))
+		require.NoError(t, h.send(msg, write))
+		require.Equal(t, uint32(1), SignalSeq(written[0]))
+		require.Equal(t, uint32(2), SignalSeq(written[1]))
+		// sent as a copy
+		require.Zero(t, SignalSeq(msg))
+	})
+
+	t.Run("replays what the client missed", func(t *testing.T) {
+		written = nil
+		h := &signalHistory{}
+		for _, name := range []string{"a", "b", "c", "d"} {
+			require.NoError(t, h.send(update(name), write))
+		}
+		require.NoError(t, h.send(&livekit.SignalResponse{
+			Message: &livekit.SignalResponse_Trickle{Trickle: &livekit.TrickleRequest{}},
+		}, write))
+		require.NoError(t, h.send(&livekit.SignalResponse{
+			Message: &livekit.SignalResponse_Offer{Offer: &livekit.SessionDescription{Type: "offer"}},
+		}, write))
+		h.ack(1)
+
+		// nothing is replayed unless the client resumes
+		attached := false
+		written = nil
+		replayed, dropped, offered := h.attach(func() { attached = true }, write)
+		require.True(t, attached)
+		require.Zero(t, replayed)
+		require.Zero(t, dropped)
+		require.False(t, offered)
+		require.Empty(t, written)
+
+		h.resume(2)
+		replayed, dropped, offered = h.attach(func() {}, write)
+		require.Equal(t, 2, replayed)
+		require.Zero(t, dropped)
+		require.True(t, offered)
+		require.Equal(t, []string{"c", "d"}, names(written))
+		require.Equal(t, uint32(3), SignalSeq(written[0]))
+
+		// numbering goes on
+		written = nil
+		require.NoError(t, h.send(update("e"), write))
+		require.Equal(t, uint32(7), SignalSeq(written[0]))
+	})
+
+	t.Run("reports responses no longer kept", func(t *testing.T) {
+		written = nil
+		h := &signalHistory{}
+		for i := 0; i < maxSignalHistory+10; i++ {
+			require.NoError(t, h.send(update("a"), write))
+		}
+		written = nil
+		h.resume(5)
+		replayed, dropped, _ := h.attach(func() {}, write)
+		require.Equal(t, maxSignalHistory, replayed)
+		require.Equal(t, 5, dropped)
+		require.Equal(t, uint32(11), SignalSeq(written[0]))
+
+		// the client received everything
+		h.resume(maxSignalHistory + 10)
+		replayed, dropped, _ = h.attach(func() {}, write)
+		require.Zero(t, replayed)
+		require.Zero(t, dropped)
+	})
+}
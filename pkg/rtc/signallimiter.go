@@ -0,0 +1,104 @@
+package rtc
+
+import (
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// kinds of signal requests, limited separately
+const (
+	SignalKindOffer    = "offer"
+	SignalKindTrickle  = "trickle"
+	SignalKindAddTrack = "add_track"
+	SignalKindData     = "data"
+	SignalKindOther    = "other"
+)
+
+// SignalRateLimiter limits the signal requests of a participant session by kind, so that a client
+// flooding the node can't take over the loop handling its requests. Requests over the limits are
+// dropped, and the session is ended once max_violations of them were within violation_window. It's
+// used by that loop alone
+type SignalRateLimiter struct {
+	buckets         map[string]*tokenBucket
+	maxViolations   int
+	violationWindow time.Duration
+	// when the requests dropped within the window were, oldest first
+	violations []time.Time
+}
+
+func NewSignalRateLimiter(conf config.SignalRateLimitConfig) *SignalRateLimiter {
+	return &SignalRateLimiter{
+		buckets: map[string]*tokenBucket{
+			SignalKindOffer:    newTokenBucket(conf.Offer),
+			SignalKindTrickle:  newTokenBucket(conf.Trickle),
+			SignalKindAddTrack: newTokenBucket(conf.AddTrack),
+			SignalKindData:     newTokenBucket(conf.Data),
+			SignalKindOther:    newTokenBucket(conf.Other),
+		},
+		maxViolations:   conf.MaxViolations,
+		violationWindow: conf.ViolationWindow,
+	}
+}
+
+// Allow returns whether req can be handled, false when it's over the limit of its kind
+func (l *SignalRateLimiter) Allow(req *livekit.SignalRequest) bool {
+	if _, ok := req.Message.(*livekit.SignalRequest_Leave); ok {
+		return true
+	}
+	return l.AllowKind(SignalRequestKind(req))
+}
+
+// AllowKind returns whether a request of kind can be handled, for requests that aren't signal
+// requests, like text requests
+func (l *SignalRateLimiter) AllowKind(kind string) bool {
+	return l.allow(kind, time.Now())
+}
+
+func (l *SignalRateLimiter) allow(kind string, now time.Time) bool {
+	bucket := l.buckets[kind]
+	if bucket == nil || bucket.take(now) {
+		return true
+	}
+	if l.maxViolations > 0 {
+		l.violations = append(l.violations, now)
+		l.expireViolations(now)
+	}
+	return false
+}
+
+// Exceeded returns true once max_violations requests were dropped within violation_window, the
+// participant should be disconnected
+func (l *SignalRateLimiter) Exceeded() bool {
+	return l.maxViolations > 0 && len(l.violations) >= l.maxViolations
+}
+
+func (l *SignalRateLimiter) expireViolations(now time.Time) {
+	i := 0
+	for i < len(l.violations) && now.Sub(l.violations[i]) > l.violationWindow {
+		i++
+	}
+	// only the last max_violations matter
+	if len(l.violations)-i > l.maxViolations {
+		i = len(l.violations) - l.maxViolations
+	}
+	l.violations = l.violations[i:]
+}
+
+// SignalRequestKind returns the kind req is limited as
+func SignalRequestKind(req *livekit.SignalRequest) string {
+	switch req.Message.(type) {
+	case *livekit.SignalRequest_Offer:
+		return SignalKindOffer
+	case *livekit.SignalRequest_Trickle:
+		return SignalKindTrickle
+	case *livekit.SignalRequest_AddTrack:
+		return SignalKindAddTrack
+	}
+	if SignalRequestData(req) != nil {
+		return SignalKindData
+	}
+	return SignalKindOther
+}
@@ -0,0 +1,80 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestSignalRateLimiter(t *testing.T) {
+	offer := &livekit.SignalRequest{Message: &livekit.SignalRequest_Offer{Offer: &livekit.SessionDescription{}}}
+	trickle := &livekit.SignalRequest{Message: &livekit.SignalRequest_Trickle{Trickle: &livekit.TrickleRequest{}}}
+	mute := &livekit.SignalRequest{Message: &livekit.SignalRequest_Mute{Mute: &livekit.MuteTrackRequest{}}}
+	leave := &livekit.SignalRequest{Message: &livekit.SignalRequest_Leave{Leave: &livekit.LeaveRequest{}}}
+	data, err := NewSignalDataRequest(&livekit.DataPacket{})
+	require.NoError(t, err)
+
+	t.Run("kinds", func(t *testing.T) {
+		require.Equal(t, SignalKindOffer, SignalRequestKind(offer))
+		require.Equal(t, SignalKindTrickle, SignalRequestKind(trickle))
+		require.Equal(t, SignalKindData, SignalRequestKind(data))
+		require.Equal(t, SignalKindOther, SignalRequestKind(mute))
+	})
+
+	t.Run("kinds are limited separately", func(t *testing.T) {
+		l := NewSignalRateLimiter(config.SignalRateLimitConfig{
+			Offer: config.RateLimitConfig{Rate: 1, Burst: 2},
+			Other: config.RateLimitConfig{Rate: 1, Burst: 1},
+		})
+		require.True(t, l.Allow(offer))
+		require.True(t, l.Allow(offer))
+		require.False(t, l.Allow(offer))
+
+		require.True(t, l.Allow(mute))
+		require.False(t, l.Allow(mute))
+		// unlimited kinds, and leave requests
+		require.True(t, l.Allow(trickle))
+		require.True(t, l.Allow(leave))
+		require.False(t, l.Exceeded())
+	})
+
+	t.Run("disconnects after max violations", func(t *testing.T) {
+		l := NewSignalRateLimiter(config.SignalRateLimitConfig{
+			Trickle:         config.RateLimitConfig{Rate: 1, Burst: 1},
+			MaxViolations:   3,
+			ViolationWindow: time.Minute,
+		})
+		require.True(t, l.Allow(trickle))
+		for i := 0; i < 2; i++ {
+			require.False(t, l.Allow(trickle))
+			require.False(t, l.Exceeded())
+		}
+		require.False(t, l.Allow(trickle))
+		require.True(t, l.Exceeded())
+	})
+
+	t.Run("violations expire after the window", func(t *testing.T) {
+		l := NewSignalRateLimiter(config.SignalRateLimitConfig{
+			Trickle:         config.RateLimitConfig{Rate: 0.001, Burst: 1},
+			MaxViolations:   3,
+			ViolationWindow: time.Minute,
+		})
+		now := time.Now()
+		require.True(t, l.allow(SignalKindTrickle, now))
+		require.False(t, l.allow(SignalKindTrickle, now))
+		require.False(t, l.allow(SignalKindTrickle, now.Add(30*time.Second)))
+		require.False(t, l.Exceeded())
+
+		// the first violation is past the window, a well-behaved client isn't disconnected over time
+		require.False(t, l.allow(SignalKindTrickle, now.Add(70*time.Second)))
+		require.False(t, l.Exceeded())
+		require.Len(t, l.violations, 2)
+
+		require.False(t, l.allow(SignalKindTrickle, now.Add(80*time.Second)))
+		require.True(t, l.Exceeded())
+	})
+}
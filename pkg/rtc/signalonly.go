@@ -0,0 +1,93 @@
+package rtc
+
+import (
+	livekit "github.com/livekit/protocol/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	// signalDataField is the field signal requests and responses carry data packets in, for
+	// signal-only participants. Like the sequence number, the protocol doesn't define it
+	signalDataField protowire.Number = 1001
+	// signalOnlyField flags the participant info of signal-only participants
+	signalOnlyField protowire.Number = 1002
+)
+
+// NewSignalDataResponse returns a signal response delivering dp to a signal-only participant
+func NewSignalDataResponse(dp *livekit.DataPacket) (*livekit.SignalResponse, error) {
+	data, err := proto.Marshal(dp)
+	if err != nil {
+		return nil, err
+	}
+	msg := &livekit.SignalResponse{}
+	setUnknownField(msg.ProtoReflect(), signalDataField, bytesField(signalDataField, data))
+	return msg, nil
+}
+
+// SignalResponseDataPacket returns the data packet a signal response delivers, nil when it's
+// another response
+func SignalResponseDataPacket(msg *livekit.SignalResponse) (*livekit.DataPacket, error) {
+	data := unknownBytesField(msg.ProtoReflect().GetUnknown(), signalDataField)
+	if data == nil {
+		return nil, nil
+	}
+	dp := &livekit.DataPacket{}
+	if err := proto.Unmarshal(data, dp); err != nil {
+		return nil, err
+	}
+	return dp, nil
+}
+
+// NewSignalDataRequest returns a signal request sending dp from a signal-only participant
+func NewSignalDataRequest(dp *livekit.DataPacket) (*livekit.SignalRequest, error) {
+	data, err := proto.Marshal(dp)
+	if err != nil {
+		return nil, err
+	}
+	req := &livekit.SignalRequest{}
+	setUnknownField(req.ProtoReflect(), signalDataField, bytesField(signalDataField, data))
+	return req, nil
+}
+
+// SignalRequestData returns the encoded data packet a signal request sends, nil when it's another
+// request
+func SignalRequestData(req *livekit.SignalRequest) []byte {
+	return unknownBytesField(req.ProtoReflect().GetUnknown(), signalDataField)
+}
+
+// IsSignalOnly returns true when the participant joined without WebRTC transports
+func IsSignalOnly(info *livekit.ParticipantInfo) bool {
+	return unknownBoolField(info.ProtoReflect().GetUnknown(), signalOnlyField)
+}
+
+func boolField(num protowire.Number) []byte {
+	b := protowire.AppendTag(nil, num, protowire.VarintType)
+	return protowire.AppendVarint(b, protowire.EncodeBool(true))
+}
+
+func setSignalOnly(info *livekit.ParticipantInfo) {
+	setUnknownField(info.ProtoReflect(), signalOnlyField, boolField(signalOnlyField))
+}
+
+// unknownBoolField returns true when the unknown field num is set and true
+func unknownBoolField(b protoreflect.RawFields, num protowire.Number) bool {
+	for len(b) > 0 {
+		fieldNum, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return false
+		}
+		b = b[n:]
+		if fieldNum == num && typ == protowire.VarintType {
+			v, n := protowire.ConsumeVarint(b)
+			return n >= 0 && v != 0
+		}
+		n = protowire.ConsumeFieldValue(fieldNum, typ, b)
+		if n < 0 {
+			return false
+		}
+		b = b[n:]
+	}
+	return false
+}
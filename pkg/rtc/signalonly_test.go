@@ -0,0 +1,98 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+func newSignalOnlyParticipantForTest(identity string) *ParticipantImpl {
+	params := newParticipantForTest(identity).params
+	params.SignalOnly = true
+	params.Sink = &routingfakes.FakeMessageSink{}
+	p, _ := NewParticipant(params)
+	return p
+}
+
+func TestSignalOnlyParticipants(t *testing.T) {
+	t.Run("has no transports", func(t *testing.T) {
+		p := newSignalOnlyParticipantForTest("viewer")
+		require.Nil(t, p.publisher)
+		require.Nil(t, p.subscriber)
+		require.False(t, p.CanPublish())
+		require.False(t, p.CanSubscribe())
+		require.True(t, p.CanPublishData())
+		require.Nil(t, p.SubscriberPC())
+		require.True(t, IsSignalOnly(p.ToProto()))
+		require.False(t, IsSignalOnly(newParticipantForTest("other").ToProto()))
+
+		require.ErrorIs(t, p.HandleAnswer(webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer}), ErrNoSubscriber)
+		require.ErrorIs(t, p.AddICECandidate(webrtc.ICECandidateInit{}, livekit.SignalTarget_SUBSCRIBER), ErrNoSubscriber)
+		p.Negotiate()
+		require.NoError(t, p.ICERestart())
+		require.NoError(t, p.Close())
+	})
+
+	t.Run("is active once it received the join response", func(t *testing.T) {
+		p := newSignalOnlyParticipantForTest("viewer")
+		states := make(chan livekit.ParticipantInfo_State, 1)
+		p.OnStateChange(func(p types.Participant, _ livekit.ParticipantInfo_State) {
+			states <- p.State()
+		})
+		require.NoError(t, p.SendJoinResponse(&livekit.Room{Name: "room"}, nil, nil))
+		select {
+		case state := <-states:
+			require.Equal(t, livekit.ParticipantInfo_ACTIVE, state)
+		case <-time.After(time.Second):
+			t.Fatal("participant didn't become active")
+		}
+		p.Start()
+		require.NoError(t, p.Close())
+	})
+
+	t.Run("data packets go over the signal connection", func(t *testing.T) {
+		p := newSignalOnlyParticipantForTest("viewer")
+		p.params.SequenceSignal = true
+		sink := p.params.Sink.(*routingfakes.FakeMessageSink)
+		p.updateState(livekit.ParticipantInfo_ACTIVE)
+
+		dp := &livekit.DataPacket{
+			Kind:  livekit.DataPacket_RELIABLE,
+			Value: &livekit.DataPacket_User{User: &livekit.UserPacket{Payload: []byte("hello")}},
+		}
+		require.NoError(t, p.SendDataPacket(dp))
+		require.Equal(t, 1, sink.WriteMessageCallCount())
+		res := sink.WriteMessageArgsForCall(0).(*livekit.SignalResponse)
+		require.Nil(t, res.Message)
+		// numbered like the other responses, the sequence number doesn't replace the packet
+		require.NotZero(t, SignalSeq(res))
+		sent, err := SignalResponseDataPacket(res)
+		require.NoError(t, err)
+		require.True(t, proto.Equal(dp, sent))
+
+		var received []*livekit.DataPacket
+		p.OnDataPacket(func(_ types.Participant, dp *livekit.DataPacket) {
+			received = append(received, dp)
+		})
+		req, err := NewSignalDataRequest(dp)
+		require.NoError(t, err)
+		p.HandleSignalData(SignalRequestData(req))
+		require.Len(t, received, 1)
+		require.Equal(t, livekit.DataPacket_RELIABLE, received[0].Kind)
+		require.Equal(t, p.ID(), received[0].GetUser().ParticipantSid)
+	})
+
+	t.Run("other requests carry no data", func(t *testing.T) {
+		require.Nil(t, SignalRequestData(&livekit.SignalRequest{Message: &livekit.SignalRequest_Leave{Leave: &livekit.LeaveRequest{}}}))
+		dp, err := SignalResponseDataPacket(&livekit.SignalResponse{})
+		require.NoError(t, err)
+		require.Nil(t, dp)
+	})
+}
@@ -0,0 +1,264 @@
+package rtc
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+const (
+	// a participant that's silent for less than this is still in the same speaking segment, speech
+	// has short pauses
+	speakingSegmentGap = 1500 * time.Millisecond
+	// the dominant speaker is the participant that spoke the most over this window
+	dominantSpeakerWindow = 10 * time.Second
+	// speaking segments kept per participant, the oldest are dropped. Talk time keeps counting them
+	maxSpeakingSegments = 1000
+)
+
+// SpeakingSegment is a period a participant was speaking, in unix milliseconds
+type SpeakingSegment struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// SpeakerStats tells how much a participant spoke in a room
+type SpeakerStats struct {
+	ParticipantSid      string `json:"participant_sid"`
+	ParticipantIdentity string `json:"participant_identity"`
+	// total time the participant spoke, in milliseconds
+	TalkTime int64 `json:"talk_time"`
+	// share of the talk time of all participants, 0 to 1
+	TalkShare float64 `json:"talk_share"`
+	// number of times the participant started speaking
+	NumSegments int  `json:"num_segments"`
+	Speaking    bool `json:"speaking"`
+	// the latest speaking segments, oldest first, when they were asked for
+	Segments []SpeakingSegment `json:"segments,omitempty"`
+}
+
+type speakerRecord struct {
+	sid      string
+	identity string
+	// closed segments, and their total duration
+	segments    []SpeakingSegment
+	numSegments int
+	talkTime    time.Duration
+	// the open segment, zero when the participant isn't speaking
+	start      time.Time
+	lastActive time.Time
+}
+
+func (s *speakerRecord) speaking() bool {
+	return !s.start.IsZero()
+}
+
+func (s *speakerRecord) closeSegment() {
+	s.talkTime += s.lastActive.Sub(s.start)
+	s.segments = append(s.segments, SpeakingSegment{Start: s.start.UnixMilli(), End: s.lastActive.UnixMilli()})
+	if len(s.segments) > maxSpeakingSegments {
+		s.segments = s.segments[len(s.segments)-maxSpeakingSegments:]
+	}
+	s.numSegments++
+	s.start = time.Time{}
+	s.lastActive = time.Time{}
+}
+
+// talkTimeSince returns how long the participant spoke since t
+func (s *speakerRecord) talkTimeSince(t time.Time) time.Duration {
+	var talkTime time.Duration
+	since := t.UnixMilli()
+	for i := len(s.segments) - 1; i >= 0 && s.segments[i].End > since; i-- {
+		start := s.segments[i].Start
+		if start < since {
+			start = since
+		}
+		talkTime += time.Duration(s.segments[i].End-start) * time.Millisecond
+	}
+	if s.speaking() {
+		start := s.start
+		if start.Before(t) {
+			start = t
+		}
+		talkTime += s.lastActive.Sub(start)
+	}
+	return talkTime
+}
+
+// speakerHistory records when the participants of a room were speaking, for their talk time and
+// the dominant speaker. Participants are kept after they leave, their talk time is part of the
+// room's
+type speakerHistory struct {
+	lock     sync.Mutex
+	speakers map[string]*speakerRecord
+	// the last time speakers were observed, active speakers were speaking since
+	observedAt time.Time
+	dominant   *speakerRecord
+}
+
+func newSpeakerHistory() *speakerHistory {
+	return &speakerHistory{speakers: make(map[string]*speakerRecord)}
+}
+
+// observe records the participants that are speaking, identities by sid. It returns the new
+// dominant speaker when it changed, with the one before it
+func (h *speakerHistory) observe(speaking map[string]string, now time.Time) (dominant, previous *speakerRecord) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	since := h.observedAt
+	if since.IsZero() || now.Sub(since) > speakingSegmentGap {
+		since = now
+	}
+	h.observedAt = now
+
+	for sid, identity := range speaking {
+		s := h.speakers[sid]
+		if s == nil {
+			s = &speakerRecord{sid: sid, identity: identity}
+			h.speakers[sid] = s
+		}
+		if !s.speaking() {
+			s.start = since
+		}
+		s.lastActive = now
+	}
+	for sid, s := range h.speakers {
+		if _, ok := speaking[sid]; !ok && s.speaking() && now.Sub(s.lastActive) >= speakingSegmentGap {
+			s.closeSegment()
+		}
+	}
+
+	// the dominant speaker stays until someone else spoke more over the window
+	windowStart := now.Add(-dominantSpeakerWindow)
+	var mostTalkTime time.Duration
+	if h.dominant != nil {
+		mostTalkTime = h.dominant.talkTimeSince(windowStart)
+	}
+	next := h.dominant
+	for _, s := range h.speakers {
+		if talkTime := s.talkTimeSince(windowStart); talkTime > mostTalkTime {
+			next, mostTalkTime = s, talkTime
+		}
+	}
+	if next == h.dominant {
+		return nil, nil
+	}
+	previous, h.dominant = h.dominant, next
+	return next, previous
+}
+
+// stats returns the talk time of each participant that spoke, most first, and the dominant
+// speaker's sid
+func (h *speakerHistory) stats(withSegments bool) (dominantSid string, stats []*SpeakerStats) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	var total time.Duration
+	stats = make([]*SpeakerStats, 0, len(h.speakers))
+	for _, s := range h.speakers {
+		talkTime := s.talkTime
+		numSegments := s.numSegments
+		if s.speaking() {
+			talkTime += s.lastActive.Sub(s.start)
+			numSegments++
+		}
+		total += talkTime
+		st := &SpeakerStats{
+			ParticipantSid:      s.sid,
+			ParticipantIdentity: s.identity,
+			TalkTime:            talkTime.Milliseconds(),
+			NumSegments:         numSegments,
+			Speaking:            s.speaking(),
+		}
+		if withSegments {
+			st.Segments = append([]SpeakingSegment{}, s.segments...)
+			if s.speaking() {
+				st.Segments = append(st.Segments, SpeakingSegment{Start: s.start.UnixMilli(), End: s.lastActive.UnixMilli()})
+			}
+		}
+		stats = append(stats, st)
+	}
+	for _, st := range stats {
+		if total > 0 {
+			st.TalkShare = float64(st.TalkTime) / float64(total.Milliseconds())
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].TalkTime != stats[j].TalkTime {
+			return stats[i].TalkTime > stats[j].TalkTime
+		}
+		return stats[i].ParticipantIdentity < stats[j].ParticipantIdentity
+	})
+
+	if h.dominant != nil {
+		dominantSid = h.dominant.sid
+	}
+	return dominantSid, stats
+}
+
+// SpeakerStats returns how much each participant that spoke in the room did, most first, and the
+// sid of the dominant speaker, empty when nobody spoke yet. Speaking segments are included when
+// withSegments is set
+func (r *Room) SpeakerStats(withSegments bool) (dominantSid string, stats []*SpeakerStats) {
+	return r.speakers.stats(withSegments)
+}
+
+// observeSpeakers records the active speakers in the speaker history, reporting when the dominant
+// speaker changed
+func (r *Room) observeSpeakers(activeSpeakers []*livekit.SpeakerInfo, now time.Time) {
+	identities := make(map[string]string, len(activeSpeakers))
+	for _, speaker := range activeSpeakers {
+		identities[speaker.Sid] = ""
+	}
+	for _, p := range r.GetParticipants() {
+		if _, ok := identities[p.ID()]; ok {
+			identities[p.ID()] = p.Identity()
+		}
+	}
+
+	dominant, previous := r.speakers.observe(identities, now)
+	if dominant == nil {
+		return
+	}
+	event := &telemetry.DominantSpeakerEvent{
+		RoomSid:             r.Room.Sid,
+		RoomName:            r.Room.Name,
+		ParticipantSid:      dominant.sid,
+		ParticipantIdentity: dominant.identity,
+		Time:                now.Unix(),
+	}
+	if previous != nil {
+		event.PreviousParticipantSid = previous.sid
+	}
+	r.Logger.Debugw("dominant speaker changed", "participant", dominant.identity)
+	r.telemetry.DominantSpeakerChanged(context.Background(), event)
+}
+
+// reportSpeakerStats sends the talk time of the participants that spoke, once the room closed
+func (r *Room) reportSpeakerStats() {
+	dominantSid, stats := r.speakers.stats(false)
+	if len(stats) == 0 {
+		return
+	}
+	event := &telemetry.SpeakerStatsEvent{
+		RoomSid:         r.Room.Sid,
+		RoomName:        r.Room.Name,
+		DominantSpeaker: dominantSid,
+	}
+	for _, st := range stats {
+		event.Speakers = append(event.Speakers, &telemetry.SpeakerTalkTime{
+			ParticipantSid:      st.ParticipantSid,
+			ParticipantIdentity: st.ParticipantIdentity,
+			TalkTime:            st.TalkTime,
+			TalkShare:           st.TalkShare,
+			NumSegments:         st.NumSegments,
+		})
+	}
+	r.telemetry.SpeakerStatsReported(context.Background(), event)
+}
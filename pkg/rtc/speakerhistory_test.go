@@ -0,0 +1,68 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpeakerHistory(t *testing.T) {
+	h := newSpeakerHistory()
+	start := time.Unix(1000, 0)
+	tick := 500 * time.Millisecond
+	alice := map[string]string{"PA_alice": "alice"}
+	bob := map[string]string{"PA_bob": "bob"}
+	nobody := map[string]string{}
+
+	// alice speaks for 2s, with a pause shorter than the segment gap
+	var dominant, previous *speakerRecord
+	at := start
+	observe := func(speaking map[string]string, ticks int) {
+		for i := 0; i < ticks; i++ {
+			at = at.Add(tick)
+			if d, p := h.observe(speaking, at); d != nil {
+				dominant, previous = d, p
+			}
+		}
+	}
+	observe(alice, 3)
+	require.Equal(t, "PA_alice", dominant.sid)
+	require.Nil(t, previous)
+	observe(nobody, 1)
+	observe(alice, 2)
+	dominantSid, stats := h.stats(true)
+	require.Equal(t, "PA_alice", dominantSid)
+	require.Len(t, stats, 1)
+	require.True(t, stats[0].Speaking)
+	// the pause is part of the segment
+	require.Equal(t, int64(2500), stats[0].TalkTime)
+	require.Equal(t, 1, stats[0].NumSegments)
+
+	// the segment ends once alice is silent for the gap
+	observe(nobody, 4)
+	_, stats = h.stats(true)
+	require.False(t, stats[0].Speaking)
+	require.Equal(t, []SpeakingSegment{{Start: start.Add(tick).UnixMilli(), End: start.Add(6 * tick).UnixMilli()}}, stats[0].Segments)
+
+	// bob only becomes the dominant speaker once he spoke more than alice over the window
+	observe(bob, 4)
+	require.Equal(t, "PA_alice", dominant.sid)
+	observe(bob, 2)
+	require.Equal(t, "PA_bob", dominant.sid)
+	require.Equal(t, "PA_alice", previous.sid)
+
+	dominantSid, stats = h.stats(false)
+	require.Equal(t, "PA_bob", dominantSid)
+	require.Equal(t, "bob", stats[0].ParticipantIdentity)
+	require.Equal(t, int64(3000), stats[0].TalkTime)
+	require.Equal(t, "alice", stats[1].ParticipantIdentity)
+	require.InDelta(t, 3000.0/5500, stats[0].TalkShare, 0.001)
+	require.InDelta(t, 2500.0/5500, stats[1].TalkShare, 0.001)
+	require.Nil(t, stats[0].Segments)
+
+	// the dominant speaker stays while nobody speaks
+	observe(nobody, 40)
+	dominantSid, _ = h.stats(false)
+	require.Equal(t, "PA_bob", dominantSid)
+}
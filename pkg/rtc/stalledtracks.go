@@ -0,0 +1,198 @@
+package rtc
+
+import (
+	"context"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+// trackActivity follows the media received on a published track
+type trackActivity struct {
+	participant types.Participant
+	packets     uint64
+	// last time the packet count went up
+	lastReceived time.Time
+	stalled      bool
+	// unpublished after this long without media, 0 when it's kept
+	closeTimeout time.Duration
+}
+
+// trackStallsMessage tells subscribers which of the tracks they receive stopped receiving media
+// from their publisher, or resumed. Viewers would otherwise see frozen video without explanation
+type trackStallsMessage struct {
+	Type   string        `json:"type"`
+	Tracks []*trackStall `json:"tracks"`
+}
+
+type trackStall struct {
+	ParticipantSid string `json:"participant_sid"`
+	TrackSid       string `json:"track_sid"`
+	Stalled        bool   `json:"stalled"`
+	// unix time of the last media received, while stalled
+	StalledAt int64 `json:"stalled_at,omitempty"`
+	// unix time the track will be unpublished at unless media resumes, while stalled
+	ClosesAt int64 `json:"closes_at,omitempty"`
+}
+
+func newTrackStallsPacket(stalls []*trackStall) (*livekit.DataPacket, error) {
+	return newServerMessagePacket(&trackStallsMessage{
+		Type:   trackStallsMessageType,
+		Tracks: stalls,
+	})
+}
+
+// detectStalledTracks compares the packets received on each unmuted track of active participants
+// with the last check, returning the activity of the tracks by track ID. Tracks that haven't
+// received media for the stalled timeout of their kind are stalled, and unpublished once they
+// reach its close timeout. Muted tracks and tracks of participants that aren't connected are left
+// out, they aren't expected to receive media
+func (r *Room) detectStalledTracks(participants []types.Participant, last map[string]*trackActivity, now time.Time) map[string]*trackActivity {
+	activity := make(map[string]*trackActivity)
+	for _, p := range participants {
+		if p.State() != livekit.ParticipantInfo_ACTIVE {
+			continue
+		}
+		for _, track := range p.GetPublishedTracks() {
+			inactivity := r.roomConfig.TrackInactivity(track.Kind() == livekit.TrackType_AUDIO)
+			if track.IsMuted() || inactivity.StalledTimeout == 0 {
+				continue
+			}
+			stats := track.GetStats()
+			if stats == nil {
+				continue
+			}
+			var packets uint64
+			for _, layer := range stats.Layers {
+				packets += uint64(layer.Packets)
+			}
+
+			a := last[track.ID()]
+			if a == nil {
+				a = &trackActivity{participant: p, packets: packets, lastReceived: now}
+			}
+			a.closeTimeout = inactivity.CloseTimeout
+			activity[track.ID()] = a
+			if packets != a.packets {
+				a.packets = packets
+				a.lastReceived = now
+				if a.stalled {
+					a.stalled = false
+					r.onTrackStallChanged(p, track.ID(), a, now)
+				}
+			} else if !a.stalled && now.Sub(a.lastReceived) >= inactivity.StalledTimeout {
+				a.stalled = true
+				r.onTrackStallChanged(p, track.ID(), a, now)
+			} else if a.stalled && a.closeTimeout > 0 && now.Sub(a.lastReceived) >= a.closeTimeout {
+				r.closeInactiveTrack(p, track.ID(), a, now)
+				delete(activity, track.ID())
+			}
+		}
+	}
+
+	// stalls of tracks that were muted or unpublished, or whose publisher left, are over
+	for trackID, a := range last {
+		if _, ok := activity[trackID]; !ok && a.stalled {
+			a.stalled = false
+			r.onTrackStallChanged(a.participant, trackID, a, now)
+		}
+	}
+	return activity
+}
+
+// closeInactiveTrack unpublishes a track that stayed stalled for its close timeout. Its stall ends
+// without it having resumed
+func (r *Room) closeInactiveTrack(p types.Participant, trackID string, a *trackActivity, now time.Time) {
+	a.stalled = false
+	r.Logger.Infow("unpublishing inactive track", "participant", p.Identity(), "track", trackID,
+		"lastReceived", a.lastReceived)
+	if err := p.UnpublishTrack(trackID); err != nil && err != ErrTrackNotFound {
+		r.Logger.Warnw("could not unpublish inactive track", err, "participant", p.Identity(), "track", trackID)
+	}
+	r.telemetry.TrackInactive(context.Background(), &telemetry.TrackStallEvent{
+		RoomSid:             r.Room.Sid,
+		RoomName:            r.Room.Name,
+		ParticipantSid:      p.ID(),
+		ParticipantIdentity: p.Identity(),
+		TrackSid:            trackID,
+		StalledAt:           a.lastReceived.Unix(),
+		Duration:            now.Sub(a.lastReceived).Milliseconds(),
+	})
+}
+
+func (r *Room) onTrackStallChanged(p types.Participant, trackID string, a *trackActivity, now time.Time) {
+	event := &telemetry.TrackStallEvent{
+		RoomSid:             r.Room.Sid,
+		RoomName:            r.Room.Name,
+		ParticipantSid:      p.ID(),
+		ParticipantIdentity: p.Identity(),
+		TrackSid:            trackID,
+		StalledAt:           a.lastReceived.Unix(),
+	}
+	if a.stalled {
+		r.Logger.Infow("track stalled", "participant", p.Identity(), "track", trackID,
+			"lastReceived", a.lastReceived)
+		r.telemetry.TrackStalled(context.Background(), event)
+	} else {
+		event.Duration = now.Sub(a.lastReceived).Milliseconds()
+		r.Logger.Infow("track resumed", "participant", p.Identity(), "track", trackID,
+			"stalledFor", now.Sub(a.lastReceived))
+		r.telemetry.TrackResumed(context.Background(), event)
+	}
+}
+
+// sendTrackStalls sends participants the stalls of their own tracks and of the tracks they're
+// subscribed to that started or ended since they were last sent. It returns the stalled tracks sent
+// so far by identity, each with the ID of its publisher
+func (r *Room) sendTrackStalls(participants []types.Participant, activity map[string]*trackActivity, lastSent map[string]map[string]string) map[string]map[string]string {
+	sent := make(map[string]map[string]string, len(participants))
+	for _, op := range participants {
+		if !op.Capabilities().DataPackets || op.State() != livekit.ParticipantInfo_ACTIVE {
+			continue
+		}
+
+		prev := lastSent[op.Identity()]
+		next := make(map[string]string)
+		var changed []*trackStall
+		for trackID, a := range activity {
+			if !a.stalled || (a.participant.ID() != op.ID() && op.GetSubscribedTrack(trackID) == nil) {
+				continue
+			}
+			next[trackID] = a.participant.ID()
+			if _, ok := prev[trackID]; !ok {
+				stall := &trackStall{
+					ParticipantSid: a.participant.ID(),
+					TrackSid:       trackID,
+					Stalled:        true,
+					StalledAt:      a.lastReceived.Unix(),
+				}
+				if a.closeTimeout > 0 {
+					stall.ClosesAt = a.lastReceived.Add(a.closeTimeout).Unix()
+				}
+				changed = append(changed, stall)
+			}
+		}
+		for trackID, participantID := range prev {
+			if _, ok := next[trackID]; !ok {
+				changed = append(changed, &trackStall{ParticipantSid: participantID, TrackSid: trackID})
+			}
+		}
+
+		if len(changed) != 0 {
+			dp, err := newTrackStallsPacket(changed)
+			if err == nil {
+				err = op.SendDataPacket(dp)
+			}
+			if err != nil {
+				// try again on the next update
+				r.Logger.Warnw("could not send track stalls", err, "participant", op.Identity())
+				next = prev
+			}
+		}
+		sent[op.Identity()] = next
+	}
+	return sent
+}
@@ -0,0 +1,183 @@
+package rtc
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+func TestStalledTracks(t *testing.T) {
+	track := &typesfakes.FakePublishedTrack{}
+	track.IDReturns("TR_camera")
+	setPackets := func(packets uint32) {
+		track.GetStatsReturns(&types.PublishedTrackStats{
+			TrackID: "TR_camera",
+			Layers:  []sfu.LayerStats{{Layer: 0, Packets: packets}, {Layer: 1, Packets: packets}},
+		})
+	}
+	setPackets(100)
+
+	publisher := &typesfakes.FakeParticipant{}
+	publisher.IdentityReturns("publisher")
+	publisher.IDReturns("PA_publisher")
+	publisher.StateReturns(livekit.ParticipantInfo_ACTIVE)
+	publisher.CapabilitiesReturns(types.ProtocolVersion(3).Capabilities())
+	publisher.GetPublishedTracksReturns([]types.PublishedTrack{track})
+
+	viewer := &typesfakes.FakeParticipant{}
+	viewer.IdentityReturns("viewer")
+	viewer.IDReturns("PA_viewer")
+	viewer.StateReturns(livekit.ParticipantInfo_ACTIVE)
+	viewer.CapabilitiesReturns(types.ProtocolVersion(3).Capabilities())
+	viewer.GetSubscribedTrackReturns(&typesfakes.FakeSubscribedTrack{})
+
+	// not subscribed to the track
+	other := &typesfakes.FakeParticipant{}
+	other.IdentityReturns("other")
+	other.IDReturns("PA_other")
+	other.StateReturns(livekit.ParticipantInfo_ACTIVE)
+	other.CapabilitiesReturns(types.ProtocolVersion(3).Capabilities())
+
+	r := &Room{
+		Room:       &livekit.Room{Name: "room", Sid: "RM_room"},
+		Logger:     logger.Logger(logger.GetLogger()),
+		roomConfig: &config.RoomConfig{StalledTrackTimeout: 10 * time.Second},
+		telemetry:  telemetry.NewTelemetryService(nil, nil, nil, nil),
+	}
+	participants := []types.Participant{publisher, viewer, other}
+
+	lastStall := func(t *testing.T, p *typesfakes.FakeParticipant) *trackStall {
+		var msg trackStallsMessage
+		require.NoError(t, json.Unmarshal(p.SendDataPacketArgsForCall(p.SendDataPacketCallCount()-1).GetUser().Payload, &msg))
+		require.Equal(t, trackStallsMessageType, msg.Type)
+		require.Len(t, msg.Tracks, 1)
+		return msg.Tracks[0]
+	}
+
+	now := time.Now()
+	activity := r.detectStalledTracks(participants, nil, now)
+	sent := r.sendTrackStalls(participants, activity, nil)
+	require.False(t, activity["TR_camera"].stalled)
+
+	// media keeps flowing
+	setPackets(200)
+	activity = r.detectStalledTracks(participants, activity, now.Add(5*time.Second))
+	sent = r.sendTrackStalls(participants, activity, sent)
+	activity = r.detectStalledTracks(participants, activity, now.Add(10*time.Second))
+	sent = r.sendTrackStalls(participants, activity, sent)
+	require.False(t, activity["TR_camera"].stalled)
+	require.Zero(t, viewer.SendDataPacketCallCount())
+
+	// nothing received since the last check
+	activity = r.detectStalledTracks(participants, activity, now.Add(15*time.Second))
+	sent = r.sendTrackStalls(participants, activity, sent)
+	require.True(t, activity["TR_camera"].stalled)
+	for _, p := range []*typesfakes.FakeParticipant{publisher, viewer} {
+		require.Equal(t, 1, p.SendDataPacketCallCount())
+		require.Equal(t, &trackStall{
+			ParticipantSid: "PA_publisher",
+			TrackSid:       "TR_camera",
+			Stalled:        true,
+			StalledAt:      now.Add(5 * time.Second).Unix(),
+		}, lastStall(t, p))
+	}
+	require.Zero(t, other.SendDataPacketCallCount())
+
+	// sent once
+	activity = r.detectStalledTracks(participants, activity, now.Add(20*time.Second))
+	sent = r.sendTrackStalls(participants, activity, sent)
+	require.Equal(t, 1, viewer.SendDataPacketCallCount())
+
+	// resumed
+	setPackets(300)
+	activity = r.detectStalledTracks(participants, activity, now.Add(25*time.Second))
+	r.sendTrackStalls(participants, activity, sent)
+	require.False(t, activity["TR_camera"].stalled)
+	require.Equal(t, 2, viewer.SendDataPacketCallCount())
+	require.Equal(t, &trackStall{ParticipantSid: "PA_publisher", TrackSid: "TR_camera"}, lastStall(t, viewer))
+
+	// muted tracks aren't expected to receive media
+	track.IsMutedReturns(true)
+	activity = r.detectStalledTracks(participants, activity, now.Add(60*time.Second))
+	require.Empty(t, activity)
+}
+
+func TestInactiveTracks(t *testing.T) {
+	newTrack := func(id string, kind livekit.TrackType) *typesfakes.FakePublishedTrack {
+		track := &typesfakes.FakePublishedTrack{}
+		track.IDReturns(id)
+		track.KindReturns(kind)
+		track.GetStatsReturns(&types.PublishedTrackStats{
+			TrackID: id,
+			Layers:  []sfu.LayerStats{{Layer: 0, Packets: 100}},
+		})
+		return track
+	}
+	audio := newTrack("TR_mic", livekit.TrackType_AUDIO)
+	video := newTrack("TR_camera", livekit.TrackType_VIDEO)
+
+	publisher := &typesfakes.FakeParticipant{}
+	publisher.IdentityReturns("publisher")
+	publisher.IDReturns("PA_publisher")
+	publisher.StateReturns(livekit.ParticipantInfo_ACTIVE)
+	publisher.CapabilitiesReturns(types.ProtocolVersion(3).Capabilities())
+	publisher.GetPublishedTracksReturns([]types.PublishedTrack{audio, video})
+
+	r := &Room{
+		Room:   &livekit.Room{Name: "room", Sid: "RM_room"},
+		Logger: logger.Logger(logger.GetLogger()),
+		roomConfig: &config.RoomConfig{
+			StalledTrackTimeout: 10 * time.Second,
+			AudioInactivity:     config.TrackInactivityConfig{StalledTimeout: 30 * time.Second},
+			VideoInactivity:     config.TrackInactivityConfig{CloseTimeout: 30 * time.Second},
+		},
+		telemetry: telemetry.NewTelemetryService(nil, nil, nil, nil),
+	}
+	participants := []types.Participant{publisher}
+
+	now := time.Now()
+	activity := r.detectStalledTracks(participants, nil, now)
+	sent := r.sendTrackStalls(participants, activity, nil)
+
+	// audio stalls later than video
+	activity = r.detectStalledTracks(participants, activity, now.Add(10*time.Second))
+	sent = r.sendTrackStalls(participants, activity, sent)
+	require.False(t, activity["TR_mic"].stalled)
+	require.True(t, activity["TR_camera"].stalled)
+	var msg trackStallsMessage
+	require.NoError(t, json.Unmarshal(publisher.SendDataPacketArgsForCall(0).GetUser().Payload, &msg))
+	require.Equal(t, []*trackStall{{
+		ParticipantSid: "PA_publisher",
+		TrackSid:       "TR_camera",
+		Stalled:        true,
+		StalledAt:      now.Unix(),
+		ClosesAt:       now.Add(30 * time.Second).Unix(),
+	}}, msg.Tracks)
+
+	// unpublished once stalled for the close timeout
+	activity = r.detectStalledTracks(participants, activity, now.Add(30*time.Second))
+	require.Equal(t, 1, publisher.UnpublishTrackCallCount())
+	require.Equal(t, "TR_camera", publisher.UnpublishTrackArgsForCall(0))
+	require.NotContains(t, activity, "TR_camera")
+	require.True(t, activity["TR_mic"].stalled)
+	// subscribers are told the stall is over
+	r.sendTrackStalls(participants, activity, sent)
+	msg = trackStallsMessage{}
+	require.NoError(t, json.Unmarshal(publisher.SendDataPacketArgsForCall(1).GetUser().Payload, &msg))
+	require.ElementsMatch(t, []*trackStall{{ParticipantSid: "PA_publisher", TrackSid: "TR_mic", Stalled: true, StalledAt: now.Unix()},
+		{ParticipantSid: "PA_publisher", TrackSid: "TR_camera"}}, msg.Tracks)
+
+	// audio has no close timeout
+	activity = r.detectStalledTracks(participants, activity, now.Add(time.Hour))
+	require.Equal(t, 1, publisher.UnpublishTrackCallCount())
+}
@@ -1,10 +1,14 @@
 package rtc
 
 import (
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/bep/debounce"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/telemetry"
 	livekit "github.com/livekit/protocol/proto"
 	"github.com/livekit/protocol/utils"
 	"github.com/pion/webrtc/v3"
@@ -16,19 +20,47 @@ const (
 
 type SubscribedTrack struct {
 	dt                *sfu.DownTrack
+	publishedTrack    types.PublishedTrack
 	publisherIdentity string
 	subMuted          utils.AtomicFlag
 	pubMuted          utils.AtomicFlag
+	// whether the down track is muted, by either side
+	muted utils.AtomicFlag
+	// unix nanos since the subscriber disabled the track, 0 while it's enabled
+	hiddenSince int64
+	// low power mode of the subscriber
+	lowPowerMode string
+	// highest layer the subscriber asked for, and the cap of the node while it's degraded
+	maxLayer int32
+	layerCap int32
 
-	debouncer func(func())
+	debouncer                func(func())
+	onMutedChanged           func(muted bool, reason string)
+	onSubscribedLayerChanged func()
 }
 
-func NewSubscribedTrack(publisherIdentity string, dt *sfu.DownTrack) *SubscribedTrack {
-	return &SubscribedTrack{
+func NewSubscribedTrack(publishedTrack types.PublishedTrack, publisherIdentity string, dt *sfu.DownTrack, lowPowerMode string) *SubscribedTrack {
+	t := &SubscribedTrack{
+		publishedTrack:    publishedTrack,
 		publisherIdentity: publisherIdentity,
 		dt:                dt,
+		lowPowerMode:      lowPowerMode,
+		maxLayer:          spatialLayerForQuality(livekit.VideoQuality_HIGH),
+		layerCap:          sfu.InvalidSpatialLayer,
 		debouncer:         debounce.New(subscriptionDebounceInterval),
 	}
+	if t.isLowPowerVideo() {
+		switch lowPowerMode {
+		case LowPowerModeLowestLayer:
+			dt.SetMaxSpatialLayer(0)
+		case LowPowerModeReducedFrameRate:
+			// only VP8 packets carry the temporal layer they belong to
+			if strings.EqualFold(dt.Codec().MimeType, webrtc.MimeTypeVP8) {
+				dt.SetMaxTemporalLayer(0)
+			}
+		}
+	}
+	return t
 }
 
 func (t *SubscribedTrack) ID() string {
@@ -47,6 +79,31 @@ func (t *SubscribedTrack) SubscribeLossPercentage() uint32 {
 	return FixedPointToPercent(t.DownTrack().CurrentMaxLossFraction())
 }
 
+func (t *SubscribedTrack) GetStats() *types.SubscribedTrackStats {
+	return &types.SubscribedTrackStats{
+		TrackID:           t.ID(),
+		PublisherIdentity: t.publisherIdentity,
+		Kind:              t.dt.Kind().String(),
+		QualityLabel:      t.QualityLabel(),
+		DownTrackStats:    t.dt.GetStats(),
+	}
+}
+
+// QualityLabel summarizes the quality the video track is received at, empty for audio or muted tracks
+func (t *SubscribedTrack) QualityLabel() string {
+	if t.dt.Kind() != webrtc.RTPCodecTypeVideo || t.IsMuted() || t.dt.IsForwarderMuted() {
+		return ""
+	}
+	return qualityLabelForLayer(t.publishedTrack.ToProto(), t.dt.CurrentSpatialLayer())
+}
+
+func (t *SubscribedTrack) LastVisible() time.Time {
+	if hiddenSince := atomic.LoadInt64(&t.hiddenSince); hiddenSince != 0 {
+		return time.Unix(0, hiddenSince)
+	}
+	return time.Now()
+}
+
 // has subscriber indicated it wants to mute this track
 func (t *SubscribedTrack) IsMuted() bool {
 	return t.subMuted.Get()
@@ -59,17 +116,105 @@ func (t *SubscribedTrack) SetPublisherMuted(muted bool) {
 
 func (t *SubscribedTrack) UpdateSubscriberSettings(enabled bool, quality livekit.VideoQuality) {
 	t.debouncer(func() {
-		t.subMuted.TrySet(!enabled)
+		// settings are only sent once the client has attached the track, so it's ready to receive
+		t.dt.MarkReady()
+		if enabled {
+			atomic.StoreInt64(&t.hiddenSince, 0)
+		} else {
+			atomic.CompareAndSwapInt64(&t.hiddenSince, 0, time.Now().UnixNano())
+		}
+		subMutedChanged := t.subMuted.TrySet(!enabled)
 		t.updateDownTrackMute()
-		if enabled && t.dt.Kind() == webrtc.RTPCodecTypeVideo {
-			t.dt.SetMaxSpatialLayer(spatialLayerForQuality(quality))
+		if enabled {
+			t.SetMaxQuality(quality)
+		} else if subMutedChanged && t.onSubscribedLayerChanged != nil {
+			t.onSubscribedLayerChanged()
 		}
 	})
 }
 
+// SetMaxQuality caps the simulcast layer forwarded to the subscriber at quality, so video shown as a
+// thumbnail isn't received at full resolution
+func (t *SubscribedTrack) SetMaxQuality(quality livekit.VideoQuality) {
+	if t.dt.Kind() != webrtc.RTPCodecTypeVideo {
+		return
+	}
+	atomic.StoreInt32(&t.maxLayer, spatialLayerForQuality(quality))
+	t.updateMaxSpatialLayer()
+}
+
+// CapSpatialLayer caps the simulcast layer forwarded to the subscriber at layer while the node is degraded,
+// below the quality it asked for. sfu.InvalidSpatialLayer lifts the cap. Screen shares aren't capped, their
+// text would become unreadable
+func (t *SubscribedTrack) CapSpatialLayer(layer int32) {
+	if t.dt.Kind() != webrtc.RTPCodecTypeVideo || t.publishedTrack.ToProto().GetSource() == livekit.TrackSource_SCREEN_SHARE {
+		return
+	}
+	if atomic.SwapInt32(&t.layerCap, layer) != layer {
+		t.updateMaxSpatialLayer()
+	}
+}
+
+func (t *SubscribedTrack) updateMaxSpatialLayer() {
+	t.dt.SetMaxSpatialLayer(t.maxSpatialLayer())
+	if t.onSubscribedLayerChanged != nil {
+		t.onSubscribedLayerChanged()
+	}
+}
+
+func (t *SubscribedTrack) maxSpatialLayer() int32 {
+	layer := atomic.LoadInt32(&t.maxLayer)
+	if layerCap := atomic.LoadInt32(&t.layerCap); layerCap != sfu.InvalidSpatialLayer && layerCap < layer {
+		layer = layerCap
+	}
+	if t.lowPowerMode == LowPowerModeLowestLayer {
+		layer = 0
+	}
+	return layer
+}
+
+// SubscribedLayer returns the highest layer of the video the subscriber receives while the publisher
+// isn't muted, sfu.InvalidSpatialLayer when it disabled the track
+func (t *SubscribedTrack) SubscribedLayer() int32 {
+	if t.subMuted.Get() || (t.isLowPowerVideo() && t.lowPowerMode == LowPowerModeAudioOnly) {
+		return sfu.InvalidSpatialLayer
+	}
+	return t.maxSpatialLayer()
+}
+
+// OnSubscribedLayerChanged is called when the layer the subscriber receives might have changed
+func (t *SubscribedTrack) OnSubscribedLayerChanged(fn func()) {
+	t.onSubscribedLayerChanged = fn
+}
+
+// OnMutedChanged is called when forwarding is paused or resumed by either side, with the reason
+// it was paused
+func (t *SubscribedTrack) OnMutedChanged(fn func(muted bool, reason string)) {
+	t.onMutedChanged = fn
+}
+
 func (t *SubscribedTrack) updateDownTrackMute() {
-	muted := t.subMuted.Get() || t.pubMuted.Get()
+	pubMuted := t.pubMuted.Get()
+	audioOnly := t.isLowPowerVideo() && t.lowPowerMode == LowPowerModeAudioOnly
+	muted := t.subMuted.Get() || pubMuted || audioOnly
 	t.dt.Mute(muted)
+
+	if t.muted.TrySet(muted) && t.onMutedChanged != nil {
+		reason := ""
+		if pubMuted {
+			reason = telemetry.SubscriptionPausedPublisherMuted
+		} else if audioOnly {
+			reason = telemetry.SubscriptionPausedLowPower
+		} else if muted {
+			reason = telemetry.SubscriptionPausedSubscriberDisabled
+		}
+		t.onMutedChanged(muted, reason)
+	}
+}
+
+// isLowPowerVideo returns true for video received by a subscriber in low power mode
+func (t *SubscribedTrack) isLowPowerVideo() bool {
+	return t.lowPowerMode != "" && t.dt.Kind() == webrtc.RTPCodecTypeVideo
 }
 
 func spatialLayerForQuality(quality livekit.VideoQuality) int32 {
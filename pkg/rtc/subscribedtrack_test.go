@@ -0,0 +1,75 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+func TestSubscribedTrackMaxQuality(t *testing.T) {
+	newSubscribedTrack := func(t *testing.T, mimeType string, lowPowerMode string) *SubscribedTrack {
+		dt, err := sfu.NewDownTrack(webrtc.RTPCodecCapability{MimeType: mimeType}, &stubTrackReceiver{trackID: "TR_1"}, nil, "sub", 500)
+		require.NoError(t, err)
+		return NewSubscribedTrack(&typesfakes.FakePublishedTrack{}, "pub", dt, lowPowerMode)
+	}
+
+	t.Run("quality caps the spatial layer", func(t *testing.T) {
+		st := newSubscribedTrack(t, webrtc.MimeTypeVP8, "")
+		require.Equal(t, int32(2), st.DownTrack().MaxSpatialLayer())
+
+		st.SetMaxQuality(livekit.VideoQuality_LOW)
+		require.Equal(t, int32(0), st.DownTrack().MaxSpatialLayer())
+		st.SetMaxQuality(livekit.VideoQuality_MEDIUM)
+		require.Equal(t, int32(1), st.DownTrack().MaxSpatialLayer())
+		st.SetMaxQuality(livekit.VideoQuality_HIGH)
+		require.Equal(t, int32(2), st.DownTrack().MaxSpatialLayer())
+	})
+
+	t.Run("degraded node caps the spatial layer", func(t *testing.T) {
+		st := newSubscribedTrack(t, webrtc.MimeTypeVP8, "")
+		st.CapSpatialLayer(1)
+		require.Equal(t, int32(1), st.DownTrack().MaxSpatialLayer())
+		// below the cap, the subscriber's quality applies
+		st.SetMaxQuality(livekit.VideoQuality_LOW)
+		require.Equal(t, int32(0), st.DownTrack().MaxSpatialLayer())
+		st.SetMaxQuality(livekit.VideoQuality_HIGH)
+		require.Equal(t, int32(1), st.DownTrack().MaxSpatialLayer())
+
+		st.CapSpatialLayer(sfu.InvalidSpatialLayer)
+		require.Equal(t, int32(2), st.DownTrack().MaxSpatialLayer())
+	})
+
+	t.Run("screen shares aren't capped", func(t *testing.T) {
+		dt, err := sfu.NewDownTrack(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}, &stubTrackReceiver{trackID: "TR_1"}, nil, "sub", 500)
+		require.NoError(t, err)
+		track := &typesfakes.FakePublishedTrack{}
+		track.ToProtoReturns(&livekit.TrackInfo{Source: livekit.TrackSource_SCREEN_SHARE})
+		st := NewSubscribedTrack(track, "pub", dt, "")
+		st.CapSpatialLayer(0)
+		require.Equal(t, int32(2), st.DownTrack().MaxSpatialLayer())
+	})
+
+	t.Run("low power mode keeps the lowest layer", func(t *testing.T) {
+		st := newSubscribedTrack(t, webrtc.MimeTypeVP8, LowPowerModeLowestLayer)
+		st.SetMaxQuality(livekit.VideoQuality_HIGH)
+		require.Equal(t, int32(0), st.DownTrack().MaxSpatialLayer())
+	})
+}
+
+func TestSubscribedTrackReady(t *testing.T) {
+	dt, err := sfu.NewDownTrack(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}, &stubTrackReceiver{trackID: "TR_1"}, nil, "sub", 500)
+	require.NoError(t, err)
+	dt.WaitForReady(time.Minute)
+	st := NewSubscribedTrack(&typesfakes.FakePublishedTrack{}, "pub", dt, "")
+	require.False(t, dt.IsReady())
+
+	// settings are the client's acknowledgement that it attached the track, even when hiding it
+	st.UpdateSubscriberSettings(false, livekit.VideoQuality_HIGH)
+	require.Eventually(t, dt.IsReady, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,109 @@
+package rtc
+
+import (
+	"sort"
+	"strings"
+
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// SubscriptionBlock keeps a subscriber from receiving the tracks of a publisher, both by identity
+type SubscriptionBlock struct {
+	Subscriber string `json:"subscriber"`
+	Publisher  string `json:"publisher"`
+}
+
+// SetSubscriptionBlocked stops the participant with the subscriber identity from receiving the
+// tracks of publisher, or lets it receive them again. Blocks last as long as the room, also when
+// either participant leaves and joins again. Existing subscriptions are removed, unblocked
+// participants are subscribed again when they auto subscribe
+func (r *Room) SetSubscriptionBlocked(subscriber, publisher string, blocked bool) {
+	r.blocksLock.Lock()
+	if blocked {
+		if r.blocks == nil {
+			r.blocks = make(map[string]map[string]bool)
+		}
+		if r.blocks[subscriber] == nil {
+			r.blocks[subscriber] = make(map[string]bool)
+		}
+		r.blocks[subscriber][publisher] = true
+	} else {
+		delete(r.blocks[subscriber], publisher)
+		if len(r.blocks[subscriber]) == 0 {
+			delete(r.blocks, subscriber)
+		}
+	}
+	r.blocksLock.Unlock()
+
+	sub := r.GetParticipant(subscriber)
+	pub := r.GetParticipant(publisher)
+	if sub == nil || pub == nil {
+		return
+	}
+	if blocked {
+		r.Logger.Infow("blocking subscription", "subscriber", subscriber, "publisher", publisher)
+		pub.RemoveSubscriber(sub.ID())
+		return
+	}
+
+	r.Logger.Infow("unblocking subscription", "subscriber", subscriber, "publisher", publisher)
+	r.lock.RLock()
+	shouldSubscribe := r.autoSubscribe(sub)
+	r.lock.RUnlock()
+	if !shouldSubscribe || !sub.CanSubscribeTo(publisher) || sub.State() != livekit.ParticipantInfo_ACTIVE {
+		return
+	}
+	if _, err := pub.AddSubscriber(sub); err != nil {
+		r.Logger.Errorw("could not subscribe to participant", err,
+			"participants", []string{publisher, subscriber},
+			"pIDs", []string{pub.ID(), sub.ID()})
+	}
+}
+
+// IsSubscriptionBlocked returns true when the participant with the subscriber identity may not
+// receive the tracks of publisher
+func (r *Room) IsSubscriptionBlocked(subscriber, publisher string) bool {
+	r.blocksLock.RLock()
+	defer r.blocksLock.RUnlock()
+	return r.blocks[subscriber][publisher]
+}
+
+// SubscriptionBlocks lists the blocked subscriptions, ordered by subscriber and publisher
+func (r *Room) SubscriptionBlocks() []*SubscriptionBlock {
+	r.blocksLock.RLock()
+	blocks := make([]*SubscriptionBlock, 0, len(r.blocks))
+	for subscriber, publishers := range r.blocks {
+		for publisher := range publishers {
+			blocks = append(blocks, &SubscriptionBlock{Subscriber: subscriber, Publisher: publisher})
+		}
+	}
+	r.blocksLock.RUnlock()
+
+	sort.Slice(blocks, func(i, j int) bool {
+		if blocks[i].Subscriber != blocks[j].Subscriber {
+			return blocks[i].Subscriber < blocks[j].Subscriber
+		}
+		return blocks[i].Publisher < blocks[j].Publisher
+	})
+	return blocks
+}
+
+func (r *Room) isSubscriptionBlocked(subscriber, publisher types.Participant) bool {
+	return r.IsSubscriptionBlocked(subscriber.Identity(), publisher.Identity())
+}
+
+// isIdentityAllowed returns true when identity is one of allowed, or allowed is empty. Entries ending
+// with * allow the identities they're a prefix of
+func isIdentityAllowed(allowed []string, identity string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if a == identity || (strings.HasSuffix(a, "*") && strings.HasPrefix(identity, strings.TrimSuffix(a, "*"))) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package rtc_test
+
+import (
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestSubscriptionBlocks(t *testing.T) {
+	setup := func() (*rtc.Room, *typesfakes.FakeParticipant, *typesfakes.FakeParticipant, *typesfakes.FakePublishedTrack) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		sub := rm.GetParticipant("p0").(*typesfakes.FakeParticipant)
+		pub := rm.GetParticipant("p1").(*typesfakes.FakeParticipant)
+		video := newMockTrack(livekit.TrackType_VIDEO, "webcam")
+		pub.GetPublishedTracksReturns([]types.PublishedTrack{video})
+		return rm, sub, pub, video
+	}
+
+	t.Run("blocking removes subscriptions, unblocking restores them", func(t *testing.T) {
+		rm, sub, pub, _ := setup()
+
+		rm.SetSubscriptionBlocked("p0", "p1", true)
+		require.True(t, rm.IsSubscriptionBlocked("p0", "p1"))
+		require.False(t, rm.IsSubscriptionBlocked("p1", "p0"))
+		require.Equal(t, 1, pub.RemoveSubscriberCallCount())
+		require.Equal(t, sub.ID(), pub.RemoveSubscriberArgsForCall(0))
+		require.Equal(t, []*rtc.SubscriptionBlock{{Subscriber: "p0", Publisher: "p1"}}, rm.SubscriptionBlocks())
+
+		subscribed := pub.AddSubscriberCallCount()
+		rm.SetSubscriptionBlocked("p0", "p1", false)
+		require.False(t, rm.IsSubscriptionBlocked("p0", "p1"))
+		require.Empty(t, rm.SubscriptionBlocks())
+		require.Equal(t, subscribed+1, pub.AddSubscriberCallCount())
+		require.Equal(t, sub, pub.AddSubscriberArgsForCall(subscribed))
+	})
+
+	t.Run("blocked subscriptions are refused", func(t *testing.T) {
+		rm, sub, _, video := setup()
+		rm.SetSubscriptionBlocked("p0", "p1", true)
+
+		err := rm.UpdateSubscriptions(sub, []string{video.ID()}, true)
+		require.Equal(t, rtc.ErrSubscriptionBlocked, err)
+		require.Zero(t, video.AddSubscriberCallCount())
+		require.Equal(t, 1, sub.SendSubscriptionErrorCallCount())
+		sid, err := sub.SendSubscriptionErrorArgsForCall(0)
+		require.Equal(t, video.ID(), sid)
+		require.Equal(t, rtc.ErrSubscriptionBlocked, err)
+	})
+
+	t.Run("blocked participants aren't subscribed to new tracks", func(t *testing.T) {
+		rm, _, pub, _ := setup()
+		rm.SetSubscriptionBlocked("p0", "p1", true)
+
+		track := newMockTrack(livekit.TrackType_AUDIO, "mic")
+		trackCB := pub.OnTrackPublishedArgsForCall(0)
+		trackCB(pub, track)
+		require.Zero(t, track.AddSubscriberCallCount())
+	})
+
+	t.Run("participants aren't subscribed to publishers their token doesn't allow", func(t *testing.T) {
+		rm, sub, pub, _ := setup()
+		sub.CanSubscribeToStub = func(identity string) bool {
+			return identity != "p1"
+		}
+
+		track := newMockTrack(livekit.TrackType_AUDIO, "mic")
+		trackCB := pub.OnTrackPublishedArgsForCall(0)
+		trackCB(pub, track)
+		require.Zero(t, track.AddSubscriberCallCount())
+
+		// nor once a block is lifted
+		rm.SetSubscriptionBlocked("p0", "p1", true)
+		subscribed := pub.AddSubscriberCallCount()
+		rm.SetSubscriptionBlocked("p0", "p1", false)
+		require.Equal(t, subscribed, pub.AddSubscriberCallCount())
+	})
+
+	t.Run("blocks apply to participants that join later", func(t *testing.T) {
+		rm, _, pub, _ := setup()
+		rm.SetSubscriptionBlocked("late", "p1", true)
+
+		p := newMockParticipant("late", types.DefaultProtocol, false)
+		require.NoError(t, rm.Join(p, &rtc.ParticipantOptions{AutoSubscribe: true}, iceServersForRoom))
+		subscribed := pub.AddSubscriberCallCount()
+		p.StateReturns(livekit.ParticipantInfo_ACTIVE)
+		p.OnStateChangeArgsForCall(0)(p, livekit.ParticipantInfo_JOINED)
+
+		require.Equal(t, subscribed, pub.AddSubscriberCallCount())
+		other := rm.GetParticipant("p0").(*typesfakes.FakeParticipant)
+		require.Equal(t, p, other.AddSubscriberArgsForCall(other.AddSubscriberCallCount()-1))
+	})
+}
@@ -0,0 +1,51 @@
+package rtc
+
+import (
+	"sort"
+
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// SetSubscriptions makes the participant receive exactly the given tracks, video at most at the
+// quality of each. It's unsubscribed from the others, and no longer subscribed to tracks
+// automatically, so the layout the application chose for it is kept as tracks are published.
+// Tracks it couldn't be subscribed to are reported to it, the first error is returned
+func (r *Room) SetSubscriptions(participant types.Participant, tracks map[string]livekit.VideoQuality) error {
+	r.lock.Lock()
+	if opts := r.participantOpts[participant.Identity()]; opts != nil {
+		opts.AutoSubscribe = false
+	} else {
+		r.participantOpts[participant.Identity()] = &ParticipantOptions{}
+	}
+	r.lock.Unlock()
+
+	var unsubscribe []string
+	for _, st := range participant.GetSubscribedTracks() {
+		if _, ok := tracks[st.ID()]; !ok {
+			unsubscribe = append(unsubscribe, st.ID())
+		}
+	}
+	if len(unsubscribe) != 0 {
+		if err := r.UpdateSubscriptions(participant, unsubscribe, false); err != nil {
+			return err
+		}
+	}
+
+	var subscribe []string
+	for sid, quality := range tracks {
+		// kept for when it's subscribed to again
+		participant.SetSubscriberQuality(sid, quality)
+		if st := participant.GetSubscribedTrack(sid); st != nil {
+			st.SetMaxQuality(quality)
+		} else {
+			subscribe = append(subscribe, sid)
+		}
+	}
+	if len(subscribe) == 0 {
+		return nil
+	}
+	sort.Strings(subscribe)
+	return r.UpdateSubscriptions(participant, subscribe, true)
+}
@@ -0,0 +1,52 @@
+package rtc_test
+
+import (
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestSetSubscriptions(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+	sub := rm.GetParticipant("p0").(*typesfakes.FakeParticipant)
+	pub := rm.GetParticipant("p1").(*typesfakes.FakeParticipant)
+	webcam := newMockTrack(livekit.TrackType_VIDEO, "webcam")
+	screen := newMockTrack(livekit.TrackType_VIDEO, "screen")
+	pub.GetPublishedTracksReturns([]types.PublishedTrack{webcam, screen})
+
+	// subscribed to the webcam, which stays at a lower quality, and not to the screen share
+	subscribed := &typesfakes.FakeSubscribedTrack{}
+	subscribed.IDReturns(webcam.ID())
+	sub.GetSubscribedTracksReturns([]types.SubscribedTrack{subscribed})
+	sub.GetSubscribedTrackStub = func(sid string) types.SubscribedTrack {
+		if sid == webcam.ID() {
+			return subscribed
+		}
+		return nil
+	}
+
+	err := rm.SetSubscriptions(sub, map[string]livekit.VideoQuality{
+		webcam.ID(): livekit.VideoQuality_LOW,
+		screen.ID(): livekit.VideoQuality_HIGH,
+	})
+	require.NoError(t, err)
+	require.Zero(t, webcam.AddSubscriberCallCount())
+	require.Equal(t, 1, subscribed.SetMaxQualityCallCount())
+	require.Equal(t, livekit.VideoQuality_LOW, subscribed.SetMaxQualityArgsForCall(0))
+	require.Equal(t, 1, screen.AddSubscriberCallCount())
+	require.Equal(t, 2, sub.SetSubscriberQualityCallCount())
+
+	// tracks left out are unsubscribed from
+	require.NoError(t, rm.SetSubscriptions(sub, map[string]livekit.VideoQuality{screen.ID(): livekit.VideoQuality_MEDIUM}))
+	require.Equal(t, 1, webcam.RemoveSubscriberCallCount())
+	require.Equal(t, sub.ID(), webcam.RemoveSubscriberArgsForCall(0))
+
+	// and tracks published later aren't subscribed to
+	mic := newMockTrack(livekit.TrackType_AUDIO, "mic")
+	pub.OnTrackPublishedArgsForCall(0)(pub, mic)
+	require.Zero(t, mic.AddSubscriberCallCount())
+}
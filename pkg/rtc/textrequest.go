@@ -0,0 +1,170 @@
+package rtc
+
+import (
+	livekit "github.com/livekit/protocol/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// textRequestField carries the requests the signal protocol has no message for, sent by clients as
+// JSON text messages, to the node hosting the room, and their responses back. Node messages carry
+// them too, for requests made through the admin API
+const textRequestField protowire.Number = 1008
+
+// NewSignalTextRequest returns a signal request routing the text request of a participant to the
+// node hosting the room. value is the JSON encoded request
+func NewSignalTextRequest(key string, value []byte) *livekit.SignalRequest {
+	req := &livekit.SignalRequest{}
+	setTextField(req.ProtoReflect(), key, value)
+	return req
+}
+
+// SignalRequestText returns the key and value of the text request a signal request routes, an
+// empty key when it's another request
+func SignalRequestText(req *livekit.SignalRequest) (string, []byte) {
+	return textField(req.ProtoReflect())
+}
+
+// NewSignalTextResponse returns a signal response sending a text message to a participant
+func NewSignalTextResponse(key string, value []byte) *livekit.SignalResponse {
+	msg := &livekit.SignalResponse{}
+	setTextField(msg.ProtoReflect(), key, value)
+	return msg
+}
+
+// SignalResponseText returns the key and value of the text message a signal response sends, an
+// empty key when it's another response
+func SignalResponseText(msg *livekit.SignalResponse) (string, []byte) {
+	return textField(msg.ProtoReflect())
+}
+
+// NewRTCNodeTextMessage returns a node message routing a text request made on behalf of a
+// participant to the node hosting the room
+func NewRTCNodeTextMessage(key string, value []byte) *livekit.RTCNodeMessage {
+	msg := &livekit.RTCNodeMessage{}
+	setTextField(msg.ProtoReflect(), key, value)
+	return msg
+}
+
+// RTCNodeMessageText returns the key and value of the text request a node message routes, an empty
+// key when it's another message
+func RTCNodeMessageText(msg *livekit.RTCNodeMessage) (string, []byte) {
+	return textField(msg.ProtoReflect())
+}
+
+func setTextField(m protoreflect.Message, key string, value []byte) {
+	b := protowire.AppendTag(nil, 1, protowire.BytesType)
+	b = protowire.AppendString(b, key)
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendBytes(b, value)
+	setUnknownField(m, textRequestField, bytesField(textRequestField, b))
+}
+
+func textField(m protoreflect.Message) (string, []byte) {
+	b := unknownBytesField(m.GetUnknown(), textRequestField)
+	if b == nil {
+		return "", nil
+	}
+	var key string
+	var value []byte
+	ok := decodeFields(b, func(num protowire.Number, s string, _ uint64) {
+		switch num {
+		case 1:
+			key = s
+		case 2:
+			value = []byte(s)
+		}
+	})
+	if !ok {
+		return "", nil
+	}
+	return key, value
+}
+
+func bytesField(num protowire.Number, data []byte) []byte {
+	b := protowire.AppendTag(nil, num, protowire.BytesType)
+	return protowire.AppendBytes(b, data)
+}
+
+// unknownBytesField returns the value of the length-delimited unknown field num, nil when there's
+// none
+func unknownBytesField(b protoreflect.RawFields, num protowire.Number) []byte {
+	for len(b) > 0 {
+		fieldNum, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil
+		}
+		b = b[n:]
+		if fieldNum == num && typ == protowire.BytesType {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return nil
+			}
+			return v
+		}
+		n = protowire.ConsumeFieldValue(fieldNum, typ, b)
+		if n < 0 {
+			return nil
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// setUnknownField replaces the unknown field num of m with field, encoded with its tag. The other
+// unknown fields are kept
+func setUnknownField(m protoreflect.Message, num protowire.Number, field []byte) {
+	var kept protoreflect.RawFields
+	b := m.GetUnknown()
+	for len(b) > 0 {
+		fieldNum, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			break
+		}
+		size := protowire.ConsumeFieldValue(fieldNum, typ, b[n:])
+		if size < 0 {
+			break
+		}
+		n += size
+		if fieldNum != num {
+			kept = append(kept, b[:n]...)
+		}
+		b = b[n:]
+	}
+	m.SetUnknown(append(kept, field...))
+}
+
+// decodeFields calls fn with the string and varint fields of an encoded message, false when it's
+// malformed
+func decodeFields(b []byte, fn func(num protowire.Number, s string, v uint64)) bool {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return false
+		}
+		b = b[n:]
+		switch typ {
+		case protowire.BytesType:
+			s, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return false
+			}
+			fn(num, s, 0)
+			b = b[n:]
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return false
+			}
+			fn(num, "", v)
+			b = b[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return false
+			}
+			b = b[n:]
+		}
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package rtc
+
+import (
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTextRequestMessages(t *testing.T) {
+	value := []byte(`{"request_id": "1", "track_sid": "TR_a"}`)
+
+	req := NewSignalTextRequest("unpublish", value)
+	require.Nil(t, req.Message)
+	data, err := proto.Marshal(req)
+	require.NoError(t, err)
+	receivedReq := &livekit.SignalRequest{}
+	require.NoError(t, proto.Unmarshal(data, receivedReq))
+	key, receivedValue := SignalRequestText(receivedReq)
+	require.Equal(t, "unpublish", key)
+	require.Equal(t, value, receivedValue)
+
+	res := NewSignalTextResponse("unpublish_response", value)
+	data, err = proto.Marshal(res)
+	require.NoError(t, err)
+	receivedRes := &livekit.SignalResponse{}
+	require.NoError(t, proto.Unmarshal(data, receivedRes))
+	key, receivedValue = SignalResponseText(receivedRes)
+	require.Equal(t, "unpublish_response", key)
+	require.Equal(t, value, receivedValue)
+
+	msg := NewRTCNodeTextMessage("unpublish", value)
+	msg.ParticipantKey = "room|bob"
+	data, err = proto.Marshal(msg)
+	require.NoError(t, err)
+	receivedMsg := &livekit.RTCNodeMessage{}
+	require.NoError(t, proto.Unmarshal(data, receivedMsg))
+	require.Nil(t, receivedMsg.Message)
+	key, receivedValue = RTCNodeMessageText(receivedMsg)
+	require.Equal(t, "unpublish", key)
+	require.Equal(t, value, receivedValue)
+
+	key, _ = SignalRequestText(&livekit.SignalRequest{})
+	require.Empty(t, key)
+	key, _ = SignalResponseText(&livekit.SignalResponse{})
+	require.Empty(t, key)
+}
@@ -0,0 +1,104 @@
+package rtc
+
+import (
+	livekit "github.com/livekit/protocol/proto"
+)
+
+// reasons a track couldn't be published or subscribed to, for clients to act on
+const (
+	trackErrorPermissionDenied  = "permission_denied"
+	trackErrorSubscriptionLimit = "subscription_limit"
+	trackErrorTrackNotFound     = "track_not_found"
+	trackErrorInternal          = "internal"
+)
+
+// errors held while the data channel isn't open, the ones past it are dropped
+const maxHeldTrackErrors = 16
+
+// trackPublishFailedMessage tells a participant a track it asked to publish was rejected
+type trackPublishFailedMessage struct {
+	Type    string `json:"type"`
+	Cid     string `json:"cid"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// subscriptionErrorMessage tells a participant it couldn't be subscribed to a track
+type subscriptionErrorMessage struct {
+	Type     string `json:"type"`
+	TrackSid string `json:"track_sid"`
+	Reason   string `json:"reason"`
+	Message  string `json:"message"`
+}
+
+func trackErrorReason(err error) string {
+	switch err {
+	case ErrCannotPublish, ErrCannotPublishSource, ErrCannotSubscribe, ErrCannotSubscribeTo, ErrSubscriptionBlocked, ErrPermissionDenied:
+		return trackErrorPermissionDenied
+	case ErrSubscriptionLimit:
+		return trackErrorSubscriptionLimit
+	case ErrTrackNotFound, ErrPendingTrackNotFound:
+		return trackErrorTrackNotFound
+	default:
+		return trackErrorInternal
+	}
+}
+
+func (p *ParticipantImpl) sendTrackPublishFailed(cid string, err error) {
+	p.sendTrackError(&trackPublishFailedMessage{
+		Type:    trackPublishFailedMessageType,
+		Cid:     cid,
+		Reason:  trackErrorReason(err),
+		Message: err.Error(),
+	})
+}
+
+func (p *ParticipantImpl) SendSubscriptionError(trackSid string, err error) {
+	p.sendTrackError(&subscriptionErrorMessage{
+		Type:     subscriptionErrorMessageType,
+		TrackSid: trackSid,
+		Reason:   trackErrorReason(err),
+		Message:  err.Error(),
+	})
+}
+
+func (p *ParticipantImpl) sendTrackError(msg interface{}) {
+	if !p.Capabilities().DataPackets {
+		return
+	}
+	dp, err := newServerMessagePacket(msg)
+	if err != nil {
+		p.params.Logger.Errorw("could not encode track error", err)
+		return
+	}
+	p.sendTrackErrorPacket(dp)
+}
+
+// sendTrackErrorPacket sends a track error, or holds it until the data channel opens. Clients
+// commonly publish and subscribe before they're connected
+func (p *ParticipantImpl) sendTrackErrorPacket(dp *livekit.DataPacket) {
+	err := p.SendDataPacket(dp)
+	if err == ErrDataChannelUnavailable && p.State() != livekit.ParticipantInfo_DISCONNECTED {
+		p.lock.Lock()
+		if len(p.heldTrackErrors) < maxHeldTrackErrors {
+			p.heldTrackErrors = append(p.heldTrackErrors, dp)
+		}
+		p.lock.Unlock()
+		return
+	}
+	if err != nil {
+		p.params.Logger.Debugw("could not send track error", "error", err)
+	}
+}
+
+// flushTrackErrors sends the track errors held until the data channel opened
+func (p *ParticipantImpl) flushTrackErrors() {
+	p.lock.Lock()
+	held := p.heldTrackErrors
+	p.heldTrackErrors = nil
+	p.lock.Unlock()
+
+	for _, dp := range held {
+		p.sendTrackErrorPacket(dp)
+	}
+}
@@ -0,0 +1,96 @@
+package rtc
+
+import (
+	"context"
+
+	livekit "github.com/livekit/protocol/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+// trackMetadataField carries the metadata of a track in its track info, the protocol has no field
+// for it
+const trackMetadataField protowire.Number = 1006
+
+// max bytes of track metadata
+const maxTrackMetadataLength = 4096
+
+// TrackMetadata returns the metadata in a track info, empty when it has none
+func TrackMetadata(info *livekit.TrackInfo) string {
+	return string(unknownBytesField(info.ProtoReflect().GetUnknown(), trackMetadataField))
+}
+
+func setTrackMetadata(info *livekit.TrackInfo, metadata string) {
+	var field []byte
+	if metadata != "" {
+		field = bytesField(trackMetadataField, []byte(metadata))
+	}
+	setUnknownField(info.ProtoReflect(), trackMetadataField, field)
+}
+
+// Metadata returns the metadata the publisher attached to the track
+func (t *MediaTrack) Metadata() string {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return TrackMetadata(t.params.TrackInfo)
+}
+
+// SetMetadata attaches metadata to the track, empty to remove it
+func (t *MediaTrack) SetMetadata(metadata string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	setTrackMetadata(t.params.TrackInfo, metadata)
+}
+
+// SetTrackMetadata attaches metadata to a track of the participant. Tracks that wait for their media
+// keep it once published. The room and webhooks are told when it changed
+func (p *ParticipantImpl) SetTrackMetadata(trackSid string, metadata string) error {
+	if len(metadata) > maxTrackMetadataLength {
+		return ErrInvalidTrackMetadata
+	}
+
+	var info *livekit.TrackInfo
+	p.lock.Lock()
+	track := p.publishedTracks[trackSid]
+	if track == nil {
+		for _, ti := range p.pendingTracks {
+			if ti.Sid == trackSid {
+				info = ti
+			}
+		}
+	}
+	changed := false
+	if info != nil {
+		changed = TrackMetadata(info) != metadata
+		setTrackMetadata(info, metadata)
+	}
+	p.lock.Unlock()
+
+	if track != nil {
+		changed = track.Metadata() != metadata
+		track.SetMetadata(metadata)
+		info = track.ToProto()
+	}
+	if info == nil {
+		return ErrTrackNotFound
+	}
+	if !changed {
+		return nil
+	}
+
+	p.params.Logger.Debugw("track metadata updated", "track", trackSid)
+	p.params.Telemetry.TrackMetadataUpdated(context.Background(), &telemetry.TrackMetadataEvent{
+		ParticipantSid:      p.ID(),
+		ParticipantIdentity: p.Identity(),
+		TrackSid:            trackSid,
+		TrackType:           info.Type.String(),
+		TrackSource:         info.Source.String(),
+		Metadata:            metadata,
+	})
+	// pending tracks aren't sent to others yet
+	if track != nil && p.onTrackUpdated != nil {
+		p.onTrackUpdated(p, track)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package rtc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/livekit/protocol/logger"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+func TestTrackMetadata(t *testing.T) {
+	info := &livekit.TrackInfo{Sid: "TR_screen", Name: "screen"}
+	require.Empty(t, TrackMetadata(info))
+	setTrackMetadata(info, "Slides - Editor")
+	setTrackMetadata(info, `{"facing": "user"}`)
+	require.Equal(t, `{"facing": "user"}`, TrackMetadata(info))
+
+	// it's kept by protobuf clients
+	data, err := proto.Marshal(info)
+	require.NoError(t, err)
+	decoded := &livekit.TrackInfo{}
+	require.NoError(t, proto.Unmarshal(data, decoded))
+	require.Equal(t, `{"facing": "user"}`, TrackMetadata(decoded))
+
+	setTrackMetadata(info, "")
+	require.Empty(t, TrackMetadata(info))
+	require.Empty(t, info.ProtoReflect().GetUnknown())
+}
+
+func TestSetTrackMetadata(t *testing.T) {
+	newParticipant := func() (*ParticipantImpl, *int) {
+		p := newParticipantForTest("test")
+		p.params.Telemetry = telemetry.NewTelemetryService(nil, nil, nil, nil)
+		p.params.Logger = logger.Logger(logger.GetLogger())
+		updates := 0
+		p.OnTrackUpdated(func(types.Participant, types.PublishedTrack) {
+			updates++
+		})
+		return p, &updates
+	}
+
+	t.Run("published tracks", func(t *testing.T) {
+		p, updates := newParticipant()
+		track := &typesfakes.FakePublishedTrack{}
+		track.IDReturns("TR_screen")
+		info := &livekit.TrackInfo{Sid: "TR_screen", Source: livekit.TrackSource_SCREEN_SHARE}
+		track.ToProtoReturns(info)
+		track.MetadataCalls(func() string {
+			return TrackMetadata(info)
+		})
+		track.SetMetadataCalls(func(metadata string) {
+			setTrackMetadata(info, metadata)
+		})
+		p.publishedTracks["TR_screen"] = track
+
+		require.NoError(t, p.SetTrackMetadata("TR_screen", "Slides"))
+		require.Equal(t, "Slides", TrackMetadata(info))
+		require.Equal(t, 1, *updates)
+
+		// unchanged metadata isn't an update
+		require.NoError(t, p.SetTrackMetadata("TR_screen", "Slides"))
+		require.Equal(t, 1, *updates)
+
+		require.NoError(t, p.SetTrackMetadata("TR_screen", ""))
+		require.Empty(t, TrackMetadata(info))
+		require.Equal(t, 2, *updates)
+	})
+
+	t.Run("pending tracks keep it", func(t *testing.T) {
+		p, updates := newParticipant()
+		p.AddTrack(&livekit.AddTrackRequest{Cid: "camera", Type: livekit.TrackType_VIDEO})
+		p.lock.RLock()
+		info := p.pendingTracks["camera"]
+		p.lock.RUnlock()
+		require.NotNil(t, info)
+
+		require.NoError(t, p.SetTrackMetadata(info.Sid, "back"))
+		require.Equal(t, "back", TrackMetadata(info))
+		// others don't know about it yet
+		require.Zero(t, *updates)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		p, _ := newParticipant()
+		require.Equal(t, ErrTrackNotFound, p.SetTrackMetadata("TR_mic", "muted"))
+		require.Equal(t, ErrInvalidTrackMetadata, p.SetTrackMetadata("TR_mic", strings.Repeat("a", maxTrackMetadataLength+1)))
+	})
+}
@@ -0,0 +1,89 @@
+package rtc
+
+import (
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	// previews are sent again when a track's bitrate changed by more than this fraction
+	trackPreviewBitrateChange = 0.25
+	// or its frame rate by more than this many frames per second
+	trackPreviewFrameRateChange = 2
+)
+
+// trackPreview describes what subscribing to a published track would currently take, so that
+// clients can decide whether to subscribe before doing so. Width, height and frame rate are of the
+// highest layer being published, bitrate is that of the layer. Zero when unknown
+type trackPreview struct {
+	ParticipantSid string `json:"participant_sid"`
+	TrackSid       string `json:"track_sid"`
+	Width          uint32 `json:"width,omitempty"`
+	Height         uint32 `json:"height,omitempty"`
+	FrameRate      uint32 `json:"frame_rate,omitempty"`
+	Bitrate        int64  `json:"bitrate"`
+}
+
+// trackPreviewsMessage tells participants the previews of tracks published by others
+type trackPreviewsMessage struct {
+	Type   string          `json:"type"`
+	Tracks []*trackPreview `json:"tracks"`
+}
+
+func newTrackPreviewsPacket(previews []*trackPreview) (*livekit.DataPacket, error) {
+	return newServerMessagePacket(&trackPreviewsMessage{
+		Type:   trackPreviewsMessageType,
+		Tracks: previews,
+	})
+}
+
+// publishedTrackPreview returns the preview of track from the stats of its buffers
+func publishedTrackPreview(p types.Participant, track types.PublishedTrack) *trackPreview {
+	preview := &trackPreview{
+		ParticipantSid: p.ID(),
+		TrackSid:       track.ID(),
+	}
+	if info := track.ToProto(); info != nil {
+		// dimensions announced by the publisher, until a key frame is parsed
+		preview.Width, preview.Height = info.Width, info.Height
+	}
+
+	stats := track.GetStats()
+	if stats == nil {
+		return preview
+	}
+	// the highest layer being received
+	top := -1
+	layers := stats.Layers
+	for i, layer := range layers {
+		if layer.Bitrate != 0 && (top < 0 || layer.Layer > layers[top].Layer) {
+			top = i
+		}
+	}
+	if top < 0 {
+		return preview
+	}
+	layer := layers[top]
+	preview.Bitrate = layer.Bitrate
+	preview.FrameRate = layer.FrameRate
+	if layer.Width != 0 && layer.Height != 0 {
+		preview.Width, preview.Height = layer.Width, layer.Height
+	}
+	return preview
+}
+
+// changed returns true when a preview differs enough from prev to be sent again
+func (t *trackPreview) changed(prev *trackPreview) bool {
+	if prev == nil || t.Width != prev.Width || t.Height != prev.Height {
+		return true
+	}
+	if diff := int64(t.FrameRate) - int64(prev.FrameRate); diff > trackPreviewFrameRateChange || -diff > trackPreviewFrameRateChange {
+		return true
+	}
+	if prev.Bitrate == 0 {
+		return t.Bitrate != 0
+	}
+	change := float64(t.Bitrate-prev.Bitrate) / float64(prev.Bitrate)
+	return change > trackPreviewBitrateChange || -change > trackPreviewBitrateChange
+}
@@ -0,0 +1,77 @@
+package rtc
+
+import (
+	"encoding/json"
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+func TestSendTrackPreviews(t *testing.T) {
+	newParticipant := func(identity string) *typesfakes.FakeParticipant {
+		p := &typesfakes.FakeParticipant{}
+		p.IdentityReturns(identity)
+		p.IDReturns("PA_" + identity)
+		p.StateReturns(livekit.ParticipantInfo_ACTIVE)
+		p.CapabilitiesReturns(types.ProtocolVersion(3).Capabilities())
+		return p
+	}
+	stats := &types.PublishedTrackStats{
+		Layers: []sfu.LayerStats{
+			{Layer: 0, Bitrate: 150000, FrameRate: 15, Width: 320, Height: 180},
+			{Layer: 2, Bitrate: 2500000, FrameRate: 30, Width: 3840, Height: 2160},
+			// not being published
+			{Layer: 1},
+		},
+	}
+	track := &typesfakes.FakePublishedTrack{}
+	track.IDReturns("TR_screen")
+	track.ToProtoReturns(&livekit.TrackInfo{Sid: "TR_screen", Width: 1920, Height: 1080})
+	track.GetStatsReturns(stats)
+
+	publisher := newParticipant("publisher")
+	publisher.GetPublishedTracksReturns([]types.PublishedTrack{track})
+	mobile := newParticipant("mobile")
+	r := &Room{
+		participants: map[string]types.Participant{
+			"publisher": publisher,
+			"mobile":    mobile,
+		},
+	}
+	participants := r.GetParticipants()
+
+	sent := r.sendTrackPreviews(participants, nil)
+	require.Zero(t, publisher.SendDataPacketCallCount())
+	require.Equal(t, 1, mobile.SendDataPacketCallCount())
+
+	var msg trackPreviewsMessage
+	require.NoError(t, json.Unmarshal(mobile.SendDataPacketArgsForCall(0).GetUser().Payload, &msg))
+	require.Equal(t, trackPreviewsMessageType, msg.Type)
+	require.Equal(t, []*trackPreview{{
+		ParticipantSid: "PA_publisher",
+		TrackSid:       "TR_screen",
+		Width:          3840,
+		Height:         2160,
+		FrameRate:      30,
+		Bitrate:        2500000,
+	}}, msg.Tracks)
+
+	// small changes aren't sent
+	stats.Layers[1].Bitrate = 2400000
+	stats.Layers[1].FrameRate = 29
+	sent = r.sendTrackPreviews(participants, sent)
+	require.Equal(t, 1, mobile.SendDataPacketCallCount())
+
+	// the top layer stopping is
+	stats.Layers[1].Bitrate = 0
+	r.sendTrackPreviews(participants, sent)
+	require.Equal(t, 2, mobile.SendDataPacketCallCount())
+	require.NoError(t, json.Unmarshal(mobile.SendDataPacketArgsForCall(1).GetUser().Payload, &msg))
+	require.Equal(t, uint32(320), msg.Tracks[0].Width)
+	require.Equal(t, int64(150000), msg.Tracks[0].Bitrate)
+}
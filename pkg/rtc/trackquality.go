@@ -0,0 +1,61 @@
+package rtc
+
+import (
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+// quality labels of subscribed video tracks, so that clients can display consistent badges
+const (
+	TrackQualityHD = "HD"
+	TrackQualitySD = "SD"
+	// video is paused, only audio gets through
+	TrackQualityAudioOnly = "AUDIO_ONLY"
+
+	// shorter side of a layer, at or above which it's considered HD
+	hdLayerSize = 720
+)
+
+// sizes of simulcast layers, low to high, matching GetQualityForDimension. The top layer is the published size
+var simulcastLayerSizes = []uint32{180, 360}
+
+func qualityLabelForLayer(info *livekit.TrackInfo, spatial int32) string {
+	if spatial == sfu.InvalidSpatialLayer {
+		return TrackQualityAudioOnly
+	}
+
+	origSize := info.Height
+	if info.Width < info.Height {
+		// portrait
+		origSize = info.Width
+	}
+
+	size := origSize
+	if info.Simulcast && int(spatial) < len(simulcastLayerSizes) {
+		size = simulcastLayerSizes[spatial]
+	}
+	if size >= hdLayerSize || (size == 0 && int(spatial) == len(simulcastLayerSizes)) {
+		return TrackQualityHD
+	}
+	return TrackQualitySD
+}
+
+// trackQualityMessage is sent to subscribers with the labels that changed since the previous message
+type trackQualityMessage struct {
+	Type   string               `json:"type"`
+	Tracks []*trackQualityLabel `json:"tracks"`
+}
+
+type trackQualityLabel struct {
+	ParticipantSid string `json:"participant_sid"`
+	TrackSid       string `json:"track_sid"`
+	Quality        string `json:"quality"`
+}
+
+func newTrackQualityPacket(labels []*trackQualityLabel) (*livekit.DataPacket, error) {
+	return newServerMessagePacket(&trackQualityMessage{
+		Type:   trackQualityMessageType,
+		Tracks: labels,
+	})
+}
@@ -0,0 +1,98 @@
+package rtc
+
+import (
+	"encoding/json"
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+func TestQualityLabelForLayer(t *testing.T) {
+	t.Run("paused video is audio only", func(t *testing.T) {
+		info := &livekit.TrackInfo{Width: 1280, Height: 720, Simulcast: true}
+		require.Equal(t, TrackQualityAudioOnly, qualityLabelForLayer(info, sfu.InvalidSpatialLayer))
+	})
+
+	t.Run("simulcast layers", func(t *testing.T) {
+		info := &livekit.TrackInfo{Width: 1280, Height: 720, Simulcast: true}
+		require.Equal(t, TrackQualitySD, qualityLabelForLayer(info, 0))
+		require.Equal(t, TrackQualitySD, qualityLabelForLayer(info, 1))
+		require.Equal(t, TrackQualityHD, qualityLabelForLayer(info, 2))
+
+		// top layer is only as good as what's published
+		info = &livekit.TrackInfo{Width: 960, Height: 540, Simulcast: true}
+		require.Equal(t, TrackQualitySD, qualityLabelForLayer(info, 2))
+	})
+
+	t.Run("portrait uses the shorter side", func(t *testing.T) {
+		require.Equal(t, TrackQualityHD, qualityLabelForLayer(&livekit.TrackInfo{Width: 720, Height: 1280}, 0))
+		require.Equal(t, TrackQualitySD, qualityLabelForLayer(&livekit.TrackInfo{Width: 540, Height: 960}, 0))
+	})
+
+	t.Run("unknown dimensions", func(t *testing.T) {
+		info := &livekit.TrackInfo{Simulcast: true}
+		require.Equal(t, TrackQualitySD, qualityLabelForLayer(info, 1))
+		require.Equal(t, TrackQualityHD, qualityLabelForLayer(info, 2))
+	})
+}
+
+func TestTrackQualityPacket(t *testing.T) {
+	dp, err := newTrackQualityPacket([]*trackQualityLabel{
+		{ParticipantSid: "PA_1", TrackSid: "TR_1", Quality: TrackQualityHD},
+	})
+	require.NoError(t, err)
+	require.Equal(t, livekit.DataPacket_RELIABLE, dp.Kind)
+
+	var msg map[string]interface{}
+	require.NoError(t, json.Unmarshal(dp.GetUser().Payload, &msg))
+	require.Equal(t, trackQualityMessageType, msg["type"])
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"participant_sid": "PA_1", "track_sid": "TR_1", "quality": "HD"},
+	}, msg["tracks"])
+}
+
+func TestSendTrackQualityLabels(t *testing.T) {
+	publisher := &typesfakes.FakeParticipant{}
+	publisher.IdentityReturns("pub")
+	publisher.IDReturns("PA_pub")
+
+	st := &typesfakes.FakeSubscribedTrack{}
+	st.IDReturns("TR_video")
+	st.PublisherIdentityReturns("pub")
+	st.QualityLabelReturns(TrackQualityHD)
+
+	subscriber := &typesfakes.FakeParticipant{}
+	subscriber.IdentityReturns("sub")
+	subscriber.CapabilitiesReturns(types.ProtocolVersion(3).Capabilities())
+	subscriber.GetSubscribedTracksReturns([]types.SubscribedTrack{st})
+
+	r := &Room{
+		participants: map[string]types.Participant{
+			"pub": publisher,
+			"sub": subscriber,
+		},
+	}
+	participants := r.GetParticipants()
+
+	sent := r.sendTrackQualityLabels(participants, nil)
+	require.Equal(t, 1, subscriber.SendDataPacketCallCount())
+	require.Equal(t, TrackQualityHD, sent["sub"]["TR_video"])
+
+	// unchanged labels aren't sent again
+	sent = r.sendTrackQualityLabels(participants, sent)
+	require.Equal(t, 1, subscriber.SendDataPacketCallCount())
+
+	st.QualityLabelReturns(TrackQualityAudioOnly)
+	r.sendTrackQualityLabels(participants, sent)
+	require.Equal(t, 2, subscriber.SendDataPacketCallCount())
+	var msg trackQualityMessage
+	require.NoError(t, json.Unmarshal(subscriber.SendDataPacketArgsForCall(1).GetUser().Payload, &msg))
+	require.Len(t, msg.Tracks, 1)
+	require.Equal(t, "PA_pub", msg.Tracks[0].ParticipantSid)
+	require.Equal(t, TrackQualityAudioOnly, msg.Tracks[0].Quality)
+}
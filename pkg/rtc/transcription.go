@@ -0,0 +1,182 @@
+package rtc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+const (
+	// peer ID the audio of a track is teed to its transcription stream with, added to the receiver
+	// like the down track of a subscriber
+	transcriptionPeerID = "transcription"
+)
+
+// TranscriptionProvider is a speech-to-text service, transcribing the audio tracks published in rooms
+type TranscriptionProvider interface {
+	// NewStream starts transcribing a track. Transcripts are passed to onTranscript as the provider
+	// produces them, until the stream is closed
+	NewStream(info TranscriptionStreamInfo, onTranscript func(transcript *Transcript)) (TranscriptionStream, error)
+}
+
+// TranscriptionStreamInfo describes the track a stream transcribes
+type TranscriptionStreamInfo struct {
+	RoomName       string
+	ParticipantSid string
+	TrackSid       string
+	MimeType       string
+	ClockRate      uint32
+	Channels       uint16
+}
+
+// TranscriptionStream receives the audio of a track. It's closed when the track is unpublished
+type TranscriptionStream interface {
+	// WriteSample is called with the payload of each packet of the track, as it's forwarded. It must
+	// not block, and payload is only valid during the call
+	WriteSample(payload []byte) error
+	Close() error
+}
+
+// Transcript is speech transcribed by a provider. Interim transcripts are replaced by the ones
+// that follow for the same speech, up to a final transcript
+type Transcript struct {
+	Text     string
+	Final    bool
+	Language string
+	// offsets of the speech from the start of the stream
+	StartTime time.Duration
+	EndTime   time.Duration
+}
+
+// transcriptionMessage sends the transcribed speech of a participant to the room
+type transcriptionMessage struct {
+	Type           string `json:"type"`
+	ParticipantSid string `json:"participant_sid"`
+	TrackSid       string `json:"track_sid"`
+	Text           string `json:"text"`
+	Final          bool   `json:"final"`
+	Language       string `json:"language,omitempty"`
+	// milliseconds since the start of the track
+	StartTime int64 `json:"start_time"`
+	EndTime   int64 `json:"end_time"`
+}
+
+func newTranscriptionPacket(p types.Participant, track types.PublishedTrack, transcript *Transcript) (*livekit.DataPacket, error) {
+	return newServerMessagePacket(&transcriptionMessage{
+		Type:           transcriptionMessageType,
+		ParticipantSid: p.ID(),
+		TrackSid:       track.ID(),
+		Text:           transcript.Text,
+		Final:          transcript.Final,
+		Language:       transcript.Language,
+		StartTime:      transcript.StartTime.Milliseconds(),
+		EndTime:        transcript.EndTime.Milliseconds(),
+	})
+}
+
+// transcribes returns true when track is audio the transcription provider can decode, it can't
+// decode end-to-end encrypted media
+func (r *Room) transcribes(p types.Participant, track types.PublishedTrack) bool {
+	return r.transcription != nil && track.Kind() == livekit.TrackType_AUDIO && !p.Capabilities().E2EE
+}
+
+// startTranscription tees the audio of track into a new transcription stream
+func (r *Room) startTranscription(p types.Participant, track types.PublishedTrack) {
+	receiver := track.Receiver()
+	if receiver == nil {
+		return
+	}
+	codec := receiver.Codec()
+	stream, err := r.transcription.NewStream(TranscriptionStreamInfo{
+		RoomName:       r.Room.Name,
+		ParticipantSid: p.ID(),
+		TrackSid:       track.ID(),
+		MimeType:       codec.MimeType,
+		ClockRate:      codec.ClockRate,
+		Channels:       codec.Channels,
+	}, func(transcript *Transcript) {
+		r.onTranscript(p, track, transcript)
+	})
+	if err != nil {
+		r.Logger.Warnw("could not start transcription", err,
+			"participant", p.Identity(),
+			"pID", p.ID(),
+			"track", track.ID())
+		return
+	}
+	receiver.AddDownTrack(newTranscriptionTap(track.ID(), codec, stream))
+}
+
+// onTranscript sends a transcript to the room, and final transcripts to webhooks when enabled
+func (r *Room) onTranscript(p types.Participant, track types.PublishedTrack, transcript *Transcript) {
+	if transcript.Text == "" {
+		return
+	}
+	dp, err := newTranscriptionPacket(p, track, transcript)
+	if err != nil {
+		r.Logger.Warnw("could not send transcript", err, "participant", p.Identity(), "track", track.ID())
+		return
+	}
+	r.onDataPacket(nil, dp)
+
+	if transcript.Final && r.transcriptionWebhook {
+		r.telemetry.TranscriptionReceived(context.Background(), &telemetry.TranscriptionEvent{
+			RoomSid:             r.Room.Sid,
+			RoomName:            r.Room.Name,
+			ParticipantSid:      p.ID(),
+			ParticipantIdentity: p.Identity(),
+			TrackSid:            track.ID(),
+			Text:                transcript.Text,
+			Language:            transcript.Language,
+			StartTime:           transcript.StartTime.Milliseconds(),
+			EndTime:             transcript.EndTime.Milliseconds(),
+		})
+	}
+}
+
+// transcriptionTap passes the packets a receiver forwards to a transcription stream. The stream is
+// closed with the receiver
+type transcriptionTap struct {
+	id        string
+	codec     webrtc.RTPCodecCapability
+	stream    TranscriptionStream
+	closeOnce sync.Once
+}
+
+func newTranscriptionTap(trackID string, codec webrtc.RTPCodecCapability, stream TranscriptionStream) *transcriptionTap {
+	return &transcriptionTap{
+		id:     trackID,
+		codec:  codec,
+		stream: stream,
+	}
+}
+
+func (t *transcriptionTap) WriteRTP(p *buffer.ExtPacket, _ int32) error {
+	if len(p.Packet.Payload) == 0 {
+		return nil
+	}
+	return t.stream.WriteSample(p.Packet.Payload)
+}
+
+func (t *transcriptionTap) Close() {
+	t.closeOnce.Do(func() {
+		_ = t.stream.Close()
+	})
+}
+
+func (t *transcriptionTap) ID() string { return t.id }
+
+func (t *transcriptionTap) PeerID() string { return transcriptionPeerID }
+
+func (t *transcriptionTap) Codec() webrtc.RTPCodecCapability { return t.codec }
+
+func (t *transcriptionTap) SetTrackType(_ bool) {}
+
+func (t *transcriptionTap) UptrackLayersChange(_ []uint16) {}
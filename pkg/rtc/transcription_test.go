@@ -0,0 +1,157 @@
+package rtc
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+func TestTranscription(t *testing.T) {
+	publisher := &typesfakes.FakeParticipant{}
+	publisher.IdentityReturns("publisher")
+	publisher.IDReturns("PA_publisher")
+	publisher.StateReturns(livekit.ParticipantInfo_ACTIVE)
+	listener := &typesfakes.FakeParticipant{}
+	listener.IdentityReturns("listener")
+	listener.IDReturns("PA_listener")
+	listener.StateReturns(livekit.ParticipantInfo_ACTIVE)
+
+	receiver := &tapTrackReceiver{codec: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2}}
+	track := &typesfakes.FakePublishedTrack{}
+	track.IDReturns("TR_mic")
+	track.KindReturns(livekit.TrackType_AUDIO)
+	track.ReceiverReturns(receiver)
+
+	provider := &testTranscriptionProvider{}
+	r := &Room{
+		Room:      &livekit.Room{Name: "room"},
+		telemetry: telemetry.NewTelemetryService(nil, nil, nil, nil),
+		participants: map[string]types.Participant{
+			"publisher": publisher,
+			"listener":  listener,
+		},
+	}
+	r.EnableTranscription(provider, true)
+	require.True(t, r.transcribes(publisher, track))
+	r.startTranscription(publisher, track)
+
+	require.Equal(t, TranscriptionStreamInfo{
+		RoomName:       "room",
+		ParticipantSid: "PA_publisher",
+		TrackSid:       "TR_mic",
+		MimeType:       webrtc.MimeTypeOpus,
+		ClockRate:      48000,
+		Channels:       2,
+	}, provider.info)
+	require.Len(t, receiver.downTracks, 1)
+	tap := receiver.downTracks[0]
+	require.Equal(t, transcriptionPeerID, tap.PeerID())
+
+	t.Run("audio is teed into the stream", func(t *testing.T) {
+		require.NoError(t, tap.WriteRTP(&buffer.ExtPacket{Packet: rtp.Packet{Payload: []byte{1, 2, 3}}}, 0))
+		// padding only
+		require.NoError(t, tap.WriteRTP(&buffer.ExtPacket{}, 0))
+		require.Equal(t, [][]byte{{1, 2, 3}}, provider.stream.samples)
+	})
+
+	t.Run("transcripts are sent to the room", func(t *testing.T) {
+		provider.onTranscript(&Transcript{Text: "hello", Final: true, StartTime: time.Second, EndTime: 2 * time.Second})
+		// empty transcripts are skipped
+		provider.onTranscript(&Transcript{})
+
+		// including the speaker
+		require.Equal(t, 1, publisher.SendDataPacketCallCount())
+		require.Equal(t, 1, listener.SendDataPacketCallCount())
+		var msg transcriptionMessage
+		require.NoError(t, json.Unmarshal(listener.SendDataPacketArgsForCall(0).GetUser().Payload, &msg))
+		require.Equal(t, transcriptionMessage{
+			Type:           transcriptionMessageType,
+			ParticipantSid: "PA_publisher",
+			TrackSid:       "TR_mic",
+			Text:           "hello",
+			Final:          true,
+			StartTime:      1000,
+			EndTime:        2000,
+		}, msg)
+	})
+
+	t.Run("stream is closed with the receiver", func(t *testing.T) {
+		tap.Close()
+		tap.Close()
+		require.Equal(t, 1, provider.stream.closed)
+	})
+}
+
+// tapTrackReceiver records the down tracks added to it
+type tapTrackReceiver struct {
+	sfu.TrackReceiver
+	codec      webrtc.RTPCodecCapability
+	downTracks []sfu.TrackSender
+}
+
+func (r *tapTrackReceiver) Codec() webrtc.RTPCodecCapability {
+	return r.codec
+}
+
+func (r *tapTrackReceiver) AddDownTrack(track sfu.TrackSender) {
+	r.downTracks = append(r.downTracks, track)
+}
+
+func (r *tapTrackReceiver) DeleteDownTrack(peerID string) {
+	for i, track := range r.downTracks {
+		if track.PeerID() == peerID {
+			r.downTracks = append(r.downTracks[:i], r.downTracks[i+1:]...)
+			return
+		}
+	}
+}
+
+func TestTranscribesEncryptedAudio(t *testing.T) {
+	publisher := &typesfakes.FakeParticipant{}
+	publisher.CapabilitiesReturns(types.Capabilities{E2EE: true})
+	track := &typesfakes.FakePublishedTrack{}
+	track.KindReturns(livekit.TrackType_AUDIO)
+
+	r := &Room{Room: &livekit.Room{Name: "room"}}
+	r.EnableTranscription(&testTranscriptionProvider{}, false)
+	require.False(t, r.transcribes(publisher, track))
+}
+
+type testTranscriptionProvider struct {
+	info         TranscriptionStreamInfo
+	onTranscript func(*Transcript)
+	stream       *testTranscriptionStream
+}
+
+func (p *testTranscriptionProvider) NewStream(info TranscriptionStreamInfo, onTranscript func(*Transcript)) (TranscriptionStream, error) {
+	p.info = info
+	p.onTranscript = onTranscript
+	p.stream = &testTranscriptionStream{}
+	return p.stream, nil
+}
+
+type testTranscriptionStream struct {
+	samples [][]byte
+	closed  int
+}
+
+func (s *testTranscriptionStream) WriteSample(payload []byte) error {
+	s.samples = append(s.samples, append([]byte{}, payload...))
+	return nil
+}
+
+func (s *testTranscriptionStream) Close() error {
+	s.closed++
+	return nil
+}
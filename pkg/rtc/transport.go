@@ -1,6 +1,7 @@
 package rtc
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -18,6 +19,10 @@ import (
 
 const (
 	negotiationFrequency = 150 * time.Millisecond
+	// time a client has to answer an offer before the negotiation fails
+	negotiationTimeout = 15 * time.Second
+	// answers in a row that can't be applied before the negotiation fails
+	maxNegotiationFailures = 2
 )
 
 const (
@@ -39,9 +44,25 @@ type PCTransport struct {
 	onOffer               func(offer webrtc.SessionDescription)
 	restartAfterGathering bool
 	negotiationState      int
+	// the pending offer was sent again after the client reconnected, its answer is expected
+	offerResent bool
+	// restart ICE once the pending offer is answered
+	restartAfterAnswer bool
+	// fails the negotiation when the pending offer isn't answered in time
+	negotiationTimeout time.Duration
+	negotiationTimer   *time.Timer
+	// answers that couldn't be applied since the last one that could
+	negotiationFailures int
+	onNegotiationFailed func()
 
 	// stream allocator for subscriber PC
 	streamAllocator *sfu.StreamAllocator
+	// paces the media of subscriber PC, nil when pacing is disabled
+	pacer *sfu.Pacer
+	// types of local candidates that aren't sent to the client
+	excludedCandidateTypes []webrtc.ICECandidateType
+	// resolves the mDNS candidates of the client, they're dropped when nil
+	mdnsResolver *MDNSResolver
 
 	logger logger.Logger
 }
@@ -53,16 +74,20 @@ type TransportParams struct {
 	Config              *WebRTCConfig
 	Telemetry           telemetry.TelemetryService
 	EnabledCodecs       []*livekit.Codec
-	Logger              logger.Logger
+	// names of the RTP header extensions negotiated, see config.KnownHeaderExtensions
+	HeaderExtensions []string
+	// additional interceptors for the PeerConnection
+	Interceptors []interceptor.Factory
+	Logger       logger.Logger
 }
 
 func newPeerConnection(params TransportParams) (*webrtc.PeerConnection, *webrtc.MediaEngine, error) {
 	var me *webrtc.MediaEngine
 	var err error
 	if params.Target == livekit.SignalTarget_PUBLISHER {
-		me, err = createPubMediaEngine(params.EnabledCodecs)
+		me, err = createPubMediaEngine(params.EnabledCodecs, params.HeaderExtensions)
 	} else {
-		me, err = createSubMediaEngine(params.EnabledCodecs)
+		me, err = createSubMediaEngine(params.EnabledCodecs, params.HeaderExtensions)
 	}
 	if err != nil {
 		return nil, nil, err
@@ -76,6 +101,9 @@ func newPeerConnection(params TransportParams) (*webrtc.PeerConnection, *webrtc.
 		f := params.Telemetry.NewStatsInterceptorFactory(params.ParticipantID, params.ParticipantIdentity)
 		ir.Add(f)
 	}
+	for _, f := range params.Interceptors {
+		ir.Add(f)
+	}
 	api := webrtc.NewAPI(
 		webrtc.WithMediaEngine(me),
 		webrtc.WithSettingEngine(se),
@@ -86,6 +114,18 @@ func newPeerConnection(params TransportParams) (*webrtc.PeerConnection, *webrtc.
 }
 
 func NewPCTransport(params TransportParams) (*PCTransport, error) {
+	var controller sfu.CongestionController
+	if params.Target == livekit.SignalTarget_SUBSCRIBER {
+		var err error
+		controller, err = sfu.NewCongestionController(params.Config.CongestionControl.AlgorithmFor(params.ParticipantIdentity))
+		if err != nil {
+			return nil, err
+		}
+		if emulation := params.Config.NetworkEmulation; emulation != nil && emulation.MaxBitrate > 0 {
+			controller = sfu.NewCappedCongestionController(controller, int64(emulation.MaxBitrate))
+		}
+	}
+
 	pc, me, err := newPeerConnection(params)
 	if err != nil {
 		return nil, err
@@ -96,14 +136,23 @@ func NewPCTransport(params TransportParams) (*PCTransport, error) {
 		me:                 me,
 		debouncedNegotiate: debounce.New(negotiationFrequency),
 		negotiationState:   negotiationStateNone,
-		logger:             params.Logger,
+		negotiationTimeout: negotiationTimeout,
+		logger:             withValues(params.Logger, "transport", params.Target.String()),
+
+		excludedCandidateTypes: params.Config.ExcludedCandidateTypes,
+		mdnsResolver:           params.Config.MDNSResolver,
 	}
 	if params.Target == livekit.SignalTarget_SUBSCRIBER {
 		t.streamAllocator = sfu.NewStreamAllocator(sfu.StreamAllocatorParams{
-			ParticipantID: params.ParticipantID,
-			Logger:        params.Logger,
+			ParticipantID:            params.ParticipantID,
+			Logger:                   params.Logger,
+			CongestionController:     controller,
+			OnCongestionControlStats: recordCongestionControlStats,
 		})
 		t.streamAllocator.Start()
+		if pacer := params.Config.Sender.Pacer; pacer.Rate > 0 {
+			t.pacer = sfu.NewPacer(pacer.Rate, pacer.Burst)
+		}
 	}
 	t.pc.OnICEGatheringStateChange(func(state webrtc.ICEGathererState) {
 		if state == webrtc.ICEGathererStateComplete {
@@ -123,7 +172,28 @@ func NewPCTransport(params TransportParams) (*PCTransport, error) {
 	return t, nil
 }
 
+// OnICECandidate sets the handler of local candidates to send to the client. Candidates of types
+// excluded by the config aren't passed on
+func (t *PCTransport) OnICECandidate(f func(c *webrtc.ICECandidate)) {
+	t.pc.OnICECandidate(func(c *webrtc.ICECandidate) {
+		if c != nil {
+			for _, candidateType := range t.excludedCandidateTypes {
+				if c.Typ == candidateType {
+					t.logger.Debugw("not sending excluded candidate", "candidate", c.String())
+					return
+				}
+			}
+		}
+		f(c)
+	})
+}
+
 func (t *PCTransport) AddICECandidate(candidate webrtc.ICECandidateInit) error {
+	if host, err := mdnsCandidateHost(candidate.Candidate); err == nil {
+		t.addMDNSCandidate(candidate, host)
+		return nil
+	}
+
 	if t.pc.RemoteDescription() == nil {
 		t.lock.Lock()
 		t.pendingCandidates = append(t.pendingCandidates, candidate)
@@ -134,6 +204,32 @@ func (t *PCTransport) AddICECandidate(candidate webrtc.ICECandidateInit) error {
 	return t.pc.AddICECandidate(candidate)
 }
 
+// addMDNSCandidate adds an mDNS candidate of the client once its hostname is resolved. Without a
+// resolver, or when the client doesn't answer, the candidate is dropped. The client is still reached
+// through the peer reflexive candidates learned from its connectivity checks
+func (t *PCTransport) addMDNSCandidate(candidate webrtc.ICECandidateInit, host string) {
+	if t.mdnsResolver == nil {
+		t.logger.Debugw("dropping mDNS candidate, resolution is disabled", "candidate", candidate.Candidate)
+		prometheus.IncrementMDNSCandidate(prometheus.MDNSCandidateDropped)
+		return
+	}
+
+	go func() {
+		ip, err := t.mdnsResolver.Resolve(context.Background(), host)
+		if err != nil {
+			t.logger.Debugw("could not resolve mDNS candidate", "candidate", candidate.Candidate, "error", err)
+			prometheus.IncrementMDNSCandidate(prometheus.MDNSCandidateUnresolved)
+			return
+		}
+		prometheus.IncrementMDNSCandidate(prometheus.MDNSCandidateResolved)
+
+		candidate.Candidate = replaceCandidateHost(candidate.Candidate, ip)
+		if err := t.AddICECandidate(candidate); err != nil {
+			t.logger.Debugw("could not add resolved mDNS candidate", "candidate", candidate.Candidate, "error", err)
+		}
+	}()
+}
+
 func (t *PCTransport) PeerConnection() *webrtc.PeerConnection {
 	return t.pc
 }
@@ -142,6 +238,13 @@ func (t *PCTransport) Close() {
 	if t.streamAllocator != nil {
 		t.streamAllocator.Stop()
 	}
+	if t.pacer != nil {
+		t.pacer.Stop()
+	}
+
+	t.lock.Lock()
+	t.stopNegotiationTimer()
+	t.lock.Unlock()
 
 	_ = t.pc.Close()
 }
@@ -151,12 +254,22 @@ func (t *PCTransport) SetRemoteDescription(sd webrtc.SessionDescription) error {
 	defer t.lock.Unlock()
 
 	if err := t.pc.SetRemoteDescription(sd); err != nil {
+		if sd.Type == webrtc.SDPTypeAnswer && t.negotiationState != negotiationStateNone {
+			t.handleAnswerFailure(err)
+		}
 		return err
 	}
+	if sd.Type == webrtc.SDPTypeAnswer {
+		t.stopNegotiationTimer()
+		t.negotiationFailures = 0
+	}
 
 	// negotiated, reset flag
 	lastState := t.negotiationState
 	t.negotiationState = negotiationStateNone
+	t.offerResent = false
+	restartICE := t.restartAfterAnswer
+	t.restartAfterAnswer = false
 
 	for _, c := range t.pendingCandidates {
 		if err := t.pc.AddICECandidate(c); err != nil {
@@ -167,8 +280,12 @@ func (t *PCTransport) SetRemoteDescription(sd webrtc.SessionDescription) error {
 
 	// only initiate when we are the offerer
 	if lastState == negotiationRetry && sd.Type == webrtc.SDPTypeAnswer {
-		t.logger.Debugw("re-negotiate after answering")
-		if err := t.createAndSendOffer(nil); err != nil {
+		t.logger.Debugw("re-negotiate after answering", "iceRestart", restartICE)
+		var options *webrtc.OfferOptions
+		if restartICE {
+			options = &webrtc.OfferOptions{ICERestart: true}
+		}
+		if err := t.createAndSendOffer(options); err != nil {
 			t.logger.Errorw("could not negotiate", err)
 		}
 	}
@@ -180,6 +297,92 @@ func (t *PCTransport) OnOffer(f func(sd webrtc.SessionDescription)) {
 	t.onOffer = f
 }
 
+// OnNegotiationFailed is called when the client doesn't answer an offer in time, or keeps sending
+// answers that can't be applied. The PeerConnection can't be renegotiated after that
+func (t *PCTransport) OnNegotiationFailed(f func()) {
+	t.onNegotiationFailed = f
+}
+
+// handleAnswerFailure rolls back the offer an answer couldn't be applied to, and offers again with
+// what was queued in the meantime. Should be called with lock held
+func (t *PCTransport) handleAnswerFailure(err error) {
+	t.negotiationFailures++
+	t.logger.Warnw("could not apply answer", err, "failures", t.negotiationFailures)
+	restartICE := t.restartAfterAnswer
+	t.restartAfterAnswer = false
+	if err := t.rollback(); err != nil {
+		t.logger.Warnw("could not roll back offer", err)
+		t.failNegotiation()
+		return
+	}
+	if t.negotiationFailures >= maxNegotiationFailures {
+		t.failNegotiation()
+		return
+	}
+
+	var options *webrtc.OfferOptions
+	if restartICE {
+		options = &webrtc.OfferOptions{ICERestart: true}
+	}
+	if err := t.createAndSendOffer(options); err != nil {
+		t.logger.Errorw("could not negotiate", err)
+	}
+}
+
+// rollback discards the offer waiting for an answer, returning to the last negotiated state.
+// Should be called with lock held
+func (t *PCTransport) rollback() error {
+	if t.pc.SignalingState() == webrtc.SignalingStateHaveLocalOffer {
+		// pion can't roll back local offers, applying the last answer again gets back to stable
+		currentSD := t.pc.CurrentRemoteDescription()
+		if currentSD == nil {
+			return ErrNothingToRollBack
+		}
+		if err := t.pc.SetRemoteDescription(*currentSD); err != nil {
+			return err
+		}
+	}
+	t.stopNegotiationTimer()
+	t.negotiationState = negotiationStateNone
+	t.offerResent = false
+	return nil
+}
+
+// should be called with lock held
+func (t *PCTransport) failNegotiation() {
+	t.stopNegotiationTimer()
+	prometheus.ServiceOperationCounter.WithLabelValues("negotiate", "error", "failed").Add(1)
+	if t.onNegotiationFailed != nil {
+		go t.onNegotiationFailed()
+	}
+}
+
+// startNegotiationTimer fails the negotiation unless the offer just sent is answered in time.
+// Should be called with lock held
+func (t *PCTransport) startNegotiationTimer() {
+	t.stopNegotiationTimer()
+	var timer *time.Timer
+	timer = time.AfterFunc(t.negotiationTimeout, func() {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		if t.negotiationTimer != timer {
+			return
+		}
+		t.negotiationTimer = nil
+		t.logger.Warnw("offer wasn't answered in time", nil, "timeout", t.negotiationTimeout)
+		t.failNegotiation()
+	})
+	t.negotiationTimer = timer
+}
+
+// should be called with lock held
+func (t *PCTransport) stopNegotiationTimer() {
+	if t.negotiationTimer != nil {
+		t.negotiationTimer.Stop()
+		t.negotiationTimer = nil
+	}
+}
+
 func (t *PCTransport) Negotiate() {
 	t.debouncedNegotiate(func() {
 		if err := t.CreateAndSendOffer(nil); err != nil {
@@ -188,6 +391,22 @@ func (t *PCTransport) Negotiate() {
 	})
 }
 
+// ResendOffer sends the offer that's waiting for an answer again, for clients that didn't receive
+// it. Offers are only resent once, later offers wait for its answer
+func (t *PCTransport) ResendOffer() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	offer := t.pc.PendingLocalDescription()
+	if t.onOffer == nil || t.negotiationState == negotiationStateNone || offer == nil || offer.Type != webrtc.SDPTypeOffer {
+		return
+	}
+	t.logger.Debugw("resending offer")
+	t.offerResent = true
+	t.startNegotiationTimer()
+	go t.onOffer(*offer)
+}
+
 func (t *PCTransport) CreateAndSendOffer(options *webrtc.OfferOptions) error {
 	t.lock.Lock()
 	defer t.lock.Unlock()
@@ -217,11 +436,16 @@ func (t *PCTransport) createAndSendOffer(options *webrtc.OfferOptions) error {
 
 	// when there's an ongoing negotiation, let it finish and not disrupt its state
 	if t.negotiationState == negotiationStateClient {
-		currentSD := t.pc.CurrentRemoteDescription()
-		if iceRestart && currentSD != nil {
+		if iceRestart && t.offerResent {
+			// the client has the pending offer, rolling it back would leave its answer unusable
+			t.logger.Debugw("restart ICE after the resent offer is answered")
+			t.negotiationState = negotiationRetry
+			t.restartAfterAnswer = true
+			return nil
+		} else if iceRestart && t.pc.CurrentRemoteDescription() != nil {
 			t.logger.Debugw("recovering from client negotiation state")
-			if err := t.pc.SetRemoteDescription(*currentSD); err != nil {
-				prometheus.ServiceOperationCounter.WithLabelValues("offer", "error", "remote_description").Add(1)
+			if err := t.rollback(); err != nil {
+				prometheus.ServiceOperationCounter.WithLabelValues("offer", "error", "rollback").Add(1)
 				return err
 			}
 		} else {
@@ -231,6 +455,9 @@ func (t *PCTransport) createAndSendOffer(options *webrtc.OfferOptions) error {
 		}
 	} else if t.negotiationState == negotiationRetry {
 		// already set to retry, we can safely skip this attempt
+		if iceRestart {
+			t.restartAfterAnswer = true
+		}
 		return nil
 	}
 
@@ -251,6 +478,7 @@ func (t *PCTransport) createAndSendOffer(options *webrtc.OfferOptions) error {
 	// indicate waiting for client
 	t.negotiationState = negotiationStateClient
 	t.restartAfterGathering = false
+	t.startNegotiationTimer()
 
 	go t.onOffer(offer)
 	return nil
@@ -279,3 +507,19 @@ func (t *PCTransport) RemoveTrack(subTrack types.SubscribedTrack) {
 
 	t.streamAllocator.RemoveTrack(subTrack.DownTrack())
 }
+
+func recordCongestionControlStats(stats sfu.CongestionControlStats) {
+	var capacity int64
+	if stats.CommittedCapacity != sfu.ChannelCapacityInfinity {
+		capacity = stats.CommittedCapacity
+	}
+	prometheus.RecordCongestionControl(
+		stats.Algorithm,
+		stats.State.String(),
+		stats.Sample.Interval,
+		capacity,
+		stats.Sample.BytesSent,
+		stats.Sample.LossPercentage,
+		stats.Sample.RTTMs,
+	)
+}
@@ -1,6 +1,7 @@
 package rtc
 
 import (
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -113,6 +114,152 @@ func TestNegotiationTiming(t *testing.T) {
 	require.False(t, offer2 == actualOffer)
 }
 
+func TestResendOffer(t *testing.T) {
+	params := TransportParams{
+		ParticipantID:       "id",
+		ParticipantIdentity: "identity",
+		Target:              livekit.SignalTarget_SUBSCRIBER,
+		Config:              &WebRTCConfig{},
+	}
+	transportA, err := NewPCTransport(params)
+	require.NoError(t, err)
+	_, err = transportA.pc.CreateDataChannel("test", nil)
+	require.NoError(t, err)
+	transportB, err := NewPCTransport(params)
+	require.NoError(t, err)
+	defer transportA.Close()
+	defer transportB.Close()
+	handleICEExchange(t, transportA, transportB)
+
+	offers := make(chan webrtc.SessionDescription, 2)
+	transportA.OnOffer(func(sd webrtc.SessionDescription) {
+		offers <- sd
+	})
+	answer := func(offer webrtc.SessionDescription) {
+		require.NoError(t, transportB.SetRemoteDescription(offer))
+		answer, err := transportB.pc.CreateAnswer(nil)
+		require.NoError(t, err)
+		require.NoError(t, transportB.pc.SetLocalDescription(answer))
+		require.NoError(t, transportA.SetRemoteDescription(answer))
+	}
+
+	// lost while the client was away
+	require.NoError(t, transportA.CreateAndSendOffer(nil))
+	lost := <-offers
+
+	transportA.ResendOffer()
+	resent := <-offers
+	// with the candidates gathered since
+	require.Equal(t, webrtc.SDPTypeOffer, resent.Type)
+	require.True(t, strings.HasPrefix(resent.SDP, lost.SDP[:strings.Index(lost.SDP, "m=")]))
+
+	// restarting ICE waits for the answer of the resent offer
+	testutils.WithTimeout(t, "ICE gathering", func() bool {
+		return transportA.pc.ICEGatheringState() == webrtc.ICEGatheringStateComplete
+	})
+	require.NoError(t, transportA.CreateAndSendOffer(&webrtc.OfferOptions{ICERestart: true}))
+	require.Equal(t, negotiationRetry, transportA.negotiationState)
+	answer(resent)
+	restarted := <-offers
+	require.Equal(t, negotiationStateClient, transportA.negotiationState)
+	ufrag := func(sd webrtc.SessionDescription) string {
+		parsed, err := sd.Unmarshal()
+		require.NoError(t, err)
+		value, _ := parsed.MediaDescriptions[0].Attribute("ice-ufrag")
+		return value
+	}
+	require.NotEqual(t, ufrag(resent), ufrag(restarted))
+	answer(restarted)
+
+	// nothing to resend once answered
+	transportA.ResendOffer()
+	select {
+	case <-offers:
+		t.Fatal("offer resent after it was answered")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestNegotiationFailure(t *testing.T) {
+	newTransports := func(t *testing.T) (*PCTransport, *PCTransport, chan webrtc.SessionDescription, chan struct{}) {
+		params := TransportParams{
+			ParticipantID:       "id",
+			ParticipantIdentity: "identity",
+			Target:              livekit.SignalTarget_SUBSCRIBER,
+			Config:              &WebRTCConfig{},
+		}
+		transportA, err := NewPCTransport(params)
+		require.NoError(t, err)
+		_, err = transportA.pc.CreateDataChannel("test", nil)
+		require.NoError(t, err)
+		transportB, err := NewPCTransport(params)
+		require.NoError(t, err)
+		t.Cleanup(transportA.Close)
+		t.Cleanup(transportB.Close)
+
+		offers := make(chan webrtc.SessionDescription, 2)
+		transportA.OnOffer(func(sd webrtc.SessionDescription) {
+			offers <- sd
+		})
+		failed := make(chan struct{}, 1)
+		transportA.OnNegotiationFailed(func() {
+			failed <- struct{}{}
+		})
+		return transportA, transportB, offers, failed
+	}
+	answer := func(t *testing.T, a, b *PCTransport, offer webrtc.SessionDescription) {
+		require.NoError(t, b.SetRemoteDescription(offer))
+		answer, err := b.pc.CreateAnswer(nil)
+		require.NoError(t, err)
+		require.NoError(t, b.pc.SetLocalDescription(answer))
+		require.NoError(t, a.SetRemoteDescription(answer))
+	}
+	badAnswer := webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer, SDP: "not an answer"}
+
+	t.Run("unanswered offer", func(t *testing.T) {
+		transportA, transportB, offers, failed := newTransports(t)
+		transportA.negotiationTimeout = 50 * time.Millisecond
+
+		require.NoError(t, transportA.CreateAndSendOffer(nil))
+		answer(t, transportA, transportB, <-offers)
+		require.NoError(t, transportA.CreateAndSendOffer(nil))
+		<-offers
+
+		select {
+		case <-failed:
+		case <-time.After(time.Second):
+			t.Fatal("negotiation didn't fail")
+		}
+	})
+
+	t.Run("answers that can't be applied", func(t *testing.T) {
+		transportA, transportB, offers, failed := newTransports(t)
+
+		require.NoError(t, transportA.CreateAndSendOffer(nil))
+		answer(t, transportA, transportB, <-offers)
+
+		// the offer is rolled back and made again, with what was queued in the meantime
+		require.NoError(t, transportA.CreateAndSendOffer(nil))
+		<-offers
+		require.NoError(t, transportA.CreateAndSendOffer(nil))
+		require.Equal(t, negotiationRetry, transportA.negotiationState)
+		require.Error(t, transportA.SetRemoteDescription(badAnswer))
+		<-offers
+		require.Equal(t, negotiationStateClient, transportA.negotiationState)
+		require.Empty(t, failed)
+
+		// once more and the client is given up on
+		require.Error(t, transportA.SetRemoteDescription(badAnswer))
+		select {
+		case <-failed:
+		case <-time.After(time.Second):
+			t.Fatal("negotiation didn't fail")
+		}
+		require.Empty(t, offers)
+		require.Equal(t, webrtc.SignalingStateStable, transportA.pc.SignalingState())
+	})
+}
+
 func handleOfferFunc(t *testing.T, current, other *PCTransport) func(sd webrtc.SessionDescription) {
 	return func(sd webrtc.SessionDescription) {
 		t.Logf("handling offer")
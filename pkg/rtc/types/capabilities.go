@@ -0,0 +1,94 @@
+package types
+
+import (
+	"sort"
+	"strings"
+)
+
+// Capabilities are the features a client and the server agreed on when it joined. Most follow from
+// the client's protocol version, the optional ones are declared by the client and granted when the
+// server supports them
+type Capabilities struct {
+	Protobuf          bool
+	PackedStreamID    bool
+	DataPackets       bool
+	SubscriberPrimary bool
+	SpeakerChanged    bool
+	TransceiverReuse  bool
+	ConnectionQuality bool
+
+	// publishers are told which simulcast layers their subscribers receive, so they can pause the others
+	Dynacast bool
+	// subscribers receive the lowest layer of a video until they report the size of its element
+	AdaptiveStream bool
+	// signal responses are numbered and resumed from the last one received
+	Resume bool
+	// media is end-to-end encrypted, the server can't decode it
+	E2EE bool
+}
+
+// names of the capabilities clients can declare
+const (
+	CapabilityDynacast       = "dynacast"
+	CapabilityAdaptiveStream = "adaptive_stream"
+	CapabilityResume         = "resume"
+	CapabilityE2EE           = "e2ee"
+)
+
+// optional capabilities the server supports, others that clients declare are left out
+var supportedCapabilities = map[string]func(c *Capabilities){
+	CapabilityDynacast:       func(c *Capabilities) { c.Dynacast = true },
+	CapabilityAdaptiveStream: func(c *Capabilities) { c.AdaptiveStream = true },
+	CapabilityResume:         func(c *Capabilities) { c.Resume = true },
+	CapabilityE2EE:           func(c *Capabilities) { c.E2EE = true },
+}
+
+// Capabilities returns the capabilities that follow from the protocol version
+func (v ProtocolVersion) Capabilities() Capabilities {
+	return Capabilities{
+		Protobuf:          v.SupportsProtobuf(),
+		PackedStreamID:    v.SupportsPackedStreamId(),
+		DataPackets:       v.HandlesDataPackets(),
+		SubscriberPrimary: v.SubscriberAsPrimary(),
+		SpeakerChanged:    v.SupportsSpeakerChanged(),
+		TransceiverReuse:  v.SupportsTransceiverReuse(),
+		ConnectionQuality: v.SupportsConnectionQuality(),
+	}
+}
+
+// NegotiateCapabilities returns the capabilities of a client of protocol version v that declared the
+// optional ones in declared, comma separated. Those the server doesn't support, or doesn't know,
+// are left out, so newer clients fall back to what the server has
+func NegotiateCapabilities(v ProtocolVersion, declared string) Capabilities {
+	c := v.Capabilities()
+	for _, name := range strings.Split(declared, ",") {
+		if grant, ok := supportedCapabilities[strings.TrimSpace(name)]; ok {
+			grant(&c)
+		}
+	}
+	return c
+}
+
+// Names returns the names of the capabilities, sorted
+func (c Capabilities) Names() []string {
+	names := make([]string, 0)
+	for name, enabled := range map[string]bool{
+		"protobuf":               c.Protobuf,
+		"packed_stream_id":       c.PackedStreamID,
+		"data_packets":           c.DataPackets,
+		"subscriber_primary":     c.SubscriberPrimary,
+		"speaker_changed":        c.SpeakerChanged,
+		"transceiver_reuse":      c.TransceiverReuse,
+		"connection_quality":     c.ConnectionQuality,
+		CapabilityDynacast:       c.Dynacast,
+		CapabilityAdaptiveStream: c.AdaptiveStream,
+		CapabilityResume:         c.Resume,
+		CapabilityE2EE:           c.E2EE,
+	} {
+		if enabled {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,24 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateCapabilities(t *testing.T) {
+	require.Equal(t, ProtocolVersion(3).Capabilities(), NegotiateCapabilities(3, ""))
+	require.Equal(t, []string{"data_packets", "packed_stream_id", "protobuf"}, ProtocolVersion(2).Capabilities().Names())
+
+	c := NegotiateCapabilities(5, "resume, e2ee,dynacast,something_new")
+	require.True(t, c.Resume)
+	require.True(t, c.E2EE)
+	require.True(t, c.Dynacast)
+	require.False(t, c.AdaptiveStream)
+	// not known by the server
+	require.Equal(t, []string{"connection_quality", "data_packets", "dynacast", "e2ee", "packed_stream_id", "protobuf",
+		"resume", "speaker_changed", "subscriber_primary", "transceiver_reuse"}, c.Names())
+
+	require.Equal(t, []string{CapabilityAdaptiveStream}, NegotiateCapabilities(0, "adaptive_stream").Names())
+	require.Empty(t, NegotiateCapabilities(0, "something_new").Names())
+}
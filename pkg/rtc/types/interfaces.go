@@ -3,10 +3,11 @@ package types
 import (
 	"time"
 
+	"github.com/livekit/protocol/logger"
 	livekit "github.com/livekit/protocol/proto"
-	"github.com/pion/rtcp"
 	"github.com/pion/webrtc/v3"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/sfu"
 )
@@ -26,15 +27,45 @@ type Participant interface {
 	Identity() string
 	State() livekit.ParticipantInfo_State
 	ProtocolVersion() ProtocolVersion
+	// features the client and the server agreed on when it joined
+	Capabilities() Capabilities
+	// logs who the participant is along with everything else
+	GetLogger() logger.Logger
 	IsReady() bool
 	ConnectedAt() time.Time
 	ToProto() *livekit.ParticipantInfo
-	RTCPChan() chan []rtcp.Packet
+	// RTCPQueue returns the queue of the RTCP feedback written to the publisher
+	RTCPQueue() *sfu.RTCPQueue
 	SetMetadata(metadata string)
+	// Name returns the participant's display name, it can change while the identity can't
+	Name() string
+	SetName(name string)
+	// Attributes returns the attributes set on the participant, and their version
+	Attributes() (map[string]string, uint32)
+	// UpdateAttributes sets the given attributes and keeps the others, empty values remove them
+	UpdateAttributes(attributes map[string]string) error
 	SetPermission(permission *livekit.ParticipantPermission)
+	// UpdateLimits applies the limits of a reloaded RTC config
+	UpdateLimits(conf *config.RTCConfig)
+	// SetDegraded caps the video forwarded to the participant and throttles its keyframe requests
+	// further while the node is overloaded
+	SetDegraded(degraded bool)
+	// SpatialLayerCap returns the highest layer forwarded to the participant while the node is degraded,
+	// sfu.InvalidSpatialLayer when it isn't
+	SpatialLayerCap() int32
 	GetResponseSink() routing.MessageSink
 	SetResponseSink(sink routing.MessageSink)
+	// AckSignal drops the signal responses up to seq, the client received them
+	AckSignal(seq uint32)
+	// ResumeSignal sends the signal responses after seq again once the next response sink is set
+	ResumeSignal(seq uint32)
 	SubscriberMediaEngine() *webrtc.MediaEngine
+	// delay added to media sent to the participant, to emulate network latency
+	SubscriberMediaDelay() time.Duration
+	// paces the media sent to the participant, nil when media is sent as it's forwarded
+	SubscriberPacer() *sfu.Pacer
+	// low power mode the client requested, empty when video is received as usual
+	LowPowerMode() string
 	Negotiate()
 	ICERestart() error
 
@@ -43,6 +74,10 @@ type Participant interface {
 	GetPublishedTracks() []PublishedTrack
 	GetSubscribedTrack(sid string) SubscribedTrack
 	GetSubscribedTracks() []SubscribedTrack
+	// SetSubscriberQuality remembers the highest quality the participant wants to receive a video
+	// track at, applied whenever it's subscribed to
+	SetSubscriberQuality(trackSid string, quality livekit.VideoQuality)
+	SubscriberQuality(trackSid string) (livekit.VideoQuality, bool)
 	HandleOffer(sdp webrtc.SessionDescription) (answer webrtc.SessionDescription, err error)
 	HandleAnswer(sdp webrtc.SessionDescription) error
 	AddICECandidate(candidate webrtc.ICECandidateInit, target livekit.SignalTarget) error
@@ -50,13 +85,27 @@ type Participant interface {
 	RemoveSubscriber(peerId string)
 	SendJoinResponse(info *livekit.Room, otherParticipants []*livekit.ParticipantInfo, iceServers []*livekit.ICEServer) error
 	SendParticipantUpdate(participants []*livekit.ParticipantInfo, updatedAt time.Time) error
+	// SendParticipantPage answers a request for a page of the room's participants
+	SendParticipantPage(requestID string, participants []*livekit.ParticipantInfo, next string) error
 	SendSpeakerUpdate(speakers []*livekit.SpeakerInfo) error
+	// SendTextMessage sends a JSON text message the signal protocol has no message for, like the
+	// response to a text request
+	SendTextMessage(key string, value []byte) error
 	SendDataPacket(packet *livekit.DataPacket) error
+	// SendSubscriptionError tells the participant it couldn't be subscribed to the track, and why
+	SendSubscriptionError(trackSid string, err error)
 	SendRoomUpdate(room *livekit.Room) error
 	SendConnectionQualityUpdate(update *livekit.ConnectionQualityUpdate) error
 	SetTrackMuted(trackId string, muted bool, fromAdmin bool)
+	// SetTrackMetadata attaches metadata to a published or pending track, empty to remove it
+	SetTrackMetadata(trackSid string, metadata string) error
+	// UnpublishTrack stops forwarding a published track and unsubscribes everyone from it
+	UnpublishTrack(trackSid string) error
 	GetAudioLevel() (level uint8, active bool)
+	// SetAudioConfig changes the audio level thresholds of published audio to those of the room
+	SetAudioConfig(conf config.AudioConfig)
 	GetConnectionQuality() livekit.ConnectionQuality
+	GetStats() *ParticipantStats
 	IsSubscribedTo(identity string) bool
 	// returns list of participant identities that the current participant is subscribed to
 	GetSubscribedParticipants() []string
@@ -65,9 +114,21 @@ type Participant interface {
 
 	CanPublish() bool
 	CanSubscribe() bool
+	// CanSubscribeTo returns true when the participant may subscribe to the tracks of the publisher
+	// with the given identity
+	CanSubscribeTo(identity string) bool
 	CanPublishData() bool
+	// approves the joins of participants waiting in the room's waiting room
+	CanApproveJoins() bool
 	Hidden() bool
+	// standard, hidden, recorder, ingress or agent
+	Kind() string
 	SubscriberAsPrimary() bool
+	// has no WebRTC transports, data packets are sent over the signal connection
+	SignalOnly() bool
+	// HandleSignalData handles a data packet the participant sent over the signal connection, it has
+	// no data channels or they failed
+	HandleSignalData(data []byte)
 
 	Start()
 	Close() error
@@ -80,10 +141,17 @@ type Participant interface {
 	// OnTrackUpdated - one of its publishedTracks changed in status
 	OnTrackUpdated(callback func(Participant, PublishedTrack))
 	OnMetadataUpdate(callback func(Participant))
+	// OnAttributesUpdate is called with the keys of the attributes that changed
+	OnAttributesUpdate(callback func(Participant, []string))
 	OnDataPacket(callback func(Participant, *livekit.DataPacket))
 	OnClose(func(Participant))
 
 	// package methods
+	// ReserveSubscription reserves room for subscribing to the track within the subscription limit,
+	// evicting a subscribed track the client hides if needed. AddSubscribedTrack takes the
+	// reservation, ReleaseSubscription gives it up when subscribing failed
+	ReserveSubscription(trackID string) error
+	ReleaseSubscription(trackID string)
 	AddSubscribedTrack(st SubscribedTrack)
 	RemoveSubscribedTrack(st SubscribedTrack)
 	SubscriberPC() *webrtc.PeerConnection
@@ -101,12 +169,17 @@ type PublishedTrack interface {
 	SdpCid() string
 	Kind() livekit.TrackType
 	Name() string
+	// metadata the publisher attached to the track, it can change while the track is published
+	Metadata() string
+	SetMetadata(metadata string)
 	IsMuted() bool
 	SetMuted(muted bool)
 	AddSubscriber(participant Participant) error
 	RemoveSubscriber(participantId string)
 	IsSubscriber(subId string) bool
 	RemoveAllSubscribers()
+	// Close stops receiving the track
+	Close()
 	// returns quality information that's appropriate for width & height
 	GetQualityForDimension(width, height uint32) livekit.VideoQuality
 	// returns number of uptracks that are publishing, registered
@@ -114,6 +187,7 @@ type PublishedTrack interface {
 	PublishLossPercentage() uint32
 	ToProto() *livekit.TrackInfo
 	Receiver() sfu.TrackReceiver
+	GetStats() *PublishedTrackStats
 
 	// callbacks
 	AddOnClose(func())
@@ -127,7 +201,16 @@ type SubscribedTrack interface {
 	IsMuted() bool
 	SetPublisherMuted(muted bool)
 	UpdateSubscriberSettings(enabled bool, quality livekit.VideoQuality)
+	// SetMaxQuality caps the video forwarded to the subscriber at quality
+	SetMaxQuality(quality livekit.VideoQuality)
+	// CapSpatialLayer caps the video forwarded to the subscriber at layer while the node is degraded,
+	// sfu.InvalidSpatialLayer lifts the cap
+	CapSpatialLayer(layer int32)
 	SubscribeLossPercentage() uint32
+	GetStats() *SubscribedTrackStats
+	QualityLabel() string
+	// LastVisible returns when the subscriber last had the track enabled, now while it does
+	LastVisible() time.Time
 }
 
 // interface for properties of webrtc.TrackRemote
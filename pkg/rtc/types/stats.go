@@ -0,0 +1,72 @@
+package types
+
+import (
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+// ParticipantStats is a point in time snapshot of a participant's media statistics
+type ParticipantStats struct {
+	Identity          string                    `json:"identity"`
+	ParticipantID     string                    `json:"participant_id"`
+	ConnectionQuality string                    `json:"connection_quality"`
+	Score             float32                   `json:"score"` // mean opinion score, 1-5
+	QualityHistory    []ConnectionQualitySample `json:"quality_history"`
+	PublishedTracks   []*PublishedTrackStats    `json:"published_tracks"`
+	SubscribedTracks  []*SubscribedTrackStats   `json:"subscribed_tracks"`
+	Name              string                    `json:"name,omitempty"`
+	Kind              string                    `json:"kind"`
+	Attributes        map[string]string         `json:"attributes,omitempty"`
+}
+
+type ConnectionQualitySample struct {
+	Time    time.Time `json:"time"`
+	Quality string    `json:"quality"`
+}
+
+type PublishedTrackStats struct {
+	TrackID string  `json:"track_id"`
+	Name    string  `json:"name"`
+	Kind    string  `json:"kind"`
+	Muted   bool    `json:"muted"`
+	Score   float32 `json:"score,omitempty"`
+	// upstream loss and round trip time the score was computed from
+	LossPercentage float32          `json:"loss_percentage"`
+	RTTMs          uint32           `json:"rtt_ms,omitempty"`
+	Layers         []sfu.LayerStats `json:"layers"`
+}
+
+type SubscribedTrackStats struct {
+	TrackID           string  `json:"track_id"`
+	PublisherIdentity string  `json:"publisher_identity"`
+	Kind              string  `json:"kind"`
+	Score             float32 `json:"score,omitempty"`
+	QualityLabel      string  `json:"quality_label,omitempty"`
+	sfu.DownTrackStats
+}
+
+// ParticipantSummary is a participant along with what it receives, so a room can be rendered as a
+// matrix of who receives what from a single request
+type ParticipantSummary struct {
+	Info              *livekit.ParticipantInfo `json:"info"`
+	ConnectionQuality string                   `json:"connection_quality"`
+	Score             float32                  `json:"score"`
+	Subscriptions     []*SubscriptionSummary   `json:"subscriptions"`
+}
+
+type SubscriptionSummary struct {
+	TrackID           string  `json:"track_id"`
+	PublisherIdentity string  `json:"publisher_identity"`
+	Kind              string  `json:"kind"`
+	Muted             bool    `json:"muted"`
+	Score             float32 `json:"score,omitempty"`
+	// video only, layers being forwarded and the highest the subscriber wants
+	CurrentSpatialLayer  int32  `json:"current_spatial_layer"`
+	CurrentTemporalLayer int32  `json:"current_temporal_layer"`
+	MaxSpatialLayer      int32  `json:"max_spatial_layer"`
+	ForwardingStatus     string `json:"forwarding_status,omitempty"`
+	QualityLabel         string `json:"quality_label,omitempty"`
+}
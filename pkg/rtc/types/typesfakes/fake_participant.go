@@ -5,14 +5,21 @@ import (
 	"sync"
 	"time"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/protocol/logger"
 	livekit "github.com/livekit/protocol/proto"
-	"github.com/pion/rtcp"
 	webrtc "github.com/pion/webrtc/v3"
 )
 
 type FakeParticipant struct {
+	AckSignalStub        func(uint32)
+	ackSignalMutex       sync.RWMutex
+	ackSignalArgsForCall []struct {
+		arg1 uint32
+	}
 	AddICECandidateStub        func(webrtc.ICECandidateInit, livekit.SignalTarget) error
 	addICECandidateMutex       sync.RWMutex
 	addICECandidateArgsForCall []struct {
@@ -48,6 +55,28 @@ type FakeParticipant struct {
 	addTrackArgsForCall []struct {
 		arg1 *livekit.AddTrackRequest
 	}
+	AttributesStub        func() (map[string]string, uint32)
+	attributesMutex       sync.RWMutex
+	attributesArgsForCall []struct {
+	}
+	attributesReturns struct {
+		result1 map[string]string
+		result2 uint32
+	}
+	attributesReturnsOnCall map[int]struct {
+		result1 map[string]string
+		result2 uint32
+	}
+	CanApproveJoinsStub        func() bool
+	canApproveJoinsMutex       sync.RWMutex
+	canApproveJoinsArgsForCall []struct {
+	}
+	canApproveJoinsReturns struct {
+		result1 bool
+	}
+	canApproveJoinsReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	CanPublishStub        func() bool
 	canPublishMutex       sync.RWMutex
 	canPublishArgsForCall []struct {
@@ -78,6 +107,27 @@ type FakeParticipant struct {
 	canSubscribeReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	CanSubscribeToStub        func(string) bool
+	canSubscribeToMutex       sync.RWMutex
+	canSubscribeToArgsForCall []struct {
+		arg1 string
+	}
+	canSubscribeToReturns struct {
+		result1 bool
+	}
+	canSubscribeToReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	CapabilitiesStub        func() types.Capabilities
+	capabilitiesMutex       sync.RWMutex
+	capabilitiesArgsForCall []struct {
+	}
+	capabilitiesReturns struct {
+		result1 types.Capabilities
+	}
+	capabilitiesReturnsOnCall map[int]struct {
+		result1 types.Capabilities
+	}
 	CloseStub        func() error
 	closeMutex       sync.RWMutex
 	closeArgsForCall []struct {
@@ -130,6 +180,16 @@ type FakeParticipant struct {
 	getConnectionQualityReturnsOnCall map[int]struct {
 		result1 livekit.ConnectionQuality
 	}
+	GetLoggerStub        func() logger.Logger
+	getLoggerMutex       sync.RWMutex
+	getLoggerArgsForCall []struct {
+	}
+	getLoggerReturns struct {
+		result1 logger.Logger
+	}
+	getLoggerReturnsOnCall map[int]struct {
+		result1 logger.Logger
+	}
 	GetPublishedTrackStub        func(string) types.PublishedTrack
 	getPublishedTrackMutex       sync.RWMutex
 	getPublishedTrackArgsForCall []struct {
@@ -161,6 +221,16 @@ type FakeParticipant struct {
 	getResponseSinkReturnsOnCall map[int]struct {
 		result1 routing.MessageSink
 	}
+	GetStatsStub        func() *types.ParticipantStats
+	getStatsMutex       sync.RWMutex
+	getStatsArgsForCall []struct {
+	}
+	getStatsReturns struct {
+		result1 *types.ParticipantStats
+	}
+	getStatsReturnsOnCall map[int]struct {
+		result1 *types.ParticipantStats
+	}
 	GetSubscribedParticipantsStub        func() []string
 	getSubscribedParticipantsMutex       sync.RWMutex
 	getSubscribedParticipantsArgsForCall []struct {
@@ -216,6 +286,11 @@ type FakeParticipant struct {
 		result1 webrtc.SessionDescription
 		result2 error
 	}
+	HandleSignalDataStub        func([]byte)
+	handleSignalDataMutex       sync.RWMutex
+	handleSignalDataArgsForCall []struct {
+		arg1 []byte
+	}
 	HiddenStub        func() bool
 	hiddenMutex       sync.RWMutex
 	hiddenArgsForCall []struct {
@@ -277,10 +352,45 @@ type FakeParticipant struct {
 	isSubscribedToReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	KindStub        func() string
+	kindMutex       sync.RWMutex
+	kindArgsForCall []struct {
+	}
+	kindReturns struct {
+		result1 string
+	}
+	kindReturnsOnCall map[int]struct {
+		result1 string
+	}
+	LowPowerModeStub        func() string
+	lowPowerModeMutex       sync.RWMutex
+	lowPowerModeArgsForCall []struct {
+	}
+	lowPowerModeReturns struct {
+		result1 string
+	}
+	lowPowerModeReturnsOnCall map[int]struct {
+		result1 string
+	}
+	NameStub        func() string
+	nameMutex       sync.RWMutex
+	nameArgsForCall []struct {
+	}
+	nameReturns struct {
+		result1 string
+	}
+	nameReturnsOnCall map[int]struct {
+		result1 string
+	}
 	NegotiateStub        func()
 	negotiateMutex       sync.RWMutex
 	negotiateArgsForCall []struct {
 	}
+	OnAttributesUpdateStub        func(func(types.Participant, []string))
+	onAttributesUpdateMutex       sync.RWMutex
+	onAttributesUpdateArgsForCall []struct {
+		arg1 func(types.Participant, []string)
+	}
 	OnCloseStub        func(func(types.Participant))
 	onCloseMutex       sync.RWMutex
 	onCloseArgsForCall []struct {
@@ -321,15 +431,20 @@ type FakeParticipant struct {
 	protocolVersionReturnsOnCall map[int]struct {
 		result1 types.ProtocolVersion
 	}
-	RTCPChanStub        func() chan []rtcp.Packet
-	rTCPChanMutex       sync.RWMutex
-	rTCPChanArgsForCall []struct {
+	RTCPQueueStub        func() *sfu.RTCPQueue
+	rTCPQueueMutex       sync.RWMutex
+	rTCPQueueArgsForCall []struct {
+	}
+	rTCPQueueReturns struct {
+		result1 *sfu.RTCPQueue
 	}
-	rTCPChanReturns struct {
-		result1 chan []rtcp.Packet
+	rTCPQueueReturnsOnCall map[int]struct {
+		result1 *sfu.RTCPQueue
 	}
-	rTCPChanReturnsOnCall map[int]struct {
-		result1 chan []rtcp.Packet
+	ReleaseSubscriptionStub        func(string)
+	releaseSubscriptionMutex       sync.RWMutex
+	releaseSubscriptionArgsForCall []struct {
+		arg1 string
 	}
 	RemoveSubscribedTrackStub        func(types.SubscribedTrack)
 	removeSubscribedTrackMutex       sync.RWMutex
@@ -341,6 +456,22 @@ type FakeParticipant struct {
 	removeSubscriberArgsForCall []struct {
 		arg1 string
 	}
+	ReserveSubscriptionStub        func(string) error
+	reserveSubscriptionMutex       sync.RWMutex
+	reserveSubscriptionArgsForCall []struct {
+		arg1 string
+	}
+	reserveSubscriptionReturns struct {
+		result1 error
+	}
+	reserveSubscriptionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ResumeSignalStub        func(uint32)
+	resumeSignalMutex       sync.RWMutex
+	resumeSignalArgsForCall []struct {
+		arg1 uint32
+	}
 	SendConnectionQualityUpdateStub        func(*livekit.ConnectionQualityUpdate) error
 	sendConnectionQualityUpdateMutex       sync.RWMutex
 	sendConnectionQualityUpdateArgsForCall []struct {
@@ -376,6 +507,19 @@ type FakeParticipant struct {
 	sendJoinResponseReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SendParticipantPageStub        func(string, []*livekit.ParticipantInfo, string) error
+	sendParticipantPageMutex       sync.RWMutex
+	sendParticipantPageArgsForCall []struct {
+		arg1 string
+		arg2 []*livekit.ParticipantInfo
+		arg3 string
+	}
+	sendParticipantPageReturns struct {
+		result1 error
+	}
+	sendParticipantPageReturnsOnCall map[int]struct {
+		result1 error
+	}
 	SendParticipantUpdateStub        func([]*livekit.ParticipantInfo, time.Time) error
 	sendParticipantUpdateMutex       sync.RWMutex
 	sendParticipantUpdateArgsForCall []struct {
@@ -410,11 +554,44 @@ type FakeParticipant struct {
 	sendSpeakerUpdateReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SendSubscriptionErrorStub        func(string, error)
+	sendSubscriptionErrorMutex       sync.RWMutex
+	sendSubscriptionErrorArgsForCall []struct {
+		arg1 string
+		arg2 error
+	}
+	SendTextMessageStub        func(string, []byte) error
+	sendTextMessageMutex       sync.RWMutex
+	sendTextMessageArgsForCall []struct {
+		arg1 string
+		arg2 []byte
+	}
+	sendTextMessageReturns struct {
+		result1 error
+	}
+	sendTextMessageReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SetAudioConfigStub        func(config.AudioConfig)
+	setAudioConfigMutex       sync.RWMutex
+	setAudioConfigArgsForCall []struct {
+		arg1 config.AudioConfig
+	}
+	SetDegradedStub        func(bool)
+	setDegradedMutex       sync.RWMutex
+	setDegradedArgsForCall []struct {
+		arg1 bool
+	}
 	SetMetadataStub        func(string)
 	setMetadataMutex       sync.RWMutex
 	setMetadataArgsForCall []struct {
 		arg1 string
 	}
+	SetNameStub        func(string)
+	setNameMutex       sync.RWMutex
+	setNameArgsForCall []struct {
+		arg1 string
+	}
 	SetPermissionStub        func(*livekit.ParticipantPermission)
 	setPermissionMutex       sync.RWMutex
 	setPermissionArgsForCall []struct {
@@ -425,6 +602,24 @@ type FakeParticipant struct {
 	setResponseSinkArgsForCall []struct {
 		arg1 routing.MessageSink
 	}
+	SetSubscriberQualityStub        func(string, livekit.VideoQuality)
+	setSubscriberQualityMutex       sync.RWMutex
+	setSubscriberQualityArgsForCall []struct {
+		arg1 string
+		arg2 livekit.VideoQuality
+	}
+	SetTrackMetadataStub        func(string, string) error
+	setTrackMetadataMutex       sync.RWMutex
+	setTrackMetadataArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	setTrackMetadataReturns struct {
+		result1 error
+	}
+	setTrackMetadataReturnsOnCall map[int]struct {
+		result1 error
+	}
 	SetTrackMutedStub        func(string, bool, bool)
 	setTrackMutedMutex       sync.RWMutex
 	setTrackMutedArgsForCall []struct {
@@ -432,10 +627,30 @@ type FakeParticipant struct {
 		arg2 bool
 		arg3 bool
 	}
+	SignalOnlyStub        func() bool
+	signalOnlyMutex       sync.RWMutex
+	signalOnlyArgsForCall []struct {
+	}
+	signalOnlyReturns struct {
+		result1 bool
+	}
+	signalOnlyReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	StartStub        func()
 	startMutex       sync.RWMutex
 	startArgsForCall []struct {
 	}
+	SpatialLayerCapStub        func() int32
+	spatialLayerCapMutex       sync.RWMutex
+	spatialLayerCapArgsForCall []struct {
+	}
+	spatialLayerCapReturns struct {
+		result1 int32
+	}
+	spatialLayerCapReturnsOnCall map[int]struct {
+		result1 int32
+	}
 	StateStub        func() livekit.ParticipantInfo_State
 	stateMutex       sync.RWMutex
 	stateArgsForCall []struct {
@@ -456,6 +671,16 @@ type FakeParticipant struct {
 	subscriberAsPrimaryReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	SubscriberMediaDelayStub        func() time.Duration
+	subscriberMediaDelayMutex       sync.RWMutex
+	subscriberMediaDelayArgsForCall []struct {
+	}
+	subscriberMediaDelayReturns struct {
+		result1 time.Duration
+	}
+	subscriberMediaDelayReturnsOnCall map[int]struct {
+		result1 time.Duration
+	}
 	SubscriberMediaEngineStub        func() *webrtc.MediaEngine
 	subscriberMediaEngineMutex       sync.RWMutex
 	subscriberMediaEngineArgsForCall []struct {
@@ -476,6 +701,29 @@ type FakeParticipant struct {
 	subscriberPCReturnsOnCall map[int]struct {
 		result1 *webrtc.PeerConnection
 	}
+	SubscriberPacerStub        func() *sfu.Pacer
+	subscriberPacerMutex       sync.RWMutex
+	subscriberPacerArgsForCall []struct {
+	}
+	subscriberPacerReturns struct {
+		result1 *sfu.Pacer
+	}
+	subscriberPacerReturnsOnCall map[int]struct {
+		result1 *sfu.Pacer
+	}
+	SubscriberQualityStub        func(string) (livekit.VideoQuality, bool)
+	subscriberQualityMutex       sync.RWMutex
+	subscriberQualityArgsForCall []struct {
+		arg1 string
+	}
+	subscriberQualityReturns struct {
+		result1 livekit.VideoQuality
+		result2 bool
+	}
+	subscriberQualityReturnsOnCall map[int]struct {
+		result1 livekit.VideoQuality
+		result2 bool
+	}
 	ToProtoStub        func() *livekit.ParticipantInfo
 	toProtoMutex       sync.RWMutex
 	toProtoArgsForCall []struct {
@@ -486,10 +734,69 @@ type FakeParticipant struct {
 	toProtoReturnsOnCall map[int]struct {
 		result1 *livekit.ParticipantInfo
 	}
+	UnpublishTrackStub        func(string) error
+	unpublishTrackMutex       sync.RWMutex
+	unpublishTrackArgsForCall []struct {
+		arg1 string
+	}
+	unpublishTrackReturns struct {
+		result1 error
+	}
+	unpublishTrackReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UpdateAttributesStub        func(map[string]string) error
+	updateAttributesMutex       sync.RWMutex
+	updateAttributesArgsForCall []struct {
+		arg1 map[string]string
+	}
+	updateAttributesReturns struct {
+		result1 error
+	}
+	updateAttributesReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UpdateLimitsStub        func(*config.RTCConfig)
+	updateLimitsMutex       sync.RWMutex
+	updateLimitsArgsForCall []struct {
+		arg1 *config.RTCConfig
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeParticipant) AckSignal(arg1 uint32) {
+	fake.ackSignalMutex.Lock()
+	fake.ackSignalArgsForCall = append(fake.ackSignalArgsForCall, struct {
+		arg1 uint32
+	}{arg1})
+	stub := fake.AckSignalStub
+	fake.recordInvocation("AckSignal", []interface{}{arg1})
+	fake.ackSignalMutex.Unlock()
+	if stub != nil {
+		fake.AckSignalStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) AckSignalCallCount() int {
+	fake.ackSignalMutex.RLock()
+	defer fake.ackSignalMutex.RUnlock()
+	return len(fake.ackSignalArgsForCall)
+}
+
+func (fake *FakeParticipant) AckSignalCalls(stub func(uint32)) {
+	fake.ackSignalMutex.Lock()
+	defer fake.ackSignalMutex.Unlock()
+	fake.AckSignalStub = stub
+}
+
+func (fake *FakeParticipant) AckSignalArgsForCall(i int) uint32 {
+	fake.ackSignalMutex.RLock()
+	defer fake.ackSignalMutex.RUnlock()
+	argsForCall := fake.ackSignalArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeParticipant) AddICECandidate(arg1 webrtc.ICECandidateInit, arg2 livekit.SignalTarget) error {
 	fake.addICECandidateMutex.Lock()
 	ret, specificReturn := fake.addICECandidateReturnsOnCall[len(fake.addICECandidateArgsForCall)]
@@ -680,6 +987,115 @@ func (fake *FakeParticipant) AddTrackArgsForCall(i int) *livekit.AddTrackRequest
 	return argsForCall.arg1
 }
 
+func (fake *FakeParticipant) Attributes() (map[string]string, uint32) {
+	fake.attributesMutex.Lock()
+	ret, specificReturn := fake.attributesReturnsOnCall[len(fake.attributesArgsForCall)]
+	fake.attributesArgsForCall = append(fake.attributesArgsForCall, struct {
+	}{})
+	stub := fake.AttributesStub
+	fakeReturns := fake.attributesReturns
+	fake.recordInvocation("Attributes", []interface{}{})
+	fake.attributesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeParticipant) AttributesCallCount() int {
+	fake.attributesMutex.RLock()
+	defer fake.attributesMutex.RUnlock()
+	return len(fake.attributesArgsForCall)
+}
+
+func (fake *FakeParticipant) AttributesCalls(stub func() (map[string]string, uint32)) {
+	fake.attributesMutex.Lock()
+	defer fake.attributesMutex.Unlock()
+	fake.AttributesStub = stub
+}
+
+func (fake *FakeParticipant) AttributesReturns(result1 map[string]string, result2 uint32) {
+	fake.attributesMutex.Lock()
+	defer fake.attributesMutex.Unlock()
+	fake.AttributesStub = nil
+	fake.attributesReturns = struct {
+		result1 map[string]string
+		result2 uint32
+	}{result1, result2}
+}
+
+func (fake *FakeParticipant) AttributesReturnsOnCall(i int, result1 map[string]string, result2 uint32) {
+	fake.attributesMutex.Lock()
+	defer fake.attributesMutex.Unlock()
+	fake.AttributesStub = nil
+	if fake.attributesReturnsOnCall == nil {
+		fake.attributesReturnsOnCall = make(map[int]struct {
+			result1 map[string]string
+			result2 uint32
+		})
+	}
+	fake.attributesReturnsOnCall[i] = struct {
+		result1 map[string]string
+		result2 uint32
+	}{result1, result2}
+}
+
+func (fake *FakeParticipant) CanApproveJoins() bool {
+	fake.canApproveJoinsMutex.Lock()
+	ret, specificReturn := fake.canApproveJoinsReturnsOnCall[len(fake.canApproveJoinsArgsForCall)]
+	fake.canApproveJoinsArgsForCall = append(fake.canApproveJoinsArgsForCall, struct {
+	}{})
+	stub := fake.CanApproveJoinsStub
+	fakeReturns := fake.canApproveJoinsReturns
+	fake.recordInvocation("CanApproveJoins", []interface{}{})
+	fake.canApproveJoinsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) CanApproveJoinsCallCount() int {
+	fake.canApproveJoinsMutex.RLock()
+	defer fake.canApproveJoinsMutex.RUnlock()
+	return len(fake.canApproveJoinsArgsForCall)
+}
+
+func (fake *FakeParticipant) CanApproveJoinsCalls(stub func() bool) {
+	fake.canApproveJoinsMutex.Lock()
+	defer fake.canApproveJoinsMutex.Unlock()
+	fake.CanApproveJoinsStub = stub
+}
+
+func (fake *FakeParticipant) CanApproveJoinsReturns(result1 bool) {
+	fake.canApproveJoinsMutex.Lock()
+	defer fake.canApproveJoinsMutex.Unlock()
+	fake.CanApproveJoinsStub = nil
+	fake.canApproveJoinsReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeParticipant) CanApproveJoinsReturnsOnCall(i int, result1 bool) {
+	fake.canApproveJoinsMutex.Lock()
+	defer fake.canApproveJoinsMutex.Unlock()
+	fake.CanApproveJoinsStub = nil
+	if fake.canApproveJoinsReturnsOnCall == nil {
+		fake.canApproveJoinsReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.canApproveJoinsReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *FakeParticipant) CanPublish() bool {
 	fake.canPublishMutex.Lock()
 	ret, specificReturn := fake.canPublishReturnsOnCall[len(fake.canPublishArgsForCall)]
@@ -699,6 +1115,8 @@ func (fake *FakeParticipant) CanPublish() bool {
 }
 
 func (fake *FakeParticipant) CanPublishCallCount() int {
+	fake.canApproveJoinsMutex.RLock()
+	defer fake.canApproveJoinsMutex.RUnlock()
 	fake.canPublishMutex.RLock()
 	defer fake.canPublishMutex.RUnlock()
 	return len(fake.canPublishArgsForCall)
@@ -839,6 +1257,120 @@ func (fake *FakeParticipant) CanSubscribeReturnsOnCall(i int, result1 bool) {
 	}{result1}
 }
 
+func (fake *FakeParticipant) CanSubscribeTo(arg1 string) bool {
+	fake.canSubscribeToMutex.Lock()
+	ret, specificReturn := fake.canSubscribeToReturnsOnCall[len(fake.canSubscribeToArgsForCall)]
+	fake.canSubscribeToArgsForCall = append(fake.canSubscribeToArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.CanSubscribeToStub
+	fakeReturns := fake.canSubscribeToReturns
+	fake.recordInvocation("CanSubscribeTo", []interface{}{arg1})
+	fake.canSubscribeToMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) CanSubscribeToCallCount() int {
+	fake.canSubscribeToMutex.RLock()
+	defer fake.canSubscribeToMutex.RUnlock()
+	return len(fake.canSubscribeToArgsForCall)
+}
+
+func (fake *FakeParticipant) CanSubscribeToCalls(stub func(string) bool) {
+	fake.canSubscribeToMutex.Lock()
+	defer fake.canSubscribeToMutex.Unlock()
+	fake.CanSubscribeToStub = stub
+}
+
+func (fake *FakeParticipant) CanSubscribeToArgsForCall(i int) string {
+	fake.canSubscribeToMutex.RLock()
+	defer fake.canSubscribeToMutex.RUnlock()
+	argsForCall := fake.canSubscribeToArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) CanSubscribeToReturns(result1 bool) {
+	fake.canSubscribeToMutex.Lock()
+	defer fake.canSubscribeToMutex.Unlock()
+	fake.CanSubscribeToStub = nil
+	fake.canSubscribeToReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeParticipant) CanSubscribeToReturnsOnCall(i int, result1 bool) {
+	fake.canSubscribeToMutex.Lock()
+	defer fake.canSubscribeToMutex.Unlock()
+	fake.CanSubscribeToStub = nil
+	if fake.canSubscribeToReturnsOnCall == nil {
+		fake.canSubscribeToReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.canSubscribeToReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeParticipant) Capabilities() types.Capabilities {
+	fake.capabilitiesMutex.Lock()
+	ret, specificReturn := fake.capabilitiesReturnsOnCall[len(fake.capabilitiesArgsForCall)]
+	fake.capabilitiesArgsForCall = append(fake.capabilitiesArgsForCall, struct {
+	}{})
+	stub := fake.CapabilitiesStub
+	fakeReturns := fake.capabilitiesReturns
+	fake.recordInvocation("Capabilities", []interface{}{})
+	fake.capabilitiesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) CapabilitiesCallCount() int {
+	fake.capabilitiesMutex.RLock()
+	defer fake.capabilitiesMutex.RUnlock()
+	return len(fake.capabilitiesArgsForCall)
+}
+
+func (fake *FakeParticipant) CapabilitiesCalls(stub func() types.Capabilities) {
+	fake.capabilitiesMutex.Lock()
+	defer fake.capabilitiesMutex.Unlock()
+	fake.CapabilitiesStub = stub
+}
+
+func (fake *FakeParticipant) CapabilitiesReturns(result1 types.Capabilities) {
+	fake.capabilitiesMutex.Lock()
+	defer fake.capabilitiesMutex.Unlock()
+	fake.CapabilitiesStub = nil
+	fake.capabilitiesReturns = struct {
+		result1 types.Capabilities
+	}{result1}
+}
+
+func (fake *FakeParticipant) CapabilitiesReturnsOnCall(i int, result1 types.Capabilities) {
+	fake.capabilitiesMutex.Lock()
+	defer fake.capabilitiesMutex.Unlock()
+	fake.CapabilitiesStub = nil
+	if fake.capabilitiesReturnsOnCall == nil {
+		fake.capabilitiesReturnsOnCall = make(map[int]struct {
+			result1 types.Capabilities
+		})
+	}
+	fake.capabilitiesReturnsOnCall[i] = struct {
+		result1 types.Capabilities
+	}{result1}
+}
+
 func (fake *FakeParticipant) Close() error {
 	fake.closeMutex.Lock()
 	ret, specificReturn := fake.closeReturnsOnCall[len(fake.closeArgsForCall)]
@@ -1107,6 +1639,59 @@ func (fake *FakeParticipant) GetConnectionQualityReturnsOnCall(i int, result1 li
 	}{result1}
 }
 
+func (fake *FakeParticipant) GetLogger() logger.Logger {
+	fake.getLoggerMutex.Lock()
+	ret, specificReturn := fake.getLoggerReturnsOnCall[len(fake.getLoggerArgsForCall)]
+	fake.getLoggerArgsForCall = append(fake.getLoggerArgsForCall, struct {
+	}{})
+	stub := fake.GetLoggerStub
+	fakeReturns := fake.getLoggerReturns
+	fake.recordInvocation("GetLogger", []interface{}{})
+	fake.getLoggerMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) GetLoggerCallCount() int {
+	fake.getLoggerMutex.RLock()
+	defer fake.getLoggerMutex.RUnlock()
+	return len(fake.getLoggerArgsForCall)
+}
+
+func (fake *FakeParticipant) GetLoggerCalls(stub func() logger.Logger) {
+	fake.getLoggerMutex.Lock()
+	defer fake.getLoggerMutex.Unlock()
+	fake.GetLoggerStub = stub
+}
+
+func (fake *FakeParticipant) GetLoggerReturns(result1 logger.Logger) {
+	fake.getLoggerMutex.Lock()
+	defer fake.getLoggerMutex.Unlock()
+	fake.GetLoggerStub = nil
+	fake.getLoggerReturns = struct {
+		result1 logger.Logger
+	}{result1}
+}
+
+func (fake *FakeParticipant) GetLoggerReturnsOnCall(i int, result1 logger.Logger) {
+	fake.getLoggerMutex.Lock()
+	defer fake.getLoggerMutex.Unlock()
+	fake.GetLoggerStub = nil
+	if fake.getLoggerReturnsOnCall == nil {
+		fake.getLoggerReturnsOnCall = make(map[int]struct {
+			result1 logger.Logger
+		})
+	}
+	fake.getLoggerReturnsOnCall[i] = struct {
+		result1 logger.Logger
+	}{result1}
+}
+
 func (fake *FakeParticipant) GetPublishedTrack(arg1 string) types.PublishedTrack {
 	fake.getPublishedTrackMutex.Lock()
 	ret, specificReturn := fake.getPublishedTrackReturnsOnCall[len(fake.getPublishedTrackArgsForCall)]
@@ -1274,15 +1859,15 @@ func (fake *FakeParticipant) GetResponseSinkReturnsOnCall(i int, result1 routing
 	}{result1}
 }
 
-func (fake *FakeParticipant) GetSubscribedParticipants() []string {
-	fake.getSubscribedParticipantsMutex.Lock()
-	ret, specificReturn := fake.getSubscribedParticipantsReturnsOnCall[len(fake.getSubscribedParticipantsArgsForCall)]
-	fake.getSubscribedParticipantsArgsForCall = append(fake.getSubscribedParticipantsArgsForCall, struct {
+func (fake *FakeParticipant) GetStats() *types.ParticipantStats {
+	fake.getStatsMutex.Lock()
+	ret, specificReturn := fake.getStatsReturnsOnCall[len(fake.getStatsArgsForCall)]
+	fake.getStatsArgsForCall = append(fake.getStatsArgsForCall, struct {
 	}{})
-	stub := fake.GetSubscribedParticipantsStub
-	fakeReturns := fake.getSubscribedParticipantsReturns
-	fake.recordInvocation("GetSubscribedParticipants", []interface{}{})
-	fake.getSubscribedParticipantsMutex.Unlock()
+	stub := fake.GetStatsStub
+	fakeReturns := fake.getStatsReturns
+	fake.recordInvocation("GetStats", []interface{}{})
+	fake.getStatsMutex.Unlock()
 	if stub != nil {
 		return stub()
 	}
@@ -1292,15 +1877,68 @@ func (fake *FakeParticipant) GetSubscribedParticipants() []string {
 	return fakeReturns.result1
 }
 
-func (fake *FakeParticipant) GetSubscribedParticipantsCallCount() int {
-	fake.getSubscribedParticipantsMutex.RLock()
-	defer fake.getSubscribedParticipantsMutex.RUnlock()
-	return len(fake.getSubscribedParticipantsArgsForCall)
+func (fake *FakeParticipant) GetStatsCallCount() int {
+	fake.getStatsMutex.RLock()
+	defer fake.getStatsMutex.RUnlock()
+	return len(fake.getStatsArgsForCall)
 }
 
-func (fake *FakeParticipant) GetSubscribedParticipantsCalls(stub func() []string) {
-	fake.getSubscribedParticipantsMutex.Lock()
-	defer fake.getSubscribedParticipantsMutex.Unlock()
+func (fake *FakeParticipant) GetStatsCalls(stub func() *types.ParticipantStats) {
+	fake.getStatsMutex.Lock()
+	defer fake.getStatsMutex.Unlock()
+	fake.GetStatsStub = stub
+}
+
+func (fake *FakeParticipant) GetStatsReturns(result1 *types.ParticipantStats) {
+	fake.getStatsMutex.Lock()
+	defer fake.getStatsMutex.Unlock()
+	fake.GetStatsStub = nil
+	fake.getStatsReturns = struct {
+		result1 *types.ParticipantStats
+	}{result1}
+}
+
+func (fake *FakeParticipant) GetStatsReturnsOnCall(i int, result1 *types.ParticipantStats) {
+	fake.getStatsMutex.Lock()
+	defer fake.getStatsMutex.Unlock()
+	fake.GetStatsStub = nil
+	if fake.getStatsReturnsOnCall == nil {
+		fake.getStatsReturnsOnCall = make(map[int]struct {
+			result1 *types.ParticipantStats
+		})
+	}
+	fake.getStatsReturnsOnCall[i] = struct {
+		result1 *types.ParticipantStats
+	}{result1}
+}
+
+func (fake *FakeParticipant) GetSubscribedParticipants() []string {
+	fake.getSubscribedParticipantsMutex.Lock()
+	ret, specificReturn := fake.getSubscribedParticipantsReturnsOnCall[len(fake.getSubscribedParticipantsArgsForCall)]
+	fake.getSubscribedParticipantsArgsForCall = append(fake.getSubscribedParticipantsArgsForCall, struct {
+	}{})
+	stub := fake.GetSubscribedParticipantsStub
+	fakeReturns := fake.getSubscribedParticipantsReturns
+	fake.recordInvocation("GetSubscribedParticipants", []interface{}{})
+	fake.getSubscribedParticipantsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) GetSubscribedParticipantsCallCount() int {
+	fake.getSubscribedParticipantsMutex.RLock()
+	defer fake.getSubscribedParticipantsMutex.RUnlock()
+	return len(fake.getSubscribedParticipantsArgsForCall)
+}
+
+func (fake *FakeParticipant) GetSubscribedParticipantsCalls(stub func() []string) {
+	fake.getSubscribedParticipantsMutex.Lock()
+	defer fake.getSubscribedParticipantsMutex.Unlock()
 	fake.GetSubscribedParticipantsStub = stub
 }
 
@@ -1566,6 +2204,43 @@ func (fake *FakeParticipant) HandleOfferReturnsOnCall(i int, result1 webrtc.Sess
 	}{result1, result2}
 }
 
+func (fake *FakeParticipant) HandleSignalData(arg1 []byte) {
+	var arg1Copy []byte
+	if arg1 != nil {
+		arg1Copy = make([]byte, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.handleSignalDataMutex.Lock()
+	fake.handleSignalDataArgsForCall = append(fake.handleSignalDataArgsForCall, struct {
+		arg1 []byte
+	}{arg1Copy})
+	stub := fake.HandleSignalDataStub
+	fake.recordInvocation("HandleSignalData", []interface{}{arg1Copy})
+	fake.handleSignalDataMutex.Unlock()
+	if stub != nil {
+		fake.HandleSignalDataStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) HandleSignalDataCallCount() int {
+	fake.handleSignalDataMutex.RLock()
+	defer fake.handleSignalDataMutex.RUnlock()
+	return len(fake.handleSignalDataArgsForCall)
+}
+
+func (fake *FakeParticipant) HandleSignalDataCalls(stub func([]byte)) {
+	fake.handleSignalDataMutex.Lock()
+	defer fake.handleSignalDataMutex.Unlock()
+	fake.HandleSignalDataStub = stub
+}
+
+func (fake *FakeParticipant) HandleSignalDataArgsForCall(i int) []byte {
+	fake.handleSignalDataMutex.RLock()
+	defer fake.handleSignalDataMutex.RUnlock()
+	argsForCall := fake.handleSignalDataArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeParticipant) Hidden() bool {
 	fake.hiddenMutex.Lock()
 	ret, specificReturn := fake.hiddenReturnsOnCall[len(fake.hiddenArgsForCall)]
@@ -1892,6 +2567,165 @@ func (fake *FakeParticipant) IsSubscribedToReturnsOnCall(i int, result1 bool) {
 	}{result1}
 }
 
+func (fake *FakeParticipant) Kind() string {
+	fake.kindMutex.Lock()
+	ret, specificReturn := fake.kindReturnsOnCall[len(fake.kindArgsForCall)]
+	fake.kindArgsForCall = append(fake.kindArgsForCall, struct {
+	}{})
+	stub := fake.KindStub
+	fakeReturns := fake.kindReturns
+	fake.recordInvocation("Kind", []interface{}{})
+	fake.kindMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) KindCallCount() int {
+	fake.kindMutex.RLock()
+	defer fake.kindMutex.RUnlock()
+	return len(fake.kindArgsForCall)
+}
+
+func (fake *FakeParticipant) KindCalls(stub func() string) {
+	fake.kindMutex.Lock()
+	defer fake.kindMutex.Unlock()
+	fake.KindStub = stub
+}
+
+func (fake *FakeParticipant) KindReturns(result1 string) {
+	fake.kindMutex.Lock()
+	defer fake.kindMutex.Unlock()
+	fake.KindStub = nil
+	fake.kindReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeParticipant) KindReturnsOnCall(i int, result1 string) {
+	fake.kindMutex.Lock()
+	defer fake.kindMutex.Unlock()
+	fake.KindStub = nil
+	if fake.kindReturnsOnCall == nil {
+		fake.kindReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.kindReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeParticipant) LowPowerMode() string {
+	fake.lowPowerModeMutex.Lock()
+	ret, specificReturn := fake.lowPowerModeReturnsOnCall[len(fake.lowPowerModeArgsForCall)]
+	fake.lowPowerModeArgsForCall = append(fake.lowPowerModeArgsForCall, struct {
+	}{})
+	stub := fake.LowPowerModeStub
+	fakeReturns := fake.lowPowerModeReturns
+	fake.recordInvocation("LowPowerMode", []interface{}{})
+	fake.lowPowerModeMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) LowPowerModeCallCount() int {
+	fake.lowPowerModeMutex.RLock()
+	defer fake.lowPowerModeMutex.RUnlock()
+	return len(fake.lowPowerModeArgsForCall)
+}
+
+func (fake *FakeParticipant) LowPowerModeCalls(stub func() string) {
+	fake.lowPowerModeMutex.Lock()
+	defer fake.lowPowerModeMutex.Unlock()
+	fake.LowPowerModeStub = stub
+}
+
+func (fake *FakeParticipant) LowPowerModeReturns(result1 string) {
+	fake.lowPowerModeMutex.Lock()
+	defer fake.lowPowerModeMutex.Unlock()
+	fake.LowPowerModeStub = nil
+	fake.lowPowerModeReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeParticipant) LowPowerModeReturnsOnCall(i int, result1 string) {
+	fake.lowPowerModeMutex.Lock()
+	defer fake.lowPowerModeMutex.Unlock()
+	fake.LowPowerModeStub = nil
+	if fake.lowPowerModeReturnsOnCall == nil {
+		fake.lowPowerModeReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.lowPowerModeReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeParticipant) Name() string {
+	fake.nameMutex.Lock()
+	ret, specificReturn := fake.nameReturnsOnCall[len(fake.nameArgsForCall)]
+	fake.nameArgsForCall = append(fake.nameArgsForCall, struct {
+	}{})
+	stub := fake.NameStub
+	fakeReturns := fake.nameReturns
+	fake.recordInvocation("Name", []interface{}{})
+	fake.nameMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) NameCallCount() int {
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
+	return len(fake.nameArgsForCall)
+}
+
+func (fake *FakeParticipant) NameCalls(stub func() string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = stub
+}
+
+func (fake *FakeParticipant) NameReturns(result1 string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = nil
+	fake.nameReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeParticipant) NameReturnsOnCall(i int, result1 string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = nil
+	if fake.nameReturnsOnCall == nil {
+		fake.nameReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.nameReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
 func (fake *FakeParticipant) Negotiate() {
 	fake.negotiateMutex.Lock()
 	fake.negotiateArgsForCall = append(fake.negotiateArgsForCall, struct {
@@ -1916,6 +2750,38 @@ func (fake *FakeParticipant) NegotiateCalls(stub func()) {
 	fake.NegotiateStub = stub
 }
 
+func (fake *FakeParticipant) OnAttributesUpdate(arg1 func(types.Participant, []string)) {
+	fake.onAttributesUpdateMutex.Lock()
+	fake.onAttributesUpdateArgsForCall = append(fake.onAttributesUpdateArgsForCall, struct {
+		arg1 func(types.Participant, []string)
+	}{arg1})
+	stub := fake.OnAttributesUpdateStub
+	fake.recordInvocation("OnAttributesUpdate", []interface{}{arg1})
+	fake.onAttributesUpdateMutex.Unlock()
+	if stub != nil {
+		fake.OnAttributesUpdateStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) OnAttributesUpdateCallCount() int {
+	fake.onAttributesUpdateMutex.RLock()
+	defer fake.onAttributesUpdateMutex.RUnlock()
+	return len(fake.onAttributesUpdateArgsForCall)
+}
+
+func (fake *FakeParticipant) OnAttributesUpdateCalls(stub func(func(types.Participant, []string))) {
+	fake.onAttributesUpdateMutex.Lock()
+	defer fake.onAttributesUpdateMutex.Unlock()
+	fake.OnAttributesUpdateStub = stub
+}
+
+func (fake *FakeParticipant) OnAttributesUpdateArgsForCall(i int) func(types.Participant, []string) {
+	fake.onAttributesUpdateMutex.RLock()
+	defer fake.onAttributesUpdateMutex.RUnlock()
+	argsForCall := fake.onAttributesUpdateArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeParticipant) OnClose(arg1 func(types.Participant)) {
 	fake.onCloseMutex.Lock()
 	fake.onCloseArgsForCall = append(fake.onCloseArgsForCall, struct {
@@ -2161,15 +3027,15 @@ func (fake *FakeParticipant) ProtocolVersionReturnsOnCall(i int, result1 types.P
 	}{result1}
 }
 
-func (fake *FakeParticipant) RTCPChan() chan []rtcp.Packet {
-	fake.rTCPChanMutex.Lock()
-	ret, specificReturn := fake.rTCPChanReturnsOnCall[len(fake.rTCPChanArgsForCall)]
-	fake.rTCPChanArgsForCall = append(fake.rTCPChanArgsForCall, struct {
+func (fake *FakeParticipant) RTCPQueue() *sfu.RTCPQueue {
+	fake.rTCPQueueMutex.Lock()
+	ret, specificReturn := fake.rTCPQueueReturnsOnCall[len(fake.rTCPQueueArgsForCall)]
+	fake.rTCPQueueArgsForCall = append(fake.rTCPQueueArgsForCall, struct {
 	}{})
-	stub := fake.RTCPChanStub
-	fakeReturns := fake.rTCPChanReturns
-	fake.recordInvocation("RTCPChan", []interface{}{})
-	fake.rTCPChanMutex.Unlock()
+	stub := fake.RTCPQueueStub
+	fakeReturns := fake.rTCPQueueReturns
+	fake.recordInvocation("RTCPQueue", []interface{}{})
+	fake.rTCPQueueMutex.Unlock()
 	if stub != nil {
 		return stub()
 	}
@@ -2179,41 +3045,73 @@ func (fake *FakeParticipant) RTCPChan() chan []rtcp.Packet {
 	return fakeReturns.result1
 }
 
-func (fake *FakeParticipant) RTCPChanCallCount() int {
-	fake.rTCPChanMutex.RLock()
-	defer fake.rTCPChanMutex.RUnlock()
-	return len(fake.rTCPChanArgsForCall)
+func (fake *FakeParticipant) RTCPQueueCallCount() int {
+	fake.rTCPQueueMutex.RLock()
+	defer fake.rTCPQueueMutex.RUnlock()
+	return len(fake.rTCPQueueArgsForCall)
 }
 
-func (fake *FakeParticipant) RTCPChanCalls(stub func() chan []rtcp.Packet) {
-	fake.rTCPChanMutex.Lock()
-	defer fake.rTCPChanMutex.Unlock()
-	fake.RTCPChanStub = stub
+func (fake *FakeParticipant) RTCPQueueCalls(stub func() *sfu.RTCPQueue) {
+	fake.rTCPQueueMutex.Lock()
+	defer fake.rTCPQueueMutex.Unlock()
+	fake.RTCPQueueStub = stub
 }
 
-func (fake *FakeParticipant) RTCPChanReturns(result1 chan []rtcp.Packet) {
-	fake.rTCPChanMutex.Lock()
-	defer fake.rTCPChanMutex.Unlock()
-	fake.RTCPChanStub = nil
-	fake.rTCPChanReturns = struct {
-		result1 chan []rtcp.Packet
+func (fake *FakeParticipant) RTCPQueueReturns(result1 *sfu.RTCPQueue) {
+	fake.rTCPQueueMutex.Lock()
+	defer fake.rTCPQueueMutex.Unlock()
+	fake.RTCPQueueStub = nil
+	fake.rTCPQueueReturns = struct {
+		result1 *sfu.RTCPQueue
 	}{result1}
 }
 
-func (fake *FakeParticipant) RTCPChanReturnsOnCall(i int, result1 chan []rtcp.Packet) {
-	fake.rTCPChanMutex.Lock()
-	defer fake.rTCPChanMutex.Unlock()
-	fake.RTCPChanStub = nil
-	if fake.rTCPChanReturnsOnCall == nil {
-		fake.rTCPChanReturnsOnCall = make(map[int]struct {
-			result1 chan []rtcp.Packet
+func (fake *FakeParticipant) RTCPQueueReturnsOnCall(i int, result1 *sfu.RTCPQueue) {
+	fake.rTCPQueueMutex.Lock()
+	defer fake.rTCPQueueMutex.Unlock()
+	fake.RTCPQueueStub = nil
+	if fake.rTCPQueueReturnsOnCall == nil {
+		fake.rTCPQueueReturnsOnCall = make(map[int]struct {
+			result1 *sfu.RTCPQueue
 		})
 	}
-	fake.rTCPChanReturnsOnCall[i] = struct {
-		result1 chan []rtcp.Packet
+	fake.rTCPQueueReturnsOnCall[i] = struct {
+		result1 *sfu.RTCPQueue
 	}{result1}
 }
 
+func (fake *FakeParticipant) ReleaseSubscription(arg1 string) {
+	fake.releaseSubscriptionMutex.Lock()
+	fake.releaseSubscriptionArgsForCall = append(fake.releaseSubscriptionArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ReleaseSubscriptionStub
+	fake.recordInvocation("ReleaseSubscription", []interface{}{arg1})
+	fake.releaseSubscriptionMutex.Unlock()
+	if stub != nil {
+		fake.ReleaseSubscriptionStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) ReleaseSubscriptionCallCount() int {
+	fake.releaseSubscriptionMutex.RLock()
+	defer fake.releaseSubscriptionMutex.RUnlock()
+	return len(fake.releaseSubscriptionArgsForCall)
+}
+
+func (fake *FakeParticipant) ReleaseSubscriptionCalls(stub func(string)) {
+	fake.releaseSubscriptionMutex.Lock()
+	defer fake.releaseSubscriptionMutex.Unlock()
+	fake.ReleaseSubscriptionStub = stub
+}
+
+func (fake *FakeParticipant) ReleaseSubscriptionArgsForCall(i int) string {
+	fake.releaseSubscriptionMutex.RLock()
+	defer fake.releaseSubscriptionMutex.RUnlock()
+	argsForCall := fake.releaseSubscriptionArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeParticipant) RemoveSubscribedTrack(arg1 types.SubscribedTrack) {
 	fake.removeSubscribedTrackMutex.Lock()
 	fake.removeSubscribedTrackArgsForCall = append(fake.removeSubscribedTrackArgsForCall, struct {
@@ -2278,6 +3176,99 @@ func (fake *FakeParticipant) RemoveSubscriberArgsForCall(i int) string {
 	return argsForCall.arg1
 }
 
+func (fake *FakeParticipant) ReserveSubscription(arg1 string) error {
+	fake.reserveSubscriptionMutex.Lock()
+	ret, specificReturn := fake.reserveSubscriptionReturnsOnCall[len(fake.reserveSubscriptionArgsForCall)]
+	fake.reserveSubscriptionArgsForCall = append(fake.reserveSubscriptionArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ReserveSubscriptionStub
+	fakeReturns := fake.reserveSubscriptionReturns
+	fake.recordInvocation("ReserveSubscription", []interface{}{arg1})
+	fake.reserveSubscriptionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) ReserveSubscriptionCallCount() int {
+	fake.reserveSubscriptionMutex.RLock()
+	defer fake.reserveSubscriptionMutex.RUnlock()
+	return len(fake.reserveSubscriptionArgsForCall)
+}
+
+func (fake *FakeParticipant) ReserveSubscriptionCalls(stub func(string) error) {
+	fake.reserveSubscriptionMutex.Lock()
+	defer fake.reserveSubscriptionMutex.Unlock()
+	fake.ReserveSubscriptionStub = stub
+}
+
+func (fake *FakeParticipant) ReserveSubscriptionArgsForCall(i int) string {
+	fake.reserveSubscriptionMutex.RLock()
+	defer fake.reserveSubscriptionMutex.RUnlock()
+	argsForCall := fake.reserveSubscriptionArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) ReserveSubscriptionReturns(result1 error) {
+	fake.reserveSubscriptionMutex.Lock()
+	defer fake.reserveSubscriptionMutex.Unlock()
+	fake.ReserveSubscriptionStub = nil
+	fake.reserveSubscriptionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) ReserveSubscriptionReturnsOnCall(i int, result1 error) {
+	fake.reserveSubscriptionMutex.Lock()
+	defer fake.reserveSubscriptionMutex.Unlock()
+	fake.ReserveSubscriptionStub = nil
+	if fake.reserveSubscriptionReturnsOnCall == nil {
+		fake.reserveSubscriptionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.reserveSubscriptionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) ResumeSignal(arg1 uint32) {
+	fake.resumeSignalMutex.Lock()
+	fake.resumeSignalArgsForCall = append(fake.resumeSignalArgsForCall, struct {
+		arg1 uint32
+	}{arg1})
+	stub := fake.ResumeSignalStub
+	fake.recordInvocation("ResumeSignal", []interface{}{arg1})
+	fake.resumeSignalMutex.Unlock()
+	if stub != nil {
+		fake.ResumeSignalStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) ResumeSignalCallCount() int {
+	fake.resumeSignalMutex.RLock()
+	defer fake.resumeSignalMutex.RUnlock()
+	return len(fake.resumeSignalArgsForCall)
+}
+
+func (fake *FakeParticipant) ResumeSignalCalls(stub func(uint32)) {
+	fake.resumeSignalMutex.Lock()
+	defer fake.resumeSignalMutex.Unlock()
+	fake.ResumeSignalStub = stub
+}
+
+func (fake *FakeParticipant) ResumeSignalArgsForCall(i int) uint32 {
+	fake.resumeSignalMutex.RLock()
+	defer fake.resumeSignalMutex.RUnlock()
+	argsForCall := fake.resumeSignalArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeParticipant) SendConnectionQualityUpdate(arg1 *livekit.ConnectionQualityUpdate) error {
 	fake.sendConnectionQualityUpdateMutex.Lock()
 	ret, specificReturn := fake.sendConnectionQualityUpdateReturnsOnCall[len(fake.sendConnectionQualityUpdateArgsForCall)]
@@ -2473,6 +3464,74 @@ func (fake *FakeParticipant) SendJoinResponseReturnsOnCall(i int, result1 error)
 	}{result1}
 }
 
+func (fake *FakeParticipant) SendParticipantPage(arg1 string, arg2 []*livekit.ParticipantInfo, arg3 string) error {
+	var arg2Copy []*livekit.ParticipantInfo
+	if arg2 != nil {
+		arg2Copy = make([]*livekit.ParticipantInfo, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.sendParticipantPageMutex.Lock()
+	ret, specificReturn := fake.sendParticipantPageReturnsOnCall[len(fake.sendParticipantPageArgsForCall)]
+	fake.sendParticipantPageArgsForCall = append(fake.sendParticipantPageArgsForCall, struct {
+		arg1 string
+		arg2 []*livekit.ParticipantInfo
+		arg3 string
+	}{arg1, arg2Copy, arg3})
+	stub := fake.SendParticipantPageStub
+	fakeReturns := fake.sendParticipantPageReturns
+	fake.recordInvocation("SendParticipantPage", []interface{}{arg1, arg2Copy, arg3})
+	fake.sendParticipantPageMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) SendParticipantPageCallCount() int {
+	fake.sendParticipantPageMutex.RLock()
+	defer fake.sendParticipantPageMutex.RUnlock()
+	return len(fake.sendParticipantPageArgsForCall)
+}
+
+func (fake *FakeParticipant) SendParticipantPageCalls(stub func(string, []*livekit.ParticipantInfo, string) error) {
+	fake.sendParticipantPageMutex.Lock()
+	defer fake.sendParticipantPageMutex.Unlock()
+	fake.SendParticipantPageStub = stub
+}
+
+func (fake *FakeParticipant) SendParticipantPageArgsForCall(i int) (string, []*livekit.ParticipantInfo, string) {
+	fake.sendParticipantPageMutex.RLock()
+	defer fake.sendParticipantPageMutex.RUnlock()
+	argsForCall := fake.sendParticipantPageArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeParticipant) SendParticipantPageReturns(result1 error) {
+	fake.sendParticipantPageMutex.Lock()
+	defer fake.sendParticipantPageMutex.Unlock()
+	fake.SendParticipantPageStub = nil
+	fake.sendParticipantPageReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) SendParticipantPageReturnsOnCall(i int, result1 error) {
+	fake.sendParticipantPageMutex.Lock()
+	defer fake.sendParticipantPageMutex.Unlock()
+	fake.SendParticipantPageStub = nil
+	if fake.sendParticipantPageReturnsOnCall == nil {
+		fake.sendParticipantPageReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.sendParticipantPageReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeParticipant) SendParticipantUpdate(arg1 []*livekit.ParticipantInfo, arg2 time.Time) error {
 	var arg1Copy []*livekit.ParticipantInfo
 	if arg1 != nil {
@@ -2667,44 +3726,235 @@ func (fake *FakeParticipant) SendSpeakerUpdateReturnsOnCall(i int, result1 error
 	}{result1}
 }
 
-func (fake *FakeParticipant) SetMetadata(arg1 string) {
-	fake.setMetadataMutex.Lock()
-	fake.setMetadataArgsForCall = append(fake.setMetadataArgsForCall, struct {
+func (fake *FakeParticipant) SendSubscriptionError(arg1 string, arg2 error) {
+	fake.sendSubscriptionErrorMutex.Lock()
+	fake.sendSubscriptionErrorArgsForCall = append(fake.sendSubscriptionErrorArgsForCall, struct {
 		arg1 string
-	}{arg1})
-	stub := fake.SetMetadataStub
-	fake.recordInvocation("SetMetadata", []interface{}{arg1})
-	fake.setMetadataMutex.Unlock()
+		arg2 error
+	}{arg1, arg2})
+	stub := fake.SendSubscriptionErrorStub
+	fake.recordInvocation("SendSubscriptionError", []interface{}{arg1, arg2})
+	fake.sendSubscriptionErrorMutex.Unlock()
 	if stub != nil {
-		fake.SetMetadataStub(arg1)
+		fake.SendSubscriptionErrorStub(arg1, arg2)
 	}
 }
 
-func (fake *FakeParticipant) SetMetadataCallCount() int {
-	fake.setMetadataMutex.RLock()
-	defer fake.setMetadataMutex.RUnlock()
-	return len(fake.setMetadataArgsForCall)
+func (fake *FakeParticipant) SendSubscriptionErrorCallCount() int {
+	fake.sendSubscriptionErrorMutex.RLock()
+	defer fake.sendSubscriptionErrorMutex.RUnlock()
+	return len(fake.sendSubscriptionErrorArgsForCall)
 }
 
-func (fake *FakeParticipant) SetMetadataCalls(stub func(string)) {
-	fake.setMetadataMutex.Lock()
-	defer fake.setMetadataMutex.Unlock()
-	fake.SetMetadataStub = stub
+func (fake *FakeParticipant) SendSubscriptionErrorCalls(stub func(string, error)) {
+	fake.sendSubscriptionErrorMutex.Lock()
+	defer fake.sendSubscriptionErrorMutex.Unlock()
+	fake.SendSubscriptionErrorStub = stub
 }
 
-func (fake *FakeParticipant) SetMetadataArgsForCall(i int) string {
-	fake.setMetadataMutex.RLock()
-	defer fake.setMetadataMutex.RUnlock()
-	argsForCall := fake.setMetadataArgsForCall[i]
-	return argsForCall.arg1
+func (fake *FakeParticipant) SendSubscriptionErrorArgsForCall(i int) (string, error) {
+	fake.sendSubscriptionErrorMutex.RLock()
+	defer fake.sendSubscriptionErrorMutex.RUnlock()
+	argsForCall := fake.sendSubscriptionErrorArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
 }
 
-func (fake *FakeParticipant) SetPermission(arg1 *livekit.ParticipantPermission) {
-	fake.setPermissionMutex.Lock()
-	fake.setPermissionArgsForCall = append(fake.setPermissionArgsForCall, struct {
-		arg1 *livekit.ParticipantPermission
-	}{arg1})
-	stub := fake.SetPermissionStub
+func (fake *FakeParticipant) SendTextMessage(arg1 string, arg2 []byte) error {
+	fake.sendTextMessageMutex.Lock()
+	ret, specificReturn := fake.sendTextMessageReturnsOnCall[len(fake.sendTextMessageArgsForCall)]
+	fake.sendTextMessageArgsForCall = append(fake.sendTextMessageArgsForCall, struct {
+		arg1 string
+		arg2 []byte
+	}{arg1, arg2})
+	stub := fake.SendTextMessageStub
+	fakeReturns := fake.sendTextMessageReturns
+	fake.recordInvocation("SendTextMessage", []interface{}{arg1, arg2})
+	fake.sendTextMessageMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) SendTextMessageCallCount() int {
+	fake.sendTextMessageMutex.RLock()
+	defer fake.sendTextMessageMutex.RUnlock()
+	return len(fake.sendTextMessageArgsForCall)
+}
+
+func (fake *FakeParticipant) SendTextMessageCalls(stub func(string, []byte) error) {
+	fake.sendTextMessageMutex.Lock()
+	defer fake.sendTextMessageMutex.Unlock()
+	fake.SendTextMessageStub = stub
+}
+
+func (fake *FakeParticipant) SendTextMessageArgsForCall(i int) (string, []byte) {
+	fake.sendTextMessageMutex.RLock()
+	defer fake.sendTextMessageMutex.RUnlock()
+	argsForCall := fake.sendTextMessageArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeParticipant) SendTextMessageReturns(result1 error) {
+	fake.sendTextMessageMutex.Lock()
+	defer fake.sendTextMessageMutex.Unlock()
+	fake.SendTextMessageStub = nil
+	fake.sendTextMessageReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) SendTextMessageReturnsOnCall(i int, result1 error) {
+	fake.sendTextMessageMutex.Lock()
+	defer fake.sendTextMessageMutex.Unlock()
+	fake.SendTextMessageStub = nil
+	if fake.sendTextMessageReturnsOnCall == nil {
+		fake.sendTextMessageReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.sendTextMessageReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) SetAudioConfig(arg1 config.AudioConfig) {
+	fake.setAudioConfigMutex.Lock()
+	fake.setAudioConfigArgsForCall = append(fake.setAudioConfigArgsForCall, struct {
+		arg1 config.AudioConfig
+	}{arg1})
+	stub := fake.SetAudioConfigStub
+	fake.recordInvocation("SetAudioConfig", []interface{}{arg1})
+	fake.setAudioConfigMutex.Unlock()
+	if stub != nil {
+		fake.SetAudioConfigStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) SetAudioConfigCallCount() int {
+	fake.setAudioConfigMutex.RLock()
+	defer fake.setAudioConfigMutex.RUnlock()
+	return len(fake.setAudioConfigArgsForCall)
+}
+
+func (fake *FakeParticipant) SetAudioConfigCalls(stub func(config.AudioConfig)) {
+	fake.setAudioConfigMutex.Lock()
+	defer fake.setAudioConfigMutex.Unlock()
+	fake.SetAudioConfigStub = stub
+}
+
+func (fake *FakeParticipant) SetAudioConfigArgsForCall(i int) config.AudioConfig {
+	fake.setAudioConfigMutex.RLock()
+	defer fake.setAudioConfigMutex.RUnlock()
+	argsForCall := fake.setAudioConfigArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) SetDegraded(arg1 bool) {
+	fake.setDegradedMutex.Lock()
+	fake.setDegradedArgsForCall = append(fake.setDegradedArgsForCall, struct {
+		arg1 bool
+	}{arg1})
+	stub := fake.SetDegradedStub
+	fake.recordInvocation("SetDegraded", []interface{}{arg1})
+	fake.setDegradedMutex.Unlock()
+	if stub != nil {
+		fake.SetDegradedStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) SetDegradedCallCount() int {
+	fake.setDegradedMutex.RLock()
+	defer fake.setDegradedMutex.RUnlock()
+	return len(fake.setDegradedArgsForCall)
+}
+
+func (fake *FakeParticipant) SetDegradedCalls(stub func(bool)) {
+	fake.setDegradedMutex.Lock()
+	defer fake.setDegradedMutex.Unlock()
+	fake.SetDegradedStub = stub
+}
+
+func (fake *FakeParticipant) SetDegradedArgsForCall(i int) bool {
+	fake.setDegradedMutex.RLock()
+	defer fake.setDegradedMutex.RUnlock()
+	argsForCall := fake.setDegradedArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) SetMetadata(arg1 string) {
+	fake.setMetadataMutex.Lock()
+	fake.setMetadataArgsForCall = append(fake.setMetadataArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.SetMetadataStub
+	fake.recordInvocation("SetMetadata", []interface{}{arg1})
+	fake.setMetadataMutex.Unlock()
+	if stub != nil {
+		fake.SetMetadataStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) SetMetadataCallCount() int {
+	fake.setMetadataMutex.RLock()
+	defer fake.setMetadataMutex.RUnlock()
+	return len(fake.setMetadataArgsForCall)
+}
+
+func (fake *FakeParticipant) SetMetadataCalls(stub func(string)) {
+	fake.setMetadataMutex.Lock()
+	defer fake.setMetadataMutex.Unlock()
+	fake.SetMetadataStub = stub
+}
+
+func (fake *FakeParticipant) SetMetadataArgsForCall(i int) string {
+	fake.setMetadataMutex.RLock()
+	defer fake.setMetadataMutex.RUnlock()
+	argsForCall := fake.setMetadataArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) SetName(arg1 string) {
+	fake.setNameMutex.Lock()
+	fake.setNameArgsForCall = append(fake.setNameArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.SetNameStub
+	fake.recordInvocation("SetName", []interface{}{arg1})
+	fake.setNameMutex.Unlock()
+	if stub != nil {
+		fake.SetNameStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) SetNameCallCount() int {
+	fake.setNameMutex.RLock()
+	defer fake.setNameMutex.RUnlock()
+	return len(fake.setNameArgsForCall)
+}
+
+func (fake *FakeParticipant) SetNameCalls(stub func(string)) {
+	fake.setNameMutex.Lock()
+	defer fake.setNameMutex.Unlock()
+	fake.SetNameStub = stub
+}
+
+func (fake *FakeParticipant) SetNameArgsForCall(i int) string {
+	fake.setNameMutex.RLock()
+	defer fake.setNameMutex.RUnlock()
+	argsForCall := fake.setNameArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) SetPermission(arg1 *livekit.ParticipantPermission) {
+	fake.setPermissionMutex.Lock()
+	fake.setPermissionArgsForCall = append(fake.setPermissionArgsForCall, struct {
+		arg1 *livekit.ParticipantPermission
+	}{arg1})
+	stub := fake.SetPermissionStub
 	fake.recordInvocation("SetPermission", []interface{}{arg1})
 	fake.setPermissionMutex.Unlock()
 	if stub != nil {
@@ -2763,6 +4013,101 @@ func (fake *FakeParticipant) SetResponseSinkArgsForCall(i int) routing.MessageSi
 	return argsForCall.arg1
 }
 
+func (fake *FakeParticipant) SetSubscriberQuality(arg1 string, arg2 livekit.VideoQuality) {
+	fake.setSubscriberQualityMutex.Lock()
+	fake.setSubscriberQualityArgsForCall = append(fake.setSubscriberQualityArgsForCall, struct {
+		arg1 string
+		arg2 livekit.VideoQuality
+	}{arg1, arg2})
+	stub := fake.SetSubscriberQualityStub
+	fake.recordInvocation("SetSubscriberQuality", []interface{}{arg1, arg2})
+	fake.setSubscriberQualityMutex.Unlock()
+	if stub != nil {
+		fake.SetSubscriberQualityStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeParticipant) SetSubscriberQualityCallCount() int {
+	fake.setSubscriberQualityMutex.RLock()
+	defer fake.setSubscriberQualityMutex.RUnlock()
+	return len(fake.setSubscriberQualityArgsForCall)
+}
+
+func (fake *FakeParticipant) SetSubscriberQualityCalls(stub func(string, livekit.VideoQuality)) {
+	fake.setSubscriberQualityMutex.Lock()
+	defer fake.setSubscriberQualityMutex.Unlock()
+	fake.SetSubscriberQualityStub = stub
+}
+
+func (fake *FakeParticipant) SetSubscriberQualityArgsForCall(i int) (string, livekit.VideoQuality) {
+	fake.setSubscriberQualityMutex.RLock()
+	defer fake.setSubscriberQualityMutex.RUnlock()
+	argsForCall := fake.setSubscriberQualityArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeParticipant) SetTrackMetadata(arg1 string, arg2 string) error {
+	fake.setTrackMetadataMutex.Lock()
+	ret, specificReturn := fake.setTrackMetadataReturnsOnCall[len(fake.setTrackMetadataArgsForCall)]
+	fake.setTrackMetadataArgsForCall = append(fake.setTrackMetadataArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.SetTrackMetadataStub
+	fakeReturns := fake.setTrackMetadataReturns
+	fake.recordInvocation("SetTrackMetadata", []interface{}{arg1, arg2})
+	fake.setTrackMetadataMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) SetTrackMetadataCallCount() int {
+	fake.setTrackMetadataMutex.RLock()
+	defer fake.setTrackMetadataMutex.RUnlock()
+	return len(fake.setTrackMetadataArgsForCall)
+}
+
+func (fake *FakeParticipant) SetTrackMetadataCalls(stub func(string, string) error) {
+	fake.setTrackMetadataMutex.Lock()
+	defer fake.setTrackMetadataMutex.Unlock()
+	fake.SetTrackMetadataStub = stub
+}
+
+func (fake *FakeParticipant) SetTrackMetadataArgsForCall(i int) (string, string) {
+	fake.setTrackMetadataMutex.RLock()
+	defer fake.setTrackMetadataMutex.RUnlock()
+	argsForCall := fake.setTrackMetadataArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeParticipant) SetTrackMetadataReturns(result1 error) {
+	fake.setTrackMetadataMutex.Lock()
+	defer fake.setTrackMetadataMutex.Unlock()
+	fake.SetTrackMetadataStub = nil
+	fake.setTrackMetadataReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) SetTrackMetadataReturnsOnCall(i int, result1 error) {
+	fake.setTrackMetadataMutex.Lock()
+	defer fake.setTrackMetadataMutex.Unlock()
+	fake.SetTrackMetadataStub = nil
+	if fake.setTrackMetadataReturnsOnCall == nil {
+		fake.setTrackMetadataReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setTrackMetadataReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeParticipant) SetTrackMuted(arg1 string, arg2 bool, arg3 bool) {
 	fake.setTrackMutedMutex.Lock()
 	fake.setTrackMutedArgsForCall = append(fake.setTrackMutedArgsForCall, struct {
@@ -2779,6 +4124,8 @@ func (fake *FakeParticipant) SetTrackMuted(arg1 string, arg2 bool, arg3 bool) {
 }
 
 func (fake *FakeParticipant) SetTrackMutedCallCount() int {
+	fake.setSubscriberQualityMutex.RLock()
+	defer fake.setSubscriberQualityMutex.RUnlock()
 	fake.setTrackMutedMutex.RLock()
 	defer fake.setTrackMutedMutex.RUnlock()
 	return len(fake.setTrackMutedArgsForCall)
@@ -2797,6 +4144,59 @@ func (fake *FakeParticipant) SetTrackMutedArgsForCall(i int) (string, bool, bool
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
+func (fake *FakeParticipant) SignalOnly() bool {
+	fake.signalOnlyMutex.Lock()
+	ret, specificReturn := fake.signalOnlyReturnsOnCall[len(fake.signalOnlyArgsForCall)]
+	fake.signalOnlyArgsForCall = append(fake.signalOnlyArgsForCall, struct {
+	}{})
+	stub := fake.SignalOnlyStub
+	fakeReturns := fake.signalOnlyReturns
+	fake.recordInvocation("SignalOnly", []interface{}{})
+	fake.signalOnlyMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) SignalOnlyCallCount() int {
+	fake.signalOnlyMutex.RLock()
+	defer fake.signalOnlyMutex.RUnlock()
+	return len(fake.signalOnlyArgsForCall)
+}
+
+func (fake *FakeParticipant) SignalOnlyCalls(stub func() bool) {
+	fake.signalOnlyMutex.Lock()
+	defer fake.signalOnlyMutex.Unlock()
+	fake.SignalOnlyStub = stub
+}
+
+func (fake *FakeParticipant) SignalOnlyReturns(result1 bool) {
+	fake.signalOnlyMutex.Lock()
+	defer fake.signalOnlyMutex.Unlock()
+	fake.SignalOnlyStub = nil
+	fake.signalOnlyReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeParticipant) SignalOnlyReturnsOnCall(i int, result1 bool) {
+	fake.signalOnlyMutex.Lock()
+	defer fake.signalOnlyMutex.Unlock()
+	fake.SignalOnlyStub = nil
+	if fake.signalOnlyReturnsOnCall == nil {
+		fake.signalOnlyReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.signalOnlyReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *FakeParticipant) Start() {
 	fake.startMutex.Lock()
 	fake.startArgsForCall = append(fake.startArgsForCall, struct {
@@ -2821,6 +4221,59 @@ func (fake *FakeParticipant) StartCalls(stub func()) {
 	fake.StartStub = stub
 }
 
+func (fake *FakeParticipant) SpatialLayerCap() int32 {
+	fake.spatialLayerCapMutex.Lock()
+	ret, specificReturn := fake.spatialLayerCapReturnsOnCall[len(fake.spatialLayerCapArgsForCall)]
+	fake.spatialLayerCapArgsForCall = append(fake.spatialLayerCapArgsForCall, struct {
+	}{})
+	stub := fake.SpatialLayerCapStub
+	fakeReturns := fake.spatialLayerCapReturns
+	fake.recordInvocation("SpatialLayerCap", []interface{}{})
+	fake.spatialLayerCapMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) SpatialLayerCapCallCount() int {
+	fake.spatialLayerCapMutex.RLock()
+	defer fake.spatialLayerCapMutex.RUnlock()
+	return len(fake.spatialLayerCapArgsForCall)
+}
+
+func (fake *FakeParticipant) SpatialLayerCapCalls(stub func() int32) {
+	fake.spatialLayerCapMutex.Lock()
+	defer fake.spatialLayerCapMutex.Unlock()
+	fake.SpatialLayerCapStub = stub
+}
+
+func (fake *FakeParticipant) SpatialLayerCapReturns(result1 int32) {
+	fake.spatialLayerCapMutex.Lock()
+	defer fake.spatialLayerCapMutex.Unlock()
+	fake.SpatialLayerCapStub = nil
+	fake.spatialLayerCapReturns = struct {
+		result1 int32
+	}{result1}
+}
+
+func (fake *FakeParticipant) SpatialLayerCapReturnsOnCall(i int, result1 int32) {
+	fake.spatialLayerCapMutex.Lock()
+	defer fake.spatialLayerCapMutex.Unlock()
+	fake.SpatialLayerCapStub = nil
+	if fake.spatialLayerCapReturnsOnCall == nil {
+		fake.spatialLayerCapReturnsOnCall = make(map[int]struct {
+			result1 int32
+		})
+	}
+	fake.spatialLayerCapReturnsOnCall[i] = struct {
+		result1 int32
+	}{result1}
+}
+
 func (fake *FakeParticipant) State() livekit.ParticipantInfo_State {
 	fake.stateMutex.Lock()
 	ret, specificReturn := fake.stateReturnsOnCall[len(fake.stateArgsForCall)]
@@ -2927,6 +4380,59 @@ func (fake *FakeParticipant) SubscriberAsPrimaryReturnsOnCall(i int, result1 boo
 	}{result1}
 }
 
+func (fake *FakeParticipant) SubscriberMediaDelay() time.Duration {
+	fake.subscriberMediaDelayMutex.Lock()
+	ret, specificReturn := fake.subscriberMediaDelayReturnsOnCall[len(fake.subscriberMediaDelayArgsForCall)]
+	fake.subscriberMediaDelayArgsForCall = append(fake.subscriberMediaDelayArgsForCall, struct {
+	}{})
+	stub := fake.SubscriberMediaDelayStub
+	fakeReturns := fake.subscriberMediaDelayReturns
+	fake.recordInvocation("SubscriberMediaDelay", []interface{}{})
+	fake.subscriberMediaDelayMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) SubscriberMediaDelayCallCount() int {
+	fake.subscriberMediaDelayMutex.RLock()
+	defer fake.subscriberMediaDelayMutex.RUnlock()
+	return len(fake.subscriberMediaDelayArgsForCall)
+}
+
+func (fake *FakeParticipant) SubscriberMediaDelayCalls(stub func() time.Duration) {
+	fake.subscriberMediaDelayMutex.Lock()
+	defer fake.subscriberMediaDelayMutex.Unlock()
+	fake.SubscriberMediaDelayStub = stub
+}
+
+func (fake *FakeParticipant) SubscriberMediaDelayReturns(result1 time.Duration) {
+	fake.subscriberMediaDelayMutex.Lock()
+	defer fake.subscriberMediaDelayMutex.Unlock()
+	fake.SubscriberMediaDelayStub = nil
+	fake.subscriberMediaDelayReturns = struct {
+		result1 time.Duration
+	}{result1}
+}
+
+func (fake *FakeParticipant) SubscriberMediaDelayReturnsOnCall(i int, result1 time.Duration) {
+	fake.subscriberMediaDelayMutex.Lock()
+	defer fake.subscriberMediaDelayMutex.Unlock()
+	fake.SubscriberMediaDelayStub = nil
+	if fake.subscriberMediaDelayReturnsOnCall == nil {
+		fake.subscriberMediaDelayReturnsOnCall = make(map[int]struct {
+			result1 time.Duration
+		})
+	}
+	fake.subscriberMediaDelayReturnsOnCall[i] = struct {
+		result1 time.Duration
+	}{result1}
+}
+
 func (fake *FakeParticipant) SubscriberMediaEngine() *webrtc.MediaEngine {
 	fake.subscriberMediaEngineMutex.Lock()
 	ret, specificReturn := fake.subscriberMediaEngineReturnsOnCall[len(fake.subscriberMediaEngineArgsForCall)]
@@ -3001,6 +4507,8 @@ func (fake *FakeParticipant) SubscriberPC() *webrtc.PeerConnection {
 func (fake *FakeParticipant) SubscriberPCCallCount() int {
 	fake.subscriberPCMutex.RLock()
 	defer fake.subscriberPCMutex.RUnlock()
+	fake.subscriberQualityMutex.RLock()
+	defer fake.subscriberQualityMutex.RUnlock()
 	return len(fake.subscriberPCArgsForCall)
 }
 
@@ -3033,6 +4541,123 @@ func (fake *FakeParticipant) SubscriberPCReturnsOnCall(i int, result1 *webrtc.Pe
 	}{result1}
 }
 
+func (fake *FakeParticipant) SubscriberPacer() *sfu.Pacer {
+	fake.subscriberPacerMutex.Lock()
+	ret, specificReturn := fake.subscriberPacerReturnsOnCall[len(fake.subscriberPacerArgsForCall)]
+	fake.subscriberPacerArgsForCall = append(fake.subscriberPacerArgsForCall, struct {
+	}{})
+	stub := fake.SubscriberPacerStub
+	fakeReturns := fake.subscriberPacerReturns
+	fake.recordInvocation("SubscriberPacer", []interface{}{})
+	fake.subscriberPacerMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) SubscriberPacerCallCount() int {
+	fake.subscriberPacerMutex.RLock()
+	defer fake.subscriberPacerMutex.RUnlock()
+	return len(fake.subscriberPacerArgsForCall)
+}
+
+func (fake *FakeParticipant) SubscriberPacerCalls(stub func() *sfu.Pacer) {
+	fake.subscriberPacerMutex.Lock()
+	defer fake.subscriberPacerMutex.Unlock()
+	fake.SubscriberPacerStub = stub
+}
+
+func (fake *FakeParticipant) SubscriberPacerReturns(result1 *sfu.Pacer) {
+	fake.subscriberPacerMutex.Lock()
+	defer fake.subscriberPacerMutex.Unlock()
+	fake.SubscriberPacerStub = nil
+	fake.subscriberPacerReturns = struct {
+		result1 *sfu.Pacer
+	}{result1}
+}
+
+func (fake *FakeParticipant) SubscriberPacerReturnsOnCall(i int, result1 *sfu.Pacer) {
+	fake.subscriberPacerMutex.Lock()
+	defer fake.subscriberPacerMutex.Unlock()
+	fake.SubscriberPacerStub = nil
+	if fake.subscriberPacerReturnsOnCall == nil {
+		fake.subscriberPacerReturnsOnCall = make(map[int]struct {
+			result1 *sfu.Pacer
+		})
+	}
+	fake.subscriberPacerReturnsOnCall[i] = struct {
+		result1 *sfu.Pacer
+	}{result1}
+}
+
+func (fake *FakeParticipant) SubscriberQuality(arg1 string) (livekit.VideoQuality, bool) {
+	fake.subscriberQualityMutex.Lock()
+	ret, specificReturn := fake.subscriberQualityReturnsOnCall[len(fake.subscriberQualityArgsForCall)]
+	fake.subscriberQualityArgsForCall = append(fake.subscriberQualityArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.SubscriberQualityStub
+	fakeReturns := fake.subscriberQualityReturns
+	fake.recordInvocation("SubscriberQuality", []interface{}{arg1})
+	fake.subscriberQualityMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeParticipant) SubscriberQualityCallCount() int {
+	fake.subscriberQualityMutex.RLock()
+	defer fake.subscriberQualityMutex.RUnlock()
+	return len(fake.subscriberQualityArgsForCall)
+}
+
+func (fake *FakeParticipant) SubscriberQualityCalls(stub func(string) (livekit.VideoQuality, bool)) {
+	fake.subscriberQualityMutex.Lock()
+	defer fake.subscriberQualityMutex.Unlock()
+	fake.SubscriberQualityStub = stub
+}
+
+func (fake *FakeParticipant) SubscriberQualityArgsForCall(i int) string {
+	fake.subscriberQualityMutex.RLock()
+	defer fake.subscriberQualityMutex.RUnlock()
+	argsForCall := fake.subscriberQualityArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) SubscriberQualityReturns(result1 livekit.VideoQuality, result2 bool) {
+	fake.subscriberQualityMutex.Lock()
+	defer fake.subscriberQualityMutex.Unlock()
+	fake.SubscriberQualityStub = nil
+	fake.subscriberQualityReturns = struct {
+		result1 livekit.VideoQuality
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeParticipant) SubscriberQualityReturnsOnCall(i int, result1 livekit.VideoQuality, result2 bool) {
+	fake.subscriberQualityMutex.Lock()
+	defer fake.subscriberQualityMutex.Unlock()
+	fake.SubscriberQualityStub = nil
+	if fake.subscriberQualityReturnsOnCall == nil {
+		fake.subscriberQualityReturnsOnCall = make(map[int]struct {
+			result1 livekit.VideoQuality
+			result2 bool
+		})
+	}
+	fake.subscriberQualityReturnsOnCall[i] = struct {
+		result1 livekit.VideoQuality
+		result2 bool
+	}{result1, result2}
+}
+
 func (fake *FakeParticipant) ToProto() *livekit.ParticipantInfo {
 	fake.toProtoMutex.Lock()
 	ret, specificReturn := fake.toProtoReturnsOnCall[len(fake.toProtoArgsForCall)]
@@ -3086,9 +4711,165 @@ func (fake *FakeParticipant) ToProtoReturnsOnCall(i int, result1 *livekit.Partic
 	}{result1}
 }
 
+func (fake *FakeParticipant) UnpublishTrack(arg1 string) error {
+	fake.unpublishTrackMutex.Lock()
+	ret, specificReturn := fake.unpublishTrackReturnsOnCall[len(fake.unpublishTrackArgsForCall)]
+	fake.unpublishTrackArgsForCall = append(fake.unpublishTrackArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.UnpublishTrackStub
+	fakeReturns := fake.unpublishTrackReturns
+	fake.recordInvocation("UnpublishTrack", []interface{}{arg1})
+	fake.unpublishTrackMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) UnpublishTrackCallCount() int {
+	fake.unpublishTrackMutex.RLock()
+	defer fake.unpublishTrackMutex.RUnlock()
+	return len(fake.unpublishTrackArgsForCall)
+}
+
+func (fake *FakeParticipant) UnpublishTrackCalls(stub func(string) error) {
+	fake.unpublishTrackMutex.Lock()
+	defer fake.unpublishTrackMutex.Unlock()
+	fake.UnpublishTrackStub = stub
+}
+
+func (fake *FakeParticipant) UnpublishTrackArgsForCall(i int) string {
+	fake.unpublishTrackMutex.RLock()
+	defer fake.unpublishTrackMutex.RUnlock()
+	argsForCall := fake.unpublishTrackArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) UnpublishTrackReturns(result1 error) {
+	fake.unpublishTrackMutex.Lock()
+	defer fake.unpublishTrackMutex.Unlock()
+	fake.UnpublishTrackStub = nil
+	fake.unpublishTrackReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) UnpublishTrackReturnsOnCall(i int, result1 error) {
+	fake.unpublishTrackMutex.Lock()
+	defer fake.unpublishTrackMutex.Unlock()
+	fake.UnpublishTrackStub = nil
+	if fake.unpublishTrackReturnsOnCall == nil {
+		fake.unpublishTrackReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.unpublishTrackReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) UpdateAttributes(arg1 map[string]string) error {
+	fake.updateAttributesMutex.Lock()
+	ret, specificReturn := fake.updateAttributesReturnsOnCall[len(fake.updateAttributesArgsForCall)]
+	fake.updateAttributesArgsForCall = append(fake.updateAttributesArgsForCall, struct {
+		arg1 map[string]string
+	}{arg1})
+	stub := fake.UpdateAttributesStub
+	fakeReturns := fake.updateAttributesReturns
+	fake.recordInvocation("UpdateAttributes", []interface{}{arg1})
+	fake.updateAttributesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) UpdateAttributesCallCount() int {
+	fake.updateAttributesMutex.RLock()
+	defer fake.updateAttributesMutex.RUnlock()
+	return len(fake.updateAttributesArgsForCall)
+}
+
+func (fake *FakeParticipant) UpdateAttributesCalls(stub func(map[string]string) error) {
+	fake.updateAttributesMutex.Lock()
+	defer fake.updateAttributesMutex.Unlock()
+	fake.UpdateAttributesStub = stub
+}
+
+func (fake *FakeParticipant) UpdateAttributesArgsForCall(i int) map[string]string {
+	fake.updateAttributesMutex.RLock()
+	defer fake.updateAttributesMutex.RUnlock()
+	argsForCall := fake.updateAttributesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) UpdateAttributesReturns(result1 error) {
+	fake.updateAttributesMutex.Lock()
+	defer fake.updateAttributesMutex.Unlock()
+	fake.UpdateAttributesStub = nil
+	fake.updateAttributesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) UpdateAttributesReturnsOnCall(i int, result1 error) {
+	fake.updateAttributesMutex.Lock()
+	defer fake.updateAttributesMutex.Unlock()
+	fake.UpdateAttributesStub = nil
+	if fake.updateAttributesReturnsOnCall == nil {
+		fake.updateAttributesReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.updateAttributesReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) UpdateLimits(arg1 *config.RTCConfig) {
+	fake.updateLimitsMutex.Lock()
+	fake.updateLimitsArgsForCall = append(fake.updateLimitsArgsForCall, struct {
+		arg1 *config.RTCConfig
+	}{arg1})
+	stub := fake.UpdateLimitsStub
+	fake.recordInvocation("UpdateLimits", []interface{}{arg1})
+	fake.updateLimitsMutex.Unlock()
+	if stub != nil {
+		fake.UpdateLimitsStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) UpdateLimitsCallCount() int {
+	fake.updateLimitsMutex.RLock()
+	defer fake.updateLimitsMutex.RUnlock()
+	return len(fake.updateLimitsArgsForCall)
+}
+
+func (fake *FakeParticipant) UpdateLimitsCalls(stub func(*config.RTCConfig)) {
+	fake.updateLimitsMutex.Lock()
+	defer fake.updateLimitsMutex.Unlock()
+	fake.UpdateLimitsStub = stub
+}
+
+func (fake *FakeParticipant) UpdateLimitsArgsForCall(i int) *config.RTCConfig {
+	fake.updateLimitsMutex.RLock()
+	defer fake.updateLimitsMutex.RUnlock()
+	argsForCall := fake.updateLimitsArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeParticipant) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.ackSignalMutex.RLock()
+	defer fake.ackSignalMutex.RUnlock()
 	fake.addICECandidateMutex.RLock()
 	defer fake.addICECandidateMutex.RUnlock()
 	fake.addSubscribedTrackMutex.RLock()
@@ -3097,12 +4878,20 @@ func (fake *FakeParticipant) Invocations() map[string][][]interface{} {
 	defer fake.addSubscriberMutex.RUnlock()
 	fake.addTrackMutex.RLock()
 	defer fake.addTrackMutex.RUnlock()
+	fake.attributesMutex.RLock()
+	defer fake.attributesMutex.RUnlock()
+	fake.canApproveJoinsMutex.RLock()
+	defer fake.canApproveJoinsMutex.RUnlock()
 	fake.canPublishMutex.RLock()
 	defer fake.canPublishMutex.RUnlock()
 	fake.canPublishDataMutex.RLock()
 	defer fake.canPublishDataMutex.RUnlock()
 	fake.canSubscribeMutex.RLock()
 	defer fake.canSubscribeMutex.RUnlock()
+	fake.canSubscribeToMutex.RLock()
+	defer fake.canSubscribeToMutex.RUnlock()
+	fake.capabilitiesMutex.RLock()
+	defer fake.capabilitiesMutex.RUnlock()
 	fake.closeMutex.RLock()
 	defer fake.closeMutex.RUnlock()
 	fake.connectedAtMutex.RLock()
@@ -3113,12 +4902,16 @@ func (fake *FakeParticipant) Invocations() map[string][][]interface{} {
 	defer fake.getAudioLevelMutex.RUnlock()
 	fake.getConnectionQualityMutex.RLock()
 	defer fake.getConnectionQualityMutex.RUnlock()
+	fake.getLoggerMutex.RLock()
+	defer fake.getLoggerMutex.RUnlock()
 	fake.getPublishedTrackMutex.RLock()
 	defer fake.getPublishedTrackMutex.RUnlock()
 	fake.getPublishedTracksMutex.RLock()
 	defer fake.getPublishedTracksMutex.RUnlock()
 	fake.getResponseSinkMutex.RLock()
 	defer fake.getResponseSinkMutex.RUnlock()
+	fake.getStatsMutex.RLock()
+	defer fake.getStatsMutex.RUnlock()
 	fake.getSubscribedParticipantsMutex.RLock()
 	defer fake.getSubscribedParticipantsMutex.RUnlock()
 	fake.getSubscribedTrackMutex.RLock()
@@ -3129,6 +4922,8 @@ func (fake *FakeParticipant) Invocations() map[string][][]interface{} {
 	defer fake.handleAnswerMutex.RUnlock()
 	fake.handleOfferMutex.RLock()
 	defer fake.handleOfferMutex.RUnlock()
+	fake.handleSignalDataMutex.RLock()
+	defer fake.handleSignalDataMutex.RUnlock()
 	fake.hiddenMutex.RLock()
 	defer fake.hiddenMutex.RUnlock()
 	fake.iCERestartMutex.RLock()
@@ -3141,8 +4936,16 @@ func (fake *FakeParticipant) Invocations() map[string][][]interface{} {
 	defer fake.isReadyMutex.RUnlock()
 	fake.isSubscribedToMutex.RLock()
 	defer fake.isSubscribedToMutex.RUnlock()
+	fake.kindMutex.RLock()
+	defer fake.kindMutex.RUnlock()
+	fake.lowPowerModeMutex.RLock()
+	defer fake.lowPowerModeMutex.RUnlock()
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
 	fake.negotiateMutex.RLock()
 	defer fake.negotiateMutex.RUnlock()
+	fake.onAttributesUpdateMutex.RLock()
+	defer fake.onAttributesUpdateMutex.RUnlock()
 	fake.onCloseMutex.RLock()
 	defer fake.onCloseMutex.RUnlock()
 	fake.onDataPacketMutex.RLock()
@@ -3157,44 +4960,78 @@ func (fake *FakeParticipant) Invocations() map[string][][]interface{} {
 	defer fake.onTrackUpdatedMutex.RUnlock()
 	fake.protocolVersionMutex.RLock()
 	defer fake.protocolVersionMutex.RUnlock()
-	fake.rTCPChanMutex.RLock()
-	defer fake.rTCPChanMutex.RUnlock()
+	fake.rTCPQueueMutex.RLock()
+	defer fake.rTCPQueueMutex.RUnlock()
+	fake.releaseSubscriptionMutex.RLock()
+	defer fake.releaseSubscriptionMutex.RUnlock()
 	fake.removeSubscribedTrackMutex.RLock()
 	defer fake.removeSubscribedTrackMutex.RUnlock()
 	fake.removeSubscriberMutex.RLock()
 	defer fake.removeSubscriberMutex.RUnlock()
+	fake.reserveSubscriptionMutex.RLock()
+	defer fake.reserveSubscriptionMutex.RUnlock()
+	fake.resumeSignalMutex.RLock()
+	defer fake.resumeSignalMutex.RUnlock()
 	fake.sendConnectionQualityUpdateMutex.RLock()
 	defer fake.sendConnectionQualityUpdateMutex.RUnlock()
 	fake.sendDataPacketMutex.RLock()
 	defer fake.sendDataPacketMutex.RUnlock()
 	fake.sendJoinResponseMutex.RLock()
 	defer fake.sendJoinResponseMutex.RUnlock()
+	fake.sendParticipantPageMutex.RLock()
+	defer fake.sendParticipantPageMutex.RUnlock()
 	fake.sendParticipantUpdateMutex.RLock()
 	defer fake.sendParticipantUpdateMutex.RUnlock()
 	fake.sendRoomUpdateMutex.RLock()
 	defer fake.sendRoomUpdateMutex.RUnlock()
 	fake.sendSpeakerUpdateMutex.RLock()
 	defer fake.sendSpeakerUpdateMutex.RUnlock()
+	fake.sendSubscriptionErrorMutex.RLock()
+	defer fake.sendSubscriptionErrorMutex.RUnlock()
+	fake.sendTextMessageMutex.RLock()
+	defer fake.sendTextMessageMutex.RUnlock()
+	fake.setAudioConfigMutex.RLock()
+	defer fake.setAudioConfigMutex.RUnlock()
+	fake.setDegradedMutex.RLock()
+	defer fake.setDegradedMutex.RUnlock()
 	fake.setMetadataMutex.RLock()
 	defer fake.setMetadataMutex.RUnlock()
+	fake.setNameMutex.RLock()
+	defer fake.setNameMutex.RUnlock()
 	fake.setPermissionMutex.RLock()
 	defer fake.setPermissionMutex.RUnlock()
 	fake.setResponseSinkMutex.RLock()
 	defer fake.setResponseSinkMutex.RUnlock()
+	fake.setTrackMetadataMutex.RLock()
+	defer fake.setTrackMetadataMutex.RUnlock()
 	fake.setTrackMutedMutex.RLock()
 	defer fake.setTrackMutedMutex.RUnlock()
+	fake.signalOnlyMutex.RLock()
+	defer fake.signalOnlyMutex.RUnlock()
 	fake.startMutex.RLock()
 	defer fake.startMutex.RUnlock()
+	fake.spatialLayerCapMutex.RLock()
+	defer fake.spatialLayerCapMutex.RUnlock()
 	fake.stateMutex.RLock()
 	defer fake.stateMutex.RUnlock()
 	fake.subscriberAsPrimaryMutex.RLock()
 	defer fake.subscriberAsPrimaryMutex.RUnlock()
+	fake.subscriberMediaDelayMutex.RLock()
+	defer fake.subscriberMediaDelayMutex.RUnlock()
 	fake.subscriberMediaEngineMutex.RLock()
 	defer fake.subscriberMediaEngineMutex.RUnlock()
 	fake.subscriberPCMutex.RLock()
 	defer fake.subscriberPCMutex.RUnlock()
+	fake.subscriberPacerMutex.RLock()
+	defer fake.subscriberPacerMutex.RUnlock()
 	fake.toProtoMutex.RLock()
 	defer fake.toProtoMutex.RUnlock()
+	fake.unpublishTrackMutex.RLock()
+	defer fake.unpublishTrackMutex.RUnlock()
+	fake.updateAttributesMutex.RLock()
+	defer fake.updateAttributesMutex.RUnlock()
+	fake.updateLimitsMutex.RLock()
+	defer fake.updateLimitsMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
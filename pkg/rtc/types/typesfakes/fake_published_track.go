@@ -26,6 +26,10 @@ type FakePublishedTrack struct {
 	addSubscriberReturnsOnCall map[int]struct {
 		result1 error
 	}
+	CloseStub        func()
+	closeMutex       sync.RWMutex
+	closeArgsForCall []struct {
+	}
 	GetQualityForDimensionStub        func(uint32, uint32) livekit.VideoQuality
 	getQualityForDimensionMutex       sync.RWMutex
 	getQualityForDimensionArgsForCall []struct {
@@ -38,6 +42,16 @@ type FakePublishedTrack struct {
 	getQualityForDimensionReturnsOnCall map[int]struct {
 		result1 livekit.VideoQuality
 	}
+	GetStatsStub        func() *types.PublishedTrackStats
+	getStatsMutex       sync.RWMutex
+	getStatsArgsForCall []struct {
+	}
+	getStatsReturns struct {
+		result1 *types.PublishedTrackStats
+	}
+	getStatsReturnsOnCall map[int]struct {
+		result1 *types.PublishedTrackStats
+	}
 	IDStub        func() string
 	iDMutex       sync.RWMutex
 	iDArgsForCall []struct {
@@ -79,6 +93,16 @@ type FakePublishedTrack struct {
 	kindReturnsOnCall map[int]struct {
 		result1 livekit.TrackType
 	}
+	MetadataStub        func() string
+	metadataMutex       sync.RWMutex
+	metadataArgsForCall []struct {
+	}
+	metadataReturns struct {
+		result1 string
+	}
+	metadataReturnsOnCall map[int]struct {
+		result1 string
+	}
 	NameStub        func() string
 	nameMutex       sync.RWMutex
 	nameArgsForCall []struct {
@@ -140,6 +164,11 @@ type FakePublishedTrack struct {
 	sdpCidReturnsOnCall map[int]struct {
 		result1 string
 	}
+	SetMetadataStub        func(string)
+	setMetadataMutex       sync.RWMutex
+	setMetadataArgsForCall []struct {
+		arg1 string
+	}
 	SetMutedStub        func(bool)
 	setMutedMutex       sync.RWMutex
 	setMutedArgsForCall []struct {
@@ -266,6 +295,30 @@ func (fake *FakePublishedTrack) AddSubscriberReturnsOnCall(i int, result1 error)
 	}{result1}
 }
 
+func (fake *FakePublishedTrack) Close() {
+	fake.closeMutex.Lock()
+	fake.closeArgsForCall = append(fake.closeArgsForCall, struct {
+	}{})
+	stub := fake.CloseStub
+	fake.recordInvocation("Close", []interface{}{})
+	fake.closeMutex.Unlock()
+	if stub != nil {
+		fake.CloseStub()
+	}
+}
+
+func (fake *FakePublishedTrack) CloseCallCount() int {
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	return len(fake.closeArgsForCall)
+}
+
+func (fake *FakePublishedTrack) CloseCalls(stub func()) {
+	fake.closeMutex.Lock()
+	defer fake.closeMutex.Unlock()
+	fake.CloseStub = stub
+}
+
 func (fake *FakePublishedTrack) GetQualityForDimension(arg1 uint32, arg2 uint32) livekit.VideoQuality {
 	fake.getQualityForDimensionMutex.Lock()
 	ret, specificReturn := fake.getQualityForDimensionReturnsOnCall[len(fake.getQualityForDimensionArgsForCall)]
@@ -328,6 +381,59 @@ func (fake *FakePublishedTrack) GetQualityForDimensionReturnsOnCall(i int, resul
 	}{result1}
 }
 
+func (fake *FakePublishedTrack) GetStats() *types.PublishedTrackStats {
+	fake.getStatsMutex.Lock()
+	ret, specificReturn := fake.getStatsReturnsOnCall[len(fake.getStatsArgsForCall)]
+	fake.getStatsArgsForCall = append(fake.getStatsArgsForCall, struct {
+	}{})
+	stub := fake.GetStatsStub
+	fakeReturns := fake.getStatsReturns
+	fake.recordInvocation("GetStats", []interface{}{})
+	fake.getStatsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePublishedTrack) GetStatsCallCount() int {
+	fake.getStatsMutex.RLock()
+	defer fake.getStatsMutex.RUnlock()
+	return len(fake.getStatsArgsForCall)
+}
+
+func (fake *FakePublishedTrack) GetStatsCalls(stub func() *types.PublishedTrackStats) {
+	fake.getStatsMutex.Lock()
+	defer fake.getStatsMutex.Unlock()
+	fake.GetStatsStub = stub
+}
+
+func (fake *FakePublishedTrack) GetStatsReturns(result1 *types.PublishedTrackStats) {
+	fake.getStatsMutex.Lock()
+	defer fake.getStatsMutex.Unlock()
+	fake.GetStatsStub = nil
+	fake.getStatsReturns = struct {
+		result1 *types.PublishedTrackStats
+	}{result1}
+}
+
+func (fake *FakePublishedTrack) GetStatsReturnsOnCall(i int, result1 *types.PublishedTrackStats) {
+	fake.getStatsMutex.Lock()
+	defer fake.getStatsMutex.Unlock()
+	fake.GetStatsStub = nil
+	if fake.getStatsReturnsOnCall == nil {
+		fake.getStatsReturnsOnCall = make(map[int]struct {
+			result1 *types.PublishedTrackStats
+		})
+	}
+	fake.getStatsReturnsOnCall[i] = struct {
+		result1 *types.PublishedTrackStats
+	}{result1}
+}
+
 func (fake *FakePublishedTrack) ID() string {
 	fake.iDMutex.Lock()
 	ret, specificReturn := fake.iDReturnsOnCall[len(fake.iDArgsForCall)]
@@ -548,6 +654,59 @@ func (fake *FakePublishedTrack) KindReturnsOnCall(i int, result1 livekit.TrackTy
 	}{result1}
 }
 
+func (fake *FakePublishedTrack) Metadata() string {
+	fake.metadataMutex.Lock()
+	ret, specificReturn := fake.metadataReturnsOnCall[len(fake.metadataArgsForCall)]
+	fake.metadataArgsForCall = append(fake.metadataArgsForCall, struct {
+	}{})
+	stub := fake.MetadataStub
+	fakeReturns := fake.metadataReturns
+	fake.recordInvocation("Metadata", []interface{}{})
+	fake.metadataMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePublishedTrack) MetadataCallCount() int {
+	fake.metadataMutex.RLock()
+	defer fake.metadataMutex.RUnlock()
+	return len(fake.metadataArgsForCall)
+}
+
+func (fake *FakePublishedTrack) MetadataCalls(stub func() string) {
+	fake.metadataMutex.Lock()
+	defer fake.metadataMutex.Unlock()
+	fake.MetadataStub = stub
+}
+
+func (fake *FakePublishedTrack) MetadataReturns(result1 string) {
+	fake.metadataMutex.Lock()
+	defer fake.metadataMutex.Unlock()
+	fake.MetadataStub = nil
+	fake.metadataReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakePublishedTrack) MetadataReturnsOnCall(i int, result1 string) {
+	fake.metadataMutex.Lock()
+	defer fake.metadataMutex.Unlock()
+	fake.MetadataStub = nil
+	if fake.metadataReturnsOnCall == nil {
+		fake.metadataReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.metadataReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
 func (fake *FakePublishedTrack) Name() string {
 	fake.nameMutex.Lock()
 	ret, specificReturn := fake.nameReturnsOnCall[len(fake.nameArgsForCall)]
@@ -872,6 +1031,38 @@ func (fake *FakePublishedTrack) SdpCidReturnsOnCall(i int, result1 string) {
 	}{result1}
 }
 
+func (fake *FakePublishedTrack) SetMetadata(arg1 string) {
+	fake.setMetadataMutex.Lock()
+	fake.setMetadataArgsForCall = append(fake.setMetadataArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.SetMetadataStub
+	fake.recordInvocation("SetMetadata", []interface{}{arg1})
+	fake.setMetadataMutex.Unlock()
+	if stub != nil {
+		fake.SetMetadataStub(arg1)
+	}
+}
+
+func (fake *FakePublishedTrack) SetMetadataCallCount() int {
+	fake.setMetadataMutex.RLock()
+	defer fake.setMetadataMutex.RUnlock()
+	return len(fake.setMetadataArgsForCall)
+}
+
+func (fake *FakePublishedTrack) SetMetadataCalls(stub func(string)) {
+	fake.setMetadataMutex.Lock()
+	defer fake.setMetadataMutex.Unlock()
+	fake.SetMetadataStub = stub
+}
+
+func (fake *FakePublishedTrack) SetMetadataArgsForCall(i int) string {
+	fake.setMetadataMutex.RLock()
+	defer fake.setMetadataMutex.RUnlock()
+	argsForCall := fake.setMetadataArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakePublishedTrack) SetMuted(arg1 bool) {
 	fake.setMutedMutex.Lock()
 	fake.setMutedArgsForCall = append(fake.setMutedArgsForCall, struct {
@@ -1041,8 +1232,12 @@ func (fake *FakePublishedTrack) Invocations() map[string][][]interface{} {
 	defer fake.addOnCloseMutex.RUnlock()
 	fake.addSubscriberMutex.RLock()
 	defer fake.addSubscriberMutex.RUnlock()
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
 	fake.getQualityForDimensionMutex.RLock()
 	defer fake.getQualityForDimensionMutex.RUnlock()
+	fake.getStatsMutex.RLock()
+	defer fake.getStatsMutex.RUnlock()
 	fake.iDMutex.RLock()
 	defer fake.iDMutex.RUnlock()
 	fake.isMutedMutex.RLock()
@@ -1051,6 +1246,8 @@ func (fake *FakePublishedTrack) Invocations() map[string][][]interface{} {
 	defer fake.isSubscriberMutex.RUnlock()
 	fake.kindMutex.RLock()
 	defer fake.kindMutex.RUnlock()
+	fake.metadataMutex.RLock()
+	defer fake.metadataMutex.RUnlock()
 	fake.nameMutex.RLock()
 	defer fake.nameMutex.RUnlock()
 	fake.numUpTracksMutex.RLock()
@@ -1065,6 +1262,8 @@ func (fake *FakePublishedTrack) Invocations() map[string][][]interface{} {
 	defer fake.removeSubscriberMutex.RUnlock()
 	fake.sdpCidMutex.RLock()
 	defer fake.sdpCidMutex.RUnlock()
+	fake.setMetadataMutex.RLock()
+	defer fake.setMetadataMutex.RUnlock()
 	fake.setMutedMutex.RLock()
 	defer fake.setMutedMutex.RUnlock()
 	fake.signalCidMutex.RLock()
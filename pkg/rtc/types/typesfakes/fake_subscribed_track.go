@@ -3,6 +3,7 @@ package typesfakes
 
 import (
 	"sync"
+	"time"
 
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/sfu"
@@ -10,6 +11,11 @@ import (
 )
 
 type FakeSubscribedTrack struct {
+	CapSpatialLayerStub        func(int32)
+	capSpatialLayerMutex       sync.RWMutex
+	capSpatialLayerArgsForCall []struct {
+		arg1 int32
+	}
 	DownTrackStub        func() *sfu.DownTrack
 	downTrackMutex       sync.RWMutex
 	downTrackArgsForCall []struct {
@@ -20,6 +26,16 @@ type FakeSubscribedTrack struct {
 	downTrackReturnsOnCall map[int]struct {
 		result1 *sfu.DownTrack
 	}
+	GetStatsStub        func() *types.SubscribedTrackStats
+	getStatsMutex       sync.RWMutex
+	getStatsArgsForCall []struct {
+	}
+	getStatsReturns struct {
+		result1 *types.SubscribedTrackStats
+	}
+	getStatsReturnsOnCall map[int]struct {
+		result1 *types.SubscribedTrackStats
+	}
 	IDStub        func() string
 	iDMutex       sync.RWMutex
 	iDArgsForCall []struct {
@@ -40,6 +56,16 @@ type FakeSubscribedTrack struct {
 	isMutedReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	LastVisibleStub        func() time.Time
+	lastVisibleMutex       sync.RWMutex
+	lastVisibleArgsForCall []struct {
+	}
+	lastVisibleReturns struct {
+		result1 time.Time
+	}
+	lastVisibleReturnsOnCall map[int]struct {
+		result1 time.Time
+	}
 	PublisherIdentityStub        func() string
 	publisherIdentityMutex       sync.RWMutex
 	publisherIdentityArgsForCall []struct {
@@ -50,6 +76,21 @@ type FakeSubscribedTrack struct {
 	publisherIdentityReturnsOnCall map[int]struct {
 		result1 string
 	}
+	QualityLabelStub        func() string
+	qualityLabelMutex       sync.RWMutex
+	qualityLabelArgsForCall []struct {
+	}
+	qualityLabelReturns struct {
+		result1 string
+	}
+	qualityLabelReturnsOnCall map[int]struct {
+		result1 string
+	}
+	SetMaxQualityStub        func(livekit.VideoQuality)
+	setMaxQualityMutex       sync.RWMutex
+	setMaxQualityArgsForCall []struct {
+		arg1 livekit.VideoQuality
+	}
 	SetPublisherMutedStub        func(bool)
 	setPublisherMutedMutex       sync.RWMutex
 	setPublisherMutedArgsForCall []struct {
@@ -75,6 +116,38 @@ type FakeSubscribedTrack struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeSubscribedTrack) CapSpatialLayer(arg1 int32) {
+	fake.capSpatialLayerMutex.Lock()
+	fake.capSpatialLayerArgsForCall = append(fake.capSpatialLayerArgsForCall, struct {
+		arg1 int32
+	}{arg1})
+	stub := fake.CapSpatialLayerStub
+	fake.recordInvocation("CapSpatialLayer", []interface{}{arg1})
+	fake.capSpatialLayerMutex.Unlock()
+	if stub != nil {
+		fake.CapSpatialLayerStub(arg1)
+	}
+}
+
+func (fake *FakeSubscribedTrack) CapSpatialLayerCallCount() int {
+	fake.capSpatialLayerMutex.RLock()
+	defer fake.capSpatialLayerMutex.RUnlock()
+	return len(fake.capSpatialLayerArgsForCall)
+}
+
+func (fake *FakeSubscribedTrack) CapSpatialLayerCalls(stub func(int32)) {
+	fake.capSpatialLayerMutex.Lock()
+	defer fake.capSpatialLayerMutex.Unlock()
+	fake.CapSpatialLayerStub = stub
+}
+
+func (fake *FakeSubscribedTrack) CapSpatialLayerArgsForCall(i int) int32 {
+	fake.capSpatialLayerMutex.RLock()
+	defer fake.capSpatialLayerMutex.RUnlock()
+	argsForCall := fake.capSpatialLayerArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeSubscribedTrack) DownTrack() *sfu.DownTrack {
 	fake.downTrackMutex.Lock()
 	ret, specificReturn := fake.downTrackReturnsOnCall[len(fake.downTrackArgsForCall)]
@@ -128,6 +201,59 @@ func (fake *FakeSubscribedTrack) DownTrackReturnsOnCall(i int, result1 *sfu.Down
 	}{result1}
 }
 
+func (fake *FakeSubscribedTrack) GetStats() *types.SubscribedTrackStats {
+	fake.getStatsMutex.Lock()
+	ret, specificReturn := fake.getStatsReturnsOnCall[len(fake.getStatsArgsForCall)]
+	fake.getStatsArgsForCall = append(fake.getStatsArgsForCall, struct {
+	}{})
+	stub := fake.GetStatsStub
+	fakeReturns := fake.getStatsReturns
+	fake.recordInvocation("GetStats", []interface{}{})
+	fake.getStatsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeSubscribedTrack) GetStatsCallCount() int {
+	fake.getStatsMutex.RLock()
+	defer fake.getStatsMutex.RUnlock()
+	return len(fake.getStatsArgsForCall)
+}
+
+func (fake *FakeSubscribedTrack) GetStatsCalls(stub func() *types.SubscribedTrackStats) {
+	fake.getStatsMutex.Lock()
+	defer fake.getStatsMutex.Unlock()
+	fake.GetStatsStub = stub
+}
+
+func (fake *FakeSubscribedTrack) GetStatsReturns(result1 *types.SubscribedTrackStats) {
+	fake.getStatsMutex.Lock()
+	defer fake.getStatsMutex.Unlock()
+	fake.GetStatsStub = nil
+	fake.getStatsReturns = struct {
+		result1 *types.SubscribedTrackStats
+	}{result1}
+}
+
+func (fake *FakeSubscribedTrack) GetStatsReturnsOnCall(i int, result1 *types.SubscribedTrackStats) {
+	fake.getStatsMutex.Lock()
+	defer fake.getStatsMutex.Unlock()
+	fake.GetStatsStub = nil
+	if fake.getStatsReturnsOnCall == nil {
+		fake.getStatsReturnsOnCall = make(map[int]struct {
+			result1 *types.SubscribedTrackStats
+		})
+	}
+	fake.getStatsReturnsOnCall[i] = struct {
+		result1 *types.SubscribedTrackStats
+	}{result1}
+}
+
 func (fake *FakeSubscribedTrack) ID() string {
 	fake.iDMutex.Lock()
 	ret, specificReturn := fake.iDReturnsOnCall[len(fake.iDArgsForCall)]
@@ -234,6 +360,59 @@ func (fake *FakeSubscribedTrack) IsMutedReturnsOnCall(i int, result1 bool) {
 	}{result1}
 }
 
+func (fake *FakeSubscribedTrack) LastVisible() time.Time {
+	fake.lastVisibleMutex.Lock()
+	ret, specificReturn := fake.lastVisibleReturnsOnCall[len(fake.lastVisibleArgsForCall)]
+	fake.lastVisibleArgsForCall = append(fake.lastVisibleArgsForCall, struct {
+	}{})
+	stub := fake.LastVisibleStub
+	fakeReturns := fake.lastVisibleReturns
+	fake.recordInvocation("LastVisible", []interface{}{})
+	fake.lastVisibleMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeSubscribedTrack) LastVisibleCallCount() int {
+	fake.lastVisibleMutex.RLock()
+	defer fake.lastVisibleMutex.RUnlock()
+	return len(fake.lastVisibleArgsForCall)
+}
+
+func (fake *FakeSubscribedTrack) LastVisibleCalls(stub func() time.Time) {
+	fake.lastVisibleMutex.Lock()
+	defer fake.lastVisibleMutex.Unlock()
+	fake.LastVisibleStub = stub
+}
+
+func (fake *FakeSubscribedTrack) LastVisibleReturns(result1 time.Time) {
+	fake.lastVisibleMutex.Lock()
+	defer fake.lastVisibleMutex.Unlock()
+	fake.LastVisibleStub = nil
+	fake.lastVisibleReturns = struct {
+		result1 time.Time
+	}{result1}
+}
+
+func (fake *FakeSubscribedTrack) LastVisibleReturnsOnCall(i int, result1 time.Time) {
+	fake.lastVisibleMutex.Lock()
+	defer fake.lastVisibleMutex.Unlock()
+	fake.LastVisibleStub = nil
+	if fake.lastVisibleReturnsOnCall == nil {
+		fake.lastVisibleReturnsOnCall = make(map[int]struct {
+			result1 time.Time
+		})
+	}
+	fake.lastVisibleReturnsOnCall[i] = struct {
+		result1 time.Time
+	}{result1}
+}
+
 func (fake *FakeSubscribedTrack) PublisherIdentity() string {
 	fake.publisherIdentityMutex.Lock()
 	ret, specificReturn := fake.publisherIdentityReturnsOnCall[len(fake.publisherIdentityArgsForCall)]
@@ -287,6 +466,91 @@ func (fake *FakeSubscribedTrack) PublisherIdentityReturnsOnCall(i int, result1 s
 	}{result1}
 }
 
+func (fake *FakeSubscribedTrack) QualityLabel() string {
+	fake.qualityLabelMutex.Lock()
+	ret, specificReturn := fake.qualityLabelReturnsOnCall[len(fake.qualityLabelArgsForCall)]
+	fake.qualityLabelArgsForCall = append(fake.qualityLabelArgsForCall, struct {
+	}{})
+	stub := fake.QualityLabelStub
+	fakeReturns := fake.qualityLabelReturns
+	fake.recordInvocation("QualityLabel", []interface{}{})
+	fake.qualityLabelMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeSubscribedTrack) QualityLabelCallCount() int {
+	fake.qualityLabelMutex.RLock()
+	defer fake.qualityLabelMutex.RUnlock()
+	return len(fake.qualityLabelArgsForCall)
+}
+
+func (fake *FakeSubscribedTrack) QualityLabelCalls(stub func() string) {
+	fake.qualityLabelMutex.Lock()
+	defer fake.qualityLabelMutex.Unlock()
+	fake.QualityLabelStub = stub
+}
+
+func (fake *FakeSubscribedTrack) QualityLabelReturns(result1 string) {
+	fake.qualityLabelMutex.Lock()
+	defer fake.qualityLabelMutex.Unlock()
+	fake.QualityLabelStub = nil
+	fake.qualityLabelReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeSubscribedTrack) QualityLabelReturnsOnCall(i int, result1 string) {
+	fake.qualityLabelMutex.Lock()
+	defer fake.qualityLabelMutex.Unlock()
+	fake.QualityLabelStub = nil
+	if fake.qualityLabelReturnsOnCall == nil {
+		fake.qualityLabelReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.qualityLabelReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeSubscribedTrack) SetMaxQuality(arg1 livekit.VideoQuality) {
+	fake.setMaxQualityMutex.Lock()
+	fake.setMaxQualityArgsForCall = append(fake.setMaxQualityArgsForCall, struct {
+		arg1 livekit.VideoQuality
+	}{arg1})
+	stub := fake.SetMaxQualityStub
+	fake.recordInvocation("SetMaxQuality", []interface{}{arg1})
+	fake.setMaxQualityMutex.Unlock()
+	if stub != nil {
+		fake.SetMaxQualityStub(arg1)
+	}
+}
+
+func (fake *FakeSubscribedTrack) SetMaxQualityCallCount() int {
+	fake.setMaxQualityMutex.RLock()
+	defer fake.setMaxQualityMutex.RUnlock()
+	return len(fake.setMaxQualityArgsForCall)
+}
+
+func (fake *FakeSubscribedTrack) SetMaxQualityCalls(stub func(livekit.VideoQuality)) {
+	fake.setMaxQualityMutex.Lock()
+	defer fake.setMaxQualityMutex.Unlock()
+	fake.SetMaxQualityStub = stub
+}
+
+func (fake *FakeSubscribedTrack) SetMaxQualityArgsForCall(i int) livekit.VideoQuality {
+	fake.setMaxQualityMutex.RLock()
+	defer fake.setMaxQualityMutex.RUnlock()
+	argsForCall := fake.setMaxQualityArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeSubscribedTrack) SetPublisherMuted(arg1 bool) {
 	fake.setPublisherMutedMutex.Lock()
 	fake.setPublisherMutedArgsForCall = append(fake.setPublisherMutedArgsForCall, struct {
@@ -408,14 +672,24 @@ func (fake *FakeSubscribedTrack) UpdateSubscriberSettingsArgsForCall(i int) (boo
 func (fake *FakeSubscribedTrack) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.capSpatialLayerMutex.RLock()
+	defer fake.capSpatialLayerMutex.RUnlock()
 	fake.downTrackMutex.RLock()
 	defer fake.downTrackMutex.RUnlock()
+	fake.getStatsMutex.RLock()
+	defer fake.getStatsMutex.RUnlock()
 	fake.iDMutex.RLock()
 	defer fake.iDMutex.RUnlock()
 	fake.isMutedMutex.RLock()
 	defer fake.isMutedMutex.RUnlock()
+	fake.lastVisibleMutex.RLock()
+	defer fake.lastVisibleMutex.RUnlock()
 	fake.publisherIdentityMutex.RLock()
 	defer fake.publisherIdentityMutex.RUnlock()
+	fake.qualityLabelMutex.RLock()
+	defer fake.qualityLabelMutex.RUnlock()
+	fake.setMaxQualityMutex.RLock()
+	defer fake.setMaxQualityMutex.RUnlock()
 	fake.setPublisherMutedMutex.RLock()
 	defer fake.setPublisherMutedMutex.RUnlock()
 	fake.subscribeLossPercentageMutex.RLock()
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+
+	"github.com/livekit/protocol/logger"
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+// adminForwardedHeader is set on admin requests forwarded to the node hosting the room, to the ID of
+// the node that forwarded them. That node serves them, whatever it thinks hosts the room
+const adminForwardedHeader = "X-Livekit-Forwarded-By"
+
+// forwardToRoomNode forwards the admin requests handler serves for rooms hosted by other nodes to
+// the node hosting the room, at its IP and the port of this node. The room is read from the room
+// query parameter of GET requests, and from the room field of JSON bodies
+func (s *AdminService) forwardToRoomNode(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(adminForwardedHeader) != "" {
+			handler(w, r)
+			return
+		}
+		roomName, err := adminRequestRoom(r)
+		if err != nil || roomName == "" || EnsureAdminPermission(r.Context(), roomName) != nil ||
+			s.roomManager.GetRoom(r.Context(), roomName) != nil {
+			// handler serves rooms hosted on this node, and rejects the others
+			handler(w, r)
+			return
+		}
+		node, err := s.roomManager.remoteRoomNode(r.Context(), roomName)
+		if err == ErrRoomNotFound {
+			handler(w, r)
+			return
+		} else if err != nil {
+			handleError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.forward(w, r, node)
+	}
+}
+
+// forward serves an admin request with the response of node
+func (s *AdminService) forward(w http.ResponseWriter, r *http.Request, node *livekit.Node) {
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{
+		Scheme: "http",
+		Host:   net.JoinHostPort(node.Ip, strconv.Itoa(int(s.roomManager.getConfig().Port))),
+	})
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		logger.Warnw("could not forward admin request", err, "node", node.Id, "path", r.URL.Path)
+		handleError(w, http.StatusBadGateway, fmt.Sprintf("the room is hosted by node %s, which can't be reached", node.Id))
+	}
+	r.Header.Set(adminForwardedHeader, s.roomManager.currentNode.Id)
+	proxy.ServeHTTP(w, r)
+}
+
+// adminRequestRoom returns the room an admin request is for. The body is kept for the handler
+func adminRequestRoom(r *http.Request) (string, error) {
+	if r.Method == http.MethodGet {
+		return r.URL.Query().Get("room"), nil
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return "", err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	req := &struct {
+		Room string `json:"room"`
+	}{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return "", err
+	}
+	return req.Room, nil
+}
+
+// remoteRoomNode returns the node hosting the room, ErrRoomNotFound unless it's another node
+func (r *RoomManager) remoteRoomNode(ctx context.Context, roomName string) (*livekit.Node, error) {
+	node, err := r.router.GetNodeForRoom(ctx, roomName)
+	if err == routing.ErrNotFound || (err == nil && node.Id == r.currentNode.Id) {
+		return nil, ErrRoomNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/livekit/protocol/auth"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+func TestAdminForwardToRoomNode(t *testing.T) {
+	var forwarded []*http.Request
+	var forwardedBodies []string
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		forwarded = append(forwarded, r)
+		forwardedBodies = append(forwardedBodies, string(body))
+		writeJSON(w, map[string]string{"served_by": "ND_other"})
+	}))
+	defer remote.Close()
+	_, port, err := net.SplitHostPort(strings.TrimPrefix(remote.URL, "http://"))
+	require.NoError(t, err)
+
+	conf, err := config.NewConfig("", nil)
+	require.NoError(t, err)
+	p, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	conf.Port = uint32(p)
+	node, err := routing.NewLocalNode(conf)
+	require.NoError(t, err)
+	router := &routingfakes.FakeRouter{}
+	router.GetNodeForRoomStub = func(ctx context.Context, roomName string) (*livekit.Node, error) {
+		switch roomName {
+		case "remote":
+			return &livekit.Node{Id: "ND_other", Ip: "127.0.0.1"}, nil
+		case "down":
+			// nothing listens there
+			return &livekit.Node{Id: "ND_down", Ip: "127.0.0.2"}, nil
+		}
+		return nil, routing.ErrNotFound
+	}
+	roomManager, err := NewLocalRoomManager(conf, NewLocalRoomStore(), node, router, telemetry.NewTelemetryService(nil, nil, nil, nil))
+	require.NoError(t, err)
+	defer roomManager.Stop()
+	s := &AdminService{roomManager: roomManager}
+	mux := http.NewServeMux()
+	s.SetupRoutes(mux)
+
+	serve := func(method, target, body, room string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		if room != "" {
+			r = r.WithContext(context.WithValue(r.Context(), grantsKey, &auth.ClaimGrants{
+				Video: &auth.VideoGrant{RoomAdmin: true, Room: room},
+			}))
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("GET requests", func(t *testing.T) {
+		w := serve(http.MethodGet, "/admin/participant_stats?room=remote&identity=bob", "", "remote")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"served_by": "ND_other"}`, w.Body.String())
+		require.Len(t, forwarded, 1)
+		require.Equal(t, "/admin/participant_stats", forwarded[0].URL.Path)
+		require.Equal(t, "remote", forwarded[0].URL.Query().Get("room"))
+		require.Equal(t, node.Id, forwarded[0].Header.Get(adminForwardedHeader))
+	})
+
+	t.Run("POST requests keep their body", func(t *testing.T) {
+		body := `{"room": "remote", "enabled": true}`
+		w := serve(http.MethodPost, "/admin/rooms/raw_dump", body, "remote")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Len(t, forwarded, 2)
+		require.Equal(t, body, forwardedBodies[1])
+	})
+
+	t.Run("rooms that don't exist aren't forwarded", func(t *testing.T) {
+		w := serve(http.MethodGet, "/admin/participant_stats?room=unknown&identity=bob", "", "unknown")
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Len(t, forwarded, 2)
+	})
+
+	t.Run("unauthorized requests aren't forwarded", func(t *testing.T) {
+		w := serve(http.MethodGet, "/admin/participant_stats?room=remote&identity=bob", "", "other")
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Len(t, forwarded, 2)
+	})
+
+	t.Run("forwarded requests are served", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/admin/participant_stats?room=remote&identity=bob", nil)
+		r.Header.Set(adminForwardedHeader, "ND_third")
+		r = r.WithContext(context.WithValue(r.Context(), grantsKey, &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomAdmin: true, Room: "remote"},
+		}))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Len(t, forwarded, 2)
+	})
+
+	t.Run("unreachable nodes", func(t *testing.T) {
+		w := serve(http.MethodGet, "/admin/participant_stats?room=down&identity=bob", "", "down")
+		require.Equal(t, http.StatusBadGateway, w.Code)
+		require.Contains(t, w.Body.String(), "ND_down")
+	})
+}
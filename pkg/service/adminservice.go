@@ -0,0 +1,762 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// AdminService exposes node administration endpoints that aren't part of the RoomService API.
+// Requests are authenticated with the same access tokens as RoomService.
+type AdminService struct {
+	roomManager    *RoomManager
+	roomService    *RoomService
+	configReloader *ConfigReloader
+	scheduler      *RoomScheduler
+	// nil when diagnostics aren't served
+	diagnostics *DiagnosticsServer
+}
+
+// CreateRoomWithPolicyRequest is a CreateRoom request with settings that override the room
+// config for this room
+type CreateRoomWithPolicyRequest struct {
+	Name            string `json:"name"`
+	EmptyTimeout    uint32 `json:"empty_timeout"`
+	MaxParticipants uint32 `json:"max_participants"`
+	NodeId          string `json:"node_id"`
+	// codecs that participants can publish, all codecs enabled in the room config when not set
+	EnabledCodecs []config.CodecSpec `json:"enabled_codecs"`
+	// fields that are set override the policy in the room config
+	Policy *config.RoomPolicy `json:"policy"`
+}
+
+// RoomParticipantsSummary lists the participants of a room with what each of them receives
+type RoomParticipantsSummary struct {
+	Room         string                      `json:"room"`
+	Participants []*types.ParticipantSummary `json:"participants"`
+}
+
+// RoomSpeakers tells how much each participant of a room spoke, and who the dominant speaker is
+type RoomSpeakers struct {
+	Room string `json:"room"`
+	// sid of the participant that spoke the most over the last seconds
+	DominantSpeaker string              `json:"dominant_speaker,omitempty"`
+	Speakers        []*rtc.SpeakerStats `json:"speakers"`
+}
+
+// RawDumpState tells whether the media published in a room is written to disk
+type RawDumpState struct {
+	Room    string `json:"room"`
+	Enabled bool   `json:"enabled"`
+}
+
+// UnpublishTrackRequest unpublishes a track of a participant
+type UnpublishTrackRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	TrackSid string `json:"track_sid"`
+}
+
+// PendingJoins lists the participants waiting to join a room
+type PendingJoins struct {
+	Room         string             `json:"room"`
+	Participants []*rtc.PendingJoin `json:"participants"`
+}
+
+// ApproveJoinRequest lets a participant waiting in the waiting room join, or turns it away
+type ApproveJoinRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	Approved bool   `json:"approved"`
+}
+
+// SubscriptionBlockRequest keeps a subscriber from receiving the tracks of a publisher, or lets it
+// receive them again
+type SubscriptionBlockRequest struct {
+	Room       string `json:"room"`
+	Subscriber string `json:"subscriber"`
+	Publisher  string `json:"publisher"`
+	Blocked    bool   `json:"blocked"`
+	// the publisher is blocked from receiving the tracks of the subscriber too, or unblocked
+	Mutual bool `json:"mutual"`
+}
+
+// SubscriptionBlocks lists the blocked subscriptions of a room
+type SubscriptionBlocks struct {
+	Room   string                   `json:"room"`
+	Blocks []*rtc.SubscriptionBlock `json:"blocks"`
+}
+
+func NewAdminService(
+	roomManager *RoomManager,
+	roomService *RoomService,
+	configReloader *ConfigReloader,
+	scheduler *RoomScheduler,
+	diagnostics *DiagnosticsServer,
+) *AdminService {
+	return &AdminService{
+		roomManager:    roomManager,
+		roomService:    roomService,
+		configReloader: configReloader,
+		scheduler:      scheduler,
+		diagnostics:    diagnostics,
+	}
+}
+
+// SetupRoutes registers the admin endpoints. Those for a room are forwarded to the node hosting it,
+// except for unpublishing, join approvals and attributes, which the room manager routes there
+func (s *AdminService) SetupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/admin/rooms/create", s.createRoom)
+	mux.HandleFunc("/admin/rooms/update", s.forwardToRoomNode(s.updateRoom))
+	mux.HandleFunc("/admin/rooms/audit", s.auditRooms)
+	mux.HandleFunc("/admin/participant_stats", s.forwardToRoomNode(s.participantStats))
+	mux.HandleFunc("/admin/rooms/participants", s.forwardToRoomNode(s.roomParticipants))
+	mux.HandleFunc("/admin/rooms/speakers", s.forwardToRoomNode(s.roomSpeakers))
+	mux.HandleFunc("/admin/config/reload", s.reloadConfig)
+	mux.HandleFunc("/admin/diagnostics", s.toggleDiagnostics)
+	mux.HandleFunc("/admin/rooms/schedule", s.scheduleActions)
+	mux.HandleFunc("/admin/rooms/raw_dump", s.forwardToRoomNode(s.rawDump))
+	mux.HandleFunc("/admin/rooms/unpublish", s.unpublishTrack)
+	mux.HandleFunc("/admin/rooms/pending_joins", s.forwardToRoomNode(s.pendingJoins))
+	mux.HandleFunc("/admin/rooms/approve_join", s.approveJoin)
+	mux.HandleFunc("/admin/rooms/subscription_blocks", s.forwardToRoomNode(s.subscriptionBlocks))
+	mux.HandleFunc("/admin/rooms/subscriptions", s.forwardToRoomNode(s.setSubscriptions))
+	mux.HandleFunc("/admin/rooms/participant_attributes", s.updateParticipantAttributes)
+	mux.HandleFunc("/admin/rooms/participant_name", s.forwardToRoomNode(s.updateParticipantName))
+}
+
+// createRoom creates a room with codecs and a policy of its own
+func (s *AdminService) createRoom(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		handleError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	req := &CreateRoomWithPolicyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		handleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := req.validate(); err != nil {
+		handleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rm, err := s.roomService.CreateRoomWithPolicy(r.Context(), &livekit.CreateRoomRequest{
+		Name:            req.Name,
+		EmptyTimeout:    req.EmptyTimeout,
+		MaxParticipants: req.MaxParticipants,
+		NodeId:          req.NodeId,
+	}, req.EnabledCodecs, req.Policy)
+	if err == ErrPermissionDenied {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	} else if err != nil {
+		handleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, rm)
+}
+
+func (req *CreateRoomWithPolicyRequest) validate() error {
+	if req.Name == "" {
+		return errors.New("name is required")
+	}
+	if req.EnabledCodecs != nil && len(req.EnabledCodecs) == 0 {
+		return errors.New("enabled_codecs must not be empty, leave it out to use the room config")
+	}
+	for _, codec := range req.EnabledCodecs {
+		if codec.Mime == "" {
+			return errors.New("enabled_codecs require a mime type")
+		}
+	}
+	if req.Policy != nil && req.Policy.MaxSimulcastLayers < 0 {
+		return errors.New("max_simulcast_layers must not be negative")
+	}
+	if req.Policy != nil {
+		if interval := time.Duration(req.Policy.SenderReportIntervalMs) * time.Millisecond; interval != 0 &&
+			(interval < config.MinSenderReportInterval || interval > config.MaxSenderReportInterval) {
+			return fmt.Errorf("sender_report_interval_ms must be between %d and %d",
+				config.MinSenderReportInterval.Milliseconds(), config.MaxSenderReportInterval.Milliseconds())
+		}
+		if req.Policy.SenderReportBatchSize < 0 || req.Policy.SenderReportBatchSize > config.MaxSenderReportBatchSize {
+			return fmt.Errorf("sender_report_batch_size must be between 1 and %d", config.MaxSenderReportBatchSize)
+		}
+		if err := config.ValidateHeaderExtensions(req.Policy.HeaderExtensions); err != nil {
+			return err
+		}
+		if req.Policy.Audio != nil {
+			if err := req.Policy.Audio.Validate(); err != nil {
+				return err
+			}
+		}
+		if req.Policy.Broadcast != nil && len(req.Policy.Broadcast.Publishers) == 0 {
+			return errors.New("broadcast needs the identities of its publishers")
+		}
+	}
+	return nil
+}
+
+// updateRoom changes the audio config of a room, applying it right away when the room is hosted
+// on this node
+func (s *AdminService) updateRoom(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		handleError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	req := &UpdateRoomRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		handleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Room == "" || req.Audio == nil {
+		handleError(w, http.StatusBadRequest, "room and audio are required")
+		return
+	}
+	if err := req.Audio.Validate(); err != nil {
+		handleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), req.Room); err != nil {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	audio, err := s.roomManager.UpdateRoomAudio(r.Context(), req.Room, req.Audio)
+	switch err {
+	case nil:
+		writeJSON(w, &UpdateRoomRequest{Room: req.Room, Audio: audio})
+	case ErrRoomNotFound:
+		handleError(w, http.StatusNotFound, err.Error())
+	default:
+		handleError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// auditRooms lists orphaned rooms and participants on GET, and removes them on POST
+func (s *AdminService) auditRooms(w http.ResponseWriter, r *http.Request) {
+	var cleanup bool
+	switch r.Method {
+	case http.MethodGet:
+		if err := EnsureListPermission(r.Context()); err != nil {
+			handleError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+	case http.MethodPost:
+		// cleanup deletes rooms, same as DeleteRoom
+		if err := EnsureCreatePermission(r.Context()); err != nil {
+			handleError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		cleanup = true
+	default:
+		handleError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	res, err := s.roomManager.AuditRooms(r.Context(), cleanup)
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, res)
+}
+
+// participantStats returns live media stats for a participant hosted on this node
+func (s *AdminService) participantStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		handleError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	roomName := r.FormValue("room")
+	identity := r.FormValue("identity")
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	room := s.roomManager.GetRoom(r.Context(), roomName)
+	if room == nil {
+		handleError(w, http.StatusNotFound, ErrRoomNotFound.Error())
+		return
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		handleError(w, http.StatusNotFound, ErrParticipantNotFound.Error())
+		return
+	}
+	writeJSON(w, participant.GetStats())
+}
+
+// roomSpeakers returns the talk time of the participants of a room hosted on this node, with
+// their speaking segments when segments is set
+func (s *AdminService) roomSpeakers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		handleError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	roomName := r.FormValue("room")
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	room := s.roomManager.GetRoom(r.Context(), roomName)
+	if room == nil {
+		handleError(w, http.StatusNotFound, ErrRoomNotFound.Error())
+		return
+	}
+	dominant, speakers := room.SpeakerStats(boolValue(r.FormValue("segments")))
+	writeJSON(w, &RoomSpeakers{Room: roomName, DominantSpeaker: dominant, Speakers: speakers})
+}
+
+// roomParticipants lists the participants of a room hosted on this node with their subscriptions,
+// the layers forwarded to them and their connection quality. ListParticipants has no room for
+// those, and is served from the room store
+func (s *AdminService) roomParticipants(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		handleError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	roomName := r.FormValue("room")
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	room := s.roomManager.GetRoom(r.Context(), roomName)
+	if room == nil {
+		handleError(w, http.StatusNotFound, ErrRoomNotFound.Error())
+		return
+	}
+	writeJSON(w, &RoomParticipantsSummary{
+		Room:         roomName,
+		Participants: room.ParticipantSummaries(),
+	})
+}
+
+// reloadConfig loads the config again and applies what can be changed while running, returning
+// what was applied and what requires a restart
+func (s *AdminService) reloadConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		handleError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	// changes keys and room defaults for every room
+	if err := EnsureCreatePermission(r.Context()); err != nil {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	res, err := s.configReloader.Reload()
+	if err == ErrConfigReloadUnavailable {
+		handleError(w, http.StatusNotImplemented, err.Error())
+		return
+	} else if err != nil {
+		handleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, res)
+}
+
+// toggleDiagnostics tells whether diagnostics are served by this node on GET, and enables or
+// disables them on POST
+func (s *AdminService) toggleDiagnostics(w http.ResponseWriter, r *http.Request) {
+	if s.diagnostics == nil {
+		handleError(w, http.StatusNotImplemented, ErrDiagnosticsUnavailable.Error())
+		return
+	}
+	req := &DiagnosticsState{}
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			handleError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	default:
+		handleError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	// diagnostics cover every room, same as the config
+	if err := EnsureCreatePermission(r.Context()); err != nil {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		s.diagnostics.SetEnabled(req.Enabled)
+	}
+	writeJSON(w, &DiagnosticsState{Enabled: s.diagnostics.Enabled()})
+}
+
+// scheduleActions schedules an action on a room on POST, lists the pending actions of a room on
+// GET, and cancels one on DELETE
+func (s *AdminService) scheduleActions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		action := &ScheduledAction{}
+		if err := json.NewDecoder(r.Body).Decode(action); err != nil {
+			handleError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := action.Validate(); err != nil {
+			handleError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := ensureScheduledActionPermission(r.Context(), action); err != nil {
+			handleError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if err := s.scheduler.Schedule(r.Context(), action); err != nil {
+			handleError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, action)
+
+	case http.MethodGet:
+		roomName := r.FormValue("room")
+		if EnsureAdminPermission(r.Context(), roomName) != nil {
+			if err := EnsureListPermission(r.Context()); err != nil {
+				handleError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+		}
+		actions, err := s.scheduler.List(r.Context(), roomName)
+		if err != nil {
+			handleError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if actions == nil {
+			actions = []*ScheduledAction{}
+		}
+		writeJSON(w, actions)
+
+	case http.MethodDelete:
+		// canceling requires the same permissions as scheduling
+		actions, err := s.scheduler.List(r.Context(), r.FormValue("room"))
+		if err != nil {
+			handleError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		var action *ScheduledAction
+		for _, a := range actions {
+			if a.ID == r.FormValue("id") {
+				action = a
+			}
+		}
+		if action == nil {
+			handleError(w, http.StatusNotFound, ErrScheduledActionNotFound.Error())
+			return
+		}
+		if err := ensureScheduledActionPermission(r.Context(), action); err != nil {
+			handleError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if canceled, err := s.scheduler.Cancel(r.Context(), action.ID); err != nil {
+			handleError(w, http.StatusInternalServerError, err.Error())
+			return
+		} else if !canceled {
+			// executed in the meantime
+			handleError(w, http.StatusNotFound, ErrScheduledActionNotFound.Error())
+			return
+		}
+		writeJSON(w, action)
+
+	default:
+		handleError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// rawDump tells whether the media of a room hosted on this node is written to disk on GET, and
+// turns it on or off on POST
+func (s *AdminService) rawDump(w http.ResponseWriter, r *http.Request) {
+	conf := s.roomManager.getConfig().RawDump
+	if !conf.Enabled {
+		handleError(w, http.StatusNotImplemented, ErrRawDumpDisabled.Error())
+		return
+	}
+
+	req := &RawDumpState{}
+	switch r.Method {
+	case http.MethodGet:
+		req.Room = r.FormValue("room")
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			handleError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	default:
+		handleError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if req.Room == "" {
+		handleError(w, http.StatusBadRequest, "room is required")
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), req.Room); err != nil {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	room := s.roomManager.GetRoom(r.Context(), req.Room)
+	if room == nil {
+		handleError(w, http.StatusNotFound, ErrRoomNotFound.Error())
+		return
+	}
+	if r.Method == http.MethodPost {
+		if !req.Enabled {
+			room.StopRawDump()
+		} else if err := room.StartRawDump(&conf); err != nil {
+			handleError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	writeJSON(w, &RawDumpState{Room: req.Room, Enabled: room.IsRawDumping()})
+}
+
+// unpublishTrack unpublishes a track of a participant, on the node hosting the room
+func (s *AdminService) unpublishTrack(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		handleError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	req := &UnpublishTrackRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		handleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), req.Room); err != nil {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	err := s.roomManager.UnpublishTrack(r.Context(), req.Room, req.Identity, req.TrackSid)
+	switch err {
+	case nil:
+		writeJSON(w, req)
+	case ErrRoomNotFound, ErrParticipantNotFound, ErrTrackNotFound:
+		handleError(w, http.StatusNotFound, err.Error())
+	default:
+		handleError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// pendingJoins lists the participants waiting to join a room hosted on this node
+func (s *AdminService) pendingJoins(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		handleError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	roomName := r.FormValue("room")
+	if roomName == "" {
+		handleError(w, http.StatusBadRequest, "room is required")
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if s.roomManager.GetRoom(r.Context(), roomName) == nil {
+		handleError(w, http.StatusNotFound, ErrRoomNotFound.Error())
+		return
+	}
+	writeJSON(w, &PendingJoins{Room: roomName, Participants: s.roomManager.ListPendingJoins(roomName)})
+}
+
+// approveJoin approves or denies the join of a participant waiting to join a room, on the node
+// hosting the room
+func (s *AdminService) approveJoin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		handleError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	req := &ApproveJoinRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		handleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), req.Room); err != nil {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	err := s.roomManager.ResolveJoin(r.Context(), req.Room, req.Identity, req.Approved)
+	switch err {
+	case nil:
+		writeJSON(w, req)
+	case ErrRoomNotFound, ErrParticipantNotFound:
+		handleError(w, http.StatusNotFound, err.Error())
+	default:
+		handleError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// subscriptionBlocks lists the blocked subscriptions of a room hosted on this node on GET, and
+// blocks or unblocks a subscription on POST
+func (s *AdminService) subscriptionBlocks(w http.ResponseWriter, r *http.Request) {
+	req := &SubscriptionBlockRequest{}
+	switch r.Method {
+	case http.MethodGet:
+		req.Room = r.FormValue("room")
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			handleError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if req.Subscriber == "" || req.Publisher == "" || req.Subscriber == req.Publisher {
+			handleError(w, http.StatusBadRequest, "subscriber and publisher must be different participants")
+			return
+		}
+	default:
+		handleError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if req.Room == "" {
+		handleError(w, http.StatusBadRequest, "room is required")
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), req.Room); err != nil {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	room := s.roomManager.GetRoom(r.Context(), req.Room)
+	if room == nil {
+		handleError(w, http.StatusNotFound, ErrRoomNotFound.Error())
+		return
+	}
+	if r.Method == http.MethodPost {
+		room.SetSubscriptionBlocked(req.Subscriber, req.Publisher, req.Blocked)
+		if req.Mutual {
+			room.SetSubscriptionBlocked(req.Publisher, req.Subscriber, req.Blocked)
+		}
+	}
+	writeJSON(w, &SubscriptionBlocks{Room: req.Room, Blocks: room.SubscriptionBlocks()})
+}
+
+// setSubscriptions sets the tracks subscribers of a room hosted on this node receive
+func (s *AdminService) setSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		handleError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	req := &SetSubscriptionsRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		handleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Room == "" {
+		handleError(w, http.StatusBadRequest, "room is required")
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), req.Room); err != nil {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	res, err := s.roomManager.SetSubscriptions(r.Context(), req)
+	switch err {
+	case nil:
+		writeJSON(w, res)
+	case ErrInvalidTrackSelection:
+		handleError(w, http.StatusBadRequest, err.Error())
+	case ErrRoomNotFound:
+		handleError(w, http.StatusNotFound, err.Error())
+	default:
+		handleError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// updateParticipantAttributes sets attributes of a participant, on the node hosting the room
+func (s *AdminService) updateParticipantAttributes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		handleError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	req := &ParticipantAttributesRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		handleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), req.Room); err != nil {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	err := s.roomManager.UpdateParticipantAttributes(r.Context(), req.Room, req.Identity, req.Attributes)
+	switch {
+	case err == nil:
+		writeJSON(w, req)
+	case isAttributeError(err):
+		handleError(w, http.StatusBadRequest, err.Error())
+	case err == ErrRoomNotFound, err == ErrParticipantNotFound:
+		handleError(w, http.StatusNotFound, err.Error())
+	default:
+		handleError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// updateParticipantName renames a participant in a room hosted on this node
+func (s *AdminService) updateParticipantName(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		handleError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	req := &ParticipantNameRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		handleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), req.Room); err != nil {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	err := s.roomManager.UpdateParticipantName(r.Context(), req.Room, req.Identity, req.Name)
+	switch err {
+	case nil:
+		writeJSON(w, req)
+	case rtc.ErrInvalidName:
+		handleError(w, http.StatusBadRequest, err.Error())
+	case ErrRoomNotFound, ErrParticipantNotFound:
+		handleError(w, http.StatusNotFound, err.Error())
+	default:
+		handleError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// ensureScheduledActionPermission checks that the action could be done right away: closing a
+// room requires the same permission as deleting it, locking it admin permission for the room
+func ensureScheduledActionPermission(ctx context.Context, action *ScheduledAction) error {
+	switch action.Action {
+	case ScheduledActionClose:
+		return EnsureCreatePermission(ctx)
+	case ScheduledActionStartRecording:
+		req, err := action.RecordingRequest()
+		if err != nil {
+			return err
+		}
+		return EnsureEgressPermission(ctx, req.GetTemplate().GetRoomName())
+	default:
+		return EnsureAdminPermission(ctx, action.Room)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/logger"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	// jobs an agent can be dispatched
+	AgentJobRoom  = "room"
+	AgentJobTrack = "track"
+
+	agentMessageRegister   = "register"
+	agentMessageRegistered = "registered"
+	agentMessageJob        = "job"
+
+	agentWorkerPrefix = "AW_"
+	agentJobPrefix    = "AJ_"
+
+	// time a worker has to register after connecting
+	agentRegisterTimeout = 10 * time.Second
+)
+
+// agentMessage is a JSON message exchanged with agent workers over their websocket
+type agentMessage struct {
+	Type string `json:"type"`
+	// name of the agent, jobs are dispatched to one worker of each name. Sent when registering
+	Name string `json:"name,omitempty"`
+	// jobs the worker takes. Sent when registering
+	Jobs []string `json:"jobs,omitempty"`
+	// assigned when registered
+	WorkerID string    `json:"worker_id,omitempty"`
+	Job      *AgentJob `json:"job,omitempty"`
+}
+
+// AgentJob asks an agent to join a room. Agents connect to /rtc with the token, and kind=agent
+type AgentJob struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	RoomSid  string `json:"room_sid"`
+	RoomName string `json:"room_name"`
+	// participant that published the track, for track jobs
+	ParticipantSid      string `json:"participant_sid,omitempty"`
+	ParticipantIdentity string `json:"participant_identity,omitempty"`
+	TrackSid            string `json:"track_sid,omitempty"`
+	// join token of the agent
+	Token string `json:"token"`
+}
+
+// AgentDispatcher dispatches jobs to the agent workers connected to this node, when rooms hosted
+// by this node start or participants in them publish tracks. Each job goes to one worker of each
+// agent name that takes it, round robin
+type AgentDispatcher struct {
+	conf     *config.AgentsConfig
+	provider auth.KeyProvider
+	upgrader websocket.Upgrader
+
+	lock sync.Mutex
+	// workers by agent name
+	workers map[string][]*agentWorker
+	// index of the worker of each agent name that gets the next job
+	next map[string]int
+}
+
+type agentWorker struct {
+	id   string
+	name string
+	jobs map[string]bool
+
+	lock sync.Mutex
+	conn *websocket.Conn
+}
+
+// NewAgentDispatcher returns nil when agents are disabled
+func NewAgentDispatcher(conf *config.Config, provider auth.KeyProvider, roomManager *RoomManager) *AgentDispatcher {
+	if !conf.Agents.Enabled {
+		return nil
+	}
+	d := &AgentDispatcher{
+		conf:     &conf.Agents,
+		provider: provider,
+		workers:  make(map[string][]*agentWorker),
+		next:     make(map[string]int),
+	}
+	roomManager.OnRoomStarted(d.onRoomStarted)
+	return d
+}
+
+// ServeHTTP registers an agent worker connecting over a websocket. Workers keep the connection open
+// to receive jobs
+func (d *AgentDispatcher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !websocket.IsWebSocketUpgrade(r) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err := EnsureAgentPermission(r.Context()); err != nil {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	conn, err := d.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Warnw("could not upgrade agent worker to WS", err)
+		return
+	}
+	defer conn.Close()
+
+	worker, err := d.register(conn)
+	if err != nil {
+		logger.Warnw("could not register agent worker", err)
+		_ = conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()), time.Now().Add(pingTimeout))
+		return
+	}
+	defer d.unregister(worker)
+	logger.Infow("agent worker registered", "agent", worker.name, "workerID", worker.id)
+
+	done := make(chan struct{})
+	defer close(done)
+	go worker.pingWorker(done)
+
+	// workers don't send anything after registering, reading detects closed connections
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			logger.Infow("agent worker disconnected", "agent", worker.name, "workerID", worker.id)
+			return
+		}
+	}
+}
+
+// Stop disconnects all workers
+func (d *AgentDispatcher) Stop() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	for _, workers := range d.workers {
+		for _, worker := range workers {
+			_ = worker.conn.Close()
+		}
+	}
+}
+
+func (d *AgentDispatcher) register(conn *websocket.Conn) (*agentWorker, error) {
+	_ = conn.SetReadDeadline(time.Now().Add(agentRegisterTimeout))
+	msg := &agentMessage{}
+	if err := conn.ReadJSON(msg); err != nil {
+		return nil, err
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+	if msg.Type != agentMessageRegister || msg.Name == "" || len(msg.Jobs) == 0 {
+		return nil, ErrInvalidAgentRegistration
+	}
+
+	worker := &agentWorker{
+		id:   utils.NewGuid(agentWorkerPrefix),
+		name: msg.Name,
+		jobs: make(map[string]bool),
+		conn: conn,
+	}
+	for _, job := range msg.Jobs {
+		if job != AgentJobRoom && job != AgentJobTrack {
+			return nil, ErrInvalidAgentRegistration
+		}
+		worker.jobs[job] = true
+	}
+
+	// jobs are dispatched once the worker is told it's registered
+	worker.lock.Lock()
+	d.lock.Lock()
+	d.workers[worker.name] = append(d.workers[worker.name], worker)
+	d.lock.Unlock()
+	err := worker.writeLocked(&agentMessage{Type: agentMessageRegistered, WorkerID: worker.id})
+	worker.lock.Unlock()
+	if err != nil {
+		d.unregister(worker)
+		return nil, err
+	}
+	return worker, nil
+}
+
+func (d *AgentDispatcher) unregister(worker *agentWorker) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	workers := d.workers[worker.name]
+	for i, w := range workers {
+		if w == worker {
+			workers = append(workers[:i], workers[i+1:]...)
+			break
+		}
+	}
+	if len(workers) == 0 {
+		delete(d.workers, worker.name)
+		delete(d.next, worker.name)
+	} else {
+		d.workers[worker.name] = workers
+	}
+}
+
+func (d *AgentDispatcher) onRoomStarted(room *rtc.Room) {
+	room.OnTrackPublished(func(participant types.Participant, track types.PublishedTrack) {
+		d.onTrackPublished(room.Room, participant, track)
+	})
+	go d.dispatch(&AgentJob{
+		Type:     AgentJobRoom,
+		RoomSid:  room.Room.Sid,
+		RoomName: room.Room.Name,
+	})
+}
+
+func (d *AgentDispatcher) onTrackPublished(room *livekit.Room, participant types.Participant, track types.PublishedTrack) {
+	// agents aren't dispatched for each other
+	if participant.Kind() == rtc.ParticipantKindAgent || participant.Hidden() {
+		return
+	}
+	go d.dispatch(&AgentJob{
+		Type:                AgentJobTrack,
+		RoomSid:             room.Sid,
+		RoomName:            room.Name,
+		ParticipantSid:      participant.ID(),
+		ParticipantIdentity: participant.Identity(),
+		TrackSid:            track.ID(),
+	})
+}
+
+// dispatch sends a copy of job to one worker of each agent name that takes it
+func (d *AgentDispatcher) dispatch(job *AgentJob) {
+	for _, worker := range d.selectWorkers(job.Type) {
+		workerJob := *job
+		workerJob.ID = utils.NewGuid(agentJobPrefix)
+		token, err := d.createToken(worker.name+"-"+workerJob.ID, job.RoomName)
+		if err != nil {
+			logger.Errorw("could not create agent token", err, "agent", worker.name, "room", job.RoomName)
+			continue
+		}
+		workerJob.Token = token
+
+		if err := worker.write(&agentMessage{Type: agentMessageJob, Job: &workerJob}); err != nil {
+			logger.Warnw("could not dispatch agent job", err,
+				"agent", worker.name, "workerID", worker.id, "room", job.RoomName, "jobType", job.Type)
+			continue
+		}
+		logger.Debugw("dispatched agent job",
+			"agent", worker.name, "workerID", worker.id, "jobID", workerJob.ID, "room", job.RoomName, "jobType", job.Type)
+	}
+}
+
+func (d *AgentDispatcher) selectWorkers(jobType string) []*agentWorker {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	var selected []*agentWorker
+	for name, workers := range d.workers {
+		var candidates []*agentWorker
+		for _, w := range workers {
+			if w.jobs[jobType] {
+				candidates = append(candidates, w)
+			}
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+		idx := d.next[name] % len(candidates)
+		d.next[name] = idx + 1
+		selected = append(selected, candidates[idx])
+	}
+	return selected
+}
+
+func (d *AgentDispatcher) createToken(identity, roomName string) (string, error) {
+	secret := d.provider.GetSecret(d.conf.APIKey)
+	if secret == "" {
+		return "", fmt.Errorf("%s is not one of the configured keys", d.conf.APIKey)
+	}
+	return auth.NewAccessToken(d.conf.APIKey, secret).
+		AddGrant(&auth.VideoGrant{
+			RoomJoin: true,
+			Room:     roomName,
+		}).
+		SetIdentity(identity).
+		SetValidFor(d.conf.TokenTTL).
+		ToJWT()
+}
+
+func (w *agentWorker) write(msg *agentMessage) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.writeLocked(msg)
+}
+
+func (w *agentWorker) writeLocked(msg *agentMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return w.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func (w *agentWorker) pingWorker(done chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case <-time.After(pingFrequency):
+		}
+		w.lock.Lock()
+		err := w.conn.WriteControl(websocket.PingMessage, []byte(""), time.Now().Add(pingTimeout))
+		w.lock.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
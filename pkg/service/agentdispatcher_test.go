@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/livekit/protocol/auth"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+func TestAgentDispatcher(t *testing.T) {
+	ctx := context.Background()
+	conf, err := config.NewConfig("", nil)
+	require.NoError(t, err)
+	conf.Agents.Enabled = true
+	conf.Agents.APIKey = "key"
+	secret := "0123456789abcdef0123456789abcdef"
+	provider := auth.NewFileBasedKeyProviderFromMap(map[string]string{"key": secret})
+
+	node, err := routing.NewLocalNode(conf)
+	require.NoError(t, err)
+	store := NewLocalRoomStore()
+	roomManager, err := NewLocalRoomManager(conf, store, node, &routingfakes.FakeRouter{},
+		telemetry.NewTelemetryService(nil, telemetry.NewAnalyticsService(conf, node, nil), nil, nil))
+	require.NoError(t, err)
+	defer roomManager.Stop()
+
+	d := NewAgentDispatcher(conf, provider, roomManager)
+	defer d.Stop()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("agent") != "" {
+			r = r.WithContext(context.WithValue(r.Context(), scopesKey, &ScopeGrants{CanRegisterAgent: true}))
+		}
+		d.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	connect := func(t *testing.T, jobs ...string) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial(url+"?agent=1", nil)
+		require.NoError(t, err)
+		require.NoError(t, conn.WriteJSON(&agentMessage{Type: agentMessageRegister, Name: "transcriber", Jobs: jobs}))
+		msg := &agentMessage{}
+		require.NoError(t, conn.ReadJSON(msg))
+		require.Equal(t, agentMessageRegistered, msg.Type)
+		require.NotEmpty(t, msg.WorkerID)
+		return conn
+	}
+	readJob := func(t *testing.T, conn *websocket.Conn) *AgentJob {
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		msg := &agentMessage{}
+		require.NoError(t, conn.ReadJSON(msg))
+		require.Equal(t, agentMessageJob, msg.Type)
+		return msg.Job
+	}
+
+	t.Run("workers need the agent scope", func(t *testing.T) {
+		_, res, err := websocket.DefaultDialer.Dial(url, nil)
+		require.Error(t, err)
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("invalid registrations are rejected", func(t *testing.T) {
+		conn, _, err := websocket.DefaultDialer.Dial(url+"?agent=1", nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		require.NoError(t, conn.WriteJSON(&agentMessage{Type: agentMessageRegister, Name: "transcriber", Jobs: []string{"call"}}))
+		_, _, err = conn.ReadMessage()
+		require.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation))
+	})
+
+	conn := connect(t, AgentJobRoom, AgentJobTrack)
+	defer conn.Close()
+
+	var room *rtc.Room
+	t.Run("agents are dispatched when rooms start", func(t *testing.T) {
+		require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Sid: "RM_1", Name: "myroom"}))
+		room, err = roomManager.getOrCreateRoom(ctx, "myroom")
+		require.NoError(t, err)
+
+		job := readJob(t, conn)
+		require.Equal(t, AgentJobRoom, job.Type)
+		require.Equal(t, "RM_1", job.RoomSid)
+		require.Equal(t, "myroom", job.RoomName)
+
+		v, err := auth.ParseAPIToken(job.Token)
+		require.NoError(t, err)
+		grants, err := v.Verify(secret)
+		require.NoError(t, err)
+		require.True(t, grants.Video.RoomJoin)
+		require.Equal(t, "myroom", grants.Video.Room)
+		require.Equal(t, "transcriber-"+job.ID, grants.Identity)
+	})
+
+	t.Run("agents are dispatched for tracks of other participants", func(t *testing.T) {
+		agent := &typesfakes.FakeParticipant{}
+		agent.KindReturns(rtc.ParticipantKindAgent)
+		d.onTrackPublished(room.Room, agent, &typesfakes.FakePublishedTrack{})
+
+		publisher := &typesfakes.FakeParticipant{}
+		publisher.KindReturns(rtc.ParticipantKindStandard)
+		publisher.IDReturns("PA_1")
+		publisher.IdentityReturns("alice")
+		track := &typesfakes.FakePublishedTrack{}
+		track.IDReturns("TR_1")
+		d.onTrackPublished(room.Room, publisher, track)
+
+		job := readJob(t, conn)
+		require.Equal(t, AgentJobTrack, job.Type)
+		require.Equal(t, "PA_1", job.ParticipantSid)
+		require.Equal(t, "alice", job.ParticipantIdentity)
+		require.Equal(t, "TR_1", job.TrackSid)
+	})
+
+	t.Run("jobs go to one worker of each agent", func(t *testing.T) {
+		other := connect(t, AgentJobRoom)
+		defer other.Close()
+
+		d.dispatch(&AgentJob{Type: AgentJobRoom, RoomName: "myroom"})
+		d.dispatch(&AgentJob{Type: AgentJobRoom, RoomName: "myroom"})
+		readJob(t, conn)
+		readJob(t, other)
+	})
+}
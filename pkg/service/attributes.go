@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// UpdateAttributesRequest is sent by clients over the signal connection as a JSON text message,
+// {"update_attributes": {...}}, to set attributes of their own. The signal protocol has no request
+// for it
+type UpdateAttributesRequest struct {
+	// echoed in the response
+	RequestID string `json:"request_id,omitempty"`
+	// attributes to set, the others are kept. Empty values remove attributes
+	Attributes map[string]string `json:"attributes"`
+}
+
+// UpdateAttributesResponse tells a client whether its attributes were updated
+type UpdateAttributesResponse struct {
+	RequestID string `json:"request_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ParticipantAttributesRequest sets attributes of a participant through the admin API
+type ParticipantAttributesRequest struct {
+	Room       string            `json:"room"`
+	Identity   string            `json:"identity"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+// canUpdateAttribute returns true when one of the keys a token grants matches key. Keys ending
+// with * match the keys they're a prefix of
+func canUpdateAttribute(granted []string, key string) bool {
+	for _, g := range granted {
+		if g == key || (strings.HasSuffix(g, "*") && strings.HasPrefix(key, strings.TrimSuffix(g, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// EnsureUpdateAttributesPermission checks that participants can set the attributes of their own
+func EnsureUpdateAttributesPermission(ctx context.Context, attributes map[string]string) error {
+	scopes := GetScopeGrants(ctx)
+	if scopes == nil {
+		return ErrPermissionDenied
+	}
+	for key := range attributes {
+		if !canUpdateAttribute(scopes.CanUpdateAttributes, key) {
+			return ErrPermissionDenied
+		}
+	}
+	return nil
+}
+
+// UpdateParticipantAttributes sets attributes of a participant. Updates for rooms hosted by other
+// nodes are routed to them, once they're valid and the room's participant is known
+func (r *RoomManager) UpdateParticipantAttributes(ctx context.Context, roomName, identity string, attributes map[string]string) error {
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		if err := r.ensureRemoteRoom(ctx, roomName); err != nil {
+			return err
+		}
+		if err := rtc.ValidateAttributes(attributes); err != nil {
+			return err
+		}
+		if _, err := r.roomStore.LoadParticipant(ctx, roomName, identity); err != nil {
+			return err
+		}
+		return r.routeTextRequest(ctx, roomName, identity, textKeyUpdateAttributes, &UpdateAttributesRequest{Attributes: attributes})
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		return ErrParticipantNotFound
+	}
+	return participant.UpdateAttributes(attributes)
+}
+
+// handleUpdateAttributesRequest sets attributes of participant on the node hosting the room. The
+// signal node checked the participant is allowed to set them
+func (r *RoomManager) handleUpdateAttributesRequest(room *rtc.Room, participant types.Participant, value json.RawMessage) (interface{}, error) {
+	req := &UpdateAttributesRequest{}
+	if err := decodeTextRequest(value, req); err != nil {
+		return nil, err
+	}
+	res := &UpdateAttributesResponse{RequestID: req.RequestID}
+	err := participant.UpdateAttributes(req.Attributes)
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res, err
+}
+
+// isAttributeError returns true when err rejects the attributes of an update
+func isAttributeError(err error) bool {
+	return err == rtc.ErrInvalidAttribute || err == rtc.ErrReservedAttribute || err == rtc.ErrTooManyAttributes
+}
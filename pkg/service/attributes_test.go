@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestEnsureUpdateAttributesPermission(t *testing.T) {
+	attributes := map[string]string{"status": "away"}
+	require.ErrorIs(t, EnsureUpdateAttributesPermission(context.Background(), attributes), ErrPermissionDenied)
+
+	ctx := context.WithValue(context.Background(), scopesKey, &ScopeGrants{CanUpdateAttributes: []string{"status", "app.*"}})
+	require.NoError(t, EnsureUpdateAttributesPermission(ctx, attributes))
+	require.NoError(t, EnsureUpdateAttributesPermission(ctx, map[string]string{"app.theme": "dark", "status": ""}))
+	require.ErrorIs(t, EnsureUpdateAttributesPermission(ctx, map[string]string{"role": "host"}), ErrPermissionDenied)
+	require.ErrorIs(t, EnsureUpdateAttributesPermission(ctx, map[string]string{"statuses": "away"}), ErrPermissionDenied)
+
+	ctx = context.WithValue(context.Background(), scopesKey, &ScopeGrants{CanUpdateAttributes: []string{"*"}})
+	require.NoError(t, EnsureUpdateAttributesPermission(ctx, map[string]string{"role": "host"}))
+}
+
+func TestUpdateParticipantAttributes(t *testing.T) {
+	rooms := newTextRequestRooms(t)
+	ctx := context.Background()
+	attributes := map[string]string{"status": "away"}
+	require.Equal(t, ErrRoomNotFound, rooms.roomManager.UpdateParticipantAttributes(ctx, "unknown", "bob", attributes))
+
+	t.Run("hosted rooms", func(t *testing.T) {
+		require.Equal(t, ErrParticipantNotFound, rooms.roomManager.UpdateParticipantAttributes(ctx, "hosted", "bob", attributes))
+		require.NoError(t, rooms.roomManager.UpdateParticipantAttributes(ctx, "hosted", "alice", attributes))
+		require.Equal(t, attributes, rooms.alice.UpdateAttributesArgsForCall(0))
+	})
+
+	t.Run("rooms hosted by other nodes", func(t *testing.T) {
+		require.NoError(t, rooms.store.StoreParticipant(ctx, "remote", &livekit.ParticipantInfo{Identity: "bob"}))
+		require.Equal(t, ErrParticipantNotFound, rooms.roomManager.UpdateParticipantAttributes(ctx, "remote", "carol", attributes))
+		require.Equal(t, rtc.ErrReservedAttribute, rooms.roomManager.UpdateParticipantAttributes(ctx, "remote", "bob", map[string]string{"low_power_mode": "true"}))
+		require.Equal(t, 0, rooms.router.WriteRoomRTCCallCount())
+
+		require.NoError(t, rooms.roomManager.UpdateParticipantAttributes(ctx, "remote", "bob", attributes))
+		roomName, identity, key, value := rooms.routedTextRequest(0)
+		require.Equal(t, "remote", roomName)
+		require.Equal(t, "bob", identity)
+		require.Equal(t, textKeyUpdateAttributes, key)
+		require.JSONEq(t, `{"attributes": {"status": "away"}}`, string(value))
+
+		rooms.roomManager.handleRTCMessage(ctx, "hosted", "alice", rtc.NewRTCNodeTextMessage(key, value))
+		require.Equal(t, attributes, rooms.alice.UpdateAttributesArgsForCall(1))
+	})
+}
+
+func TestUpdateAttributesRequest(t *testing.T) {
+	rooms := newTextRequestRooms(t)
+	rooms.alice.UpdateAttributesReturnsOnCall(1, rtc.ErrTooManyAttributes)
+
+	rooms.roomManager.handleTextRequest(rooms.room, rooms.alice, textKeyUpdateAttributes, []byte(`{"request_id": "1", "attributes": {"status": "away"}}`))
+	require.Equal(t, map[string]string{"status": "away"}, rooms.alice.UpdateAttributesArgsForCall(0))
+	key, value := rooms.alice.SendTextMessageArgsForCall(0)
+	require.Equal(t, "update_attributes_response", key)
+	require.JSONEq(t, `{"request_id": "1"}`, string(value))
+
+	rooms.roomManager.handleTextRequest(rooms.room, rooms.alice, textKeyUpdateAttributes, []byte(`{"request_id": "2", "attributes": {"hand": "up"}}`))
+	_, value = rooms.alice.SendTextMessageArgsForCall(1)
+	require.JSONEq(t, `{"request_id": "2", "error": "`+rtc.ErrTooManyAttributes.Error()+`"}`, string(value))
+}
+
+func TestWSSignalConnectionUpdateAttributes(t *testing.T) {
+	client := &typesfakes.FakeWebsocketClient{}
+	client.ReadMessageReturnsOnCall(0, websocket.TextMessage, []byte(`{"update_attributes": {"request_id": "1", "attributes": {"status": "away", "app.theme": ""}}}`), nil)
+	client.ReadMessageReturnsOnCall(1, websocket.BinaryMessage, []byte{}, nil)
+	conn := &WSSignalConnection{conn: client}
+
+	// passed on to the node hosting the room
+	conn.OnTextRequest(textKeyUpdateAttributes, forwardTextRequest(textKeyUpdateAttributes))
+	req, err := conn.ReadRequest()
+	require.NoError(t, err)
+	require.Nil(t, req.Message)
+	key, value := rtc.SignalRequestText(req)
+	require.Equal(t, textKeyUpdateAttributes, key)
+	require.JSONEq(t, `{"request_id": "1", "attributes": {"status": "away", "app.theme": ""}}`, string(value))
+	// still protobuf
+	require.False(t, conn.useJSON)
+
+	require.NoError(t, conn.WriteTextMessage(textResponseKey(textKeyUpdateAttributes), &UpdateAttributesResponse{RequestID: "1", Error: "permission denied"}))
+	messageType, payload := client.WriteMessageArgsForCall(0)
+	require.Equal(t, websocket.TextMessage, messageType)
+	require.JSONEq(t, `{"update_attributes_response": {"request_id": "1", "error": "permission denied"}}`, string(payload))
+}
@@ -7,14 +7,20 @@ import (
 	"strings"
 
 	"github.com/twitchtv/twirp"
+	"gopkg.in/square/go-jose.v2/jwt"
 
 	"github.com/livekit/protocol/auth"
+
+	"github.com/livekit/livekit-server/pkg/config"
 )
 
 const (
 	authorizationHeader = "Authorization"
 	bearerPrefix        = "Bearer "
 	grantsKey           = "grants"
+	scopesKey           = "scopes"
+	nameKey             = "name"
+	tokenKey            = "token"
 	accessTokenParam    = "access_token"
 )
 
@@ -22,6 +28,40 @@ var (
 	ErrPermissionDenied = errors.New("permissions denied")
 )
 
+// ScopeGrants are permissions that aren't part of auth.VideoGrant. They're read from the same video claim
+type ScopeGrants struct {
+	// start and manage recordings of the token's room, or of any room when the token has no room
+	CanStartEgress bool `json:"canStartEgress,omitempty"`
+	// manage ingress of the token's room, or of any room when the token has no room
+	CanManageIngress bool `json:"canManageIngress,omitempty"`
+	// register as an agent worker, and be dispatched into any room
+	CanRegisterAgent bool `json:"canRegisterAgent,omitempty"`
+	// mute tracks of and remove other participants of the room, over the signal connection
+	CanModerate bool `json:"canModerate,omitempty"`
+	// sources tracks can be published from: camera, microphone, screen_share or screen_share_audio.
+	// All when empty
+	CanPublishSources []string `json:"canPublishSources,omitempty"`
+	// approve or deny the joins of participants in the room's waiting room, without waiting there
+	CanApproveJoins bool `json:"canApproveJoins,omitempty"`
+	// attribute keys participants can set on themselves over the signal connection. Keys ending
+	// with * allow those they're a prefix of
+	CanUpdateAttributes []string `json:"canUpdateAttributes,omitempty"`
+	// identities of the participants whose tracks the participant can subscribe to. Identities
+	// ending with * allow those they're a prefix of. All when empty
+	CanSubscribeTo []string `json:"canSubscribeTo,omitempty"`
+	// whether the participant is subscribed to the tracks of others as they're published, unless
+	// the client asks otherwise when it connects. Defaults to true
+	AutoSubscribe *bool `json:"autoSubscribe,omitempty"`
+}
+
+// KeyScopeProvider is a KeyProvider whose keys can be restricted to what they're used for, with the
+// scopes of config.KeyScopes
+type KeyScopeProvider interface {
+	auth.KeyProvider
+	// GetKeyScopes returns the scopes of the key, none when it isn't restricted
+	GetKeyScopes(key string) []string
+}
+
 // authentication middleware
 type APIKeyAuthMiddleware struct {
 	provider auth.KeyProvider
@@ -34,7 +74,7 @@ func NewAPIKeyAuthMiddleware(provider auth.KeyProvider) *APIKeyAuthMiddleware {
 }
 
 func (m *APIKeyAuthMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
-	if r.URL != nil && r.URL.Path == "/rtc/validate" {
+	if r.URL != nil && (r.URL.Path == "/rtc/validate" || r.URL.Path == "/rtc/nodes") {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 	}
 
@@ -54,30 +94,61 @@ func (m *APIKeyAuthMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request,
 	}
 
 	if authToken != "" {
-		v, err := auth.ParseAPIToken(authToken)
+		ctx, err := m.withGrants(r.Context(), authToken)
 		if err != nil {
-			handleError(w, http.StatusUnauthorized, "invalid authorization token")
+			handleError(w, http.StatusUnauthorized, err.Error())
 			return
 		}
+		r = r.WithContext(ctx)
+	}
 
-		secret := m.provider.GetSecret(v.APIKey())
-		if secret == "" {
-			handleError(w, http.StatusUnauthorized, "invalid API key")
-			return
-		}
+	next.ServeHTTP(w, r)
+}
 
-		grants, err := v.Verify(secret)
-		if err != nil {
-			handleError(w, http.StatusUnauthorized, "invalid token: "+authToken+", error: "+err.Error())
-			return
-		}
+// withGrants verifies authToken, and returns ctx with its grants set
+func (m *APIKeyAuthMiddleware) withGrants(ctx context.Context, authToken string) (context.Context, error) {
+	v, err := auth.ParseAPIToken(authToken)
+	if err != nil {
+		return nil, errors.New("invalid authorization token")
+	}
 
-		// set grants in context
-		ctx := r.Context()
-		r = r.WithContext(context.WithValue(ctx, grantsKey, grants))
+	secret := m.provider.GetSecret(v.APIKey())
+	if secret == "" {
+		return nil, errors.New("invalid API key")
 	}
 
-	next.ServeHTTP(w, r)
+	grants, err := v.Verify(secret)
+	if err != nil {
+		return nil, errors.New("invalid token: " + authToken + ", error: " + err.Error())
+	}
+
+	scopes, err := parseScopeGrants(authToken)
+	if err != nil {
+		// the token isn't included, the error is sent to the client and logged
+		return nil, errors.New("invalid token scopes")
+	}
+
+	name, err := parseNameClaim(authToken)
+	if err != nil {
+		return nil, errors.New("invalid token name")
+	}
+
+	if sp, ok := m.provider.(KeyScopeProvider); ok {
+		if keyScopes := sp.GetKeyScopes(v.APIKey()); len(keyScopes) != 0 {
+			scopes = restrictToKeyScopes(grants, scopes, keyScopes)
+		}
+	}
+
+	// set grants in context
+	ctx = context.WithValue(ctx, grantsKey, grants)
+	ctx = context.WithValue(ctx, tokenKey, authToken)
+	if scopes != nil {
+		ctx = context.WithValue(ctx, scopesKey, scopes)
+	}
+	if name != "" {
+		ctx = context.WithValue(ctx, nameKey, name)
+	}
+	return ctx, nil
 }
 
 func GetGrants(ctx context.Context) *auth.ClaimGrants {
@@ -88,6 +159,106 @@ func GetGrants(ctx context.Context) *auth.ClaimGrants {
 	return claims
 }
 
+// GetAccessToken returns the verified token the grants were read from
+func GetAccessToken(ctx context.Context) string {
+	token, _ := ctx.Value(tokenKey).(string)
+	return token
+}
+
+func GetScopeGrants(ctx context.Context) *ScopeGrants {
+	scopes, ok := ctx.Value(scopesKey).(*ScopeGrants)
+	if !ok {
+		return nil
+	}
+	return scopes
+}
+
+// GetParticipantName returns the display name the token gives its participant, empty when it
+// has none
+func GetParticipantName(ctx context.Context) string {
+	name, _ := ctx.Value(nameKey).(string)
+	return name
+}
+
+// restrictToKeyScopes drops the grants of a token that the scopes of the key it's signed with don't
+// allow, so that a leaked key can only be used for what it was meant for. Returns the scope grants
+// that are left
+func restrictToKeyScopes(grants *auth.ClaimGrants, scopes *ScopeGrants, keyScopes []string) *ScopeGrants {
+	var admin, roomCreate, join, egress bool
+	for _, scope := range keyScopes {
+		switch scope {
+		case config.KeyScopeAdmin:
+			admin = true
+		case config.KeyScopeRoomCreate:
+			roomCreate = true
+		case config.KeyScopeJoin:
+			join = true
+		case config.KeyScopeEgress:
+			egress = true
+		}
+	}
+	if admin {
+		return scopes
+	}
+
+	if video := grants.Video; video != nil {
+		video.RoomCreate = video.RoomCreate && roomCreate
+		video.RoomRecord = video.RoomRecord && egress
+		video.RoomList = false
+		video.RoomAdmin = false
+		if !join {
+			video.RoomJoin = false
+			video.Hidden = false
+		}
+	}
+	if scopes == nil {
+		return nil
+	}
+	restricted := &ScopeGrants{
+		CanStartEgress: scopes.CanStartEgress && egress,
+	}
+	if join {
+		restricted.CanModerate = scopes.CanModerate
+		restricted.CanPublishSources = scopes.CanPublishSources
+		restricted.CanApproveJoins = scopes.CanApproveJoins
+		restricted.CanUpdateAttributes = scopes.CanUpdateAttributes
+		restricted.CanSubscribeTo = scopes.CanSubscribeTo
+		restricted.AutoSubscribe = scopes.AutoSubscribe
+	}
+	return restricted
+}
+
+// parseScopeGrants reads scopes from a token, its signature must have been verified already
+func parseScopeGrants(token string) (*ScopeGrants, error) {
+	tok, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, err
+	}
+	claims := struct {
+		Video *ScopeGrants `json:"video,omitempty"`
+	}{}
+	if err := tok.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return nil, err
+	}
+	return claims.Video, nil
+}
+
+// parseNameClaim reads the display name from a token, its signature must have been verified
+// already. auth.ClaimGrants has no field for it
+func parseNameClaim(token string) (string, error) {
+	tok, err := jwt.ParseSigned(token)
+	if err != nil {
+		return "", err
+	}
+	claims := struct {
+		Name string `json:"name,omitempty"`
+	}{}
+	if err := tok.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return "", err
+	}
+	return claims.Name, nil
+}
+
 func SetAuthorizationToken(r *http.Request, token string) {
 	r.Header.Set(authorizationHeader, bearerPrefix+token)
 }
@@ -152,7 +323,99 @@ func EnsureRecordPermission(ctx context.Context) error {
 	return nil
 }
 
+// EnsureEgressPermission checks that recordings of the room can be started. room is empty when
+// the recording isn't for a known room, which only tokens without room restriction can start
+func EnsureEgressPermission(ctx context.Context, room string) error {
+	claims := GetGrants(ctx)
+	if claims == nil || claims.Video == nil {
+		return ErrPermissionDenied
+	}
+	if claims.Video.RoomRecord {
+		return nil
+	}
+
+	scopes := GetScopeGrants(ctx)
+	if scopes == nil || !scopes.CanStartEgress {
+		return ErrPermissionDenied
+	}
+	if claims.Video.Room != "" && claims.Video.Room != room {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// EnsureEgressManagePermission checks that an existing recording of the room can be updated or
+// ended. room is empty when the recording isn't for a known room, or isn't known at all, which
+// only tokens without room restriction can manage
+func EnsureEgressManagePermission(ctx context.Context, room string) error {
+	claims := GetGrants(ctx)
+	if claims == nil || claims.Video == nil {
+		return ErrPermissionDenied
+	}
+	if claims.Video.RoomRecord {
+		return nil
+	}
+
+	if scopes := GetScopeGrants(ctx); scopes == nil || !scopes.CanStartEgress {
+		return ErrPermissionDenied
+	}
+	if claims.Video.Room != "" && claims.Video.Room != room {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// EnsureIngressPermission checks that ingress can be managed for the room
+func EnsureIngressPermission(ctx context.Context, room string) error {
+	claims := GetGrants(ctx)
+	if claims == nil || claims.Video == nil {
+		return ErrPermissionDenied
+	}
+
+	scopes := GetScopeGrants(ctx)
+	if scopes == nil || !scopes.CanManageIngress {
+		return ErrPermissionDenied
+	}
+	if claims.Video.Room != "" && claims.Video.Room != room {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// EnsureAgentPermission checks that agent workers can be registered
+func EnsureAgentPermission(ctx context.Context) error {
+	if scopes := GetScopeGrants(ctx); scopes == nil || !scopes.CanRegisterAgent {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// EnsureModeratePermission checks that participants can moderate the room they joined
+func EnsureModeratePermission(ctx context.Context) error {
+	if scopes := GetScopeGrants(ctx); scopes == nil || !scopes.CanModerate {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// EnsureApproveJoinsPermission checks that participants can approve joins to the room they joined
+func EnsureApproveJoinsPermission(ctx context.Context) error {
+	if scopes := GetScopeGrants(ctx); scopes == nil || !scopes.CanApproveJoins {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
 // wraps authentication errors around Twirp
 func twirpAuthError(err error) error {
 	return twirp.NewError(twirp.Unauthenticated, err.Error())
 }
+
+// translates lookup errors to Twirp NotFound, leaving other errors as they are
+func twirpNotFoundError(err error) error {
+	switch err {
+	case ErrRoomNotFound, ErrParticipantNotFound, ErrTrackNotFound:
+		return twirp.NotFoundError(err.Error())
+	}
+	return err
+}
@@ -1,14 +1,19 @@
 package service_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/service"
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/auth/authfakes"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
 )
 
 func TestAuthMiddleware(t *testing.T) {
@@ -56,3 +61,211 @@ func TestAuthMiddleware(t *testing.T) {
 	require.Nil(t, grants)
 	require.Equal(t, http.StatusUnauthorized, w.Code)
 }
+
+func TestScopeGrants(t *testing.T) {
+	api := "APIabcdefg"
+	secret := "somesecretencodedinbase62"
+	provider := &authfakes.FakeKeyProvider{}
+	provider.GetSecretReturns(secret)
+	m := service.NewAPIKeyAuthMiddleware(provider)
+
+	// returns a token carrying the video claim
+	sign := func(t *testing.T, video map[string]interface{}) string {
+		sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte(secret)},
+			(&jose.SignerOptions{}).WithType("JWT"))
+		require.NoError(t, err)
+		token, err := jwt.Signed(sig).
+			Claims(jwt.Claims{Issuer: api, Expiry: jwt.NewNumericDate(time.Now().Add(time.Minute))}).
+			Claims(map[string]interface{}{"video": video}).
+			CompactSerialize()
+		require.NoError(t, err)
+		return token
+	}
+	// serves the request with a token carrying the video claim, returning the handler's context
+	serve := func(t *testing.T, video map[string]interface{}) context.Context {
+		token := sign(t, video)
+		var ctx context.Context
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx = r.Context()
+		})
+		r := &http.Request{Header: http.Header{}}
+		service.SetAuthorizationToken(r, token)
+		m.ServeHTTP(httptest.NewRecorder(), r, handler)
+		require.NotNil(t, ctx)
+		return ctx
+	}
+
+	t.Run("moderator can record own room", func(t *testing.T) {
+		ctx := serve(t, map[string]interface{}{"room": "myroom", "roomAdmin": true, "canStartEgress": true})
+		require.Equal(t, &service.ScopeGrants{CanStartEgress: true}, service.GetScopeGrants(ctx))
+
+		require.NoError(t, service.EnsureEgressPermission(ctx, "myroom"))
+		require.NoError(t, service.EnsureEgressManagePermission(ctx, "myroom"))
+		require.Error(t, service.EnsureEgressPermission(ctx, "otherroom"))
+		require.Error(t, service.EnsureEgressManagePermission(ctx, "otherroom"))
+		require.Error(t, service.EnsureEgressManagePermission(ctx, ""))
+		require.Error(t, service.EnsureEgressPermission(ctx, ""))
+		require.Error(t, service.EnsureRecordPermission(ctx))
+		require.Error(t, service.EnsureIngressPermission(ctx, "myroom"))
+	})
+
+	t.Run("invalid scopes are rejected without the token", func(t *testing.T) {
+		token := sign(t, map[string]interface{}{"room": "myroom", "canStartEgress": "yes"})
+		r := &http.Request{Header: http.Header{}}
+		service.SetAuthorizationToken(r, token)
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("request with invalid scopes was served")
+		})
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.NotContains(t, w.Body.String(), token)
+	})
+
+	t.Run("unscoped egress", func(t *testing.T) {
+		ctx := serve(t, map[string]interface{}{"canStartEgress": true})
+		require.NoError(t, service.EnsureEgressPermission(ctx, "otherroom"))
+		require.NoError(t, service.EnsureEgressPermission(ctx, ""))
+		require.NoError(t, service.EnsureEgressManagePermission(ctx, "otherroom"))
+		require.NoError(t, service.EnsureEgressManagePermission(ctx, ""))
+	})
+
+	t.Run("roomRecord allows all egress", func(t *testing.T) {
+		ctx := serve(t, map[string]interface{}{"roomRecord": true})
+		require.False(t, service.GetScopeGrants(ctx).CanStartEgress)
+		require.NoError(t, service.EnsureEgressPermission(ctx, ""))
+		require.NoError(t, service.EnsureEgressManagePermission(ctx, ""))
+	})
+
+	t.Run("ingress", func(t *testing.T) {
+		ctx := serve(t, map[string]interface{}{"room": "myroom", "canManageIngress": true})
+		require.NoError(t, service.EnsureIngressPermission(ctx, "myroom"))
+		require.Error(t, service.EnsureIngressPermission(ctx, "otherroom"))
+		require.Error(t, service.EnsureEgressManagePermission(ctx, "myroom"))
+	})
+
+	t.Run("publish sources", func(t *testing.T) {
+		ctx := serve(t, map[string]interface{}{"room": "myroom", "roomJoin": true, "canPublishSources": []string{"microphone"}})
+		require.Equal(t, []string{"microphone"}, service.GetScopeGrants(ctx).CanPublishSources)
+	})
+
+	t.Run("subscriptions and auto subscribe", func(t *testing.T) {
+		ctx := serve(t, map[string]interface{}{"room": "myroom", "roomJoin": true, "canSubscribeTo": []string{"host", "stage-*"}, "autoSubscribe": false})
+		scopes := service.GetScopeGrants(ctx)
+		require.Equal(t, []string{"host", "stage-*"}, scopes.CanSubscribeTo)
+		require.NotNil(t, scopes.AutoSubscribe)
+		require.False(t, *scopes.AutoSubscribe)
+	})
+}
+
+// scopedKeyProvider restricts its keys to the given scopes
+type scopedKeyProvider struct {
+	authfakes.FakeKeyProvider
+	scopes map[string][]string
+}
+
+func (p *scopedKeyProvider) GetKeyScopes(key string) []string {
+	return p.scopes[key]
+}
+
+func TestKeyScopes(t *testing.T) {
+	secret := "somesecretencodedinbase62"
+	provider := &scopedKeyProvider{
+		scopes: map[string][]string{
+			"APIcreate": {config.KeyScopeRoomCreate},
+			"APIegress": {config.KeyScopeEgress, config.KeyScopeJoin},
+			"APIadmin":  {config.KeyScopeAdmin},
+		},
+	}
+	provider.GetSecretReturns(secret)
+	m := service.NewAPIKeyAuthMiddleware(provider)
+
+	serve := func(t *testing.T, key string, grant *auth.VideoGrant) context.Context {
+		token, err := auth.NewAccessToken(key, secret).AddGrant(grant).ToJWT()
+		require.NoError(t, err)
+
+		var ctx context.Context
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx = r.Context()
+		})
+		r := &http.Request{Header: http.Header{}}
+		service.SetAuthorizationToken(r, token)
+		m.ServeHTTP(httptest.NewRecorder(), r, handler)
+		require.NotNil(t, ctx)
+		return ctx
+	}
+	all := func() *auth.VideoGrant {
+		return &auth.VideoGrant{RoomCreate: true, RoomList: true, RoomRecord: true, RoomAdmin: true, RoomJoin: true, Room: "myroom"}
+	}
+
+	t.Run("room create only", func(t *testing.T) {
+		ctx := serve(t, "APIcreate", all())
+		require.NoError(t, service.EnsureCreatePermission(ctx))
+		require.Error(t, service.EnsureListPermission(ctx))
+		require.Error(t, service.EnsureRecordPermission(ctx))
+		require.Error(t, service.EnsureAdminPermission(ctx, "myroom"))
+		_, err := service.EnsureJoinPermission(ctx)
+		require.Error(t, err)
+	})
+
+	t.Run("egress and join", func(t *testing.T) {
+		ctx := serve(t, "APIegress", all())
+		require.NoError(t, service.EnsureRecordPermission(ctx))
+		room, err := service.EnsureJoinPermission(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "myroom", room)
+		require.Error(t, service.EnsureCreatePermission(ctx))
+		require.Error(t, service.EnsureAdminPermission(ctx, "myroom"))
+	})
+
+	t.Run("admin and unscoped keys are unrestricted", func(t *testing.T) {
+		for _, key := range []string{"APIadmin", "APIother"} {
+			ctx := serve(t, key, all())
+			require.EqualValues(t, all(), service.GetGrants(ctx).Video)
+		}
+	})
+}
+
+func TestParticipantNameClaim(t *testing.T) {
+	api := "APIabcdefg"
+	secret := "somesecretencodedinbase62"
+	provider := &authfakes.FakeKeyProvider{}
+	provider.GetSecretReturns(secret)
+	m := service.NewAPIKeyAuthMiddleware(provider)
+
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte(secret)},
+		(&jose.SignerOptions{}).WithType("JWT"))
+	require.NoError(t, err)
+	token, err := jwt.Signed(sig).
+		Claims(jwt.Claims{Issuer: api, Subject: "alice", Expiry: jwt.NewNumericDate(time.Now().Add(time.Minute))}).
+		Claims(map[string]interface{}{"name": "Alice", "video": map[string]interface{}{"room": "myroom", "roomJoin": true}}).
+		CompactSerialize()
+	require.NoError(t, err)
+
+	var ctx context.Context
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})
+	r := &http.Request{Header: http.Header{}}
+	service.SetAuthorizationToken(r, token)
+	m.ServeHTTP(httptest.NewRecorder(), r, handler)
+	require.NotNil(t, ctx)
+	require.Equal(t, "Alice", service.GetParticipantName(ctx))
+	require.Equal(t, "alice", service.GetGrants(ctx).Identity)
+
+	require.Empty(t, service.GetParticipantName(context.Background()))
+
+	// invalid names are rejected without the token
+	token, err = jwt.Signed(sig).
+		Claims(jwt.Claims{Issuer: api, Subject: "alice", Expiry: jwt.NewNumericDate(time.Now().Add(time.Minute))}).
+		Claims(map[string]interface{}{"name": 5, "video": map[string]interface{}{"room": "myroom", "roomJoin": true}}).
+		CompactSerialize()
+	require.NoError(t, err)
+	ctx = nil
+	r = &http.Request{Header: http.Header{}}
+	service.SetAuthorizationToken(r, token)
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r, handler)
+	require.Nil(t, ctx)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.NotContains(t, w.Body.String(), token)
+}
@@ -0,0 +1,8 @@
+package service
+
+// CapabilitiesInfo is sent to clients that declare capabilities when connecting, as a JSON text
+// message, {"capabilities": {...}}, before the join response. It lists those the server granted,
+// including the ones that follow from the client's protocol version
+type CapabilitiesInfo struct {
+	Enabled []string `json:"enabled"`
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+func TestWSSignalConnectionCapabilities(t *testing.T) {
+	client := &typesfakes.FakeWebsocketClient{}
+	conn := &WSSignalConnection{conn: client}
+
+	capabilities := types.NegotiateCapabilities(2, "resume,dynacast,something_new")
+	require.NoError(t, conn.WriteTextMessage(textKeyCapabilities, &CapabilitiesInfo{Enabled: capabilities.Names()}))
+	messageType, payload := client.WriteMessageArgsForCall(0)
+	require.Equal(t, websocket.TextMessage, messageType)
+	require.JSONEq(t, `{"capabilities": {"enabled": ["data_packets", "dynacast", "packed_stream_id", "protobuf", "resume"]}}`, string(payload))
+}
+
+func TestWSSignalConnectionSubscribedQuality(t *testing.T) {
+	client := &typesfakes.FakeWebsocketClient{}
+	conn := &WSSignalConnection{conn: client}
+
+	// JSON text messages for protobuf clients too
+	require.NoError(t, conn.WriteResponse(rtc.NewSubscribedQualityResponse(&rtc.SubscribedQualityUpdate{TrackSid: "TR_a", MaxLayer: 1})))
+	messageType, payload := client.WriteMessageArgsForCall(0)
+	require.Equal(t, websocket.TextMessage, messageType)
+	require.JSONEq(t, `{"subscribed_quality_update": {"track_sid": "TR_a", "subscribed_qualities": [
+		{"quality": "LOW", "enabled": true}, {"quality": "MEDIUM", "enabled": true}, {"quality": "HIGH", "enabled": false}
+	]}}`, string(payload))
+
+	require.NoError(t, conn.WriteResponse(rtc.NewSubscribedQualityResponse(&rtc.SubscribedQualityUpdate{TrackSid: "TR_a", MaxLayer: sfu.InvalidSpatialLayer})))
+	_, payload = client.WriteMessageArgsForCall(1)
+	require.JSONEq(t, `{"subscribed_quality_update": {"track_sid": "TR_a", "subscribed_qualities": [
+		{"quality": "LOW", "enabled": false}, {"quality": "MEDIUM", "enabled": false}, {"quality": "HIGH", "enabled": false}
+	]}}`, string(payload))
+}
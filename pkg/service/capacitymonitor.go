@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/selector"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+// reason a node is over capacity, besides its limits
+const capacityReasonCPU = "cpu"
+
+// subscriptionQuality counts the subscribed video tracks on the node that are forwarded below the
+// quality their subscribers asked for
+type subscriptionQuality struct {
+	videoTracks    int
+	degradedTracks int
+	// rooms with at least one degraded track
+	affectedRooms int
+}
+
+// CapacityMonitor checks the load of the node every interval, and reports to webhooks when it goes
+// over capacity, when that degrades the quality subscribers receive across the node, and when it
+// recovers
+type CapacityMonitor struct {
+	conf         *config.CapacityReportConfig
+	limits       config.LimitConfig
+	sysloadLimit float32
+	currentNode  routing.LocalNode
+	roomManager  *RoomManager
+	telemetry    telemetry.TelemetryService
+
+	// sampled by the monitor, the router only keeps the node's stats up to date when using Redis
+	stats    *livekit.NodeStats
+	severity string
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewCapacityMonitor(conf *config.Config, currentNode routing.LocalNode, roomManager *RoomManager, telemetryService telemetry.TelemetryService) *CapacityMonitor {
+	if !conf.CapacityReport.Enabled {
+		return nil
+	}
+	now := time.Now().Unix()
+	return &CapacityMonitor{
+		conf:         &conf.CapacityReport,
+		limits:       conf.Limit,
+		sysloadLimit: conf.NodeSelector.SysloadLimit,
+		currentNode:  currentNode,
+		roomManager:  roomManager,
+		telemetry:    telemetryService,
+		stats:        &livekit.NodeStats{StartedAt: now, UpdatedAt: now},
+		severity:     telemetry.CapacitySeverityNone,
+		done:         make(chan struct{}),
+	}
+}
+
+func (m *CapacityMonitor) Start() {
+	m.wg.Add(1)
+	go m.run()
+}
+
+func (m *CapacityMonitor) Stop() {
+	close(m.done)
+	m.wg.Wait()
+}
+
+func (m *CapacityMonitor) run() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.conf.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			m.check()
+		}
+	}
+}
+
+// check reports the node's capacity when its severity changed since the last check
+func (m *CapacityMonitor) check() {
+	if err := prometheus.UpdateCurrentNodeStats(m.stats); err != nil {
+		logger.Warnw("could not update node stats", err)
+	}
+
+	report := m.evaluate(m.stats, m.roomManager.sampleSubscriptionQuality())
+	if report.Severity == m.severity {
+		return
+	}
+	m.severity = report.Severity
+
+	values := []interface{}{
+		"severity", report.Severity,
+		"reasons", report.Reasons,
+		"cpuLoad", report.CPULoad,
+		"affectedRooms", report.AffectedRooms,
+		"degradedTracks", report.DegradedTracks,
+		"videoTracks", report.VideoTracks,
+	}
+	if report.Severity == telemetry.CapacitySeverityNone {
+		logger.Infow("node is back under capacity", values...)
+	} else {
+		logger.Warnw("node is over capacity", nil, values...)
+	}
+	m.telemetry.NodeCapacityChanged(context.Background(), report)
+}
+
+// evaluate tells how overloaded the node is. It's a warning when the node is over one of its
+// limits, and critical when that comes with a share of degraded video subscriptions of at least
+// degraded_track_ratio
+func (m *CapacityMonitor) evaluate(stats *livekit.NodeStats, quality subscriptionQuality) *telemetry.CapacityReport {
+	report := &telemetry.CapacityReport{
+		NodeID:         m.currentNode.Id,
+		Region:         m.currentNode.Region,
+		Severity:       telemetry.CapacitySeverityNone,
+		NumRooms:       stats.NumRooms,
+		AffectedRooms:  quality.affectedRooms,
+		DegradedTracks: quality.degradedTracks,
+		VideoTracks:    quality.videoTracks,
+		Time:           time.Now().Unix(),
+	}
+	if stats.NumCpus > 0 {
+		report.CPULoad = stats.LoadAvgLast1Min / float32(stats.NumCpus)
+	}
+
+	if m.sysloadLimit > 0 && report.CPULoad >= m.sysloadLimit {
+		report.Reasons = append(report.Reasons, capacityReasonCPU)
+	}
+	report.Reasons = append(report.Reasons, selector.ExceededLimits(m.limits, stats)...)
+	if len(report.Reasons) == 0 {
+		return report
+	}
+
+	report.Severity = telemetry.CapacitySeverityWarning
+	if quality.videoTracks > 0 &&
+		float32(quality.degradedTracks)/float32(quality.videoTracks) >= m.conf.DegradedTrackRatio {
+		report.Severity = telemetry.CapacitySeverityCritical
+	}
+	return report
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing/selector"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+func TestCapacityMonitor_Evaluate(t *testing.T) {
+	m := &CapacityMonitor{
+		conf:         &config.CapacityReportConfig{DegradedTrackRatio: 0.2},
+		limits:       config.LimitConfig{NumTracks: 100, BytesPerSec: 1000},
+		sysloadLimit: 0.7,
+		currentNode:  &livekit.Node{Id: "node", Region: "us"},
+	}
+
+	t.Run("under capacity", func(t *testing.T) {
+		// degraded subscriptions on their own are network problems
+		report := m.evaluate(&livekit.NodeStats{NumCpus: 4, LoadAvgLast1Min: 1, NumRooms: 2},
+			subscriptionQuality{videoTracks: 10, degradedTracks: 5, affectedRooms: 2})
+		require.Equal(t, telemetry.CapacitySeverityNone, report.Severity)
+		require.Empty(t, report.Reasons)
+		require.Equal(t, "node", report.NodeID)
+		require.Equal(t, "us", report.Region)
+		require.Equal(t, float32(0.25), report.CPULoad)
+		require.Equal(t, int32(2), report.NumRooms)
+		require.Equal(t, 2, report.AffectedRooms)
+	})
+
+	t.Run("over capacity", func(t *testing.T) {
+		report := m.evaluate(&livekit.NodeStats{NumCpus: 4, LoadAvgLast1Min: 3, NumTracksIn: 20, NumTracksOut: 80},
+			subscriptionQuality{videoTracks: 10, degradedTracks: 1, affectedRooms: 1})
+		require.Equal(t, telemetry.CapacitySeverityWarning, report.Severity)
+		require.Equal(t, []string{capacityReasonCPU, selector.LimitTracks}, report.Reasons)
+	})
+
+	t.Run("degrading quality", func(t *testing.T) {
+		report := m.evaluate(&livekit.NodeStats{NumCpus: 4, BytesInPerSec: 200, BytesOutPerSec: 800},
+			subscriptionQuality{videoTracks: 10, degradedTracks: 2, affectedRooms: 1})
+		require.Equal(t, telemetry.CapacitySeverityCritical, report.Severity)
+		require.Equal(t, []string{selector.LimitBandwidth}, report.Reasons)
+		require.Equal(t, 2, report.DegradedTracks)
+		require.Equal(t, 10, report.VideoTracks)
+	})
+}
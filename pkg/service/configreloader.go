@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/webhook"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	serverlogger "github.com/livekit/livekit-server/pkg/logger"
+)
+
+// ConfigLoader loads the config the same way it was loaded at startup
+type ConfigLoader func() (*config.Config, error)
+
+// ConfigReloadResult lists the fields that changed when reloading the config
+type ConfigReloadResult struct {
+	// fields that were applied
+	Reloaded []string `json:"reloaded"`
+	// fields that changed, but only take effect after a restart
+	RestartRequired []string `json:"restart_required"`
+}
+
+// ConfigReloader applies changes to the config of a running server, without disconnecting
+// participants. Only the fields listed by config.IsReloadable are applied: the log level, room
+// defaults for new rooms, RTC limits, webhooks, API keys and whether diagnostics are served
+type ConfigReloader struct {
+	lock   sync.Mutex
+	conf   *config.Config
+	loader ConfigLoader
+
+	keyProvider   *ReloadableKeyProvider
+	notifier      *ReloadableNotifier
+	roomAllocator RoomAllocator
+	roomService   *RoomService
+	roomManager   *RoomManager
+	// nil when diagnostics aren't served
+	diagnostics *DiagnosticsServer
+}
+
+func NewConfigReloader(
+	conf *config.Config,
+	keyProvider *ReloadableKeyProvider,
+	notifier *ReloadableNotifier,
+	roomAllocator RoomAllocator,
+	roomService *RoomService,
+	roomManager *RoomManager,
+	diagnostics *DiagnosticsServer,
+) *ConfigReloader {
+	return &ConfigReloader{
+		conf:          conf,
+		keyProvider:   keyProvider,
+		notifier:      notifier,
+		roomAllocator: roomAllocator,
+		roomService:   roomService,
+		roomManager:   roomManager,
+		diagnostics:   diagnostics,
+	}
+}
+
+// SetLoader sets where Reload loads the config from
+func (r *ConfigReloader) SetLoader(loader ConfigLoader) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.loader = loader
+}
+
+// Reload loads the config again, and applies it
+func (r *ConfigReloader) Reload() (*ConfigReloadResult, error) {
+	r.lock.Lock()
+	loader := r.loader
+	r.lock.Unlock()
+	if loader == nil {
+		return nil, ErrConfigReloadUnavailable
+	}
+
+	next, err := loader()
+	if err != nil {
+		return nil, err
+	}
+	return r.Apply(next)
+}
+
+// Apply applies the reloadable fields of next. next is validated first, nothing is applied when
+// it's invalid or the API keys or webhook can't be set up
+func (r *ConfigReloader) Apply(next *config.Config) (*ConfigReloadResult, error) {
+	issues := next.Validate()
+	if err := config.ValidationErr(issues); err != nil {
+		return nil, err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	res := &ConfigReloadResult{}
+	for _, field := range r.conf.ChangedFields(next) {
+		if config.IsReloadable(field) {
+			res.Reloaded = append(res.Reloaded, field)
+		} else {
+			res.RestartRequired = append(res.RestartRequired, field)
+		}
+	}
+	if len(res.Reloaded) == 0 {
+		return res, nil
+	}
+
+	reloaded := r.conf.WithReloadable(next)
+	keyProvider, err := loadKeyProvider(reloaded)
+	if err != nil {
+		return nil, err
+	}
+	notifier, err := newWebhookNotifier(reloaded, keyProvider)
+	if err != nil {
+		return nil, err
+	}
+	if err := serverlogger.SetLevel(reloaded.LogLevel); err != nil {
+		return nil, errors.Wrap(err, "invalid log_level")
+	}
+
+	for _, issue := range issues {
+		logger.Warnw("config issue", nil, "field", issue.Field, "issue", issue.Message)
+	}
+	r.keyProvider.Set(keyProvider)
+	r.notifier.Set(notifier)
+	r.roomAllocator.UpdateConfig(reloaded)
+	r.roomService.UpdateConfig(reloaded)
+	r.roomManager.UpdateConfig(reloaded)
+	// only when it changed, so that toggling it through the admin API holds until then
+	if r.diagnostics != nil && reloaded.Diagnostics.Enabled != r.conf.Diagnostics.Enabled {
+		r.diagnostics.SetEnabled(reloaded.Diagnostics.Enabled)
+	}
+	r.conf = reloaded
+
+	return res, nil
+}
+
+// ReloadKeys loads the API keys again, from the key command or key file, and applies them when they
+// changed. Returns whether they did. Keys are rotated this way without a change to the config
+func (r *ConfigReloader) ReloadKeys() (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	keyProvider, err := loadKeyProvider(r.conf)
+	if err != nil {
+		return false, err
+	}
+	if reflect.DeepEqual(keyProvider, r.keyProvider.get()) {
+		return false, nil
+	}
+	notifier, err := newWebhookNotifier(r.conf, keyProvider)
+	if err != nil {
+		return false, err
+	}
+	r.keyProvider.Set(keyProvider)
+	r.notifier.Set(notifier)
+	return true, nil
+}
+
+// loadKeyProvider loads the API keys from the key command, the key file, or from the config when
+// there's neither. They're restricted to the configured scopes
+func loadKeyProvider(conf *config.Config) (auth.KeyProvider, error) {
+	keys, err := loadKeys(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &keySet{
+		keys:   keys,
+		scopes: conf.KeyScopes,
+	}, nil
+}
+
+func loadKeys(conf *config.Config) (map[string]string, error) {
+	// the command is preferred, then the key file
+	if len(conf.KeyRotation.Command) != 0 {
+		ctx := context.Background()
+		if conf.KeyRotation.CommandTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, conf.KeyRotation.CommandTimeout)
+			defer cancel()
+		}
+		cmd := exec.CommandContext(ctx, conf.KeyRotation.Command[0], conf.KeyRotation.Command[1:]...)
+		out, err := cmd.Output()
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) != 0 {
+			return nil, errors.Wrapf(err, "key command failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		} else if err != nil {
+			return nil, errors.Wrap(err, "key command failed")
+		}
+		return parseKeys(out)
+	}
+
+	if conf.KeyFile != "" {
+		if st, err := os.Stat(conf.KeyFile); err != nil {
+			return nil, err
+		} else if st.Mode().Perm() != 0600 {
+			return nil, fmt.Errorf("key file must have permission set to 600")
+		}
+		b, err := ioutil.ReadFile(conf.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return parseKeys(b)
+	}
+
+	if len(conf.Keys) == 0 {
+		return nil, errors.New("one of key-file or keys must be provided in order to support a secure installation")
+	}
+	return conf.Keys, nil
+}
+
+// parseKeys reads API keys in the format of the key file, a YAML map of keys to secrets
+func parseKeys(b []byte) (map[string]string, error) {
+	keys := map[string]string{}
+	if err := yaml.Unmarshal(b, &keys); err != nil {
+		return nil, errors.Wrap(err, "could not parse keys")
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no API keys were loaded")
+	}
+	return keys, nil
+}
+
+// newWebhookNotifier returns the notifier for the configured webhooks, nil when there are none
+func newWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (webhook.Notifier, error) {
+	wc := conf.WebHook
+	if len(wc.URLs) == 0 {
+		return nil, nil
+	}
+	secret := provider.GetSecret(wc.APIKey)
+	if secret == "" {
+		return nil, ErrWebHookMissingAPIKey
+	}
+
+	return webhook.NewNotifier(wc.APIKey, secret, wc.URLs), nil
+}
+
+//------------------------------------------------
+
+// ReloadableKeyProvider is a KeyProvider whose keys can be replaced while it's in use
+type ReloadableKeyProvider struct {
+	lock     sync.RWMutex
+	provider auth.KeyProvider
+}
+
+func NewReloadableKeyProvider(provider auth.KeyProvider) *ReloadableKeyProvider {
+	return &ReloadableKeyProvider{
+		provider: provider,
+	}
+}
+
+func (p *ReloadableKeyProvider) Set(provider auth.KeyProvider) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.provider = provider
+}
+
+func (p *ReloadableKeyProvider) get() auth.KeyProvider {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.provider
+}
+
+func (p *ReloadableKeyProvider) GetSecret(key string) string {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.provider.GetSecret(key)
+}
+
+func (p *ReloadableKeyProvider) NumKeys() int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.provider.NumKeys()
+}
+
+// GetKeyScopes returns the scopes the key is restricted to, none when it isn't
+func (p *ReloadableKeyProvider) GetKeyScopes(key string) []string {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if sp, ok := p.provider.(KeyScopeProvider); ok {
+		return sp.GetKeyScopes(key)
+	}
+	return nil
+}
+
+//------------------------------------------------
+
+// keySet is the API keys the server accepts, with the scopes they're restricted to
+type keySet struct {
+	keys   map[string]string
+	scopes map[string][]string
+}
+
+func (s *keySet) GetSecret(key string) string {
+	return s.keys[key]
+}
+
+func (s *keySet) NumKeys() int {
+	return len(s.keys)
+}
+
+func (s *keySet) GetKeyScopes(key string) []string {
+	return s.scopes[key]
+}
+
+//------------------------------------------------
+
+// ReloadableNotifier is a webhook Notifier whose webhooks can be replaced while it's in use.
+// Without webhooks, notifications are dropped
+type ReloadableNotifier struct {
+	lock     sync.RWMutex
+	notifier webhook.Notifier
+}
+
+func NewReloadableNotifier(notifier webhook.Notifier) *ReloadableNotifier {
+	return &ReloadableNotifier{
+		notifier: notifier,
+	}
+}
+
+func (n *ReloadableNotifier) Set(notifier webhook.Notifier) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.notifier = notifier
+}
+
+func (n *ReloadableNotifier) Notify(ctx context.Context, payload interface{}) error {
+	n.lock.RLock()
+	notifier := n.notifier
+	n.lock.RUnlock()
+	if notifier == nil {
+		return nil
+	}
+	return notifier.Notify(ctx, payload)
+}
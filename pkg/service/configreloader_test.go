@@ -0,0 +1,159 @@
+package service_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/livekit/protocol/auth"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/service/servicefakes"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+func TestConfigReloader(t *testing.T) {
+	newConfig := func() *config.Config {
+		conf, err := config.NewConfig("", nil)
+		require.NoError(t, err)
+		conf.Keys = map[string]string{"key": "0123456789abcdef0123456789abcdef"}
+		conf.Diagnostics.Port = 7882
+		return conf
+	}
+	conf := newConfig()
+	node, err := routing.NewLocalNode(conf)
+	require.NoError(t, err)
+
+	ra, _ := newTestRoomAllocator(t, conf, node)
+	roomService, err := service.NewRoomService(conf, ra, nil, nil)
+	require.NoError(t, err)
+	roomManager, err := service.NewLocalRoomManager(conf, &servicefakes.FakeRoomStore{}, node, &routingfakes.FakeRouter{},
+		telemetry.NewTelemetryService(nil, nil, nil, nil))
+	require.NoError(t, err)
+	defer roomManager.Stop()
+	keyProvider := service.NewReloadableKeyProvider(auth.NewFileBasedKeyProviderFromMap(conf.Keys))
+	notifier := service.NewReloadableNotifier(nil)
+	diagnostics := service.NewDiagnosticsServer(conf, roomManager, keyProvider)
+	reloader := service.NewConfigReloader(conf, keyProvider, notifier, ra, roomService, roomManager, diagnostics)
+
+	_, err = reloader.Reload()
+	require.ErrorIs(t, err, service.ErrConfigReloadUnavailable)
+
+	webhooks := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		webhooks <- struct{}{}
+	}))
+	defer server.Close()
+
+	next := newConfig()
+	next.Keys = map[string]string{"rotated": "fedcba9876543210fedcba9876543210"}
+	next.WebHook.URLs = []string{server.URL}
+	next.WebHook.APIKey = "rotated"
+	next.Room.MaxParticipants = 10
+	next.Diagnostics.Enabled = true
+	next.Port = 7890
+	reloader.SetLoader(func() (*config.Config, error) {
+		return next, nil
+	})
+
+	t.Run("reloadable fields are applied", func(t *testing.T) {
+		res, err := reloader.Reload()
+		require.NoError(t, err)
+		require.Equal(t, []string{"room.max_participants", "webhook.urls", "webhook.api_key", "keys", "diagnostics.enabled"}, res.Reloaded)
+		require.Equal(t, []string{"port"}, res.RestartRequired)
+
+		require.Empty(t, keyProvider.GetSecret("key"))
+		require.Equal(t, next.Keys["rotated"], keyProvider.GetSecret("rotated"))
+
+		require.NoError(t, notifier.Notify(context.Background(), &livekit.WebhookEvent{Event: "room_started"}))
+		<-webhooks
+
+		room, err := ra.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "myroom"})
+		require.NoError(t, err)
+		require.Equal(t, uint32(10), room.MaxParticipants)
+		require.True(t, diagnostics.Enabled())
+	})
+
+	t.Run("invalid config is rejected", func(t *testing.T) {
+		invalid := newConfig()
+		invalid.Keys = nil
+		_, err := reloader.Apply(invalid)
+		require.Error(t, err)
+		require.Equal(t, next.Keys["rotated"], keyProvider.GetSecret("rotated"))
+
+		// the webhook key must be one of the keys
+		invalid = newConfig()
+		invalid.WebHook = next.WebHook
+		_, err = reloader.Apply(invalid)
+		require.Error(t, err)
+		require.Equal(t, next.Keys["rotated"], keyProvider.GetSecret("rotated"))
+	})
+}
+
+func TestConfigReloader_ReloadKeys(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "keys.yaml")
+	writeKeys := func(keys string) {
+		require.NoError(t, ioutil.WriteFile(keyFile, []byte(keys), 0600))
+	}
+	writeKeys("key: 0123456789abcdef0123456789abcdef\n")
+
+	for name, setKeys := range map[string]func(conf *config.Config){
+		"key file": func(conf *config.Config) {
+			conf.KeyFile = keyFile
+		},
+		"key command": func(conf *config.Config) {
+			conf.KeyRotation.Command = []string{"cat", keyFile}
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			writeKeys("key: 0123456789abcdef0123456789abcdef\n")
+			conf, err := config.NewConfig("", nil)
+			require.NoError(t, err)
+			setKeys(conf)
+			conf.KeyScopes = map[string][]string{"rotated": {config.KeyScopeJoin}}
+			node, err := routing.NewLocalNode(conf)
+			require.NoError(t, err)
+
+			ra, _ := newTestRoomAllocator(t, conf, node)
+			roomService, err := service.NewRoomService(conf, ra, nil, nil)
+			require.NoError(t, err)
+			roomManager, err := service.NewLocalRoomManager(conf, &servicefakes.FakeRoomStore{}, node, &routingfakes.FakeRouter{},
+				telemetry.NewTelemetryService(nil, nil, nil, nil))
+			require.NoError(t, err)
+			defer roomManager.Stop()
+			keyProvider := service.NewReloadableKeyProvider(auth.NewFileBasedKeyProviderFromMap(nil))
+			reloader := service.NewConfigReloader(conf, keyProvider, service.NewReloadableNotifier(nil), ra, roomService, roomManager, nil)
+
+			rotated, err := reloader.ReloadKeys()
+			require.NoError(t, err)
+			require.True(t, rotated)
+			require.Equal(t, "0123456789abcdef0123456789abcdef", keyProvider.GetSecret("key"))
+
+			rotated, err = reloader.ReloadKeys()
+			require.NoError(t, err)
+			require.False(t, rotated)
+
+			writeKeys("rotated: fedcba9876543210fedcba9876543210\n")
+			rotated, err = reloader.ReloadKeys()
+			require.NoError(t, err)
+			require.True(t, rotated)
+			require.Empty(t, keyProvider.GetSecret("key"))
+			require.Equal(t, "fedcba9876543210fedcba9876543210", keyProvider.GetSecret("rotated"))
+			require.Equal(t, []string{config.KeyScopeJoin}, keyProvider.GetKeyScopes("rotated"))
+
+			// keys that can't be loaded leave the current ones in place
+			writeKeys("")
+			_, err = reloader.ReloadKeys()
+			require.Error(t, err)
+			require.Equal(t, "fedcba9876543210fedcba9876543210", keyProvider.GetSecret("rotated"))
+		})
+	}
+}
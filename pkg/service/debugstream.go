@@ -0,0 +1,105 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+const (
+	defaultDebugStreamInterval = time.Second
+	minDebugStreamInterval     = 100 * time.Millisecond
+)
+
+// ParticipantDownTracks holds the stats of the down tracks of a subscriber
+type ParticipantDownTracks struct {
+	Room        string                    `json:"room"`
+	Participant string                    `json:"participant"`
+	DownTracks  []sfu.DownTrackDebugStats `json:"down_tracks"`
+}
+
+// DownTrackDebugStats returns the stats of the down tracks of the participants hosted by the node,
+// limited to roomName and identity when they're set
+func (r *RoomManager) DownTrackDebugStats(roomName, identity string) []*ParticipantDownTracks {
+	r.lock.RLock()
+	rooms := make([]*rtc.Room, 0, len(r.rooms))
+	for name, room := range r.rooms {
+		if roomName == "" || name == roomName {
+			rooms = append(rooms, room)
+		}
+	}
+	r.lock.RUnlock()
+
+	stats := make([]*ParticipantDownTracks, 0)
+	for _, room := range rooms {
+		for _, p := range room.GetParticipants() {
+			if identity != "" && p.Identity() != identity {
+				continue
+			}
+			pdt := &ParticipantDownTracks{
+				Room:        room.Room.Name,
+				Participant: p.Identity(),
+				DownTracks:  make([]sfu.DownTrackDebugStats, 0),
+			}
+			for _, st := range p.GetSubscribedTracks() {
+				if dt := st.DownTrack(); dt != nil {
+					pdt.DownTracks = append(pdt.DownTracks, dt.GetDebugStats())
+				}
+			}
+			stats = append(stats, pdt)
+		}
+	}
+	return stats
+}
+
+// debugDownTracks streams the stats of down tracks as server-sent events, an event every interval
+// until the client disconnects. The room and participant query parameters narrow the stats down
+func (s *LivekitServer) debugDownTracks(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		handleError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	interval := defaultDebugStreamInterval
+	if v := r.URL.Query().Get("interval"); v != "" {
+		var err error
+		if interval, err = time.ParseDuration(v); err != nil {
+			handleError(w, http.StatusBadRequest, "invalid interval: "+err.Error())
+			return
+		}
+		if interval < minDebugStreamInterval {
+			interval = minDebugStreamInterval
+		}
+	}
+	roomName := r.URL.Query().Get("room")
+	identity := r.URL.Query().Get("participant")
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		b, err := json.Marshal(s.roomManager.DownTrackDebugStats(roomName, identity))
+		if err != nil {
+			_, _ = fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
+		} else {
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", b)
+		}
+		flusher.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.doneChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
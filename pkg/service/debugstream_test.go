@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+func TestDebugDownTracks(t *testing.T) {
+	room := rtc.NewRoom(&livekit.Room{Name: "room"}, rtc.WebRTCConfig{}, &config.RoomConfig{},
+		&config.AudioConfig{UpdateInterval: 500}, telemetry.NewTelemetryService(nil, nil, nil, nil))
+	t.Cleanup(room.Close)
+	for _, identity := range []string{"alice", "bob"} {
+		p := &typesfakes.FakeParticipant{}
+		p.IDReturns("PA_" + identity)
+		p.IdentityReturns(identity)
+		p.StateReturns(livekit.ParticipantInfo_JOINED)
+		// not bound yet
+		p.GetSubscribedTracksReturns([]types.SubscribedTrack{&typesfakes.FakeSubscribedTrack{}})
+		require.NoError(t, room.Join(p, &rtc.ParticipantOptions{}, nil))
+	}
+	s := &LivekitServer{
+		roomManager: &RoomManager{rooms: map[string]*rtc.Room{"room": room}},
+		doneChan:    make(chan struct{}),
+	}
+
+	read := func(query string) []*ParticipantDownTracks {
+		// the first event is sent right away
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req := httptest.NewRequest(http.MethodGet, "/debug/downtracks?"+query, nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+		s.debugDownTracks(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+		event := strings.TrimSpace(w.Body.String())
+		require.True(t, strings.HasPrefix(event, "data: "), event)
+		var stats []*ParticipantDownTracks
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(event, "data: ")), &stats))
+		return stats
+	}
+
+	require.Len(t, read(""), 2)
+	stats := read("room=room&participant=bob")
+	require.Len(t, stats, 1)
+	require.Equal(t, "room", stats[0].Room)
+	require.Equal(t, "bob", stats[0].Participant)
+	require.Empty(t, stats[0].DownTracks)
+	require.Empty(t, read("room=other"))
+
+	w := httptest.NewRecorder()
+	s.debugDownTracks(w, httptest.NewRequest(http.MethodGet, "/debug/downtracks?interval=often", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+// DegradationMonitor checks the CPU load of the node every interval, and degrades the video forwarded
+// across the node while it's overloaded, so that it sheds work instead of falling over. It degrades
+// once the load reaches high_load and recovers once it's back below low_load, so that it doesn't flap
+// around a single threshold
+type DegradationMonitor struct {
+	conf        *config.DegradationConfig
+	roomManager *RoomManager
+
+	// sampled by the monitor, the router only keeps the node's stats up to date when using Redis
+	stats    *livekit.NodeStats
+	degraded bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewDegradationMonitor(conf *config.Config, roomManager *RoomManager) *DegradationMonitor {
+	if !conf.Degradation.Enabled {
+		return nil
+	}
+	now := time.Now().Unix()
+	return &DegradationMonitor{
+		conf:        &conf.Degradation,
+		roomManager: roomManager,
+		stats:       &livekit.NodeStats{StartedAt: now, UpdatedAt: now},
+		done:        make(chan struct{}),
+	}
+}
+
+func (m *DegradationMonitor) Start() {
+	m.wg.Add(1)
+	go m.run()
+}
+
+func (m *DegradationMonitor) Stop() {
+	close(m.done)
+	m.wg.Wait()
+}
+
+func (m *DegradationMonitor) run() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.conf.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			m.check()
+		}
+	}
+}
+
+func (m *DegradationMonitor) check() {
+	if err := prometheus.UpdateCurrentNodeStats(m.stats); err != nil {
+		logger.Warnw("could not update node stats", err)
+		return
+	}
+	var load float32
+	if m.stats.NumCpus > 0 {
+		load = m.stats.LoadAvgLast1Min / float32(m.stats.NumCpus)
+	}
+	if !m.update(load) {
+		return
+	}
+
+	if m.degraded {
+		logger.Warnw("node is overloaded, degrading video", nil,
+			"cpuLoad", load,
+			"maxSpatialLayer", m.conf.MaxSpatialLayer,
+			"pliThrottleFactor", m.conf.PLIThrottleFactor,
+		)
+	} else {
+		logger.Infow("node recovered, no longer degrading video", "cpuLoad", load)
+	}
+	prometheus.SetNodeDegraded(m.degraded)
+	m.roomManager.setDegraded(m.degraded)
+}
+
+// update returns true when the node starts or stops degrading at load
+func (m *DegradationMonitor) update(load float32) bool {
+	degraded := load >= m.conf.HighLoad
+	if m.degraded {
+		degraded = load >= m.conf.LowLoad
+	}
+	if degraded == m.degraded {
+		return false
+	}
+	m.degraded = degraded
+	return true
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestDegradationMonitor_Update(t *testing.T) {
+	m := &DegradationMonitor{
+		conf: &config.DegradationConfig{HighLoad: 0.9, LowLoad: 0.7},
+	}
+
+	require.False(t, m.update(0.8))
+	require.False(t, m.degraded)
+
+	require.True(t, m.update(0.95))
+	require.True(t, m.degraded)
+
+	// stays degraded until the load is below the low threshold
+	require.False(t, m.update(0.8))
+	require.True(t, m.degraded)
+	require.True(t, m.update(0.6))
+	require.False(t, m.degraded)
+
+	require.False(t, m.update(0.8))
+	require.False(t, m.degraded)
+}
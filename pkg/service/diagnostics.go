@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	httppprof "net/http/pprof"
+	"runtime/pprof"
+	"time"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+	"github.com/urfave/negroni"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+// DiagnosticsState tells whether diagnostics are served
+type DiagnosticsState struct {
+	Enabled bool `json:"enabled"`
+}
+
+// NodeState is the state of the rooms hosted by a node, with their participants, the tracks they
+// publish and those they're subscribed to
+type NodeState struct {
+	NodeID string                   `json:"node_id"`
+	Rooms  []map[string]interface{} `json:"rooms"`
+}
+
+// DiagnosticsServer serves pprof profiles, goroutine dumps and the state of the rooms hosted by the
+// node on a port of its own. Requests need a token with the roomCreate grant. While it's disabled,
+// requests are turned away, it can be enabled again without a restart
+type DiagnosticsServer struct {
+	roomManager *RoomManager
+	enabled     utils.AtomicFlag
+	httpServer  *http.Server
+}
+
+// NewDiagnosticsServer returns nil when diagnostics.port isn't set
+func NewDiagnosticsServer(conf *config.Config, roomManager *RoomManager, keyProvider auth.KeyProvider) *DiagnosticsServer {
+	if conf.Diagnostics.Port == 0 {
+		return nil
+	}
+	s := &DiagnosticsServer{
+		roomManager: roomManager,
+	}
+	s.enabled.TrySet(conf.Diagnostics.Enabled)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", httppprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", httppprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", httppprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", httppprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", httppprof.Trace)
+	mux.HandleFunc("/debug/goroutines", writeGoroutines)
+	mux.HandleFunc("/debug/state", s.nodeState)
+
+	middlewares := []negroni.Handler{negroni.NewRecovery()}
+	if keyProvider != nil {
+		middlewares = append(middlewares, NewAPIKeyAuthMiddleware(keyProvider))
+	}
+	middlewares = append(middlewares, negroni.HandlerFunc(s.ensureAllowed))
+	s.httpServer = &http.Server{
+		Addr:    fmt.Sprintf(":%d", conf.Diagnostics.Port),
+		Handler: configureMiddlewares(mux, middlewares...),
+	}
+	return s
+}
+
+func (s *DiagnosticsServer) Start() error {
+	ln, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := s.httpServer.Serve(ln); err != http.ErrServerClosed {
+			logger.Errorw("could not serve diagnostics", err)
+		}
+	}()
+	return nil
+}
+
+func (s *DiagnosticsServer) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = s.httpServer.Shutdown(ctx)
+}
+
+func (s *DiagnosticsServer) Enabled() bool {
+	return s.enabled.Get()
+}
+
+// SetEnabled enables or disables diagnostics, requests being served finish either way
+func (s *DiagnosticsServer) SetEnabled(enabled bool) {
+	if s.enabled.TrySet(enabled) {
+		logger.Infow("diagnostics toggled", "enabled", enabled)
+	}
+}
+
+func (s *DiagnosticsServer) ensureAllowed(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	// profiles and dumps cover every room
+	if err := EnsureCreatePermission(r.Context()); err != nil {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if !s.Enabled() {
+		handleError(w, http.StatusServiceUnavailable, ErrDiagnosticsDisabled.Error())
+		return
+	}
+	next(w, r)
+}
+
+// nodeState writes the state of the rooms hosted by the node, limited to the room query parameter
+// when it's set
+func (s *DiagnosticsServer) nodeState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		handleError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, &NodeState{
+		NodeID: s.roomManager.currentNode.Id,
+		Rooms:  s.roomManager.RoomsDebugInfo(r.FormValue("room")),
+	})
+}
+
+// RoomsDebugInfo returns the state of the rooms hosted by the node, limited to roomName when it's
+// set
+func (r *RoomManager) RoomsDebugInfo(roomName string) []map[string]interface{} {
+	r.lock.RLock()
+	rooms := make([]*rtc.Room, 0, len(r.rooms))
+	for name, room := range r.rooms {
+		if roomName == "" || name == roomName {
+			rooms = append(rooms, room)
+		}
+	}
+	r.lock.RUnlock()
+
+	info := make([]map[string]interface{}, 0, len(rooms))
+	for _, room := range rooms {
+		info = append(info, room.DebugInfo())
+	}
+	return info
+}
+
+func writeGoroutines(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_ = pprof.Lookup("goroutine").WriteTo(w, 2)
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/livekit/protocol/auth"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+func TestDiagnosticsServer(t *testing.T) {
+	conf, err := config.NewConfig("", nil)
+	require.NoError(t, err)
+	conf.Diagnostics.Port = 7882
+	room := rtc.NewRoom(&livekit.Room{Name: "room", Sid: "RM_room"}, rtc.WebRTCConfig{}, &config.RoomConfig{},
+		&config.AudioConfig{UpdateInterval: 500}, telemetry.NewTelemetryService(nil, nil, nil, nil))
+	t.Cleanup(room.Close)
+	alice := &typesfakes.FakeParticipant{}
+	alice.IDReturns("PA_alice")
+	alice.IdentityReturns("alice")
+	alice.StateReturns(livekit.ParticipantInfo_JOINED)
+	alice.DebugInfoReturns(map[string]interface{}{"ID": "PA_alice"})
+	require.NoError(t, room.Join(alice, &rtc.ParticipantOptions{}, nil))
+	roomManager := &RoomManager{
+		rooms:       map[string]*rtc.Room{"room": room},
+		currentNode: &livekit.Node{Id: "ND_local"},
+	}
+
+	secret := "0123456789abcdef0123456789abcdef"
+	keyProvider := auth.NewFileBasedKeyProviderFromMap(map[string]string{"key": secret})
+	diagnostics := NewDiagnosticsServer(conf, roomManager, keyProvider)
+	require.NotNil(t, diagnostics)
+	require.False(t, diagnostics.Enabled())
+
+	adminToken, err := auth.NewAccessToken("key", secret).AddGrant(&auth.VideoGrant{RoomCreate: true}).ToJWT()
+	require.NoError(t, err)
+	roomAdminToken, err := auth.NewAccessToken("key", secret).AddGrant(&auth.VideoGrant{RoomAdmin: true, Room: "room"}).ToJWT()
+	require.NoError(t, err)
+	get := func(path, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			SetAuthorizationToken(r, token)
+		}
+		w := httptest.NewRecorder()
+		diagnostics.httpServer.Handler.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("requests need the roomCreate grant", func(t *testing.T) {
+		diagnostics.SetEnabled(true)
+		require.Equal(t, http.StatusUnauthorized, get("/debug/state", "").Code)
+		require.Equal(t, http.StatusUnauthorized, get("/debug/state", roomAdminToken).Code)
+		require.Equal(t, http.StatusUnauthorized, get("/debug/pprof/", roomAdminToken).Code)
+	})
+
+	t.Run("disabled diagnostics are turned away", func(t *testing.T) {
+		diagnostics.SetEnabled(false)
+		w := get("/debug/goroutines", adminToken)
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+		require.Equal(t, ErrDiagnosticsDisabled.Error(), w.Body.String())
+	})
+
+	t.Run("enabled diagnostics are served", func(t *testing.T) {
+		diagnostics.SetEnabled(true)
+
+		w := get("/debug/state", adminToken)
+		require.Equal(t, http.StatusOK, w.Code)
+		state := &NodeState{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), state))
+		require.Equal(t, "ND_local", state.NodeID)
+		require.Len(t, state.Rooms, 1)
+		require.Equal(t, "RM_room", state.Rooms[0]["Sid"])
+		require.Contains(t, state.Rooms[0]["Participants"], "alice")
+
+		w = get("/debug/state?room=other", adminToken)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), state))
+		require.Empty(t, state.Rooms)
+
+		w = get("/debug/goroutines", adminToken)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.True(t, strings.HasPrefix(w.Body.String(), "goroutine "), w.Body.String())
+
+		w = get("/debug/pprof/heap", adminToken)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NotEmpty(t, w.Body.Bytes())
+	})
+
+	t.Run("admin API toggles diagnostics", func(t *testing.T) {
+		admin := &AdminService{diagnostics: diagnostics}
+		toggle := func(method, body string, grant *auth.VideoGrant) *httptest.ResponseRecorder {
+			r := httptest.NewRequest(method, "/admin/diagnostics", strings.NewReader(body))
+			r = r.WithContext(context.WithValue(r.Context(), grantsKey, &auth.ClaimGrants{Video: grant}))
+			w := httptest.NewRecorder()
+			admin.toggleDiagnostics(w, r)
+			return w
+		}
+
+		w := toggle(http.MethodPost, `{"enabled": false}`, &auth.VideoGrant{RoomAdmin: true, Room: "room"})
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.True(t, diagnostics.Enabled())
+
+		w = toggle(http.MethodPost, `{"enabled": false}`, &auth.VideoGrant{RoomCreate: true})
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"enabled": false}`, w.Body.String())
+		require.False(t, diagnostics.Enabled())
+
+		w = toggle(http.MethodGet, "", &auth.VideoGrant{RoomCreate: true})
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"enabled": false}`, w.Body.String())
+
+		// not served without a port
+		admin.diagnostics = nil
+		w = toggle(http.MethodGet, "", &auth.VideoGrant{RoomCreate: true})
+		require.Equal(t, http.StatusNotImplemented, w.Code)
+	})
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+// SubscribedQualityUpdate is sent to publishers that declared the dynacast capability as a JSON text
+// message, {"subscribed_quality_update": {...}}, when the simulcast layers subscribers of their
+// track receive changed. Publishers can pause the disabled ones
+type SubscribedQualityUpdate struct {
+	TrackSid            string               `json:"track_sid"`
+	SubscribedQualities []*SubscribedQuality `json:"subscribed_qualities"`
+}
+
+type SubscribedQuality struct {
+	// LOW, MEDIUM or HIGH
+	Quality string `json:"quality"`
+	Enabled bool   `json:"enabled"`
+}
+
+// marshalSubscribedQuality encodes the layers subscribers receive as a JSON text message, for
+// protobuf clients too
+func marshalSubscribedQuality(update *rtc.SubscribedQualityUpdate) ([]byte, error) {
+	res := &SubscribedQualityUpdate{TrackSid: update.TrackSid}
+	// layers are numbered like the qualities they're sent at
+	for _, quality := range []livekit.VideoQuality{livekit.VideoQuality_LOW, livekit.VideoQuality_MEDIUM, livekit.VideoQuality_HIGH} {
+		res.SubscribedQualities = append(res.SubscribedQualities, &SubscribedQuality{
+			Quality: quality.String(),
+			Enabled: int32(quality) <= update.MaxLayer,
+		})
+	}
+	return marshalTextMessage(textKeySubscribedQualityUpdate, res)
+}
@@ -3,10 +3,29 @@ package service
 import "errors"
 
 var (
-	ErrRoomNotFound         = errors.New("requested room does not exist")
-	ErrRoomLockFailed       = errors.New("could not lock room")
-	ErrRoomUnlockFailed     = errors.New("could not unlock room, lock token does not match")
-	ErrParticipantNotFound  = errors.New("participant does not exist")
-	ErrTrackNotFound        = errors.New("track is not found")
-	ErrWebHookMissingAPIKey = errors.New("api_key is required to use webhooks")
+	ErrRoomNotFound             = errors.New("requested room does not exist")
+	ErrRoomLockFailed           = errors.New("could not lock room")
+	ErrRoomUnlockFailed         = errors.New("could not unlock room, lock token does not match")
+	ErrParticipantNotFound      = errors.New("participant does not exist")
+	ErrTrackNotFound            = errors.New("track is not found")
+	ErrDataTooLarge             = errors.New("data packet payload is too large")
+	ErrRemoteUnmuteDisabled     = errors.New("remote unmute is disabled")
+	ErrWebHookMissingAPIKey     = errors.New("api_key is required to use webhooks")
+	ErrRecordingNotFound        = errors.New("recording does not exist")
+	ErrConfigReloadUnavailable  = errors.New("config can't be reloaded, it's not known where it was loaded from")
+	ErrScheduledActionNotFound  = errors.New("scheduled action does not exist")
+	ErrInvalidLowPowerMode      = errors.New("low_power must be lowest_layer, audio_only or a boolean")
+	ErrInvalidParticipantKind   = errors.New("kind must be standard, hidden, recorder, ingress or agent, only hidden grants can join as hidden or recorder")
+	ErrInvalidAgentRegistration = errors.New("agent workers must register with a name and the jobs they take: room or track")
+	ErrRawDumpDisabled          = errors.New("raw dumps are disabled, set raw_dump.enabled")
+	ErrInvalidModeration        = errors.New("moderation requests need an action: mute, unmute or remove, the identity of a participant, and a track_sid to mute")
+	ErrInvalidTextRequest       = errors.New("text requests need a value")
+	ErrTokenKeyRemoved          = errors.New("the API key of the token was removed")
+	ErrInvalidPublishSources    = errors.New("canPublishSources must be camera, microphone, screen_share or screen_share_audio")
+	ErrDiagnosticsDisabled      = errors.New("diagnostics are disabled")
+	ErrDiagnosticsUnavailable   = errors.New("diagnostics aren't served, set diagnostics.port")
+	ErrInvalidSignalSeq         = errors.New("last_signal_seq must be the sequence number of a signal response")
+	ErrInvalidTrackSelection    = errors.New("tracks need a track_sid, and a quality of low, medium or high")
+	ErrSignalRateLimitExceeded  = errors.New("too many signal requests over the rate limit")
+	ErrInvalidAutoSubscribe     = errors.New("auto_subscribe must be a boolean")
 )
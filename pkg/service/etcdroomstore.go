@@ -0,0 +1,418 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/livekit/protocol/utils"
+	"github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// EtcdRoomStore keeps rooms in etcd v3. Keys are below the configured prefix:
+//
+//	rooms/<room>                     Room proto
+//	participants/<room>/<identity>   ParticipantInfo proto
+//	policies/<room>                  RoomPolicy json
+//	metadata/<room>                  latest metadata, its version is the version of the key
+//	locks/<room>                     lock token, with a lease of the lock duration
+//	actions/<id>                     ScheduledAction json
+//	actions_due/<unix ms>/<id>       empty, to find due actions by range
+//	recordings/<id>                  name of the recorded room
+//
+// Room names and identities are escaped
+type EtcdRoomStore struct {
+	client *clientv3.Client
+	prefix string
+}
+
+func NewEtcdRoomStore(conf *config.EtcdStoreConfig) (*EtcdRoomStore, error) {
+	// the client connects in the background, and moves on to the next endpoint when one can't be reached
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   conf.Endpoints,
+		Username:    conf.Username,
+		Password:    conf.Password,
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create etcd client")
+	}
+	return &EtcdRoomStore{
+		client: client,
+		prefix: conf.Prefix,
+	}, nil
+}
+
+func (s *EtcdRoomStore) Close() error {
+	return s.client.Close()
+}
+
+func (s *EtcdRoomStore) roomKey(name string) string {
+	return s.prefix + "rooms/" + url.PathEscape(name)
+}
+
+func (s *EtcdRoomStore) participantsPrefix(roomName string) string {
+	return s.prefix + "participants/" + url.PathEscape(roomName) + "/"
+}
+
+func (s *EtcdRoomStore) participantKey(roomName, identity string) string {
+	return s.participantsPrefix(roomName) + url.PathEscape(identity)
+}
+
+func (s *EtcdRoomStore) policyKey(roomName string) string {
+	return s.prefix + "policies/" + url.PathEscape(roomName)
+}
+
+func (s *EtcdRoomStore) metadataKey(roomName string) string {
+	return s.prefix + "metadata/" + url.PathEscape(roomName)
+}
+
+func (s *EtcdRoomStore) lockKey(roomName string) string {
+	return s.prefix + "locks/" + url.PathEscape(roomName)
+}
+
+func (s *EtcdRoomStore) recordingKey(id string) string {
+	return s.prefix + "recordings/" + url.PathEscape(id)
+}
+
+func (s *EtcdRoomStore) actionKey(id string) string {
+	return s.prefix + "actions/" + url.PathEscape(id)
+}
+
+// due keys sort by time, the unix time is padded to the same length
+func (s *EtcdRoomStore) actionDueKey(at time.Time, id string) string {
+	return fmt.Sprintf("%s%020d/%s", s.actionsDuePrefix(), at.UnixNano()/int64(time.Millisecond), url.PathEscape(id))
+}
+
+func (s *EtcdRoomStore) actionsDuePrefix() string {
+	return s.prefix + "actions_due/"
+}
+
+func (s *EtcdRoomStore) StoreRoom(ctx context.Context, room *livekit.Room) error {
+	if room.CreationTime == 0 {
+		room.CreationTime = time.Now().Unix()
+	}
+
+	data, err := proto.Marshal(room)
+	if err != nil {
+		return err
+	}
+	if _, err := s.client.Put(ctx, s.roomKey(room.Name), string(data)); err != nil {
+		return errors.Wrap(err, "could not create room")
+	}
+	return nil
+}
+
+func (s *EtcdRoomStore) LoadRoom(ctx context.Context, name string) (*livekit.Room, error) {
+	res, err := s.client.Get(ctx, s.roomKey(name))
+	if err != nil {
+		return nil, err
+	} else if len(res.Kvs) == 0 {
+		return nil, ErrRoomNotFound
+	}
+
+	room := livekit.Room{}
+	if err := proto.Unmarshal(res.Kvs[0].Value, &room); err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+func (s *EtcdRoomStore) ListRooms(ctx context.Context) ([]*livekit.Room, error) {
+	res, err := s.client.Get(ctx, s.prefix+"rooms/", clientv3.WithPrefix())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get rooms")
+	}
+
+	rooms := make([]*livekit.Room, 0, len(res.Kvs))
+	for _, kv := range res.Kvs {
+		room := livekit.Room{}
+		if err := proto.Unmarshal(kv.Value, &room); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, &room)
+	}
+	return rooms, nil
+}
+
+func (s *EtcdRoomStore) DeleteRoom(ctx context.Context, name string) error {
+	_, err := s.LoadRoom(ctx, name)
+	if err == ErrRoomNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	_, err = s.client.Txn(ctx).Then(
+		clientv3.OpDelete(s.roomKey(name)),
+		clientv3.OpDelete(s.policyKey(name)),
+		clientv3.OpDelete(s.metadataKey(name)),
+		clientv3.OpDelete(s.participantsPrefix(name), clientv3.WithPrefix()),
+	).Commit()
+	return err
+}
+
+func (s *EtcdRoomStore) StoreRoomPolicy(ctx context.Context, roomName string, policy *config.RoomPolicy) error {
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.Put(ctx, s.policyKey(roomName), string(data))
+	return err
+}
+
+func (s *EtcdRoomStore) LoadRoomPolicy(ctx context.Context, roomName string) (*config.RoomPolicy, error) {
+	res, err := s.client.Get(ctx, s.policyKey(roomName))
+	if err != nil || len(res.Kvs) == 0 {
+		return nil, err
+	}
+
+	policy := config.RoomPolicy{}
+	if err := json.Unmarshal(res.Kvs[0].Value, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (s *EtcdRoomStore) StoreRoomMetadata(ctx context.Context, roomName, metadata string) (uint64, error) {
+	// read back in the same transaction, so that the version is the one of this write
+	key := s.metadataKey(roomName)
+	res, err := s.client.Txn(ctx).Then(
+		clientv3.OpPut(key, metadata),
+		clientv3.OpGet(key),
+	).Commit()
+	if err != nil {
+		return 0, errors.Wrap(err, "could not store room metadata")
+	}
+	if len(res.Responses) != 2 || len(res.Responses[1].GetResponseRange().GetKvs()) == 0 {
+		return 0, errors.New("could not store room metadata")
+	}
+	return uint64(res.Responses[1].GetResponseRange().Kvs[0].Version), nil
+}
+
+func (s *EtcdRoomStore) LoadRoomMetadata(ctx context.Context, roomName string) (string, uint64, error) {
+	res, err := s.client.Get(ctx, s.metadataKey(roomName))
+	if err != nil || len(res.Kvs) == 0 {
+		return "", 0, err
+	}
+	return string(res.Kvs[0].Value), uint64(res.Kvs[0].Version), nil
+}
+
+func (s *EtcdRoomStore) LockRoom(ctx context.Context, name string, duration time.Duration) (string, error) {
+	token := utils.NewGuid("LOCK")
+	key := s.lockKey(name)
+
+	startTime := time.Now()
+	for {
+		// leases are in seconds
+		lease, err := s.client.Grant(ctx, int64((duration+time.Second-1)/time.Second))
+		if err != nil {
+			return "", err
+		}
+		// the key doesn't exist
+		res, err := s.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+			Then(clientv3.OpPut(key, token, clientv3.WithLease(lease.ID))).
+			Commit()
+		if err != nil {
+			return "", err
+		}
+		if res.Succeeded {
+			return token, nil
+		}
+		// it would expire as well, just later
+		_, _ = s.client.Revoke(ctx, lease.ID)
+
+		// stop waiting past lock duration
+		if time.Now().Sub(startTime) > duration {
+			break
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return "", ErrRoomLockFailed
+}
+
+func (s *EtcdRoomStore) UnlockRoom(ctx context.Context, name string, uid string) error {
+	key := s.lockKey(name)
+	res, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.Value(key), "=", uid)).
+		Then(clientv3.OpDelete(key)).
+		Else(clientv3.OpGet(key)).
+		Commit()
+	if err != nil {
+		return err
+	}
+	if res.Succeeded {
+		return nil
+	}
+	if len(res.Responses) == 1 && len(res.Responses[0].GetResponseRange().GetKvs()) == 0 {
+		// already unlocked
+		return nil
+	}
+	return ErrRoomUnlockFailed
+}
+
+func (s *EtcdRoomStore) StoreParticipant(ctx context.Context, roomName string, participant *livekit.ParticipantInfo) error {
+	data, err := proto.Marshal(participant)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.Put(ctx, s.participantKey(roomName, participant.Identity), string(data))
+	return err
+}
+
+func (s *EtcdRoomStore) LoadParticipant(ctx context.Context, roomName, identity string) (*livekit.ParticipantInfo, error) {
+	res, err := s.client.Get(ctx, s.participantKey(roomName, identity))
+	if err != nil {
+		return nil, err
+	} else if len(res.Kvs) == 0 {
+		return nil, ErrParticipantNotFound
+	}
+
+	pi := livekit.ParticipantInfo{}
+	if err := proto.Unmarshal(res.Kvs[0].Value, &pi); err != nil {
+		return nil, err
+	}
+	return &pi, nil
+}
+
+func (s *EtcdRoomStore) ListParticipants(ctx context.Context, roomName string) ([]*livekit.ParticipantInfo, error) {
+	res, err := s.client.Get(ctx, s.participantsPrefix(roomName), clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	var participants []*livekit.ParticipantInfo
+	for _, kv := range res.Kvs {
+		pi := livekit.ParticipantInfo{}
+		if err := proto.Unmarshal(kv.Value, &pi); err != nil {
+			return nil, err
+		}
+		participants = append(participants, &pi)
+	}
+	return participants, nil
+}
+
+func (s *EtcdRoomStore) DeleteParticipant(ctx context.Context, roomName, identity string) error {
+	_, err := s.client.Delete(ctx, s.participantKey(roomName, identity))
+	return err
+}
+
+func (s *EtcdRoomStore) StoreScheduledAction(ctx context.Context, action *ScheduledAction) error {
+	data, err := json.Marshal(action)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.Txn(ctx).Then(
+		clientv3.OpPut(s.actionKey(action.ID), string(data)),
+		clientv3.OpPut(s.actionDueKey(action.At, action.ID), ""),
+	).Commit()
+	if err != nil {
+		return errors.Wrap(err, "could not store scheduled action")
+	}
+	return nil
+}
+
+func (s *EtcdRoomStore) ListScheduledActions(ctx context.Context, roomName string) ([]*ScheduledAction, error) {
+	res, err := s.client.Get(ctx, s.prefix+"actions/", clientv3.WithPrefix())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get scheduled actions")
+	}
+
+	var actions []*ScheduledAction
+	for _, kv := range res.Kvs {
+		action := ScheduledAction{}
+		if err := json.Unmarshal(kv.Value, &action); err != nil {
+			return nil, err
+		}
+		if action.Room == roomName {
+			actions = append(actions, &action)
+		}
+	}
+	return actions, nil
+}
+
+func (s *EtcdRoomStore) ListDueScheduledActions(ctx context.Context, dueBy time.Time) ([]*ScheduledAction, error) {
+	// every key of actions due by then is below the first key of the next millisecond
+	rangeEnd := s.actionDueKey(dueBy.Add(time.Millisecond), "")
+	res, err := s.client.Get(ctx, s.actionsDuePrefix(), clientv3.WithRange(rangeEnd))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get scheduled actions")
+	}
+
+	actions := make([]*ScheduledAction, 0, len(res.Kvs))
+	for _, kv := range res.Kvs {
+		id, err := url.PathUnescape(string(kv.Key[strings.LastIndexByte(string(kv.Key), '/')+1:]))
+		if err != nil {
+			return nil, err
+		}
+		actionRes, err := s.client.Get(ctx, s.actionKey(id))
+		if err != nil {
+			return nil, err
+		} else if len(actionRes.Kvs) == 0 {
+			// deleted since
+			continue
+		}
+		action := ScheduledAction{}
+		if err := json.Unmarshal(actionRes.Kvs[0].Value, &action); err != nil {
+			return nil, err
+		}
+		if action.At.After(dueBy) {
+			// due later in the same millisecond
+			continue
+		}
+		actions = append(actions, &action)
+	}
+	return actions, nil
+}
+
+func (s *EtcdRoomStore) DeleteScheduledAction(ctx context.Context, id string) (bool, error) {
+	// the deleted action has the time of its due key
+	res, err := s.client.Delete(ctx, s.actionKey(id), clientv3.WithPrevKV())
+	if err != nil {
+		return false, err
+	}
+	if res.Deleted == 0 {
+		return false, nil
+	}
+	for _, kv := range res.PrevKvs {
+		action := ScheduledAction{}
+		if err := json.Unmarshal(kv.Value, &action); err != nil {
+			return true, err
+		}
+		if _, err := s.client.Delete(ctx, s.actionDueKey(action.At, action.ID)); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+func (s *EtcdRoomStore) StoreRecordingRoom(ctx context.Context, recordingID, roomName string) error {
+	_, err := s.client.Put(ctx, s.recordingKey(recordingID), roomName)
+	return err
+}
+
+func (s *EtcdRoomStore) LoadRecordingRoom(ctx context.Context, recordingID string) (string, error) {
+	res, err := s.client.Get(ctx, s.recordingKey(recordingID))
+	if err != nil {
+		return "", err
+	} else if len(res.Kvs) == 0 {
+		return "", ErrRecordingNotFound
+	}
+	return string(res.Kvs[0].Value), nil
+}
+
+func (s *EtcdRoomStore) DeleteRecordingRoom(ctx context.Context, recordingID string) error {
+	_, err := s.client.Delete(ctx, s.recordingKey(recordingID))
+	return err
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/livekit/protocol/auth"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/twitchtv/twirp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RoomService is served over gRPC with the service and method names of livekit_room.proto, so that
+// clients generated from it can call it. Streams of room changes are served by RoomEventService,
+// which the protocol has no definition for:
+//
+//	service RoomEventService {
+//	  // the participants of a room, then again each time they change
+//	  rpc WatchParticipants(ListParticipantsRequest) returns (stream ListParticipantsResponse);
+//	  // changes of a room, or of all rooms when room is empty
+//	  rpc WatchRoomEvents(ListParticipantsRequest) returns (stream WebhookEvent);
+//	}
+//
+// Both are authenticated like the HTTP API, with a bearer token in the authorization metadata
+const (
+	grpcRoomServiceName      = "livekit.RoomService"
+	grpcRoomEventServiceName = "livekit.RoomEventService"
+	grpcAuthorizationKey     = "authorization"
+)
+
+// NewGRPCServer returns a gRPC server for RoomService and RoomEventService
+func NewGRPCServer(roomService livekit.RoomService, roomManager *RoomManager, keyProvider auth.KeyProvider) *grpc.Server {
+	authMiddleware := NewAPIKeyAuthMiddleware(keyProvider)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(authMiddleware.unaryInterceptor),
+		grpc.StreamInterceptor(authMiddleware.streamInterceptor),
+	)
+	server.RegisterService(&grpcRoomServiceDesc, roomService)
+	server.RegisterService(&grpcRoomEventServiceDesc, &RoomEventService{
+		roomService: roomService,
+		events:      roomManager.events,
+	})
+	return server
+}
+
+//------------------------------------------------
+
+var grpcRoomServiceDesc = grpc.ServiceDesc{
+	ServiceName: grpcRoomServiceName,
+	HandlerType: (*livekit.RoomService)(nil),
+	Methods: []grpc.MethodDesc{
+		grpcRoomServiceMethod("CreateRoom", func() interface{} { return &livekit.CreateRoomRequest{} },
+			func(s livekit.RoomService, ctx context.Context, req interface{}) (interface{}, error) {
+				return s.CreateRoom(ctx, req.(*livekit.CreateRoomRequest))
+			}),
+		grpcRoomServiceMethod("ListRooms", func() interface{} { return &livekit.ListRoomsRequest{} },
+			func(s livekit.RoomService, ctx context.Context, req interface{}) (interface{}, error) {
+				return s.ListRooms(ctx, req.(*livekit.ListRoomsRequest))
+			}),
+		grpcRoomServiceMethod("DeleteRoom", func() interface{} { return &livekit.DeleteRoomRequest{} },
+			func(s livekit.RoomService, ctx context.Context, req interface{}) (interface{}, error) {
+				return s.DeleteRoom(ctx, req.(*livekit.DeleteRoomRequest))
+			}),
+		grpcRoomServiceMethod("ListParticipants", func() interface{} { return &livekit.ListParticipantsRequest{} },
+			func(s livekit.RoomService, ctx context.Context, req interface{}) (interface{}, error) {
+				return s.ListParticipants(ctx, req.(*livekit.ListParticipantsRequest))
+			}),
+		grpcRoomServiceMethod("GetParticipant", func() interface{} { return &livekit.RoomParticipantIdentity{} },
+			func(s livekit.RoomService, ctx context.Context, req interface{}) (interface{}, error) {
+				return s.GetParticipant(ctx, req.(*livekit.RoomParticipantIdentity))
+			}),
+		grpcRoomServiceMethod("RemoveParticipant", func() interface{} { return &livekit.RoomParticipantIdentity{} },
+			func(s livekit.RoomService, ctx context.Context, req interface{}) (interface{}, error) {
+				return s.RemoveParticipant(ctx, req.(*livekit.RoomParticipantIdentity))
+			}),
+		grpcRoomServiceMethod("MutePublishedTrack", func() interface{} { return &livekit.MuteRoomTrackRequest{} },
+			func(s livekit.RoomService, ctx context.Context, req interface{}) (interface{}, error) {
+				return s.MutePublishedTrack(ctx, req.(*livekit.MuteRoomTrackRequest))
+			}),
+		grpcRoomServiceMethod("UpdateParticipant", func() interface{} { return &livekit.UpdateParticipantRequest{} },
+			func(s livekit.RoomService, ctx context.Context, req interface{}) (interface{}, error) {
+				return s.UpdateParticipant(ctx, req.(*livekit.UpdateParticipantRequest))
+			}),
+		grpcRoomServiceMethod("UpdateSubscriptions", func() interface{} { return &livekit.UpdateSubscriptionsRequest{} },
+			func(s livekit.RoomService, ctx context.Context, req interface{}) (interface{}, error) {
+				return s.UpdateSubscriptions(ctx, req.(*livekit.UpdateSubscriptionsRequest))
+			}),
+		grpcRoomServiceMethod("SendData", func() interface{} { return &livekit.SendDataRequest{} },
+			func(s livekit.RoomService, ctx context.Context, req interface{}) (interface{}, error) {
+				return s.SendData(ctx, req.(*livekit.SendDataRequest))
+			}),
+		grpcRoomServiceMethod("UpdateRoomMetadata", func() interface{} { return &livekit.UpdateRoomMetadataRequest{} },
+			func(s livekit.RoomService, ctx context.Context, req interface{}) (interface{}, error) {
+				return s.UpdateRoomMetadata(ctx, req.(*livekit.UpdateRoomMetadataRequest))
+			}),
+	},
+}
+
+func grpcRoomServiceMethod(
+	name string,
+	newRequest func() interface{},
+	call func(s livekit.RoomService, ctx context.Context, req interface{}) (interface{}, error),
+) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			req := newRequest()
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				res, err := call(srv.(livekit.RoomService), ctx, req)
+				if err != nil {
+					return nil, grpcError(err)
+				}
+				return res, nil
+			}
+			if interceptor == nil {
+				return handler(ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + grpcRoomServiceName + "/" + name}
+			return interceptor(ctx, req, info, handler)
+		},
+	}
+}
+
+//------------------------------------------------
+
+// RoomEventService streams changes of the rooms hosted on this node
+type RoomEventService struct {
+	roomService livekit.RoomService
+	events      *RoomEventHub
+}
+
+type roomEventServer interface {
+	WatchParticipants(req *livekit.ListParticipantsRequest, stream grpc.ServerStream) error
+	WatchRoomEvents(req *livekit.ListParticipantsRequest, stream grpc.ServerStream) error
+}
+
+var grpcRoomEventServiceDesc = grpc.ServiceDesc{
+	ServiceName: grpcRoomEventServiceName,
+	HandlerType: (*roomEventServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchParticipants",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				req := &livekit.ListParticipantsRequest{}
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(roomEventServer).WatchParticipants(req, stream)
+			},
+		},
+		{
+			StreamName:    "WatchRoomEvents",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				req := &livekit.ListParticipantsRequest{}
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(roomEventServer).WatchRoomEvents(req, stream)
+			},
+		},
+	},
+}
+
+// WatchParticipants sends the participants of the room, then again each time they change. Changes
+// that follow each other closely are sent at once
+func (s *RoomEventService) WatchParticipants(req *livekit.ListParticipantsRequest, stream grpc.ServerStream) error {
+	if req.Room == "" {
+		return status.Error(codes.InvalidArgument, "room is required")
+	}
+	ctx := stream.Context()
+	// before listing, so that no change is missed
+	subscription := s.events.Subscribe(req.Room)
+	defer subscription.Close()
+
+	for {
+		res, err := s.roomService.ListParticipants(ctx, req)
+		if err != nil {
+			return grpcError(err)
+		}
+		if err := stream.SendMsg(res); err != nil {
+			return err
+		}
+
+		changed := false
+		for !changed {
+			select {
+			case <-ctx.Done():
+				return nil
+			case event, ok := <-subscription.Events():
+				if !ok {
+					return errSubscriptionOverflowed
+				}
+				changed = participantEvent(event)
+			}
+		}
+		// the ones that are queued already
+		for drained := false; !drained; {
+			select {
+			case _, ok := <-subscription.Events():
+				if !ok {
+					return errSubscriptionOverflowed
+				}
+			default:
+				drained = true
+			}
+		}
+	}
+}
+
+// WatchRoomEvents sends the changes of the room, or of all rooms when the request has no room
+func (s *RoomEventService) WatchRoomEvents(req *livekit.ListParticipantsRequest, stream grpc.ServerStream) error {
+	ctx := stream.Context()
+	var err error
+	if req.Room == "" {
+		err = EnsureListPermission(ctx)
+	} else {
+		err = EnsureAdminPermission(ctx, req.Room)
+	}
+	if err != nil {
+		return grpcError(twirpAuthError(err))
+	}
+
+	subscription := s.events.Subscribe(req.Room)
+	defer subscription.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return errSubscriptionOverflowed
+			}
+			if err := stream.SendMsg(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+var errSubscriptionOverflowed = status.Error(codes.ResourceExhausted, "too slow to receive room events")
+
+//------------------------------------------------
+
+func (m *APIKeyAuthMiddleware) unaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := m.grpcContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (m *APIKeyAuthMiddleware) streamInterceptor(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := m.grpcContext(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedServerStream{ServerStream: stream, ctx: ctx})
+}
+
+// grpcContext sets the grants of the call's token in ctx. Calls without a token have none
+func (m *APIKeyAuthMiddleware) grpcContext(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(grpcAuthorizationKey)
+	if len(values) == 0 {
+		return ctx, nil
+	}
+	if !strings.HasPrefix(values[0], bearerPrefix) {
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization header. Must start with "+bearerPrefix)
+	}
+	ctx, err := m.withGrants(ctx, values[0][len(bearerPrefix):])
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return ctx, nil
+}
+
+type authenticatedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// grpcError translates Twirp and lookup errors to gRPC status errors
+func grpcError(err error) error {
+	if twerr, ok := err.(twirp.Error); ok {
+		return status.Error(grpcCode(twerr.Code()), twerr.Msg())
+	}
+	switch err {
+	case ErrRoomNotFound, ErrParticipantNotFound, ErrTrackNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case ErrPermissionDenied:
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+func grpcCode(code twirp.ErrorCode) codes.Code {
+	switch code {
+	case twirp.Canceled:
+		return codes.Canceled
+	case twirp.InvalidArgument, twirp.Malformed:
+		return codes.InvalidArgument
+	case twirp.DeadlineExceeded:
+		return codes.DeadlineExceeded
+	case twirp.NotFound, twirp.BadRoute:
+		return codes.NotFound
+	case twirp.AlreadyExists:
+		return codes.AlreadyExists
+	case twirp.PermissionDenied:
+		return codes.PermissionDenied
+	case twirp.Unauthenticated:
+		return codes.Unauthenticated
+	case twirp.ResourceExhausted:
+		return codes.ResourceExhausted
+	case twirp.FailedPrecondition:
+		return codes.FailedPrecondition
+	case twirp.Aborted:
+		return codes.Aborted
+	case twirp.OutOfRange:
+		return codes.OutOfRange
+	case twirp.Unimplemented:
+		return codes.Unimplemented
+	case twirp.Unavailable:
+		return codes.Unavailable
+	case twirp.DataLoss:
+		return codes.DataLoss
+	case twirp.Internal:
+		return codes.Internal
+	}
+	return codes.Unknown
+}
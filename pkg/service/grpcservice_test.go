@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/auth/authfakes"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/livekit/protocol/webhook"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type stubRoomService struct {
+	livekit.RoomService
+	participants map[string][]*livekit.ParticipantInfo
+}
+
+func (s *stubRoomService) ListParticipants(ctx context.Context, req *livekit.ListParticipantsRequest) (*livekit.ListParticipantsResponse, error) {
+	if err := EnsureAdminPermission(ctx, req.Room); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	return &livekit.ListParticipantsResponse{Participants: s.participants[req.Room]}, nil
+}
+
+func TestRoomEventHub(t *testing.T) {
+	hub := NewRoomEventHub()
+	room := hub.Subscribe("room")
+	all := hub.Subscribe("")
+	defer all.Close()
+
+	hub.publishRoom(webhook.EventRoomStarted, &livekit.Room{Name: "other"})
+	hub.publishRoom(webhook.EventRoomStarted, &livekit.Room{Name: "room"})
+	require.Equal(t, "room", (<-room.Events()).Room.Name)
+	require.Equal(t, "other", (<-all.Events()).Room.Name)
+	require.Equal(t, "room", (<-all.Events()).Room.Name)
+
+	room.Close()
+	_, ok := <-room.Events()
+	require.False(t, ok)
+	require.False(t, room.Overflowed())
+
+	// dropped when it falls behind
+	for i := 0; i <= roomEventQueueSize; i++ {
+		hub.publishRoom(RoomEventRoomUpdated, &livekit.Room{Name: "room"})
+	}
+	for range all.Events() {
+	}
+	require.True(t, all.Overflowed())
+}
+
+func TestGRPCRoomService(t *testing.T) {
+	api, secret := "APIabcdefg", "somesecretencodedinbase62"
+	provider := &authfakes.FakeKeyProvider{}
+	provider.GetSecretReturns(secret)
+
+	roomService := &stubRoomService{participants: map[string][]*livekit.ParticipantInfo{
+		"room": {{Identity: "alice"}},
+	}}
+	roomManager := &RoomManager{events: NewRoomEventHub()}
+	server := NewGRPCServer(roomService, roomManager, provider)
+	ln := bufconn.Listen(1 << 16)
+	go func() {
+		_ = server.Serve(ln)
+	}()
+	defer server.Stop()
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return ln.DialContext(ctx)
+	}))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	withToken := func(grant *auth.VideoGrant) context.Context {
+		token, err := auth.NewAccessToken(api, secret).AddGrant(grant).ToJWT()
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		t.Cleanup(cancel)
+		return metadata.AppendToOutgoingContext(ctx, grpcAuthorizationKey, bearerPrefix+token)
+	}
+
+	t.Run("unary calls", func(t *testing.T) {
+		res := &livekit.ListParticipantsResponse{}
+		err := conn.Invoke(withToken(&auth.VideoGrant{Room: "room", RoomAdmin: true}), "/livekit.RoomService/ListParticipants",
+			&livekit.ListParticipantsRequest{Room: "room"}, res)
+		require.NoError(t, err)
+		require.Equal(t, "alice", res.Participants[0].Identity)
+
+		err = conn.Invoke(withToken(&auth.VideoGrant{Room: "other", RoomAdmin: true}), "/livekit.RoomService/ListParticipants",
+			&livekit.ListParticipantsRequest{Room: "room"}, res)
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), grpcAuthorizationKey, bearerPrefix+"invalid")
+		err = conn.Invoke(ctx, "/livekit.RoomService/ListParticipants", &livekit.ListParticipantsRequest{Room: "room"}, res)
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("participants are sent again when they change", func(t *testing.T) {
+		desc := &grpc.StreamDesc{ServerStreams: true}
+		stream, err := conn.NewStream(withToken(&auth.VideoGrant{Room: "room", RoomAdmin: true}), desc, "/livekit.RoomEventService/WatchParticipants")
+		require.NoError(t, err)
+		require.NoError(t, stream.SendMsg(&livekit.ListParticipantsRequest{Room: "room"}))
+		require.NoError(t, stream.CloseSend())
+
+		res := &livekit.ListParticipantsResponse{}
+		require.NoError(t, stream.RecvMsg(res))
+		require.Len(t, res.Participants, 1)
+
+		roomService.participants["room"] = append(roomService.participants["room"], &livekit.ParticipantInfo{Identity: "bob"})
+		roomManager.events.publishParticipant(webhook.EventParticipantJoined, &livekit.Room{Name: "room"}, &livekit.ParticipantInfo{Identity: "bob"})
+		require.NoError(t, stream.RecvMsg(res))
+		require.Len(t, res.Participants, 2)
+	})
+
+	t.Run("room events", func(t *testing.T) {
+		desc := &grpc.StreamDesc{ServerStreams: true}
+		stream, err := conn.NewStream(withToken(&auth.VideoGrant{RoomList: true}), desc, "/livekit.RoomEventService/WatchRoomEvents")
+		require.NoError(t, err)
+		require.NoError(t, stream.SendMsg(&livekit.ListParticipantsRequest{}))
+		require.NoError(t, stream.CloseSend())
+
+		// the subscription is made once the stream is handled
+		require.Eventually(t, func() bool {
+			roomManager.events.lock.Lock()
+			defer roomManager.events.lock.Unlock()
+			for s := range roomManager.events.subscriptions {
+				if s.room == "" {
+					return true
+				}
+			}
+			return false
+		}, time.Second, 10*time.Millisecond)
+		roomManager.events.publishRoom(webhook.EventRoomStarted, &livekit.Room{Name: "new"})
+		event := &livekit.WebhookEvent{}
+		require.NoError(t, stream.RecvMsg(event))
+		require.Equal(t, webhook.EventRoomStarted, event.Event)
+		require.Equal(t, "new", event.Room.Name)
+
+		// all rooms require the list grant
+		stream, err = conn.NewStream(withToken(&auth.VideoGrant{Room: "room", RoomAdmin: true}), desc, "/livekit.RoomEventService/WatchRoomEvents")
+		require.NoError(t, err)
+		require.NoError(t, stream.SendMsg(&livekit.ListParticipantsRequest{}))
+		require.NoError(t, stream.CloseSend())
+		require.Equal(t, codes.Unauthenticated, status.Code(stream.RecvMsg(event)))
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
@@ -20,10 +22,36 @@ type RoomStore interface {
 	UnlockRoom(ctx context.Context, name string, uid string) error
 
 	StoreRoom(ctx context.Context, room *livekit.Room) error
+	// DeleteRoom also deletes the policy and metadata versions of the room
 	DeleteRoom(ctx context.Context, name string) error
 
+	// StoreRoomMetadata stores metadata as the latest version of the room's metadata, returning its version
+	StoreRoomMetadata(ctx context.Context, roomName, metadata string) (uint64, error)
+	// LoadRoomMetadata returns the latest version of the room's metadata, version 0 when it was never updated
+	LoadRoomMetadata(ctx context.Context, roomName string) (string, uint64, error)
+
+	// StoreRoomPolicy stores what a room overrides of the policy in the room config
+	StoreRoomPolicy(ctx context.Context, roomName string, policy *config.RoomPolicy) error
+	// LoadRoomPolicy returns nil when the room doesn't override the policy
+	LoadRoomPolicy(ctx context.Context, roomName string) (*config.RoomPolicy, error)
+
 	StoreParticipant(ctx context.Context, roomName string, participant *livekit.ParticipantInfo) error
 	DeleteParticipant(ctx context.Context, roomName, identity string) error
+
+	StoreScheduledAction(ctx context.Context, action *ScheduledAction) error
+	// ListScheduledActions returns the pending actions of a room
+	ListScheduledActions(ctx context.Context, roomName string) ([]*ScheduledAction, error)
+	// ListDueScheduledActions returns the pending actions of all rooms that are due by dueBy
+	ListDueScheduledActions(ctx context.Context, dueBy time.Time) ([]*ScheduledAction, error)
+	// DeleteScheduledAction returns false when the action doesn't exist. Due actions are executed
+	// by the node that deleted them
+	DeleteScheduledAction(ctx context.Context, id string) (bool, error)
+
+	// StoreRecordingRoom stores the room a recording was started for, empty when it isn't for a known room
+	StoreRecordingRoom(ctx context.Context, recordingID, roomName string) error
+	// LoadRecordingRoom returns ErrRecordingNotFound when the recording wasn't stored
+	LoadRecordingRoom(ctx context.Context, recordingID string) (string, error)
+	DeleteRecordingRoom(ctx context.Context, recordingID string) error
 }
 
 type RORoomStore interface {
@@ -36,4 +64,10 @@ type RORoomStore interface {
 
 type RoomAllocator interface {
 	CreateRoom(ctx context.Context, req *livekit.CreateRoomRequest) (*livekit.Room, error)
+	// CreateRoomWithPolicy creates a room like CreateRoom, with enabled codecs and a policy that
+	// override the room config. Either can be nil to keep what the room has
+	CreateRoomWithPolicy(ctx context.Context, req *livekit.CreateRoomRequest, codecs []config.CodecSpec,
+		policy *config.RoomPolicy) (*livekit.Room, error)
+	// UpdateConfig applies a reloaded config
+	UpdateConfig(conf *config.Config)
 }
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+// KeyRotator reloads the API keys every interval, from the key command or key file, so that keys can
+// be rotated across nodes without restarting them or reloading their config. A key that can't be
+// loaded leaves the current keys in place
+type KeyRotator struct {
+	interval time.Duration
+	reloader *ConfigReloader
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewKeyRotator(conf *config.Config, reloader *ConfigReloader) *KeyRotator {
+	if conf.KeyRotation.Interval <= 0 || (conf.KeyFile == "" && len(conf.KeyRotation.Command) == 0) {
+		return nil
+	}
+	return &KeyRotator{
+		interval: conf.KeyRotation.Interval,
+		reloader: reloader,
+		done:     make(chan struct{}),
+	}
+}
+
+func (k *KeyRotator) Start() {
+	k.wg.Add(1)
+	go k.run()
+}
+
+func (k *KeyRotator) Stop() {
+	close(k.done)
+	k.wg.Wait()
+}
+
+func (k *KeyRotator) run() {
+	defer k.wg.Done()
+
+	ticker := time.NewTicker(k.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-k.done:
+			return
+		case <-ticker.C:
+			k.rotate()
+		}
+	}
+}
+
+func (k *KeyRotator) rotate() {
+	rotated, err := k.reloader.ReloadKeys()
+	if err != nil {
+		logger.Warnw("could not reload API keys, keeping current keys", err)
+		prometheus.IncrementKeyRotation(prometheus.KeyRotationFailed)
+		return
+	}
+	if rotated {
+		logger.Infow("API keys rotated")
+		prometheus.IncrementKeyRotation(prometheus.KeyRotationRotated)
+	}
+}
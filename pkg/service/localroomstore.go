@@ -6,6 +6,8 @@ import (
 	"time"
 
 	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
 )
 
 // encapsulates CRUD operations for room settings
@@ -16,13 +18,28 @@ type LocalRoomStore struct {
 	participants map[string]map[string]*livekit.ParticipantInfo
 	lock         sync.RWMutex
 	globalLock   sync.Mutex
+
+	// map of id => scheduled action
+	scheduledActions map[string]*ScheduledAction
+	// map of roomName => policy
+	policies map[string]*config.RoomPolicy
+	// map of roomName => latest metadata and its version
+	metadata         map[string]string
+	metadataVersions map[string]uint64
+	// map of recordingID => roomName
+	recordingRooms map[string]string
 }
 
 func NewLocalRoomStore() *LocalRoomStore {
 	return &LocalRoomStore{
-		rooms:        make(map[string]*livekit.Room),
-		participants: make(map[string]map[string]*livekit.ParticipantInfo),
-		lock:         sync.RWMutex{},
+		rooms:            make(map[string]*livekit.Room),
+		participants:     make(map[string]map[string]*livekit.ParticipantInfo),
+		scheduledActions: make(map[string]*ScheduledAction),
+		policies:         make(map[string]*config.RoomPolicy),
+		metadata:         make(map[string]string),
+		metadataVersions: make(map[string]uint64),
+		recordingRooms:   make(map[string]string),
+		lock:             sync.RWMutex{},
 	}
 }
 
@@ -70,9 +87,39 @@ func (p *LocalRoomStore) DeleteRoom(ctx context.Context, name string) error {
 
 	delete(p.participants, room.Name)
 	delete(p.rooms, room.Name)
+	delete(p.policies, room.Name)
+	delete(p.metadata, room.Name)
+	delete(p.metadataVersions, room.Name)
+	return nil
+}
+
+func (p *LocalRoomStore) StoreRoomPolicy(ctx context.Context, roomName string, policy *config.RoomPolicy) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.policies[roomName] = policy
 	return nil
 }
 
+func (p *LocalRoomStore) LoadRoomPolicy(ctx context.Context, roomName string) (*config.RoomPolicy, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.policies[roomName], nil
+}
+
+func (p *LocalRoomStore) StoreRoomMetadata(ctx context.Context, roomName, metadata string) (uint64, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.metadataVersions[roomName]++
+	p.metadata[roomName] = metadata
+	return p.metadataVersions[roomName], nil
+}
+
+func (p *LocalRoomStore) LoadRoomMetadata(ctx context.Context, roomName string) (string, uint64, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.metadata[roomName], p.metadataVersions[roomName], nil
+}
+
 func (p *LocalRoomStore) LockRoom(ctx context.Context, name string, duration time.Duration) (string, error) {
 	// local rooms lock & unlock globally
 	p.globalLock.Lock()
@@ -139,3 +186,72 @@ func (p *LocalRoomStore) DeleteParticipant(ctx context.Context, roomName, identi
 	}
 	return nil
 }
+
+func (p *LocalRoomStore) StoreScheduledAction(ctx context.Context, action *ScheduledAction) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.scheduledActions[action.ID] = action
+	return nil
+}
+
+func (p *LocalRoomStore) ListScheduledActions(ctx context.Context, roomName string) ([]*ScheduledAction, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	var actions []*ScheduledAction
+	for _, a := range p.scheduledActions {
+		if a.Room == roomName {
+			actions = append(actions, a)
+		}
+	}
+	return actions, nil
+}
+
+func (p *LocalRoomStore) ListDueScheduledActions(ctx context.Context, dueBy time.Time) ([]*ScheduledAction, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	var actions []*ScheduledAction
+	for _, a := range p.scheduledActions {
+		if !a.At.After(dueBy) {
+			actions = append(actions, a)
+		}
+	}
+	return actions, nil
+}
+
+func (p *LocalRoomStore) DeleteScheduledAction(ctx context.Context, id string) (bool, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.scheduledActions[id] == nil {
+		return false, nil
+	}
+	delete(p.scheduledActions, id)
+	return true, nil
+}
+
+func (p *LocalRoomStore) StoreRecordingRoom(ctx context.Context, recordingID, roomName string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.recordingRooms[recordingID] = roomName
+	return nil
+}
+
+func (p *LocalRoomStore) LoadRecordingRoom(ctx context.Context, recordingID string) (string, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	roomName, ok := p.recordingRooms[recordingID]
+	if !ok {
+		return "", ErrRecordingNotFound
+	}
+	return roomName, nil
+}
+
+func (p *LocalRoomStore) DeleteRecordingRoom(ctx context.Context, recordingID string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	delete(p.recordingRooms, recordingID)
+	return nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/rand"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/livekit/protocol/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+// how long a message of a queue is locked to the instance that got it first
+const messageQueueLockExpiration = 5 * time.Second
+
+// UniversalMessageBus is the protocol's RedisMessageBus for Redis clients other than a single
+// server, which it can't take
+type UniversalMessageBus struct {
+	rc redis.UniversalClient
+}
+
+func NewUniversalMessageBus(rc redis.UniversalClient) *UniversalMessageBus {
+	return &UniversalMessageBus{rc: rc}
+}
+
+func (b *UniversalMessageBus) Lock(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	return b.rc.SetNX(ctx, key, rand.Int(), expiration).Result()
+}
+
+func (b *UniversalMessageBus) Subscribe(ctx context.Context, channel string) (utils.PubSub, error) {
+	return b.subscribe(ctx, channel, false), nil
+}
+
+// SubscribeQueue only passes on the messages that no other subscriber got first
+func (b *UniversalMessageBus) SubscribeQueue(ctx context.Context, channel string) (utils.PubSub, error) {
+	return b.subscribe(ctx, channel, true), nil
+}
+
+func (b *UniversalMessageBus) subscribe(ctx context.Context, channel string, queue bool) *universalPubSub {
+	ps := &universalPubSub{
+		ps: b.rc.Subscribe(ctx, channel),
+		// same size as the Redis client's
+		c:    make(chan interface{}, 100),
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(ps.c)
+		for {
+			select {
+			case <-ps.done:
+				return
+			case msg, ok := <-ps.ps.Channel():
+				if !ok {
+					return
+				}
+				if queue {
+					sha := sha256.Sum256([]byte(msg.Payload))
+					if acquired, _ := b.Lock(ctx, base64.StdEncoding.EncodeToString(sha[:]), messageQueueLockExpiration); !acquired {
+						continue
+					}
+					utils.PromMessageBusCounter.WithLabelValues("in", "success").Add(1)
+				}
+				select {
+				case ps.c <- msg:
+				case <-ps.done:
+					return
+				}
+			}
+		}
+	}()
+	return ps
+}
+
+func (b *UniversalMessageBus) Publish(ctx context.Context, channel string, msg proto.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		utils.PromMessageBusCounter.WithLabelValues("out", "failure").Add(1)
+		return err
+	}
+
+	if err = b.rc.Publish(ctx, channel, data).Err(); err != nil {
+		utils.PromMessageBusCounter.WithLabelValues("out", "failure").Add(1)
+		return err
+	}
+	utils.PromMessageBusCounter.WithLabelValues("out", "success").Add(1)
+	return nil
+}
+
+type universalPubSub struct {
+	ps   *redis.PubSub
+	c    chan interface{}
+	done chan struct{}
+}
+
+func (p *universalPubSub) Channel() <-chan interface{} {
+	return p.c
+}
+
+func (p *universalPubSub) Payload(msg interface{}) []byte {
+	return []byte(msg.(*redis.Message).Payload)
+}
+
+func (p *universalPubSub) Close() error {
+	close(p.done)
+	return p.ps.Close()
+}
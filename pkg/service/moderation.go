@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+
+	livekit "github.com/livekit/protocol/proto"
+)
+
+const (
+	// actions participants with the canModerate grant can take on others in their room
+	ModerationActionMute   = "mute"
+	ModerationActionUnmute = "unmute"
+	ModerationActionRemove = "remove"
+)
+
+// ModerationRequest is sent by clients over the signal connection as a JSON text message,
+// {"moderate": {...}}. The signal protocol has no request for it
+type ModerationRequest struct {
+	// echoed in the response
+	RequestID string `json:"request_id,omitempty"`
+	Action    string `json:"action"`
+	// participant the action is taken on
+	Identity string `json:"identity"`
+	// track to mute or unmute
+	TrackSid string `json:"track_sid,omitempty"`
+}
+
+// ModerationResponse tells a client whether its moderation request was accepted. Accepted actions
+// are carried out by the node hosting the room
+type ModerationResponse struct {
+	RequestID string `json:"request_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// moderate validates a moderation request of a participant in roomName, and routes it to the node
+// hosting the room like the equivalent room API call
+func (s *RTCService) moderate(ctx context.Context, roomName string, req *ModerationRequest) error {
+	if err := EnsureModeratePermission(ctx); err != nil {
+		return err
+	}
+	if req.Identity == "" {
+		return ErrInvalidModeration
+	}
+
+	switch req.Action {
+	case ModerationActionMute, ModerationActionUnmute:
+		if req.TrackSid == "" {
+			return ErrInvalidModeration
+		}
+		// unmuting is dropped by the room's node unless enable_remote_unmute is set
+		return s.router.WriteParticipantRTC(ctx, roomName, req.Identity, &livekit.RTCNodeMessage{
+			Message: &livekit.RTCNodeMessage_MuteTrack{
+				MuteTrack: &livekit.MuteRoomTrackRequest{
+					Room:     roomName,
+					Identity: req.Identity,
+					TrackSid: req.TrackSid,
+					Muted:    req.Action == ModerationActionMute,
+				},
+			},
+		})
+	case ModerationActionRemove:
+		return s.router.WriteRoomRTC(ctx, roomName, req.Identity, &livekit.RTCNodeMessage{
+			Message: &livekit.RTCNodeMessage_RemoveParticipant{
+				RemoveParticipant: &livekit.RoomParticipantIdentity{
+					Room:     roomName,
+					Identity: req.Identity,
+				},
+			},
+		})
+	default:
+		return ErrInvalidModeration
+	}
+}
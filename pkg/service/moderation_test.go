@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestModeration(t *testing.T) {
+	moderatorCtx := context.WithValue(context.Background(), scopesKey, &ScopeGrants{CanModerate: true})
+
+	t.Run("requires the moderate grant", func(t *testing.T) {
+		router := &routingfakes.FakeRouter{}
+		s := &RTCService{router: router}
+		err := s.moderate(context.Background(), "room", &ModerationRequest{Action: ModerationActionRemove, Identity: "bob"})
+		require.ErrorIs(t, err, ErrPermissionDenied)
+		require.Zero(t, router.WriteRoomRTCCallCount())
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		s := &RTCService{router: &routingfakes.FakeRouter{}}
+		for _, req := range []*ModerationRequest{
+			{Action: ModerationActionRemove},
+			{Action: ModerationActionMute, Identity: "bob"},
+			{Action: "ban", Identity: "bob"},
+		} {
+			require.ErrorIs(t, s.moderate(moderatorCtx, "room", req), ErrInvalidModeration)
+		}
+	})
+
+	t.Run("mute", func(t *testing.T) {
+		router := &routingfakes.FakeRouter{}
+		s := &RTCService{router: router}
+		require.NoError(t, s.moderate(moderatorCtx, "room", &ModerationRequest{
+			Action:   ModerationActionMute,
+			Identity: "bob",
+			TrackSid: "TR_mic",
+		}))
+		require.Equal(t, 1, router.WriteParticipantRTCCallCount())
+		_, room, identity, msg := router.WriteParticipantRTCArgsForCall(0)
+		require.Equal(t, "room", room)
+		require.Equal(t, "bob", identity)
+		require.Equal(t, "TR_mic", msg.GetMuteTrack().TrackSid)
+		require.True(t, msg.GetMuteTrack().Muted)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		router := &routingfakes.FakeRouter{}
+		s := &RTCService{router: router}
+		require.NoError(t, s.moderate(moderatorCtx, "room", &ModerationRequest{
+			Action:   ModerationActionRemove,
+			Identity: "bob",
+		}))
+		require.Equal(t, 1, router.WriteRoomRTCCallCount())
+		_, room, identity, msg := router.WriteRoomRTCArgsForCall(0)
+		require.Equal(t, "room", room)
+		require.Equal(t, "bob", identity)
+		require.Equal(t, "bob", msg.GetRemoveParticipant().Identity)
+	})
+}
+
+func TestWSSignalConnectionModeration(t *testing.T) {
+	client := &typesfakes.FakeWebsocketClient{}
+	client.ReadMessageReturnsOnCall(0, websocket.TextMessage, []byte(`{"moderate": {"request_id": "1", "action": "remove", "identity": "bob"}}`), nil)
+	client.ReadMessageReturnsOnCall(1, websocket.BinaryMessage, []byte{}, nil)
+	conn := &WSSignalConnection{conn: client}
+
+	var received *ModerationRequest
+	conn.OnTextRequest(textKeyModerate, func(value json.RawMessage) (*livekit.SignalRequest, error) {
+		received = &ModerationRequest{}
+		return nil, decodeTextRequest(value, received)
+	})
+	req, err := conn.ReadRequest()
+	require.NoError(t, err)
+	require.Nil(t, req.Message)
+	require.Equal(t, &ModerationRequest{RequestID: "1", Action: ModerationActionRemove, Identity: "bob"}, received)
+	// still protobuf
+	require.False(t, conn.useJSON)
+
+	require.NoError(t, conn.WriteTextMessage(textResponseKey(textKeyModerate), &ModerationResponse{RequestID: "1", Error: "permissions denied"}))
+	messageType, payload := client.WriteMessageArgsForCall(0)
+	require.Equal(t, websocket.TextMessage, messageType)
+	require.JSONEq(t, `{"moderate_response": {"request_id": "1", "error": "permissions denied"}}`, string(payload))
+}
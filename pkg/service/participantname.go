@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+// ParticipantNameRequest renames a participant through the admin API
+type ParticipantNameRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	// empty to remove the display name
+	Name string `json:"name"`
+}
+
+// UpdateParticipantName renames a participant in a room hosted on this node. The protocol between
+// nodes has no message for it
+func (r *RoomManager) UpdateParticipantName(ctx context.Context, roomName, identity, name string) error {
+	if err := rtc.ValidateParticipantName(name); err != nil {
+		return err
+	}
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		return ErrParticipantNotFound
+	}
+	participant.SetName(name)
+	return nil
+}
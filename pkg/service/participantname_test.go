@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+func TestUpdateParticipantName(t *testing.T) {
+	r := &RoomManager{rooms: make(map[string]*rtc.Room)}
+	require.Equal(t, rtc.ErrInvalidName, r.UpdateParticipantName(context.Background(), "room", "bob", strings.Repeat("a", 257)))
+	// rooms hosted by other nodes aren't known
+	require.Equal(t, ErrRoomNotFound, r.UpdateParticipantName(context.Background(), "room", "bob", "Bob"))
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"encoding/json"
+
+	livekit "github.com/livekit/protocol/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+// ListParticipantsRequest is sent by clients over the signal connection as a JSON text message,
+// {"list_participants": {...}}, to fetch the participants the join response of a large room left
+// out. The signal protocol has no request for it
+type ListParticipantsRequest struct {
+	// echoed in the page
+	RequestID string `json:"request_id,omitempty"`
+	// sid of the last participant of the previous page, empty for the first one
+	After string `json:"after,omitempty"`
+	// max participants in the page, the room's page size when 0 or larger
+	Limit int `json:"limit,omitempty"`
+}
+
+// ParticipantPage answers a ListParticipantsRequest with participants ordered by sid. Each is
+// encoded like the participants of a JSON participant update
+type ParticipantPage struct {
+	RequestID    string            `json:"request_id,omitempty"`
+	Participants []json.RawMessage `json:"participants"`
+	// after of the request for the next page, empty on the last page
+	Next string `json:"next,omitempty"`
+}
+
+// handleListParticipantsRequest passes on a request for a page of participants, it's routed to the
+// node hosting the room like signal requests
+func handleListParticipantsRequest(value json.RawMessage) (*livekit.SignalRequest, error) {
+	req := &ListParticipantsRequest{}
+	if err := decodeTextRequest(value, req); err != nil {
+		return nil, err
+	}
+	limit := req.Limit
+	if limit < 0 {
+		limit = 0
+	}
+	return rtc.NewParticipantPageRequest(&rtc.ParticipantPageRequest{
+		RequestID: req.RequestID,
+		After:     req.After,
+		Limit:     limit,
+	}), nil
+}
+
+// marshalParticipantPage encodes a page of participants as a JSON text message, for protobuf
+// clients too
+func marshalParticipantPage(page *rtc.ParticipantPage) ([]byte, error) {
+	res := &ParticipantPage{
+		RequestID:    page.RequestID,
+		Participants: make([]json.RawMessage, 0, len(page.Participants)),
+		Next:         page.Next,
+	}
+	for _, pi := range page.Participants {
+		data, err := protojson.Marshal(pi)
+		if err != nil {
+			return nil, err
+		}
+		res.Participants = append(res.Participants, data)
+	}
+	return marshalTextMessage(textKeyParticipantPage, res)
+}
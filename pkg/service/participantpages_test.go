@@ -0,0 +1,46 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/gorilla/websocket"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestWSSignalConnectionParticipantPages(t *testing.T) {
+	client := &typesfakes.FakeWebsocketClient{}
+	client.ReadMessageReturnsOnCall(0, websocket.TextMessage, []byte(`{"list_participants": {"request_id": "1", "after": "PA_a", "limit": 50}}`), nil)
+	client.ReadMessageReturnsOnCall(1, websocket.TextMessage, []byte(`{"list_participants": {"limit": -1}}`), nil)
+	conn := NewWSSignalConnection(client)
+	conn.useJSON = false
+
+	req, err := conn.ReadRequest()
+	require.NoError(t, err)
+	require.Nil(t, req.Message)
+	require.Equal(t, &rtc.ParticipantPageRequest{RequestID: "1", After: "PA_a", Limit: 50}, rtc.SignalRequestParticipantPage(req))
+	// a JSON request doesn't switch protobuf clients to JSON
+	require.False(t, conn.useJSON)
+
+	req, err = conn.ReadRequest()
+	require.NoError(t, err)
+	require.Equal(t, &rtc.ParticipantPageRequest{}, rtc.SignalRequestParticipantPage(req))
+
+	// pages are JSON text messages for protobuf clients too
+	res := rtc.NewParticipantPageResponse(&rtc.ParticipantPage{
+		RequestID:    "1",
+		Participants: []*livekit.ParticipantInfo{{Sid: "PA_b", Identity: "bob"}},
+		Next:         "PA_b",
+	})
+	require.NoError(t, conn.WriteResponse(res))
+	messageType, payload := client.WriteMessageArgsForCall(0)
+	require.Equal(t, websocket.TextMessage, messageType)
+	require.JSONEq(t, `{"participant_page": {"request_id": "1", "participants": [{"sid": "PA_b", "identity": "bob"}], "next": "PA_b"}}`, string(payload))
+
+	require.NoError(t, conn.WriteResponse(rtc.NewParticipantPageResponse(&rtc.ParticipantPage{RequestID: "2"})))
+	_, payload = client.WriteMessageArgsForCall(1)
+	require.JSONEq(t, `{"participant_page": {"request_id": "2", "participants": []}}`, string(payload))
+}
@@ -0,0 +1,454 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	_ "github.com/lib/pq"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/livekit/protocol/utils"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// key of the advisory lock nodes take while migrating, so that only one of them does
+const postgresMigrationLock = 0x6c6b_7374
+
+// postgresMigrations are applied in order, each once. Released migrations must not be changed,
+// schema changes are appended
+var postgresMigrations = []string{
+	`CREATE TABLE livekit_rooms (
+		name text PRIMARY KEY,
+		-- Room proto
+		data bytea NOT NULL
+	);
+	CREATE TABLE livekit_participants (
+		room text NOT NULL,
+		identity text NOT NULL,
+		-- ParticipantInfo proto
+		data bytea NOT NULL,
+		PRIMARY KEY (room, identity)
+	);
+	CREATE TABLE livekit_room_policies (
+		room text PRIMARY KEY,
+		policy jsonb NOT NULL
+	);
+	CREATE TABLE livekit_room_metadata (
+		room text PRIMARY KEY,
+		metadata text NOT NULL,
+		version bigint NOT NULL
+	);
+	CREATE TABLE livekit_room_locks (
+		room text PRIMARY KEY,
+		token text NOT NULL,
+		expires_at timestamptz NOT NULL
+	);
+	CREATE TABLE livekit_scheduled_actions (
+		id text PRIMARY KEY,
+		room text NOT NULL,
+		due_at timestamptz NOT NULL,
+		-- ScheduledAction json
+		action jsonb NOT NULL
+	);
+	CREATE INDEX livekit_scheduled_actions_room ON livekit_scheduled_actions (room);
+	CREATE INDEX livekit_scheduled_actions_due_at ON livekit_scheduled_actions (due_at);`,
+
+	`CREATE TABLE livekit_recording_rooms (
+		recording_id text PRIMARY KEY,
+		room text NOT NULL
+	);`,
+}
+
+type PostgresRoomStore struct {
+	db *sql.DB
+}
+
+// NewPostgresRoomStore connects to the database and migrates its schema to the latest version
+func NewPostgresRoomStore(conf *config.PostgresStoreConfig) (*PostgresRoomStore, error) {
+	db, err := sql.Open("postgres", conf.URL)
+	if err != nil {
+		return nil, err
+	}
+	s := &PostgresRoomStore{db: db}
+	if err := s.migrate(context.Background()); err != nil {
+		_ = db.Close()
+		return nil, errors.Wrap(err, "could not migrate postgres store")
+	}
+	return s, nil
+}
+
+func (s *PostgresRoomStore) migrate(ctx context.Context) error {
+	// advisory locks are held by a session, all statements have to use the same connection
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", postgresMigrationLock); err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", postgresMigrationLock)
+	}()
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS livekit_schema_migrations (
+		version integer PRIMARY KEY,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`); err != nil {
+		return err
+	}
+	var version int
+	if err := conn.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM livekit_schema_migrations").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(postgresMigrations) {
+		return errors.Errorf("schema version %d is newer than this server, which knows %d", version, len(postgresMigrations))
+	}
+
+	for i := version; i < len(postgresMigrations); i++ {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, postgresMigrations[i]); err != nil {
+			_ = tx.Rollback()
+			return errors.Wrapf(err, "migration %d", i+1)
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO livekit_schema_migrations (version) VALUES ($1)", i+1); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *PostgresRoomStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *PostgresRoomStore) StoreRoom(ctx context.Context, room *livekit.Room) error {
+	if room.CreationTime == 0 {
+		room.CreationTime = time.Now().Unix()
+	}
+
+	data, err := proto.Marshal(room)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO livekit_rooms (name, data) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET data = EXCLUDED.data`, room.Name, data)
+	if err != nil {
+		return errors.Wrap(err, "could not create room")
+	}
+	return nil
+}
+
+func (s *PostgresRoomStore) LoadRoom(ctx context.Context, name string) (*livekit.Room, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, "SELECT data FROM livekit_rooms WHERE name = $1", name).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrRoomNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	room := livekit.Room{}
+	if err := proto.Unmarshal(data, &room); err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+func (s *PostgresRoomStore) ListRooms(ctx context.Context) ([]*livekit.Room, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT data FROM livekit_rooms")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get rooms")
+	}
+	defer rows.Close()
+
+	rooms := make([]*livekit.Room, 0)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		room := livekit.Room{}
+		if err := proto.Unmarshal(data, &room); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, &room)
+	}
+	return rooms, rows.Err()
+}
+
+func (s *PostgresRoomStore) DeleteRoom(ctx context.Context, name string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM livekit_rooms WHERE name = $1", name)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return nil
+	}
+	for _, query := range []string{
+		"DELETE FROM livekit_participants WHERE room = $1",
+		"DELETE FROM livekit_room_policies WHERE room = $1",
+		"DELETE FROM livekit_room_metadata WHERE room = $1",
+	} {
+		if _, err := tx.ExecContext(ctx, query, name); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresRoomStore) StoreRoomPolicy(ctx context.Context, roomName string, policy *config.RoomPolicy) error {
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO livekit_room_policies (room, policy) VALUES ($1, $2)
+		ON CONFLICT (room) DO UPDATE SET policy = EXCLUDED.policy`, roomName, string(data))
+	return err
+}
+
+func (s *PostgresRoomStore) LoadRoomPolicy(ctx context.Context, roomName string) (*config.RoomPolicy, error) {
+	var data string
+	err := s.db.QueryRowContext(ctx, "SELECT policy FROM livekit_room_policies WHERE room = $1", roomName).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	policy := config.RoomPolicy{}
+	if err := json.Unmarshal([]byte(data), &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (s *PostgresRoomStore) StoreRoomMetadata(ctx context.Context, roomName, metadata string) (uint64, error) {
+	var version uint64
+	err := s.db.QueryRowContext(ctx, `INSERT INTO livekit_room_metadata (room, metadata, version) VALUES ($1, $2, 1)
+		ON CONFLICT (room) DO UPDATE SET metadata = EXCLUDED.metadata, version = livekit_room_metadata.version + 1
+		RETURNING version`, roomName, metadata).Scan(&version)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not store room metadata")
+	}
+	return version, nil
+}
+
+func (s *PostgresRoomStore) LoadRoomMetadata(ctx context.Context, roomName string) (string, uint64, error) {
+	var metadata string
+	var version uint64
+	err := s.db.QueryRowContext(ctx, "SELECT metadata, version FROM livekit_room_metadata WHERE room = $1", roomName).
+		Scan(&metadata, &version)
+	if err == sql.ErrNoRows {
+		return "", 0, nil
+	} else if err != nil {
+		return "", 0, err
+	}
+	return metadata, version, nil
+}
+
+func (s *PostgresRoomStore) LockRoom(ctx context.Context, name string, duration time.Duration) (string, error) {
+	token := utils.NewGuid("LOCK")
+
+	startTime := time.Now()
+	for {
+		// taken over once it expired
+		res, err := s.db.ExecContext(ctx, `INSERT INTO livekit_room_locks (room, token, expires_at)
+			VALUES ($1, $2, now() + $3 * interval '1 millisecond')
+			ON CONFLICT (room) DO UPDATE SET token = EXCLUDED.token, expires_at = EXCLUDED.expires_at
+			WHERE livekit_room_locks.expires_at < now()`, name, token, duration.Milliseconds())
+		if err != nil {
+			return "", err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return "", err
+		} else if n == 1 {
+			return token, nil
+		}
+
+		// stop waiting past lock duration
+		if time.Now().Sub(startTime) > duration {
+			break
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return "", ErrRoomLockFailed
+}
+
+func (s *PostgresRoomStore) UnlockRoom(ctx context.Context, name string, uid string) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM livekit_room_locks WHERE room = $1 AND token = $2", name, uid)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 1 {
+		return nil
+	}
+
+	// already unlocked, unless another lock is held
+	var locked bool
+	err = s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM livekit_room_locks WHERE room = $1 AND expires_at >= now())", name).
+		Scan(&locked)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return ErrRoomUnlockFailed
+	}
+	return nil
+}
+
+func (s *PostgresRoomStore) StoreParticipant(ctx context.Context, roomName string, participant *livekit.ParticipantInfo) error {
+	data, err := proto.Marshal(participant)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO livekit_participants (room, identity, data) VALUES ($1, $2, $3)
+		ON CONFLICT (room, identity) DO UPDATE SET data = EXCLUDED.data`, roomName, participant.Identity, data)
+	return err
+}
+
+func (s *PostgresRoomStore) LoadParticipant(ctx context.Context, roomName, identity string) (*livekit.ParticipantInfo, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, "SELECT data FROM livekit_participants WHERE room = $1 AND identity = $2", roomName, identity).
+		Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrParticipantNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	pi := livekit.ParticipantInfo{}
+	if err := proto.Unmarshal(data, &pi); err != nil {
+		return nil, err
+	}
+	return &pi, nil
+}
+
+func (s *PostgresRoomStore) ListParticipants(ctx context.Context, roomName string) ([]*livekit.ParticipantInfo, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT data FROM livekit_participants WHERE room = $1", roomName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var participants []*livekit.ParticipantInfo
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		pi := livekit.ParticipantInfo{}
+		if err := proto.Unmarshal(data, &pi); err != nil {
+			return nil, err
+		}
+		participants = append(participants, &pi)
+	}
+	return participants, rows.Err()
+}
+
+func (s *PostgresRoomStore) DeleteParticipant(ctx context.Context, roomName, identity string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM livekit_participants WHERE room = $1 AND identity = $2", roomName, identity)
+	return err
+}
+
+func (s *PostgresRoomStore) StoreScheduledAction(ctx context.Context, action *ScheduledAction) error {
+	data, err := json.Marshal(action)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO livekit_scheduled_actions (id, room, due_at, action) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET room = EXCLUDED.room, due_at = EXCLUDED.due_at, action = EXCLUDED.action`,
+		action.ID, action.Room, action.At, string(data))
+	if err != nil {
+		return errors.Wrap(err, "could not store scheduled action")
+	}
+	return nil
+}
+
+func (s *PostgresRoomStore) ListScheduledActions(ctx context.Context, roomName string) ([]*ScheduledAction, error) {
+	return s.queryScheduledActions(ctx, "SELECT action FROM livekit_scheduled_actions WHERE room = $1", roomName)
+}
+
+func (s *PostgresRoomStore) ListDueScheduledActions(ctx context.Context, dueBy time.Time) ([]*ScheduledAction, error) {
+	return s.queryScheduledActions(ctx, "SELECT action FROM livekit_scheduled_actions WHERE due_at <= $1", dueBy)
+}
+
+func (s *PostgresRoomStore) queryScheduledActions(ctx context.Context, query string, arg interface{}) ([]*ScheduledAction, error) {
+	rows, err := s.db.QueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get scheduled actions")
+	}
+	defer rows.Close()
+
+	var actions []*ScheduledAction
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		action := ScheduledAction{}
+		if err := json.Unmarshal([]byte(data), &action); err != nil {
+			return nil, err
+		}
+		actions = append(actions, &action)
+	}
+	return actions, rows.Err()
+}
+
+func (s *PostgresRoomStore) DeleteScheduledAction(ctx context.Context, id string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM livekit_scheduled_actions WHERE id = $1", id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (s *PostgresRoomStore) StoreRecordingRoom(ctx context.Context, recordingID, roomName string) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO livekit_recording_rooms (recording_id, room) VALUES ($1, $2)
+		ON CONFLICT (recording_id) DO UPDATE SET room = EXCLUDED.room`, recordingID, roomName)
+	return err
+}
+
+func (s *PostgresRoomStore) LoadRecordingRoom(ctx context.Context, recordingID string) (string, error) {
+	var roomName string
+	err := s.db.QueryRowContext(ctx, "SELECT room FROM livekit_recording_rooms WHERE recording_id = $1", recordingID).
+		Scan(&roomName)
+	if err == sql.ErrNoRows {
+		return "", ErrRecordingNotFound
+	} else if err != nil {
+		return "", err
+	}
+	return roomName, nil
+}
+
+func (s *PostgresRoomStore) DeleteRecordingRoom(ctx context.Context, recordingID string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM livekit_recording_rooms WHERE recording_id = $1", recordingID)
+	return err
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const rawDumpCleanupInterval = time.Minute
+
+// RawDumpJanitor deletes the media dumps in raw_dump.directory once they're older than the
+// retention period, along with the room directories left empty
+type RawDumpJanitor struct {
+	conf *config.RawDumpConfig
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewRawDumpJanitor(conf *config.Config) *RawDumpJanitor {
+	if !conf.RawDump.Enabled || conf.RawDump.Retention == 0 {
+		return nil
+	}
+	return &RawDumpJanitor{
+		conf: &conf.RawDump,
+		done: make(chan struct{}),
+	}
+}
+
+func (j *RawDumpJanitor) Start() {
+	j.wg.Add(1)
+	go j.run()
+}
+
+func (j *RawDumpJanitor) Stop() {
+	close(j.done)
+	j.wg.Wait()
+}
+
+func (j *RawDumpJanitor) run() {
+	defer j.wg.Done()
+
+	ticker := time.NewTicker(rawDumpCleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-j.done:
+			return
+		case <-ticker.C:
+			j.deleteExpired(time.Now())
+		}
+	}
+}
+
+// deleteExpired deletes the files that were last written to before the retention period
+func (j *RawDumpJanitor) deleteExpired(now time.Time) {
+	expiry := now.Add(-j.conf.Retention)
+	var dirs []string
+	err := filepath.WalkDir(j.conf.Directory, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != j.conf.Directory {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().Before(expiry) {
+			if err := os.Remove(path); err != nil {
+				logger.Warnw("could not delete expired dump", err, "file", path)
+			}
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		logger.Warnw("could not clean up dumps", err, "directory", j.conf.Directory)
+		return
+	}
+
+	// deepest first, removing fails for directories that aren't empty
+	for i := len(dirs) - 1; i >= 0; i-- {
+		_ = os.Remove(dirs[i])
+	}
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestRawDumpJanitor(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	write := func(name string, modTime time.Time) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte{1}, 0o640))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+		return path
+	}
+	expired := write("old/PA_1_TR_1.rtpdump", now.Add(-2*time.Hour))
+	current := write("room/PA_2_TR_2.rtpdump", now.Add(-30*time.Minute))
+	expiredInRoom := write("room/PA_2_TR_3.rtpdump", now.Add(-90*time.Minute))
+
+	conf, err := config.NewConfig("", nil)
+	require.NoError(t, err)
+	conf.RawDump.Enabled = true
+	conf.RawDump.Directory = dir
+	conf.RawDump.Retention = time.Hour
+	NewRawDumpJanitor(conf).deleteExpired(now)
+
+	for _, path := range []string{expired, expiredInRoom, filepath.Dir(expired)} {
+		_, err := os.Stat(path)
+		require.True(t, os.IsNotExist(err), path)
+	}
+	_, err = os.Stat(current)
+	require.NoError(t, err)
+}
@@ -18,13 +18,15 @@ import (
 type RecordingService struct {
 	bus       utils.MessageBus
 	telemetry telemetry.TelemetryService
+	store     RoomStore
 	shutdown  chan struct{}
 }
 
-func NewRecordingService(mb utils.MessageBus, telemetry telemetry.TelemetryService) *RecordingService {
+func NewRecordingService(mb utils.MessageBus, telemetry telemetry.TelemetryService, store RoomStore) *RecordingService {
 	return &RecordingService{
 		bus:       mb,
 		telemetry: telemetry,
+		store:     store,
 		shutdown:  make(chan struct{}, 1),
 	}
 }
@@ -40,9 +42,15 @@ func (s *RecordingService) Stop() {
 }
 
 func (s *RecordingService) StartRecording(ctx context.Context, req *livekit.StartRecordingRequest) (*livekit.StartRecordingResponse, error) {
-	if err := EnsureRecordPermission(ctx); err != nil {
+	// only templates name the room being recorded
+	if err := EnsureEgressPermission(ctx, req.GetTemplate().GetRoomName()); err != nil {
 		return nil, twirpAuthError(err)
 	}
+	return s.startRecording(ctx, req)
+}
+
+// startRecording starts a recording without checking permissions
+func (s *RecordingService) startRecording(ctx context.Context, req *livekit.StartRecordingRequest) (*livekit.StartRecordingResponse, error) {
 	if s.bus == nil {
 		return nil, errors.New("recording not configured (redis required)")
 	}
@@ -63,6 +71,11 @@ func (s *RecordingService) StartRecording(ctx context.Context, req *livekit.Star
 		return nil, err
 	}
 
+	// recordings are managed by ID, tokens for a room may only manage the recordings of that room
+	if err := s.store.StoreRecordingRoom(ctx, recordingId, req.GetTemplate().GetRoomName()); err != nil {
+		logger.Errorw("could not store room of recording", err, "recordingID", recordingId)
+	}
+
 	logger.Debugw("recording started", "recordingID", recordingId)
 	s.telemetry.RecordingStarted(ctx, recordingId, req)
 
@@ -70,8 +83,8 @@ func (s *RecordingService) StartRecording(ctx context.Context, req *livekit.Star
 }
 
 func (s *RecordingService) AddOutput(ctx context.Context, req *livekit.AddOutputRequest) (*emptypb.Empty, error) {
-	if err := EnsureRecordPermission(ctx); err != nil {
-		return nil, twirpAuthError(err)
+	if err := s.ensureManagePermission(ctx, req.RecordingId); err != nil {
+		return nil, err
 	}
 	if s.bus == nil {
 		return nil, errors.New("recording not configured (redis required)")
@@ -89,8 +102,8 @@ func (s *RecordingService) AddOutput(ctx context.Context, req *livekit.AddOutput
 }
 
 func (s *RecordingService) RemoveOutput(ctx context.Context, req *livekit.RemoveOutputRequest) (*emptypb.Empty, error) {
-	if err := EnsureRecordPermission(ctx); err != nil {
-		return nil, twirpAuthError(err)
+	if err := s.ensureManagePermission(ctx, req.RecordingId); err != nil {
+		return nil, err
 	}
 	if s.bus == nil {
 		return nil, errors.New("recording not configured (redis required)")
@@ -108,8 +121,8 @@ func (s *RecordingService) RemoveOutput(ctx context.Context, req *livekit.Remove
 }
 
 func (s *RecordingService) EndRecording(ctx context.Context, req *livekit.EndRecordingRequest) (*emptypb.Empty, error) {
-	if err := EnsureRecordPermission(ctx); err != nil {
-		return nil, twirpAuthError(err)
+	if err := s.ensureManagePermission(ctx, req.RecordingId); err != nil {
+		return nil, err
 	}
 	if s.bus == nil {
 		return nil, errors.New("recording not configured (redis required)")
@@ -127,6 +140,19 @@ func (s *RecordingService) EndRecording(ctx context.Context, req *livekit.EndRec
 	return &emptypb.Empty{}, nil
 }
 
+// ensureManagePermission checks that the recording may be managed with the token of the request,
+// against the room it was started for
+func (s *RecordingService) ensureManagePermission(ctx context.Context, recordingID string) error {
+	room, err := s.store.LoadRecordingRoom(ctx, recordingID)
+	if err != nil && err != ErrRecordingNotFound {
+		return err
+	}
+	if err := EnsureEgressManagePermission(ctx, room); err != nil {
+		return twirpAuthError(err)
+	}
+	return nil
+}
+
 func (s *RecordingService) resultsWorker() {
 	sub, err := s.bus.SubscribeQueue(context.Background(), recording.ResultChannel)
 	if err != nil {
@@ -158,6 +184,9 @@ func (s *RecordingService) resultsWorker() {
 			}
 			logger.Debugw("recording ended", values...)
 
+			if err := s.store.DeleteRecordingRoom(context.Background(), res.Id); err != nil {
+				logger.Errorw("could not delete room of recording", err, "recordingID", res.Id)
+			}
 			s.telemetry.RecordingEnded(context.Background(), res)
 		case <-s.shutdown:
 			_ = sub.Close()
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/livekit/protocol/auth"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingManagePermission(t *testing.T) {
+	store := NewLocalRoomStore()
+	s := NewRecordingService(nil, nil, store)
+	require.NoError(t, store.StoreRecordingRoom(context.Background(), "RC_mine", "myroom"))
+	require.NoError(t, store.StoreRecordingRoom(context.Background(), "RC_other", "otherroom"))
+
+	ctx := context.WithValue(context.Background(), grantsKey, &auth.ClaimGrants{Video: &auth.VideoGrant{Room: "myroom"}})
+	ctx = context.WithValue(ctx, scopesKey, &ScopeGrants{CanStartEgress: true})
+
+	t.Run("recording of the token's room", func(t *testing.T) {
+		// passes the permission check, then fails for the missing message bus
+		_, err := s.EndRecording(ctx, &livekit.EndRecordingRequest{RecordingId: "RC_mine"})
+		require.EqualError(t, err, "recording not configured (redis required)")
+	})
+
+	t.Run("recording of another room", func(t *testing.T) {
+		_, err := s.EndRecording(ctx, &livekit.EndRecordingRequest{RecordingId: "RC_other"})
+		require.Equal(t, twirpAuthError(ErrPermissionDenied), err)
+		_, err = s.AddOutput(ctx, &livekit.AddOutputRequest{RecordingId: "RC_other"})
+		require.Equal(t, twirpAuthError(ErrPermissionDenied), err)
+		_, err = s.RemoveOutput(ctx, &livekit.RemoveOutputRequest{RecordingId: "RC_other"})
+		require.Equal(t, twirpAuthError(ErrPermissionDenied), err)
+	})
+
+	t.Run("unknown recording", func(t *testing.T) {
+		_, err := s.EndRecording(ctx, &livekit.EndRecordingRequest{RecordingId: "RC_unknown"})
+		require.Equal(t, twirpAuthError(ErrPermissionDenied), err)
+	})
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -9,6 +11,9 @@ import (
 	"github.com/livekit/protocol/utils"
 	"github.com/pkg/errors"
 	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
 )
 
 const (
@@ -21,18 +26,61 @@ const (
 
 	// RoomLockPrefix is a simple key containing a provided lock uid
 	RoomLockPrefix = "room_lock:"
+
+	// RoomPoliciesKey is hash of room_name => RoomPolicy json
+	RoomPoliciesKey = "room_policies"
+
+	// RoomMetadataKey is hash of room_name => latest metadata set through the API
+	RoomMetadataKey = "room_metadata"
+
+	// RoomMetadataVersionsKey is hash of room_name => version of the latest metadata
+	RoomMetadataVersionsKey = "room_metadata_versions"
+
+	// ScheduledActionsKey is hash of action_id => ScheduledAction json
+	ScheduledActionsKey = "scheduled_actions"
+
+	// ScheduledActionsDueKey is a sorted set of action ids, scored by the unix time in ms they're due
+	ScheduledActionsDueKey = "scheduled_actions_due"
+
+	// RecordingRoomsKey is hash of recording_id => room_name
+	RecordingRoomsKey = "recording_rooms"
+)
+
+// on Redis Cluster, keys that are written in the same transaction are hash tagged to be in the same
+// slot, otherwise the transaction is split up
+const (
+	clusterRoomMetadataKey         = "{room_metadata}"
+	clusterRoomMetadataVersionsKey = "{room_metadata}_versions"
+	clusterScheduledActionsKey     = "{scheduled_actions}"
+	clusterScheduledActionsDueKey  = "{scheduled_actions}_due"
 )
 
 type RedisRoomStore struct {
-	rc  *redis.Client
+	rc  redis.UniversalClient
 	ctx context.Context
+
+	metadataKey         string
+	metadataVersionsKey string
+	actionsKey          string
+	actionsDueKey       string
 }
 
-func NewRedisRoomStore(rc *redis.Client) *RedisRoomStore {
-	return &RedisRoomStore{
-		ctx: context.Background(),
-		rc:  rc,
+func NewRedisRoomStore(rc redis.UniversalClient) *RedisRoomStore {
+	s := &RedisRoomStore{
+		ctx:                 context.Background(),
+		rc:                  rc,
+		metadataKey:         RoomMetadataKey,
+		metadataVersionsKey: RoomMetadataVersionsKey,
+		actionsKey:          ScheduledActionsKey,
+		actionsDueKey:       ScheduledActionsDueKey,
 	}
+	if routing.IsRedisCluster(rc) {
+		s.metadataKey = clusterRoomMetadataKey
+		s.metadataVersionsKey = clusterRoomMetadataVersionsKey
+		s.actionsKey = clusterScheduledActionsKey
+		s.actionsDueKey = clusterScheduledActionsDueKey
+	}
+	return s
 }
 
 func (p *RedisRoomStore) StoreRoom(ctx context.Context, room *livekit.Room) error {
@@ -99,12 +147,72 @@ func (p *RedisRoomStore) DeleteRoom(ctx context.Context, name string) error {
 
 	pp := p.rc.Pipeline()
 	pp.HDel(p.ctx, RoomsKey, name)
+	pp.HDel(p.ctx, RoomPoliciesKey, name)
+	pp.HDel(p.ctx, p.metadataKey, name)
+	pp.HDel(p.ctx, p.metadataVersionsKey, name)
 	pp.Del(p.ctx, RoomParticipantsPrefix+name)
 
 	_, err = pp.Exec(p.ctx)
 	return err
 }
 
+func (p *RedisRoomStore) StoreRoomPolicy(ctx context.Context, roomName string, policy *config.RoomPolicy) error {
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	return p.rc.HSet(p.ctx, RoomPoliciesKey, roomName, data).Err()
+}
+
+func (p *RedisRoomStore) LoadRoomPolicy(ctx context.Context, roomName string) (*config.RoomPolicy, error) {
+	data, err := p.rc.HGet(p.ctx, RoomPoliciesKey, roomName).Result()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	policy := config.RoomPolicy{}
+	if err := json.Unmarshal([]byte(data), &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (p *RedisRoomStore) StoreRoomMetadata(ctx context.Context, roomName, metadata string) (uint64, error) {
+	// in a transaction, so that the latest version always has the latest metadata
+	var version *redis.IntCmd
+	_, err := p.rc.TxPipelined(p.ctx, func(pp redis.Pipeliner) error {
+		version = pp.HIncrBy(p.ctx, p.metadataVersionsKey, roomName, 1)
+		pp.HSet(p.ctx, p.metadataKey, roomName, metadata)
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "could not store room metadata")
+	}
+	return uint64(version.Val()), nil
+}
+
+func (p *RedisRoomStore) LoadRoomMetadata(ctx context.Context, roomName string) (string, uint64, error) {
+	var metadata, version *redis.StringCmd
+	_, err := p.rc.TxPipelined(p.ctx, func(pp redis.Pipeliner) error {
+		metadata = pp.HGet(p.ctx, p.metadataKey, roomName)
+		version = pp.HGet(p.ctx, p.metadataVersionsKey, roomName)
+		return nil
+	})
+	if err == redis.Nil {
+		return "", 0, nil
+	} else if err != nil {
+		return "", 0, err
+	}
+
+	v, err := strconv.ParseUint(version.Val(), 10, 64)
+	if err != nil {
+		return "", 0, err
+	}
+	return metadata.Val(), v, nil
+}
+
 func (p *RedisRoomStore) LockRoom(ctx context.Context, name string, duration time.Duration) (string, error) {
 	token := utils.NewGuid("LOCK")
 	key := RoomLockPrefix + name
@@ -199,3 +307,101 @@ func (p *RedisRoomStore) DeleteParticipant(ctx context.Context, roomName, identi
 
 	return p.rc.HDel(p.ctx, key, identity).Err()
 }
+
+func (p *RedisRoomStore) StoreScheduledAction(ctx context.Context, action *ScheduledAction) error {
+	data, err := json.Marshal(action)
+	if err != nil {
+		return err
+	}
+
+	pp := p.rc.TxPipeline()
+	pp.HSet(p.ctx, p.actionsKey, action.ID, data)
+	pp.ZAdd(p.ctx, p.actionsDueKey, &redis.Z{
+		Score:  float64(action.At.UnixNano() / int64(time.Millisecond)),
+		Member: action.ID,
+	})
+	if _, err = pp.Exec(p.ctx); err != nil {
+		return errors.Wrap(err, "could not store scheduled action")
+	}
+	return nil
+}
+
+func (p *RedisRoomStore) ListScheduledActions(ctx context.Context, roomName string) ([]*ScheduledAction, error) {
+	items, err := p.rc.HVals(p.ctx, p.actionsKey).Result()
+	if err != nil && err != redis.Nil {
+		return nil, errors.Wrap(err, "could not get scheduled actions")
+	}
+
+	var actions []*ScheduledAction
+	for _, item := range items {
+		action := ScheduledAction{}
+		if err := json.Unmarshal([]byte(item), &action); err != nil {
+			return nil, err
+		}
+		if action.Room == roomName {
+			actions = append(actions, &action)
+		}
+	}
+	return actions, nil
+}
+
+func (p *RedisRoomStore) ListDueScheduledActions(ctx context.Context, dueBy time.Time) ([]*ScheduledAction, error) {
+	ids, err := p.rc.ZRangeByScore(p.ctx, p.actionsDueKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(dueBy.UnixNano()/int64(time.Millisecond), 10),
+	}).Result()
+	if err != nil && err != redis.Nil {
+		return nil, errors.Wrap(err, "could not get scheduled actions")
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	items, err := p.rc.HMGet(p.ctx, p.actionsKey, ids...).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get scheduled actions")
+	}
+
+	actions := make([]*ScheduledAction, 0, len(items))
+	for _, item := range items {
+		// deleted since
+		data, ok := item.(string)
+		if !ok {
+			continue
+		}
+		action := ScheduledAction{}
+		if err := json.Unmarshal([]byte(data), &action); err != nil {
+			return nil, err
+		}
+		actions = append(actions, &action)
+	}
+	return actions, nil
+}
+
+func (p *RedisRoomStore) DeleteScheduledAction(ctx context.Context, id string) (bool, error) {
+	pp := p.rc.TxPipeline()
+	deleted := pp.HDel(p.ctx, p.actionsKey, id)
+	pp.ZRem(p.ctx, p.actionsDueKey, id)
+	if _, err := pp.Exec(p.ctx); err != nil {
+		return false, err
+	}
+	return deleted.Val() == 1, nil
+}
+
+func (p *RedisRoomStore) StoreRecordingRoom(ctx context.Context, recordingID, roomName string) error {
+	return p.rc.HSet(p.ctx, RecordingRoomsKey, recordingID, roomName).Err()
+}
+
+func (p *RedisRoomStore) LoadRecordingRoom(ctx context.Context, recordingID string) (string, error) {
+	roomName, err := p.rc.HGet(p.ctx, RecordingRoomsKey, recordingID).Result()
+	if err == redis.Nil {
+		return "", ErrRecordingNotFound
+	} else if err != nil {
+		return "", err
+	}
+	return roomName, nil
+}
+
+func (p *RedisRoomStore) DeleteRecordingRoom(ctx context.Context, recordingID string) error {
+	return p.rc.HDel(p.ctx, RecordingRoomsKey, recordingID).Err()
+}
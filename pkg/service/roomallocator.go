@@ -2,18 +2,35 @@ package service
 
 import (
 	"context"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/livekit/protocol/logger"
 	livekit "github.com/livekit/protocol/proto"
 	"github.com/livekit/protocol/utils"
+	"github.com/pkg/errors"
 
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/routing/selector"
 )
 
+const nodeRTTsKey = "nodeRTTs"
+
+// WithNodeRTTs sets the RTTs to nodes measured by the client that is joining, node ID => RTT.
+// When RTT probes are enabled, a new room is hosted on the node with the lowest RTT
+func WithNodeRTTs(ctx context.Context, rtts map[string]time.Duration) context.Context {
+	return context.WithValue(ctx, nodeRTTsKey, rtts)
+}
+
+func GetNodeRTTs(ctx context.Context) map[string]time.Duration {
+	rtts, _ := ctx.Value(nodeRTTsKey).(map[string]time.Duration)
+	return rtts
+}
+
 type StandardRoomAllocator struct {
+	lock      sync.RWMutex
 	config    *config.Config
 	router    routing.Router
 	selector  selector.NodeSelector
@@ -37,6 +54,17 @@ func NewRoomAllocator(conf *config.Config, router routing.Router, rs RoomStore)
 // CreateRoom creates a new room from a request and allocates it to a node to handle
 // it'll also monitor its state, and cleans it up when appropriate
 func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.CreateRoomRequest) (*livekit.Room, error) {
+	return r.CreateRoomWithPolicy(ctx, req, nil, nil)
+}
+
+// CreateRoomWithPolicy creates a room like CreateRoom. Codecs and policy, when set, override those of
+// the room config for this room
+func (r *StandardRoomAllocator) CreateRoomWithPolicy(ctx context.Context, req *livekit.CreateRoomRequest,
+	codecs []config.CodecSpec, policy *config.RoomPolicy) (*livekit.Room, error) {
+	r.lock.RLock()
+	conf := r.config
+	r.lock.RUnlock()
+
 	token, err := r.roomStore.LockRoom(ctx, req.Name, 5*time.Second)
 	if err != nil {
 		return nil, err
@@ -54,7 +82,7 @@ func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.Cre
 			CreationTime: time.Now().Unix(),
 			TurnPassword: utils.RandomSecret(),
 		}
-		applyDefaultRoomConfig(rm, &r.config.Room)
+		applyDefaultRoomConfig(rm, &conf.Room)
 	} else if err != nil {
 		return nil, err
 	}
@@ -65,9 +93,17 @@ func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.Cre
 	if req.MaxParticipants > 0 {
 		rm.MaxParticipants = req.MaxParticipants
 	}
+	if codecs != nil {
+		rm.EnabledCodecs = toProtoCodecs(codecs)
+	}
 	if err := r.roomStore.StoreRoom(ctx, rm); err != nil {
 		return nil, err
 	}
+	if policy != nil {
+		if err := r.roomStore.StoreRoomPolicy(ctx, rm.Name, policy); err != nil {
+			return nil, err
+		}
+	}
 
 	// check if room already assigned
 	existing, err := r.router.GetNodeForRoom(ctx, rm.Name)
@@ -76,10 +112,10 @@ func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.Cre
 	}
 
 	// if already assigned and still available, keep it on that node
-	if err == nil && selector.IsAvailable(existing) {
+	if err == nil && selector.IsAvailable(existing) && selector.HostsRooms(existing) {
 		// if node hosting the room is full, deny entry
-		if selector.LimitsReached(r.config.Limit, existing.Stats) {
-			return nil, routing.ErrNodeLimitReached
+		if exceeded := selector.ExceededLimits(conf.Limit, existing.Stats); len(exceeded) != 0 {
+			return nil, nodeLimitError(exceeded)
 		}
 
 		return rm, nil
@@ -92,10 +128,22 @@ func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.Cre
 		if err != nil {
 			return nil, err
 		}
+		// nodes over their limits don't take new rooms, they go to the other nodes
+		withinLimits := selector.FilterWithinLimits(conf.Limit, nodes)
+		if len(selector.GetAvailableNodes(withinLimits)) == 0 && len(selector.GetAvailableNodes(nodes)) != 0 {
+			return nil, routing.ErrNodeLimitReached
+		}
+		nodes = withinLimits
 
-		node, err := r.selector.SelectNode(nodes)
-		if err != nil {
-			return nil, err
+		var node *livekit.Node
+		if rtts := GetNodeRTTs(ctx); conf.NodeSelector.ProbeRTT && len(rtts) != 0 {
+			node = selector.SelectLowestRTT(nodes, rtts, conf.NodeSelector.SysloadLimit)
+		}
+		if node == nil {
+			node, err = r.selector.SelectNode(nodes)
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		nodeId = node.Id
@@ -110,13 +158,31 @@ func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.Cre
 	return rm, nil
 }
 
+// UpdateConfig applies a reloaded config to rooms created from now on
+func (r *StandardRoomAllocator) UpdateConfig(conf *config.Config) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.config = conf
+}
+
+// nodeLimitError tells which limits kept a participant from joining
+func nodeLimitError(exceeded []string) error {
+	return errors.Wrapf(routing.ErrNodeLimitReached, "%s limit", strings.Join(exceeded, " and "))
+}
+
 func applyDefaultRoomConfig(room *livekit.Room, conf *config.RoomConfig) {
 	room.EmptyTimeout = conf.EmptyTimeout
 	room.MaxParticipants = conf.MaxParticipants
-	for _, codec := range conf.EnabledCodecs {
-		room.EnabledCodecs = append(room.EnabledCodecs, &livekit.Codec{
+	room.EnabledCodecs = toProtoCodecs(conf.EnabledCodecs)
+}
+
+func toProtoCodecs(codecs []config.CodecSpec) []*livekit.Codec {
+	var protoCodecs []*livekit.Codec
+	for _, codec := range codecs {
+		protoCodecs = append(protoCodecs, &livekit.Codec{
 			Mime:     codec.Mime,
 			FmtpLine: codec.FmtpLine,
 		})
 	}
+	return protoCodecs
 }
@@ -3,6 +3,7 @@ package service_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	livekit "github.com/livekit/protocol/proto"
 	"github.com/stretchr/testify/require"
@@ -10,6 +11,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/routing/selector"
 	"github.com/livekit/livekit-server/pkg/service"
 	"github.com/livekit/livekit-server/pkg/service/servicefakes"
 )
@@ -46,6 +48,22 @@ func TestCreateRoom(t *testing.T) {
 		require.ErrorIs(t, err, routing.ErrNodeLimitReached)
 	})
 
+	t.Run("reject new participants when participant limit has been reached", func(t *testing.T) {
+		conf, err := config.NewConfig("", nil)
+		require.NoError(t, err)
+		conf.Limit.NumParticipants = 10
+
+		node, err := routing.NewLocalNode(conf)
+		require.NoError(t, err)
+		node.Stats.NumClients = 10
+
+		ra, conf := newTestRoomAllocator(t, conf, node)
+
+		_, err = ra.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "low-limit-room"})
+		require.ErrorIs(t, err, routing.ErrNodeLimitReached)
+		require.Contains(t, err.Error(), selector.LimitParticipants)
+	})
+
 	t.Run("reject new participants when bandwidth limit has been reached", func(t *testing.T) {
 		conf, err := config.NewConfig("", nil)
 		require.NoError(t, err)
@@ -63,6 +81,112 @@ func TestCreateRoom(t *testing.T) {
 	})
 }
 
+func TestCreateRoom_NodeLimits(t *testing.T) {
+	conf, err := config.NewConfig("", nil)
+	require.NoError(t, err)
+	conf.Limit.NumRooms = 10
+
+	newNode := func(id string, numRooms int32) *livekit.Node {
+		node := &livekit.Node{
+			Id:    id,
+			State: livekit.NodeState_SERVING,
+			Stats: &livekit.NodeStats{UpdatedAt: time.Now().Unix(), NumCpus: 1, NumRooms: numRooms},
+		}
+		selector.SetNodeLimits(node.Stats, conf.Limit)
+		return node
+	}
+	newAllocator := func(nodes ...*livekit.Node) (service.RoomAllocator, *routingfakes.FakeRouter) {
+		store := &servicefakes.FakeRoomStore{}
+		store.LoadRoomReturns(nil, service.ErrRoomNotFound)
+		router := &routingfakes.FakeRouter{}
+		router.GetNodeForRoomReturns(nil, routing.ErrNotFound)
+		router.ListNodesReturns(nodes, nil)
+		ra, err := service.NewRoomAllocator(conf, router, store)
+		require.NoError(t, err)
+		return ra, router
+	}
+
+	t.Run("new rooms go to nodes under their limits", func(t *testing.T) {
+		ra, router := newAllocator(newNode("ND_full", 10), newNode("ND_free", 2))
+		for i := 0; i < 5; i++ {
+			_, err := ra.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "myroom"})
+			require.NoError(t, err)
+			_, _, nodeID := router.SetNodeForRoomArgsForCall(i)
+			require.Equal(t, "ND_free", nodeID)
+		}
+	})
+
+	t.Run("rejected when all nodes are full", func(t *testing.T) {
+		ra, router := newAllocator(newNode("ND_full", 10), newNode("ND_over", 12))
+		_, err := ra.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "myroom"})
+		require.ErrorIs(t, err, routing.ErrNodeLimitReached)
+		require.Zero(t, router.SetNodeForRoomCallCount())
+	})
+}
+
+func TestCreateRoom_NodeRTTs(t *testing.T) {
+	conf, err := config.NewConfig("", nil)
+	require.NoError(t, err)
+	conf.NodeSelector.ProbeRTT = true
+
+	newNode := func(id string) *livekit.Node {
+		return &livekit.Node{
+			Id:    id,
+			State: livekit.NodeState_SERVING,
+			Stats: &livekit.NodeStats{UpdatedAt: time.Now().Unix(), NumCpus: 1},
+		}
+	}
+	store := &servicefakes.FakeRoomStore{}
+	store.LoadRoomReturns(nil, service.ErrRoomNotFound)
+	router := &routingfakes.FakeRouter{}
+	router.GetNodeForRoomReturns(nil, routing.ErrNotFound)
+	router.ListNodesReturns([]*livekit.Node{newNode("ND_far"), newNode("ND_near")}, nil)
+	ra, err := service.NewRoomAllocator(conf, router, store)
+	require.NoError(t, err)
+
+	ctx := service.WithNodeRTTs(context.Background(), map[string]time.Duration{
+		"ND_far":  150 * time.Millisecond,
+		"ND_near": 30 * time.Millisecond,
+	})
+	for i := 0; i < 5; i++ {
+		_, err = ra.CreateRoom(ctx, &livekit.CreateRoomRequest{Name: "myroom"})
+		require.NoError(t, err)
+		_, _, nodeID := router.SetNodeForRoomArgsForCall(i)
+		require.Equal(t, "ND_near", nodeID)
+	}
+}
+
+func TestCreateRoomWithPolicy(t *testing.T) {
+	conf, err := config.NewConfig("", nil)
+	require.NoError(t, err)
+	node, err := routing.NewLocalNode(conf)
+	require.NoError(t, err)
+
+	store := &servicefakes.FakeRoomStore{}
+	store.LoadRoomReturns(nil, service.ErrRoomNotFound)
+	router := &routingfakes.FakeRouter{}
+	router.GetNodeForRoomReturns(node, nil)
+	ra, err := service.NewRoomAllocator(conf, router, store)
+	require.NoError(t, err)
+
+	codecs := []config.CodecSpec{{Mime: "audio/opus"}, {Mime: "video/vp8"}}
+	policy := &config.RoomPolicy{MaxPublishBitrate: 1_000_000, MaxSimulcastLayers: 2}
+	room, err := ra.CreateRoomWithPolicy(context.Background(), &livekit.CreateRoomRequest{Name: "myroom"}, codecs, policy)
+	require.NoError(t, err)
+	require.Len(t, room.EnabledCodecs, 2)
+	require.Equal(t, "video/vp8", room.EnabledCodecs[1].Mime)
+
+	require.Equal(t, 1, store.StoreRoomPolicyCallCount())
+	_, roomName, stored := store.StoreRoomPolicyArgsForCall(0)
+	require.Equal(t, "myroom", roomName)
+	require.Equal(t, policy, stored)
+
+	// rooms created without a policy use the room config
+	_, err = ra.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "other"})
+	require.NoError(t, err)
+	require.Equal(t, 1, store.StoreRoomPolicyCallCount())
+}
+
 func newTestRoomAllocator(t *testing.T, conf *config.Config, node *livekit.Node) (service.RoomAllocator, *config.Config) {
 	store := &servicefakes.FakeRoomStore{}
 	store.LoadRoomReturns(nil, service.ErrRoomNotFound)
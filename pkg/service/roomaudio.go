@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// UpdateRoomRequest changes the settings of a room that was created
+type UpdateRoomRequest struct {
+	Room string `json:"room"`
+	// fields that are set override the audio config of the room, on top of what it already overrides
+	Audio *config.RoomAudioConfig `json:"audio"`
+}
+
+// UpdateRoomAudio overrides the audio config of a room. The override is stored with the room's
+// policy, and applied to the room right away when it's hosted on this node. It returns everything
+// the room now overrides of the audio config
+func (r *RoomManager) UpdateRoomAudio(ctx context.Context, roomName string, audio *config.RoomAudioConfig) (*config.RoomAudioConfig, error) {
+	token, err := r.roomStore.LockRoom(ctx, roomName, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = r.roomStore.UnlockRoom(ctx, roomName, token)
+	}()
+
+	if _, err := r.roomStore.LoadRoom(ctx, roomName); err != nil {
+		return nil, err
+	}
+	stored, err := r.roomStore.LoadRoomPolicy(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+	// the stored policy isn't modified, local stores hand it out
+	policy := config.RoomPolicy{}
+	if stored != nil {
+		policy = *stored
+	}
+	policy.Audio = policy.Audio.WithOverride(audio)
+	if err := r.roomStore.StoreRoomPolicy(ctx, roomName, &policy); err != nil {
+		return nil, err
+	}
+
+	if room := r.GetRoom(ctx, roomName); room != nil {
+		room.SetAudioConfig(r.getConfig().Audio.WithOverride(policy.Audio))
+	}
+	return policy.Audio, nil
+}
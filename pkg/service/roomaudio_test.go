@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+func TestUpdateRoomAudio(t *testing.T) {
+	ctx := context.Background()
+	conf, err := config.NewConfig("", nil)
+	require.NoError(t, err)
+	node, err := routing.NewLocalNode(conf)
+	require.NoError(t, err)
+	store := NewLocalRoomStore()
+	roomManager, err := NewLocalRoomManager(conf, store, node, &routingfakes.FakeRouter{}, telemetry.NewTelemetryService(nil, nil, nil, nil))
+	require.NoError(t, err)
+	defer roomManager.Stop()
+
+	level := uint8(50)
+	_, err = roomManager.UpdateRoomAudio(ctx, "myroom", &config.RoomAudioConfig{ActiveLevel: &level})
+	require.Equal(t, ErrRoomNotFound, err)
+
+	require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "myroom"}))
+	require.NoError(t, store.StoreRoomPolicy(ctx, "myroom", &config.RoomPolicy{MaxDuration: 600}))
+	room := rtc.NewRoom(&livekit.Room{Name: "myroom"}, *roomManager.rtcConfig, &conf.Room, &conf.Audio,
+		telemetry.NewTelemetryService(nil, nil, nil, nil))
+	defer room.Close()
+	roomManager.rooms["myroom"] = room
+
+	audio, err := roomManager.UpdateRoomAudio(ctx, "myroom", &config.RoomAudioConfig{ActiveLevel: &level})
+	require.NoError(t, err)
+	require.Equal(t, &config.RoomAudioConfig{ActiveLevel: &level}, audio)
+	audio, err = roomManager.UpdateRoomAudio(ctx, "myroom", &config.RoomAudioConfig{UpdateInterval: 200})
+	require.NoError(t, err)
+	require.Equal(t, &config.RoomAudioConfig{ActiveLevel: &level, UpdateInterval: 200}, audio)
+
+	// the rest of the policy is kept
+	policy, err := store.LoadRoomPolicy(ctx, "myroom")
+	require.NoError(t, err)
+	require.Equal(t, uint32(600), policy.MaxDuration)
+	require.Equal(t, audio, policy.Audio)
+
+	expected := conf.Audio
+	expected.ActiveLevel = level
+	expected.UpdateInterval = 200
+	require.Equal(t, expected, room.AudioConfig())
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const (
+	// rooms younger than this are skipped, they could still be in the process of being allocated
+	roomAuditGracePeriod = time.Minute
+
+	OrphanReasonNoNode    = "no_node"
+	OrphanReasonNodeGone  = "node_gone"
+	OrphanReasonExpired   = "expired"
+	OrphanReasonNotInRoom = "not_in_room"
+)
+
+type RoomAuditEntry struct {
+	Room   string `json:"room"`
+	RoomID string `json:"room_id"`
+	NodeID string `json:"node_id,omitempty"`
+	// set when the room itself is orphaned
+	Reason string `json:"reason,omitempty"`
+	// identities the store lists that aren't hosted by any node
+	OrphanedParticipants []string `json:"orphaned_participants,omitempty"`
+}
+
+// RoomAuditError is a room that couldn't be audited or cleaned up
+type RoomAuditError struct {
+	Room  string `json:"room"`
+	Error string `json:"error"`
+}
+
+type RoomAuditResult struct {
+	NumRooms int               `json:"num_rooms"`
+	Orphaned []*RoomAuditEntry `json:"orphaned"`
+	Errors   []*RoomAuditError `json:"errors,omitempty"`
+	Cleaned  bool              `json:"cleaned"`
+}
+
+// AuditRooms compares rooms and participants in the room store with the nodes that should be hosting them.
+// When cleanup is set, orphaned rooms and participants are removed from the store and routing.
+// Rooms that fail are listed in the result's errors, the others are still audited.
+func (r *RoomManager) AuditRooms(ctx context.Context, cleanup bool) (*RoomAuditResult, error) {
+	rooms, err := r.roomStore.ListRooms(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := r.router.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+	nodesByID := make(map[string]*livekit.Node, len(nodes))
+	for _, n := range nodes {
+		nodesByID[n.Id] = n
+	}
+
+	res := &RoomAuditResult{
+		NumRooms: len(rooms),
+		Cleaned:  cleanup,
+	}
+	now := time.Now()
+	for _, rm := range rooms {
+		if now.Sub(time.Unix(rm.CreationTime, 0)) < roomAuditGracePeriod {
+			continue
+		}
+		entry, err := r.auditRoom(ctx, rm, nodesByID, now)
+		if err != nil {
+			res.Errors = append(res.Errors, &RoomAuditError{Room: rm.Name, Error: err.Error()})
+			continue
+		}
+		if entry == nil {
+			continue
+		}
+		res.Orphaned = append(res.Orphaned, entry)
+
+		if cleanup {
+			if err := r.cleanupOrphan(ctx, entry); err != nil {
+				logger.Warnw("could not clean up orphaned room state", err, "room", entry.Room)
+				res.Errors = append(res.Errors, &RoomAuditError{Room: entry.Room, Error: err.Error()})
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func (r *RoomManager) auditRoom(ctx context.Context, rm *livekit.Room, nodesByID map[string]*livekit.Node, now time.Time) (*RoomAuditEntry, error) {
+	entry := &RoomAuditEntry{
+		Room:   rm.Name,
+		RoomID: rm.Sid,
+	}
+
+	node, err := r.router.GetNodeForRoom(ctx, rm.Name)
+	switch {
+	case err == routing.ErrNotFound:
+		entry.Reason = OrphanReasonNoNode
+	case err != nil:
+		return nil, err
+	default:
+		entry.NodeID = node.Id
+		// a node that missed stats updates may still be hosting the room, only one that
+		// deregistered is known to be gone
+		if nodesByID[node.Id] == nil {
+			entry.Reason = OrphanReasonNodeGone
+		}
+	}
+
+	participants, err := r.roomStore.ListParticipants(ctx, rm.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.Reason != "" {
+		// nothing can be hosting participants of an orphaned room
+		for _, p := range participants {
+			entry.OrphanedParticipants = append(entry.OrphanedParticipants, p.Identity)
+		}
+		return entry, nil
+	}
+
+	if entry.NodeID != r.currentNode.Id {
+		// hosted by another live node, which is authoritative for its participants
+		return nil, nil
+	}
+
+	room := r.GetRoom(ctx, rm.Name)
+	if room == nil {
+		// allocated to this node but never joined
+		if len(participants) > 0 || now.Sub(time.Unix(rm.CreationTime, 0)) > time.Duration(rm.EmptyTimeout)*time.Second {
+			entry.Reason = OrphanReasonExpired
+		}
+		for _, p := range participants {
+			entry.OrphanedParticipants = append(entry.OrphanedParticipants, p.Identity)
+		}
+	} else {
+		for _, p := range participants {
+			if room.GetParticipant(p.Identity) == nil {
+				entry.OrphanedParticipants = append(entry.OrphanedParticipants, p.Identity)
+			}
+		}
+		if len(entry.OrphanedParticipants) > 0 {
+			entry.Reason = OrphanReasonNotInRoom
+		}
+	}
+
+	if entry.Reason == "" {
+		return nil, nil
+	}
+	return entry, nil
+}
+
+func (r *RoomManager) cleanupOrphan(ctx context.Context, entry *RoomAuditEntry) error {
+	logger.Infow("cleaning up orphaned room state",
+		"room", entry.Room,
+		"roomID", entry.RoomID,
+		"nodeID", entry.NodeID,
+		"reason", entry.Reason,
+		"participants", entry.OrphanedParticipants,
+	)
+
+	for _, identity := range entry.OrphanedParticipants {
+		if err := r.roomStore.DeleteParticipant(ctx, entry.Room, identity); err != nil {
+			return err
+		}
+	}
+
+	// a live room only needs participants it doesn't know about removed
+	if entry.Reason == OrphanReasonNotInRoom {
+		return nil
+	}
+	return r.DeleteRoom(ctx, entry.Room)
+}
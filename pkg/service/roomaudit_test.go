@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+func TestAuditRooms(t *testing.T) {
+	ctx := context.Background()
+	conf, err := config.NewConfig("", nil)
+	require.NoError(t, err)
+	node, err := routing.NewLocalNode(conf)
+	require.NoError(t, err)
+	node.Stats.UpdatedAt = time.Now().Unix()
+	otherNode := &livekit.Node{Id: "ND_other", Stats: &livekit.NodeStats{UpdatedAt: time.Now().Unix()}}
+	staleNode := &livekit.Node{Id: "ND_stale", Stats: &livekit.NodeStats{UpdatedAt: time.Now().Add(-time.Hour).Unix()}}
+
+	router := &routingfakes.FakeRouter{}
+	router.ListNodesReturns([]*livekit.Node{node, otherNode, staleNode}, nil)
+	router.GetNodeForRoomStub = func(ctx context.Context, roomName string) (*livekit.Node, error) {
+		switch roomName {
+		case "gone":
+			return &livekit.Node{Id: "ND_gone"}, nil
+		case "stale":
+			return staleNode, nil
+		case "elsewhere":
+			return otherNode, nil
+		case "hosted", "never_joined", "allocated", "recent":
+			return node, nil
+		case "broken":
+			return nil, errors.New("routing unavailable")
+		}
+		return nil, routing.ErrNotFound
+	}
+	store := NewLocalRoomStore()
+	roomManager, err := NewLocalRoomManager(conf, store, node, router, telemetry.NewTelemetryService(nil, nil, nil, nil))
+	require.NoError(t, err)
+	defer roomManager.Stop()
+
+	created := time.Now().Add(-2 * roomAuditGracePeriod).Unix()
+	for _, name := range []string{"unrouted", "gone", "stale", "elsewhere", "hosted", "never_joined", "allocated", "broken"} {
+		require.NoError(t, store.StoreRoom(ctx, &livekit.Room{
+			Sid:          "RM_" + name,
+			Name:         name,
+			CreationTime: created,
+			EmptyTimeout: 600,
+		}))
+	}
+	// still in its grace period, though no participant ever joined
+	require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Sid: "RM_recent", Name: "recent", CreationTime: time.Now().Unix()}))
+	for room, identities := range map[string][]string{
+		"unrouted":     {"a"},
+		"stale":        {"b"},
+		"elsewhere":    {"c"},
+		"hosted":       {"alice", "ghost"},
+		"never_joined": {"d"},
+		"recent":       {"e"},
+	} {
+		for _, identity := range identities {
+			require.NoError(t, store.StoreParticipant(ctx, room, &livekit.ParticipantInfo{Identity: identity}))
+		}
+	}
+
+	room := rtc.NewRoom(&livekit.Room{Name: "hosted"}, *roomManager.rtcConfig, &conf.Room, &conf.Audio,
+		telemetry.NewTelemetryService(nil, nil, nil, nil))
+	t.Cleanup(room.Close)
+	alice := &typesfakes.FakeParticipant{}
+	alice.IDReturns("PA_alice")
+	alice.IdentityReturns("alice")
+	alice.StateReturns(livekit.ParticipantInfo_JOINED)
+	require.NoError(t, room.Join(alice, &rtc.ParticipantOptions{}, nil))
+	roomManager.rooms["hosted"] = room
+
+	expected := map[string]*RoomAuditEntry{
+		"unrouted":     {Room: "unrouted", RoomID: "RM_unrouted", Reason: OrphanReasonNoNode, OrphanedParticipants: []string{"a"}},
+		"gone":         {Room: "gone", RoomID: "RM_gone", NodeID: "ND_gone", Reason: OrphanReasonNodeGone},
+		"hosted":       {Room: "hosted", RoomID: "RM_hosted", NodeID: node.Id, Reason: OrphanReasonNotInRoom, OrphanedParticipants: []string{"ghost"}},
+		"never_joined": {Room: "never_joined", RoomID: "RM_never_joined", NodeID: node.Id, Reason: OrphanReasonExpired, OrphanedParticipants: []string{"d"}},
+	}
+	requireReport := func(res *RoomAuditResult, cleaned bool) {
+		require.Equal(t, 9, res.NumRooms)
+		require.Equal(t, cleaned, res.Cleaned)
+		// a room that fails doesn't keep the others from being audited
+		require.Equal(t, []*RoomAuditError{{Room: "broken", Error: "routing unavailable"}}, res.Errors)
+		require.Len(t, res.Orphaned, len(expected))
+		for _, entry := range res.Orphaned {
+			require.Equal(t, expected[entry.Room], entry, entry.Room)
+		}
+	}
+
+	t.Run("report leaves the store alone", func(t *testing.T) {
+		res, err := roomManager.AuditRooms(ctx, false)
+		require.NoError(t, err)
+		requireReport(res, false)
+
+		rooms, err := store.ListRooms(ctx)
+		require.NoError(t, err)
+		require.Len(t, rooms, 9)
+		participants, err := store.ListParticipants(ctx, "hosted")
+		require.NoError(t, err)
+		require.Len(t, participants, 2)
+		require.Equal(t, 0, router.ClearRoomStateCallCount())
+	})
+
+	t.Run("cleanup removes orphaned state", func(t *testing.T) {
+		res, err := roomManager.AuditRooms(ctx, true)
+		require.NoError(t, err)
+		requireReport(res, true)
+
+		for _, name := range []string{"unrouted", "gone", "never_joined"} {
+			_, err := store.LoadRoom(ctx, name)
+			require.Equal(t, ErrRoomNotFound, err, name)
+			participants, err := store.ListParticipants(ctx, name)
+			require.NoError(t, err)
+			require.Empty(t, participants, name)
+		}
+		cleared := make(map[string]bool)
+		for i := 0; i < router.ClearRoomStateCallCount(); i++ {
+			_, name := router.ClearRoomStateArgsForCall(i)
+			cleared[name] = true
+		}
+		require.Equal(t, map[string]bool{"unrouted": true, "gone": true, "never_joined": true}, cleared)
+
+		// the live room only loses the participant it doesn't host
+		_, err = store.LoadRoom(ctx, "hosted")
+		require.NoError(t, err)
+		participants, err := store.ListParticipants(ctx, "hosted")
+		require.NoError(t, err)
+		require.Len(t, participants, 1)
+		require.Equal(t, "alice", participants[0].Identity)
+		require.NotNil(t, roomManager.GetRoom(ctx, "hosted"))
+
+		// a node that stopped updating its stats isn't known to be gone
+		for _, name := range []string{"stale", "elsewhere", "allocated", "recent", "broken"} {
+			_, err := store.LoadRoom(ctx, name)
+			require.NoError(t, err, name)
+		}
+		participants, err = store.ListParticipants(ctx, "stale")
+		require.NoError(t, err)
+		require.Len(t, participants, 1)
+
+		// nothing is left to clean up
+		res, err = roomManager.AuditRooms(ctx, false)
+		require.NoError(t, err)
+		require.Equal(t, 6, res.NumRooms)
+		require.Empty(t, res.Orphaned)
+		require.Len(t, res.Errors, 1)
+	})
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"sync"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/livekit/protocol/webhook"
+)
+
+// room events that have no webhook, the others use the webhook event names
+const (
+	RoomEventRoomUpdated        = "room_updated"
+	RoomEventParticipantUpdated = "participant_updated"
+)
+
+// events buffered for each subscription, subscribers that fall further behind are dropped
+const roomEventQueueSize = 256
+
+// RoomEventHub fans out changes of the rooms hosted on this node to subscribers in the process.
+// Events are sent as webhook events, without masking identities: subscribers are admins
+type RoomEventHub struct {
+	lock          sync.Mutex
+	subscriptions map[*RoomEventSubscription]struct{}
+}
+
+func NewRoomEventHub() *RoomEventHub {
+	return &RoomEventHub{
+		subscriptions: make(map[*RoomEventSubscription]struct{}),
+	}
+}
+
+// RoomEventSubscription receives the events of a room, or of all rooms
+type RoomEventSubscription struct {
+	hub    *RoomEventHub
+	room   string
+	events chan *livekit.WebhookEvent
+	// set before events is closed, when the subscriber fell behind
+	overflowed bool
+}
+
+// Subscribe returns a subscription to the events of room, all rooms when it's empty. It has to be
+// closed
+func (h *RoomEventHub) Subscribe(room string) *RoomEventSubscription {
+	s := &RoomEventSubscription{
+		hub:    h,
+		room:   room,
+		events: make(chan *livekit.WebhookEvent, roomEventQueueSize),
+	}
+	h.lock.Lock()
+	h.subscriptions[s] = struct{}{}
+	h.lock.Unlock()
+	return s
+}
+
+// Publish sends event to the subscriptions of its room. It doesn't block, subscriptions whose
+// queue is full are closed
+func (h *RoomEventHub) Publish(event *livekit.WebhookEvent) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for s := range h.subscriptions {
+		if s.room != "" && s.room != event.Room.GetName() {
+			continue
+		}
+		select {
+		case s.events <- event:
+		default:
+			s.overflowed = true
+			delete(h.subscriptions, s)
+			close(s.events)
+		}
+	}
+}
+
+func (h *RoomEventHub) publishRoom(name string, room *livekit.Room) {
+	h.Publish(&livekit.WebhookEvent{Event: name, Room: room})
+}
+
+func (h *RoomEventHub) publishParticipant(name string, room *livekit.Room, participant *livekit.ParticipantInfo) {
+	h.Publish(&livekit.WebhookEvent{Event: name, Room: room, Participant: participant})
+}
+
+// Events returns the events of the subscription. It's closed when the subscription is closed,
+// or dropped because it fell behind
+func (s *RoomEventSubscription) Events() <-chan *livekit.WebhookEvent {
+	return s.events
+}
+
+// Overflowed returns true when the subscription was dropped because it fell behind, once Events
+// is closed
+func (s *RoomEventSubscription) Overflowed() bool {
+	return s.overflowed
+}
+
+func (s *RoomEventSubscription) Close() {
+	s.hub.lock.Lock()
+	defer s.hub.lock.Unlock()
+	if _, ok := s.hub.subscriptions[s]; ok {
+		delete(s.hub.subscriptions, s)
+		close(s.events)
+	}
+}
+
+// participantEvent returns true for events that change the participants of a room
+func participantEvent(event *livekit.WebhookEvent) bool {
+	switch event.Event {
+	case webhook.EventParticipantJoined, webhook.EventParticipantLeft, RoomEventParticipantUpdated, webhook.EventRoomFinished:
+		return true
+	}
+	return false
+}
@@ -8,12 +8,17 @@ import (
 
 	"github.com/livekit/protocol/logger"
 	livekit "github.com/livekit/protocol/proto"
+	"github.com/livekit/protocol/utils"
+	"github.com/livekit/protocol/webhook"
+	"github.com/pion/webrtc/v3"
 
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/rtc"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
 const (
@@ -33,6 +38,16 @@ type RoomManager struct {
 	telemetry   telemetry.TelemetryService
 
 	rooms map[string]*rtc.Room
+	// room name -> identity -> sessions held in the waiting room
+	pendingJoins map[string]map[string]*pendingJoin
+	// changes of the rooms, for streams of the gRPC service
+	events *RoomEventHub
+	// transcribes audio tracks of new rooms, nil when transcription is disabled
+	transcription rtc.TranscriptionProvider
+	// the node is overloaded, video is degraded for all participants
+	degraded utils.AtomicFlag
+
+	onRoomStarted func(room *rtc.Room)
 }
 
 func NewLocalRoomManager(
@@ -43,9 +58,14 @@ func NewLocalRoomManager(
 	telemetry telemetry.TelemetryService,
 ) (*RoomManager, error) {
 
-	rtcConf, err := rtc.NewWebRTCConfig(conf, currentNode.Ip)
-	if err != nil {
-		return nil, err
+	// signal relays don't host rooms, so they don't bind media ports
+	var rtcConf *rtc.WebRTCConfig
+	if !conf.IsSignalRelay() {
+		var err error
+		rtcConf, err = rtc.NewWebRTCConfig(conf, currentNode.Ip)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	r := &RoomManager{
@@ -56,7 +76,12 @@ func NewLocalRoomManager(
 		roomStore:   roomStore,
 		telemetry:   telemetry,
 
-		rooms: make(map[string]*rtc.Room),
+		rooms:        make(map[string]*rtc.Room),
+		pendingJoins: make(map[string]map[string]*pendingJoin),
+		events:       NewRoomEventHub(),
+	}
+	if conf.Transcription.Enabled {
+		r.transcription = NewWebSocketTranscriptionProvider(&conf.Transcription)
 	}
 
 	// hook up to router
@@ -65,6 +90,50 @@ func NewLocalRoomManager(
 	return r, nil
 }
 
+// UpdateConfig applies a reloaded config. Rooms that are already open keep their settings, the
+// RTC limits are applied to participants that are already connected
+func (r *RoomManager) UpdateConfig(conf *config.Config) {
+	r.lock.Lock()
+	r.config = conf
+	rooms := make([]*rtc.Room, 0, len(r.rooms))
+	for _, room := range r.rooms {
+		rooms = append(rooms, room)
+	}
+	r.lock.Unlock()
+
+	for _, room := range rooms {
+		for _, participant := range room.GetParticipants() {
+			participant.UpdateLimits(&conf.RTC)
+		}
+	}
+}
+
+// setDegraded degrades the video forwarded to all participants of the node, and to those joining
+// from now on, while it's overloaded
+func (r *RoomManager) setDegraded(degraded bool) {
+	if !r.degraded.TrySet(degraded) {
+		return
+	}
+	r.lock.RLock()
+	rooms := make([]*rtc.Room, 0, len(r.rooms))
+	for _, room := range r.rooms {
+		rooms = append(rooms, room)
+	}
+	r.lock.RUnlock()
+
+	for _, room := range rooms {
+		for _, participant := range room.GetParticipants() {
+			participant.SetDegraded(degraded)
+		}
+	}
+}
+
+func (r *RoomManager) getConfig() *config.Config {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.config
+}
+
 func (r *RoomManager) GetRoom(ctx context.Context, roomName string) *rtc.Room {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
@@ -120,6 +189,8 @@ func (r *RoomManager) CleanupRooms() error {
 	return nil
 }
 
+// CloseIdleRooms closes rooms that stayed empty past their timeout, and rooms that exceeded their
+// max duration along with their participants
 func (r *RoomManager) CloseIdleRooms() {
 	r.lock.RLock()
 	rooms := make([]*rtc.Room, 0, len(r.rooms))
@@ -129,10 +200,110 @@ func (r *RoomManager) CloseIdleRooms() {
 	r.lock.RUnlock()
 
 	for _, room := range rooms {
+		if room.MaxDurationExceeded() && !room.IsClosed() {
+			room.Logger.Infow("room exceeded its max duration, closing",
+				"roomID", room.Room.Sid,
+				"maxDuration", room.Policy().MaxDuration)
+			prometheus.RoomClosed("max_duration")
+			closeRoom(room)
+			continue
+		}
 		room.CloseIfEmpty()
 	}
 }
 
+// sampleTracks returns the counters of every track published and subscribed to on this node
+func (r *RoomManager) sampleTracks() []*telemetry.TrackSample {
+	r.lock.RLock()
+	rooms := make([]*rtc.Room, 0, len(r.rooms))
+	for _, rm := range r.rooms {
+		rooms = append(rooms, rm)
+	}
+	r.lock.RUnlock()
+
+	var samples []*telemetry.TrackSample
+	for _, room := range rooms {
+		for _, p := range room.GetParticipants() {
+			for _, t := range p.GetPublishedTracks() {
+				stats := t.GetStats()
+				if stats == nil {
+					continue
+				}
+				sample := &telemetry.TrackSample{
+					RoomID:        room.Room.Sid,
+					RoomName:      room.Room.Name,
+					ParticipantID: p.ID(),
+					TrackID:       stats.TrackID,
+					Direction:     livekit.StreamType_UPSTREAM,
+				}
+				// simulcast layers are added up, with the jitter of the worst one
+				for _, layer := range stats.Layers {
+					sample.Packets += uint64(layer.Packets)
+					sample.Bytes += layer.Bytes
+					sample.PacketsLost += uint64(layer.PacketsLost)
+					if layer.JitterMs > sample.JitterMs {
+						sample.JitterMs = layer.JitterMs
+					}
+				}
+				samples = append(samples, sample)
+			}
+
+			for _, t := range p.GetSubscribedTracks() {
+				stats := t.GetStats()
+				if stats == nil {
+					continue
+				}
+				samples = append(samples, &telemetry.TrackSample{
+					RoomID:        room.Room.Sid,
+					RoomName:      room.Room.Name,
+					ParticipantID: p.ID(),
+					TrackID:       stats.TrackID,
+					Direction:     livekit.StreamType_DOWNSTREAM,
+					Packets:       uint64(stats.PacketsSent),
+					Bytes:         uint64(stats.BytesSent),
+					PacketsLost:   uint64(stats.PacketsLost),
+					LayerSwitches: uint64(stats.LayerSwitches),
+					JitterMs:      stats.JitterMs,
+				})
+			}
+		}
+	}
+	return samples
+}
+
+// sampleSubscriptionQuality counts the unmuted video tracks subscribed to on this node that aren't
+// forwarded at the quality their subscribers asked for
+func (r *RoomManager) sampleSubscriptionQuality() subscriptionQuality {
+	r.lock.RLock()
+	rooms := make([]*rtc.Room, 0, len(r.rooms))
+	for _, rm := range r.rooms {
+		rooms = append(rooms, rm)
+	}
+	r.lock.RUnlock()
+
+	var quality subscriptionQuality
+	for _, room := range rooms {
+		affected := false
+		for _, p := range room.GetParticipants() {
+			for _, t := range p.GetSubscribedTracks() {
+				stats := t.GetStats()
+				if stats == nil || stats.Kind != webrtc.RTPCodecTypeVideo.String() || stats.Muted {
+					continue
+				}
+				quality.videoTracks++
+				if stats.ForwardingStatus != sfu.ForwardingStatusOptimal.String() {
+					quality.degradedTracks++
+					affected = true
+				}
+			}
+		}
+		if affected {
+			quality.affectedRooms++
+		}
+	}
+	return quality
+}
+
 func (r *RoomManager) HasParticipants() bool {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
@@ -168,11 +339,19 @@ func (r *RoomManager) Stop() {
 		if r.rtcConfig.TCPMuxListener != nil {
 			_ = r.rtcConfig.TCPMuxListener.Close()
 		}
+		if r.rtcConfig.MDNSResolver != nil {
+			r.rtcConfig.MDNSResolver.Close()
+		}
 	}
 }
 
 // StartSession starts WebRTC session when a new participant is connected, takes place on RTC node
 func (r *RoomManager) StartSession(ctx context.Context, roomName string, pi routing.ParticipantInit, requestSource routing.MessageSource, responseSink routing.MessageSink) {
+	if r.rtcConfig == nil {
+		logger.Errorw("cannot start RTC session on a signal relay", nil, "room", roomName, "participant", pi.Identity)
+		return
+	}
+
 	room, err := r.getOrCreateRoom(ctx, roomName)
 	if err != nil {
 		logger.Errorw("could not create room", err, "room", roomName)
@@ -189,6 +368,9 @@ func (r *RoomManager) StartSession(ctx context.Context, roomName string, pi rout
 				"nodeID", r.currentNode.Id,
 				"participant", pi.Identity,
 			)
+			if pi.LastSignalSeq != nil {
+				participant.ResumeSignal(*pi.LastSignalSeq)
+			}
 			if err = room.ResumeParticipant(participant, responseSink); err != nil {
 				logger.Warnw("could not resume participant", err,
 					"participant", pi.Identity)
@@ -213,6 +395,16 @@ func (r *RoomManager) StartSession(ctx context.Context, roomName string, pi rout
 		return
 	}
 
+	if pi.AwaitApproval {
+		r.holdJoin(ctx, room, pi, requestSource, responseSink)
+		return
+	}
+	r.joinRoom(ctx, room, pi, requestSource, responseSink)
+}
+
+// joinRoom creates the participant of a session and adds it to the room
+func (r *RoomManager) joinRoom(ctx context.Context, room *rtc.Room, pi routing.ParticipantInit, requestSource routing.MessageSource, responseSink routing.MessageSink) {
+	roomName := room.Room.Name
 	logger.Debugw("starting RTC session",
 		"room", roomName,
 		"nodeID", r.currentNode.Id,
@@ -220,22 +412,42 @@ func (r *RoomManager) StartSession(ctx context.Context, roomName string, pi rout
 		"sdk", pi.Client.Sdk,
 		"sdkVersion", pi.Client.Version,
 		"protocol", pi.Client.Protocol,
+		"autoSubscribe", pi.AutoSubscribe,
 	)
 
 	pv := types.ProtocolVersion(pi.Client.Protocol)
+	conf := r.getConfig()
 	rtcConf := *r.rtcConfig
 	rtcConf.SetBufferFactory(room.GetBufferFactor())
-	participant, err = rtc.NewParticipant(rtc.ParticipantParams{
-		Identity:        pi.Identity,
-		Config:          &rtcConf,
-		Sink:            responseSink,
-		AudioConfig:     r.config.Audio,
-		ProtocolVersion: pv,
-		Telemetry:       r.telemetry,
-		ThrottleConfig:  r.config.RTC.PLIThrottle,
-		EnabledCodecs:   room.Room.EnabledCodecs,
-		Hidden:          pi.Hidden,
-		Logger:          room.Logger,
+	if emulation := conf.Room.NetworkEmulationFor(roomName); emulation != nil {
+		logger.Infow("emulating network constraints", "room", roomName, "participant", pi.Identity,
+			"maxBitrate", emulation.MaxBitrate, "delay", emulation.Delay)
+		rtcConf.NetworkEmulation = emulation
+	}
+	participant, err := rtc.NewParticipant(rtc.ParticipantParams{
+		Identity:            pi.Identity,
+		Config:              &rtcConf,
+		Sink:                responseSink,
+		AudioConfig:         room.AudioConfig(),
+		ProtocolVersion:     pv,
+		Capabilities:        types.NegotiateCapabilities(pv, pi.Capabilities),
+		Telemetry:           r.telemetry,
+		ThrottleConfig:      conf.RTC.PLIThrottle,
+		DataRateLimit:       conf.RTC.DataRateLimit,
+		DataBackpressure:    conf.RTC.DataBackpressure,
+		SubscriptionLimit:   conf.RTC.SubscriptionLimit,
+		PendingTrackTimeout: conf.RTC.PendingTrackTimeout,
+		Degradation:         conf.Degradation,
+		EnabledCodecs:       room.Room.EnabledCodecs,
+		Policy:              room.Policy(),
+		LowPowerMode:        pi.LowPowerMode,
+		Kind:                participantKind(pi),
+		PublishSources:      pi.PublishSources,
+		CanApproveJoins:     pi.CanApproveJoins,
+		CanSubscribeTo:      pi.CanSubscribeTo,
+		SignalOnly:          pi.SignalOnly,
+		SequenceSignal:      pi.SequenceSignal,
+		Logger:              room.Logger,
 	})
 	if err != nil {
 		logger.Errorw("could not create participant", err)
@@ -244,10 +456,16 @@ func (r *RoomManager) StartSession(ctx context.Context, roomName string, pi rout
 	if pi.Metadata != "" {
 		participant.SetMetadata(pi.Metadata)
 	}
+	if pi.Name != "" {
+		participant.SetName(pi.Name)
+	}
 
 	if pi.Permission != nil {
 		participant.SetPermission(pi.Permission)
 	}
+	if r.degraded.Get() {
+		participant.SetDegraded(true)
+	}
 
 	// join room
 	opts := rtc.ParticipantOptions{
@@ -269,6 +487,7 @@ func (r *RoomManager) StartSession(ctx context.Context, roomName string, pi rout
 	}
 
 	r.telemetry.ParticipantJoined(ctx, room.Room, participant.ToProto())
+	r.events.publishParticipant(webhook.EventParticipantJoined, room.Room, participant.ToProto())
 	participant.OnClose(func(p types.Participant) {
 		if err := r.roomStore.DeleteParticipant(ctx, roomName, p.Identity()); err != nil {
 			logger.Errorw("could not delete participant", err)
@@ -281,6 +500,7 @@ func (r *RoomManager) StartSession(ctx context.Context, roomName string, pi rout
 			}
 		}
 		r.telemetry.ParticipantLeft(ctx, room.Room, p.ToProto())
+		r.events.publishParticipant(webhook.EventParticipantLeft, room.Room, p.ToProto())
 	})
 
 	go r.rtcSessionWorker(room, participant, requestSource)
@@ -302,15 +522,30 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName string) (*rt
 		return nil, err
 	}
 
+	policy, err := r.roomStore.LoadRoomPolicy(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+
 	// construct ice servers
-	room = rtc.NewRoom(ri, *r.rtcConfig, &r.config.Audio, r.telemetry)
+	conf := r.getConfig()
+	roomConf := conf.Room
+	roomConf.Policy = roomConf.Policy.WithOverride(policy)
+	audioConf := conf.Audio.WithOverride(roomConf.Policy.Audio)
+	room = rtc.NewRoom(ri, *r.rtcConfig, &roomConf, &audioConf, r.telemetry)
 	r.telemetry.RoomStarted(ctx, room.Room)
+	r.events.publishRoom(webhook.EventRoomStarted, room.Room)
+	if transcription := r.getTranscriptionProvider(); transcription != nil {
+		room.EnableTranscription(transcription, conf.Transcription.Webhook)
+	}
 
 	room.OnClose(func() {
+		r.dropPendingJoins(roomName)
 		r.telemetry.RoomEnded(ctx, room.Room)
 		if err := r.DeleteRoom(ctx, roomName); err != nil {
 			logger.Errorw("could not delete room", err)
 		}
+		r.events.publishRoom(webhook.EventRoomFinished, room.Room)
 
 		logger.Infow("room closed")
 	})
@@ -318,14 +553,20 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName string) (*rt
 		if err := r.roomStore.StoreRoom(ctx, room.Room); err != nil {
 			logger.Errorw("could not handle metadata update", err)
 		}
+		r.events.publishRoom(RoomEventRoomUpdated, room.Room)
 	})
 	room.OnParticipantChanged(func(p types.Participant) {
 		if p.State() != livekit.ParticipantInfo_DISCONNECTED {
-			if err := r.roomStore.StoreParticipant(ctx, roomName, p.ToProto()); err != nil {
+			info := p.ToProto()
+			if err := r.roomStore.StoreParticipant(ctx, roomName, info); err != nil {
 				logger.Errorw("could not handle participant change", err)
 			}
+			r.events.publishParticipant(RoomEventParticipantUpdated, room.Room, info)
 		}
 	})
+	if r.onRoomStarted != nil {
+		r.onRoomStarted(room)
+	}
 	r.lock.Lock()
 	r.rooms[roomName] = room
 	r.lock.Unlock()
@@ -333,6 +574,25 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName string) (*rt
 	return room, nil
 }
 
+// OnRoomStarted is called when a room is created on this node, before participants join it
+func (r *RoomManager) OnRoomStarted(fn func(room *rtc.Room)) {
+	r.onRoomStarted = fn
+}
+
+// SetTranscriptionProvider replaces the speech-to-text service audio tracks of new rooms are
+// transcribed with, nil disables transcription
+func (r *RoomManager) SetTranscriptionProvider(provider rtc.TranscriptionProvider) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.transcription = provider
+}
+
+func (r *RoomManager) getTranscriptionProvider() rtc.TranscriptionProvider {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.transcription
+}
+
 // manages an RTC session for a participant, runs on the RTC node
 func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.Participant, requestSource routing.MessageSource) {
 	defer func() {
@@ -346,6 +606,7 @@ func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.Partici
 	}()
 	defer rtc.Recover()
 
+	limiter := rtc.NewSignalRateLimiter(r.getConfig().RTC.SignalRateLimit)
 	for {
 		select {
 		case <-time.After(time.Millisecond * 50):
@@ -361,6 +622,27 @@ func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.Partici
 			}
 
 			req := obj.(*livekit.SignalRequest)
+			if !limiter.Allow(req) {
+				kind := rtc.SignalRequestKind(req)
+				prometheus.IncrementSignalRequestDropped(kind)
+				if limiter.Exceeded() {
+					logger.Warnw("disconnecting participant, too many signal requests over the rate limit", nil,
+						"room", room.Room.Name,
+						"participant", participant.Identity(),
+						"pID", participant.ID(),
+						"kind", kind,
+					)
+					prometheus.IncrementSignalRateLimitDisconnect()
+					return
+				}
+				logger.Debugw("dropping signal request, rate limit exceeded",
+					"room", room.Room.Name,
+					"participant", participant.Identity(),
+					"pID", participant.ID(),
+					"kind", kind,
+				)
+				continue
+			}
 
 			switch msg := req.Message.(type) {
 			case *livekit.SignalRequest_Offer:
@@ -420,9 +702,9 @@ func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.Partici
 				}
 			case *livekit.SignalRequest_TrackSetting:
 				for _, sid := range msg.TrackSetting.TrackSids {
-					subTrack := participant.GetSubscribedTrack(sid)
-					if subTrack == nil {
-						logger.Warnw("unable to find SubscribedTrack", nil,
+					pubTrack := room.GetPublishedTrack(sid)
+					if pubTrack == nil {
+						logger.Warnw("unable to find PublishedTrack", nil,
 							"room", room.Room.Name,
 							"participant", participant.Identity(),
 							"pID", participant.ID(),
@@ -430,44 +712,52 @@ func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.Partici
 						continue
 					}
 
-					// find the source PublishedTrack
-					publisher := room.GetParticipant(subTrack.PublisherIdentity())
-					if publisher == nil {
-						logger.Warnw("unable to find publisher of SubscribedTrack", nil,
-							"room", room.Room.Name,
-							"participant", participant.Identity(),
-							"pID", participant.ID(),
-							"publisher", subTrack.PublisherIdentity(),
-							"track", sid)
-						continue
+					// find quality for published track
+					quality := msg.TrackSetting.Quality
+					if msg.TrackSetting.Width > 0 {
+						quality = pubTrack.GetQualityForDimension(msg.TrackSetting.Width, msg.TrackSetting.Height)
+					}
+					if !msg.TrackSetting.Disabled {
+						// kept for when the track is subscribed to, settings can arrive before that
+						participant.SetSubscriberQuality(sid, quality)
 					}
 
-					pubTrack := publisher.GetPublishedTrack(sid)
-					if pubTrack == nil {
-						logger.Warnw("unable to find PublishedTrack", nil,
+					subTrack := participant.GetSubscribedTrack(sid)
+					if subTrack == nil {
+						logger.Debugw("track settings will apply once subscribed",
 							"room", room.Room.Name,
-							"participant", publisher.Identity(),
-							"pID", publisher.ID(),
+							"participant", participant.Identity(),
+							"pID", participant.ID(),
 							"track", sid)
 						continue
 					}
-					if msg.TrackSetting.Width > 0 {
-						msg.TrackSetting.Quality = pubTrack.GetQualityForDimension(msg.TrackSetting.Width, msg.TrackSetting.Height)
-					}
 
-					// find quality for published track
 					logger.Debugw("updating track settings",
 						"room", room.Room.Name,
 						"participant", participant.Identity(),
 						"pID", participant.ID(),
-						"settings", msg.TrackSetting)
+						"settings", msg.TrackSetting,
+						"quality", quality)
 					subTrack.UpdateSubscriberSettings(
 						!msg.TrackSetting.Disabled,
-						msg.TrackSetting.Quality,
+						quality,
 					)
 				}
 			case *livekit.SignalRequest_Leave:
 				_ = participant.Close()
+			default:
+				// data packets of signal-only participants, participant pages and text requests
+				// aren't requests the protocol defines
+				if data := rtc.SignalRequestData(req); data != nil {
+					participant.HandleSignalData(data)
+				} else if pageReq := rtc.SignalRequestParticipantPage(req); pageReq != nil {
+					if err := room.SendParticipantPage(participant, pageReq); err != nil {
+						logger.Warnw("could not send participant page", err,
+							"participant", participant.Identity(), "room", room.Room.Name)
+					}
+				} else if key, value := rtc.SignalRequestText(req); key != "" {
+					r.handleTextRequest(room, participant, key, value)
+				}
 			}
 		}
 	}
@@ -489,6 +779,11 @@ func (r *RoomManager) handleRTCMessage(ctx context.Context, roomName, identity s
 	switch rm := msg.Message.(type) {
 	case *livekit.RTCNodeMessage_RemoveParticipant:
 		if participant == nil {
+			// the store still lists a participant that isn't in the room, reconcile it
+			logger.Infow("removing stale participant", "room", roomName, "participant", identity)
+			if err := r.roomStore.DeleteParticipant(ctx, roomName, identity); err != nil {
+				logger.Errorw("could not delete participant", err)
+			}
 			return
 		}
 		logger.Infow("removing participant", "room", roomName, "participant", identity)
@@ -499,7 +794,7 @@ func (r *RoomManager) handleRTCMessage(ctx context.Context, roomName, identity s
 		}
 		logger.Debugw("setting track muted", "room", roomName, "participant", identity,
 			"track", rm.MuteTrack.TrackSid, "muted", rm.MuteTrack.Muted)
-		if !rm.MuteTrack.Muted && !r.config.Room.EnableRemoteUnmute {
+		if !rm.MuteTrack.Muted && !r.getConfig().Room.EnableRemoteUnmute {
 			logger.Errorw("cannot unmute track, remote unmute is disabled", nil)
 			return
 		}
@@ -513,13 +808,10 @@ func (r *RoomManager) handleRTCMessage(ctx context.Context, roomName, identity s
 			participant.SetMetadata(rm.UpdateParticipant.Metadata)
 		}
 		if rm.UpdateParticipant.Permission != nil {
-			participant.SetPermission(rm.UpdateParticipant.Permission)
+			room.SetParticipantPermission(participant, rm.UpdateParticipant.Permission)
 		}
 	case *livekit.RTCNodeMessage_DeleteRoom:
-		for _, p := range room.GetParticipants() {
-			_ = p.Close()
-		}
-		room.Close()
+		closeRoom(room)
 	case *livekit.RTCNodeMessage_UpdateSubscriptions:
 		if participant == nil {
 			return
@@ -541,23 +833,47 @@ func (r *RoomManager) handleRTCMessage(ctx context.Context, roomName, identity s
 		room.SendDataPacket(up, rm.SendData.Kind)
 	case *livekit.RTCNodeMessage_UpdateRoomMetadata:
 		logger.Debugw("updating room", "room", roomName)
-		room.SetMetadata(rm.UpdateRoomMetadata.Metadata)
+		metadata, version, err := r.roomStore.LoadRoomMetadata(ctx, roomName)
+		if err != nil {
+			logger.Errorw("could not load room metadata", err, "room", roomName)
+			return
+		}
+		if version == 0 {
+			// sent by a node that doesn't version metadata
+			room.SetMetadata(rm.UpdateRoomMetadata.Metadata)
+		} else if !room.SetMetadataVersion(metadata, version) {
+			logger.Debugw("skipping room metadata update, a later version was applied", "room", roomName, "version", version)
+		}
+	default:
+		// text requests routed on behalf of participants, the protocol has no message for them
+		if key, value := rtc.RTCNodeMessageText(msg); key != "" {
+			r.handleRoutedTextRequest(ctx, room, identity, key, value)
+		}
+	}
+}
+
+// closeRoom disconnects all participants and closes the room
+func closeRoom(room *rtc.Room) {
+	for _, p := range room.GetParticipants() {
+		_ = p.Close()
 	}
+	room.Close()
 }
 
 func (r *RoomManager) iceServersForRoom(ri *livekit.Room) []*livekit.ICEServer {
 	var iceServers []*livekit.ICEServer
 
+	conf := r.getConfig()
 	hasSTUN := false
-	if r.config.TURN.Enabled {
+	if conf.TURN.Enabled {
 		var urls []string
-		if r.config.TURN.UDPPort > 0 {
+		if conf.TURN.UDPPort > 0 {
 			// UDP TURN is used as STUN
 			hasSTUN = true
-			urls = append(urls, fmt.Sprintf("turn:%s:%d?transport=udp", r.config.RTC.NodeIP, r.config.TURN.UDPPort))
+			urls = append(urls, fmt.Sprintf("turn:%s:%d?transport=udp", conf.RTC.NodeIP, conf.TURN.UDPPort))
 		}
-		if r.config.TURN.TLSPort > 0 {
-			urls = append(urls, fmt.Sprintf("turns:%s:443?transport=tcp", r.config.TURN.Domain))
+		if conf.TURN.TLSPort > 0 {
+			urls = append(urls, fmt.Sprintf("turns:%s:443?transport=tcp", conf.TURN.Domain))
 		}
 		if len(urls) > 0 {
 			iceServers = append(iceServers, &livekit.ICEServer{
@@ -568,9 +884,9 @@ func (r *RoomManager) iceServersForRoom(ri *livekit.Room) []*livekit.ICEServer {
 		}
 	}
 
-	if len(r.config.RTC.StunServers) > 0 {
+	if len(conf.RTC.StunServers) > 0 {
 		hasSTUN = true
-		iceServers = append(iceServers, iceServerForStunServers(r.config.RTC.StunServers))
+		iceServers = append(iceServers, iceServerForStunServers(conf.RTC.StunServers))
 	}
 
 	if !hasSTUN {
@@ -586,3 +902,15 @@ func iceServerForStunServers(servers []string) *livekit.ICEServer {
 	}
 	return iceServer
 }
+
+// participantKind returns the kind pi joins as, signal nodes that don't send the kind only tell
+// whether the participant is hidden
+func participantKind(pi routing.ParticipantInit) string {
+	if pi.Kind != "" {
+		return pi.Kind
+	}
+	if pi.Hidden {
+		return rtc.ParticipantKindHidden
+	}
+	return rtc.ParticipantKindStandard
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/livekit/protocol/utils"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const (
+	ScheduledActionClose          = "close"
+	ScheduledActionLock           = "lock"
+	ScheduledActionUnlock         = "unlock"
+	ScheduledActionStartRecording = "start_recording"
+
+	scheduleCheckInterval = time.Second
+)
+
+// ScheduledAction is an action on a room that is executed at a given time
+type ScheduledAction struct {
+	ID     string    `json:"id"`
+	Room   string    `json:"room"`
+	Action string    `json:"action"`
+	At     time.Time `json:"at"`
+	// StartRecordingRequest in its JSON form, for start_recording
+	Recording json.RawMessage `json:"recording,omitempty"`
+}
+
+// Validate checks that the action can be executed. Recordings from a template record the room of
+// the action when the template doesn't name one
+func (a *ScheduledAction) Validate() error {
+	if a.Room == "" {
+		return errors.New("room is required")
+	}
+	if a.At.IsZero() {
+		return errors.New("at is required")
+	}
+
+	switch a.Action {
+	case ScheduledActionClose, ScheduledActionLock, ScheduledActionUnlock:
+		if len(a.Recording) != 0 {
+			return errors.New("recording is only used by start_recording")
+		}
+	case ScheduledActionStartRecording:
+		req, err := a.RecordingRequest()
+		if err != nil {
+			return errors.Wrap(err, "invalid recording")
+		}
+		if template := req.GetTemplate(); template != nil {
+			if template.Room == nil {
+				template.Room = &livekit.RecordingTemplate_RoomName{RoomName: a.Room}
+			} else if roomName := template.GetRoomName(); roomName != "" && roomName != a.Room {
+				return errors.New("recording template is for a different room")
+			}
+			if a.Recording, err = protojson.Marshal(req); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.Errorf("unknown action %q", a.Action)
+	}
+	return nil
+}
+
+// RecordingRequest returns the request that start_recording starts the recording with
+func (a *ScheduledAction) RecordingRequest() (*livekit.StartRecordingRequest, error) {
+	if len(a.Recording) == 0 {
+		return nil, errors.New("recording is required")
+	}
+	req := &livekit.StartRecordingRequest{}
+	if err := protojson.Unmarshal(a.Recording, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func sortScheduledActions(actions []*ScheduledAction) {
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].At.Before(actions[j].At)
+	})
+}
+
+// RoomScheduler executes the actions scheduled on rooms once they're due. Actions are kept in the
+// room store, every node checks for due actions and executes those for the rooms it hosts.
+// Recordings are started by whichever node sees them first, they don't depend on a hosting node.
+// Closing, locking and unlocking a room that isn't active when the action is due has no effect,
+// the action is dropped
+type RoomScheduler struct {
+	roomStore   RoomStore
+	router      routing.Router
+	currentNode routing.LocalNode
+	roomManager *RoomManager
+	recService  *RecordingService
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewRoomScheduler(
+	roomStore RoomStore,
+	router routing.Router,
+	currentNode routing.LocalNode,
+	roomManager *RoomManager,
+	recService *RecordingService,
+) *RoomScheduler {
+	return &RoomScheduler{
+		roomStore:   roomStore,
+		router:      router,
+		currentNode: currentNode,
+		roomManager: roomManager,
+		recService:  recService,
+		done:        make(chan struct{}),
+	}
+}
+
+func (s *RoomScheduler) Start() {
+	s.wg.Add(1)
+	go s.run()
+}
+
+func (s *RoomScheduler) Stop() {
+	close(s.done)
+	s.wg.Wait()
+}
+
+// Schedule stores a validated action, assigning its ID
+func (s *RoomScheduler) Schedule(ctx context.Context, action *ScheduledAction) error {
+	action.ID = utils.NewGuid("SA_")
+	return s.roomStore.StoreScheduledAction(ctx, action)
+}
+
+// List returns the pending actions of a room, in the order they're due
+func (s *RoomScheduler) List(ctx context.Context, roomName string) ([]*ScheduledAction, error) {
+	actions, err := s.roomStore.ListScheduledActions(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+	sortScheduledActions(actions)
+	return actions, nil
+}
+
+// Cancel removes a pending action, returning false when it was already executed or canceled
+func (s *RoomScheduler) Cancel(ctx context.Context, id string) (bool, error) {
+	return s.roomStore.DeleteScheduledAction(ctx, id)
+}
+
+func (s *RoomScheduler) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(scheduleCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.executeDue(context.Background(), time.Now())
+		}
+	}
+}
+
+// executeDue executes the actions that are due by now and that this node is responsible for
+func (s *RoomScheduler) executeDue(ctx context.Context, now time.Time) {
+	actions, err := s.roomStore.ListDueScheduledActions(ctx, now)
+	if err != nil {
+		logger.Errorw("could not list scheduled actions", err)
+		return
+	}
+	sortScheduledActions(actions)
+
+	for _, action := range actions {
+		if action.Action != ScheduledActionStartRecording {
+			// only the hosting node executes room actions. Rooms that aren't active, or whose node
+			// is gone, are claimed by any node
+			node, err := s.router.GetNodeForRoom(ctx, action.Room)
+			if err == nil && node.Id != s.currentNode.Id {
+				continue
+			} else if err != nil && err != routing.ErrNotFound {
+				logger.Warnw("could not get node for room", err, "room", action.Room)
+				continue
+			}
+		}
+
+		// whoever deletes the action executes it
+		claimed, err := s.roomStore.DeleteScheduledAction(ctx, action.ID)
+		if err != nil {
+			logger.Errorw("could not claim scheduled action", err, "room", action.Room, "actionID", action.ID)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		s.execute(ctx, action)
+	}
+}
+
+func (s *RoomScheduler) execute(ctx context.Context, action *ScheduledAction) {
+	values := []interface{}{"room", action.Room, "action", action.Action, "actionID", action.ID}
+
+	if action.Action == ScheduledActionStartRecording {
+		req, err := action.RecordingRequest()
+		if err == nil {
+			_, err = s.recService.startRecording(ctx, req)
+		}
+		if err != nil {
+			logger.Errorw("could not execute scheduled action", err, values...)
+			return
+		}
+		logger.Infow("executed scheduled action", values...)
+		return
+	}
+
+	room := s.roomManager.GetRoom(ctx, action.Room)
+	if room == nil {
+		logger.Infow("dropping scheduled action, room isn't active", values...)
+		return
+	}
+	switch action.Action {
+	case ScheduledActionClose:
+		closeRoom(room)
+	case ScheduledActionLock:
+		room.SetLocked(true)
+	case ScheduledActionUnlock:
+		room.SetLocked(false)
+	}
+	logger.Infow("executed scheduled action", values...)
+}
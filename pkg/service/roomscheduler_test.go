@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+func TestScheduledActionValidate(t *testing.T) {
+	at := time.Now()
+
+	t.Run("actions are validated", func(t *testing.T) {
+		require.Error(t, (&ScheduledAction{Room: "myroom", Action: "mute", At: at}).Validate())
+		require.Error(t, (&ScheduledAction{Room: "myroom", Action: ScheduledActionClose}).Validate())
+		require.Error(t, (&ScheduledAction{Action: ScheduledActionClose, At: at}).Validate())
+		require.Error(t, (&ScheduledAction{Room: "myroom", Action: ScheduledActionLock, At: at, Recording: []byte(`{}`)}).Validate())
+		require.Error(t, (&ScheduledAction{Room: "myroom", Action: ScheduledActionStartRecording, At: at}).Validate())
+		require.NoError(t, (&ScheduledAction{Room: "myroom", Action: ScheduledActionClose, At: at}).Validate())
+	})
+
+	t.Run("recording templates record the room of the action", func(t *testing.T) {
+		action := &ScheduledAction{
+			Room:      "myroom",
+			Action:    ScheduledActionStartRecording,
+			At:        at,
+			Recording: []byte(`{"template": {"layout": "speaker-dark"}, "filepath": "myroom.mp4"}`),
+		}
+		require.NoError(t, action.Validate())
+		req, err := action.RecordingRequest()
+		require.NoError(t, err)
+		require.Equal(t, "myroom", req.GetTemplate().GetRoomName())
+		require.Equal(t, "myroom.mp4", req.GetFilepath())
+
+		other, err := protojson.Marshal(&livekit.StartRecordingRequest{
+			Input: &livekit.StartRecordingRequest_Template{
+				Template: &livekit.RecordingTemplate{Room: &livekit.RecordingTemplate_RoomName{RoomName: "other"}},
+			},
+		})
+		require.NoError(t, err)
+		action.Recording = other
+		require.Error(t, action.Validate())
+	})
+}
+
+func TestRoomScheduler(t *testing.T) {
+	ctx := context.Background()
+	conf, err := config.NewConfig("", nil)
+	require.NoError(t, err)
+	node, err := routing.NewLocalNode(conf)
+	require.NoError(t, err)
+	otherNode := &livekit.Node{Id: "ND_other"}
+
+	router := &routingfakes.FakeRouter{}
+	router.GetNodeForRoomStub = func(ctx context.Context, roomName string) (*livekit.Node, error) {
+		switch roomName {
+		case "hosted":
+			return node, nil
+		case "elsewhere":
+			return otherNode, nil
+		}
+		return nil, routing.ErrNotFound
+	}
+	store := NewLocalRoomStore()
+	roomManager, err := NewLocalRoomManager(conf, store, node, router, telemetry.NewTelemetryService(nil, nil, nil, nil))
+	require.NoError(t, err)
+	defer roomManager.Stop()
+	room := rtc.NewRoom(&livekit.Room{Name: "hosted"}, *roomManager.rtcConfig, &conf.Room, &conf.Audio,
+		telemetry.NewTelemetryService(nil, nil, nil, nil))
+	roomManager.rooms["hosted"] = room
+
+	s := NewRoomScheduler(store, router, node, roomManager, NewRecordingService(nil, nil, store))
+	now := time.Now()
+	schedule := func(roomName, action string, at time.Time) *ScheduledAction {
+		a := &ScheduledAction{Room: roomName, Action: action, At: at}
+		require.NoError(t, a.Validate())
+		require.NoError(t, s.Schedule(ctx, a))
+		return a
+	}
+
+	unlock := schedule("hosted", ScheduledActionUnlock, now.Add(time.Minute))
+	lock := schedule("hosted", ScheduledActionLock, now.Add(-time.Second))
+	elsewhere := schedule("elsewhere", ScheduledActionClose, now)
+	schedule("inactive", ScheduledActionLock, now)
+
+	actions, err := s.List(ctx, "hosted")
+	require.NoError(t, err)
+	require.Equal(t, []*ScheduledAction{lock, unlock}, actions)
+
+	t.Run("due actions of hosted rooms are executed", func(t *testing.T) {
+		s.executeDue(ctx, now)
+		require.True(t, room.IsLocked())
+
+		actions, err := s.List(ctx, "hosted")
+		require.NoError(t, err)
+		require.Equal(t, []*ScheduledAction{unlock}, actions)
+
+		s.executeDue(ctx, unlock.At)
+		require.False(t, room.IsLocked())
+	})
+
+	t.Run("actions of rooms hosted on other nodes are left to them", func(t *testing.T) {
+		actions, err := s.List(ctx, "elsewhere")
+		require.NoError(t, err)
+		require.Equal(t, []*ScheduledAction{elsewhere}, actions)
+	})
+
+	t.Run("actions of inactive rooms are dropped", func(t *testing.T) {
+		actions, err := s.List(ctx, "inactive")
+		require.NoError(t, err)
+		require.Empty(t, actions)
+	})
+
+	t.Run("canceled actions aren't executed", func(t *testing.T) {
+		a := schedule("hosted", ScheduledActionClose, now)
+		canceled, err := s.Cancel(ctx, a.ID)
+		require.NoError(t, err)
+		require.True(t, canceled)
+		canceled, err = s.Cancel(ctx, a.ID)
+		require.NoError(t, err)
+		require.False(t, canceled)
+
+		s.executeDue(ctx, now)
+		require.False(t, room.IsClosed())
+	})
+
+	t.Run("rooms are closed", func(t *testing.T) {
+		schedule("hosted", ScheduledActionClose, now)
+		s.executeDue(ctx, now)
+		require.True(t, room.IsClosed())
+	})
+}
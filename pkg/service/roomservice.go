@@ -2,24 +2,33 @@ package service
 
 import (
 	"context"
+	"sync"
 
 	livekit "github.com/livekit/protocol/proto"
 	"github.com/pkg/errors"
 	"github.com/thoas/go-funk"
 	"github.com/twitchtv/twirp"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
 )
 
+// largest payload SendData accepts, leaving room for the rest of the packet within the 64KiB
+// that data channels can reliably deliver in one message
+const maxSendDataSize = 60 * 1024
+
 // A rooms service that supports a single node
 type RoomService struct {
+	lock          sync.RWMutex
+	conf          *config.Config
 	router        routing.MessageRouter
 	roomAllocator RoomAllocator
-	roomStore     RORoomStore
+	roomStore     RoomStore
 }
 
-func NewRoomService(ra RoomAllocator, rs RORoomStore, router routing.MessageRouter) (svc livekit.RoomService, err error) {
+func NewRoomService(conf *config.Config, ra RoomAllocator, rs RoomStore, router routing.MessageRouter) (svc *RoomService, err error) {
 	svc = &RoomService{
+		conf:          conf,
 		router:        router,
 		roomAllocator: ra,
 		roomStore:     rs,
@@ -27,6 +36,19 @@ func NewRoomService(ra RoomAllocator, rs RORoomStore, router routing.MessageRout
 	return
 }
 
+// UpdateConfig applies a reloaded config
+func (s *RoomService) UpdateConfig(conf *config.Config) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.conf = conf
+}
+
+func (s *RoomService) getConfig() *config.Config {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.conf
+}
+
 func (s *RoomService) CreateRoom(ctx context.Context, req *livekit.CreateRoomRequest) (rm *livekit.Room, err error) {
 	if err = EnsureCreatePermission(ctx); err != nil {
 		return nil, twirpAuthError(err)
@@ -40,6 +62,21 @@ func (s *RoomService) CreateRoom(ctx context.Context, req *livekit.CreateRoomReq
 	return
 }
 
+// CreateRoomWithPolicy creates a room like CreateRoom, with enabled codecs and a policy that override
+// the room config
+func (s *RoomService) CreateRoomWithPolicy(ctx context.Context, req *livekit.CreateRoomRequest,
+	codecs []config.CodecSpec, policy *config.RoomPolicy) (*livekit.Room, error) {
+	if err := EnsureCreatePermission(ctx); err != nil {
+		return nil, err
+	}
+
+	rm, err := s.roomAllocator.CreateRoomWithPolicy(ctx, req, codecs, policy)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create room")
+	}
+	return rm, nil
+}
+
 func (s *RoomService) ListRooms(ctx context.Context, req *livekit.ListRoomsRequest) (res *livekit.ListRoomsResponse, err error) {
 	err = EnsureListPermission(ctx)
 	if err != nil {
@@ -104,6 +141,7 @@ func (s *RoomService) GetParticipant(ctx context.Context, req *livekit.RoomParti
 	return
 }
 
+// RemoveParticipant disconnects a participant from the node it's connected to
 func (s *RoomService) RemoveParticipant(ctx context.Context, req *livekit.RoomParticipantIdentity) (res *livekit.RemoveParticipantResponse, err error) {
 	err = s.writeRoomMessage(ctx, req.Room, req.Identity, &livekit.RTCNodeMessage{
 		Message: &livekit.RTCNodeMessage_RemoveParticipant{
@@ -118,14 +156,19 @@ func (s *RoomService) RemoveParticipant(ctx context.Context, req *livekit.RoomPa
 	return
 }
 
+// MutePublishedTrack mutes or unmutes a published track on behalf of its publisher, which is told
+// about it. Unmuting requires enable_remote_unmute, so that tracks can't be turned on without consent
 func (s *RoomService) MutePublishedTrack(ctx context.Context, req *livekit.MuteRoomTrackRequest) (res *livekit.MuteRoomTrackResponse, err error) {
 	if err = EnsureAdminPermission(ctx, req.Room); err != nil {
 		return nil, twirpAuthError(err)
 	}
+	if !req.Muted && !s.getConfig().Room.EnableRemoteUnmute {
+		return nil, twirp.NewError(twirp.PermissionDenied, ErrRemoteUnmuteDisabled.Error())
+	}
 
 	participant, err := s.roomStore.LoadParticipant(ctx, req.Room, req.Identity)
 	if err != nil {
-		return nil, err
+		return nil, twirpNotFoundError(err)
 	}
 	// find the track
 	track := funk.Find(participant.Tracks, func(t *livekit.TrackInfo) bool {
@@ -184,7 +227,22 @@ func (s *RoomService) UpdateSubscriptions(ctx context.Context, req *livekit.Upda
 	return &livekit.UpdateSubscriptionsResponse{}, nil
 }
 
+// SendData delivers a user data packet to participants of a room, or only the ones listed in
+// DestinationSids. Packets sent through the API have no participant as their source
 func (s *RoomService) SendData(ctx context.Context, req *livekit.SendDataRequest) (*livekit.SendDataResponse, error) {
+	if err := EnsureAdminPermission(ctx, req.Room); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if _, ok := livekit.DataPacket_Kind_name[int32(req.Kind)]; !ok {
+		return nil, twirp.InvalidArgumentError("kind", "unknown data packet kind")
+	}
+	if len(req.Data) > maxSendDataSize {
+		return nil, twirp.InvalidArgumentError("data", ErrDataTooLarge.Error())
+	}
+	if _, err := s.roomStore.LoadRoom(ctx, req.Room); err != nil {
+		return nil, twirpNotFoundError(err)
+	}
+
 	err := s.writeRoomMessage(ctx, req.Room, "", &livekit.RTCNodeMessage{
 		Message: &livekit.RTCNodeMessage_SendData{
 			SendData: req,
@@ -209,6 +267,12 @@ func (s *RoomService) UpdateRoomMetadata(ctx context.Context, req *livekit.Updat
 
 	room.Metadata = req.Metadata
 
+	// the RTC node applies the latest version when it's notified, so that concurrent updates can't
+	// be applied out of order
+	if _, err = s.roomStore.StoreRoomMetadata(ctx, req.Room, req.Metadata); err != nil {
+		return nil, err
+	}
+
 	err = s.writeRoomMessage(ctx, req.Room, "", &livekit.RTCNodeMessage{
 		Message: &livekit.RTCNodeMessage_UpdateRoomMetadata{
 			UpdateRoomMetadata: req,
@@ -228,7 +292,7 @@ func (s *RoomService) writeParticipantMessage(ctx context.Context, room, identit
 
 	_, err := s.roomStore.LoadParticipant(ctx, room, identity)
 	if err != nil {
-		return err
+		return twirpNotFoundError(err)
 	}
 
 	return s.router.WriteParticipantRTC(ctx, room, identity, msg)
@@ -242,7 +306,7 @@ func (s *RoomService) writeRoomMessage(ctx context.Context, room, identity strin
 	if identity != "" {
 		_, err := s.roomStore.LoadParticipant(ctx, room, identity)
 		if err != nil {
-			return err
+			return twirpNotFoundError(err)
 		}
 	}
 
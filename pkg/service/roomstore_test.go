@@ -0,0 +1,67 @@
+package service_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/service/roomstoretest"
+)
+
+// the stores backed by a server are tested when its address is set, e.g.
+// LIVEKIT_TEST_POSTGRES=postgres://postgres@localhost/livekit?sslmode=disable
+func TestRoomStores(t *testing.T) {
+	t.Run("local", func(t *testing.T) {
+		roomstoretest.Run(t, func(t *testing.T) service.RoomStore {
+			return service.NewLocalRoomStore()
+		})
+	})
+
+	t.Run("redis", func(t *testing.T) {
+		address := os.Getenv("LIVEKIT_TEST_REDIS")
+		if address == "" {
+			t.Skip("LIVEKIT_TEST_REDIS isn't set")
+		}
+		roomstoretest.Run(t, func(t *testing.T) service.RoomStore {
+			return service.NewRedisRoomStore(redis.NewClient(&redis.Options{Addr: address}))
+		})
+	})
+
+	t.Run("etcd", func(t *testing.T) {
+		endpoints := os.Getenv("LIVEKIT_TEST_ETCD")
+		if endpoints == "" {
+			t.Skip("LIVEKIT_TEST_ETCD isn't set")
+		}
+		roomstoretest.Run(t, func(t *testing.T) service.RoomStore {
+			s, err := service.NewEtcdRoomStore(&config.EtcdStoreConfig{
+				Endpoints: strings.Split(endpoints, ","),
+				Prefix:    "/livekit-test/",
+			})
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				_ = s.Close()
+			})
+			return s
+		})
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		url := os.Getenv("LIVEKIT_TEST_POSTGRES")
+		if url == "" {
+			t.Skip("LIVEKIT_TEST_POSTGRES isn't set")
+		}
+		roomstoretest.Run(t, func(t *testing.T) service.RoomStore {
+			s, err := service.NewPostgresRoomStore(&config.PostgresStoreConfig{URL: url})
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				_ = s.Close()
+			})
+			return s
+		})
+	})
+}
@@ -0,0 +1,308 @@
+// Package roomstoretest checks that a RoomStore behaves like the stores of this repository, for
+// implementations of the interface to run in their tests
+package roomstoretest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/livekit/protocol/utils"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+// Run runs the suite against the store newStore returns. Stores may be shared between runs, the
+// rooms and actions of each run have names of their own
+func Run(t *testing.T, newStore func(t *testing.T) service.RoomStore) {
+	t.Run("rooms", func(t *testing.T) {
+		testRooms(t, newStore(t))
+	})
+	t.Run("participants", func(t *testing.T) {
+		testParticipants(t, newStore(t))
+	})
+	t.Run("policy and metadata", func(t *testing.T) {
+		testPolicyAndMetadata(t, newStore(t))
+	})
+	t.Run("scheduled actions", func(t *testing.T) {
+		testScheduledActions(t, newStore(t))
+	})
+	t.Run("locks", func(t *testing.T) {
+		testLocks(t, newStore(t))
+	})
+	t.Run("recording rooms", func(t *testing.T) {
+		testRecordingRooms(t, newStore(t))
+	})
+}
+
+func roomName() string {
+	// special characters have to be escaped in keys
+	return "room/" + utils.NewGuid("RM_") + " ü"
+}
+
+func testRooms(t *testing.T, s service.RoomStore) {
+	ctx := context.Background()
+	name := roomName()
+
+	_, err := s.LoadRoom(ctx, name)
+	require.Equal(t, service.ErrRoomNotFound, err)
+
+	room := &livekit.Room{Sid: utils.NewGuid(utils.RoomPrefix), Name: name, MaxParticipants: 5, Metadata: "meta"}
+	require.NoError(t, s.StoreRoom(ctx, room))
+	require.NotZero(t, room.CreationTime)
+
+	loaded, err := s.LoadRoom(ctx, name)
+	require.NoError(t, err)
+	require.Equal(t, room.Sid, loaded.Sid)
+	require.Equal(t, room.MaxParticipants, loaded.MaxParticipants)
+	require.Equal(t, room.CreationTime, loaded.CreationTime)
+
+	// stored again on updates
+	room.Metadata = "updated"
+	require.NoError(t, s.StoreRoom(ctx, room))
+	loaded, err = s.LoadRoom(ctx, name)
+	require.NoError(t, err)
+	require.Equal(t, "updated", loaded.Metadata)
+
+	rooms, err := s.ListRooms(ctx)
+	require.NoError(t, err)
+	require.True(t, containsRoom(rooms, name))
+
+	require.NoError(t, s.DeleteRoom(ctx, name))
+	_, err = s.LoadRoom(ctx, name)
+	require.Equal(t, service.ErrRoomNotFound, err)
+	rooms, err = s.ListRooms(ctx)
+	require.NoError(t, err)
+	require.False(t, containsRoom(rooms, name))
+
+	// deleting a room that doesn't exist isn't an error
+	require.NoError(t, s.DeleteRoom(ctx, name))
+}
+
+func testParticipants(t *testing.T, s service.RoomStore) {
+	ctx := context.Background()
+	name := roomName()
+	require.NoError(t, s.StoreRoom(ctx, &livekit.Room{Name: name}))
+
+	_, err := s.LoadParticipant(ctx, name, "alice")
+	require.Equal(t, service.ErrParticipantNotFound, err)
+	participants, err := s.ListParticipants(ctx, name)
+	require.NoError(t, err)
+	require.Empty(t, participants)
+
+	alice := &livekit.ParticipantInfo{
+		Sid:      "PA_alice",
+		Identity: "alice/ü",
+		State:    livekit.ParticipantInfo_ACTIVE,
+		Tracks:   []*livekit.TrackInfo{{Sid: "TR_audio", Type: livekit.TrackType_AUDIO}},
+	}
+	require.NoError(t, s.StoreParticipant(ctx, name, alice))
+	require.NoError(t, s.StoreParticipant(ctx, name, &livekit.ParticipantInfo{Sid: "PA_bob", Identity: "bob"}))
+	// participants of other rooms aren't listed
+	other := roomName()
+	require.NoError(t, s.StoreParticipant(ctx, other, &livekit.ParticipantInfo{Sid: "PA_carol", Identity: "carol"}))
+	defer s.DeleteParticipant(ctx, other, "carol")
+
+	loaded, err := s.LoadParticipant(ctx, name, alice.Identity)
+	require.NoError(t, err)
+	require.Equal(t, alice.Sid, loaded.Sid)
+	require.Len(t, loaded.Tracks, 1)
+	require.Equal(t, "TR_audio", loaded.Tracks[0].Sid)
+
+	participants, err = s.ListParticipants(ctx, name)
+	require.NoError(t, err)
+	require.Len(t, participants, 2)
+
+	require.NoError(t, s.DeleteParticipant(ctx, name, "bob"))
+	_, err = s.LoadParticipant(ctx, name, "bob")
+	require.Equal(t, service.ErrParticipantNotFound, err)
+	// already deleted
+	require.NoError(t, s.DeleteParticipant(ctx, name, "bob"))
+
+	// deleting the room deletes its participants
+	require.NoError(t, s.DeleteRoom(ctx, name))
+	participants, err = s.ListParticipants(ctx, name)
+	require.NoError(t, err)
+	require.Empty(t, participants)
+	participants, err = s.ListParticipants(ctx, other)
+	require.NoError(t, err)
+	require.Len(t, participants, 1)
+}
+
+func testPolicyAndMetadata(t *testing.T, s service.RoomStore) {
+	ctx := context.Background()
+	name := roomName()
+	require.NoError(t, s.StoreRoom(ctx, &livekit.Room{Name: name}))
+
+	policy, err := s.LoadRoomPolicy(ctx, name)
+	require.NoError(t, err)
+	require.Nil(t, policy)
+
+	require.NoError(t, s.StoreRoomPolicy(ctx, name, &config.RoomPolicy{MaxPublishBitrate: 500_000}))
+	policy, err = s.LoadRoomPolicy(ctx, name)
+	require.NoError(t, err)
+	require.NotNil(t, policy)
+	require.Equal(t, uint64(500_000), policy.MaxPublishBitrate)
+
+	metadata, version, err := s.LoadRoomMetadata(ctx, name)
+	require.NoError(t, err)
+	require.Equal(t, "", metadata)
+	require.Equal(t, uint64(0), version)
+
+	version, err = s.StoreRoomMetadata(ctx, name, "first")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), version)
+	version, err = s.StoreRoomMetadata(ctx, name, "second")
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), version)
+
+	metadata, version, err = s.LoadRoomMetadata(ctx, name)
+	require.NoError(t, err)
+	require.Equal(t, "second", metadata)
+	require.Equal(t, uint64(2), version)
+
+	// deleting the room deletes both, versions start over
+	require.NoError(t, s.DeleteRoom(ctx, name))
+	policy, err = s.LoadRoomPolicy(ctx, name)
+	require.NoError(t, err)
+	require.Nil(t, policy)
+	_, version, err = s.LoadRoomMetadata(ctx, name)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), version)
+
+	require.NoError(t, s.StoreRoom(ctx, &livekit.Room{Name: name}))
+	defer s.DeleteRoom(ctx, name)
+	version, err = s.StoreRoomMetadata(ctx, name, "again")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), version)
+}
+
+func testScheduledActions(t *testing.T, s service.RoomStore) {
+	ctx := context.Background()
+	name := roomName()
+	now := time.Now()
+
+	due := &service.ScheduledAction{ID: utils.NewGuid("SA_"), Room: name, Action: "end_room", At: now.Add(-time.Minute)}
+	later := &service.ScheduledAction{ID: utils.NewGuid("SA_"), Room: name, Action: "end_room", At: now.Add(time.Hour)}
+	otherRoom := &service.ScheduledAction{ID: utils.NewGuid("SA_"), Room: roomName(), Action: "end_room", At: now.Add(time.Hour)}
+	for _, a := range []*service.ScheduledAction{due, later, otherRoom} {
+		require.NoError(t, s.StoreScheduledAction(ctx, a))
+	}
+	defer s.DeleteScheduledAction(ctx, otherRoom.ID)
+
+	actions, err := s.ListScheduledActions(ctx, name)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{due.ID, later.ID}, actionIDs(actions))
+
+	actions, err = s.ListDueScheduledActions(ctx, now)
+	require.NoError(t, err)
+	ids := actionIDs(actions)
+	require.Contains(t, ids, due.ID)
+	require.NotContains(t, ids, later.ID)
+	for _, a := range actions {
+		if a.ID == due.ID {
+			require.Equal(t, "end_room", a.Action)
+			// databases may store times with less precision
+			require.WithinDuration(t, due.At, a.At, time.Millisecond)
+		}
+	}
+
+	deleted, err := s.DeleteScheduledAction(ctx, due.ID)
+	require.NoError(t, err)
+	require.True(t, deleted)
+	deleted, err = s.DeleteScheduledAction(ctx, due.ID)
+	require.NoError(t, err)
+	require.False(t, deleted)
+
+	actions, err = s.ListDueScheduledActions(ctx, now)
+	require.NoError(t, err)
+	require.NotContains(t, actionIDs(actions), due.ID)
+
+	deleted, err = s.DeleteScheduledAction(ctx, later.ID)
+	require.NoError(t, err)
+	require.True(t, deleted)
+	actions, err = s.ListScheduledActions(ctx, name)
+	require.NoError(t, err)
+	require.Empty(t, actions)
+}
+
+func testLocks(t *testing.T, s service.RoomStore) {
+	ctx := context.Background()
+	name := roomName()
+
+	token, err := s.LockRoom(ctx, name, 5*time.Second)
+	require.NoError(t, err)
+
+	// waits for the lock to be released
+	type lockResult struct {
+		token string
+		err   error
+	}
+	locked := make(chan lockResult, 1)
+	go func() {
+		token, err := s.LockRoom(ctx, name, 5*time.Second)
+		locked <- lockResult{token, err}
+	}()
+	select {
+	case <-locked:
+		require.Fail(t, "room locked twice")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	require.NoError(t, s.UnlockRoom(ctx, name, token))
+	select {
+	case res := <-locked:
+		require.NoError(t, res.err)
+		require.NoError(t, s.UnlockRoom(ctx, name, res.token))
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "room wasn't locked after it was unlocked")
+	}
+}
+
+func testRecordingRooms(t *testing.T, s service.RoomStore) {
+	ctx := context.Background()
+	name := roomName()
+	id := utils.NewGuid("RC_")
+
+	_, err := s.LoadRecordingRoom(ctx, id)
+	require.Equal(t, service.ErrRecordingNotFound, err)
+
+	require.NoError(t, s.StoreRecordingRoom(ctx, id, name))
+	roomName, err := s.LoadRecordingRoom(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, name, roomName)
+
+	// recordings that aren't for a known room
+	other := utils.NewGuid("RC_")
+	require.NoError(t, s.StoreRecordingRoom(ctx, other, ""))
+	roomName, err = s.LoadRecordingRoom(ctx, other)
+	require.NoError(t, err)
+	require.Equal(t, "", roomName)
+	require.NoError(t, s.DeleteRecordingRoom(ctx, other))
+
+	require.NoError(t, s.DeleteRecordingRoom(ctx, id))
+	_, err = s.LoadRecordingRoom(ctx, id)
+	require.Equal(t, service.ErrRecordingNotFound, err)
+	// deleting again is fine
+	require.NoError(t, s.DeleteRecordingRoom(ctx, id))
+}
+
+func containsRoom(rooms []*livekit.Room, name string) bool {
+	for _, r := range rooms {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func actionIDs(actions []*service.ScheduledAction) []string {
+	ids := make([]string, 0, len(actions))
+	for _, a := range actions {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
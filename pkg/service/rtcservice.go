@@ -1,14 +1,18 @@
 package service
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/logger"
 	livekit "github.com/livekit/protocol/proto"
 
@@ -27,16 +31,44 @@ type RTCService struct {
 	currentNode   routing.LocalNode
 	isDev         bool
 	limits        config.LimitConfig
+	config        *config.Config
+
+	// carries out the unpublish and join approval requests of participants in rooms hosted on this
+	// node
+	roomManager *RoomManager
+	// signs the refreshed tokens of participants
+	keyProvider auth.KeyProvider
+}
+
+// ProbeNode is a node that a client can measure its RTT to before joining
+type ProbeNode struct {
+	ID      string `json:"id"`
+	Region  string `json:"region,omitempty"`
+	PingURL string `json:"ping_url"`
+}
+
+type ProbeNodesResponse struct {
+	Nodes []*ProbeNode `json:"nodes"`
 }
 
-func NewRTCService(conf *config.Config, ra RoomAllocator, router routing.MessageRouter, currentNode routing.LocalNode) *RTCService {
+func NewRTCService(
+	conf *config.Config,
+	ra RoomAllocator,
+	router routing.MessageRouter,
+	currentNode routing.LocalNode,
+	roomManager *RoomManager,
+	keyProvider auth.KeyProvider,
+) *RTCService {
 	s := &RTCService{
 		router:        router,
 		roomAllocator: ra,
+		roomManager:   roomManager,
+		keyProvider:   keyProvider,
 		upgrader:      websocket.Upgrader{},
 		currentNode:   currentNode,
 		isDev:         conf.Development,
 		limits:        conf.Limit,
+		config:        conf,
 	}
 
 	// allow connections from any origin, since script may be hosted anywhere
@@ -57,6 +89,54 @@ func (s *RTCService) Validate(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte("success"))
 }
 
+// Nodes lists the nodes that the client can probe before joining, when RTT probes are enabled.
+// The client reports the RTTs it measured when joining, in the node_rtts parameter. The list is
+// empty when the room is already hosted on a node
+func (s *RTCService) Nodes(w http.ResponseWriter, r *http.Request) {
+	if !s.config.NodeSelector.ProbeRTT {
+		handleError(w, http.StatusNotFound, "RTT probes are not enabled")
+		return
+	}
+	roomName, _, code, err := s.validate(r)
+	if err != nil {
+		handleError(w, code, err.Error())
+		return
+	}
+
+	res := &ProbeNodesResponse{Nodes: make([]*ProbeNode, 0)}
+	router, ok := s.router.(routing.Router)
+	if !ok {
+		writeJSON(w, res)
+		return
+	}
+	if node, err := router.GetNodeForRoom(r.Context(), roomName); err == nil && selector.IsAvailable(node) {
+		writeJSON(w, res)
+		return
+	}
+
+	nodes, err := router.ListNodes()
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// full nodes won't host the room, no need to probe them
+	for _, node := range selector.FilterWithinLimits(s.limits, selector.GetAvailableNodes(nodes)) {
+		res.Nodes = append(res.Nodes, &ProbeNode{
+			ID:      node.Id,
+			Region:  node.Region,
+			PingURL: s.config.NodePingURL(node.Id, node.Ip, node.Region),
+		})
+	}
+	writeJSON(w, res)
+}
+
+// Ping is probed by clients to measure their RTT to this node
+func (s *RTCService) Ping(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write([]byte("pong"))
+}
+
 func (s *RTCService) validate(r *http.Request) (string, routing.ParticipantInit, int, error) {
 	claims := GetGrants(r.Context())
 	// require a claim
@@ -72,6 +152,11 @@ func (s *RTCService) validate(r *http.Request) (string, routing.ParticipantInit,
 	roomName := r.FormValue("room")
 	reconnectParam := r.FormValue("reconnect")
 	autoSubParam := r.FormValue("auto_subscribe")
+	if autoSubParam == "" {
+		// as some clients name it
+		autoSubParam = r.FormValue("autoSubscribe")
+	}
+	capabilitiesParam := r.FormValue("capabilities")
 
 	if onlyName != "" {
 		roomName = onlyName
@@ -79,8 +164,8 @@ func (s *RTCService) validate(r *http.Request) (string, routing.ParticipantInit,
 
 	if router, ok := s.router.(routing.Router); ok {
 		if foundNode, err := router.GetNodeForRoom(r.Context(), roomName); err == nil {
-			if selector.LimitsReached(s.limits, foundNode.Stats) {
-				return "", routing.ParticipantInit{}, http.StatusServiceUnavailable, rtc.ErrLimitExceeded
+			if exceeded := selector.ExceededLimits(s.limits, foundNode.Stats); len(exceeded) != 0 {
+				return "", routing.ParticipantInit{}, http.StatusServiceUnavailable, nodeLimitError(exceeded)
 			}
 		}
 	}
@@ -92,15 +177,63 @@ func (s *RTCService) validate(r *http.Request) (string, routing.ParticipantInit,
 		Metadata:      claims.Metadata,
 		Hidden:        claims.Video.Hidden,
 		Client:        s.parseClientInfo(r.Form),
+		Capabilities:  capabilitiesParam,
+		SignalOnly:    boolValue(r.FormValue("signal_only")),
+		Name:          GetParticipantName(r.Context()),
+	}
+	if err := rtc.ValidateParticipantName(pi.Name); err != nil {
+		return "", routing.ParticipantInit{}, http.StatusUnauthorized, err
+	}
+	scopes := GetScopeGrants(r.Context())
+	if scopes != nil && scopes.AutoSubscribe != nil {
+		pi.AutoSubscribe = *scopes.AutoSubscribe
 	}
 	if autoSubParam != "" {
-		pi.AutoSubscribe = boolValue(autoSubParam)
+		if pi.AutoSubscribe, err = strconv.ParseBool(autoSubParam); err != nil {
+			return "", routing.ParticipantInit{}, http.StatusBadRequest, ErrInvalidAutoSubscribe
+		}
+	}
+	lowPowerMode, ok := rtc.ParseLowPowerMode(r.FormValue("low_power"))
+	if !ok {
+		return "", routing.ParticipantInit{}, http.StatusBadRequest, ErrInvalidLowPowerMode
+	}
+	pi.LowPowerMode = lowPowerMode
+	kind, ok := rtc.ParseParticipantKind(r.FormValue("kind"), claims.Video.Hidden)
+	if !ok {
+		return "", routing.ParticipantInit{}, http.StatusBadRequest, ErrInvalidParticipantKind
+	}
+	pi.Kind = kind
+	// responses are only kept for clients that ack them
+	pi.SequenceSignal = boolValue(r.FormValue("signal_seq")) ||
+		types.NegotiateCapabilities(types.ProtocolVersion(pi.Client.Protocol), pi.Capabilities).Resume
+	if pi.Reconnect && r.FormValue("last_signal_seq") != "" {
+		seq, err := strconv.ParseUint(r.FormValue("last_signal_seq"), 10, 32)
+		if err != nil {
+			return "", routing.ParticipantInit{}, http.StatusBadRequest, ErrInvalidSignalSeq
+		}
+		lastSeq := uint32(seq)
+		pi.LastSignalSeq = &lastSeq
 	}
 	pi.Permission = permissionFromGrant(claims.Video)
+	if scopes != nil {
+		sources, ok := rtc.ParsePublishSources(scopes.CanPublishSources)
+		if !ok {
+			return "", routing.ParticipantInit{}, http.StatusUnauthorized, ErrInvalidPublishSources
+		}
+		pi.PublishSources = sources
+		pi.CanApproveJoins = scopes.CanApproveJoins
+		pi.CanSubscribeTo = scopes.CanSubscribeTo
+	}
 
 	return roomName, pi, http.StatusOK, nil
 }
 
+// signalClient is the connection a participant signals over, a websocket or a WebTransport stream
+type signalClient interface {
+	types.WebsocketClient
+	Close() error
+}
+
 func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// reject non websocket requests
 	if !websocket.IsWebSocketUpgrade(r) {
@@ -109,6 +242,19 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.serveSignal(w, r, "signal_ws", func() (signalClient, error) {
+		conn, err := s.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return nil, err
+		}
+		return conn, nil
+	})
+}
+
+// serveSignal starts the session of a participant, and relays its signal messages. upgrade takes
+// over the request once it's valid, returning the connection the participant signals over.
+// operation labels the metrics of the transport
+func (s *RTCService) serveSignal(w http.ResponseWriter, r *http.Request, operation string, upgrade func() (signalClient, error)) {
 	roomName, pi, code, err := s.validate(r)
 	if err != nil {
 		handleError(w, code, err.Error())
@@ -116,17 +262,30 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// create room if it doesn't exist, also assigns an RTC node for the room
-	rm, err := s.roomAllocator.CreateRoom(r.Context(), &livekit.CreateRoomRequest{Name: roomName})
-	if err != nil {
-		prometheus.ServiceOperationCounter.WithLabelValues("signal_ws", "error", "create_room").Add(1)
+	ctx := r.Context()
+	if s.config.NodeSelector.ProbeRTT {
+		ctx = WithNodeRTTs(ctx, parseNodeRTTs(r.FormValue("node_rtts")))
+	}
+	rm, err := s.roomAllocator.CreateRoom(ctx, &livekit.CreateRoomRequest{Name: roomName})
+	if errors.Is(err, routing.ErrNodeLimitReached) {
+		prometheus.ServiceOperationCounter.WithLabelValues(operation, "error", "node_limit").Add(1)
+		handleError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	} else if err != nil {
+		prometheus.ServiceOperationCounter.WithLabelValues(operation, "error", "create_room").Add(1)
 		handleError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	// hidden participants don't wait, nobody would know they're there
+	if !pi.Reconnect && !pi.CanApproveJoins && !pi.Hidden && !rtc.IsHiddenKind(pi.Kind) {
+		pi.AwaitApproval = s.roomManager.waitingRoomEnabled(ctx, roomName)
+	}
+
 	// this needs to be started first *before* using router functions on this node
 	connId, reqSink, resSource, err := s.router.StartParticipantSignal(r.Context(), roomName, pi)
 	if err != nil {
-		prometheus.ServiceOperationCounter.WithLabelValues("signal_ws", "error", "start_signal").Add(1)
+		prometheus.ServiceOperationCounter.WithLabelValues(operation, "error", "start_signal").Add(1)
 		handleError(w, http.StatusInternalServerError, "could not start session: "+err.Error())
 		return
 	}
@@ -134,26 +293,106 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	done := make(chan struct{})
 	// function exits when websocket terminates, it'll close the event reading off of response sink as well
 	defer func() {
-		logger.Infow("server closing WS connection", "participant", pi.Identity, "connID", connId)
+		logger.Infow("server closing signal connection", "participant", pi.Identity, "connID", connId,
+			"transport", operation)
 		reqSink.Close()
 		close(done)
 	}()
 
 	// upgrade only once the basics are good to go
-	conn, err := s.upgrader.Upgrade(w, r, nil)
+	conn, err := upgrade()
 	if err != nil {
-		prometheus.ServiceOperationCounter.WithLabelValues("signal_ws", "error", "upgrade").Add(1)
-		logger.Warnw("could not upgrade to WS", err)
+		prometheus.ServiceOperationCounter.WithLabelValues(operation, "error", "upgrade").Add(1)
+		logger.Warnw("could not upgrade signal connection", err, "transport", operation)
 		handleError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	sigConn := NewWSSignalConnection(conn)
-	if types.ProtocolVersion(pi.Client.Protocol).SupportsProtobuf() {
+	// text requests can be handled here, without reaching the node hosting the room
+	sigConn.LimitRate(rtc.NewSignalRateLimiter(s.config.RTC.SignalRateLimit))
+	capabilities := types.NegotiateCapabilities(types.ProtocolVersion(pi.Client.Protocol), pi.Capabilities)
+	if capabilities.Protobuf {
 		sigConn.useJSON = false
 	}
+	sigConn.OnTextRequest(textKeyModerate, func(value json.RawMessage) (*livekit.SignalRequest, error) {
+		req := &ModerationRequest{}
+		if err := decodeTextRequest(value, req); err != nil {
+			return nil, err
+		}
+		res := &ModerationResponse{RequestID: req.RequestID}
+		if err := s.moderate(ctx, roomName, req); err != nil {
+			logger.Infow("moderation request rejected", "participant", pi.Identity, "room", roomName,
+				"action", req.Action, "target", req.Identity, "error", err)
+			res.Error = err.Error()
+		} else {
+			logger.Infow("moderation request accepted", "participant", pi.Identity, "room", roomName,
+				"action", req.Action, "target", req.Identity, "track", req.TrackSid)
+		}
+		if err := sigConn.WriteTextMessage(textResponseKey(textKeyModerate), res); err != nil {
+			logger.Warnw("error writing to websocket", err)
+		}
+		return nil, nil
+	})
+	sigConn.OnTextRequest(textKeyUnpublish, forwardTextRequest(textKeyUnpublish))
+	sigConn.OnTextRequest(textKeyApproveJoin, func(value json.RawMessage) (*livekit.SignalRequest, error) {
+		req := &JoinApprovalRequest{}
+		if err := decodeTextRequest(value, req); err != nil {
+			return nil, err
+		}
+		// checked by the node hosting the room too, it resolves the join
+		if err := ensureJoinApproval(ctx, req); err != nil {
+			logger.Infow("join approval request rejected", "participant", pi.Identity, "room", roomName,
+				"target", req.Identity, "error", err)
+			res := &JoinApprovalResponse{RequestID: req.RequestID, Error: err.Error()}
+			if err := sigConn.WriteTextMessage(textResponseKey(textKeyApproveJoin), res); err != nil {
+				logger.Warnw("error writing to websocket", err)
+			}
+			return nil, nil
+		}
+		return rtc.NewSignalTextRequest(textKeyApproveJoin, value), nil
+	})
+	sigConn.OnTextRequest(textKeyUpdateAttributes, func(value json.RawMessage) (*livekit.SignalRequest, error) {
+		req := &UpdateAttributesRequest{}
+		if err := decodeTextRequest(value, req); err != nil {
+			return nil, err
+		}
+		// the token's grants are only known here, the node hosting the room sets them
+		if err := EnsureUpdateAttributesPermission(ctx, req.Attributes); err != nil {
+			logger.Infow("attributes update rejected", "participant", pi.Identity, "room", roomName,
+				"error", err)
+			res := &UpdateAttributesResponse{RequestID: req.RequestID, Error: err.Error()}
+			if err := sigConn.WriteTextMessage(textResponseKey(textKeyUpdateAttributes), res); err != nil {
+				logger.Warnw("error writing to websocket", err)
+			}
+			return nil, nil
+		}
+		return rtc.NewSignalTextRequest(textKeyUpdateAttributes, value), nil
+	})
+	sigConn.OnTextRequest(textKeyUpdateTrackMetadata, forwardTextRequest(textKeyUpdateTrackMetadata))
+	sigConn.OnTextRequest(textKeySignalAck, forwardTextRequest(textKeySignalAck))
+	if pi.SequenceSignal {
+		sigConn.SequenceResponses()
+	}
+	if pi.Capabilities != "" {
+		// clients that don't declare capabilities wouldn't know the message
+		if err := sigConn.WriteTextMessage(textKeyCapabilities, &CapabilitiesInfo{Enabled: capabilities.Names()}); err != nil {
+			logger.Warnw("error writing to websocket", err)
+		}
+	}
+	if pi.AwaitApproval {
+		// written before responses are, the join response is only sent once the join is approved
+		if err := sigConn.WriteTextMessage(textKeyWaiting, &Waiting{Room: roomName}); err != nil {
+			logger.Warnw("error writing to websocket", err)
+		}
+	}
 
-	prometheus.ServiceOperationCounter.WithLabelValues("signal_ws", "success", "").Add(1)
-	logger.Infow("new client WS connected",
+	if s.config.TokenRefresh.Before > 0 {
+		go refreshTokens(&s.config.TokenRefresh, s.keyProvider, sigConn, GetAccessToken(r.Context()), pi.Identity, done)
+	}
+
+	prometheus.ServiceOperationCounter.WithLabelValues(operation, "success", "").Add(1)
+	logger.Infow("new client signal connected",
+		"transport", operation,
 		"connID", connId,
 		"roomID", rm.Sid,
 		"room", rm.Name,
@@ -204,6 +443,13 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			if err == io.EOF || strings.HasSuffix(err.Error(), "use of closed network connection") ||
 				websocket.IsCloseError(err, websocket.CloseAbnormalClosure, websocket.CloseGoingAway, websocket.CloseNormalClosure, websocket.CloseNoStatusReceived) {
 				return
+			} else if errors.Is(err, ErrSignalRateLimitExceeded) {
+				logger.Warnw("disconnecting participant, too many signal requests over the rate limit", nil,
+					"room", roomName,
+					"participant", pi.Identity,
+					"connID", connId,
+				)
+				return
 			} else {
 				logger.Errorw("error reading from websocket", err)
 				return
@@ -238,3 +484,21 @@ func (s *RTCService) parseClientInfo(values url.Values) *livekit.ClientInfo {
 	ci.Version = values.Get("version")
 	return ci
 }
+
+// parseNodeRTTs parses RTTs reported by clients, a list of node ID and RTT in ms, like
+// ND_1:25,ND_2:80. Invalid entries are ignored
+func parseNodeRTTs(value string) map[string]time.Duration {
+	rtts := make(map[string]time.Duration)
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			continue
+		}
+		ms, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || ms < 0 {
+			continue
+		}
+		rtts[parts[0]] = time.Duration(ms * float64(time.Millisecond))
+	}
+	return rtts
+}
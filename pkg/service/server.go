@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"net"
 	"net/http"
-	"runtime/pprof"
 	"time"
 
 	"github.com/livekit/protocol/auth"
@@ -17,9 +16,12 @@ import (
 	"github.com/pion/turn/v2"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/urfave/negroni"
+	"google.golang.org/grpc"
 
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 	"github.com/livekit/livekit-server/version"
 )
 
@@ -31,20 +33,52 @@ type LivekitServer struct {
 	promServer  *http.Server
 	router      routing.Router
 	roomManager *RoomManager
+	reloader    *ConfigReloader
+	keyRotator  *KeyRotator
+	scheduler   *RoomScheduler
+	trackStats  *telemetry.TrackStatsWorker
+	audit       *telemetry.SubscriptionAuditWorker
+	analytics   telemetry.AnalyticsService
+	capacity    *CapacityMonitor
+	degradation *DegradationMonitor
+	rawDumps    *RawDumpJanitor
+	agents      *AgentDispatcher
+	diagnostics *DiagnosticsServer
 	turnServer  *turn.Server
 	currentNode routing.LocalNode
 	running     utils.AtomicFlag
 	doneChan    chan struct{}
 	closedChan  chan struct{}
+
+	// nil when gRPC is disabled
+	grpcServer *grpc.Server
+	// nil when WebTransport is disabled
+	webTransport *WebTransportServer
+	// nil when the event bus is disabled
+	eventPublisher telemetry.EventPublisher
 }
 
 func NewLivekitServer(conf *config.Config,
 	roomService livekit.RoomService,
 	recService *RecordingService,
 	rtcService *RTCService,
+	adminService *AdminService,
+	reloader *ConfigReloader,
+	keyRotator *KeyRotator,
+	scheduler *RoomScheduler,
 	keyProvider auth.KeyProvider,
 	router routing.Router,
 	roomManager *RoomManager,
+	trackStats *telemetry.TrackStatsWorker,
+	audit *telemetry.SubscriptionAuditWorker,
+	analytics telemetry.AnalyticsService,
+	eventPublisher telemetry.EventPublisher,
+	capacity *CapacityMonitor,
+	degradation *DegradationMonitor,
+	rawDumps *RawDumpJanitor,
+	agents *AgentDispatcher,
+	webTransport *WebTransportServer,
+	diagnostics *DiagnosticsServer,
 	turnServer *turn.Server,
 	currentNode routing.LocalNode,
 ) (s *LivekitServer, err error) {
@@ -54,10 +88,24 @@ func NewLivekitServer(conf *config.Config,
 		rtcService:  rtcService,
 		router:      router,
 		roomManager: roomManager,
+		reloader:    reloader,
+		keyRotator:  keyRotator,
+		scheduler:   scheduler,
+		trackStats:  trackStats,
+		audit:       audit,
+		analytics:   analytics,
+		capacity:    capacity,
+		degradation: degradation,
+		rawDumps:    rawDumps,
+		agents:      agents,
+		diagnostics: diagnostics,
 		// turn server starts automatically
 		turnServer:  turnServer,
 		currentNode: currentNode,
 		closedChan:  make(chan struct{}),
+
+		webTransport:   webTransport,
+		eventPublisher: eventPublisher,
 	}
 
 	middlewares := []negroni.Handler{
@@ -76,10 +124,17 @@ func NewLivekitServer(conf *config.Config,
 	mux.Handle(recServer.PathPrefix(), recServer)
 	mux.Handle("/rtc", rtcService)
 	mux.HandleFunc("/rtc/validate", rtcService.Validate)
+	mux.HandleFunc("/rtc/nodes", rtcService.Nodes)
+	mux.HandleFunc("/rtc/ping", rtcService.Ping)
+	if agents != nil {
+		mux.Handle("/agent", agents)
+	}
+	adminService.SetupRoutes(mux)
 	mux.HandleFunc("/", s.healthCheck)
 	if conf.Development {
-		mux.HandleFunc("/debug/goroutine", s.debugGoroutines)
+		mux.HandleFunc("/debug/goroutine", writeGoroutines)
 		mux.HandleFunc("/debug/rooms", s.debugInfo)
+		mux.HandleFunc("/debug/downtracks", s.debugDownTracks)
 	}
 
 	s.httpServer = &http.Server{
@@ -87,7 +142,12 @@ func NewLivekitServer(conf *config.Config,
 		Handler: configureMiddlewares(mux, middlewares...),
 	}
 
+	if conf.GRPCPort > 0 {
+		s.grpcServer = NewGRPCServer(roomService, roomManager, keyProvider)
+	}
+
 	if conf.PrometheusPort > 0 {
+		prometheus.SetLabelLimits(conf.Metrics.RoomLabelLimit, conf.Metrics.ParticipantLabelLimit)
 		s.promServer = &http.Server{
 			Addr:    fmt.Sprintf(":%d", conf.PrometheusPort),
 			Handler: promhttp.Handler(),
@@ -109,6 +169,16 @@ func (s *LivekitServer) Node() *livekit.Node {
 	return s.currentNode
 }
 
+// SetConfigLoader sets where the config is loaded from when it's reloaded
+func (s *LivekitServer) SetConfigLoader(loader ConfigLoader) {
+	s.reloader.SetLoader(loader)
+}
+
+// ReloadConfig loads the config again, and applies the fields that can be changed while running
+func (s *LivekitServer) ReloadConfig() (*ConfigReloadResult, error) {
+	return s.reloader.Reload()
+}
+
 func (s *LivekitServer) IsRunning() bool {
 	return s.running.Get()
 }
@@ -150,6 +220,30 @@ func (s *LivekitServer) Start() error {
 		}()
 	}
 
+	if s.webTransport != nil {
+		if err := s.webTransport.Start(); err != nil {
+			return err
+		}
+	}
+
+	if s.diagnostics != nil {
+		if err := s.diagnostics.Start(); err != nil {
+			return err
+		}
+	}
+
+	if s.grpcServer != nil {
+		grpcLn, err := net.Listen("tcp", fmt.Sprintf(":%d", s.config.GRPCPort))
+		if err != nil {
+			return err
+		}
+		go func() {
+			if err := s.grpcServer.Serve(grpcLn); err != nil {
+				logger.Errorw("could not serve gRPC", err)
+			}
+		}()
+	}
+
 	go func() {
 		values := []interface{}{
 			"addr", s.httpServer.Addr,
@@ -170,9 +264,21 @@ func (s *LivekitServer) Start() error {
 		if s.config.PrometheusPort != 0 {
 			values = append(values, "portPrometheus", s.config.PrometheusPort)
 		}
+		if s.config.GRPCPort != 0 {
+			values = append(values, "portGRPC", s.config.GRPCPort)
+		}
+		if s.config.WebTransport.Port != 0 {
+			values = append(values, "portWebTransport", s.config.WebTransport.Port)
+		}
+		if s.config.Diagnostics.Port != 0 {
+			values = append(values, "portDiagnostics", s.config.Diagnostics.Port)
+		}
 		if s.config.Region != "" {
 			values = append(values, "region", s.config.Region)
 		}
+		if s.config.IsSignalRelay() {
+			values = append(values, "role", s.config.Role)
+		}
 		logger.Infow("starting LiveKit server", values...)
 		if err := s.httpServer.Serve(ln); err != http.ErrServerClosed {
 			logger.Errorw("could not start server", err)
@@ -181,6 +287,25 @@ func (s *LivekitServer) Start() error {
 	}()
 
 	go s.backgroundWorker()
+	s.scheduler.Start()
+	if s.trackStats != nil {
+		s.trackStats.Start()
+	}
+	if s.audit != nil {
+		s.audit.Start()
+	}
+	if s.capacity != nil {
+		s.capacity.Start()
+	}
+	if s.degradation != nil {
+		s.degradation.Start()
+	}
+	if s.rawDumps != nil {
+		s.rawDumps.Start()
+	}
+	if s.keyRotator != nil {
+		s.keyRotator.Start()
+	}
 
 	// give time for Serve goroutine to start
 	time.Sleep(10 * time.Millisecond)
@@ -193,13 +318,54 @@ func (s *LivekitServer) Start() error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 	_ = s.httpServer.Shutdown(ctx)
+	if s.webTransport != nil {
+		s.webTransport.Stop()
+	}
+	if s.diagnostics != nil {
+		s.diagnostics.Stop()
+	}
+	if s.grpcServer != nil {
+		// streams of room events don't end on their own
+		s.grpcServer.Stop()
+	}
 
 	if s.turnServer != nil {
 		_ = s.turnServer.Close()
 	}
 
+	// export what was collected before participants are disconnected
+	if s.trackStats != nil {
+		s.trackStats.Stop()
+	}
+	s.scheduler.Stop()
+	if s.capacity != nil {
+		s.capacity.Stop()
+	}
+	if s.degradation != nil {
+		s.degradation.Stop()
+	}
+	if s.rawDumps != nil {
+		s.rawDumps.Stop()
+	}
+	if s.keyRotator != nil {
+		s.keyRotator.Stop()
+	}
+	if s.agents != nil {
+		s.agents.Stop()
+	}
 	s.roomManager.Stop()
 	s.recService.Stop()
+	// after participants are disconnected, so that their subscriptions ending are recorded
+	if s.audit != nil {
+		s.audit.Stop()
+	}
+	// last, events of the rooms closing and the audit export go through the event bus
+	s.analytics.Stop()
+	if s.eventPublisher != nil {
+		if err := s.eventPublisher.Close(); err != nil {
+			logger.Warnw("could not close event publisher", err)
+		}
+	}
 
 	close(s.closedChan)
 	return nil
@@ -234,19 +400,8 @@ func (s *LivekitServer) RoomManager() *RoomManager {
 	return s.roomManager
 }
 
-func (s *LivekitServer) debugGoroutines(w http.ResponseWriter, _ *http.Request) {
-	_ = pprof.Lookup("goroutine").WriteTo(w, 2)
-}
-
 func (s *LivekitServer) debugInfo(w http.ResponseWriter, _ *http.Request) {
-	s.roomManager.lock.RLock()
-	info := make([]map[string]interface{}, 0, len(s.roomManager.rooms))
-	for _, room := range s.roomManager.rooms {
-		info = append(info, room.DebugInfo())
-	}
-	s.roomManager.lock.RUnlock()
-
-	b, err := json.Marshal(info)
+	b, err := json.Marshal(s.roomManager.RoomsDebugInfo(""))
 	if err != nil {
 		w.WriteHeader(400)
 		_, _ = w.Write([]byte(err.Error()))
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/service"
 	livekit "github.com/livekit/protocol/proto"
 )
@@ -24,6 +25,18 @@ type FakeRoomStore struct {
 	deleteParticipantReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DeleteRecordingRoomStub        func(context.Context, string) error
+	deleteRecordingRoomMutex       sync.RWMutex
+	deleteRecordingRoomArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	deleteRecordingRoomReturns struct {
+		result1 error
+	}
+	deleteRecordingRoomReturnsOnCall map[int]struct {
+		result1 error
+	}
 	DeleteRoomStub        func(context.Context, string) error
 	deleteRoomMutex       sync.RWMutex
 	deleteRoomArgsForCall []struct {
@@ -36,6 +49,34 @@ type FakeRoomStore struct {
 	deleteRoomReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DeleteScheduledActionStub        func(context.Context, string) (bool, error)
+	deleteScheduledActionMutex       sync.RWMutex
+	deleteScheduledActionArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	deleteScheduledActionReturns struct {
+		result1 bool
+		result2 error
+	}
+	deleteScheduledActionReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	ListDueScheduledActionsStub        func(context.Context, time.Time) ([]*service.ScheduledAction, error)
+	listDueScheduledActionsMutex       sync.RWMutex
+	listDueScheduledActionsArgsForCall []struct {
+		arg1 context.Context
+		arg2 time.Time
+	}
+	listDueScheduledActionsReturns struct {
+		result1 []*service.ScheduledAction
+		result2 error
+	}
+	listDueScheduledActionsReturnsOnCall map[int]struct {
+		result1 []*service.ScheduledAction
+		result2 error
+	}
 	ListParticipantsStub        func(context.Context, string) ([]*livekit.ParticipantInfo, error)
 	listParticipantsMutex       sync.RWMutex
 	listParticipantsArgsForCall []struct {
@@ -63,6 +104,20 @@ type FakeRoomStore struct {
 		result1 []*livekit.Room
 		result2 error
 	}
+	ListScheduledActionsStub        func(context.Context, string) ([]*service.ScheduledAction, error)
+	listScheduledActionsMutex       sync.RWMutex
+	listScheduledActionsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	listScheduledActionsReturns struct {
+		result1 []*service.ScheduledAction
+		result2 error
+	}
+	listScheduledActionsReturnsOnCall map[int]struct {
+		result1 []*service.ScheduledAction
+		result2 error
+	}
 	LoadParticipantStub        func(context.Context, string, string) (*livekit.ParticipantInfo, error)
 	loadParticipantMutex       sync.RWMutex
 	loadParticipantArgsForCall []struct {
@@ -78,6 +133,20 @@ type FakeRoomStore struct {
 		result1 *livekit.ParticipantInfo
 		result2 error
 	}
+	LoadRecordingRoomStub        func(context.Context, string) (string, error)
+	loadRecordingRoomMutex       sync.RWMutex
+	loadRecordingRoomArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	loadRecordingRoomReturns struct {
+		result1 string
+		result2 error
+	}
+	loadRecordingRoomReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	LoadRoomStub        func(context.Context, string) (*livekit.Room, error)
 	loadRoomMutex       sync.RWMutex
 	loadRoomArgsForCall []struct {
@@ -92,6 +161,36 @@ type FakeRoomStore struct {
 		result1 *livekit.Room
 		result2 error
 	}
+	LoadRoomMetadataStub        func(context.Context, string) (string, uint64, error)
+	loadRoomMetadataMutex       sync.RWMutex
+	loadRoomMetadataArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	loadRoomMetadataReturns struct {
+		result1 string
+		result2 uint64
+		result3 error
+	}
+	loadRoomMetadataReturnsOnCall map[int]struct {
+		result1 string
+		result2 uint64
+		result3 error
+	}
+	LoadRoomPolicyStub        func(context.Context, string) (*config.RoomPolicy, error)
+	loadRoomPolicyMutex       sync.RWMutex
+	loadRoomPolicyArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	loadRoomPolicyReturns struct {
+		result1 *config.RoomPolicy
+		result2 error
+	}
+	loadRoomPolicyReturnsOnCall map[int]struct {
+		result1 *config.RoomPolicy
+		result2 error
+	}
 	LockRoomStub        func(context.Context, string, time.Duration) (string, error)
 	lockRoomMutex       sync.RWMutex
 	lockRoomArgsForCall []struct {
@@ -120,6 +219,19 @@ type FakeRoomStore struct {
 	storeParticipantReturnsOnCall map[int]struct {
 		result1 error
 	}
+	StoreRecordingRoomStub        func(context.Context, string, string) error
+	storeRecordingRoomMutex       sync.RWMutex
+	storeRecordingRoomArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	storeRecordingRoomReturns struct {
+		result1 error
+	}
+	storeRecordingRoomReturnsOnCall map[int]struct {
+		result1 error
+	}
 	StoreRoomStub        func(context.Context, *livekit.Room) error
 	storeRoomMutex       sync.RWMutex
 	storeRoomArgsForCall []struct {
@@ -132,6 +244,46 @@ type FakeRoomStore struct {
 	storeRoomReturnsOnCall map[int]struct {
 		result1 error
 	}
+	StoreRoomMetadataStub        func(context.Context, string, string) (uint64, error)
+	storeRoomMetadataMutex       sync.RWMutex
+	storeRoomMetadataArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	storeRoomMetadataReturns struct {
+		result1 uint64
+		result2 error
+	}
+	storeRoomMetadataReturnsOnCall map[int]struct {
+		result1 uint64
+		result2 error
+	}
+	StoreRoomPolicyStub        func(context.Context, string, *config.RoomPolicy) error
+	storeRoomPolicyMutex       sync.RWMutex
+	storeRoomPolicyArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 *config.RoomPolicy
+	}
+	storeRoomPolicyReturns struct {
+		result1 error
+	}
+	storeRoomPolicyReturnsOnCall map[int]struct {
+		result1 error
+	}
+	StoreScheduledActionStub        func(context.Context, *service.ScheduledAction) error
+	storeScheduledActionMutex       sync.RWMutex
+	storeScheduledActionArgsForCall []struct {
+		arg1 context.Context
+		arg2 *service.ScheduledAction
+	}
+	storeScheduledActionReturns struct {
+		result1 error
+	}
+	storeScheduledActionReturnsOnCall map[int]struct {
+		result1 error
+	}
 	UnlockRoomStub        func(context.Context, string, string) error
 	unlockRoomMutex       sync.RWMutex
 	unlockRoomArgsForCall []struct {
@@ -212,6 +364,68 @@ func (fake *FakeRoomStore) DeleteParticipantReturnsOnCall(i int, result1 error)
 	}{result1}
 }
 
+func (fake *FakeRoomStore) DeleteRecordingRoom(arg1 context.Context, arg2 string) error {
+	fake.deleteRecordingRoomMutex.Lock()
+	ret, specificReturn := fake.deleteRecordingRoomReturnsOnCall[len(fake.deleteRecordingRoomArgsForCall)]
+	fake.deleteRecordingRoomArgsForCall = append(fake.deleteRecordingRoomArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.DeleteRecordingRoomStub
+	fakeReturns := fake.deleteRecordingRoomReturns
+	fake.recordInvocation("DeleteRecordingRoom", []interface{}{arg1, arg2})
+	fake.deleteRecordingRoomMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRoomStore) DeleteRecordingRoomCallCount() int {
+	fake.deleteRecordingRoomMutex.RLock()
+	defer fake.deleteRecordingRoomMutex.RUnlock()
+	return len(fake.deleteRecordingRoomArgsForCall)
+}
+
+func (fake *FakeRoomStore) DeleteRecordingRoomCalls(stub func(context.Context, string) error) {
+	fake.deleteRecordingRoomMutex.Lock()
+	defer fake.deleteRecordingRoomMutex.Unlock()
+	fake.DeleteRecordingRoomStub = stub
+}
+
+func (fake *FakeRoomStore) DeleteRecordingRoomArgsForCall(i int) (context.Context, string) {
+	fake.deleteRecordingRoomMutex.RLock()
+	defer fake.deleteRecordingRoomMutex.RUnlock()
+	argsForCall := fake.deleteRecordingRoomArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRoomStore) DeleteRecordingRoomReturns(result1 error) {
+	fake.deleteRecordingRoomMutex.Lock()
+	defer fake.deleteRecordingRoomMutex.Unlock()
+	fake.DeleteRecordingRoomStub = nil
+	fake.deleteRecordingRoomReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRoomStore) DeleteRecordingRoomReturnsOnCall(i int, result1 error) {
+	fake.deleteRecordingRoomMutex.Lock()
+	defer fake.deleteRecordingRoomMutex.Unlock()
+	fake.DeleteRecordingRoomStub = nil
+	if fake.deleteRecordingRoomReturnsOnCall == nil {
+		fake.deleteRecordingRoomReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteRecordingRoomReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeRoomStore) DeleteRoom(arg1 context.Context, arg2 string) error {
 	fake.deleteRoomMutex.Lock()
 	ret, specificReturn := fake.deleteRoomReturnsOnCall[len(fake.deleteRoomArgsForCall)]
@@ -274,6 +488,136 @@ func (fake *FakeRoomStore) DeleteRoomReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeRoomStore) DeleteScheduledAction(arg1 context.Context, arg2 string) (bool, error) {
+	fake.deleteScheduledActionMutex.Lock()
+	ret, specificReturn := fake.deleteScheduledActionReturnsOnCall[len(fake.deleteScheduledActionArgsForCall)]
+	fake.deleteScheduledActionArgsForCall = append(fake.deleteScheduledActionArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.DeleteScheduledActionStub
+	fakeReturns := fake.deleteScheduledActionReturns
+	fake.recordInvocation("DeleteScheduledAction", []interface{}{arg1, arg2})
+	fake.deleteScheduledActionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRoomStore) DeleteScheduledActionCallCount() int {
+	fake.deleteScheduledActionMutex.RLock()
+	defer fake.deleteScheduledActionMutex.RUnlock()
+	return len(fake.deleteScheduledActionArgsForCall)
+}
+
+func (fake *FakeRoomStore) DeleteScheduledActionCalls(stub func(context.Context, string) (bool, error)) {
+	fake.deleteScheduledActionMutex.Lock()
+	defer fake.deleteScheduledActionMutex.Unlock()
+	fake.DeleteScheduledActionStub = stub
+}
+
+func (fake *FakeRoomStore) DeleteScheduledActionArgsForCall(i int) (context.Context, string) {
+	fake.deleteScheduledActionMutex.RLock()
+	defer fake.deleteScheduledActionMutex.RUnlock()
+	argsForCall := fake.deleteScheduledActionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRoomStore) DeleteScheduledActionReturns(result1 bool, result2 error) {
+	fake.deleteScheduledActionMutex.Lock()
+	defer fake.deleteScheduledActionMutex.Unlock()
+	fake.DeleteScheduledActionStub = nil
+	fake.deleteScheduledActionReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRoomStore) DeleteScheduledActionReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.deleteScheduledActionMutex.Lock()
+	defer fake.deleteScheduledActionMutex.Unlock()
+	fake.DeleteScheduledActionStub = nil
+	if fake.deleteScheduledActionReturnsOnCall == nil {
+		fake.deleteScheduledActionReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.deleteScheduledActionReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRoomStore) ListDueScheduledActions(arg1 context.Context, arg2 time.Time) ([]*service.ScheduledAction, error) {
+	fake.listDueScheduledActionsMutex.Lock()
+	ret, specificReturn := fake.listDueScheduledActionsReturnsOnCall[len(fake.listDueScheduledActionsArgsForCall)]
+	fake.listDueScheduledActionsArgsForCall = append(fake.listDueScheduledActionsArgsForCall, struct {
+		arg1 context.Context
+		arg2 time.Time
+	}{arg1, arg2})
+	stub := fake.ListDueScheduledActionsStub
+	fakeReturns := fake.listDueScheduledActionsReturns
+	fake.recordInvocation("ListDueScheduledActions", []interface{}{arg1, arg2})
+	fake.listDueScheduledActionsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRoomStore) ListDueScheduledActionsCallCount() int {
+	fake.listDueScheduledActionsMutex.RLock()
+	defer fake.listDueScheduledActionsMutex.RUnlock()
+	return len(fake.listDueScheduledActionsArgsForCall)
+}
+
+func (fake *FakeRoomStore) ListDueScheduledActionsCalls(stub func(context.Context, time.Time) ([]*service.ScheduledAction, error)) {
+	fake.listDueScheduledActionsMutex.Lock()
+	defer fake.listDueScheduledActionsMutex.Unlock()
+	fake.ListDueScheduledActionsStub = stub
+}
+
+func (fake *FakeRoomStore) ListDueScheduledActionsArgsForCall(i int) (context.Context, time.Time) {
+	fake.listDueScheduledActionsMutex.RLock()
+	defer fake.listDueScheduledActionsMutex.RUnlock()
+	argsForCall := fake.listDueScheduledActionsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRoomStore) ListDueScheduledActionsReturns(result1 []*service.ScheduledAction, result2 error) {
+	fake.listDueScheduledActionsMutex.Lock()
+	defer fake.listDueScheduledActionsMutex.Unlock()
+	fake.ListDueScheduledActionsStub = nil
+	fake.listDueScheduledActionsReturns = struct {
+		result1 []*service.ScheduledAction
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRoomStore) ListDueScheduledActionsReturnsOnCall(i int, result1 []*service.ScheduledAction, result2 error) {
+	fake.listDueScheduledActionsMutex.Lock()
+	defer fake.listDueScheduledActionsMutex.Unlock()
+	fake.ListDueScheduledActionsStub = nil
+	if fake.listDueScheduledActionsReturnsOnCall == nil {
+		fake.listDueScheduledActionsReturnsOnCall = make(map[int]struct {
+			result1 []*service.ScheduledAction
+			result2 error
+		})
+	}
+	fake.listDueScheduledActionsReturnsOnCall[i] = struct {
+		result1 []*service.ScheduledAction
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeRoomStore) ListParticipants(arg1 context.Context, arg2 string) ([]*livekit.ParticipantInfo, error) {
 	fake.listParticipantsMutex.Lock()
 	ret, specificReturn := fake.listParticipantsReturnsOnCall[len(fake.listParticipantsArgsForCall)]
@@ -403,6 +747,71 @@ func (fake *FakeRoomStore) ListRoomsReturnsOnCall(i int, result1 []*livekit.Room
 	}{result1, result2}
 }
 
+func (fake *FakeRoomStore) ListScheduledActions(arg1 context.Context, arg2 string) ([]*service.ScheduledAction, error) {
+	fake.listScheduledActionsMutex.Lock()
+	ret, specificReturn := fake.listScheduledActionsReturnsOnCall[len(fake.listScheduledActionsArgsForCall)]
+	fake.listScheduledActionsArgsForCall = append(fake.listScheduledActionsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ListScheduledActionsStub
+	fakeReturns := fake.listScheduledActionsReturns
+	fake.recordInvocation("ListScheduledActions", []interface{}{arg1, arg2})
+	fake.listScheduledActionsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRoomStore) ListScheduledActionsCallCount() int {
+	fake.listScheduledActionsMutex.RLock()
+	defer fake.listScheduledActionsMutex.RUnlock()
+	return len(fake.listScheduledActionsArgsForCall)
+}
+
+func (fake *FakeRoomStore) ListScheduledActionsCalls(stub func(context.Context, string) ([]*service.ScheduledAction, error)) {
+	fake.listScheduledActionsMutex.Lock()
+	defer fake.listScheduledActionsMutex.Unlock()
+	fake.ListScheduledActionsStub = stub
+}
+
+func (fake *FakeRoomStore) ListScheduledActionsArgsForCall(i int) (context.Context, string) {
+	fake.listScheduledActionsMutex.RLock()
+	defer fake.listScheduledActionsMutex.RUnlock()
+	argsForCall := fake.listScheduledActionsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRoomStore) ListScheduledActionsReturns(result1 []*service.ScheduledAction, result2 error) {
+	fake.listScheduledActionsMutex.Lock()
+	defer fake.listScheduledActionsMutex.Unlock()
+	fake.ListScheduledActionsStub = nil
+	fake.listScheduledActionsReturns = struct {
+		result1 []*service.ScheduledAction
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRoomStore) ListScheduledActionsReturnsOnCall(i int, result1 []*service.ScheduledAction, result2 error) {
+	fake.listScheduledActionsMutex.Lock()
+	defer fake.listScheduledActionsMutex.Unlock()
+	fake.ListScheduledActionsStub = nil
+	if fake.listScheduledActionsReturnsOnCall == nil {
+		fake.listScheduledActionsReturnsOnCall = make(map[int]struct {
+			result1 []*service.ScheduledAction
+			result2 error
+		})
+	}
+	fake.listScheduledActionsReturnsOnCall[i] = struct {
+		result1 []*service.ScheduledAction
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeRoomStore) LoadParticipant(arg1 context.Context, arg2 string, arg3 string) (*livekit.ParticipantInfo, error) {
 	fake.loadParticipantMutex.Lock()
 	ret, specificReturn := fake.loadParticipantReturnsOnCall[len(fake.loadParticipantArgsForCall)]
@@ -469,6 +878,71 @@ func (fake *FakeRoomStore) LoadParticipantReturnsOnCall(i int, result1 *livekit.
 	}{result1, result2}
 }
 
+func (fake *FakeRoomStore) LoadRecordingRoom(arg1 context.Context, arg2 string) (string, error) {
+	fake.loadRecordingRoomMutex.Lock()
+	ret, specificReturn := fake.loadRecordingRoomReturnsOnCall[len(fake.loadRecordingRoomArgsForCall)]
+	fake.loadRecordingRoomArgsForCall = append(fake.loadRecordingRoomArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.LoadRecordingRoomStub
+	fakeReturns := fake.loadRecordingRoomReturns
+	fake.recordInvocation("LoadRecordingRoom", []interface{}{arg1, arg2})
+	fake.loadRecordingRoomMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRoomStore) LoadRecordingRoomCallCount() int {
+	fake.loadRecordingRoomMutex.RLock()
+	defer fake.loadRecordingRoomMutex.RUnlock()
+	return len(fake.loadRecordingRoomArgsForCall)
+}
+
+func (fake *FakeRoomStore) LoadRecordingRoomCalls(stub func(context.Context, string) (string, error)) {
+	fake.loadRecordingRoomMutex.Lock()
+	defer fake.loadRecordingRoomMutex.Unlock()
+	fake.LoadRecordingRoomStub = stub
+}
+
+func (fake *FakeRoomStore) LoadRecordingRoomArgsForCall(i int) (context.Context, string) {
+	fake.loadRecordingRoomMutex.RLock()
+	defer fake.loadRecordingRoomMutex.RUnlock()
+	argsForCall := fake.loadRecordingRoomArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRoomStore) LoadRecordingRoomReturns(result1 string, result2 error) {
+	fake.loadRecordingRoomMutex.Lock()
+	defer fake.loadRecordingRoomMutex.Unlock()
+	fake.LoadRecordingRoomStub = nil
+	fake.loadRecordingRoomReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRoomStore) LoadRecordingRoomReturnsOnCall(i int, result1 string, result2 error) {
+	fake.loadRecordingRoomMutex.Lock()
+	defer fake.loadRecordingRoomMutex.Unlock()
+	fake.LoadRecordingRoomStub = nil
+	if fake.loadRecordingRoomReturnsOnCall == nil {
+		fake.loadRecordingRoomReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.loadRecordingRoomReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeRoomStore) LoadRoom(arg1 context.Context, arg2 string) (*livekit.Room, error) {
 	fake.loadRoomMutex.Lock()
 	ret, specificReturn := fake.loadRoomReturnsOnCall[len(fake.loadRoomArgsForCall)]
@@ -534,6 +1008,139 @@ func (fake *FakeRoomStore) LoadRoomReturnsOnCall(i int, result1 *livekit.Room, r
 	}{result1, result2}
 }
 
+func (fake *FakeRoomStore) LoadRoomMetadata(arg1 context.Context, arg2 string) (string, uint64, error) {
+	fake.loadRoomMetadataMutex.Lock()
+	ret, specificReturn := fake.loadRoomMetadataReturnsOnCall[len(fake.loadRoomMetadataArgsForCall)]
+	fake.loadRoomMetadataArgsForCall = append(fake.loadRoomMetadataArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.LoadRoomMetadataStub
+	fakeReturns := fake.loadRoomMetadataReturns
+	fake.recordInvocation("LoadRoomMetadata", []interface{}{arg1, arg2})
+	fake.loadRoomMetadataMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeRoomStore) LoadRoomMetadataCallCount() int {
+	fake.loadRoomMetadataMutex.RLock()
+	defer fake.loadRoomMetadataMutex.RUnlock()
+	return len(fake.loadRoomMetadataArgsForCall)
+}
+
+func (fake *FakeRoomStore) LoadRoomMetadataCalls(stub func(context.Context, string) (string, uint64, error)) {
+	fake.loadRoomMetadataMutex.Lock()
+	defer fake.loadRoomMetadataMutex.Unlock()
+	fake.LoadRoomMetadataStub = stub
+}
+
+func (fake *FakeRoomStore) LoadRoomMetadataArgsForCall(i int) (context.Context, string) {
+	fake.loadRoomMetadataMutex.RLock()
+	defer fake.loadRoomMetadataMutex.RUnlock()
+	argsForCall := fake.loadRoomMetadataArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRoomStore) LoadRoomMetadataReturns(result1 string, result2 uint64, result3 error) {
+	fake.loadRoomMetadataMutex.Lock()
+	defer fake.loadRoomMetadataMutex.Unlock()
+	fake.LoadRoomMetadataStub = nil
+	fake.loadRoomMetadataReturns = struct {
+		result1 string
+		result2 uint64
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeRoomStore) LoadRoomMetadataReturnsOnCall(i int, result1 string, result2 uint64, result3 error) {
+	fake.loadRoomMetadataMutex.Lock()
+	defer fake.loadRoomMetadataMutex.Unlock()
+	fake.LoadRoomMetadataStub = nil
+	if fake.loadRoomMetadataReturnsOnCall == nil {
+		fake.loadRoomMetadataReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 uint64
+			result3 error
+		})
+	}
+	fake.loadRoomMetadataReturnsOnCall[i] = struct {
+		result1 string
+		result2 uint64
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeRoomStore) LoadRoomPolicy(arg1 context.Context, arg2 string) (*config.RoomPolicy, error) {
+	fake.loadRoomPolicyMutex.Lock()
+	ret, specificReturn := fake.loadRoomPolicyReturnsOnCall[len(fake.loadRoomPolicyArgsForCall)]
+	fake.loadRoomPolicyArgsForCall = append(fake.loadRoomPolicyArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.LoadRoomPolicyStub
+	fakeReturns := fake.loadRoomPolicyReturns
+	fake.recordInvocation("LoadRoomPolicy", []interface{}{arg1, arg2})
+	fake.loadRoomPolicyMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRoomStore) LoadRoomPolicyCallCount() int {
+	fake.loadRoomPolicyMutex.RLock()
+	defer fake.loadRoomPolicyMutex.RUnlock()
+	return len(fake.loadRoomPolicyArgsForCall)
+}
+
+func (fake *FakeRoomStore) LoadRoomPolicyCalls(stub func(context.Context, string) (*config.RoomPolicy, error)) {
+	fake.loadRoomPolicyMutex.Lock()
+	defer fake.loadRoomPolicyMutex.Unlock()
+	fake.LoadRoomPolicyStub = stub
+}
+
+func (fake *FakeRoomStore) LoadRoomPolicyArgsForCall(i int) (context.Context, string) {
+	fake.loadRoomPolicyMutex.RLock()
+	defer fake.loadRoomPolicyMutex.RUnlock()
+	argsForCall := fake.loadRoomPolicyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRoomStore) LoadRoomPolicyReturns(result1 *config.RoomPolicy, result2 error) {
+	fake.loadRoomPolicyMutex.Lock()
+	defer fake.loadRoomPolicyMutex.Unlock()
+	fake.LoadRoomPolicyStub = nil
+	fake.loadRoomPolicyReturns = struct {
+		result1 *config.RoomPolicy
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRoomStore) LoadRoomPolicyReturnsOnCall(i int, result1 *config.RoomPolicy, result2 error) {
+	fake.loadRoomPolicyMutex.Lock()
+	defer fake.loadRoomPolicyMutex.Unlock()
+	fake.LoadRoomPolicyStub = nil
+	if fake.loadRoomPolicyReturnsOnCall == nil {
+		fake.loadRoomPolicyReturnsOnCall = make(map[int]struct {
+			result1 *config.RoomPolicy
+			result2 error
+		})
+	}
+	fake.loadRoomPolicyReturnsOnCall[i] = struct {
+		result1 *config.RoomPolicy
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeRoomStore) LockRoom(arg1 context.Context, arg2 string, arg3 time.Duration) (string, error) {
 	fake.lockRoomMutex.Lock()
 	ret, specificReturn := fake.lockRoomReturnsOnCall[len(fake.lockRoomArgsForCall)]
@@ -663,6 +1270,69 @@ func (fake *FakeRoomStore) StoreParticipantReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeRoomStore) StoreRecordingRoom(arg1 context.Context, arg2 string, arg3 string) error {
+	fake.storeRecordingRoomMutex.Lock()
+	ret, specificReturn := fake.storeRecordingRoomReturnsOnCall[len(fake.storeRecordingRoomArgsForCall)]
+	fake.storeRecordingRoomArgsForCall = append(fake.storeRecordingRoomArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.StoreRecordingRoomStub
+	fakeReturns := fake.storeRecordingRoomReturns
+	fake.recordInvocation("StoreRecordingRoom", []interface{}{arg1, arg2, arg3})
+	fake.storeRecordingRoomMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRoomStore) StoreRecordingRoomCallCount() int {
+	fake.storeRecordingRoomMutex.RLock()
+	defer fake.storeRecordingRoomMutex.RUnlock()
+	return len(fake.storeRecordingRoomArgsForCall)
+}
+
+func (fake *FakeRoomStore) StoreRecordingRoomCalls(stub func(context.Context, string, string) error) {
+	fake.storeRecordingRoomMutex.Lock()
+	defer fake.storeRecordingRoomMutex.Unlock()
+	fake.StoreRecordingRoomStub = stub
+}
+
+func (fake *FakeRoomStore) StoreRecordingRoomArgsForCall(i int) (context.Context, string, string) {
+	fake.storeRecordingRoomMutex.RLock()
+	defer fake.storeRecordingRoomMutex.RUnlock()
+	argsForCall := fake.storeRecordingRoomArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeRoomStore) StoreRecordingRoomReturns(result1 error) {
+	fake.storeRecordingRoomMutex.Lock()
+	defer fake.storeRecordingRoomMutex.Unlock()
+	fake.StoreRecordingRoomStub = nil
+	fake.storeRecordingRoomReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRoomStore) StoreRecordingRoomReturnsOnCall(i int, result1 error) {
+	fake.storeRecordingRoomMutex.Lock()
+	defer fake.storeRecordingRoomMutex.Unlock()
+	fake.StoreRecordingRoomStub = nil
+	if fake.storeRecordingRoomReturnsOnCall == nil {
+		fake.storeRecordingRoomReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.storeRecordingRoomReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeRoomStore) StoreRoom(arg1 context.Context, arg2 *livekit.Room) error {
 	fake.storeRoomMutex.Lock()
 	ret, specificReturn := fake.storeRoomReturnsOnCall[len(fake.storeRoomArgsForCall)]
@@ -725,6 +1395,197 @@ func (fake *FakeRoomStore) StoreRoomReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeRoomStore) StoreRoomMetadata(arg1 context.Context, arg2 string, arg3 string) (uint64, error) {
+	fake.storeRoomMetadataMutex.Lock()
+	ret, specificReturn := fake.storeRoomMetadataReturnsOnCall[len(fake.storeRoomMetadataArgsForCall)]
+	fake.storeRoomMetadataArgsForCall = append(fake.storeRoomMetadataArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.StoreRoomMetadataStub
+	fakeReturns := fake.storeRoomMetadataReturns
+	fake.recordInvocation("StoreRoomMetadata", []interface{}{arg1, arg2, arg3})
+	fake.storeRoomMetadataMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRoomStore) StoreRoomMetadataCallCount() int {
+	fake.storeRoomMetadataMutex.RLock()
+	defer fake.storeRoomMetadataMutex.RUnlock()
+	return len(fake.storeRoomMetadataArgsForCall)
+}
+
+func (fake *FakeRoomStore) StoreRoomMetadataCalls(stub func(context.Context, string, string) (uint64, error)) {
+	fake.storeRoomMetadataMutex.Lock()
+	defer fake.storeRoomMetadataMutex.Unlock()
+	fake.StoreRoomMetadataStub = stub
+}
+
+func (fake *FakeRoomStore) StoreRoomMetadataArgsForCall(i int) (context.Context, string, string) {
+	fake.storeRoomMetadataMutex.RLock()
+	defer fake.storeRoomMetadataMutex.RUnlock()
+	argsForCall := fake.storeRoomMetadataArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeRoomStore) StoreRoomMetadataReturns(result1 uint64, result2 error) {
+	fake.storeRoomMetadataMutex.Lock()
+	defer fake.storeRoomMetadataMutex.Unlock()
+	fake.StoreRoomMetadataStub = nil
+	fake.storeRoomMetadataReturns = struct {
+		result1 uint64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRoomStore) StoreRoomMetadataReturnsOnCall(i int, result1 uint64, result2 error) {
+	fake.storeRoomMetadataMutex.Lock()
+	defer fake.storeRoomMetadataMutex.Unlock()
+	fake.StoreRoomMetadataStub = nil
+	if fake.storeRoomMetadataReturnsOnCall == nil {
+		fake.storeRoomMetadataReturnsOnCall = make(map[int]struct {
+			result1 uint64
+			result2 error
+		})
+	}
+	fake.storeRoomMetadataReturnsOnCall[i] = struct {
+		result1 uint64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRoomStore) StoreRoomPolicy(arg1 context.Context, arg2 string, arg3 *config.RoomPolicy) error {
+	fake.storeRoomPolicyMutex.Lock()
+	ret, specificReturn := fake.storeRoomPolicyReturnsOnCall[len(fake.storeRoomPolicyArgsForCall)]
+	fake.storeRoomPolicyArgsForCall = append(fake.storeRoomPolicyArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 *config.RoomPolicy
+	}{arg1, arg2, arg3})
+	stub := fake.StoreRoomPolicyStub
+	fakeReturns := fake.storeRoomPolicyReturns
+	fake.recordInvocation("StoreRoomPolicy", []interface{}{arg1, arg2, arg3})
+	fake.storeRoomPolicyMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRoomStore) StoreRoomPolicyCallCount() int {
+	fake.storeRoomPolicyMutex.RLock()
+	defer fake.storeRoomPolicyMutex.RUnlock()
+	return len(fake.storeRoomPolicyArgsForCall)
+}
+
+func (fake *FakeRoomStore) StoreRoomPolicyCalls(stub func(context.Context, string, *config.RoomPolicy) error) {
+	fake.storeRoomPolicyMutex.Lock()
+	defer fake.storeRoomPolicyMutex.Unlock()
+	fake.StoreRoomPolicyStub = stub
+}
+
+func (fake *FakeRoomStore) StoreRoomPolicyArgsForCall(i int) (context.Context, string, *config.RoomPolicy) {
+	fake.storeRoomPolicyMutex.RLock()
+	defer fake.storeRoomPolicyMutex.RUnlock()
+	argsForCall := fake.storeRoomPolicyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeRoomStore) StoreRoomPolicyReturns(result1 error) {
+	fake.storeRoomPolicyMutex.Lock()
+	defer fake.storeRoomPolicyMutex.Unlock()
+	fake.StoreRoomPolicyStub = nil
+	fake.storeRoomPolicyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRoomStore) StoreRoomPolicyReturnsOnCall(i int, result1 error) {
+	fake.storeRoomPolicyMutex.Lock()
+	defer fake.storeRoomPolicyMutex.Unlock()
+	fake.StoreRoomPolicyStub = nil
+	if fake.storeRoomPolicyReturnsOnCall == nil {
+		fake.storeRoomPolicyReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.storeRoomPolicyReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRoomStore) StoreScheduledAction(arg1 context.Context, arg2 *service.ScheduledAction) error {
+	fake.storeScheduledActionMutex.Lock()
+	ret, specificReturn := fake.storeScheduledActionReturnsOnCall[len(fake.storeScheduledActionArgsForCall)]
+	fake.storeScheduledActionArgsForCall = append(fake.storeScheduledActionArgsForCall, struct {
+		arg1 context.Context
+		arg2 *service.ScheduledAction
+	}{arg1, arg2})
+	stub := fake.StoreScheduledActionStub
+	fakeReturns := fake.storeScheduledActionReturns
+	fake.recordInvocation("StoreScheduledAction", []interface{}{arg1, arg2})
+	fake.storeScheduledActionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRoomStore) StoreScheduledActionCallCount() int {
+	fake.storeScheduledActionMutex.RLock()
+	defer fake.storeScheduledActionMutex.RUnlock()
+	return len(fake.storeScheduledActionArgsForCall)
+}
+
+func (fake *FakeRoomStore) StoreScheduledActionCalls(stub func(context.Context, *service.ScheduledAction) error) {
+	fake.storeScheduledActionMutex.Lock()
+	defer fake.storeScheduledActionMutex.Unlock()
+	fake.StoreScheduledActionStub = stub
+}
+
+func (fake *FakeRoomStore) StoreScheduledActionArgsForCall(i int) (context.Context, *service.ScheduledAction) {
+	fake.storeScheduledActionMutex.RLock()
+	defer fake.storeScheduledActionMutex.RUnlock()
+	argsForCall := fake.storeScheduledActionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRoomStore) StoreScheduledActionReturns(result1 error) {
+	fake.storeScheduledActionMutex.Lock()
+	defer fake.storeScheduledActionMutex.Unlock()
+	fake.StoreScheduledActionStub = nil
+	fake.storeScheduledActionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRoomStore) StoreScheduledActionReturnsOnCall(i int, result1 error) {
+	fake.storeScheduledActionMutex.Lock()
+	defer fake.storeScheduledActionMutex.Unlock()
+	fake.StoreScheduledActionStub = nil
+	if fake.storeScheduledActionReturnsOnCall == nil {
+		fake.storeScheduledActionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.storeScheduledActionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeRoomStore) UnlockRoom(arg1 context.Context, arg2 string, arg3 string) error {
 	fake.unlockRoomMutex.Lock()
 	ret, specificReturn := fake.unlockRoomReturnsOnCall[len(fake.unlockRoomArgsForCall)]
@@ -793,22 +1654,44 @@ func (fake *FakeRoomStore) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.deleteParticipantMutex.RLock()
 	defer fake.deleteParticipantMutex.RUnlock()
+	fake.deleteRecordingRoomMutex.RLock()
+	defer fake.deleteRecordingRoomMutex.RUnlock()
 	fake.deleteRoomMutex.RLock()
 	defer fake.deleteRoomMutex.RUnlock()
+	fake.deleteScheduledActionMutex.RLock()
+	defer fake.deleteScheduledActionMutex.RUnlock()
+	fake.listDueScheduledActionsMutex.RLock()
+	defer fake.listDueScheduledActionsMutex.RUnlock()
 	fake.listParticipantsMutex.RLock()
 	defer fake.listParticipantsMutex.RUnlock()
 	fake.listRoomsMutex.RLock()
 	defer fake.listRoomsMutex.RUnlock()
+	fake.listScheduledActionsMutex.RLock()
+	defer fake.listScheduledActionsMutex.RUnlock()
 	fake.loadParticipantMutex.RLock()
 	defer fake.loadParticipantMutex.RUnlock()
+	fake.loadRecordingRoomMutex.RLock()
+	defer fake.loadRecordingRoomMutex.RUnlock()
 	fake.loadRoomMutex.RLock()
 	defer fake.loadRoomMutex.RUnlock()
+	fake.loadRoomMetadataMutex.RLock()
+	defer fake.loadRoomMetadataMutex.RUnlock()
+	fake.loadRoomPolicyMutex.RLock()
+	defer fake.loadRoomPolicyMutex.RUnlock()
 	fake.lockRoomMutex.RLock()
 	defer fake.lockRoomMutex.RUnlock()
 	fake.storeParticipantMutex.RLock()
 	defer fake.storeParticipantMutex.RUnlock()
+	fake.storeRecordingRoomMutex.RLock()
+	defer fake.storeRecordingRoomMutex.RUnlock()
 	fake.storeRoomMutex.RLock()
 	defer fake.storeRoomMutex.RUnlock()
+	fake.storeRoomMetadataMutex.RLock()
+	defer fake.storeRoomMetadataMutex.RUnlock()
+	fake.storeRoomPolicyMutex.RLock()
+	defer fake.storeRoomPolicyMutex.RUnlock()
+	fake.storeScheduledActionMutex.RLock()
+	defer fake.storeScheduledActionMutex.RUnlock()
 	fake.unlockRoomMutex.RLock()
 	defer fake.unlockRoomMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
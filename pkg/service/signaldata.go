@@ -0,0 +1,43 @@
+package service
+
+import (
+	"encoding/json"
+
+	livekit "github.com/livekit/protocol/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+// The data packets of signal-only participants are sent to and from JSON clients as text messages,
+// {"data_packet": {...}}, with the packet encoded like JSON signal messages. Protobuf clients send
+// and receive them in field 1001 of the signal request and response
+
+// handleDataPacketRequest passes on the data packet a signal-only participant sent
+func handleDataPacketRequest(value json.RawMessage) (*livekit.SignalRequest, error) {
+	if len(value) == 0 || string(value) == "null" {
+		return nil, ErrInvalidTextRequest
+	}
+	dp := &livekit.DataPacket{}
+	if err := protojson.Unmarshal(value, dp); err != nil {
+		return nil, err
+	}
+	return rtc.NewSignalDataRequest(dp)
+}
+
+// marshalJSONResponse encodes a signal response for JSON clients, data packets are sent as text
+// messages of their own since JSON drops the field they're in
+func marshalJSONResponse(msg *livekit.SignalResponse) ([]byte, error) {
+	dp, err := rtc.SignalResponseDataPacket(msg)
+	if err != nil {
+		return nil, err
+	}
+	if dp == nil {
+		return protojson.Marshal(msg)
+	}
+	data, err := protojson.Marshal(dp)
+	if err != nil {
+		return nil, err
+	}
+	return marshalTextMessage(textKeyDataPacket, json.RawMessage(data))
+}
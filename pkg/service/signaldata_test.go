@@ -0,0 +1,47 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/gorilla/websocket"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestWSSignalConnectionDataPackets(t *testing.T) {
+	client := &typesfakes.FakeWebsocketClient{}
+	client.ReadMessageReturnsOnCall(0, websocket.TextMessage, []byte(`{"data_packet": {"kind": "RELIABLE", "user": {"payload": "aGVsbG8="}}}`), nil)
+	conn := NewWSSignalConnection(client)
+
+	req, err := conn.ReadRequest()
+	require.NoError(t, err)
+	require.Nil(t, req.Message)
+	dp := &livekit.DataPacket{}
+	require.NoError(t, proto.Unmarshal(rtc.SignalRequestData(req), dp))
+	require.Equal(t, livekit.DataPacket_RELIABLE, dp.Kind)
+	require.Equal(t, []byte("hello"), dp.GetUser().Payload)
+
+	// JSON clients receive them as text messages of their own
+	res, err := rtc.NewSignalDataResponse(dp)
+	require.NoError(t, err)
+	require.NoError(t, conn.WriteResponse(res))
+	messageType, payload := client.WriteMessageArgsForCall(0)
+	require.Equal(t, websocket.TextMessage, messageType)
+	// reliable is the default kind, it's left out
+	require.JSONEq(t, `{"data_packet": {"user": {"payload": "aGVsbG8="}}}`, string(payload))
+
+	// protobuf clients in the field of the response
+	conn.useJSON = false
+	require.NoError(t, conn.WriteResponse(res))
+	messageType, payload = client.WriteMessageArgsForCall(1)
+	require.Equal(t, websocket.BinaryMessage, messageType)
+	received := &livekit.SignalResponse{}
+	require.NoError(t, proto.Unmarshal(payload, received))
+	sent, err := rtc.SignalResponseDataPacket(received)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(dp, sent))
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// SignalSeq is sent to clients that connect with signal_seq=1 as a JSON text message,
+// {"signal_seq": {...}}, right before the signal response it numbers
+type SignalSeq struct {
+	Seq uint32 `json:"seq"`
+}
+
+// SignalAck is sent by clients over the signal connection as a JSON text message,
+// {"signal_ack": {...}}, once they received the signal responses up to Seq. The server keeps
+// responses until they're acked, to send them again when the client resumes
+type SignalAck struct {
+	Seq uint32 `json:"seq"`
+}
+
+// handleSignalAckRequest drops the signal responses participant received, on the node hosting the
+// room. Acks aren't answered
+func (r *RoomManager) handleSignalAckRequest(room *rtc.Room, participant types.Participant, value json.RawMessage) (interface{}, error) {
+	ack := &SignalAck{}
+	if err := decodeTextRequest(value, ack); err != nil {
+		return nil, err
+	}
+	participant.AckSignal(ack.Seq)
+	return nil, nil
+}
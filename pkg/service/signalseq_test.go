@@ -0,0 +1,60 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/gorilla/websocket"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestSignalAckRequest(t *testing.T) {
+	rooms := newTextRequestRooms(t)
+	rooms.roomManager.handleTextRequest(rooms.room, rooms.alice, textKeySignalAck, []byte(`{"seq": 12}`))
+	require.Equal(t, uint32(12), rooms.alice.AckSignalArgsForCall(0))
+	// not answered
+	require.Equal(t, 0, rooms.alice.SendTextMessageCallCount())
+}
+
+func TestWSSignalConnectionSignalSeq(t *testing.T) {
+	client := &typesfakes.FakeWebsocketClient{}
+	client.ReadMessageReturnsOnCall(0, websocket.TextMessage, []byte(`{"signal_ack": {"seq": 12}}`), nil)
+	client.ReadMessageReturnsOnCall(1, websocket.BinaryMessage, []byte{}, nil)
+	conn := &WSSignalConnection{conn: client}
+
+	// passed on to the node hosting the room
+	conn.OnTextRequest(textKeySignalAck, forwardTextRequest(textKeySignalAck))
+	req, err := conn.ReadRequest()
+	require.NoError(t, err)
+	require.Nil(t, req.Message)
+	key, value := rtc.SignalRequestText(req)
+	require.Equal(t, textKeySignalAck, key)
+	require.JSONEq(t, `{"seq": 12}`, string(value))
+	require.False(t, conn.useJSON)
+
+	// sequence numbers are only written for clients that asked for them
+	res := &livekit.SignalResponse{
+		Message: &livekit.SignalResponse_RoomUpdate{RoomUpdate: &livekit.RoomUpdate{Room: &livekit.Room{Name: "room"}}},
+	}
+	// numbered in field 1000
+	res.ProtoReflect().SetUnknown(protowire.AppendVarint(protowire.AppendTag(nil, 1000, protowire.VarintType), 1))
+	require.NoError(t, conn.WriteResponse(res))
+	require.Equal(t, 1, client.WriteMessageCallCount())
+
+	conn.SequenceResponses()
+	require.NoError(t, conn.WriteResponse(res))
+	require.Equal(t, 3, client.WriteMessageCallCount())
+	messageType, payload := client.WriteMessageArgsForCall(1)
+	require.Equal(t, websocket.TextMessage, messageType)
+	require.JSONEq(t, `{"signal_seq": {"seq": 1}}`, string(payload))
+	messageType, _ = client.WriteMessageArgsForCall(2)
+	require.Equal(t, websocket.BinaryMessage, messageType)
+
+	// responses that aren't numbered go out alone
+	require.NoError(t, conn.WriteResponse(&livekit.SignalResponse{}))
+	require.Equal(t, 4, client.WriteMessageCallCount())
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	livekit "github.com/livekit/protocol/proto"
+)
+
+// SetSubscriptionsRequest sets exactly which tracks each of the subscribers receives, for layouts
+// the application decides on, like the cohorts of a webinar
+type SetSubscriptionsRequest struct {
+	Room        string              `json:"room"`
+	Subscribers []*SubscriberTracks `json:"subscribers"`
+}
+
+// SubscriberTracks are the tracks a participant is to receive, it's unsubscribed from the others
+type SubscriberTracks struct {
+	Identity string            `json:"identity"`
+	Tracks   []*TrackSelection `json:"tracks"`
+}
+
+// TrackSelection is a track a subscriber receives
+type TrackSelection struct {
+	TrackSid string `json:"track_sid"`
+	// highest quality of video it receives: low, medium or high, high when not set
+	Quality string `json:"quality,omitempty"`
+}
+
+// SetSubscriptionsResponse tells which subscribers couldn't be set up, and why
+type SetSubscriptionsResponse struct {
+	Room        string                    `json:"room"`
+	Subscribers []*SubscriberTracksResult `json:"subscribers"`
+}
+
+type SubscriberTracksResult struct {
+	Identity string `json:"identity"`
+	Error    string `json:"error,omitempty"`
+}
+
+// parseVideoQuality returns the quality of a track selection, high when it's empty
+func parseVideoQuality(value string) (livekit.VideoQuality, bool) {
+	if value == "" {
+		return livekit.VideoQuality_HIGH, true
+	}
+	quality, ok := livekit.VideoQuality_value[strings.ToUpper(value)]
+	if !ok {
+		return 0, false
+	}
+	return livekit.VideoQuality(quality), true
+}
+
+// SetSubscriptions sets the tracks the subscribers of a room hosted on this node receive. Every
+// subscriber is set up, the result of each is returned. The protocol between nodes has no message
+// for it
+func (r *RoomManager) SetSubscriptions(ctx context.Context, req *SetSubscriptionsRequest) (*SetSubscriptionsResponse, error) {
+	layouts := make([]map[string]livekit.VideoQuality, len(req.Subscribers))
+	for i, sub := range req.Subscribers {
+		layouts[i] = make(map[string]livekit.VideoQuality, len(sub.Tracks))
+		for _, track := range sub.Tracks {
+			quality, ok := parseVideoQuality(track.Quality)
+			if !ok || track.TrackSid == "" {
+				return nil, ErrInvalidTrackSelection
+			}
+			layouts[i][track.TrackSid] = quality
+		}
+	}
+
+	room := r.GetRoom(ctx, req.Room)
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+	res := &SetSubscriptionsResponse{Room: req.Room}
+	for i, sub := range req.Subscribers {
+		result := &SubscriberTracksResult{Identity: sub.Identity}
+		res.Subscribers = append(res.Subscribers, result)
+		participant := room.GetParticipant(sub.Identity)
+		if participant == nil {
+			result.Error = ErrParticipantNotFound.Error()
+			continue
+		}
+		if err := room.SetSubscriptions(participant, layouts[i]); err != nil {
+			result.Error = err.Error()
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+func TestParseVideoQuality(t *testing.T) {
+	for value, expected := range map[string]livekit.VideoQuality{
+		"":       livekit.VideoQuality_HIGH,
+		"low":    livekit.VideoQuality_LOW,
+		"MEDIUM": livekit.VideoQuality_MEDIUM,
+		"high":   livekit.VideoQuality_HIGH,
+	} {
+		quality, ok := parseVideoQuality(value)
+		require.True(t, ok, value)
+		require.Equal(t, expected, quality, value)
+	}
+	_, ok := parseVideoQuality("4k")
+	require.False(t, ok)
+}
+
+func TestSetSubscriptions(t *testing.T) {
+	r := &RoomManager{rooms: make(map[string]*rtc.Room)}
+	req := &SetSubscriptionsRequest{
+		Room: "webinar",
+		Subscribers: []*SubscriberTracks{
+			{Identity: "viewer", Tracks: []*TrackSelection{{TrackSid: "TR_webcam", Quality: "4k"}}},
+		},
+	}
+	_, err := r.SetSubscriptions(context.Background(), req)
+	require.Equal(t, ErrInvalidTrackSelection, err)
+
+	// rooms hosted by other nodes aren't known
+	req.Subscribers[0].Tracks[0].Quality = "low"
+	_, err = r.SetSubscriptions(context.Background(), req)
+	require.Equal(t, ErrRoomNotFound, err)
+}
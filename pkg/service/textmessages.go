@@ -0,0 +1,79 @@
+package service
+
+import (
+	"encoding/json"
+
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+// Requests and messages the signal protocol has no message for are exchanged over the signal
+// connection as JSON text messages with a single field, {"<key>": {...}}, for protobuf clients too.
+// They don't switch the encoding of the connection
+const (
+	// sent by clients
+	textKeyModerate            = "moderate"
+	textKeyUnpublish           = "unpublish"
+	textKeyApproveJoin         = "approve_join"
+	textKeyUpdateAttributes    = "update_attributes"
+	textKeyUpdateTrackMetadata = "update_track_metadata"
+	textKeySignalAck           = "signal_ack"
+	textKeyListParticipants    = "list_participants"
+	// sent both ways, by signal-only participants
+	textKeyDataPacket = "data_packet"
+
+	// sent by the server
+	textKeyCapabilities            = "capabilities"
+	textKeyWaiting                 = "waiting"
+	textKeyRefreshToken            = "refresh_token"
+	textKeySignalSeq               = "signal_seq"
+	textKeyParticipantPage         = "participant_page"
+	textKeySubscribedQualityUpdate = "subscribed_quality_update"
+
+	// routed between nodes, on behalf of the admin API
+	textKeyResolveJoin = "resolve_join"
+)
+
+// textRequestKind returns the kind of signal request a text request sent with key is rate limited as
+func textRequestKind(key string) string {
+	if key == textKeyDataPacket {
+		return rtc.SignalKindData
+	}
+	return rtc.SignalKindOther
+}
+
+// textResponseKey returns the key of the response to a request sent with key
+func textResponseKey(key string) string {
+	return key + "_response"
+}
+
+// TextRequestHandler handles the value of a text request. The signal request it returns, if any, is
+// passed on like those read from the connection. Requests it returns an error for are dropped
+type TextRequestHandler func(value json.RawMessage) (*livekit.SignalRequest, error)
+
+// parseTextMessage returns the key and value of a text message, false when it's another message,
+// like a JSON encoded signal request
+func parseTextMessage(payload []byte) (string, json.RawMessage, bool) {
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(payload, &fields); err != nil || len(fields) != 1 {
+		return "", nil, false
+	}
+	for key, value := range fields {
+		return key, value, true
+	}
+	return "", nil, false
+}
+
+// marshalTextMessage encodes value as a text message sent with key
+func marshalTextMessage(key string, value interface{}) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{key: value})
+}
+
+// decodeTextRequest decodes the value of a text request into req
+func decodeTextRequest(value json.RawMessage, req interface{}) error {
+	if len(value) == 0 || string(value) == "null" {
+		return ErrInvalidTextRequest
+	}
+	return json.Unmarshal(value, req)
+}
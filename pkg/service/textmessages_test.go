@@ -0,0 +1,108 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestWSSignalConnectionTextRequests(t *testing.T) {
+	client := &typesfakes.FakeWebsocketClient{}
+	for i, payload := range []string{
+		`{"moderate": null}`,
+		`{"moderate": {"request_id": 1}}`,
+		`{"moderate": {"request_id": "1", "action": "remove", "identity": "bob"}}`,
+		`{"passthrough": {}}`,
+		`{"leave": {}}`,
+		`{"moderate": {}, "leave": {}}`,
+	} {
+		client.ReadMessageReturnsOnCall(i, websocket.TextMessage, []byte(payload), nil)
+	}
+	conn := &WSSignalConnection{conn: client}
+
+	var received []*ModerationRequest
+	conn.OnTextRequest(textKeyModerate, func(value json.RawMessage) (*livekit.SignalRequest, error) {
+		req := &ModerationRequest{}
+		if err := decodeTextRequest(value, req); err != nil {
+			return nil, err
+		}
+		received = append(received, req)
+		return nil, nil
+	})
+	conn.OnTextRequest("passthrough", func(value json.RawMessage) (*livekit.SignalRequest, error) {
+		// passed on like signal requests
+		return &livekit.SignalRequest{Message: &livekit.SignalRequest_Leave{Leave: &livekit.LeaveRequest{}}}, nil
+	})
+
+	// invalid requests are dropped, handled ones aren't returned
+	req, err := conn.ReadRequest()
+	require.NoError(t, err)
+	require.NotNil(t, req.GetLeave())
+	require.Equal(t, []*ModerationRequest{{RequestID: "1", Action: ModerationActionRemove, Identity: "bob"}}, received)
+	// text requests don't switch the encoding
+	require.False(t, conn.useJSON)
+
+	// keys without a handler are signal requests
+	req, err = conn.ReadRequest()
+	require.NoError(t, err)
+	require.NotNil(t, req.GetLeave())
+	require.True(t, conn.useJSON)
+
+	// so are messages with more than one field
+	_, err = conn.ReadRequest()
+	require.Error(t, err)
+	require.Len(t, received, 1)
+
+	require.NoError(t, conn.WriteTextMessage(textResponseKey(textKeyModerate), &ModerationResponse{RequestID: "1"}))
+	messageType, payload := client.WriteMessageArgsForCall(0)
+	require.Equal(t, websocket.TextMessage, messageType)
+	require.JSONEq(t, `{"moderate_response": {"request_id": "1"}}`, string(payload))
+}
+
+func TestWSSignalConnectionRateLimit(t *testing.T) {
+	client := &typesfakes.FakeWebsocketClient{}
+	for i, payload := range []string{
+		`{"moderate": {"request_id": "1"}}`,
+		`{"moderate": {"request_id": "2"}}`,
+		`{"mute": {"sid": "TR_webcam", "muted": true}}`,
+		`{"leave": {}}`,
+		`{"moderate": {"request_id": "3"}}`,
+	} {
+		client.ReadMessageReturnsOnCall(i, websocket.TextMessage, []byte(payload), nil)
+	}
+	conn := &WSSignalConnection{conn: client}
+	conn.LimitRate(rtc.NewSignalRateLimiter(config.SignalRateLimitConfig{
+		Other:           config.RateLimitConfig{Rate: 0.001, Burst: 1},
+		MaxViolations:   3,
+		ViolationWindow: time.Minute,
+	}))
+	var moderated []string
+	conn.OnTextRequest(textKeyModerate, func(value json.RawMessage) (*livekit.SignalRequest, error) {
+		req := &ModerationRequest{}
+		if err := decodeTextRequest(value, req); err != nil {
+			return nil, err
+		}
+		moderated = append(moderated, req.RequestID)
+		return nil, nil
+	})
+
+	// text requests handled here are limited like signal requests, they share the limit of other
+	// requests. Leave requests aren't limited
+	req, err := conn.ReadRequest()
+	require.NoError(t, err)
+	require.NotNil(t, req.GetLeave())
+	require.Equal(t, []string{"1"}, moderated)
+
+	// the third request over the limit disconnects the client
+	_, err = conn.ReadRequest()
+	require.Equal(t, ErrSignalRateLimitExceeded, err)
+	require.Equal(t, []string{"1"}, moderated)
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/livekit/protocol/logger"
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// roomTextRequestHandler carries out a text request of participant on the node hosting the room.
+// It returns the response to send the participant, if any, and the error the request was rejected
+// with
+type roomTextRequestHandler func(room *rtc.Room, participant types.Participant, value json.RawMessage) (interface{}, error)
+
+// textRequestHandler returns the handler of the text requests sent with key, which signal nodes pass
+// on to the node hosting the room and other nodes route on behalf of participants. nil for unknown
+// requests
+func (r *RoomManager) textRequestHandler(key string) roomTextRequestHandler {
+	switch key {
+	case textKeyUnpublish:
+		return r.handleUnpublishRequest
+	case textKeyApproveJoin:
+		return r.handleApproveJoinRequest
+	case textKeyUpdateAttributes:
+		return r.handleUpdateAttributesRequest
+	case textKeyUpdateTrackMetadata:
+		return r.handleUpdateTrackMetadataRequest
+	case textKeySignalAck:
+		return r.handleSignalAckRequest
+	}
+	return nil
+}
+
+// forwardTextRequest returns a handler passing the text requests sent with key on to the node
+// hosting the room, with the signal requests of the participant. That node responds to them
+func forwardTextRequest(key string) TextRequestHandler {
+	return func(value json.RawMessage) (*livekit.SignalRequest, error) {
+		if len(value) == 0 || string(value) == "null" {
+			return nil, ErrInvalidTextRequest
+		}
+		return rtc.NewSignalTextRequest(key, value), nil
+	}
+}
+
+// handleTextRequest carries out a text request participant sent over its signal connection, and
+// sends it the response
+func (r *RoomManager) handleTextRequest(room *rtc.Room, participant types.Participant, key string, value []byte) {
+	handler := r.textRequestHandler(key)
+	if handler == nil {
+		logger.Debugw("dropping unknown text request", "room", room.Room.Name,
+			"participant", participant.Identity(), "request", key)
+		return
+	}
+	res, err := handler(room, participant, value)
+	if err != nil {
+		logger.Infow("text request rejected", "room", room.Room.Name,
+			"participant", participant.Identity(), "request", key, "error", err)
+	}
+	if res == nil {
+		return
+	}
+	payload, err := json.Marshal(res)
+	if err != nil {
+		logger.Errorw("could not encode text response", err, "request", key)
+		return
+	}
+	if err := participant.SendTextMessage(textResponseKey(key), payload); err != nil {
+		logger.Warnw("could not send text response", err, "room", room.Room.Name,
+			"participant", participant.Identity(), "request", key)
+	}
+}
+
+// handleRoutedTextRequest carries out a text request another node routed on behalf of the
+// participant identity. There's no one to respond to, rejections are logged
+func (r *RoomManager) handleRoutedTextRequest(ctx context.Context, room *rtc.Room, identity, key string, value []byte) {
+	var err error
+	if key == textKeyResolveJoin {
+		// the participant waits to join, it isn't in the room yet
+		err = r.handleResolveJoinRequest(ctx, room, identity, value)
+	} else if handler := r.textRequestHandler(key); handler == nil {
+		logger.Debugw("dropping unknown routed text request", "room", room.Room.Name,
+			"participant", identity, "request", key)
+		return
+	} else if participant := room.GetParticipant(identity); participant == nil {
+		err = ErrParticipantNotFound
+	} else {
+		_, err = handler(room, participant, value)
+	}
+	if err != nil {
+		logger.Infow("routed text request rejected", "room", room.Room.Name, "participant", identity,
+			"request", key, "error", err)
+	}
+}
+
+// ensureRemoteRoom returns ErrRoomNotFound unless another node hosts the room
+func (r *RoomManager) ensureRemoteRoom(ctx context.Context, roomName string) error {
+	_, err := r.remoteRoomNode(ctx, roomName)
+	return err
+}
+
+// routeTextRequest sends a text request on behalf of the participant identity to the node hosting
+// the room, once ensureRemoteRoom found it's another node. That node only logs the errors it
+// rejects the request with, so callers check what they can before
+func (r *RoomManager) routeTextRequest(ctx context.Context, roomName, identity, key string, req interface{}) error {
+	value, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return r.router.WriteRoomRTC(ctx, roomName, identity, rtc.NewRTCNodeTextMessage(key, value))
+}
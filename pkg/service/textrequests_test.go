@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+// textRequestRooms has a room manager hosting the room "hosted", with alice in it. "remote" is
+// hosted by another node, other rooms don't exist
+type textRequestRooms struct {
+	roomManager *RoomManager
+	router      *routingfakes.FakeRouter
+	store       *LocalRoomStore
+	room        *rtc.Room
+	alice       *typesfakes.FakeParticipant
+}
+
+func newTextRequestRooms(t *testing.T) *textRequestRooms {
+	conf, err := config.NewConfig("", nil)
+	require.NoError(t, err)
+	node, err := routing.NewLocalNode(conf)
+	require.NoError(t, err)
+
+	router := &routingfakes.FakeRouter{}
+	router.GetNodeForRoomStub = func(ctx context.Context, roomName string) (*livekit.Node, error) {
+		switch roomName {
+		case "hosted":
+			return node, nil
+		case "remote":
+			return &livekit.Node{Id: "ND_other"}, nil
+		}
+		return nil, routing.ErrNotFound
+	}
+	store := NewLocalRoomStore()
+	roomManager, err := NewLocalRoomManager(conf, store, node, router, telemetry.NewTelemetryService(nil, nil, nil, nil))
+	require.NoError(t, err)
+	t.Cleanup(roomManager.Stop)
+
+	room := rtc.NewRoom(&livekit.Room{Name: "hosted"}, *roomManager.rtcConfig, &conf.Room, &conf.Audio,
+		telemetry.NewTelemetryService(nil, nil, nil, nil))
+	t.Cleanup(room.Close)
+	alice := &typesfakes.FakeParticipant{}
+	alice.IDReturns("PA_alice")
+	alice.IdentityReturns("alice")
+	alice.StateReturns(livekit.ParticipantInfo_JOINED)
+	require.NoError(t, room.Join(alice, &rtc.ParticipantOptions{}, nil))
+	roomManager.rooms["hosted"] = room
+
+	return &textRequestRooms{
+		roomManager: roomManager,
+		router:      router,
+		store:       store,
+		room:        room,
+		alice:       alice,
+	}
+}
+
+// routedTextRequest returns the text request the i-th message written to the node hosting a room
+// routes
+func (rooms *textRequestRooms) routedTextRequest(i int) (string, string, string, []byte) {
+	_, roomName, identity, msg := rooms.router.WriteRoomRTCArgsForCall(i)
+	key, value := rtc.RTCNodeMessageText(msg)
+	return roomName, identity, key, value
+}
+
+func TestHandleTextRequest(t *testing.T) {
+	rooms := newTextRequestRooms(t)
+
+	t.Run("unknown requests are dropped", func(t *testing.T) {
+		rooms.roomManager.handleTextRequest(rooms.room, rooms.alice, "unknown", []byte(`{}`))
+		require.Equal(t, 0, rooms.alice.SendTextMessageCallCount())
+	})
+
+	t.Run("invalid requests aren't answered", func(t *testing.T) {
+		rooms.roomManager.handleTextRequest(rooms.room, rooms.alice, textKeyUnpublish, []byte(`"TR_webcam"`))
+		require.Equal(t, 0, rooms.alice.SendTextMessageCallCount())
+	})
+
+	t.Run("routed requests need the participant", func(t *testing.T) {
+		rooms.roomManager.handleRoutedTextRequest(context.Background(), rooms.room, "bob", textKeyUnpublish, []byte(`{"track_sid": "TR_webcam"}`))
+		require.Equal(t, 0, rooms.alice.UnpublishTrackCallCount())
+	})
+}
+
+func TestEnsureRemoteRoom(t *testing.T) {
+	rooms := newTextRequestRooms(t)
+	ctx := context.Background()
+	require.NoError(t, rooms.roomManager.ensureRemoteRoom(ctx, "remote"))
+	require.Equal(t, ErrRoomNotFound, rooms.roomManager.ensureRemoteRoom(ctx, "hosted"))
+	require.Equal(t, ErrRoomNotFound, rooms.roomManager.ensureRemoteRoom(ctx, "unknown"))
+}
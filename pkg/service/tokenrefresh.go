@@ -0,0 +1,110 @@
+package service
+
+import (
+	"time"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/logger"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// RefreshToken is sent to clients over the signal connection as a JSON text message,
+// {"refresh_token": {...}}, before their access token expires. Clients should reconnect with it
+// instead of the token they joined with. The signal protocol has no message for it
+type RefreshToken struct {
+	Token string `json:"token"`
+	// unix time the token expires at
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+// tokenExpiry returns when a token expires, zero when it doesn't. Its signature must have been
+// verified already
+func tokenExpiry(token string) (time.Time, error) {
+	tok, err := jwt.ParseSigned(token)
+	if err != nil {
+		return time.Time{}, err
+	}
+	claims := jwt.Claims{}
+	if err := tok.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return time.Time{}, err
+	}
+	if claims.Expiry == nil {
+		return time.Time{}, nil
+	}
+	return claims.Expiry.Time(), nil
+}
+
+// refreshToken signs the claims of token again, valid for ttl from now. All claims are kept,
+// including grants the protocol's AccessToken doesn't know. The token is signed with the current
+// secret of its API key, it's not refreshed once the key was removed
+func refreshToken(provider auth.KeyProvider, token string, ttl time.Duration) (*RefreshToken, error) {
+	tok, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, err
+	}
+	claims := jwt.Claims{}
+	all := make(map[string]interface{})
+	if err := tok.UnsafeClaimsWithoutVerification(&claims, &all); err != nil {
+		return nil, err
+	}
+	secret := provider.GetSecret(claims.Issuer)
+	if secret == "" {
+		return nil, ErrTokenKeyRemoved
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	all["nbf"] = jwt.NewNumericDate(now)
+	all["exp"] = jwt.NewNumericDate(expiresAt)
+
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte(secret)},
+		(&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return nil, err
+	}
+	refreshed, err := jwt.Signed(sig).Claims(all).CompactSerialize()
+	if err != nil {
+		return nil, err
+	}
+	return &RefreshToken{Token: refreshed, ExpiresAt: expiresAt.Unix()}, nil
+}
+
+// refreshTokens sends the client a refreshed token conf.Before each token it has expires, until
+// done is closed
+func refreshTokens(
+	conf *config.TokenRefreshConfig,
+	provider auth.KeyProvider,
+	sigConn *WSSignalConnection,
+	token string,
+	identity string,
+	done <-chan struct{},
+) {
+	expiresAt, err := tokenExpiry(token)
+	if err != nil || expiresAt.IsZero() {
+		return
+	}
+	for {
+		timer := time.NewTimer(time.Until(expiresAt.Add(-conf.Before)))
+		select {
+		case <-done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		refreshed, err := refreshToken(provider, token, conf.TTL)
+		if err != nil {
+			logger.Warnw("could not refresh token", err, "participant", identity)
+			return
+		}
+		if err := sigConn.WriteTextMessage(textKeyRefreshToken, refreshed); err != nil {
+			logger.Warnw("error writing to websocket", err)
+			return
+		}
+		token = refreshed.Token
+		expiresAt = time.Unix(refreshed.ExpiresAt, 0)
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/livekit/protocol/auth"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestRefreshToken(t *testing.T) {
+	api := "APIabcdefg"
+	secret := "somesecretencodedinbase62"
+	provider := auth.NewFileBasedKeyProviderFromMap(map[string]string{api: secret})
+
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte(secret)},
+		(&jose.SignerOptions{}).WithType("JWT"))
+	require.NoError(t, err)
+	token, err := jwt.Signed(sig).
+		Claims(jwt.Claims{Issuer: api, Subject: "alice", Expiry: jwt.NewNumericDate(time.Now().Add(time.Minute))}).
+		Claims(map[string]interface{}{
+			"video":    map[string]interface{}{"room": "myroom", "roomJoin": true, "canModerate": true},
+			"metadata": "meta",
+		}).
+		CompactSerialize()
+	require.NoError(t, err)
+
+	t.Run("keeps the claims", func(t *testing.T) {
+		refreshed, err := refreshToken(provider, token, 10*time.Minute)
+		require.NoError(t, err)
+		require.InDelta(t, time.Now().Add(10*time.Minute).Unix(), refreshed.ExpiresAt, 1)
+
+		v, err := auth.ParseAPIToken(refreshed.Token)
+		require.NoError(t, err)
+		require.Equal(t, api, v.APIKey())
+		grants, err := v.Verify(secret)
+		require.NoError(t, err)
+		require.Equal(t, "alice", grants.Identity)
+		require.Equal(t, "meta", grants.Metadata)
+		require.Equal(t, "myroom", grants.Video.Room)
+		require.True(t, grants.Video.RoomJoin)
+		scopes, err := parseScopeGrants(refreshed.Token)
+		require.NoError(t, err)
+		require.True(t, scopes.CanModerate)
+
+		expiresAt, err := tokenExpiry(refreshed.Token)
+		require.NoError(t, err)
+		require.Equal(t, refreshed.ExpiresAt, expiresAt.Unix())
+	})
+
+	t.Run("key removed", func(t *testing.T) {
+		_, err := refreshToken(auth.NewFileBasedKeyProviderFromMap(map[string]string{}), token, 10*time.Minute)
+		require.ErrorIs(t, err, ErrTokenKeyRemoved)
+	})
+
+	t.Run("sent before the token expires", func(t *testing.T) {
+		client := &typesfakes.FakeWebsocketClient{}
+		conn := &WSSignalConnection{conn: client}
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+		go func() {
+			// the token expires within before, it's refreshed right away
+			refreshTokens(&config.TokenRefreshConfig{Before: 2 * time.Minute, TTL: 10 * time.Minute},
+				provider, conn, token, "alice", done)
+			close(stopped)
+		}()
+
+		require.Eventually(t, func() bool {
+			return client.WriteMessageCallCount() == 1
+		}, time.Second, 10*time.Millisecond)
+		messageType, payload := client.WriteMessageArgsForCall(0)
+		require.Equal(t, websocket.TextMessage, messageType)
+		msg := map[string]*RefreshToken{}
+		require.NoError(t, json.Unmarshal(payload, &msg))
+		require.NotEmpty(t, msg[textKeyRefreshToken].Token)
+
+		// the refreshed token isn't refreshed until it's about to expire
+		time.Sleep(50 * time.Millisecond)
+		require.Equal(t, 1, client.WriteMessageCallCount())
+		close(done)
+		<-stopped
+	})
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// UpdateTrackMetadataRequest is sent by clients over the signal connection as a JSON text message,
+// {"update_track_metadata": {...}}, to attach metadata to one of their tracks, like the title of a
+// shared window. The signal protocol has no request for it
+type UpdateTrackMetadataRequest struct {
+	// echoed in the response
+	RequestID string `json:"request_id,omitempty"`
+	TrackSid  string `json:"track_sid"`
+	// empty to remove it
+	Metadata string `json:"metadata"`
+}
+
+// UpdateTrackMetadataResponse tells a client whether the metadata of its track was updated
+type UpdateTrackMetadataResponse struct {
+	RequestID string `json:"request_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// handleUpdateTrackMetadataRequest attaches metadata to a track of participant on the node hosting
+// the room
+func (r *RoomManager) handleUpdateTrackMetadataRequest(room *rtc.Room, participant types.Participant, value json.RawMessage) (interface{}, error) {
+	req := &UpdateTrackMetadataRequest{}
+	if err := decodeTextRequest(value, req); err != nil {
+		return nil, err
+	}
+	res := &UpdateTrackMetadataResponse{RequestID: req.RequestID}
+	err := updateTrackMetadata(participant, req.TrackSid, req.Metadata)
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res, err
+}
+
+func updateTrackMetadata(participant types.Participant, trackSid, metadata string) error {
+	if trackSid == "" {
+		return ErrTrackNotFound
+	}
+	if err := participant.SetTrackMetadata(trackSid, metadata); err == rtc.ErrTrackNotFound {
+		return ErrTrackNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestUpdateTrackMetadataRequest(t *testing.T) {
+	rooms := newTextRequestRooms(t)
+	rooms.alice.SetTrackMetadataReturnsOnCall(1, rtc.ErrTrackNotFound)
+
+	rooms.roomManager.handleTextRequest(rooms.room, rooms.alice, textKeyUpdateTrackMetadata, []byte(`{"request_id": "1", "track_sid": "TR_screen", "metadata": "Slides"}`))
+	trackSid, metadata := rooms.alice.SetTrackMetadataArgsForCall(0)
+	require.Equal(t, "TR_screen", trackSid)
+	require.Equal(t, "Slides", metadata)
+	key, value := rooms.alice.SendTextMessageArgsForCall(0)
+	require.Equal(t, "update_track_metadata_response", key)
+	require.JSONEq(t, `{"request_id": "1"}`, string(value))
+
+	rooms.roomManager.handleTextRequest(rooms.room, rooms.alice, textKeyUpdateTrackMetadata, []byte(`{"request_id": "2", "track_sid": "TR_webcam"}`))
+	_, value = rooms.alice.SendTextMessageArgsForCall(1)
+	require.JSONEq(t, `{"request_id": "2", "error": "track is not found"}`, string(value))
+
+	rooms.roomManager.handleTextRequest(rooms.room, rooms.alice, textKeyUpdateTrackMetadata, []byte(`{"request_id": "3", "metadata": "Slides"}`))
+	require.Equal(t, 2, rooms.alice.SetTrackMetadataCallCount())
+	_, value = rooms.alice.SendTextMessageArgsForCall(2)
+	require.JSONEq(t, `{"request_id": "3", "error": "track is not found"}`, string(value))
+}
+
+func TestWSSignalConnectionUpdateTrackMetadata(t *testing.T) {
+	client := &typesfakes.FakeWebsocketClient{}
+	client.ReadMessageReturnsOnCall(0, websocket.TextMessage, []byte(`{"update_track_metadata": {"request_id": "1", "track_sid": "TR_screen", "metadata": "Slides"}}`), nil)
+	client.ReadMessageReturnsOnCall(1, websocket.BinaryMessage, []byte{}, nil)
+	conn := &WSSignalConnection{conn: client}
+
+	// passed on to the node hosting the room
+	conn.OnTextRequest(textKeyUpdateTrackMetadata, forwardTextRequest(textKeyUpdateTrackMetadata))
+	req, err := conn.ReadRequest()
+	require.NoError(t, err)
+	require.Nil(t, req.Message)
+	key, value := rtc.SignalRequestText(req)
+	require.Equal(t, textKeyUpdateTrackMetadata, key)
+	require.JSONEq(t, `{"request_id": "1", "track_sid": "TR_screen", "metadata": "Slides"}`, string(value))
+	// still protobuf
+	require.False(t, conn.useJSON)
+
+	require.NoError(t, conn.WriteResponse(rtc.NewSignalTextResponse(textResponseKey(textKeyUpdateTrackMetadata), []byte(`{"request_id": "1", "error": "track is not found"}`))))
+	messageType, payload := client.WriteMessageArgsForCall(0)
+	require.Equal(t, websocket.TextMessage, messageType)
+	require.JSONEq(t, `{"update_track_metadata_response": {"request_id": "1", "error": "track is not found"}}`, string(payload))
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+const (
+	transcriptionMessageStart = "start"
+
+	// audio packets queued for a stream, about 2s of 20ms Opus frames. Packets are dropped when the
+	// service doesn't keep up
+	transcriptionQueueSize   = 100
+	transcriptionDialTimeout = 10 * time.Second
+)
+
+// transcriptionStart is the first message of a stream, describing the track
+type transcriptionStart struct {
+	Type           string `json:"type"`
+	RoomName       string `json:"room_name"`
+	ParticipantSid string `json:"participant_sid"`
+	TrackSid       string `json:"track_sid"`
+	MimeType       string `json:"mime_type"`
+	ClockRate      uint32 `json:"clock_rate"`
+	Channels       uint16 `json:"channels"`
+	// empty when the service detects it
+	Language string `json:"language,omitempty"`
+}
+
+// transcriptionResult is a transcript sent by the service
+type transcriptionResult struct {
+	Text     string `json:"text"`
+	Final    bool   `json:"final"`
+	Language string `json:"language"`
+	// milliseconds since the start of the stream
+	StartTime int64 `json:"start_time"`
+	EndTime   int64 `json:"end_time"`
+}
+
+// WebSocketTranscriptionProvider streams the audio of each track to a speech-to-text service over
+// its own websocket. A stream starts with a JSON text message describing the track, followed by a
+// binary message with the payload of each audio packet. The service replies with JSON text messages,
+// one per transcript
+type WebSocketTranscriptionProvider struct {
+	conf   *config.TranscriptionConfig
+	dialer *websocket.Dialer
+}
+
+func NewWebSocketTranscriptionProvider(conf *config.TranscriptionConfig) *WebSocketTranscriptionProvider {
+	return &WebSocketTranscriptionProvider{
+		conf: conf,
+		dialer: &websocket.Dialer{
+			HandshakeTimeout: transcriptionDialTimeout,
+		},
+	}
+}
+
+func (p *WebSocketTranscriptionProvider) NewStream(info rtc.TranscriptionStreamInfo, onTranscript func(*rtc.Transcript)) (rtc.TranscriptionStream, error) {
+	conn, _, err := p.dialer.Dial(p.conf.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = conn.WriteJSON(&transcriptionStart{
+		Type:           transcriptionMessageStart,
+		RoomName:       info.RoomName,
+		ParticipantSid: info.ParticipantSid,
+		TrackSid:       info.TrackSid,
+		MimeType:       info.MimeType,
+		ClockRate:      info.ClockRate,
+		Channels:       info.Channels,
+		Language:       p.conf.Language,
+	})
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	s := &webSocketTranscriptionStream{
+		trackSid: info.TrackSid,
+		conn:     conn,
+		samples:  make(chan []byte, transcriptionQueueSize),
+		done:     make(chan struct{}),
+	}
+	go s.writeWorker()
+	go s.readWorker(onTranscript)
+	return s, nil
+}
+
+type webSocketTranscriptionStream struct {
+	trackSid  string
+	conn      *websocket.Conn
+	samples   chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *webSocketTranscriptionStream) WriteSample(payload []byte) error {
+	sample := make([]byte, len(payload))
+	copy(sample, payload)
+	select {
+	case <-s.done:
+	case s.samples <- sample:
+	default:
+		// service is behind, drop
+	}
+	return nil
+}
+
+func (s *webSocketTranscriptionStream) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	return nil
+}
+
+func (s *webSocketTranscriptionStream) writeWorker() {
+	defer func() {
+		_ = s.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(pingTimeout))
+		_ = s.conn.Close()
+	}()
+	for {
+		select {
+		case <-s.done:
+			return
+		case sample := <-s.samples:
+			if err := s.conn.WriteMessage(websocket.BinaryMessage, sample); err != nil {
+				logger.Warnw("could not write to transcription service", err, "track", s.trackSid)
+				_ = s.Close()
+				return
+			}
+		}
+	}
+}
+
+func (s *webSocketTranscriptionStream) readWorker(onTranscript func(*rtc.Transcript)) {
+	for {
+		res := &transcriptionResult{}
+		if err := s.conn.ReadJSON(res); err != nil {
+			// closed by either side
+			_ = s.Close()
+			return
+		}
+		onTranscript(&rtc.Transcript{
+			Text:      res.Text,
+			Final:     res.Final,
+			Language:  res.Language,
+			StartTime: time.Duration(res.StartTime) * time.Millisecond,
+			EndTime:   time.Duration(res.EndTime) * time.Millisecond,
+		})
+	}
+}
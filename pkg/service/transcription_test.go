@@ -0,0 +1,104 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+func TestWebSocketTranscriptionProvider(t *testing.T) {
+	starts := make(chan *transcriptionStart, 1)
+	samples := make(chan []byte, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		start := &transcriptionStart{}
+		if err := conn.ReadJSON(start); err != nil {
+			return
+		}
+		starts <- start
+		_, sample, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		samples <- sample
+		_ = conn.WriteJSON(&transcriptionResult{Text: "hello", Final: true, StartTime: 20, EndTime: 40})
+		// until the stream is closed
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	provider := NewWebSocketTranscriptionProvider(&config.TranscriptionConfig{
+		URL:      "ws" + strings.TrimPrefix(server.URL, "http"),
+		Language: "en",
+	})
+	transcripts := make(chan *rtc.Transcript, 1)
+	stream, err := provider.NewStream(rtc.TranscriptionStreamInfo{
+		RoomName:       "room",
+		ParticipantSid: "PA_publisher",
+		TrackSid:       "TR_mic",
+		MimeType:       "audio/opus",
+		ClockRate:      48000,
+		Channels:       2,
+	}, func(transcript *rtc.Transcript) {
+		transcripts <- transcript
+	})
+	require.NoError(t, err)
+	defer stream.Close()
+
+	select {
+	case start := <-starts:
+		require.Equal(t, &transcriptionStart{
+			Type:           transcriptionMessageStart,
+			RoomName:       "room",
+			ParticipantSid: "PA_publisher",
+			TrackSid:       "TR_mic",
+			MimeType:       "audio/opus",
+			ClockRate:      48000,
+			Channels:       2,
+			Language:       "en",
+		}, start)
+	case <-time.After(time.Second):
+		t.Fatal("stream was not started")
+	}
+
+	payload := []byte{1, 2, 3}
+	require.NoError(t, stream.WriteSample(payload))
+	// the payload is copied
+	payload[0] = 0
+	select {
+	case sample := <-samples:
+		require.Equal(t, []byte{1, 2, 3}, sample)
+	case <-time.After(time.Second):
+		t.Fatal("sample was not sent")
+	}
+
+	select {
+	case transcript := <-transcripts:
+		require.Equal(t, &rtc.Transcript{
+			Text:      "hello",
+			Final:     true,
+			StartTime: 20 * time.Millisecond,
+			EndTime:   40 * time.Millisecond,
+		}, transcript)
+	case <-time.After(time.Second):
+		t.Fatal("transcript was not received")
+	}
+}
@@ -24,7 +24,7 @@ const (
 
 func NewTurnServer(conf *config.Config, authHandler turn.AuthHandler) (*turn.Server, error) {
 	turnConf := conf.TURN
-	if !turnConf.Enabled {
+	if !turnConf.Enabled || conf.IsSignalRelay() {
 		return nil, nil
 	}
 
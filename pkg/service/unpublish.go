@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// UnpublishRequest is sent by clients over the signal connection as a JSON text message,
+// {"unpublish": {...}}, to stop publishing one of their tracks. The signal protocol has no
+// request for it
+type UnpublishRequest struct {
+	// echoed in the response
+	RequestID string `json:"request_id,omitempty"`
+	TrackSid  string `json:"track_sid"`
+}
+
+// UnpublishResponse tells a client whether its track was unpublished
+type UnpublishResponse struct {
+	RequestID string `json:"request_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// UnpublishTrack unpublishes a track of a participant. Requests for rooms hosted by other nodes
+// are routed to them, once the room's participant is known to publish the track
+func (r *RoomManager) UnpublishTrack(ctx context.Context, roomName, identity, trackSid string) error {
+	if trackSid == "" {
+		return ErrTrackNotFound
+	}
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		if err := r.ensureRemoteRoom(ctx, roomName); err != nil {
+			return err
+		}
+		pi, err := r.roomStore.LoadParticipant(ctx, roomName, identity)
+		if err != nil {
+			return err
+		}
+		if !publishesTrack(pi, trackSid) {
+			return ErrTrackNotFound
+		}
+		return r.routeTextRequest(ctx, roomName, identity, textKeyUnpublish, &UnpublishRequest{TrackSid: trackSid})
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		return ErrParticipantNotFound
+	}
+	return unpublishTrack(participant, trackSid)
+}
+
+// handleUnpublishRequest unpublishes a track of participant on the node hosting the room
+func (r *RoomManager) handleUnpublishRequest(room *rtc.Room, participant types.Participant, value json.RawMessage) (interface{}, error) {
+	req := &UnpublishRequest{}
+	if err := decodeTextRequest(value, req); err != nil {
+		return nil, err
+	}
+	res := &UnpublishResponse{RequestID: req.RequestID}
+	err := unpublishTrack(participant, req.TrackSid)
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res, err
+}
+
+func unpublishTrack(participant types.Participant, trackSid string) error {
+	if trackSid == "" {
+		return ErrTrackNotFound
+	}
+	if err := participant.UnpublishTrack(trackSid); err == rtc.ErrTrackNotFound {
+		return ErrTrackNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// publishesTrack returns true when the participant info lists the track
+func publishesTrack(pi *livekit.ParticipantInfo, trackSid string) bool {
+	for _, track := range pi.Tracks {
+		if track.Sid == trackSid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestUnpublishTrack(t *testing.T) {
+	rooms := newTextRequestRooms(t)
+	ctx := context.Background()
+	require.Equal(t, ErrTrackNotFound, rooms.roomManager.UnpublishTrack(ctx, "hosted", "alice", ""))
+	require.Equal(t, ErrRoomNotFound, rooms.roomManager.UnpublishTrack(ctx, "unknown", "bob", "TR_webcam"))
+
+	t.Run("hosted rooms", func(t *testing.T) {
+		require.Equal(t, ErrParticipantNotFound, rooms.roomManager.UnpublishTrack(ctx, "hosted", "bob", "TR_webcam"))
+		rooms.alice.UnpublishTrackReturnsOnCall(0, rtc.ErrTrackNotFound)
+		require.Equal(t, ErrTrackNotFound, rooms.roomManager.UnpublishTrack(ctx, "hosted", "alice", "TR_mic"))
+		require.NoError(t, rooms.roomManager.UnpublishTrack(ctx, "hosted", "alice", "TR_webcam"))
+		require.Equal(t, "TR_webcam", rooms.alice.UnpublishTrackArgsForCall(1))
+		require.Equal(t, 0, rooms.router.WriteRoomRTCCallCount())
+	})
+
+	t.Run("rooms hosted by other nodes", func(t *testing.T) {
+		require.NoError(t, rooms.store.StoreParticipant(ctx, "remote", &livekit.ParticipantInfo{
+			Identity: "bob",
+			Tracks:   []*livekit.TrackInfo{{Sid: "TR_webcam"}},
+		}))
+		require.Equal(t, ErrParticipantNotFound, rooms.roomManager.UnpublishTrack(ctx, "remote", "carol", "TR_webcam"))
+		require.Equal(t, ErrTrackNotFound, rooms.roomManager.UnpublishTrack(ctx, "remote", "bob", "TR_mic"))
+		require.Equal(t, 0, rooms.router.WriteRoomRTCCallCount())
+
+		require.NoError(t, rooms.roomManager.UnpublishTrack(ctx, "remote", "bob", "TR_webcam"))
+		require.Equal(t, 1, rooms.router.WriteRoomRTCCallCount())
+		roomName, identity, key, value := rooms.routedTextRequest(0)
+		require.Equal(t, "remote", roomName)
+		require.Equal(t, "bob", identity)
+		require.Equal(t, textKeyUnpublish, key)
+		require.JSONEq(t, `{"track_sid": "TR_webcam"}`, string(value))
+	})
+
+	t.Run("routed requests", func(t *testing.T) {
+		msg := rtc.NewRTCNodeTextMessage(textKeyUnpublish, []byte(`{"track_sid": "TR_screen"}`))
+		rooms.roomManager.handleRTCMessage(ctx, "hosted", "alice", msg)
+		require.Equal(t, "TR_screen", rooms.alice.UnpublishTrackArgsForCall(rooms.alice.UnpublishTrackCallCount()-1))
+	})
+}
+
+func TestUnpublishRequest(t *testing.T) {
+	rooms := newTextRequestRooms(t)
+	rooms.alice.UnpublishTrackReturnsOnCall(1, rtc.ErrTrackNotFound)
+
+	rooms.roomManager.handleTextRequest(rooms.room, rooms.alice, textKeyUnpublish, []byte(`{"request_id": "1", "track_sid": "TR_webcam"}`))
+	require.Equal(t, "TR_webcam", rooms.alice.UnpublishTrackArgsForCall(0))
+	key, value := rooms.alice.SendTextMessageArgsForCall(0)
+	require.Equal(t, "unpublish_response", key)
+	require.JSONEq(t, `{"request_id": "1"}`, string(value))
+
+	rooms.roomManager.handleTextRequest(rooms.room, rooms.alice, textKeyUnpublish, []byte(`{"request_id": "2", "track_sid": "TR_mic"}`))
+	_, value = rooms.alice.SendTextMessageArgsForCall(1)
+	require.JSONEq(t, `{"request_id": "2", "error": "track is not found"}`, string(value))
+}
+
+func TestWSSignalConnectionUnpublish(t *testing.T) {
+	client := &typesfakes.FakeWebsocketClient{}
+	client.ReadMessageReturnsOnCall(0, websocket.TextMessage, []byte(`{"unpublish": {"request_id": "1", "track_sid": "TR_webcam"}}`), nil)
+	client.ReadMessageReturnsOnCall(1, websocket.BinaryMessage, []byte{}, nil)
+	conn := &WSSignalConnection{conn: client}
+
+	// passed on to the node hosting the room
+	conn.OnTextRequest(textKeyUnpublish, forwardTextRequest(textKeyUnpublish))
+	req, err := conn.ReadRequest()
+	require.NoError(t, err)
+	require.Nil(t, req.Message)
+	key, value := rtc.SignalRequestText(req)
+	require.Equal(t, textKeyUnpublish, key)
+	require.JSONEq(t, `{"request_id": "1", "track_sid": "TR_webcam"}`, string(value))
+	// still protobuf
+	require.False(t, conn.useJSON)
+
+	// and answered by it
+	require.NoError(t, conn.WriteResponse(rtc.NewSignalTextResponse(textResponseKey(textKeyUnpublish), []byte(`{"request_id": "1", "error": "track is not found"}`))))
+	messageType, payload := client.WriteMessageArgsForCall(0)
+	require.Equal(t, websocket.TextMessage, messageType)
+	require.JSONEq(t, `{"unpublish_response": {"request_id": "1", "error": "track is not found"}}`, string(payload))
+}
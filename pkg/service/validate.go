@@ -0,0 +1,117 @@
+package service
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/thoas/go-funk"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// ValidateDeployment checks that the environment matches the configuration, in addition to
+// config.Validate. It binds the ports the server would use, so it must not be run alongside it
+func ValidateDeployment(conf *config.Config) []*config.ValidationIssue {
+	issues := conf.Validate()
+	add := func(warning bool, field, format string, args ...interface{}) {
+		issues = append(issues, &config.ValidationIssue{
+			Field:   field,
+			Message: fmt.Sprintf(format, args...),
+			Warning: warning,
+		})
+	}
+
+	for _, p := range []struct {
+		field   string
+		network string
+		port    int
+	}{
+		{"port", "tcp", int(conf.Port)},
+		{"prometheus_port", "tcp", int(conf.PrometheusPort)},
+		{"rtc.tcp_port", "tcp", int(conf.RTC.TCPPort)},
+		{"rtc.udp_port", "udp", int(conf.RTC.UDPPort)},
+		{"turn.tls_port", "tcp", turnPort(conf, conf.TURN.TLSPort)},
+		{"turn.udp_port", "udp", turnPort(conf, conf.TURN.UDPPort)},
+	} {
+		if p.port == 0 {
+			continue
+		}
+		if err := checkPortAvailable(p.network, p.port); err != nil {
+			add(false, p.field, "%s port %d is not available, stop what's using it or pick another: %v", p.network, p.port, err)
+		}
+	}
+
+	// clients need to reach the advertised IP
+	stunServers := conf.RTC.StunServers
+	if len(stunServers) == 0 {
+		stunServers = config.DefaultStunServers
+	}
+	externalIP, err := config.GetExternalIP(stunServers)
+	if err != nil {
+		add(true, "rtc.stun_servers", "could not determine the external IP through STUN, UDP to %s may be blocked: %v", stunServers[0], err)
+	} else if externalIP != conf.RTC.NodeIP {
+		add(true, "rtc.node_ip", "advertising %s, but this node is reachable from the internet as %s. Set use_external_ip if clients connect from outside this network",
+			conf.RTC.NodeIP, externalIP)
+	}
+
+	if conf.TURN.Enabled && conf.TURN.Domain != "" {
+		if !IsValidDomain(conf.TURN.Domain) {
+			add(false, "turn.domain", "%s is not a valid domain name", conf.TURN.Domain)
+		} else if addrs, err := net.LookupHost(conf.TURN.Domain); err != nil {
+			add(false, "turn.domain", "%s does not resolve, clients won't be able to reach TURN: %v", conf.TURN.Domain, err)
+		} else if !funk.ContainsString(addrs, conf.RTC.NodeIP) && !funk.ContainsString(addrs, externalIP) {
+			add(true, "turn.domain", "%s resolves to %v rather than this node, which is fine only behind a load balancer", conf.TURN.Domain, addrs)
+		}
+	}
+	if conf.TURN.Enabled && conf.TURN.TLSPort > 0 && conf.TURN.CertFile != "" && conf.TURN.KeyFile != "" {
+		if _, err := tls.LoadX509KeyPair(conf.TURN.CertFile, conf.TURN.KeyFile); err != nil {
+			add(false, "turn.cert_file", "could not load TURN certificate: %v", err)
+		}
+	}
+
+	if conf.HasRedis() {
+		if rc, err := createRedisClient(conf); err != nil {
+			add(false, "redis.address", "%v", err)
+		} else {
+			_ = rc.Close()
+		}
+	}
+
+	if conf.KeyFile != "" || len(conf.Keys) != 0 {
+		if provider, err := createKeyProvider(conf); err != nil {
+			add(false, "key_file", "%v", err)
+		} else if len(conf.WebHook.URLs) != 0 && conf.WebHook.APIKey != "" && provider.GetSecret(conf.WebHook.APIKey) == "" {
+			add(false, "webhook.api_key", "%s is not one of the configured keys", conf.WebHook.APIKey)
+		} else if conf.Agents.Enabled && conf.Agents.APIKey != "" && provider.GetSecret(conf.Agents.APIKey) == "" {
+			add(false, "agents.api_key", "%s is not one of the configured keys", conf.Agents.APIKey)
+		}
+	}
+
+	return issues
+}
+
+func turnPort(conf *config.Config, port int) int {
+	if !conf.TURN.Enabled {
+		return 0
+	}
+	return port
+}
+
+func checkPortAvailable(network string, port int) error {
+	addr := net.JoinHostPort("", strconv.Itoa(port))
+	if network == "udp" {
+		conn, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return l.Close()
+}
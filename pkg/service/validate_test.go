@@ -0,0 +1,24 @@
+package service
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPortAvailable(t *testing.T) {
+	l, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	require.Error(t, checkPortAvailable("tcp", port))
+	require.NoError(t, l.Close())
+	require.NoError(t, checkPortAvailable("tcp", port))
+
+	conn, err := net.ListenPacket("udp", ":0")
+	require.NoError(t, err)
+	port = conn.LocalAddr().(*net.UDPAddr).Port
+	require.Error(t, checkPortAvailable("udp", port))
+	require.NoError(t, conn.Close())
+	require.NoError(t, checkPortAvailable("udp", port))
+}
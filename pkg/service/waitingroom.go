@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+// Waiting is sent to participants over the signal connection as a JSON text message,
+// {"waiting": {...}}, when they're held in the waiting room. They receive the join response once
+// their join is approved, and a leave request when it's denied
+type Waiting struct {
+	Room string `json:"room"`
+}
+
+// JoinApprovalRequest is sent by participants with the canApproveJoins grant over the signal
+// connection as a JSON text message, {"approve_join": {...}}, to let a participant waiting in the
+// waiting room join, or to turn it away. The signal protocol has no request for it
+type JoinApprovalRequest struct {
+	// echoed in the response
+	RequestID string `json:"request_id,omitempty"`
+	Identity  string `json:"identity"`
+	Approved  bool   `json:"approved"`
+}
+
+// JoinApprovalResponse tells a client whether the join was approved or denied
+type JoinApprovalResponse struct {
+	RequestID string `json:"request_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ResolveJoinRequest is routed to the node hosting the room to resolve the join of a participant
+// waiting in the waiting room, through the admin API of another node
+type ResolveJoinRequest struct {
+	Approved bool `json:"approved"`
+}
+
+// pendingJoin is a session held in the waiting room, it's started once the join is approved
+type pendingJoin struct {
+	// context of the session, used once it's started
+	ctx           context.Context
+	info          *rtc.PendingJoin
+	pi            routing.ParticipantInit
+	requestSource routing.MessageSource
+	responseSink  routing.MessageSink
+	// closed when the join is no longer pending
+	done chan struct{}
+}
+
+func (j *pendingJoin) toProto() *livekit.ParticipantInfo {
+	return &livekit.ParticipantInfo{
+		Identity: j.info.Identity,
+		Metadata: j.info.Metadata,
+		State:    livekit.ParticipantInfo_JOINING,
+		JoinedAt: j.info.RequestedAt,
+	}
+}
+
+// leave closes the session of a join that won't be approved
+func (j *pendingJoin) leave() {
+	if err := j.responseSink.WriteMessage(&livekit.SignalResponse{
+		Message: &livekit.SignalResponse_Leave{
+			Leave: &livekit.LeaveRequest{},
+		},
+	}); err != nil {
+		logger.Warnw("could not send leave request", err, "participant", j.info.Identity)
+	}
+	j.responseSink.Close()
+}
+
+// waitingRoomEnabled returns true when participants joining roomName have to be approved. Policies
+// of rooms created with one of their own are in the room store
+func (r *RoomManager) waitingRoomEnabled(ctx context.Context, roomName string) bool {
+	policy, err := r.roomStore.LoadRoomPolicy(ctx, roomName)
+	if err != nil {
+		logger.Warnw("could not load room policy", err, "room", roomName)
+	}
+	return r.getConfig().Room.Policy.WithOverride(policy).WaitingRoomEnabled()
+}
+
+// holdJoin keeps a session in the waiting room until its join is approved or denied, or the client
+// leaves
+func (r *RoomManager) holdJoin(ctx context.Context, room *rtc.Room, pi routing.ParticipantInit, requestSource routing.MessageSource, responseSink routing.MessageSink) {
+	roomName := room.Room.Name
+	join := &pendingJoin{
+		ctx: ctx,
+		info: &rtc.PendingJoin{
+			Identity:    pi.Identity,
+			Metadata:    pi.Metadata,
+			RequestedAt: time.Now().Unix(),
+		},
+		pi:            pi,
+		requestSource: requestSource,
+		responseSink:  responseSink,
+		done:          make(chan struct{}),
+	}
+
+	r.lock.Lock()
+	joins := r.pendingJoins[roomName]
+	if joins == nil {
+		joins = make(map[string]*pendingJoin)
+		r.pendingJoins[roomName] = joins
+	}
+	replaced := joins[pi.Identity]
+	joins[pi.Identity] = join
+	r.lock.Unlock()
+
+	if replaced != nil {
+		// the participant connected again, the earlier connection gives up its place
+		close(replaced.done)
+		replaced.responseSink.Close()
+	}
+	r.updatePendingJoins(room)
+
+	logger.Infow("participant waiting for approval", "room", roomName, "participant", pi.Identity)
+	r.telemetry.ParticipantPending(ctx, room.Room, join.toProto())
+	r.events.publishParticipant(telemetry.EventParticipantPending, room.Room, join.toProto())
+
+	go r.pendingJoinWorker(room, join)
+}
+
+// pendingJoinWorker waits for the client of a pending join to leave. Requests it sends before it
+// joined are dropped
+func (r *RoomManager) pendingJoinWorker(room *rtc.Room, join *pendingJoin) {
+	for {
+		select {
+		case <-join.done:
+			return
+		case obj := <-join.requestSource.ReadChan():
+			if obj != nil {
+				if req, ok := obj.(*livekit.SignalRequest); !ok || req.GetLeave() == nil {
+					continue
+				}
+			}
+			if r.takePendingJoin(room.Room.Name, join.info.Identity, join) == nil {
+				return
+			}
+			join.responseSink.Close()
+			r.updatePendingJoins(room)
+
+			logger.Infow("participant left the waiting room", "room", room.Room.Name, "participant", join.info.Identity)
+			r.telemetry.ParticipantPendingLeft(join.ctx, room.Room, join.toProto())
+			r.events.publishParticipant(telemetry.EventParticipantPendingLeft, room.Room, join.toProto())
+			return
+		}
+	}
+}
+
+// takePendingJoin removes the pending join of identity from the waiting room, any join of identity
+// when join is nil. It returns nil when there's no such join
+func (r *RoomManager) takePendingJoin(roomName, identity string, join *pendingJoin) *pendingJoin {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	joins := r.pendingJoins[roomName]
+	pending := joins[identity]
+	if pending == nil || (join != nil && pending != join) {
+		return nil
+	}
+	delete(joins, identity)
+	if len(joins) == 0 {
+		delete(r.pendingJoins, roomName)
+	}
+	close(pending.done)
+	return pending
+}
+
+// updatePendingJoins tells the room who's waiting, in the order they asked to join
+func (r *RoomManager) updatePendingJoins(room *rtc.Room) {
+	pending := r.ListPendingJoins(room.Room.Name)
+	room.SetPendingJoins(pending)
+}
+
+// ListPendingJoins returns the participants waiting to join a room hosted on this node, in the
+// order they asked to join
+func (r *RoomManager) ListPendingJoins(roomName string) []*rtc.PendingJoin {
+	r.lock.RLock()
+	pending := make([]*rtc.PendingJoin, 0, len(r.pendingJoins[roomName]))
+	for _, join := range r.pendingJoins[roomName] {
+		pending = append(pending, join.info)
+	}
+	r.lock.RUnlock()
+
+	sort.Slice(pending, func(i, j int) bool {
+		if pending[i].RequestedAt != pending[j].RequestedAt {
+			return pending[i].RequestedAt < pending[j].RequestedAt
+		}
+		return pending[i].Identity < pending[j].Identity
+	})
+	return pending
+}
+
+// ResolveJoin lets a participant waiting to join a room join, or turns it away. Joins in rooms
+// hosted by other nodes are resolved by them, they only log when no such participant waits
+func (r *RoomManager) ResolveJoin(ctx context.Context, roomName, identity string, approved bool) error {
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		if err := r.ensureRemoteRoom(ctx, roomName); err != nil {
+			return err
+		}
+		return r.routeTextRequest(ctx, roomName, identity, textKeyResolveJoin, &ResolveJoinRequest{Approved: approved})
+	}
+	join := r.takePendingJoin(roomName, identity, nil)
+	if join == nil {
+		return ErrParticipantNotFound
+	}
+	r.updatePendingJoins(room)
+
+	if approved {
+		logger.Infow("join approved", "room", roomName, "participant", identity)
+		r.joinRoom(join.ctx, room, join.pi, join.requestSource, join.responseSink)
+		return nil
+	}
+
+	logger.Infow("join denied", "room", roomName, "participant", identity)
+	join.leave()
+	r.telemetry.ParticipantDenied(join.ctx, room.Room, join.toProto())
+	r.events.publishParticipant(telemetry.EventParticipantDenied, room.Room, join.toProto())
+	return nil
+}
+
+// dropPendingJoins turns away the participants waiting to join a room that closed
+func (r *RoomManager) dropPendingJoins(roomName string) {
+	r.lock.Lock()
+	joins := r.pendingJoins[roomName]
+	delete(r.pendingJoins, roomName)
+	for _, join := range joins {
+		close(join.done)
+	}
+	r.lock.Unlock()
+
+	for _, join := range joins {
+		join.leave()
+	}
+}
+
+// ensureJoinApproval validates a join approval request of a participant, with the grants of its
+// token
+func ensureJoinApproval(ctx context.Context, req *JoinApprovalRequest) error {
+	if err := EnsureApproveJoinsPermission(ctx); err != nil {
+		return err
+	}
+	if req.Identity == "" {
+		return ErrParticipantNotFound
+	}
+	return nil
+}
+
+// handleApproveJoinRequest resolves a join participant approved or denied, on the node hosting the
+// room
+func (r *RoomManager) handleApproveJoinRequest(room *rtc.Room, participant types.Participant, value json.RawMessage) (interface{}, error) {
+	req := &JoinApprovalRequest{}
+	if err := decodeTextRequest(value, req); err != nil {
+		return nil, err
+	}
+	res := &JoinApprovalResponse{RequestID: req.RequestID}
+	var err error
+	switch {
+	case !participant.CanApproveJoins():
+		err = ErrPermissionDenied
+	case req.Identity == "":
+		err = ErrParticipantNotFound
+	default:
+		err = r.ResolveJoin(context.Background(), room.Room.Name, req.Identity, req.Approved)
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res, err
+}
+
+// handleResolveJoinRequest resolves the join of the participant identity, routed from the admin API
+// of another node
+func (r *RoomManager) handleResolveJoinRequest(ctx context.Context, room *rtc.Room, identity string, value json.RawMessage) error {
+	req := &ResolveJoinRequest{}
+	if err := decodeTextRequest(value, req); err != nil {
+		return err
+	}
+	return r.ResolveJoin(ctx, room.Room.Name, identity, req.Approved)
+}
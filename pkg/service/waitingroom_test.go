@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+func TestWaitingRoom(t *testing.T) {
+	newRoomManager := func(t *testing.T) (*RoomManager, *rtc.Room) {
+		room := rtc.NewRoom(&livekit.Room{Name: "room"}, rtc.WebRTCConfig{}, &config.RoomConfig{},
+			&config.AudioConfig{UpdateInterval: 500}, telemetry.NewTelemetryService(nil, nil, nil, nil))
+		t.Cleanup(room.Close)
+		router := &routingfakes.FakeRouter{}
+		router.GetNodeForRoomStub = func(ctx context.Context, roomName string) (*livekit.Node, error) {
+			if roomName == "remote" {
+				return &livekit.Node{Id: "ND_other"}, nil
+			}
+			return nil, routing.ErrNotFound
+		}
+		r := &RoomManager{
+			currentNode:  &livekit.Node{Id: "ND_local"},
+			router:       router,
+			rooms:        map[string]*rtc.Room{"room": room},
+			pendingJoins: make(map[string]map[string]*pendingJoin),
+			events:       NewRoomEventHub(),
+			telemetry:    telemetry.NewTelemetryService(nil, nil, nil, nil),
+		}
+		return r, room
+	}
+	hold := func(r *RoomManager, room *rtc.Room, identity string) (chan protoreflect.ProtoMessage, *routingfakes.FakeMessageSink) {
+		requests := make(chan protoreflect.ProtoMessage, 1)
+		source := &routingfakes.FakeMessageSource{}
+		source.ReadChanReturns(requests)
+		sink := &routingfakes.FakeMessageSink{}
+		r.holdJoin(context.Background(), room, routing.ParticipantInit{Identity: identity, AwaitApproval: true}, source, sink)
+		return requests, sink
+	}
+
+	t.Run("denied", func(t *testing.T) {
+		r, room := newRoomManager(t)
+		events := r.events.Subscribe("room")
+		_, sink := hold(r, room, "bob")
+
+		pending := r.ListPendingJoins("room")
+		require.Len(t, pending, 1)
+		require.Equal(t, "bob", pending[0].Identity)
+		event := <-events.events
+		require.Equal(t, telemetry.EventParticipantPending, event.Event)
+		require.Equal(t, "bob", event.Participant.Identity)
+
+		require.Equal(t, ErrParticipantNotFound, r.ResolveJoin(context.Background(), "room", "alice", false))
+		require.Equal(t, ErrRoomNotFound, r.ResolveJoin(context.Background(), "other", "bob", false))
+
+		require.NoError(t, r.ResolveJoin(context.Background(), "room", "bob", false))
+		require.Empty(t, r.ListPendingJoins("room"))
+		require.Equal(t, 1, sink.WriteMessageCallCount())
+		leave := sink.WriteMessageArgsForCall(0).(*livekit.SignalResponse).GetLeave()
+		require.NotNil(t, leave)
+		require.False(t, leave.CanReconnect)
+		require.Equal(t, 1, sink.CloseCallCount())
+		require.Equal(t, telemetry.EventParticipantDenied, (<-events.events).Event)
+
+		// no longer pending
+		require.Equal(t, ErrParticipantNotFound, r.ResolveJoin(context.Background(), "room", "bob", true))
+	})
+
+	t.Run("resolved by participants", func(t *testing.T) {
+		r, room := newRoomManager(t)
+		_, sink := hold(r, room, "bob")
+		approver := &typesfakes.FakeParticipant{}
+		approver.IdentityReturns("alice")
+
+		r.handleTextRequest(room, approver, textKeyApproveJoin, []byte(`{"request_id": "1", "identity": "bob"}`))
+		key, value := approver.SendTextMessageArgsForCall(0)
+		require.Equal(t, "approve_join_response", key)
+		require.JSONEq(t, `{"request_id": "1", "error": "`+ErrPermissionDenied.Error()+`"}`, string(value))
+		require.Len(t, r.ListPendingJoins("room"), 1)
+
+		approver.CanApproveJoinsReturns(true)
+		r.handleTextRequest(room, approver, textKeyApproveJoin, []byte(`{"request_id": "2", "identity": "bob"}`))
+		_, value = approver.SendTextMessageArgsForCall(1)
+		require.JSONEq(t, `{"request_id": "2"}`, string(value))
+		require.Empty(t, r.ListPendingJoins("room"))
+		require.Equal(t, 1, sink.CloseCallCount())
+	})
+
+	t.Run("rooms hosted by other nodes", func(t *testing.T) {
+		r, room := newRoomManager(t)
+		router := r.router.(*routingfakes.FakeRouter)
+		require.NoError(t, r.ResolveJoin(context.Background(), "remote", "bob", true))
+		_, roomName, identity, msg := router.WriteRoomRTCArgsForCall(0)
+		require.Equal(t, "remote", roomName)
+		require.Equal(t, "bob", identity)
+		key, value := rtc.RTCNodeMessageText(msg)
+		require.Equal(t, textKeyResolveJoin, key)
+		require.JSONEq(t, `{"approved": true}`, string(value))
+
+		// resolved by the node hosting the room
+		_, sink := hold(r, room, "bob")
+		r.handleRTCMessage(context.Background(), "room", "bob", rtc.NewRTCNodeTextMessage(textKeyResolveJoin, []byte(`{"approved": false}`)))
+		require.Empty(t, r.ListPendingJoins("room"))
+		require.Equal(t, 1, sink.CloseCallCount())
+	})
+
+	t.Run("left while waiting", func(t *testing.T) {
+		r, room := newRoomManager(t)
+		events := r.events.Subscribe("room")
+		requests, sink := hold(r, room, "bob")
+		<-events.events
+
+		// dropped, the participant didn't join yet
+		requests <- &livekit.SignalRequest{Message: &livekit.SignalRequest_Mute{Mute: &livekit.MuteTrackRequest{}}}
+		requests <- &livekit.SignalRequest{Message: &livekit.SignalRequest_Leave{Leave: &livekit.LeaveRequest{}}}
+		select {
+		case event := <-events.events:
+			require.Equal(t, telemetry.EventParticipantPendingLeft, event.Event)
+		case <-time.After(time.Second):
+			require.Fail(t, "participant didn't leave")
+		}
+		require.Empty(t, r.ListPendingJoins("room"))
+		require.Equal(t, 1, sink.CloseCallCount())
+		require.Zero(t, sink.WriteMessageCallCount())
+	})
+
+	t.Run("connected again", func(t *testing.T) {
+		r, room := newRoomManager(t)
+		_, first := hold(r, room, "bob")
+		_, second := hold(r, room, "bob")
+		require.Equal(t, 1, first.CloseCallCount())
+		require.Len(t, r.ListPendingJoins("room"), 1)
+
+		// turned away when the room closes
+		r.dropPendingJoins("room")
+		require.Empty(t, r.ListPendingJoins("room"))
+		require.Equal(t, 1, second.WriteMessageCallCount())
+		require.Equal(t, 1, second.CloseCallCount())
+	})
+}
+
+func TestWSSignalConnectionJoinApproval(t *testing.T) {
+	client := &typesfakes.FakeWebsocketClient{}
+	client.ReadMessageReturnsOnCall(0, websocket.TextMessage, []byte(`{"approve_join": {"request_id": "1", "identity": "bob", "approved": true}}`), nil)
+	client.ReadMessageReturnsOnCall(1, websocket.BinaryMessage, []byte{}, nil)
+	conn := &WSSignalConnection{conn: client}
+
+	// passed on to the node hosting the room
+	conn.OnTextRequest(textKeyApproveJoin, forwardTextRequest(textKeyApproveJoin))
+	req, err := conn.ReadRequest()
+	require.NoError(t, err)
+	key, value := rtc.SignalRequestText(req)
+	require.Equal(t, textKeyApproveJoin, key)
+	require.JSONEq(t, `{"request_id": "1", "identity": "bob", "approved": true}`, string(value))
+
+	require.NoError(t, conn.WriteTextMessage(textKeyWaiting, &Waiting{Room: "room"}))
+	_, payload := client.WriteMessageArgsForCall(0)
+	require.JSONEq(t, `{"waiting": {"room": "room"}}`, string(payload))
+
+	require.NoError(t, conn.WriteTextMessage(textResponseKey(textKeyApproveJoin), &JoinApprovalResponse{RequestID: "1", Error: "permission denied"}))
+	_, payload = client.WriteMessageArgsForCall(1)
+	require.JSONEq(t, `{"approve_join_response": {"request_id": "1", "error": "permission denied"}}`, string(payload))
+}
+
+func TestEnsureJoinApproval(t *testing.T) {
+	req := &JoinApprovalRequest{Identity: "bob", Approved: true}
+	require.Equal(t, ErrPermissionDenied, ensureJoinApproval(context.Background(), req))
+	ctx := context.WithValue(context.Background(), scopesKey, &ScopeGrants{CanModerate: true})
+	require.Equal(t, ErrPermissionDenied, ensureJoinApproval(ctx, req))
+	ctx = context.WithValue(context.Background(), scopesKey, &ScopeGrants{CanApproveJoins: true})
+	require.NoError(t, ensureJoinApproval(ctx, req))
+	require.Equal(t, ErrParticipantNotFound, ensureJoinApproval(ctx, &JoinApprovalRequest{}))
+}
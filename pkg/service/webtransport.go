@@ -0,0 +1,179 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/logger"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/webtransport-go"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	// a frame is the websocket message type, then the length of the payload
+	wtFrameHeaderSize = 5
+	maxWTMessageSize  = 1 << 20
+	// time clients have to open the signal stream once the session is established
+	wtStreamTimeout   = 5 * time.Second
+	wtKeepAlivePeriod = 10 * time.Second
+)
+
+// WebTransportServer serves the signal connection over WebTransport, on HTTP/3. Clients open a
+// session at /rtc, with the same parameters as the websocket, then a bidirectional stream that
+// signal messages are exchanged on
+type WebTransportServer struct {
+	rtcService *RTCService
+	port       uint32
+	server     *webtransport.Server
+}
+
+// NewWebTransportServer returns nil when webtransport.port isn't set
+func NewWebTransportServer(conf *config.Config, rtcService *RTCService, keyProvider auth.KeyProvider) (*WebTransportServer, error) {
+	if conf.WebTransport.Port == 0 {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(conf.WebTransport.CertFile, conf.WebTransport.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not load WebTransport certificate: %w", err)
+	}
+
+	s := &WebTransportServer{
+		rtcService: rtcService,
+		port:       conf.WebTransport.Port,
+	}
+	// sessions take over the response writer, which middlewares would wrap
+	authMiddleware := NewAPIKeyAuthMiddleware(keyProvider)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rtc", func(w http.ResponseWriter, r *http.Request) {
+		authMiddleware.ServeHTTP(w, r, s.serveSignal)
+	})
+	s.server = &webtransport.Server{
+		H3: http3.Server{
+			TLSConfig:  &tls.Config{Certificates: []tls.Certificate{cert}},
+			QuicConfig: &quic.Config{KeepAlivePeriod: wtKeepAlivePeriod},
+			Handler:    mux,
+		},
+		// allow connections from any origin, security is enforced by access tokens
+		CheckOrigin: func(r *http.Request) bool {
+			return true
+		},
+	}
+	return s, nil
+}
+
+func (s *WebTransportServer) Start() error {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{Port: int(s.port)})
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := s.server.Serve(conn); err != http.ErrServerClosed {
+			logger.Errorw("could not serve WebTransport", err)
+		}
+	}()
+	return nil
+}
+
+func (s *WebTransportServer) Stop() {
+	_ = s.server.Close()
+}
+
+func (s *WebTransportServer) serveSignal(w http.ResponseWriter, r *http.Request) {
+	s.rtcService.serveSignal(w, r, "signal_wt", func() (signalClient, error) {
+		session, err := s.server.Upgrade(w, r)
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), wtStreamTimeout)
+		defer cancel()
+		stream, err := session.AcceptStream(ctx)
+		if err != nil {
+			_ = session.CloseWithError(0, "signal stream wasn't opened")
+			return nil, err
+		}
+		return newWTSignalStream(session, stream), nil
+	})
+}
+
+//------------------------------------------------
+
+// wtSignalStream exchanges signal messages over a WebTransport stream. Each is framed with its
+// websocket message type, text or binary, and its length, so that they're handled the same as
+// those of websockets
+type wtSignalStream struct {
+	session *webtransport.Session
+	stream  webtransport.Stream
+	reader  *bufio.Reader
+
+	writeLock sync.Mutex
+}
+
+func newWTSignalStream(session *webtransport.Session, stream webtransport.Stream) *wtSignalStream {
+	return &wtSignalStream{
+		session: session,
+		stream:  stream,
+		reader:  bufio.NewReader(stream),
+	}
+}
+
+// ReadMessage returns io.EOF once the client closed the stream or the session
+func (c *wtSignalStream) ReadMessage() (int, []byte, error) {
+	var header [wtFrameHeaderSize]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return 0, nil, c.readError(err)
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxWTMessageSize {
+		return 0, nil, fmt.Errorf("signal message of %d bytes is too large", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return 0, nil, c.readError(err)
+	}
+	return int(header[0]), payload, nil
+}
+
+func (c *wtSignalStream) WriteMessage(messageType int, data []byte) error {
+	frame := make([]byte, wtFrameHeaderSize+len(data))
+	frame[0] = byte(messageType)
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+	copy(frame[wtFrameHeaderSize:], data)
+
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	_, err := c.stream.Write(frame)
+	return err
+}
+
+// WriteControl sends nothing, QUIC keeps the connection alive. It fails once the session is closed,
+// so that pings stop
+func (c *wtSignalStream) WriteControl(_ int, _ []byte, _ time.Time) error {
+	return c.session.Context().Err()
+}
+
+func (c *wtSignalStream) Close() error {
+	_ = c.stream.Close()
+	return c.session.CloseWithError(0, "")
+}
+
+func (c *wtSignalStream) readError(err error) error {
+	var streamErr *webtransport.StreamError
+	var connErr *webtransport.ConnectionError
+	if err == io.EOF || errors.As(err, &streamErr) || errors.As(err, &connErr) || c.session.Context().Err() != nil {
+		return io.EOF
+	}
+	return err
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/livekit/protocol/auth"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/webtransport-go"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+func TestWebTransportSignal(t *testing.T) {
+	conf, err := config.NewConfig("", nil)
+	require.NoError(t, err)
+	conf.WebTransport.Port = freeUDPPort(t)
+	conf.WebTransport.CertFile, conf.WebTransport.KeyFile = writeTestCertificate(t)
+	node, err := routing.NewLocalNode(conf)
+	require.NoError(t, err)
+	node.State = livekit.NodeState_SERVING
+
+	reqSink := routing.NewMessageChannel()
+	resSource := routing.NewMessageChannel()
+	router := &routingfakes.FakeRouter{}
+	router.GetNodeForRoomReturns(node, nil)
+	router.StartParticipantSignalReturns("CO_conn", reqSink, resSource, nil)
+	store := NewLocalRoomStore()
+	roomManager, err := NewLocalRoomManager(conf, store, node, router, telemetry.NewTelemetryService(nil, nil, nil, nil))
+	require.NoError(t, err)
+	t.Cleanup(roomManager.Stop)
+	ra, err := NewRoomAllocator(conf, router, store)
+	require.NoError(t, err)
+
+	secret := "0123456789abcdef0123456789abcdef"
+	keyProvider := auth.NewFileBasedKeyProviderFromMap(map[string]string{"key": secret})
+	rtcService := NewRTCService(conf, ra, router, node, roomManager, keyProvider)
+	server, err := NewWebTransportServer(conf, rtcService, keyProvider)
+	require.NoError(t, err)
+	require.NotNil(t, server)
+	require.NoError(t, server.Start())
+	t.Cleanup(server.Stop)
+
+	dial := func(token string) (*http.Response, *webtransport.Session, error) {
+		dialer := &webtransport.Dialer{
+			RoundTripper: &http3.RoundTripper{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		url := fmt.Sprintf("https://127.0.0.1:%d/rtc?room=room&access_token=%s", conf.WebTransport.Port, token)
+		return dialer.Dial(ctx, url, nil)
+	}
+
+	t.Run("sessions need a token", func(t *testing.T) {
+		res, _, err := dial("")
+		require.Error(t, err)
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("signal messages are exchanged on a stream", func(t *testing.T) {
+		token, err := auth.NewAccessToken("key", secret).
+			AddGrant(&auth.VideoGrant{RoomJoin: true, Room: "room"}).
+			SetIdentity("alice").
+			ToJWT()
+		require.NoError(t, err)
+		_, session, err := dial(token)
+		require.NoError(t, err)
+		stream, err := session.OpenStreamSync(context.Background())
+		require.NoError(t, err)
+
+		req, err := protojson.Marshal(&livekit.SignalRequest{
+			Message: &livekit.SignalRequest_Mute{Mute: &livekit.MuteTrackRequest{Sid: "TR_audio", Muted: true}},
+		})
+		require.NoError(t, err)
+		_, err = stream.Write(wtFrame(websocket.TextMessage, req))
+		require.NoError(t, err)
+		select {
+		case msg := <-reqSink.ReadChan():
+			require.Equal(t, "TR_audio", msg.(*livekit.SignalRequest).GetMute().Sid)
+		case <-time.After(5 * time.Second):
+			t.Fatal("request wasn't routed")
+		}
+
+		require.NoError(t, resSource.WriteMessage(&livekit.SignalResponse{
+			Message: &livekit.SignalResponse_Leave{Leave: &livekit.LeaveRequest{}},
+		}))
+		var header [wtFrameHeaderSize]byte
+		_, err = io.ReadFull(stream, header[:])
+		require.NoError(t, err)
+		require.Equal(t, byte(websocket.TextMessage), header[0])
+		payload := make([]byte, binary.BigEndian.Uint32(header[1:]))
+		_, err = io.ReadFull(stream, payload)
+		require.NoError(t, err)
+		res := &livekit.SignalResponse{}
+		require.NoError(t, protojson.Unmarshal(payload, res))
+		require.NotNil(t, res.GetLeave())
+
+		// the session ends along with the request sink
+		require.NoError(t, session.CloseWithError(0, ""))
+		require.Eventually(t, reqSink.IsClosed, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func wtFrame(messageType int, data []byte) []byte {
+	frame := make([]byte, wtFrameHeaderSize+len(data))
+	frame[0] = byte(messageType)
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+	copy(frame[wtFrameHeaderSize:], data)
+	return frame
+}
+
+func freeUDPPort(t *testing.T) uint32 {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{})
+	require.NoError(t, err)
+	defer conn.Close()
+	return uint32(conn.LocalAddr().(*net.UDPAddr).Port)
+}
+
+// writeTestCertificate writes a self-signed certificate for 127.0.0.1, returning the paths of the
+// certificate and of its key
+func writeTestCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}), 0600))
+	return certFile, keyFile
+}
@@ -5,8 +5,6 @@ package service
 
 import (
 	"context"
-	"fmt"
-	"os"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/google/wire"
@@ -14,6 +12,7 @@ import (
 
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/logger"
+	livekit "github.com/livekit/protocol/proto"
 	"github.com/livekit/protocol/utils"
 	"github.com/livekit/protocol/webhook"
 
@@ -26,19 +25,35 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	wire.Build(
 		createRedisClient,
 		createMessageBus,
+		createEventPublisher,
 		createStore,
-		wire.Bind(new(RORoomStore), new(RoomStore)),
 		createKeyProvider,
+		wire.Bind(new(auth.KeyProvider), new(*ReloadableKeyProvider)),
 		createWebhookNotifier,
+		wire.Bind(new(webhook.Notifier), new(*ReloadableNotifier)),
 		routing.CreateRouter,
 		wire.Bind(new(routing.MessageRouter), new(routing.Router)),
 		telemetry.NewAnalyticsService,
+		createSubscriptionAuditWorker,
+		createIdentityMasker,
 		telemetry.NewTelemetryService,
 		NewRecordingService,
 		NewRoomAllocator,
 		NewRoomService,
+		wire.Bind(new(livekit.RoomService), new(*RoomService)),
 		NewRTCService,
+		NewWebTransportServer,
 		NewLocalRoomManager,
+		createTrackStatsWorker,
+		NewCapacityMonitor,
+		NewDegradationMonitor,
+		NewRawDumpJanitor,
+		NewAgentDispatcher,
+		NewDiagnosticsServer,
+		NewConfigReloader,
+		NewKeyRotator,
+		NewRoomScheduler,
+		NewAdminService,
 		newTurnAuthHandler,
 		NewTurnServer,
 		NewLivekitServer,
@@ -49,62 +64,64 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 func InitializeRouter(conf *config.Config, currentNode routing.LocalNode) (routing.Router, error) {
 	wire.Build(
 		createRedisClient,
+		createKeyProvider,
+		wire.Bind(new(auth.KeyProvider), new(*ReloadableKeyProvider)),
 		routing.CreateRouter,
 	)
 
 	return nil, nil
 }
 
-func createKeyProvider(conf *config.Config) (auth.KeyProvider, error) {
-	// prefer keyfile if set
-	if conf.KeyFile != "" {
-		if st, err := os.Stat(conf.KeyFile); err != nil {
-			return nil, err
-		} else if st.Mode().Perm() != 0600 {
-			return nil, fmt.Errorf("key file must have permission set to 600")
-		}
-		f, err := os.Open(conf.KeyFile)
-		if err != nil {
-			return nil, err
-		}
-		defer func() {
-			_ = f.Close()
-		}()
-		return auth.NewFileBasedKeyProviderFromReader(f)
-	}
-
-	if len(conf.Keys) == 0 {
-		return nil, errors.New("one of key-file or keys must be provided in order to support a secure installation")
+func createKeyProvider(conf *config.Config) (*ReloadableKeyProvider, error) {
+	provider, err := loadKeyProvider(conf)
+	if err != nil {
+		return nil, err
 	}
-
-	return auth.NewFileBasedKeyProviderFromMap(conf.Keys), nil
+	return NewReloadableKeyProvider(provider), nil
 }
 
-func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (webhook.Notifier, error) {
-	wc := conf.WebHook
-	if len(wc.URLs) == 0 {
-		return nil, nil
-	}
-	secret := provider.GetSecret(wc.APIKey)
-	if secret == "" {
-		return nil, ErrWebHookMissingAPIKey
+func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (*ReloadableNotifier, error) {
+	notifier, err := newWebhookNotifier(conf, provider)
+	if err != nil {
+		return nil, err
 	}
-
-	return webhook.NewNotifier(wc.APIKey, secret, wc.URLs), nil
+	return NewReloadableNotifier(notifier), nil
 }
 
-func createRedisClient(conf *config.Config) (*redis.Client, error) {
+func createRedisClient(conf *config.Config) (redis.UniversalClient, error) {
 	if !conf.HasRedis() {
 		return nil, nil
 	}
 
-	logger.Infow("using multi-node routing via redis", "addr", conf.Redis.Address)
-	rc := redis.NewClient(&redis.Options{
-		Addr:     conf.Redis.Address,
-		Username: conf.Redis.Username,
-		Password: conf.Redis.Password,
-		DB:       conf.Redis.DB,
-	})
+	var rc redis.UniversalClient
+	switch {
+	case conf.Redis.IsCluster():
+		logger.Infow("using multi-node routing via redis cluster", "addrs", conf.Redis.ClusterAddresses)
+		rc = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:    conf.Redis.ClusterAddresses,
+			Username: conf.Redis.Username,
+			Password: conf.Redis.Password,
+		})
+	case conf.Redis.IsSentinel():
+		logger.Infow("using multi-node routing via redis sentinel", "master", conf.Redis.SentinelMasterName,
+			"addrs", conf.Redis.SentinelAddresses)
+		rc = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       conf.Redis.SentinelMasterName,
+			SentinelAddrs:    conf.Redis.SentinelAddresses,
+			SentinelPassword: conf.Redis.SentinelPassword,
+			Username:         conf.Redis.Username,
+			Password:         conf.Redis.Password,
+			DB:               conf.Redis.DB,
+		})
+	default:
+		logger.Infow("using multi-node routing via redis", "addr", conf.Redis.Address)
+		rc = redis.NewClient(&redis.Options{
+			Addr:     conf.Redis.Address,
+			Username: conf.Redis.Username,
+			Password: conf.Redis.Password,
+			DB:       conf.Redis.DB,
+		})
+	}
 	if err := rc.Ping(context.Background()).Err(); err != nil {
 		err = errors.Wrap(err, "unable to connect to redis")
 		return nil, err
@@ -113,16 +130,50 @@ func createRedisClient(conf *config.Config) (*redis.Client, error) {
 	return rc, nil
 }
 
-func createMessageBus(rc *redis.Client) utils.MessageBus {
-	if rc == nil {
+func createMessageBus(rc redis.UniversalClient) utils.MessageBus {
+	switch c := rc.(type) {
+	case nil:
 		return nil
+	case *redis.Client:
+		return utils.NewRedisMessageBus(c)
+	default:
+		return NewUniversalMessageBus(rc)
+	}
+}
+
+func createEventPublisher(conf *config.Config, rc redis.UniversalClient) (telemetry.EventPublisher, error) {
+	return telemetry.NewEventPublisher(&conf.EventBus, rc)
+}
+
+func createTrackStatsWorker(conf *config.Config, currentNode routing.LocalNode, roomManager *RoomManager, analytics telemetry.AnalyticsService) (*telemetry.TrackStatsWorker, error) {
+	sink, err := telemetry.NewTrackStatsSink(&conf.TrackStats, analytics)
+	if err != nil || sink == nil {
+		return nil, err
 	}
-	return utils.NewRedisMessageBus(rc)
+	return telemetry.NewTrackStatsWorker(&conf.TrackStats, currentNode.Id, roomManager.sampleTracks, sink), nil
+}
+
+func createSubscriptionAuditWorker(conf *config.Config, currentNode routing.LocalNode, publisher telemetry.EventPublisher) (*telemetry.SubscriptionAuditWorker, error) {
+	sink, err := telemetry.NewSubscriptionAuditSink(&conf.SubscriptionAudit, publisher)
+	if err != nil || sink == nil {
+		return nil, err
+	}
+	return telemetry.NewSubscriptionAuditWorker(&conf.SubscriptionAudit, currentNode.Id, sink), nil
+}
+
+func createIdentityMasker(conf *config.Config) *telemetry.IdentityMasker {
+	return telemetry.NewIdentityMasker(&conf.IdentityMasking)
 }
 
-func createStore(rc *redis.Client) RoomStore {
-	if rc != nil {
-		return NewRedisRoomStore(rc)
+func createStore(conf *config.Config, rc redis.UniversalClient) (RoomStore, error) {
+	switch conf.RoomStore.StoreKind(rc != nil) {
+	case config.RoomStoreRedis:
+		return NewRedisRoomStore(rc), nil
+	case config.RoomStoreEtcd:
+		return NewEtcdRoomStore(&conf.RoomStore.Etcd)
+	case config.RoomStorePostgres:
+		return NewPostgresRoomStore(&conf.RoomStore.Postgres)
+	default:
+		return NewLocalRoomStore(), nil
 	}
-	return NewLocalRoomStore()
 }
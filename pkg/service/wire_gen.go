@@ -8,7 +8,6 @@ package service
 
 import (
 	"context"
-	"fmt"
 	"github.com/go-redis/redis/v8"
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
@@ -16,9 +15,7 @@ import (
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/logger"
 	"github.com/livekit/protocol/utils"
-	"github.com/livekit/protocol/webhook"
 	"github.com/pkg/errors"
-	"os"
 )
 
 // Injectors from wire.go:
@@ -28,39 +25,68 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	if err != nil {
 		return nil, err
 	}
-	router := routing.CreateRouter(conf, client, currentNode)
-	roomStore := createStore(client)
+	keyProvider, err := createKeyProvider(conf)
+	if err != nil {
+		return nil, err
+	}
+	router := routing.CreateRouter(conf, client, currentNode, keyProvider)
+	roomStore, err := createStore(conf, client)
+	if err != nil {
+		return nil, err
+	}
 	roomAllocator, err := NewRoomAllocator(conf, router, roomStore)
 	if err != nil {
 		return nil, err
 	}
-	roomService, err := NewRoomService(roomAllocator, roomStore, router)
+	roomService, err := NewRoomService(conf, roomAllocator, roomStore, router)
 	if err != nil {
 		return nil, err
 	}
 	messageBus := createMessageBus(client)
-	keyProvider, err := createKeyProvider(conf)
+	notifier, err := createWebhookNotifier(conf, keyProvider)
 	if err != nil {
 		return nil, err
 	}
-	notifier, err := createWebhookNotifier(conf, keyProvider)
+	eventPublisher, err := createEventPublisher(conf, client)
+	if err != nil {
+		return nil, err
+	}
+	analyticsService := telemetry.NewAnalyticsService(conf, currentNode, eventPublisher)
+	subscriptionAuditWorker, err := createSubscriptionAuditWorker(conf, currentNode, eventPublisher)
 	if err != nil {
 		return nil, err
 	}
-	analyticsService := telemetry.NewAnalyticsService(conf, currentNode)
-	telemetryService := telemetry.NewTelemetryService(notifier, analyticsService)
-	recordingService := NewRecordingService(messageBus, telemetryService)
-	rtcService := NewRTCService(conf, roomAllocator, router, currentNode)
+	identityMasker := createIdentityMasker(conf)
+	telemetryService := telemetry.NewTelemetryService(notifier, analyticsService, subscriptionAuditWorker, identityMasker)
+	recordingService := NewRecordingService(messageBus, telemetryService, roomStore)
 	roomManager, err := NewLocalRoomManager(conf, roomStore, currentNode, router, telemetryService)
 	if err != nil {
 		return nil, err
 	}
+	rtcService := NewRTCService(conf, roomAllocator, router, currentNode, roomManager, keyProvider)
+	webTransportServer, err := NewWebTransportServer(conf, rtcService, keyProvider)
+	if err != nil {
+		return nil, err
+	}
+	diagnosticsServer := NewDiagnosticsServer(conf, roomManager, keyProvider)
+	configReloader := NewConfigReloader(conf, keyProvider, notifier, roomAllocator, roomService, roomManager, diagnosticsServer)
+	keyRotator := NewKeyRotator(conf, configReloader)
+	roomScheduler := NewRoomScheduler(roomStore, router, currentNode, roomManager, recordingService)
+	adminService := NewAdminService(roomManager, roomService, configReloader, roomScheduler, diagnosticsServer)
+	trackStatsWorker, err := createTrackStatsWorker(conf, currentNode, roomManager, analyticsService)
+	if err != nil {
+		return nil, err
+	}
+	capacityMonitor := NewCapacityMonitor(conf, currentNode, roomManager, telemetryService)
+	degradationMonitor := NewDegradationMonitor(conf, roomManager)
+	rawDumpJanitor := NewRawDumpJanitor(conf)
+	agentDispatcher := NewAgentDispatcher(conf, keyProvider, roomManager)
 	authHandler := newTurnAuthHandler(roomStore)
 	server, err := NewTurnServer(conf, authHandler)
 	if err != nil {
 		return nil, err
 	}
-	livekitServer, err := NewLivekitServer(conf, roomService, recordingService, rtcService, keyProvider, router, roomManager, server, currentNode)
+	livekitServer, err := NewLivekitServer(conf, roomService, recordingService, rtcService, adminService, configReloader, keyRotator, roomScheduler, keyProvider, router, roomManager, trackStatsWorker, subscriptionAuditWorker, analyticsService, eventPublisher, capacityMonitor, degradationMonitor, rawDumpJanitor, agentDispatcher, webTransportServer, diagnosticsServer, server, currentNode)
 	if err != nil {
 		return nil, err
 	}
@@ -72,61 +98,66 @@ func InitializeRouter(conf *config.Config, currentNode routing.LocalNode) (routi
 	if err != nil {
 		return nil, err
 	}
-	router := routing.CreateRouter(conf, client, currentNode)
+	keyProvider, err := createKeyProvider(conf)
+	if err != nil {
+		return nil, err
+	}
+	router := routing.CreateRouter(conf, client, currentNode, keyProvider)
 	return router, nil
 }
 
 // wire.go:
 
-func createKeyProvider(conf *config.Config) (auth.KeyProvider, error) {
-
-	if conf.KeyFile != "" {
-		if st, err := os.Stat(conf.KeyFile); err != nil {
-			return nil, err
-		} else if st.Mode().Perm() != 0600 {
-			return nil, fmt.Errorf("key file must have permission set to 600")
-		}
-		f, err := os.Open(conf.KeyFile)
-		if err != nil {
-			return nil, err
-		}
-		defer func() {
-			_ = f.Close()
-		}()
-		return auth.NewFileBasedKeyProviderFromReader(f)
-	}
-
-	if len(conf.Keys) == 0 {
-		return nil, errors.New("one of key-file or keys must be provided in order to support a secure installation")
+func createKeyProvider(conf *config.Config) (*ReloadableKeyProvider, error) {
+	provider, err := loadKeyProvider(conf)
+	if err != nil {
+		return nil, err
 	}
-
-	return auth.NewFileBasedKeyProviderFromMap(conf.Keys), nil
+	return NewReloadableKeyProvider(provider), nil
 }
 
-func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (webhook.Notifier, error) {
-	wc := conf.WebHook
-	if len(wc.URLs) == 0 {
-		return nil, nil
-	}
-	secret := provider.GetSecret(wc.APIKey)
-	if secret == "" {
-		return nil, ErrWebHookMissingAPIKey
+func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (*ReloadableNotifier, error) {
+	notifier, err := newWebhookNotifier(conf, provider)
+	if err != nil {
+		return nil, err
 	}
-
-	return webhook.NewNotifier(wc.APIKey, secret, wc.URLs), nil
+	return NewReloadableNotifier(notifier), nil
 }
 
-func createRedisClient(conf *config.Config) (*redis.Client, error) {
+func createRedisClient(conf *config.Config) (redis.UniversalClient, error) {
 	if !conf.HasRedis() {
 		return nil, nil
 	}
-	logger.Infow("using multi-node routing via redis", "addr", conf.Redis.Address)
-	rc := redis.NewClient(&redis.Options{
-		Addr:     conf.Redis.Address,
-		Username: conf.Redis.Username,
-		Password: conf.Redis.Password,
-		DB:       conf.Redis.DB,
-	})
+
+	var rc redis.UniversalClient
+	switch {
+	case conf.Redis.IsCluster():
+		logger.Infow("using multi-node routing via redis cluster", "addrs", conf.Redis.ClusterAddresses)
+		rc = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:    conf.Redis.ClusterAddresses,
+			Username: conf.Redis.Username,
+			Password: conf.Redis.Password,
+		})
+	case conf.Redis.IsSentinel():
+		logger.Infow("using multi-node routing via redis sentinel", "master", conf.Redis.SentinelMasterName,
+			"addrs", conf.Redis.SentinelAddresses)
+		rc = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       conf.Redis.SentinelMasterName,
+			SentinelAddrs:    conf.Redis.SentinelAddresses,
+			SentinelPassword: conf.Redis.SentinelPassword,
+			Username:         conf.Redis.Username,
+			Password:         conf.Redis.Password,
+			DB:               conf.Redis.DB,
+		})
+	default:
+		logger.Infow("using multi-node routing via redis", "addr", conf.Redis.Address)
+		rc = redis.NewClient(&redis.Options{
+			Addr:     conf.Redis.Address,
+			Username: conf.Redis.Username,
+			Password: conf.Redis.Password,
+			DB:       conf.Redis.DB,
+		})
+	}
 	if err := rc.Ping(context.Background()).Err(); err != nil {
 		err = errors.Wrap(err, "unable to connect to redis")
 		return nil, err
@@ -135,16 +166,50 @@ func createRedisClient(conf *config.Config) (*redis.Client, error) {
 	return rc, nil
 }
 
-func createMessageBus(rc *redis.Client) utils.MessageBus {
-	if rc == nil {
+func createMessageBus(rc redis.UniversalClient) utils.MessageBus {
+	switch c := rc.(type) {
+	case nil:
 		return nil
+	case *redis.Client:
+		return utils.NewRedisMessageBus(c)
+	default:
+		return NewUniversalMessageBus(rc)
 	}
-	return utils.NewRedisMessageBus(rc)
 }
 
-func createStore(rc *redis.Client) RoomStore {
-	if rc != nil {
-		return NewRedisRoomStore(rc)
+func createEventPublisher(conf *config.Config, rc redis.UniversalClient) (telemetry.EventPublisher, error) {
+	return telemetry.NewEventPublisher(&conf.EventBus, rc)
+}
+
+func createTrackStatsWorker(conf *config.Config, currentNode routing.LocalNode, roomManager *RoomManager, analytics telemetry.AnalyticsService) (*telemetry.TrackStatsWorker, error) {
+	sink, err := telemetry.NewTrackStatsSink(&conf.TrackStats, analytics)
+	if err != nil || sink == nil {
+		return nil, err
+	}
+	return telemetry.NewTrackStatsWorker(&conf.TrackStats, currentNode.Id, roomManager.sampleTracks, sink), nil
+}
+
+func createSubscriptionAuditWorker(conf *config.Config, currentNode routing.LocalNode, publisher telemetry.EventPublisher) (*telemetry.SubscriptionAuditWorker, error) {
+	sink, err := telemetry.NewSubscriptionAuditSink(&conf.SubscriptionAudit, publisher)
+	if err != nil || sink == nil {
+		return nil, err
+	}
+	return telemetry.NewSubscriptionAuditWorker(&conf.SubscriptionAudit, currentNode.Id, sink), nil
+}
+
+func createIdentityMasker(conf *config.Config) *telemetry.IdentityMasker {
+	return telemetry.NewIdentityMasker(&conf.IdentityMasking)
+}
+
+func createStore(conf *config.Config, rc redis.UniversalClient) (RoomStore, error) {
+	switch conf.RoomStore.StoreKind(rc != nil) {
+	case config.RoomStoreRedis:
+		return NewRedisRoomStore(rc), nil
+	case config.RoomStoreEtcd:
+		return NewEtcdRoomStore(&conf.RoomStore.Etcd)
+	case config.RoomStorePostgres:
+		return NewPostgresRoomStore(&conf.RoomStore.Postgres)
+	default:
+		return NewLocalRoomStore(), nil
 	}
-	return NewLocalRoomStore()
 }
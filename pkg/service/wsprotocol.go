@@ -1,6 +1,7 @@
 package service
 
 import (
+	"encoding/json"
 	"sync"
 	"time"
 
@@ -10,7 +11,9 @@ import (
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 
+	"github.com/livekit/livekit-server/pkg/rtc"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
 const (
@@ -22,6 +25,13 @@ type WSSignalConnection struct {
 	conn    types.WebsocketClient
 	mu      sync.Mutex
 	useJSON bool
+	// responses are preceded by their sequence number
+	sequenced bool
+
+	// handlers of text requests, by key
+	textHandlers map[string]TextRequestHandler
+	// limits the requests read, text requests included. Nil when they aren't limited
+	limiter *rtc.SignalRateLimiter
 }
 
 func NewWSSignalConnection(conn types.WebsocketClient) *WSSignalConnection {
@@ -30,6 +40,8 @@ func NewWSSignalConnection(conn types.WebsocketClient) *WSSignalConnection {
 		mu:      sync.Mutex{},
 		useJSON: true,
 	}
+	wsc.OnTextRequest(textKeyDataPacket, handleDataPacketRequest)
+	wsc.OnTextRequest(textKeyListParticipants, handleListParticipantsRequest)
 	go wsc.pingWorker()
 	return wsc
 }
@@ -52,15 +64,51 @@ func (c *WSSignalConnection) ReadRequest() (*livekit.SignalRequest, error) {
 				c.mu.Unlock()
 			}
 			// protobuf encoded
-			err := proto.Unmarshal(payload, msg)
-			return msg, err
+			if err := proto.Unmarshal(payload, msg); err != nil {
+				return msg, err
+			}
+			if ok, err := c.allowRequest(msg); !ok {
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			return msg, nil
 		case websocket.TextMessage:
+			if key, value, ok := parseTextMessage(payload); ok {
+				if handler := c.textHandlers[key]; handler != nil {
+					// handled here, some without reaching the node hosting the room
+					if ok, err := c.allow(textRequestKind(key)); !ok {
+						if err != nil {
+							return nil, err
+						}
+						continue
+					}
+					req, err := handler(value)
+					if err != nil {
+						logger.Infow("dropping invalid text request", "key", key, "error", err)
+						continue
+					}
+					if req == nil {
+						continue
+					}
+					return req, nil
+				}
+			}
 			c.mu.Lock()
 			// json encoded, also write back JSON
 			c.useJSON = true
 			c.mu.Unlock()
-			err := protojson.Unmarshal(payload, msg)
-			return msg, err
+			if err := protojson.Unmarshal(payload, msg); err != nil {
+				return msg, err
+			}
+			if ok, err := c.allowRequest(msg); !ok {
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			return msg, nil
 		default:
 			logger.Debugw("unsupported message", "message", messageType)
 			return nil, nil
@@ -68,6 +116,61 @@ func (c *WSSignalConnection) ReadRequest() (*livekit.SignalRequest, error) {
 	}
 }
 
+// LimitRate drops the requests read over the limits of limiter, text requests included. Once too
+// many were, ReadRequest returns ErrSignalRateLimitExceeded
+func (c *WSSignalConnection) LimitRate(limiter *rtc.SignalRateLimiter) {
+	c.limiter = limiter
+}
+
+// allowRequest returns whether req is read, and ErrSignalRateLimitExceeded once the client should
+// be disconnected
+func (c *WSSignalConnection) allowRequest(req *livekit.SignalRequest) (bool, error) {
+	if _, ok := req.Message.(*livekit.SignalRequest_Leave); ok {
+		return true, nil
+	}
+	return c.allow(rtc.SignalRequestKind(req))
+}
+
+func (c *WSSignalConnection) allow(kind string) (bool, error) {
+	if c.limiter == nil || c.limiter.AllowKind(kind) {
+		return true, nil
+	}
+	prometheus.IncrementSignalRequestDropped(kind)
+	if c.limiter.Exceeded() {
+		prometheus.IncrementSignalRateLimitDisconnect()
+		return false, ErrSignalRateLimitExceeded
+	}
+	logger.Debugw("dropping signal request, rate limit exceeded", "kind", kind)
+	return false, nil
+}
+
+// OnTextRequest handles the text requests sent with key, read by ReadRequest
+func (c *WSSignalConnection) OnTextRequest(key string, handler TextRequestHandler) {
+	if c.textHandlers == nil {
+		c.textHandlers = make(map[string]TextRequestHandler)
+	}
+	c.textHandlers[key] = handler
+}
+
+// WriteTextMessage sends value as a text message with key
+func (c *WSSignalConnection) WriteTextMessage(key string, value interface{}) error {
+	payload, err := marshalTextMessage(key, value)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteMessage(websocket.TextMessage, payload)
+}
+
+// SequenceResponses precedes each numbered response with its sequence number, as a text message
+func (c *WSSignalConnection) SequenceResponses() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sequenced = true
+}
+
 func (c *WSSignalConnection) WriteResponse(msg *livekit.SignalResponse) error {
 	var msgType int
 	var payload []byte
@@ -76,9 +179,18 @@ func (c *WSSignalConnection) WriteResponse(msg *livekit.SignalResponse) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if c.useJSON {
+	if page := rtc.SignalResponseParticipantPage(msg); page != nil {
+		msgType = websocket.TextMessage
+		payload, err = marshalParticipantPage(page)
+	} else if update := rtc.SignalResponseSubscribedQuality(msg); update != nil {
+		msgType = websocket.TextMessage
+		payload, err = marshalSubscribedQuality(update)
+	} else if key, value := rtc.SignalResponseText(msg); key != "" {
+		msgType = websocket.TextMessage
+		payload, err = marshalTextMessage(key, json.RawMessage(value))
+	} else if c.useJSON {
 		msgType = websocket.TextMessage
-		payload, err = protojson.Marshal(msg)
+		payload, err = marshalJSONResponse(msg)
 	} else {
 		msgType = websocket.BinaryMessage
 		payload, err = proto.Marshal(msg)
@@ -87,6 +199,15 @@ func (c *WSSignalConnection) WriteResponse(msg *livekit.SignalResponse) error {
 		return err
 	}
 
+	if seq := rtc.SignalSeq(msg); c.sequenced && seq != 0 {
+		seqPayload, err := marshalTextMessage(textKeySignalSeq, &SignalSeq{Seq: seq})
+		if err != nil {
+			return err
+		}
+		if err := c.conn.WriteMessage(websocket.TextMessage, seqPayload); err != nil {
+			return err
+		}
+	}
 	return c.conn.WriteMessage(msgType, payload)
 }
 
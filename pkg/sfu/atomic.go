@@ -72,3 +72,11 @@ func (a *atomicInt64) set(value int64) {
 func (a *atomicInt64) get() int64 {
 	return atomic.LoadInt64((*int64)(a))
 }
+
+func (a *atomicInt64) add(value int64) int64 {
+	return atomic.AddInt64((*int64)(a), value)
+}
+
+func (a *atomicInt64) swap(value int64) int64 {
+	return atomic.SwapInt64((*int64)(a), value)
+}
@@ -31,14 +31,78 @@ type pendingPackets struct {
 }
 
 type ExtPacket struct {
-	Head     bool
-	Arrival  int64
-	Packet   rtp.Packet
+	Head    bool
+	Arrival int64
+	Packet  rtp.Packet
+	// *VP8 for VP8 packets, it points into the packet
 	Payload  interface{}
 	KeyFrame bool
 	//audio level for voice, l&0x80 == 0 means audio level not present
 	AudioLevel uint8
 	RawPacket  []byte
+
+	vp8  VP8
+	refs int32
+	// the packet once it's detached from the bucket
+	buf      []byte
+	detached bool
+}
+
+// extPacketFactory recycles the packets read from buffers, so that forwarding doesn't allocate
+// per packet. The header slices of a recycled packet are reused when it's unmarshalled again
+var extPacketFactory = &sync.Pool{
+	New: func() interface{} {
+		return &ExtPacket{}
+	},
+}
+
+func newExtPacket() *ExtPacket {
+	ep := extPacketFactory.Get().(*ExtPacket)
+	ep.Head = false
+	ep.Arrival = 0
+	ep.Payload = nil
+	ep.KeyFrame = false
+	ep.AudioLevel = 0
+	ep.RawPacket = nil
+	ep.detached = false
+	ep.refs = 1
+	return ep
+}
+
+// ReleaseExtPacket releases a packet read with ReadExtended once it's been written to all down
+// tracks, or a reference added with Retain. The packet is recycled once all its references are
+// released, the packet and the payload and header slices in it must not be used after that
+func ReleaseExtPacket(ep *ExtPacket) {
+	if atomic.AddInt32(&ep.refs, -1) == 0 {
+		extPacketFactory.Put(ep)
+	}
+}
+
+// Retain adds a reference to a packet read with ReadExtended, for a reader that uses it after it's
+// released by the one that read it
+func (ep *ExtPacket) Retain() {
+	atomic.AddInt32(&ep.refs, 1)
+}
+
+// Detach copies the packet out of the buffer's bucket, which overwrites it once it wraps around,
+// so that it stays valid while it's queued. Packets are only copied once, a detached packet may be
+// read by others already
+func (ep *ExtPacket) Detach() error {
+	if ep.detached {
+		return nil
+	}
+	if cap(ep.buf) < len(ep.RawPacket) {
+		size := maxPktSize
+		if len(ep.RawPacket) > size {
+			size = len(ep.RawPacket)
+		}
+		ep.buf = make([]byte, size)
+	}
+	buf := ep.buf[:len(ep.RawPacket)]
+	copy(buf, ep.RawPacket)
+	ep.RawPacket = buf
+	ep.detached = true
+	return ep.Packet.Unmarshal(buf)
 }
 
 // Buffer contains all packets
@@ -61,6 +125,8 @@ type Buffer struct {
 	bound      bool
 	closed     atomicBool
 	mime       string
+	// payload type of the media packets, RTX packets are restored to it
+	payloadType uint8
 
 	// supported feedbacks
 	remb       bool
@@ -72,9 +138,13 @@ type Buffer struct {
 	lastPacketRead     int
 	bitrate            atomic.Value
 	bitrateHelper      [4]int64
+	frameRate          uint32 // frames per second over the last report interval
+	framesHelper       uint32
+	dimensions         uint32 // of the latest key frame, packed as width<<16 | height
 	lastSRNTPTime      uint64
 	lastSRRTPTime      uint32
 	lastSRRecv         int64 // Represents wall clock of the most recent sender report arrival
+	rtpClock           rtpClock
 	baseSN             uint16
 	lastRtcpPacketTime int64 // Time the last RTCP packet was received.
 	lastRtcpSrTime     int64 // Time the last RTCP SR was received. Required for DLSR computation.
@@ -85,10 +155,25 @@ type Buffer struct {
 
 	latestTimestamp          uint32 // latest received RTP timestamp on packet
 	latestTimestampTime      int64  // Time of the latest timestamp (in nanos since unix epoch)
-	lastFractionLostToReport uint8  // Last fractionlost from subscribers, should report to publisher; Audio only
+	lastFractionLostToReport uint32 // Last fractionlost from subscribers, should report to publisher; Audio only. Atomic
+
+	// for buffers of RTX streams, returns the buffer of the stream they repair once it's known
+	primaryForRTX func() *Buffer
+	rtxPrimary    *Buffer
+
+	// packets arriving ahead of missing ones wait for them this long, forwarded in order
+	reorderWindow  time.Duration
+	reorderStarted bool
+	// extended sequence number of the next packet to forward in order
+	nextSN uint32
+	// packets waiting for missing ones, by sequence number
+	held []heldPacket
 
 	// callbacks
 	onClose      func()
+	onRecovered  func()
+	onReordered  func(depth int)
+	onLate       func()
 	onAudioLevel func(level uint8, durationMs uint32)
 	feedbackCB   func([]rtcp.Packet)
 	feedbackTWCC func(sn uint16, timeNS int64, marker bool)
@@ -101,7 +186,11 @@ type Stats struct {
 	LastExpected uint32
 	LastReceived uint32
 	LostRate     float32
+	TotalLost    uint32  // Number of packets lost, as of the last reception report.
 	PacketCount  uint32  // Number of packets received from this source.
+	RTXRecovered uint32  // Number of packets received through RTX that weren't received otherwise.
+	Reordered    uint32  // Number of packets that arrived after later ones, within the reorder window.
+	Late         uint32  // Number of packets that arrived after the reorder window gave up on them.
 	Jitter       float64 // An estimate of the statistical variance of the RTP data packet inter-arrival time.
 	TotalByte    uint64
 }
@@ -109,6 +198,8 @@ type Stats struct {
 // BufferOptions provides configuration options for the buffer
 type Options struct {
 	MaxBitRate uint64
+	// time packets wait for the ones missing before them, 0 to forward packets as they arrive
+	ReorderWindow time.Duration
 }
 
 // NewBuffer constructs a new Buffer
@@ -130,6 +221,7 @@ func (b *Buffer) Bind(params webrtc.RTPParameters, codec webrtc.RTPCodecCapabili
 
 	b.clockRate = codec.ClockRate
 	b.maxBitrate = int64(o.MaxBitRate)
+	b.reorderWindow = o.ReorderWindow
 	b.mime = strings.ToLower(codec.MimeType)
 
 	switch {
@@ -175,7 +267,7 @@ func (b *Buffer) Bind(params webrtc.RTPParameters, codec webrtc.RTPCodecCapabili
 	}
 
 	for _, pp := range b.pPackets {
-		b.calc(pp.packet, pp.arrivalTime)
+		b.calc(pp.packet, pp.arrivalTime, false)
 	}
 	b.pPackets = nil
 	b.bound = true
@@ -194,6 +286,11 @@ func (b *Buffer) Write(pkt []byte) (n int, err error) {
 	}
 
 	if !b.bound {
+		if primary := b.getRTXPrimary(); primary != nil {
+			primary.writeRTX(pkt, time.Now().UnixNano())
+			return
+		}
+
 		packet := make([]byte, len(pkt))
 		copy(packet, pkt)
 		b.pPackets = append(b.pPackets, pendingPackets{
@@ -203,11 +300,75 @@ func (b *Buffer) Write(pkt []byte) (n int, err error) {
 		return
 	}
 
-	b.calc(pkt, time.Now().UnixNano())
+	b.calc(pkt, time.Now().UnixNano(), false)
 
 	return
 }
 
+// getRTXPrimary returns the buffer this buffer's RTX stream repairs, nil when it's not a known
+// RTX stream. Packets that arrived before the pairing was known are handed over. Must be called
+// with the lock held
+func (b *Buffer) getRTXPrimary() *Buffer {
+	if b.rtxPrimary != nil || b.primaryForRTX == nil {
+		return b.rtxPrimary
+	}
+	if b.rtxPrimary = b.primaryForRTX(); b.rtxPrimary != nil {
+		for _, pp := range b.pPackets {
+			b.rtxPrimary.writeRTX(pp.packet, pp.arrivalTime)
+		}
+		b.pPackets = nil
+		b.lastPacketRead = 0
+	}
+	return b.rtxPrimary
+}
+
+// writeRTX restores the original packet from a RFC 4588 retransmission, and processes it as if it
+// had been received on this buffer's stream
+func (b *Buffer) writeRTX(pkt []byte, arrivalTime int64) {
+	var p rtp.Packet
+	if err := p.Unmarshal(pkt); err != nil {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	if b.closed.get() || !b.bound {
+		return
+	}
+
+	// retransmissions, as well as padding sent to probe bandwidth, count towards congestion
+	// control. They are acknowledged here, as duplicates are dropped before reaching calc
+	if b.twcc {
+		if ext := p.GetExtension(b.twccExt); len(ext) > 1 {
+			b.feedbackTWCC(binary.BigEndian.Uint16(ext[0:2]), arrivalTime, p.Marker)
+		}
+		_ = p.Header.DelExtension(b.twccExt)
+	}
+	if len(p.Payload) < 2 {
+		return
+	}
+
+	p.SequenceNumber = binary.BigEndian.Uint16(p.Payload[0:2])
+	p.Payload = p.Payload[2:]
+	p.SSRC = b.mediaSSRC
+	p.PayloadType = b.payloadType
+	p.Padding = false
+	restored, err := p.Marshal()
+	if err != nil {
+		return
+	}
+
+	received := b.stats.PacketCount
+	b.calc(restored, arrivalTime, true)
+	if b.stats.PacketCount != received {
+		b.stats.RTXRecovered++
+		if b.onRecovered != nil {
+			b.onRecovered()
+		}
+	}
+}
+
 func (b *Buffer) Read(buff []byte) (n int, err error) {
 	for {
 		if b.closed.get() {
@@ -238,6 +399,10 @@ func (b *Buffer) ReadExtended() (*ExtPacket, error) {
 			return nil, io.EOF
 		}
 		b.Lock()
+		if b.extPackets.Len() == 0 && len(b.held) > 0 {
+			// no packet came to fill the gap
+			b.releaseExpired(time.Now().UnixNano())
+		}
 		if b.extPackets.Len() > 0 {
 			extPkt := b.extPackets.PopFront().(*ExtPacket)
 			b.Unlock()
@@ -269,17 +434,19 @@ func (b *Buffer) OnClose(fn func()) {
 	b.onClose = fn
 }
 
-func (b *Buffer) calc(pkt []byte, arrivalTime int64) {
+func (b *Buffer) calc(pkt []byte, arrivalTime int64, retransmitted bool) {
 	sn := binary.BigEndian.Uint16(pkt[2:4])
 
 	var headPkt bool
+	extSN := uint32(sn)
 	if b.stats.PacketCount == 0 {
 		b.baseSN = sn
 		b.lastReport = arrivalTime
 		b.seqHdlr.UpdateMaxSeq(uint32(sn))
 		headPkt = true
 	} else {
-		extSN, isNewer := b.seqHdlr.Unwrap(sn)
+		var isNewer bool
+		extSN, isNewer = b.seqHdlr.Unwrap(sn)
 		if b.nack {
 			if isNewer {
 				for i := b.seqHdlr.MaxSeqNo() + 1; i < extSN; i++ {
@@ -295,7 +462,6 @@ func (b *Buffer) calc(pkt []byte, arrivalTime int64) {
 		headPkt = isNewer
 	}
 
-	var p rtp.Packet
 	pb, err := b.bucket.AddPacket(pkt, sn, headPkt)
 	if err != nil {
 		if err == ErrRTXPacket {
@@ -303,7 +469,10 @@ func (b *Buffer) calc(pkt []byte, arrivalTime int64) {
 		}
 		return
 	}
+	ep := newExtPacket()
+	p := &ep.Packet
 	if err = p.Unmarshal(pb); err != nil {
+		ReleaseExtPacket(ep)
 		return
 	}
 
@@ -317,30 +486,33 @@ func (b *Buffer) calc(pkt []byte, arrivalTime int64) {
 
 	b.stats.TotalByte += uint64(len(pkt))
 	b.stats.PacketCount++
+	b.payloadType = p.PayloadType
 
-	ep := ExtPacket{
-		Head:      headPkt,
-		Packet:    p,
-		Arrival:   arrivalTime,
-		RawPacket: pb,
-	}
+	ep.Head = headPkt
+	ep.Arrival = arrivalTime
+	ep.RawPacket = pb
 
 	if len(p.Payload) == 0 {
 		// padding only packet, nothing else to do
-		b.extPackets.PushBack(&ep)
+		b.forward(ep, extSN, retransmitted)
 		return
 	}
 
 	temporalLayer := int32(0)
 	switch b.mime {
 	case "video/vp8":
-		vp8Packet := VP8{}
+		vp8Packet := &ep.vp8
+		*vp8Packet = VP8{}
 		if err := vp8Packet.Unmarshal(p.Payload); err != nil {
+			ReleaseExtPacket(ep)
 			return
 		}
 		ep.Payload = vp8Packet
 		ep.KeyFrame = vp8Packet.IsKeyFrame
 		temporalLayer = int32(vp8Packet.TID)
+		if width, height, ok := vp8Packet.KeyFrameDimensions(p.Payload); ok {
+			atomic.StoreUint32(&b.dimensions, width<<16|height)
+		}
 	case "video/h264":
 		ep.KeyFrame = IsH264Keyframe(p.Payload)
 	}
@@ -361,8 +533,6 @@ func (b *Buffer) calc(pkt []byte, arrivalTime int64) {
 		b.minPacketProbe++
 	}
 
-	b.extPackets.PushBack(&ep)
-
 	// if first time update or the timestamp is later (factoring timestamp wrap around)
 	latestTimestamp := atomic.LoadUint32(&b.latestTimestamp)
 	latestTimestampTimeInNanosSinceEpoch := atomic.LoadInt64(&b.latestTimestampTime)
@@ -402,6 +572,10 @@ func (b *Buffer) calc(pkt []byte, arrivalTime int64) {
 	}
 
 	b.bitrateHelper[temporalLayer] += int64(len(pkt))
+	// the marker bit is set on the last packet of each video frame
+	if b.codecType == webrtc.RTPCodecTypeVideo && headPkt && p.Marker {
+		b.framesHelper++
+	}
 
 	diff := arrivalTime - b.lastReport
 	if diff >= ReportDelta {
@@ -421,9 +595,14 @@ func (b *Buffer) calc(pkt []byte, arrivalTime int64) {
 			b.bitrateHelper[i] = 0
 		}
 		b.bitrate.Store(bitrates)
+		atomic.StoreUint32(&b.frameRate, uint32((int64(b.framesHelper)*int64(ReportDelta)+diff/2)/diff))
+		b.framesHelper = 0
 		b.feedbackCB(b.getRTCP())
 		b.lastReport = arrivalTime
 	}
+
+	// pushed last, the packet can be read and released as soon as it's queued
+	b.forward(ep, extSN, retransmitted)
 }
 
 func (b *Buffer) buildNACKPacket() []rtcp.Packet {
@@ -460,7 +639,6 @@ func (b *Buffer) buildREMBPacket() *rtcp.ReceiverEstimatedMaximumBitrate {
 	if br < 100000 {
 		br = 100000
 	}
-	b.stats.TotalByte = 0
 
 	return &rtcp.ReceiverEstimatedMaximumBitrate{
 		Bitrate: float32(br),
@@ -475,6 +653,7 @@ func (b *Buffer) buildReceptionReport() rtcp.ReceptionReport {
 	if b.stats.PacketCount < expected && b.stats.PacketCount != 0 {
 		lost = expected - b.stats.PacketCount
 	}
+	b.stats.TotalLost = lost
 	expectedInterval := expected - b.stats.LastExpected
 	b.stats.LastExpected = expected
 
@@ -488,9 +667,9 @@ func (b *Buffer) buildReceptionReport() rtcp.ReceptionReport {
 	if expectedInterval != 0 && lostInterval > 0 {
 		fracLost = uint8((lostInterval << 8) / expectedInterval)
 	}
-	if b.lastFractionLostToReport > fracLost {
+	if subFracLost := uint8(atomic.LoadUint32(&b.lastFractionLostToReport)); subFracLost > fracLost {
 		// If fraction lost from subscriber is bigger than sfu received, use it.
-		fracLost = b.lastFractionLostToReport
+		fracLost = subFracLost
 	}
 
 	var dlsr uint32
@@ -513,15 +692,23 @@ func (b *Buffer) buildReceptionReport() rtcp.ReceptionReport {
 }
 
 func (b *Buffer) SetSenderReportData(rtpTime uint32, ntpTime uint64) {
+	now := time.Now()
 	b.Lock()
-	b.lastSRRTPTime = rtpTime
-	b.lastSRNTPTime = ntpTime
-	b.lastSRRecv = time.Now().UnixNano()
+	atomic.StoreUint32(&b.lastSRRTPTime, rtpTime)
+	atomic.StoreUint64(&b.lastSRNTPTime, ntpTime)
+	atomic.StoreInt64(&b.lastSRRecv, now.UnixNano())
+	b.rtpClock.onSenderReport(b.clockRate, rtpTime, ntpTime, now)
 	b.Unlock()
 }
 
+// RTPTimeAt returns the RTP timestamp of the stream at the given time, mapped through the sender
+// reports of the publisher. False before the first sender report
+func (b *Buffer) RTPTimeAt(at time.Time) (uint32, bool) {
+	return b.rtpClock.rtpTimeAt(at)
+}
+
 func (b *Buffer) SetLastFractionLostReport(lost uint8) {
-	b.lastFractionLostToReport = lost
+	atomic.StoreUint32(&b.lastFractionLostToReport, uint32(lost))
 }
 
 func (b *Buffer) getRTCP() []rtcp.Packet {
@@ -559,6 +746,18 @@ func (b *Buffer) Bitrate() int64 {
 	return bitrate
 }
 
+// FrameRate returns the frames per second of a video stream, as of the last report interval
+func (b *Buffer) FrameRate() uint32 {
+	return atomic.LoadUint32(&b.frameRate)
+}
+
+// Dimensions returns the width and height of a video stream, as of its latest key frame. Zero until
+// a key frame the dimensions can be parsed from is received
+func (b *Buffer) Dimensions() (width, height uint32) {
+	dimensions := atomic.LoadUint32(&b.dimensions)
+	return dimensions >> 16, dimensions & 0xffff
+}
+
 // BitrateTemporalCumulative returns the current publisher stream bitrate temporal layer accumulated with lower temporal layers.
 func (b *Buffer) BitrateTemporalCumulative() []int64 {
 	bitrates, ok := b.bitrate.Load().([]int64)
@@ -589,6 +788,23 @@ func (b *Buffer) OnFeedback(fn func(fb []rtcp.Packet)) {
 	b.feedbackCB = fn
 }
 
+// OnRecovered is called for each lost packet that was recovered through RTX
+func (b *Buffer) OnRecovered(fn func()) {
+	b.onRecovered = fn
+}
+
+// OnReordered is called for each packet that arrived after later ones and was put back in order, with
+// the number of packets that waited for it
+func (b *Buffer) OnReordered(fn func(depth int)) {
+	b.onReordered = fn
+}
+
+// OnLate is called for each packet that arrived after the reorder window gave up on it, besides
+// retransmissions
+func (b *Buffer) OnLate(fn func()) {
+	b.onLate = fn
+}
+
 func (b *Buffer) OnAudioLevel(fn func(level uint8, durationMs uint32)) {
 	b.onAudioLevel = fn
 }
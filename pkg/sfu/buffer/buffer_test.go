@@ -8,6 +8,8 @@ import (
 	"github.com/pion/rtcp"
 
 	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
+	"github.com/pion/transport/packetio"
 	"github.com/pion/webrtc/v3"
 	"github.com/stretchr/testify/assert"
 )
@@ -339,3 +341,202 @@ func TestIsTimestampWrap(t *testing.T) {
 		})
 	}
 }
+
+func TestRTX(t *testing.T) {
+	factory := NewBufferFactory(500, Logger)
+	buff := factory.GetOrNew(packetio.RTPBufferPacket, 123).(*Buffer)
+	rtxBuff := factory.GetOrNew(packetio.RTPBufferPacket, 456).(*Buffer)
+
+	var twccSNs []uint16
+	buff.OnTransportWideCC(func(sn uint16, _ int64, _ bool) {
+		twccSNs = append(twccSNs, sn)
+	})
+	var recovered int
+	buff.OnRecovered(func() {
+		recovered++
+	})
+	codec := webrtc.RTPCodecCapability{
+		MimeType:  "video/vp8",
+		ClockRate: 90000,
+		RTCPFeedback: []webrtc.RTCPFeedback{
+			{Type: webrtc.TypeRTCPFBNACK},
+			{Type: webrtc.TypeRTCPFBTransportCC},
+		},
+	}
+	buff.Bind(webrtc.RTPParameters{
+		HeaderExtensions: []webrtc.RTPHeaderExtensionParameter{{URI: sdp.TransportCCURI, ID: 1}},
+		Codecs:           []webrtc.RTPCodecParameters{{RTPCodecCapability: codec, PayloadType: 96}},
+	}, codec, Options{})
+
+	write := func(b *Buffer, p *rtp.Packet, twccSN uint16) {
+		assert.NoError(t, p.SetExtension(1, []byte{byte(twccSN >> 8), byte(twccSN)}))
+		raw, err := p.Marshal()
+		assert.NoError(t, err)
+		_, err = b.Write(raw)
+		assert.NoError(t, err)
+	}
+	media := func(sn uint16) *rtp.Packet {
+		return &rtp.Packet{
+			Header:  rtp.Header{Version: 2, SSRC: 123, PayloadType: 96, SequenceNumber: sn, Timestamp: 3000},
+			Payload: []byte{1, 2, 3},
+		}
+	}
+	rtx := func(sn, osn uint16) *rtp.Packet {
+		return &rtp.Packet{
+			Header:  rtp.Header{Version: 2, SSRC: 456, PayloadType: 97, SequenceNumber: sn, Timestamp: 3000},
+			Payload: []byte{byte(osn >> 8), byte(osn), 1, 2, 3},
+		}
+	}
+
+	// retransmissions that arrive before the streams are paired are kept
+	write(rtxBuff, rtx(1, 3), 10)
+	write(buff, media(1), 11)
+	write(buff, media(2), 12)
+	write(buff, media(4), 13)
+	assert.Len(t, buff.nacker.nacks, 1)
+
+	factory.SetRTXPair(456, 123)
+	// a padding only probe is acknowledged, and hands over the retransmission
+	write(rtxBuff, &rtp.Packet{Header: rtp.Header{Version: 2, SSRC: 456, PayloadType: 97, SequenceNumber: 2}}, 14)
+	assert.Equal(t, []uint16{11, 12, 13, 10, 14}, twccSNs)
+	assert.Equal(t, 1, recovered)
+	assert.Empty(t, buff.nacker.nacks)
+
+	// duplicates are acknowledged, but not recovered
+	write(rtxBuff, rtx(3, 2), 15)
+	assert.Equal(t, []uint16{11, 12, 13, 10, 14, 15}, twccSNs)
+	assert.Equal(t, 1, recovered)
+	assert.Equal(t, uint32(1), buff.GetStats().RTXRecovered)
+
+	var sns []uint16
+	for i := 0; i < 4; i++ {
+		ep, err := buff.ReadExtended()
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(123), ep.Packet.SSRC)
+		assert.Equal(t, uint8(96), ep.Packet.PayloadType)
+		assert.Equal(t, []byte{1, 2, 3}, ep.Packet.Payload)
+		sns = append(sns, ep.Packet.SequenceNumber)
+	}
+	assert.Equal(t, []uint16{1, 2, 4, 3}, sns)
+}
+
+func TestReleaseExtPacket(t *testing.T) {
+	pool := &sync.Pool{
+		New: func() interface{} {
+			b := make([]byte, 1500)
+			return &b
+		},
+	}
+	buff := NewBuffer(123, pool, pool, Logger)
+	buff.codecType = webrtc.RTPCodecTypeVideo
+	buff.Bind(webrtc.RTPParameters{
+		HeaderExtensions: nil,
+		Codecs:           []webrtc.RTPCodecParameters{vp8Codec},
+	}, vp8Codec.RTPCodecCapability, Options{})
+
+	write := func(sn uint16, payload []byte) *ExtPacket {
+		pkt := rtp.Packet{
+			Header:  rtp.Header{Version: 2, SSRC: 123, SequenceNumber: sn, Timestamp: uint32(sn)},
+			Payload: payload,
+		}
+		b, err := pkt.Marshal()
+		assert.NoError(t, err)
+		_, err = buff.Write(b)
+		assert.NoError(t, err)
+
+		ep, err := buff.ReadExtended()
+		assert.NoError(t, err)
+		return ep
+	}
+
+	// key frame
+	ep := write(1, []byte{0x10, 0x00, 0x00, 0x9d, 0x01, 0x2a, 0x10, 0x00, 0x10, 0x00})
+	assert.True(t, ep.KeyFrame)
+	vp8, ok := ep.Payload.(*VP8)
+	assert.True(t, ok)
+	assert.True(t, vp8.IsKeyFrame)
+	ReleaseExtPacket(ep)
+
+	// released packets are reset when they're reused
+	ep = write(2, []byte{0x11, 0x01, 0x02})
+	assert.Equal(t, uint16(2), ep.Packet.SequenceNumber)
+	assert.Equal(t, []byte{0x11, 0x01, 0x02}, ep.Packet.Payload)
+	assert.False(t, ep.KeyFrame)
+	vp8, ok = ep.Payload.(*VP8)
+	assert.True(t, ok)
+	assert.False(t, vp8.IsKeyFrame)
+	ReleaseExtPacket(ep)
+}
+
+func TestDetachExtPacket(t *testing.T) {
+	pkt := rtp.Packet{
+		Header:  rtp.Header{Version: 2, SSRC: 123, SequenceNumber: 1},
+		Payload: []byte{1, 2, 3},
+	}
+	raw, err := pkt.Marshal()
+	assert.NoError(t, err)
+
+	ep := newExtPacket()
+	ep.RawPacket = raw
+	assert.NoError(t, ep.Packet.Unmarshal(raw))
+	assert.NoError(t, ep.Detach())
+
+	// the bucket slot is reused
+	copy(raw[len(raw)-3:], []byte{4, 5, 6})
+	assert.Equal(t, []byte{1, 2, 3}, ep.Packet.Payload)
+	assert.Equal(t, []byte{1, 2, 3}, ep.RawPacket[len(ep.RawPacket)-3:])
+
+	// only copied once
+	detached := ep.RawPacket
+	assert.NoError(t, ep.Detach())
+	assert.Equal(t, &detached[0], &ep.RawPacket[0])
+
+	// recycled once the last reference is released
+	ep.Retain()
+	ReleaseExtPacket(ep)
+	assert.Equal(t, int32(1), ep.refs)
+	ReleaseExtPacket(ep)
+	assert.Equal(t, int32(0), ep.refs)
+}
+
+func BenchmarkReadExtended(b *testing.B) {
+	pool := &sync.Pool{
+		New: func() interface{} {
+			b := make([]byte, 500*1500)
+			return &b
+		},
+	}
+	buff := NewBuffer(123, pool, pool, Logger)
+	buff.codecType = webrtc.RTPCodecTypeVideo
+	buff.Bind(webrtc.RTPParameters{
+		HeaderExtensions: nil,
+		Codecs:           []webrtc.RTPCodecParameters{vp8Codec},
+	}, vp8Codec.RTPCodecCapability, Options{})
+	buff.OnFeedback(func(_ []rtcp.Packet) {})
+
+	pkt := rtp.Packet{
+		Header:  rtp.Header{Version: 2, SSRC: 123},
+		Payload: make([]byte, 1000),
+	}
+	// not a key frame
+	pkt.Payload[0] = 0x11
+	raw := make([]byte, pkt.MarshalSize())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pkt.SequenceNumber++
+		pkt.Timestamp += 3000
+		if _, err := pkt.MarshalTo(raw); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := buff.Write(raw); err != nil {
+			b.Fatal(err)
+		}
+		ep, err := buff.ReadExtended()
+		if err != nil {
+			b.Fatal(err)
+		}
+		ReleaseExtPacket(ep)
+	}
+}
@@ -14,7 +14,9 @@ type Factory struct {
 	audioPool   *sync.Pool
 	rtpBuffers  map[uint32]*Buffer
 	rtcpReaders map[uint32]*RTCPReader
-	logger      logr.Logger
+	// RTX SSRC => SSRC of the stream it repairs
+	rtxPairs map[uint32]uint32
+	logger   logr.Logger
 }
 
 func NewBufferFactory(trackingPackets int, logger logr.Logger) *Factory {
@@ -42,6 +44,7 @@ func NewBufferFactory(trackingPackets int, logger logr.Logger) *Factory {
 		},
 		rtpBuffers:  make(map[uint32]*Buffer),
 		rtcpReaders: make(map[uint32]*RTCPReader),
+		rtxPairs:    make(map[uint32]uint32),
 		logger:      logger,
 	}
 }
@@ -68,9 +71,17 @@ func (f *Factory) GetOrNew(packetType packetio.BufferPacketType, ssrc uint32) io
 		}
 		buffer := NewBuffer(ssrc, f.videoPool, f.audioPool, f.logger)
 		f.rtpBuffers[ssrc] = buffer
+		buffer.primaryForRTX = func() *Buffer {
+			return f.getRTXPrimary(ssrc)
+		}
 		buffer.OnClose(func() {
 			f.Lock()
 			delete(f.rtpBuffers, ssrc)
+			for rtxSSRC, primarySSRC := range f.rtxPairs {
+				if rtxSSRC == ssrc || primarySSRC == ssrc {
+					delete(f.rtxPairs, rtxSSRC)
+				}
+			}
 			f.Unlock()
 		})
 		return buffer
@@ -78,6 +89,24 @@ func (f *Factory) GetOrNew(packetType packetio.BufferPacketType, ssrc uint32) io
 	return nil
 }
 
+// SetRTXPair declares rtxSSRC as the RTX stream repairing primarySSRC. Packets received on it are
+// restored and added to the buffer of primarySSRC
+func (f *Factory) SetRTXPair(rtxSSRC, primarySSRC uint32) {
+	f.Lock()
+	defer f.Unlock()
+	f.rtxPairs[rtxSSRC] = primarySSRC
+}
+
+func (f *Factory) getRTXPrimary(rtxSSRC uint32) *Buffer {
+	f.RLock()
+	defer f.RUnlock()
+	primarySSRC, ok := f.rtxPairs[rtxSSRC]
+	if !ok {
+		return nil
+	}
+	return f.rtpBuffers[primarySSRC]
+}
+
 func (f *Factory) GetBufferPair(ssrc uint32) (*Buffer, *RTCPReader) {
 	f.RLock()
 	defer f.RUnlock()
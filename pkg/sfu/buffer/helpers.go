@@ -166,6 +166,22 @@ func (p *VP8) Unmarshal(payload []byte) error {
 	return nil
 }
 
+// KeyFrameDimensions parses the frame dimensions from the payload of the first packet of a key
+// frame, the packet p was unmarshalled from
+func (p *VP8) KeyFrameDimensions(payload []byte) (width, height uint32, ok bool) {
+	// 3 byte frame tag, 3 byte start code, then 14 bit width and height, little endian
+	idx := p.HeaderSize
+	if !p.IsKeyFrame || len(payload) < idx+10 {
+		return 0, 0, false
+	}
+	if payload[idx+3] != 0x9d || payload[idx+4] != 0x01 || payload[idx+5] != 0x2a {
+		return 0, 0, false
+	}
+	width = uint32(binary.LittleEndian.Uint16(payload[idx+6:]) & 0x3fff)
+	height = uint32(binary.LittleEndian.Uint16(payload[idx+8:]) & 0x3fff)
+	return width, height, true
+}
+
 func (v *VP8) MarshalTo(buf []byte) error {
 	if len(buf) < v.HeaderSize {
 		return errShortPacket
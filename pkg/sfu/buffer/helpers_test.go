@@ -92,3 +92,25 @@ func TestVP8Helper_Unmarshal(t *testing.T) {
 		})
 	}
 }
+
+func TestVP8Helper_KeyFrameDimensions(t *testing.T) {
+	// payload descriptor, frame tag, start code, 1280x720
+	keyFrame := []byte{0x10, 0x50, 0x2d, 0x00, 0x9d, 0x01, 0x2a, 0x00, 0x05, 0xd0, 0x02}
+	p := &VP8{}
+	assert.NoError(t, p.Unmarshal(keyFrame))
+	width, height, ok := p.KeyFrameDimensions(keyFrame)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1280), width)
+	assert.Equal(t, uint32(720), height)
+
+	// inter frames don't carry dimensions
+	interFrame := []byte{0x10, 0x51, 0x2d, 0x00, 0x9d, 0x01, 0x2a, 0x00, 0x05, 0xd0, 0x02}
+	assert.NoError(t, p.Unmarshal(interFrame))
+	_, _, ok = p.KeyFrameDimensions(interFrame)
+	assert.False(t, ok)
+
+	// nor do truncated packets
+	assert.NoError(t, p.Unmarshal(keyFrame[:8]))
+	_, _, ok = p.KeyFrameDimensions(keyFrame[:8])
+	assert.False(t, ok)
+}
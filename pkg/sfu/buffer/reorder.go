@@ -0,0 +1,98 @@
+package buffer
+
+import (
+	"sort"
+	"time"
+)
+
+// max packets held waiting for a missing one, past it the gap is skipped
+const maxReorderPackets = 32
+
+// heldPacket waits in the reorder window for the packets missing before it
+type heldPacket struct {
+	extSN uint32
+	ep    *ExtPacket
+}
+
+// forward queues a packet to be read by the receiver. With a reorder window, packets arriving ahead of
+// missing ones wait for them up to the window, so that mildly out of order packets are forwarded in
+// order and subscribers don't request the ones they'd see missing. Packets that arrive once the window
+// has passed are forwarded as they come, late. The caller holds the lock
+func (b *Buffer) forward(ep *ExtPacket, extSN uint32, retransmitted bool) {
+	if b.reorderWindow == 0 {
+		b.extPackets.PushBack(ep)
+		return
+	}
+
+	switch {
+	case !b.reorderStarted:
+		b.reorderStarted = true
+		b.nextSN = extSN + 1
+		b.extPackets.PushBack(ep)
+	case extSN == b.nextSN:
+		if depth := len(b.held); depth > 0 {
+			// arrived after the packets held for it
+			b.stats.Reordered++
+			if b.onReordered != nil {
+				b.onReordered(depth)
+			}
+		}
+		ep.Head = true
+		b.extPackets.PushBack(ep)
+		b.nextSN++
+		b.releaseHeld()
+	case int32(extSN-b.nextSN) < 0:
+		ep.Head = false
+		// retransmissions of lost packets are expected to be late
+		if !retransmitted {
+			b.stats.Late++
+			if b.onLate != nil {
+				b.onLate()
+			}
+		}
+		b.extPackets.PushBack(ep)
+	default:
+		i := sort.Search(len(b.held), func(i int) bool { return int32(b.held[i].extSN-extSN) >= 0 })
+		b.held = append(b.held, heldPacket{})
+		copy(b.held[i+1:], b.held[i:])
+		b.held[i] = heldPacket{extSN: extSN, ep: ep}
+	}
+
+	b.releaseExpired(ep.Arrival)
+}
+
+// releaseHeld forwards the held packets that are next in order. The caller holds the lock
+func (b *Buffer) releaseHeld() {
+	n := 0
+	for ; n < len(b.held) && b.held[n].extSN == b.nextSN; n++ {
+		ep := b.held[n].ep
+		ep.Head = true
+		b.extPackets.PushBack(ep)
+		b.nextSN++
+	}
+	if n > 0 {
+		copy(b.held, b.held[n:])
+		for i := len(b.held) - n; i < len(b.held); i++ {
+			b.held[i] = heldPacket{}
+		}
+		b.held = b.held[:len(b.held)-n]
+	}
+}
+
+// releaseExpired gives up on the missing packets that held packets waited for longer than the window,
+// or that too many packets wait for. The caller holds the lock
+func (b *Buffer) releaseExpired(now int64) {
+	for len(b.held) > 0 {
+		oldest := b.held[0].ep.Arrival
+		for _, h := range b.held[1:] {
+			if h.ep.Arrival < oldest {
+				oldest = h.ep.Arrival
+			}
+		}
+		if len(b.held) <= maxReorderPackets && time.Duration(now-oldest) < b.reorderWindow {
+			return
+		}
+		b.nextSN = b.held[0].extSN
+		b.releaseHeld()
+	}
+}
@@ -0,0 +1,95 @@
+package buffer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReorderWindow(t *testing.T) {
+	pool := &sync.Pool{
+		New: func() interface{} {
+			b := make([]byte, 100*maxPktSize)
+			return &b
+		},
+	}
+	newBuffer := func(window time.Duration) *Buffer {
+		buff := NewBuffer(123, pool, pool, Logger)
+		buff.OnFeedback(func(_ []rtcp.Packet) {})
+		buff.Bind(webrtc.RTPParameters{
+			Codecs: []webrtc.RTPCodecParameters{opusCodec},
+		}, opusCodec.RTPCodecCapability, Options{ReorderWindow: window})
+		return buff
+	}
+	write := func(buff *Buffer, sns ...uint16) {
+		for _, sn := range sns {
+			pkt := rtp.Packet{
+				Header:  rtp.Header{Version: 2, SequenceNumber: sn, Timestamp: uint32(sn) * 960},
+				Payload: []byte{1, 2, 3},
+			}
+			b, err := pkt.Marshal()
+			require.NoError(t, err)
+			_, err = buff.Write(b)
+			require.NoError(t, err)
+		}
+	}
+	read := func(buff *Buffer, n int) (sns []uint16, heads []bool) {
+		for i := 0; i < n; i++ {
+			ep, err := buff.ReadExtended()
+			require.NoError(t, err)
+			sns = append(sns, ep.Packet.SequenceNumber)
+			heads = append(heads, ep.Head)
+		}
+		return
+	}
+
+	t.Run("puts packets back in order", func(t *testing.T) {
+		buff := newBuffer(time.Second)
+		var depths []int
+		buff.OnReordered(func(depth int) {
+			depths = append(depths, depth)
+		})
+
+		write(buff, 1, 2, 4, 5, 3, 6)
+		sns, heads := read(buff, 6)
+		require.Equal(t, []uint16{1, 2, 3, 4, 5, 6}, sns)
+		require.Equal(t, []bool{true, true, true, true, true, true}, heads)
+		require.Equal(t, []int{2}, depths)
+		require.Equal(t, uint32(1), buff.GetStats().Reordered)
+	})
+
+	t.Run("gives up on missing packets after the window", func(t *testing.T) {
+		buff := newBuffer(30 * time.Millisecond)
+		late := 0
+		buff.OnLate(func() {
+			late++
+		})
+
+		write(buff, 1, 3)
+		sns, _ := read(buff, 1)
+		require.Equal(t, []uint16{1}, sns)
+		// released once the window passes, without another packet
+		sns, _ = read(buff, 1)
+		require.Equal(t, []uint16{3}, sns)
+
+		write(buff, 2, 4)
+		sns, heads := read(buff, 2)
+		require.Equal(t, []uint16{2, 4}, sns)
+		require.Equal(t, []bool{false, true}, heads)
+		require.Equal(t, 1, late)
+		require.Equal(t, uint32(1), buff.GetStats().Late)
+	})
+
+	t.Run("forwards packets as they arrive without a window", func(t *testing.T) {
+		buff := newBuffer(0)
+		write(buff, 1, 3, 2)
+		sns, heads := read(buff, 3)
+		require.Equal(t, []uint16{1, 3, 2}, sns)
+		require.Equal(t, []bool{true, true, false}, heads)
+	})
+}
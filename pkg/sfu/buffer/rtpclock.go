@@ -0,0 +1,95 @@
+package buffer
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// sender reports whose RTP clock runs off its nominal rate by more than this restart the mapping,
+	// the publisher restarted its stream or stepped its clock
+	maxRTPClockDrift = 0.005
+	// sender reports closer than this are too close to measure the rate of the RTP clock
+	minRTPClockInterval = 500 * time.Millisecond
+	// weight of the latest measurement in the rate and offset of the clock, as 1/n
+	rtpClockRateSmoothing   = 8
+	rtpClockOffsetSmoothing = 16
+)
+
+var ntpEpoch = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// rtpClock maps the RTP timestamps of a stream to the node's clock, from the sender reports of the
+// publisher. It keeps the publisher's mapping of NTP to RTP time, which the streams of a publisher share,
+// so that sender reports sent to subscribers keep them in sync. The rate of the RTP clock is measured
+// across sender reports, to correct for the publisher's clock running fast or slow
+type rtpClock struct {
+	lock sync.Mutex
+	// last sender report, the time is the publisher's
+	srRTP uint32
+	srNTP time.Time
+	// RTP ticks per second of the publisher's NTP clock
+	rate float64
+	// node's clock minus the publisher's at the same instant. Network delay adds to it, so it's set to
+	// the smallest seen, and only slowly follows higher values as the clocks drift apart
+	offset time.Duration
+	valid  bool
+}
+
+// onSenderReport updates the mapping with a sender report received at receivedAt, for a stream of
+// the given clock rate
+func (c *rtpClock) onSenderReport(clockRate uint32, rtpTime uint32, ntpTime uint64, receivedAt time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if clockRate == 0 {
+		return
+	}
+	srNTP := ntpToTime(ntpTime)
+	offset := receivedAt.Sub(srNTP)
+	nominal := float64(clockRate)
+
+	if c.valid {
+		elapsed := srNTP.Sub(c.srNTP)
+		measured := float64(int32(rtpTime-c.srRTP)) / elapsed.Seconds()
+		switch {
+		case elapsed <= 0:
+			c.valid = false
+		case elapsed < minRTPClockInterval:
+		case math.Abs(measured-nominal) > nominal*maxRTPClockDrift:
+			c.valid = false
+		default:
+			c.rate += (measured - c.rate) / rtpClockRateSmoothing
+		}
+	}
+	if !c.valid || offset < c.offset {
+		c.offset = offset
+	} else {
+		c.offset += (offset - c.offset) / rtpClockOffsetSmoothing
+	}
+	if !c.valid {
+		c.rate = nominal
+		c.valid = true
+	}
+	c.srRTP = rtpTime
+	c.srNTP = srNTP
+}
+
+// rtpTimeAt returns the RTP timestamp of the stream at the given time of the node's clock, false
+// before the publisher sent a sender report
+func (c *rtpClock) rtpTimeAt(at time.Time) (uint32, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.valid {
+		return 0, false
+	}
+	elapsed := at.Add(-c.offset).Sub(c.srNTP)
+	return c.srRTP + uint32(int64(math.Round(elapsed.Seconds()*c.rate))), true
+}
+
+func ntpToTime(ntpTime uint64) time.Time {
+	sec := time.Duration(ntpTime>>32) * time.Second
+	nsec := time.Duration(((ntpTime & 0xffffffff) * 1e9) >> 32)
+	return ntpEpoch.Add(sec + nsec)
+}
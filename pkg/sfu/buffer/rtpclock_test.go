@@ -0,0 +1,71 @@
+package buffer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRTPClock(t *testing.T) {
+	const clockRate = 90000
+	// the publisher's clock runs 10s ahead of the node's
+	publisherOffset := 10 * time.Second
+	start := time.Now()
+	ntp := func(at time.Time) uint64 {
+		return timeToNTP(at.Add(publisherOffset))
+	}
+
+	t.Run("maps RTP time to the node's clock", func(t *testing.T) {
+		c := &rtpClock{}
+		_, ok := c.rtpTimeAt(start)
+		require.False(t, ok)
+
+		c.onSenderReport(clockRate, 1000, ntp(start), start.Add(20*time.Millisecond))
+		rtpTime, ok := c.rtpTimeAt(start.Add(20*time.Millisecond + time.Second))
+		require.True(t, ok)
+		require.Equal(t, uint32(1000+clockRate), rtpTime)
+	})
+
+	t.Run("network delay is left out", func(t *testing.T) {
+		c := &rtpClock{}
+		c.onSenderReport(clockRate, 1000, ntp(start), start.Add(20*time.Millisecond))
+		// a late report doesn't move the mapping much
+		c.onSenderReport(clockRate, 1000+clockRate, ntp(start.Add(time.Second)), start.Add(time.Second+200*time.Millisecond))
+		rtpTime, _ := c.rtpTimeAt(start.Add(2*time.Second + 20*time.Millisecond))
+		require.InDelta(t, 1000+2*clockRate, float64(rtpTime), clockRate*0.02)
+
+		// an earlier one replaces it
+		c.onSenderReport(clockRate, 1000+2*clockRate, ntp(start.Add(2*time.Second)), start.Add(2*time.Second+5*time.Millisecond))
+		rtpTime, _ = c.rtpTimeAt(start.Add(3*time.Second + 5*time.Millisecond))
+		require.Equal(t, uint32(1000+3*clockRate), rtpTime)
+	})
+
+	t.Run("follows the rate of the publisher's RTP clock", func(t *testing.T) {
+		// 0.2% fast
+		const rate = clockRate * 1.002
+		c := &rtpClock{}
+		for i := 0; i < 50; i++ {
+			at := start.Add(time.Duration(i) * time.Second)
+			c.onSenderReport(clockRate, uint32(i*rate), ntp(at), at)
+		}
+		at := start.Add(53 * time.Second)
+		rtpTime, _ := c.rtpTimeAt(at)
+		require.InDelta(t, 53*rate, float64(rtpTime), 5)
+	})
+
+	t.Run("restarts when the publisher's stream does", func(t *testing.T) {
+		c := &rtpClock{}
+		c.onSenderReport(clockRate, 1000, ntp(start), start)
+		c.onSenderReport(clockRate, 500_000_000, ntp(start.Add(time.Second)), start.Add(time.Second))
+		rtpTime, _ := c.rtpTimeAt(start.Add(2 * time.Second))
+		require.Equal(t, uint32(500_000_000+clockRate), rtpTime)
+	})
+}
+
+func timeToNTP(t time.Time) uint64 {
+	d := t.Sub(ntpEpoch)
+	sec := uint64(d / time.Second)
+	frac := (uint64(d%time.Second) << 32) / 1e9
+	return sec<<32 | frac
+}
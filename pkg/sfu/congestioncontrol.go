@@ -0,0 +1,326 @@
+package sfu
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+//
+// Congestion control estimates the channel capacity of a subscriber peer connection.
+// StreamAllocator allocates layers against the committed capacity, the algorithm
+// producing it is pluggable so that different ones can be evaluated side by side.
+//
+//   - gcc: commits the REMB estimates sent by the subscriber. Browsers compute those
+//     with the delay based Google Congestion Control estimator.
+//   - bbr: ignores REMB and estimates capacity from what was actually delivered, in the
+//     spirit of BBR. It tracks the bottleneck bandwidth as the windowed maximum delivery
+//     rate, backs off when loss or queueing delay shows up and otherwise probes upwards.
+//
+
+const (
+	CongestionControlGCC = "gcc"
+	CongestionControlBBR = "bbr"
+
+	// number of samples, one per second, the bottleneck bandwidth is the maximum of
+	BBRBandwidthWindow = 10
+	// number of samples minimum RTT is tracked over
+	BBRRTTWindow = 30
+	// growth when the channel is not congested
+	BBRProbeGain = 1.25
+	// loss above which the channel is considered congested, in percent
+	BBRLossThreshold = 2.0
+	// RTT over the minimum by this factor indicates queueing
+	BBRRTTInflation = 1.5
+	// ignore RTT inflation below this, to be robust to jitter on low latency paths
+	BBRRTTInflationMinMs = 30
+	// never estimate below this so that at least the lowest layers can flow
+	BBRMinEstimate = 150 * 1000 // 150 kbps
+)
+
+// ChannelSample describes what was sent on a peer connection over an interval, and the
+// feedback received for it
+type ChannelSample struct {
+	Interval  time.Duration
+	BytesSent int64
+	// weighted loss reported by the subscriber, in percent
+	LossPercentage float32
+	// 0 when unknown
+	RTTMs uint32
+}
+
+// CongestionController estimates channel capacity. It is driven from the StreamAllocator
+// event loop and does not need to be safe for concurrent use
+type CongestionController interface {
+	Name() string
+	// Reset returns to the initial state, where the channel is assumed to have infinite capacity
+	Reset()
+	// HandleEstimate is called with each REMB estimate from the subscriber
+	HandleEstimate(estimate int64)
+	// HandleSample is called periodically with channel statistics
+	HandleSample(sample ChannelSample)
+	// Estimate returns the latest capacity estimate, which may not have been committed yet
+	Estimate() int64
+	// MaybeCommit commits the latest estimate when it is due, reporting whether the
+	// committed capacity went down
+	MaybeCommit() (committed bool, isDecreasing bool)
+	CommittedCapacity() int64
+}
+
+func NewCongestionController(algorithm string) (CongestionController, error) {
+	var c CongestionController
+	switch algorithm {
+	case "", CongestionControlGCC:
+		c = &gccController{}
+	case CongestionControlBBR:
+		c = &bbrController{}
+	default:
+		return nil, fmt.Errorf("unknown congestion control algorithm: %s", algorithm)
+	}
+	c.Reset()
+	return c, nil
+}
+
+// NewCappedCongestionController limits the capacity estimated by c to maxCapacity, to emulate a
+// constrained network
+func NewCappedCongestionController(c CongestionController, maxCapacity int64) CongestionController {
+	return &cappedController{
+		CongestionController: c,
+		maxCapacity:          maxCapacity,
+	}
+}
+
+//------------------------------------------------
+
+type cappedController struct {
+	CongestionController
+	maxCapacity     int64
+	lastEnforceTime time.Time
+}
+
+func (c *cappedController) Reset() {
+	c.CongestionController.Reset()
+	c.lastEnforceTime = time.Time{}
+}
+
+func (c *cappedController) Estimate() int64 {
+	return c.capped(c.CongestionController.Estimate())
+}
+
+func (c *cappedController) CommittedCapacity() int64 {
+	return c.capped(c.CongestionController.CommittedCapacity())
+}
+
+// MaybeCommit reports a decrease periodically while the cap is in effect, so that tracks
+// allocated without regard to capacity while the allocator was stable are brought under it
+func (c *cappedController) MaybeCommit() (committed bool, isDecreasing bool) {
+	prev := c.CommittedCapacity()
+	c.CongestionController.MaybeCommit()
+	current := c.CommittedCapacity()
+	if current != prev {
+		return true, current < prev
+	}
+
+	capping := c.CongestionController.CommittedCapacity() > c.maxCapacity
+	if capping && time.Since(c.lastEnforceTime) >= EstimateCommitMs {
+		c.lastEnforceTime = time.Now()
+		return true, true
+	}
+	// changes above the cap aren't visible
+	return false, false
+}
+
+func (c *cappedController) capped(capacity int64) int64 {
+	if capacity > c.maxCapacity {
+		return c.maxCapacity
+	}
+	return capacity
+}
+
+//------------------------------------------------
+
+type gccController struct {
+	committedChannelCapacity int64
+	lastCommitTime           time.Time
+	prevReceivedEstimate     int64
+	receivedEstimate         int64
+}
+
+func (c *gccController) Name() string {
+	return CongestionControlGCC
+}
+
+func (c *gccController) Reset() {
+	c.committedChannelCapacity = ChannelCapacityInfinity
+	c.lastCommitTime = time.Now().Add(-EstimateCommitMs)
+	c.receivedEstimate = ChannelCapacityInfinity
+}
+
+func (c *gccController) HandleEstimate(estimate int64) {
+	c.prevReceivedEstimate = c.receivedEstimate
+	c.receivedEstimate = estimate
+}
+
+func (c *gccController) HandleSample(_ ChannelSample) {
+}
+
+func (c *gccController) Estimate() int64 {
+	return c.receivedEstimate
+}
+
+func (c *gccController) CommittedCapacity() int64 {
+	return c.committedChannelCapacity
+}
+
+func (c *gccController) MaybeCommit() (committed bool, isDecreasing bool) {
+	// commit channel capacity estimate under following rules
+	//   1. Abs(receivedEstimate - prevReceivedEstimate) < EstimateEpsilon => estimate stable
+	//   2. time.Since(lastCommitTime) > EstimateCommitMs => to catch long oscillating estimate
+	if math.Abs(float64(c.receivedEstimate)-float64(c.prevReceivedEstimate)) > EstimateEpsilon {
+		// too large a change, wait for estimate to settle.
+		// Unless estimate has been oscillating for too long.
+		if time.Since(c.lastCommitTime) < EstimateCommitMs {
+			return
+		}
+	}
+
+	// don't commit too often even if the change is small.
+	// Small changes will also get picked up during periodic check.
+	if time.Since(c.lastCommitTime) < EstimateCommitMs {
+		return
+	}
+
+	if c.receivedEstimate == c.committedChannelCapacity {
+		// no change in estimate, no need to commit
+		return
+	}
+
+	if c.committedChannelCapacity > c.receivedEstimate && c.committedChannelCapacity != ChannelCapacityInfinity {
+		// this prevents declaring a decrease when coming out of init state.
+		// But, this bypasses the case where streaming starts on a bunch of
+		// tracks simultaneously (imagine a participant joining a large room
+		// with a lot of video tracks). In that case, it is possible that the
+		// channel is hitting congestion. It will caught on the next estimate
+		// decrease.
+		isDecreasing = true
+	}
+	c.committedChannelCapacity = c.receivedEstimate
+	c.lastCommitTime = time.Now()
+	committed = true
+	return
+}
+
+//------------------------------------------------
+
+type bbrController struct {
+	// recent delivery rates and RTTs, oldest first
+	deliveryRates []int64
+	rtts          []int64
+
+	estimate                 int64
+	committedChannelCapacity int64
+	lastCommitTime           time.Time
+}
+
+func (c *bbrController) Name() string {
+	return CongestionControlBBR
+}
+
+func (c *bbrController) Reset() {
+	c.deliveryRates = nil
+	c.rtts = nil
+	c.estimate = ChannelCapacityInfinity
+	c.committedChannelCapacity = ChannelCapacityInfinity
+	c.lastCommitTime = time.Now().Add(-EstimateCommitMs)
+}
+
+// HandleEstimate ignores REMB, capacity is derived from delivery alone
+func (c *bbrController) HandleEstimate(_ int64) {
+}
+
+func (c *bbrController) HandleSample(sample ChannelSample) {
+	if sample.Interval <= 0 {
+		return
+	}
+
+	sentBps := sample.BytesSent * 8 * int64(time.Second) / int64(sample.Interval)
+	loss := math.Min(math.Max(float64(sample.LossPercentage), 0), 100)
+	deliveredBps := int64(float64(sentBps) * (100 - loss) / 100)
+
+	c.deliveryRates = appendWindowed(c.deliveryRates, deliveredBps, BBRBandwidthWindow)
+	if sample.RTTMs != 0 {
+		c.rtts = appendWindowed(c.rtts, int64(sample.RTTMs), BBRRTTWindow)
+	}
+
+	if loss > BBRLossThreshold || c.isQueueing(sample.RTTMs) {
+		// back off to what got through, forgetting earlier samples so the
+		// bottleneck bandwidth doesn't immediately bounce back
+		c.deliveryRates = nil
+		if deliveredBps < c.estimate {
+			c.estimate = deliveredBps
+		}
+		if c.estimate < BBRMinEstimate {
+			c.estimate = BBRMinEstimate
+		}
+		return
+	}
+
+	if c.estimate == ChannelCapacityInfinity {
+		// nothing has indicated a limit yet
+		return
+	}
+
+	// Samples are application limited most of the time as the allocator doesn't send
+	// more than it has allocated, so the estimate is only ever raised without congestion.
+	var btlBw int64
+	for _, rate := range c.deliveryRates {
+		if rate > btlBw {
+			btlBw = rate
+		}
+	}
+	if probe := int64(float64(btlBw) * BBRProbeGain); probe > c.estimate {
+		c.estimate = probe
+	}
+}
+
+func (c *bbrController) isQueueing(rttMs uint32) bool {
+	if rttMs == 0 || len(c.rtts) == 0 {
+		return false
+	}
+	minRTT := c.rtts[0]
+	for _, rtt := range c.rtts {
+		if rtt < minRTT {
+			minRTT = rtt
+		}
+	}
+	rtt := int64(rttMs)
+	return rtt > minRTT+BBRRTTInflationMinMs && float64(rtt) > float64(minRTT)*BBRRTTInflation
+}
+
+func (c *bbrController) Estimate() int64 {
+	return c.estimate
+}
+
+func (c *bbrController) CommittedCapacity() int64 {
+	return c.committedChannelCapacity
+}
+
+func (c *bbrController) MaybeCommit() (committed bool, isDecreasing bool) {
+	if time.Since(c.lastCommitTime) < EstimateCommitMs || c.estimate == c.committedChannelCapacity {
+		return
+	}
+
+	isDecreasing = c.estimate < c.committedChannelCapacity
+	c.committedChannelCapacity = c.estimate
+	c.lastCommitTime = time.Now()
+	committed = true
+	return
+}
+
+func appendWindowed(samples []int64, sample int64, size int) []int64 {
+	samples = append(samples, sample)
+	if len(samples) > size {
+		samples = samples[len(samples)-size:]
+	}
+	return samples
+}
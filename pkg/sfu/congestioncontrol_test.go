@@ -0,0 +1,133 @@
+package sfu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCongestionController(t *testing.T) {
+	for _, algorithm := range []string{"", CongestionControlGCC, CongestionControlBBR} {
+		c, err := NewCongestionController(algorithm)
+		require.NoError(t, err)
+		require.Equal(t, int64(ChannelCapacityInfinity), c.CommittedCapacity())
+	}
+
+	_, err := NewCongestionController("cubic")
+	require.Error(t, err)
+}
+
+func TestGCCController(t *testing.T) {
+	c, _ := NewCongestionController(CongestionControlGCC)
+
+	// coming out of init isn't a decrease
+	c.HandleEstimate(1_000_000)
+	committed, isDecreasing := c.MaybeCommit()
+	require.True(t, committed)
+	require.False(t, isDecreasing)
+	require.Equal(t, int64(1_000_000), c.CommittedCapacity())
+
+	// too soon after the last commit
+	c.HandleEstimate(500_000)
+	committed, _ = c.MaybeCommit()
+	require.False(t, committed)
+	require.Equal(t, int64(500_000), c.Estimate())
+
+	c.(*gccController).lastCommitTime = time.Now().Add(-EstimateCommitMs)
+	committed, isDecreasing = c.MaybeCommit()
+	require.True(t, committed)
+	require.True(t, isDecreasing)
+	require.Equal(t, int64(500_000), c.CommittedCapacity())
+}
+
+func TestBBRController(t *testing.T) {
+	sample := func(bps int64, loss float32, rtt uint32) ChannelSample {
+		return ChannelSample{
+			Interval:       time.Second,
+			BytesSent:      bps / 8,
+			LossPercentage: loss,
+			RTTMs:          rtt,
+		}
+	}
+	commit := func(c CongestionController) (bool, bool) {
+		c.(*bbrController).lastCommitTime = time.Now().Add(-EstimateCommitMs)
+		return c.MaybeCommit()
+	}
+
+	t.Run("unlimited until congestion", func(t *testing.T) {
+		c, _ := NewCongestionController(CongestionControlBBR)
+		c.HandleEstimate(100_000)
+		c.HandleSample(sample(2_000_000, 0, 50))
+		require.Equal(t, int64(ChannelCapacityInfinity), c.Estimate())
+	})
+
+	t.Run("backs off on loss and probes up", func(t *testing.T) {
+		c, _ := NewCongestionController(CongestionControlBBR)
+		c.HandleSample(sample(2_000_000, 0, 50))
+		c.HandleSample(sample(2_000_000, 10, 50))
+		require.Equal(t, int64(1_800_000), c.Estimate())
+
+		committed, isDecreasing := commit(c)
+		require.True(t, committed)
+		require.True(t, isDecreasing)
+
+		// application limited samples don't lower the estimate
+		c.HandleSample(sample(500_000, 0, 50))
+		require.Equal(t, int64(1_800_000), c.Estimate())
+
+		// sending close to the estimate without congestion probes higher
+		c.HandleSample(sample(1_800_000, 0, 50))
+		require.Equal(t, int64(1_800_000*BBRProbeGain), c.Estimate())
+
+		committed, isDecreasing = commit(c)
+		require.True(t, committed)
+		require.False(t, isDecreasing)
+	})
+
+	t.Run("backs off on queueing", func(t *testing.T) {
+		c, _ := NewCongestionController(CongestionControlBBR)
+		c.HandleSample(sample(1_000_000, 0, 40))
+		c.HandleSample(sample(1_000_000, 0, 50))
+		require.Equal(t, int64(ChannelCapacityInfinity), c.Estimate())
+
+		c.HandleSample(sample(1_000_000, 0, 200))
+		require.Equal(t, int64(1_000_000), c.Estimate())
+	})
+
+	t.Run("floor", func(t *testing.T) {
+		c, _ := NewCongestionController(CongestionControlBBR)
+		c.HandleSample(sample(0, 50, 0))
+		require.Equal(t, int64(BBRMinEstimate), c.Estimate())
+	})
+}
+
+func TestCappedController(t *testing.T) {
+	gcc, _ := NewCongestionController(CongestionControlGCC)
+	c := NewCappedCongestionController(gcc, 500_000)
+	require.Equal(t, int64(500_000), c.CommittedCapacity())
+
+	// enforced right away, even though the estimate hasn't changed
+	committed, isDecreasing := c.MaybeCommit()
+	require.True(t, committed)
+	require.True(t, isDecreasing)
+
+	// and then periodically while it's in effect
+	c.HandleEstimate(2_000_000)
+	committed, _ = c.MaybeCommit()
+	require.False(t, committed)
+	require.Equal(t, int64(500_000), c.Estimate())
+
+	c.(*cappedController).lastEnforceTime = time.Now().Add(-EstimateCommitMs)
+	committed, isDecreasing = c.MaybeCommit()
+	require.True(t, committed)
+	require.True(t, isDecreasing)
+
+	// estimates below the cap pass through
+	gcc.(*gccController).lastCommitTime = time.Now().Add(-EstimateCommitMs)
+	c.HandleEstimate(300_000)
+	committed, isDecreasing = c.MaybeCommit()
+	require.True(t, committed)
+	require.True(t, isDecreasing)
+	require.Equal(t, int64(300_000), c.CommittedCapacity())
+}
@@ -0,0 +1,99 @@
+package sfu
+
+import (
+	"io"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+)
+
+// packets that can be held back at once, enough for a few seconds of high bitrate video
+const delayedWriterQueueSize = 4096
+
+type delayedPacket struct {
+	due     time.Time
+	header  *rtp.Header
+	payload []byte
+	raw     []byte
+}
+
+// delayedWriter holds back packets written to a track for a fixed delay before sending them on,
+// to emulate network latency. Packets keep their order, and are dropped when the queue is full.
+type delayedWriter struct {
+	writer webrtc.TrackLocalWriter
+	delay  time.Duration
+	queue  chan *delayedPacket
+	done   chan struct{}
+}
+
+func newDelayedWriter(writer webrtc.TrackLocalWriter, delay time.Duration) *delayedWriter {
+	w := &delayedWriter{
+		writer: writer,
+		delay:  delay,
+		queue:  make(chan *delayedPacket, delayedWriterQueueSize),
+		done:   make(chan struct{}),
+	}
+	go w.writeWorker()
+	return w
+}
+
+func (w *delayedWriter) WriteRTP(header *rtp.Header, payload []byte) (int, error) {
+	hdr := header.Clone()
+	return w.enqueue(&delayedPacket{
+		header:  &hdr,
+		payload: append([]byte(nil), payload...),
+	}, header.MarshalSize()+len(payload))
+}
+
+func (w *delayedWriter) Write(b []byte) (int, error) {
+	return w.enqueue(&delayedPacket{
+		raw: append([]byte(nil), b...),
+	}, len(b))
+}
+
+func (w *delayedWriter) enqueue(pkt *delayedPacket, size int) (int, error) {
+	select {
+	case <-w.done:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+
+	pkt.due = time.Now().Add(w.delay)
+	select {
+	case w.queue <- pkt:
+	default:
+		// dropped like a congested link would
+	}
+	return size, nil
+}
+
+func (w *delayedWriter) Close() {
+	select {
+	case <-w.done:
+	default:
+		close(w.done)
+	}
+}
+
+func (w *delayedWriter) writeWorker() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case pkt := <-w.queue:
+			if wait := time.Until(pkt.due); wait > 0 {
+				select {
+				case <-w.done:
+					return
+				case <-time.After(wait):
+				}
+			}
+			if pkt.raw != nil {
+				_, _ = w.writer.Write(pkt.raw)
+			} else {
+				_, _ = w.writer.WriteRTP(pkt.header, pkt.payload)
+			}
+		}
+	}
+}
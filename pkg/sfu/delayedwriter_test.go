@@ -0,0 +1,66 @@
+package sfu
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingWriter struct {
+	mu      sync.Mutex
+	written []uint16
+	at      []time.Time
+}
+
+func (w *recordingWriter) WriteRTP(header *rtp.Header, _ []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.written = append(w.written, header.SequenceNumber)
+	w.at = append(w.at, time.Now())
+	return 0, nil
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *recordingWriter) numWritten() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.written)
+}
+
+func TestDelayedWriter(t *testing.T) {
+	rw := &recordingWriter{}
+	w := newDelayedWriter(rw, 50*time.Millisecond)
+	defer w.Close()
+
+	start := time.Now()
+	hdr := &rtp.Header{}
+	for sn := uint16(1); sn <= 3; sn++ {
+		hdr.SequenceNumber = sn
+		_, err := w.WriteRTP(hdr, []byte{1, 2, 3})
+		require.NoError(t, err)
+	}
+	require.Equal(t, 0, rw.numWritten())
+
+	require.Eventually(t, func() bool {
+		return rw.numWritten() == 3
+	}, time.Second, 5*time.Millisecond)
+
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	// in order and held back for the delay
+	require.Equal(t, []uint16{1, 2, 3}, rw.written)
+	require.GreaterOrEqual(t, rw.at[0].Sub(start), 50*time.Millisecond)
+}
+
+func TestDelayedWriterClose(t *testing.T) {
+	w := newDelayedWriter(&recordingWriter{}, time.Millisecond)
+	w.Close()
+	_, err := w.WriteRTP(&rtp.Header{}, nil)
+	require.Error(t, err)
+}
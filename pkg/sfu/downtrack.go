@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
 	"github.com/pion/sdp/v3"
@@ -47,6 +48,22 @@ const (
 	InvalidTemporalLayer = -1
 )
 
+// RTP header extensions whose values are forwarded from publishers to subscribers, when both
+// negotiated them
+const (
+	VideoOrientationURI = "urn:3gpp:video-orientation"
+	PlayoutDelayURI     = "http://www.webrtc.org/experiments/rtp-hdrext/playout-delay"
+)
+
+var forwardedHeaderExtensions = []string{VideoOrientationURI, PlayoutDelayURI}
+
+// forwardedHeaderExtension maps the ID of a forwarded header extension negotiated with the
+// publisher to the one negotiated with the subscriber
+type forwardedHeaderExtension struct {
+	upstreamID uint8
+	id         uint8
+}
+
 type SequenceNumberOrdering int
 
 const (
@@ -64,6 +81,22 @@ const (
 	ForwardingStatusOptimal
 )
 
+func (f ForwardingStatus) String() string {
+	switch f {
+	case ForwardingStatusOff:
+		return "off"
+	case ForwardingStatusPartial:
+		return "partial"
+	case ForwardingStatusOptimal:
+		return "optimal"
+	default:
+		return fmt.Sprintf("%d", int(f))
+	}
+}
+
+// minimum interval between bitrate samples in GetStats
+const statsBitrateInterval = time.Second
+
 var (
 	ErrUnknownKind                       = errors.New("unknown kind of codec")
 	ErrOutOfOrderSequenceNumberCacheMiss = errors.New("out-of-order sequence number not found in cache")
@@ -99,6 +132,8 @@ type TranslationParamsVP8 struct {
 type TranslationParams struct {
 	shouldDrop    bool
 	shouldSendPLI bool
+	// the forwarded spatial layer changed to the layer of the packet
+	switchedLayer bool
 	rtp           *TranslationParamsRTP
 	vp8           *TranslationParamsVP8
 }
@@ -115,12 +150,48 @@ type VideoLayers struct {
 
 type ReceiverReportListener func(dt *DownTrack, report *rtcp.ReceiverReport)
 
+// DownTrackStats holds send statistics of a DownTrack, along with what the subscriber reports back
+type DownTrackStats struct {
+	PacketsSent uint32 `json:"packets_sent"`
+	BytesSent   uint32 `json:"bytes_sent"`
+	Bitrate     int64  `json:"bitrate"`
+	// reported by the subscriber
+	PacketsLost uint32 `json:"packets_lost"`
+	// publisher's bitrate for the highest layers the subscriber can receive, video only
+	ExpectedBitrate      int64   `json:"expected_bitrate,omitempty"`
+	LossPercentage       float32 `json:"loss_percentage"`
+	JitterMs             float64 `json:"jitter_ms"`
+	RTTMs                uint32  `json:"rtt_ms"`
+	CurrentSpatialLayer  int32   `json:"current_spatial_layer"`
+	CurrentTemporalLayer int32   `json:"current_temporal_layer"`
+	TargetSpatialLayer   int32   `json:"target_spatial_layer"`
+	MaxSpatialLayer      int32   `json:"max_spatial_layer"`
+	MaxTemporalLayer     int32   `json:"max_temporal_layer"`
+	LayerSwitches        uint32  `json:"layer_switches"`
+	ForwardingStatus     string  `json:"forwarding_status"`
+	Muted                bool    `json:"muted"`
+}
+
+// DownTrackDebugStats adds the state of the queues in front of the subscriber to DownTrackStats
+type DownTrackDebugStats struct {
+	DownTrackStats
+	TrackID string `json:"track_id"`
+	Kind    string `json:"kind"`
+	// PLIs requested from the publisher on behalf of the subscriber
+	PLIs uint32 `json:"plis"`
+	// packets waiting in the forwarding queue of the down track
+	QueueDepth int `json:"queue_depth"`
+	// packets waiting in the pacer of the subscriber, shared by its down tracks
+	PacerBacklog int `json:"pacer_backlog"`
+}
+
 // DownTrack  implements TrackLocal, is the track used to write packets
 // to SFU Subscriber, the track handle the packets for simple, simulcast
 // and SVC Publisher.
 type DownTrack struct {
 	id            string
 	peerID        string
+	logger        logr.Logger
 	bound         atomicBool
 	kind          webrtc.RTPCodecType
 	mime          string
@@ -133,10 +204,28 @@ type DownTrack struct {
 	bufferFactory *buffer.Factory
 	payload       *[]byte
 
+	// reused by the headers of forwarded packets, which are written one at a time like payload
+	rtpHeader     rtp.Header
+	rtpExtensions []rtp.Extension
+	absSendTime   [3]byte
+
+	// when set, media is held back until the subscriber is ready to receive
+	waitingForReady atomicBool
+	readyTimeout    time.Duration
+	// emulated network latency, see SetEmulatedDelay
+	emulatedDelay time.Duration
+	delayedWriter *delayedWriter
+	// paces the packets sent to the subscriber, see SetPacer
+	pacer *Pacer
+
 	forwarder *Forwarder
+	// set once the down track tried starting on the key frame cached by the receiver
+	cachedKeyFrameTried atomicBool
 
 	codec                   webrtc.RTPCodecCapability
 	rtpHeaderExtensions     []webrtc.RTPHeaderExtensionParameter
+	upstreamHeaderExts      []webrtc.RTPHeaderExtensionParameter
+	forwardedHeaderExts     []forwardedHeaderExtension
 	receiver                TrackReceiver
 	transceiver             *webrtc.RTPTransceiver
 	writeStream             webrtc.TrackLocalWriter
@@ -150,9 +239,19 @@ type DownTrack struct {
 	octetCount   atomicUint32
 	packetCount  atomicUint32
 	lossFraction atomicUint8
+	packetsLost  atomicUint32 // cumulative, as reported by the subscriber
+	jitter       atomicUint32 // in RTP timestamp units, as reported by the subscriber
+	rtt          atomicUint32 // in ms
+
+	statsLock         sync.Mutex
+	bitrate           int64
+	bitrateMeasured   bool
+	lastBitrateOctets uint32
+	lastBitrateAt     time.Time
 
 	// Debug info
 	lastPli     atomicInt64
+	plis        atomicUint32
 	lastRTP     atomicInt64
 	pktsDropped atomicUint32
 
@@ -169,8 +268,15 @@ type DownTrack struct {
 	// max layer change callback
 	onSubscribedLayersChanged func(dt *DownTrack, layers VideoLayers)
 
+	// forwarded layer change callback
+	onLayerSwitched func(dt *DownTrack, layer int32)
+
 	// packet sent callback
 	onPacketSent []func(dt *DownTrack, size int)
+
+	// first frame sent callback
+	onFirstFrame   func(dt *DownTrack)
+	firstFrameSent atomicBool
 }
 
 // NewDownTrack returns a DownTrack.
@@ -196,6 +302,7 @@ func NewDownTrack(c webrtc.RTPCodecCapability, r TrackReceiver, bf *buffer.Facto
 		kind:          kind,
 		forwarder:     NewForwarder(c, kind),
 	}
+	d.SetLogger(Logger.WithValues("peer_id", peerID))
 
 	if strings.ToLower(c.MimeType) == "video/vp8" {
 		d.payload = PacketFactory.Get().(*[]byte)
@@ -204,6 +311,15 @@ func NewDownTrack(c webrtc.RTPCodecCapability, r TrackReceiver, bf *buffer.Facto
 	return d, nil
 }
 
+// SetLogger sets the logger of the down track, usually the one of its subscriber, before it's bound.
+// It's sampled, errors are logged for every packet
+func (d *DownTrack) SetLogger(l logr.Logger) {
+	if l.GetSink() == nil {
+		l = Logger.WithValues("peer_id", d.peerID)
+	}
+	d.logger = NewSampledLogger(l.WithValues("track", d.id), DefaultLogSampleInterval)
+}
+
 func (d *DownTrack) SetTrackType(isSimulcast bool) {
 	if isSimulcast {
 		d.trackType = SimulcastDownTrack
@@ -221,6 +337,16 @@ func (d *DownTrack) Bind(t webrtc.TrackLocalContext) (webrtc.RTPCodecParameters,
 		d.ssrc = uint32(t.SSRC())
 		d.payloadType = uint8(codec.PayloadType)
 		d.writeStream = t.WriteStream()
+		if d.emulatedDelay > 0 {
+			if d.delayedWriter != nil {
+				d.delayedWriter.Close()
+			}
+			d.delayedWriter = newDelayedWriter(d.writeStream, d.emulatedDelay)
+			d.writeStream = d.delayedWriter
+		}
+		if d.pacer != nil {
+			d.writeStream = d.pacer.Writer(d.writeStream)
+		}
 		d.mime = strings.ToLower(codec.MimeType)
 		if rr := d.bufferFactory.GetOrNew(packetio.RTCPBufferPacket, uint32(t.SSRC())).(*buffer.RTCPReader); rr != nil {
 			rr.OnPacket(func(pkt []byte) {
@@ -234,6 +360,7 @@ func (d *DownTrack) Bind(t webrtc.TrackLocalContext) (webrtc.RTPCodecParameters,
 			d.onBind()
 		}
 		d.bound.set(true)
+		d.startReadyTimeout()
 		return codec, nil
 	}
 	return webrtc.RTPCodecParameters{}, webrtc.ErrUnsupportedCodec
@@ -263,6 +390,35 @@ func (d *DownTrack) PeerID() string { return d.peerID }
 // Sets RTP header extensions for this track
 func (d *DownTrack) SetRTPHeaderExtensions(rtpHeaderExtensions []webrtc.RTPHeaderExtensionParameter) {
 	d.rtpHeaderExtensions = rtpHeaderExtensions
+	d.forwardedHeaderExts = matchForwardedHeaderExtensions(d.upstreamHeaderExts, rtpHeaderExtensions)
+}
+
+// SetUpstreamRTPHeaderExtensions sets the RTP header extensions negotiated with the publisher of
+// the track, the values of the ones in forwardedHeaderExtensions are forwarded to the subscriber
+func (d *DownTrack) SetUpstreamRTPHeaderExtensions(rtpHeaderExtensions []webrtc.RTPHeaderExtensionParameter) {
+	d.upstreamHeaderExts = rtpHeaderExtensions
+	d.forwardedHeaderExts = matchForwardedHeaderExtensions(rtpHeaderExtensions, d.rtpHeaderExtensions)
+}
+
+func matchForwardedHeaderExtensions(upstream, downstream []webrtc.RTPHeaderExtensionParameter) []forwardedHeaderExtension {
+	var forwarded []forwardedHeaderExtension
+	for _, uri := range forwardedHeaderExtensions {
+		var upstreamID, id int
+		for _, ext := range upstream {
+			if ext.URI == uri {
+				upstreamID = ext.ID
+			}
+		}
+		for _, ext := range downstream {
+			if ext.URI == uri {
+				id = ext.ID
+			}
+		}
+		if upstreamID != 0 && id != 0 {
+			forwarded = append(forwarded, forwardedHeaderExtension{upstreamID: uint8(upstreamID), id: uint8(id)})
+		}
+	}
+	return forwarded
 }
 
 // Kind controls if this TrackLocal is audio or video
@@ -285,17 +441,88 @@ func (d *DownTrack) SetTransceiver(transceiver *webrtc.RTPTransceiver) {
 	d.transceiver = transceiver
 }
 
+// WaitForReady holds off forwarding media until the subscriber is ready to receive this track,
+// indicated by a call to MarkReady once it sent settings for the track. The subscriber can't send
+// RTCP for a stream it hasn't received, so clients that never send settings get a delayed start:
+// forwarding starts once timeout has passed after binding. Must be called before the track is bound.
+func (d *DownTrack) WaitForReady(timeout time.Duration) {
+	d.readyTimeout = timeout
+	d.waitingForReady.set(true)
+}
+
+func (d *DownTrack) startReadyTimeout() {
+	if d.waitingForReady.get() {
+		time.AfterFunc(d.readyTimeout, d.MarkReady)
+	}
+}
+
+// SetEmulatedDelay delays all packets sent on the track, to emulate network latency in test
+// environments. Must be called before the track is bound.
+func (d *DownTrack) SetEmulatedDelay(delay time.Duration) {
+	d.emulatedDelay = delay
+}
+
+// SetPacer sends the packets of the track through the pacer of the subscriber, which is shared
+// with its other tracks. Must be called before the track is bound.
+func (d *DownTrack) SetPacer(pacer *Pacer) {
+	d.pacer = pacer
+}
+
+// MarkReady starts forwarding media if it was held back waiting for the subscriber
+func (d *DownTrack) MarkReady() {
+	if d.waitingForReady.set(false) {
+		d.logger.V(1).Info("subscriber ready to receive")
+	}
+}
+
+func (d *DownTrack) IsReady() bool {
+	return !d.waitingForReady.get()
+}
+
 // WriteRTP writes a RTP Packet to the DownTrack
 func (d *DownTrack) WriteRTP(extPkt *buffer.ExtPacket, layer int32) error {
 	d.lastRTP.set(time.Now().UnixNano())
 
-	if !d.bound.get() {
+	if !d.bound.get() || d.waitingForReady.get() {
 		return nil
 	}
 
+	if d.kind == webrtc.RTPCodecTypeVideo && !extPkt.KeyFrame && d.packetCount.get() == 0 &&
+		d.forwarder.TargetSpatialLayer() == layer && d.forwarder.CurrentSpatialLayer() == InvalidSpatialLayer {
+		d.writeCachedKeyFrame(layer, extPkt.Packet.SequenceNumber)
+	}
+
+	return d.writeRTP(extPkt, layer)
+}
+
+// writeCachedKeyFrame starts the down track on the key frame of layer cached by the receiver, and
+// the packets received since, up to the packet with sequence number sn. Without a cached key frame
+// the forwarder sends a PLI and waits for the next one instead
+func (d *DownTrack) writeCachedKeyFrame(layer int32, sn uint16) {
+	if !d.cachedKeyFrameTried.set(true) {
+		return
+	}
+
+	packets := d.receiver.GetCachedKeyFrame(layer, sn)
+	for _, pkt := range packets {
+		if err := d.writeRTP(pkt, layer); err != nil {
+			d.logger.Error(err, "writing cached key frame err")
+		}
+		buffer.ReleaseExtPacket(pkt)
+	}
+	if len(packets) != 0 {
+		d.logger.V(1).Info("started on cached key frame", "layer", layer, "packets", len(packets))
+	}
+}
+
+func (d *DownTrack) writeRTP(extPkt *buffer.ExtPacket, layer int32) error {
 	tp, err := d.forwarder.GetTranslationParams(extPkt, layer)
+	if tp.switchedLayer && d.onLayerSwitched != nil {
+		d.onLayerSwitched(d, layer)
+	}
 	if tp.shouldSendPLI {
 		d.lastPli.set(time.Now().UnixNano())
+		d.plis.add(1)
 		d.receiver.SendPLI(layer)
 	}
 	if tp.shouldDrop {
@@ -305,8 +532,9 @@ func (d *DownTrack) WriteRTP(extPkt *buffer.ExtPacket, layer int32) error {
 
 	payload := extPkt.Packet.Payload
 	if tp.vp8 != nil {
-		incomingVP8, _ := extPkt.Payload.(buffer.VP8)
-		payload, err = d.translateVP8Packet(&extPkt.Packet, &incomingVP8, tp.vp8.header)
+		// the munger only translates VP8 packets
+		incomingVP8 := extPkt.Payload.(*buffer.VP8)
+		payload, err = d.translateVP8Packet(&extPkt.Packet, incomingVP8, tp.vp8.header)
 		if err != nil {
 			d.pktsDropped.add(1)
 			return err
@@ -331,6 +559,9 @@ func (d *DownTrack) WriteRTP(extPkt *buffer.ExtPacket, layer int32) error {
 		for _, f := range d.onPacketSent {
 			f(d, hdr.MarshalSize()+len(payload))
 		}
+		if (extPkt.KeyFrame || d.kind == webrtc.RTPCodecTypeAudio) && d.onFirstFrame != nil && d.firstFrameSent.set(true) {
+			d.onFirstFrame(d)
+		}
 	} else {
 		d.pktsDropped.add(1)
 	}
@@ -401,7 +632,7 @@ func (d *DownTrack) WritePaddingRTP(bytesToSend int) int {
 			CSRC:           []uint32{},
 		}
 
-		err = d.writeRTPHeaderExtensions(&hdr)
+		err = d.writeRTPHeaderExtensions(&hdr, nil, nil)
 		if err != nil {
 			return bytesSent
 		}
@@ -459,10 +690,13 @@ func (d *DownTrack) Close() {
 	d.writeBlankFrameRTP()
 
 	d.closeOnce.Do(func() {
-		Logger.V(1).Info("Closing sender", "peer_id", d.peerID, "kind", d.kind)
+		d.logger.V(1).Info("Closing sender", "kind", d.kind)
 		if d.payload != nil {
 			PacketFactory.Put(d.payload)
 		}
+		if d.delayedWriter != nil {
+			d.delayedWriter.Close()
+		}
 		if d.onCloseHandler != nil {
 			d.onCloseHandler()
 		}
@@ -495,6 +729,26 @@ func (d *DownTrack) MaxLayers() VideoLayers {
 	return d.forwarder.MaxLayers()
 }
 
+// MaxSpatialLayer returns the highest layer the subscriber wants to receive
+func (d *DownTrack) MaxSpatialLayer() int32 {
+	return d.forwarder.MaxLayers().spatial
+}
+
+// MaxTemporalLayer returns the highest temporal layer the subscriber wants to receive, higher
+// temporal layers of VP8 tracks are filtered out
+func (d *DownTrack) MaxTemporalLayer() int32 {
+	return d.forwarder.MaxLayers().temporal
+}
+
+// CurrentSpatialLayer returns the layer being forwarded, InvalidSpatialLayer when none is
+func (d *DownTrack) CurrentSpatialLayer() int32 {
+	return d.forwarder.CurrentLayers().spatial
+}
+
+func (d *DownTrack) IsForwarderMuted() bool {
+	return d.forwarder.Muted()
+}
+
 func (d *DownTrack) GetForwardingStatus() ForwardingStatus {
 	return d.forwarder.GetForwardingStatus()
 }
@@ -546,10 +800,22 @@ func (d *DownTrack) OnSubscribedLayersChanged(fn func(dt *DownTrack, layers Vide
 	d.onSubscribedLayersChanged = fn
 }
 
+// OnLayerSwitched is called when the forwarded spatial layer changes, including when forwarding
+// starts, with the layer forwarded from then on
+func (d *DownTrack) OnLayerSwitched(fn func(dt *DownTrack, layer int32)) {
+	d.onLayerSwitched = fn
+}
+
 func (d *DownTrack) OnPacketSent(fn func(dt *DownTrack, size int)) {
 	d.onPacketSent = append(d.onPacketSent, fn)
 }
 
+// OnFirstFrame is called once the subscriber is sent the first frame it can decode, the first key
+// frame for video and the first packet for audio
+func (d *DownTrack) OnFirstFrame(fn func(dt *DownTrack)) {
+	d.onFirstFrame = fn
+}
+
 func (d *DownTrack) Allocate(availableChannelCapacity int64) VideoAllocationResult {
 	return d.forwarder.Allocate(availableChannelCapacity, d.receiver.GetBitrateTemporalCumulative())
 }
@@ -605,20 +871,20 @@ func (d *DownTrack) CreateSenderReport() *rtcp.SenderReport {
 		return nil
 	}
 
-	srRTP, srNTP := d.receiver.GetSenderReportTime(currentSpatialLayer)
-	if srRTP == 0 {
+	// the publisher's timestamp now, offset like the packets forwarded from the layer. Each stream is
+	// mapped through the publisher's sender reports, keeping its streams in sync for subscribers
+	now := time.Now()
+	rtpTime, ok := d.receiver.GetRTPTimeAt(currentSpatialLayer, now)
+	if !ok {
 		return nil
 	}
+	rtpTime -= d.forwarder.GetRTPMungerParams().tsOffset
 
-	now := time.Now()
-	nowNTP := toNtpTime(now)
-
-	diff := (uint64(now.Sub(ntpTime(srNTP).Time())) * uint64(d.codec.ClockRate)) / uint64(time.Second)
 	octets, packets := d.getSRStats()
 	return &rtcp.SenderReport{
 		SSRC:        d.ssrc,
-		NTPTime:     uint64(nowNTP),
-		RTPTime:     srRTP + uint32(diff),
+		NTPTime:     uint64(toNtpTime(now)),
+		RTPTime:     rtpTime,
 		PacketCount: packets,
 		OctetCount:  octets,
 	}
@@ -660,7 +926,7 @@ func (d *DownTrack) writeBlankFrameRTP() error {
 			CSRC:           []uint32{},
 		}
 
-		err = d.writeRTPHeaderExtensions(&hdr)
+		err = d.writeRTPHeaderExtensions(&hdr, nil, nil)
 		if err != nil {
 			return err
 		}
@@ -723,7 +989,7 @@ func (d *DownTrack) writeH264BlankFrame(hdr *rtp.Header, frameEndNeeded bool) er
 func (d *DownTrack) handleRTCP(bytes []byte) {
 	pkts, err := rtcp.Unmarshal(bytes)
 	if err != nil {
-		Logger.Error(err, "Unmarshal rtcp receiver packets err")
+		d.logger.Error(err, "Unmarshal rtcp receiver packets err")
 		return
 	}
 
@@ -737,6 +1003,7 @@ func (d *DownTrack) handleRTCP(bytes []byte) {
 			targetSpatialLayer := d.forwarder.TargetSpatialLayer()
 			if targetSpatialLayer != InvalidSpatialLayer {
 				d.lastPli.set(time.Now().UnixNano())
+				d.plis.add(1)
 				d.receiver.SendPLI(targetSpatialLayer)
 				pliOnce = false
 			}
@@ -768,6 +1035,9 @@ func (d *DownTrack) handleRTCP(bytes []byte) {
 				if maxRatePacketLoss == 0 || maxRatePacketLoss < r.FractionLost {
 					maxRatePacketLoss = r.FractionLost
 				}
+				d.jitter.set(r.Jitter)
+				d.packetsLost.set(r.TotalLost)
+				d.updateRTT(r)
 			}
 			d.lossFraction.set(maxRatePacketLoss)
 			if len(rr.Reports) > 0 {
@@ -787,6 +1057,27 @@ func (d *DownTrack) handleRTCP(bytes []byte) {
 	}
 }
 
+// RTT returns the last round trip time measured from subscriber receiver reports, in ms
+func (d *DownTrack) RTT() uint32 {
+	return d.rtt.get()
+}
+
+// updateRTT computes round trip time from the last sender report echoed back in a reception report
+func (d *DownTrack) updateRTT(r rtcp.ReceptionReport) {
+	if r.LastSenderReport == 0 {
+		return
+	}
+
+	// middle 32 bits of the NTP timestamp, in units of 1/65536 seconds
+	now := uint32(uint64(toNtpTime(time.Now())) >> 16)
+	rtt := now - r.LastSenderReport - r.Delay
+	if rtt > 1<<31 {
+		// negative, clocks are off
+		return
+	}
+	d.rtt.set(uint32(uint64(rtt) * 1000 / 65536))
+}
+
 func (d *DownTrack) maybeTranslateVP8(pkt *rtp.Packet, meta packetMeta) error {
 	if d.mime != "video/vp8" || len(pkt.Payload) == 0 {
 		return nil
@@ -830,18 +1121,21 @@ func (d *DownTrack) retransmitPackets(nackedPackets []packetMeta) {
 
 		err = d.maybeTranslateVP8(&pkt, meta)
 		if err != nil {
-			Logger.Error(err, "translating VP8 packet err")
+			d.logger.Error(err, "translating VP8 packet err")
 			continue
 		}
 
-		err = d.writeRTPHeaderExtensions(&pkt.Header)
+		// the retransmitted packet's own extensions, as written ones would reuse their slice
+		upstream := pkt.Header
+		pkt.Header.Extensions = nil
+		err = d.writeRTPHeaderExtensions(&pkt.Header, &upstream, nil)
 		if err != nil {
-			Logger.Error(err, "writing rtp header extensions err")
+			d.logger.Error(err, "writing rtp header extensions err")
 			continue
 		}
 
 		if _, err = d.writeStream.WriteRTP(&pkt.Header, pkt.Payload); err != nil {
-			Logger.Error(err, "Writing rtx packet err")
+			d.logger.Error(err, "Writing rtx packet err")
 		} else {
 			d.UpdateStats(uint32(n))
 		}
@@ -853,25 +1147,38 @@ func (d *DownTrack) getSRStats() (octets, packets uint32) {
 }
 
 // writes RTP header extensions of track
-func (d *DownTrack) writeRTPHeaderExtensions(hdr *rtp.Header) error {
+// writeRTPHeaderExtensions replaces the extensions of hdr, reusing its extensions slice. The
+// abs-send-time is written to absSendTime when it's set, instead of a new slice. The values of
+// forwarded extensions are taken from upstream, the header of the publisher's packet, when it's set
+func (d *DownTrack) writeRTPHeaderExtensions(hdr *rtp.Header, upstream *rtp.Header, absSendTime []byte) error {
 	// clear out extensions that may have been in the forwarded header
 	hdr.Extension = false
 	hdr.ExtensionProfile = 0
-	hdr.Extensions = []rtp.Extension{}
+	hdr.Extensions = hdr.Extensions[:0]
+
+	if upstream != nil {
+		for _, forwarded := range d.forwardedHeaderExts {
+			if payload := upstream.GetExtension(forwarded.upstreamID); payload != nil {
+				if err := hdr.SetExtension(forwarded.id, payload); err != nil {
+					return err
+				}
+			}
+		}
+	}
 
 	for _, ext := range d.rtpHeaderExtensions {
 		if ext.URI != sdp.ABSSendTimeURI {
-			// supporting only abs-send-time
+			// the others are forwarded, or not written
 			continue
 		}
 
-		sendTime := rtp.NewAbsSendTimeExtension(time.Now())
-		b, err := sendTime.Marshal()
-		if err != nil {
-			return err
+		b := absSendTime
+		if b == nil {
+			b = make([]byte, 3)
 		}
+		putAbsSendTime(b, time.Now())
 
-		err = hdr.SetExtension(uint8(ext.ID), b)
+		err := hdr.SetExtension(uint8(ext.ID), b)
 		if err != nil {
 			return err
 		}
@@ -881,18 +1188,22 @@ func (d *DownTrack) writeRTPHeaderExtensions(hdr *rtp.Header) error {
 }
 
 func (d *DownTrack) getTranslatedRTPHeader(extPkt *buffer.ExtPacket, tpRTP *TranslationParamsRTP) (*rtp.Header, error) {
-	hdr := extPkt.Packet.Header
+	hdr := &d.rtpHeader
+	*hdr = extPkt.Packet.Header
 	hdr.PayloadType = d.payloadType
 	hdr.Timestamp = tpRTP.timestamp
 	hdr.SequenceNumber = tpRTP.sequenceNumber
 	hdr.SSRC = d.ssrc
 
-	err := d.writeRTPHeaderExtensions(&hdr)
+	// the extensions of the forwarded header belong to the packet, shared by all down tracks
+	hdr.Extensions = d.rtpExtensions
+	err := d.writeRTPHeaderExtensions(hdr, &extPkt.Packet.Header, d.absSendTime[:])
+	d.rtpExtensions = hdr.Extensions
 	if err != nil {
 		return nil, err
 	}
 
-	return &hdr, nil
+	return hdr, nil
 }
 
 func (d *DownTrack) translateVP8Packet(pkt *rtp.Packet, incomingVP8 *buffer.VP8, translatedVP8 *buffer.VP8) (buf []byte, err error) {
@@ -909,6 +1220,82 @@ func (d *DownTrack) translateVP8Packet(pkt *rtp.Packet, incomingVP8 *buffer.VP8,
 	return
 }
 
+// GetStats returns current send statistics. Bitrate is averaged since the previous sample,
+// which is taken at most once every statsBitrateInterval
+func (d *DownTrack) GetStats() DownTrackStats {
+	octets, packets := d.getSRStats()
+
+	d.statsLock.Lock()
+	now := time.Now()
+	if elapsed := now.Sub(d.lastBitrateAt); elapsed >= statsBitrateInterval {
+		if !d.lastBitrateAt.IsZero() {
+			d.bitrate = int64(octets-d.lastBitrateOctets) * 8 * int64(time.Second) / int64(elapsed)
+			d.bitrateMeasured = true
+		}
+		d.lastBitrateOctets = octets
+		d.lastBitrateAt = now
+	}
+	bitrate, bitrateMeasured := d.bitrate, d.bitrateMeasured
+	d.statsLock.Unlock()
+
+	current := d.forwarder.CurrentLayers()
+	maxLayers := d.forwarder.MaxLayers()
+	stats := DownTrackStats{
+		PacketsSent:          packets,
+		BytesSent:            octets,
+		Bitrate:              bitrate,
+		PacketsLost:          d.packetsLost.get(),
+		LossPercentage:       float32(d.lossFraction.get()) * 100 / 256,
+		RTTMs:                d.rtt.get(),
+		CurrentSpatialLayer:  current.spatial,
+		CurrentTemporalLayer: current.temporal,
+		TargetSpatialLayer:   d.forwarder.TargetSpatialLayer(),
+		MaxSpatialLayer:      maxLayers.spatial,
+		MaxTemporalLayer:     maxLayers.temporal,
+		LayerSwitches:        d.forwarder.LayerSwitches(),
+		ForwardingStatus:     d.forwarder.GetForwardingStatus().String(),
+		Muted:                d.forwarder.Muted(),
+	}
+	if d.codec.ClockRate != 0 {
+		stats.JitterMs = float64(d.jitter.get()) * 1000 / float64(d.codec.ClockRate)
+	}
+	// without a measured bitrate there's nothing to compare against
+	if d.kind == webrtc.RTPCodecTypeVideo && bitrateMeasured {
+		stats.ExpectedBitrate = expectedBitrate(d.receiver.GetBitrateTemporalCumulative(), maxLayers)
+	}
+	return stats
+}
+
+// GetDebugStats returns GetStats along with the PLIs sent and the packets waiting to be sent
+func (d *DownTrack) GetDebugStats() DownTrackDebugStats {
+	stats := DownTrackDebugStats{
+		DownTrackStats: d.GetStats(),
+		TrackID:        d.id,
+		Kind:           d.kind.String(),
+		PLIs:           d.plis.get(),
+		QueueDepth:     d.receiver.GetQueueDepth(d.peerID),
+	}
+	if d.pacer != nil {
+		stats.PacerBacklog = d.pacer.Backlog()
+	}
+	return stats
+}
+
+// expectedBitrate returns the bitrate of the highest available layer within maxLayers
+func expectedBitrate(brs [3][4]int64, maxLayers VideoLayers) int64 {
+	for s := int(maxLayers.spatial); s >= 0; s-- {
+		if s >= len(brs) {
+			continue
+		}
+		for t := int(maxLayers.temporal); t >= 0; t-- {
+			if t < len(brs[s]) && brs[s][t] != 0 {
+				return brs[s][t]
+			}
+		}
+	}
+	return 0
+}
+
 func (d *DownTrack) DebugInfo() map[string]interface{} {
 	rtpMungerParams := d.forwarder.GetRTPMungerParams()
 	stats := map[string]interface{}{
@@ -920,6 +1307,7 @@ func (d *DownTrack) DebugInfo() map[string]interface{} {
 		"LastMarker":        rtpMungerParams.lastMarker,
 		"LastRTP":           d.lastRTP.get(),
 		"LastPli":           d.lastPli.get(),
+		"PLIs":              d.plis.get(),
 		"PacketsDropped":    d.pktsDropped.get(),
 	}
 
@@ -938,7 +1326,7 @@ func (d *DownTrack) DebugInfo() map[string]interface{} {
 		"MimeType":            d.codec.MimeType,
 		"Bound":               d.bound.get(),
 		"Muted":               d.forwarder.Muted(),
-		"CurrentSpatialLayer": d.forwarder.CurrentSpatialLayer,
+		"CurrentSpatialLayer": d.forwarder.CurrentSpatialLayer(),
 		"Stats":               stats,
 	}
 }
@@ -0,0 +1,104 @@
+package sfu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/sfu/testutils"
+)
+
+func newTestHeaderDownTrack() *DownTrack {
+	d := &DownTrack{ssrc: 5678, payloadType: 111}
+	d.SetRTPHeaderExtensions([]webrtc.RTPHeaderExtensionParameter{{URI: sdp.ABSSendTimeURI, ID: 3}})
+	return d
+}
+
+func TestDownTrackTranslatedRTPHeader(t *testing.T) {
+	d := newTestHeaderDownTrack()
+	extPkt, err := testutils.GetTestExtPacket(&testutils.TestExtPacketParams{
+		PayloadType:    96,
+		SequenceNumber: 10,
+		Timestamp:      1000,
+		SSRC:           1234,
+		PayloadSize:    20,
+	})
+	require.NoError(t, err)
+	require.NoError(t, extPkt.Packet.SetExtension(1, []byte{1, 2}))
+
+	for sn := uint16(20); sn < 22; sn++ {
+		hdr, err := d.getTranslatedRTPHeader(extPkt, &TranslationParamsRTP{sequenceNumber: sn, timestamp: 2000})
+		require.NoError(t, err)
+		require.Equal(t, uint32(5678), hdr.SSRC)
+		require.Equal(t, uint8(111), hdr.PayloadType)
+		require.Equal(t, sn, hdr.SequenceNumber)
+		require.Equal(t, uint32(2000), hdr.Timestamp)
+		require.Len(t, hdr.GetExtension(3), 3)
+		require.Nil(t, hdr.GetExtension(1))
+	}
+
+	// the forwarded packet is shared by all down tracks, and left as is
+	require.Equal(t, uint32(1234), extPkt.Packet.SSRC)
+	require.Equal(t, []byte{1, 2}, extPkt.Packet.GetExtension(1))
+	require.Nil(t, extPkt.Packet.GetExtension(3))
+}
+
+func BenchmarkDownTrackTranslatedRTPHeader(b *testing.B) {
+	d := newTestHeaderDownTrack()
+	extPkt, err := testutils.GetTestExtPacket(&testutils.TestExtPacketParams{SequenceNumber: 10, SSRC: 1234, PayloadSize: 1000})
+	require.NoError(b, err)
+	tp := &TranslationParamsRTP{}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tp.sequenceNumber++
+		if _, err := d.getTranslatedRTPHeader(extPkt, tp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestDownTrackForwardedHeaderExtensions(t *testing.T) {
+	d := newTestHeaderDownTrack()
+	d.SetUpstreamRTPHeaderExtensions([]webrtc.RTPHeaderExtensionParameter{
+		{URI: VideoOrientationURI, ID: 7},
+		{URI: PlayoutDelayURI, ID: 8},
+		{URI: sdp.AudioLevelURI, ID: 9},
+	})
+	// playout delay isn't negotiated with the subscriber
+	d.SetRTPHeaderExtensions([]webrtc.RTPHeaderExtensionParameter{
+		{URI: sdp.ABSSendTimeURI, ID: 3},
+		{URI: VideoOrientationURI, ID: 4},
+		{URI: sdp.AudioLevelURI, ID: 5},
+	})
+
+	extPkt, err := testutils.GetTestExtPacket(&testutils.TestExtPacketParams{SequenceNumber: 10, SSRC: 1234, PayloadSize: 20})
+	require.NoError(t, err)
+	require.NoError(t, extPkt.Packet.SetExtension(7, []byte{0x1}))
+	require.NoError(t, extPkt.Packet.SetExtension(8, []byte{0x0, 0x1, 0x2}))
+	require.NoError(t, extPkt.Packet.SetExtension(9, []byte{0x7f}))
+
+	hdr, err := d.getTranslatedRTPHeader(extPkt, &TranslationParamsRTP{sequenceNumber: 20})
+	require.NoError(t, err)
+	require.Len(t, hdr.GetExtension(3), 3)
+	require.Equal(t, []byte{0x1}, hdr.GetExtension(4))
+	require.Nil(t, hdr.GetExtension(5))
+	require.Nil(t, hdr.GetExtension(7))
+	require.Nil(t, hdr.GetExtension(8))
+	require.Equal(t, []byte{0x1}, extPkt.Packet.GetExtension(7))
+}
+
+func TestDownTrackReadyTimeout(t *testing.T) {
+	d := &DownTrack{}
+	d.WaitForReady(50 * time.Millisecond)
+	require.False(t, d.IsReady())
+
+	// without settings from the subscriber, forwarding starts after the timeout
+	d.startReadyTimeout()
+	require.False(t, d.IsReady())
+	require.Eventually(t, d.IsReady, time.Second, 10*time.Millisecond)
+}
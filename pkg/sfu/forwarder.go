@@ -57,6 +57,9 @@ type Forwarder struct {
 	currentTemporalLayer int32
 	targetTemporalLayer  int32
 
+	// number of times forwarding moved from one spatial layer to another
+	layerSwitches uint32
+
 	lastAllocationState      VideoAllocationState
 	lastAllocationRequestBps int64
 
@@ -139,6 +142,24 @@ func (f *Forwarder) CurrentSpatialLayer() int32 {
 	return f.currentSpatialLayer
 }
 
+func (f *Forwarder) CurrentLayers() VideoLayers {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return VideoLayers{
+		spatial:  f.currentSpatialLayer,
+		temporal: f.currentTemporalLayer,
+	}
+}
+
+// LayerSwitches returns the number of times the forwarded spatial layer has changed
+func (f *Forwarder) LayerSwitches() uint32 {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.layerSwitches
+}
+
 func (f *Forwarder) TargetSpatialLayer() int32 {
 	f.lock.RLock()
 	defer f.lock.RUnlock()
@@ -156,6 +177,12 @@ func (f *Forwarder) SetMaxTemporalLayer(temporalLayer int32) (bool, VideoLayers)
 
 	f.maxTemporalLayer = temporalLayer
 
+	// dropping temporal layers doesn't need a key frame, so lower layers are forwarded from the next
+	// packet that belongs to them rather than waiting for the next allocation
+	if temporalLayer != InvalidTemporalLayer && f.targetTemporalLayer > temporalLayer {
+		f.targetTemporalLayer = temporalLayer
+	}
+
 	return true, VideoLayers{
 		spatial:  f.maxSpatialLayer,
 		temporal: f.maxTemporalLayer,
@@ -544,7 +571,11 @@ func (f *Forwarder) getTranslationParamsVideo(extPkt *buffer.ExtPacket, layer in
 		if f.targetSpatialLayer == layer {
 			if extPkt.KeyFrame {
 				// lock to target layer
+				if f.currentSpatialLayer != InvalidSpatialLayer {
+					f.layerSwitches++
+				}
 				f.currentSpatialLayer = f.targetSpatialLayer
+				tp.switchedLayer = true
 			} else {
 				tp.shouldSendPLI = true
 			}
@@ -0,0 +1,40 @@
+package sfu
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForwarderMaxTemporalLayer(t *testing.T) {
+	f := NewForwarder(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}, webrtc.RTPCodecTypeVideo)
+	f.UptrackLayersChange([]uint16{0, 1, 2})
+	brs := [3][4]int64{
+		{100_000, 150_000, 200_000},
+		{300_000, 450_000, 600_000},
+		{1_000_000, 1_500_000, 2_000_000},
+	}
+
+	f.Allocate(ChannelCapacityInfinity, brs)
+	require.Equal(t, int32(2), f.TargetSpatialLayer())
+	require.Equal(t, int32(2), f.targetTemporalLayer)
+
+	// higher temporal layers are dropped right away, on the same spatial layer
+	changed, maxLayers := f.SetMaxTemporalLayer(0)
+	require.True(t, changed)
+	require.Equal(t, VideoLayers{spatial: 2, temporal: 0}, maxLayers)
+	require.Equal(t, int32(2), f.TargetSpatialLayer())
+	require.Equal(t, int32(0), f.targetTemporalLayer)
+
+	result := f.Allocate(ChannelCapacityInfinity, brs)
+	require.Equal(t, VideoAllocationStateOptimal, result.state)
+	require.Equal(t, int64(1_000_000), result.bandwidthRequested)
+	require.Equal(t, int32(0), f.targetTemporalLayer)
+
+	// raising the cap waits for the next allocation
+	f.SetMaxTemporalLayer(2)
+	require.Equal(t, int32(0), f.targetTemporalLayer)
+	f.Allocate(ChannelCapacityInfinity, brs)
+	require.Equal(t, int32(2), f.targetTemporalLayer)
+}
@@ -0,0 +1,221 @@
+package sfu
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+// packets written to a down track at a time, before the worker moves on to the next down track
+const forwardingBatchSize = 16
+
+// ForwardingPool writes the packets of receivers to their down tracks with a fixed number of
+// workers, instead of in the read loop of the receiver. Each down track has a bounded queue, packets
+// are dropped and counted when it's full, so that a subscriber whose PeerConnection can't keep up
+// doesn't hold back the publisher's track or the other subscribers. Down tracks are sharded across
+// the workers by subscriber, the packets of a down track are written in order by one worker
+type ForwardingPool struct {
+	queueSize int
+	shards    []*forwardingShard
+
+	onDropped func(track TrackSender)
+}
+
+// forwardingShard is a worker, writing the queued packets of the down tracks assigned to it
+type forwardingShard struct {
+	mu sync.Mutex
+	// queues with packets to write, each queue appears at most once
+	ready []*ForwardingQueue
+	wake  chan struct{}
+	done  chan struct{}
+}
+
+type queuedPacket struct {
+	pkt   *buffer.ExtPacket
+	layer int32
+}
+
+// ForwardingQueue holds the packets waiting to be written to a down track
+type ForwardingQueue struct {
+	track   TrackSender
+	shard   *forwardingShard
+	pool    *ForwardingPool
+	packets chan queuedPacket
+	// set while the queue is in the ready list of its shard
+	scheduled atomicBool
+	closed    atomicBool
+	dropped   atomicUint32
+}
+
+// NewForwardingPool creates a pool with workers, each down track queuing up to queueSize packets
+func NewForwardingPool(workers int, queueSize int) *ForwardingPool {
+	p := &ForwardingPool{
+		queueSize: queueSize,
+		shards:    make([]*forwardingShard, workers),
+	}
+	for i := range p.shards {
+		s := &forwardingShard{
+			wake: make(chan struct{}, 1),
+			done: make(chan struct{}),
+		}
+		p.shards[i] = s
+		go s.writeWorker()
+	}
+	return p
+}
+
+// OnPacketDropped sets the function called when a packet is dropped because the queue of a down
+// track is full. It must be set before the pool is used
+func (p *ForwardingPool) OnPacketDropped(fn func(track TrackSender)) {
+	p.onDropped = fn
+}
+
+// NewQueue creates the queue of a down track. It must be closed when the down track is removed
+func (p *ForwardingPool) NewQueue(track TrackSender) *ForwardingQueue {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(track.PeerID()))
+	return &ForwardingQueue{
+		track:   track,
+		shard:   p.shards[h.Sum32()%uint32(len(p.shards))],
+		pool:    p,
+		packets: make(chan queuedPacket, p.queueSize),
+	}
+}
+
+// Stop stops the workers. Packets that are still queued aren't written
+func (p *ForwardingPool) Stop() {
+	for _, s := range p.shards {
+		close(s.done)
+	}
+}
+
+// Forward queues pkt for each of the down tracks with queues, without waiting for them to be
+// written. The caller keeps its reference to pkt
+func (p *ForwardingPool) Forward(pkt *buffer.ExtPacket, layer int32, queues []*ForwardingQueue) {
+	// the packet outlives its slot in the bucket while it's queued
+	if err := pkt.Detach(); err != nil {
+		log.Error().Err(err).Msg("could not detach packet")
+		return
+	}
+	for _, q := range queues {
+		if q != nil {
+			q.enqueue(pkt, layer)
+		}
+	}
+}
+
+// Len returns the number of packets waiting to be written
+func (q *ForwardingQueue) Len() int {
+	return len(q.packets)
+}
+
+func (q *ForwardingQueue) enqueue(pkt *buffer.ExtPacket, layer int32) {
+	if q.closed.get() {
+		return
+	}
+
+	pkt.Retain()
+	select {
+	case q.packets <- queuedPacket{pkt: pkt, layer: layer}:
+	default:
+		buffer.ReleaseExtPacket(pkt)
+		q.dropped.add(1)
+		if q.pool.onDropped != nil {
+			q.pool.onDropped(q.track)
+		}
+		return
+	}
+
+	if q.scheduled.set(true) {
+		q.shard.schedule(q)
+	}
+}
+
+// Dropped returns the number of packets dropped because the queue was full
+func (q *ForwardingQueue) Dropped() uint32 {
+	return q.dropped.get()
+}
+
+// Close drops the queued packets, the down track isn't written to anymore
+func (q *ForwardingQueue) Close() {
+	if !q.closed.set(true) {
+		return
+	}
+	q.drain()
+}
+
+func (q *ForwardingQueue) drain() {
+	for {
+		select {
+		case p := <-q.packets:
+			buffer.ReleaseExtPacket(p.pkt)
+		default:
+			return
+		}
+	}
+}
+
+// write writes up to a batch of queued packets, returning whether packets are left
+func (q *ForwardingQueue) write() bool {
+	for i := 0; i < forwardingBatchSize; i++ {
+		if q.closed.get() {
+			q.drain()
+			return false
+		}
+
+		var p queuedPacket
+		select {
+		case p = <-q.packets:
+		default:
+			return false
+		}
+		if err := q.track.WriteRTP(p.pkt, p.layer); err != nil {
+			log.Error().Err(err).Str("id", q.track.ID()).Msg("Error writing to down track")
+		}
+		buffer.ReleaseExtPacket(p.pkt)
+	}
+	return true
+}
+
+func (s *forwardingShard) schedule(q *ForwardingQueue) {
+	s.mu.Lock()
+	s.ready = append(s.ready, q)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *forwardingShard) writeWorker() {
+	for {
+		s.mu.Lock()
+		var q *ForwardingQueue
+		if len(s.ready) != 0 {
+			q = s.ready[0]
+			s.ready[0] = nil
+			s.ready = s.ready[1:]
+		}
+		s.mu.Unlock()
+
+		if q == nil {
+			select {
+			case <-s.done:
+				return
+			case <-s.wake:
+			}
+			continue
+		}
+
+		more := q.write()
+		q.scheduled.set(false)
+		// packets queued while it was scheduled are written on its next turn
+		if (more || len(q.packets) != 0) && q.scheduled.set(true) {
+			s.schedule(q)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package sfu
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/sfu/testutils"
+)
+
+// queueTestTrack records the sequence numbers written to it, blocking writes until unblocked
+type queueTestTrack struct {
+	peerID  string
+	mu      sync.Mutex
+	written []uint16
+	writing int
+	block   chan struct{}
+}
+
+func (t *queueTestTrack) UptrackLayersChange(_ []uint16) {}
+
+func (t *queueTestTrack) WriteRTP(p *buffer.ExtPacket, _ int32) error {
+	t.mu.Lock()
+	t.writing++
+	t.mu.Unlock()
+	if t.block != nil {
+		<-t.block
+	}
+	t.mu.Lock()
+	t.written = append(t.written, p.Packet.SequenceNumber)
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *queueTestTrack) Close() {}
+
+func (t *queueTestTrack) ID() string { return "TR_" + t.peerID }
+
+func (t *queueTestTrack) SetTrackType(_ bool) {}
+
+func (t *queueTestTrack) Codec() webrtc.RTPCodecCapability { return webrtc.RTPCodecCapability{} }
+
+func (t *queueTestTrack) PeerID() string { return t.peerID }
+
+func (t *queueTestTrack) writtenPackets() []uint16 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]uint16(nil), t.written...)
+}
+
+func TestForwardingPool(t *testing.T) {
+	pool := NewForwardingPool(2, 16)
+	defer pool.Stop()
+	var dropped []string
+	var droppedMu sync.Mutex
+	pool.OnPacketDropped(func(track TrackSender) {
+		droppedMu.Lock()
+		dropped = append(dropped, track.PeerID())
+		droppedMu.Unlock()
+	})
+
+	fast := &queueTestTrack{peerID: "fast"}
+	slow := &queueTestTrack{peerID: "slow", block: make(chan struct{})}
+	fastQueue := pool.NewQueue(fast)
+	slowQueue := pool.NewQueue(slow)
+	queues := []*ForwardingQueue{fastQueue, nil, slowQueue}
+
+	var sent []uint16
+	for sn := uint16(1); sn <= 40; sn++ {
+		pkt, err := testutils.GetTestExtPacket(&testutils.TestExtPacketParams{SequenceNumber: sn, PayloadSize: 10})
+		require.NoError(t, err)
+		pool.Forward(pkt, 0, queues)
+		sent = append(sent, sn)
+
+		// the fast subscriber keeps up while the slow one is stuck
+		require.Eventually(t, func() bool {
+			slow.mu.Lock()
+			defer slow.mu.Unlock()
+			return len(fast.writtenPackets()) == len(sent) && slow.writing == 1
+		}, time.Second, time.Millisecond)
+	}
+	require.Equal(t, sent, fast.writtenPackets())
+	require.Zero(t, fastQueue.Dropped())
+
+	// the slow subscriber holds the packet it's writing and a full queue, the rest is dropped
+	require.Equal(t, uint32(40-1-16), slowQueue.Dropped())
+	droppedMu.Lock()
+	require.Len(t, dropped, 40-1-16)
+	require.Equal(t, "slow", dropped[0])
+	droppedMu.Unlock()
+
+	close(slow.block)
+	require.Eventually(t, func() bool {
+		return len(slow.writtenPackets()) == 1+16
+	}, time.Second, time.Millisecond)
+	require.Equal(t, sent[:17], slow.writtenPackets())
+
+	// closed queues don't write anymore
+	fastQueue.Close()
+	pkt, err := testutils.GetTestExtPacket(&testutils.TestExtPacketParams{SequenceNumber: 41, PayloadSize: 10})
+	require.NoError(t, err)
+	pool.Forward(pkt, 0, queues)
+	require.Eventually(t, func() bool {
+		return len(slow.writtenPackets()) == 1+16+1
+	}, time.Second, time.Millisecond)
+	require.Len(t, fast.writtenPackets(), 40)
+}
@@ -82,3 +82,12 @@ func toNtpTime(t time.Time) ntpTime {
 	}
 	return ntpTime(sec<<32 | frac)
 }
+
+// putAbsSendTime writes the abs-send-time of t to b, the 24 bits of NTP time in 6.18 fixed point
+// seconds
+func putAbsSendTime(b []byte, t time.Time) {
+	ts := uint64(toNtpTime(t)) >> 14
+	b[0] = byte(ts >> 16)
+	b[1] = byte(ts >> 8)
+	b[2] = byte(ts)
+}
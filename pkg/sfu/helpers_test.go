@@ -3,6 +3,9 @@ package sfu
 import (
 	"testing"
 	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_timeToNtp(t *testing.T) {
@@ -32,3 +35,13 @@ func Test_timeToNtp(t *testing.T) {
 		})
 	}
 }
+
+func Test_putAbsSendTime(t *testing.T) {
+	now := time.Unix(1602391458, 0)
+	want, err := rtp.NewAbsSendTimeExtension(now).Marshal()
+	require.NoError(t, err)
+
+	b := make([]byte, 3)
+	putAbsSendTime(b, now)
+	require.Equal(t, want, b)
+}
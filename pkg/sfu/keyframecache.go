@@ -0,0 +1,94 @@
+package sfu
+
+import (
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+// keyFrameCache keeps the packets of a layer from its latest key frame on, so that a down track
+// starting on the layer can be sent the key frame right away, instead of waiting for the publisher
+// to answer a PLI. The packets after the key frame are kept as well, the frames that follow it
+// can't be decoded without them. When the publisher sends more than maxPackets packets until its
+// next key frame, the cache is emptied and down tracks fall back to PLI
+type keyFrameCache struct {
+	maxPackets int
+
+	mu      sync.Mutex
+	packets []*buffer.ExtPacket
+}
+
+func newKeyFrameCache(maxPackets int) *keyFrameCache {
+	return &keyFrameCache{
+		maxPackets: maxPackets,
+	}
+}
+
+// add caches pkt when it's part of the latest key frame or follows it. A reference is kept on
+// pkt, the caller keeps its own
+func (c *keyFrameCache) add(pkt *buffer.ExtPacket) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// the first packet of a key frame starts over, further packets of the same frame may be
+	// flagged as well
+	if pkt.KeyFrame && (len(c.packets) == 0 || c.packets[0].Packet.Timestamp != pkt.Packet.Timestamp) {
+		c.reset()
+	} else if len(c.packets) == 0 {
+		// waiting for a key frame
+		return
+	}
+	if len(c.packets) >= c.maxPackets {
+		c.reset()
+		return
+	}
+
+	// the packet outlives its slot in the bucket while it's cached
+	if err := pkt.Detach(); err != nil {
+		log.Error().Err(err).Msg("could not detach packet")
+		return
+	}
+	pkt.Retain()
+	c.packets = append(c.packets, pkt)
+}
+
+// packetsBefore returns the cached packets that precede the packet with sequence number sn,
+// starting with the key frame, or nil when the key frame doesn't precede it. A reference is added
+// to each packet returned, the caller releases them once they're written
+func (c *keyFrameCache) packetsBefore(sn uint16) []*buffer.ExtPacket {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var packets []*buffer.ExtPacket
+	for _, pkt := range c.packets {
+		if diff := sn - pkt.Packet.SequenceNumber; diff == 0 || diff >= 0x8000 {
+			if len(packets) == 0 {
+				return nil
+			}
+			// out of order, the packets received after it are skipped as well
+			break
+		}
+		packets = append(packets, pkt)
+	}
+	for _, pkt := range packets {
+		pkt.Retain()
+	}
+	return packets
+}
+
+// close releases the cached packets
+func (c *keyFrameCache) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reset()
+}
+
+// should be called with lock held
+func (c *keyFrameCache) reset() {
+	for _, pkt := range c.packets {
+		buffer.ReleaseExtPacket(pkt)
+	}
+	c.packets = nil
+}
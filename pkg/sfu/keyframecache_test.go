@@ -0,0 +1,80 @@
+package sfu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/sfu/testutils"
+)
+
+func TestKeyFrameCache(t *testing.T) {
+	add := func(c *keyFrameCache, sn uint16, ts uint32, keyFrame bool) {
+		pkt, err := testutils.GetTestExtPacket(&testutils.TestExtPacketParams{
+			SequenceNumber: sn,
+			Timestamp:      ts,
+			IsKeyFrame:     keyFrame,
+			PayloadSize:    10,
+		})
+		require.NoError(t, err)
+		c.add(pkt)
+	}
+	sequenceNumbers := func(packets []*buffer.ExtPacket) []uint16 {
+		var sns []uint16
+		for _, pkt := range packets {
+			sns = append(sns, pkt.Packet.SequenceNumber)
+			buffer.ReleaseExtPacket(pkt)
+		}
+		return sns
+	}
+
+	t.Run("starts on the latest key frame", func(t *testing.T) {
+		c := newKeyFrameCache(100)
+		defer c.close()
+
+		// nothing is cached before the first key frame
+		add(c, 1, 1000, false)
+		require.Nil(t, c.packetsBefore(2))
+
+		add(c, 2, 2000, true)
+		add(c, 3, 2000, true)
+		add(c, 4, 3000, false)
+		add(c, 5, 4000, false)
+		require.Equal(t, []uint16{2, 3, 4, 5}, sequenceNumbers(c.packetsBefore(6)))
+		// only the packets before the one the down track starts on
+		require.Equal(t, []uint16{2, 3}, sequenceNumbers(c.packetsBefore(4)))
+		require.Nil(t, c.packetsBefore(2))
+		require.Nil(t, c.packetsBefore(1))
+
+		// a new key frame replaces the cached one
+		add(c, 6, 5000, true)
+		add(c, 7, 6000, false)
+		require.Equal(t, []uint16{6, 7}, sequenceNumbers(c.packetsBefore(8)))
+	})
+
+	t.Run("sequence numbers wrap around", func(t *testing.T) {
+		c := newKeyFrameCache(100)
+		defer c.close()
+
+		add(c, 65534, 1000, true)
+		add(c, 65535, 2000, false)
+		add(c, 0, 3000, false)
+		require.Equal(t, []uint16{65534, 65535, 0}, sequenceNumbers(c.packetsBefore(1)))
+	})
+
+	t.Run("long key frame intervals aren't cached", func(t *testing.T) {
+		c := newKeyFrameCache(3)
+		defer c.close()
+
+		add(c, 1, 1000, true)
+		add(c, 2, 2000, false)
+		add(c, 3, 3000, false)
+		add(c, 4, 4000, false)
+		add(c, 5, 5000, false)
+		require.Nil(t, c.packetsBefore(6))
+
+		add(c, 6, 6000, true)
+		require.Equal(t, []uint16{6}, sequenceNumbers(c.packetsBefore(7)))
+	})
+}
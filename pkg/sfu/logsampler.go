@@ -0,0 +1,103 @@
+package sfu
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// DefaultLogSampleInterval is how often a sampled logger logs the same message
+const DefaultLogSampleInterval = 10 * time.Second
+
+// NewSampledLogger returns a logger that logs each message at most once per interval, with the
+// number of times it was dropped since it was last logged. Warnings logged for every packet, like
+// failed RTCP writes, would otherwise flood the log because of a single bad connection. Loggers
+// derived from it share the sampling
+func NewSampledLogger(l logr.Logger, interval time.Duration) logr.Logger {
+	sink := l.GetSink()
+	if sink == nil {
+		return l
+	}
+	// skip the frame of the sampling sink when reporting callers
+	if cd, ok := sink.(logr.CallDepthLogSink); ok {
+		sink = cd.WithCallDepth(1)
+	}
+	return l.WithSink(&samplingSink{
+		LogSink: sink,
+		sampler: &logSampler{interval: interval, messages: make(map[string]*sampledMessage)},
+	})
+}
+
+type sampledMessage struct {
+	loggedAt time.Time
+	dropped  int
+}
+
+type logSampler struct {
+	interval time.Duration
+
+	lock     sync.Mutex
+	messages map[string]*sampledMessage
+}
+
+// sample returns true when msg should be logged, with the number of times it was dropped since
+func (s *logSampler) sample(msg string) (dropped int, ok bool) {
+	now := time.Now()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	m := s.messages[msg]
+	if m == nil {
+		s.messages[msg] = &sampledMessage{loggedAt: now}
+		return 0, true
+	}
+	if now.Sub(m.loggedAt) < s.interval {
+		m.dropped++
+		return 0, false
+	}
+	dropped = m.dropped
+	m.loggedAt = now
+	m.dropped = 0
+	return dropped, true
+}
+
+type samplingSink struct {
+	logr.LogSink
+	sampler *logSampler
+}
+
+func (s *samplingSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	if dropped, ok := s.sampler.sample(msg); ok {
+		s.LogSink.Info(level, msg, withDropped(keysAndValues, dropped)...)
+	}
+}
+
+func (s *samplingSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	if dropped, ok := s.sampler.sample(msg); ok {
+		s.LogSink.Error(err, msg, withDropped(keysAndValues, dropped)...)
+	}
+}
+
+func (s *samplingSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &samplingSink{LogSink: s.LogSink.WithValues(keysAndValues...), sampler: s.sampler}
+}
+
+func (s *samplingSink) WithName(name string) logr.LogSink {
+	return &samplingSink{LogSink: s.LogSink.WithName(name), sampler: s.sampler}
+}
+
+func (s *samplingSink) WithCallDepth(depth int) logr.LogSink {
+	if cd, ok := s.LogSink.(logr.CallDepthLogSink); ok {
+		return &samplingSink{LogSink: cd.WithCallDepth(depth), sampler: s.sampler}
+	}
+	return s
+}
+
+func withDropped(keysAndValues []interface{}, dropped int) []interface{} {
+	if dropped == 0 {
+		return keysAndValues
+	}
+	// don't modify the caller's values
+	return append(append([]interface{}(nil), keysAndValues...), "dropped", dropped)
+}
@@ -0,0 +1,37 @@
+package sfu
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampledLogger(t *testing.T) {
+	var lines []string
+	l := NewSampledLogger(funcr.New(func(prefix, args string) {
+		lines = append(lines, args)
+	}, funcr.Options{}), time.Hour)
+
+	err := errors.New("closed")
+	for i := 0; i < 3; i++ {
+		l.Error(err, "could not write RTCP")
+	}
+	l.Info("other message")
+	// derived loggers share the sampling
+	l.WithValues("track", "TR_1").Error(err, "could not write RTCP")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], `"msg"="could not write RTCP"`)
+	require.Contains(t, lines[1], `"msg"="other message"`)
+
+	// logged again once the interval passed, with how often it was dropped
+	sink := l.GetSink().(*samplingSink)
+	sink.sampler.messages["could not write RTCP"].loggedAt = time.Now().Add(-time.Hour)
+	l.Error(err, "could not write RTCP")
+	require.Len(t, lines, 3)
+	require.Contains(t, lines[2], `"dropped"=3`)
+	l.Error(err, "could not write RTCP")
+	require.Len(t, lines, 3)
+}
@@ -0,0 +1,146 @@
+package sfu
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+)
+
+// packets that can wait to be sent at once, across the tracks of a subscriber
+const pacerQueueSize = 4096
+
+type pacedPacket struct {
+	writer  webrtc.TrackLocalWriter
+	header  *rtp.Header
+	payload []byte
+	raw     []byte
+	size    int
+}
+
+// Pacer spreads out the packets sent to a subscriber, so that key frames and layer switches don't
+// send thousands of packets at once and overrun the jitter buffer of the client. It's a leaky
+// bucket shared by the down tracks of a subscriber PeerConnection: packets are sent at up to rate,
+// bursts of up to burst bytes are sent right away. Packets keep their order, and are dropped when
+// the queue is full
+type Pacer struct {
+	// bytes per second
+	rate  float64
+	burst float64
+
+	queue     chan *pacedPacket
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// only accessed by the worker
+	tokens   float64
+	lastFill time.Time
+}
+
+// NewPacer creates a pacer that sends at up to rate, in bps, with bursts of up to burst bytes
+func NewPacer(rate uint64, burst uint32) *Pacer {
+	p := &Pacer{
+		rate:  float64(rate) / 8,
+		burst: float64(burst),
+		queue: make(chan *pacedPacket, pacerQueueSize),
+		done:  make(chan struct{}),
+	}
+	p.tokens = p.burst
+	go p.sendWorker()
+	return p
+}
+
+// Writer returns a writer that paces the packets written to w
+func (p *Pacer) Writer(w webrtc.TrackLocalWriter) webrtc.TrackLocalWriter {
+	return &pacedWriter{pacer: p, writer: w}
+}
+
+func (p *Pacer) Stop() {
+	p.closeOnce.Do(func() {
+		close(p.done)
+	})
+}
+
+// Backlog returns the number of packets waiting to be sent
+func (p *Pacer) Backlog() int {
+	return len(p.queue)
+}
+
+func (p *Pacer) enqueue(pkt *pacedPacket) (int, error) {
+	select {
+	case <-p.done:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+
+	select {
+	case p.queue <- pkt:
+	default:
+		// dropped like a congested link would, the subscriber will NACK it
+	}
+	return pkt.size, nil
+}
+
+func (p *Pacer) sendWorker() {
+	p.lastFill = time.Now()
+	for {
+		select {
+		case <-p.done:
+			return
+		case pkt := <-p.queue:
+			if wait := p.take(pkt.size, time.Now()); wait > 0 {
+				select {
+				case <-p.done:
+					return
+				case <-time.After(wait):
+				}
+			}
+			if pkt.raw != nil {
+				_, _ = pkt.writer.Write(pkt.raw)
+			} else {
+				_, _ = pkt.writer.WriteRTP(pkt.header, pkt.payload)
+			}
+		}
+	}
+}
+
+// take removes size bytes from the bucket, returning how long to wait before they can be sent
+func (p *Pacer) take(size int, now time.Time) time.Duration {
+	p.tokens += now.Sub(p.lastFill).Seconds() * p.rate
+	if p.tokens > p.burst {
+		p.tokens = p.burst
+	}
+	p.lastFill = now
+
+	p.tokens -= float64(size)
+	if p.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-p.tokens / p.rate * float64(time.Second))
+}
+
+// pacedWriter queues the packets of a down track with its pacer
+type pacedWriter struct {
+	pacer  *Pacer
+	writer webrtc.TrackLocalWriter
+}
+
+func (w *pacedWriter) WriteRTP(header *rtp.Header, payload []byte) (int, error) {
+	hdr := header.Clone()
+	return w.pacer.enqueue(&pacedPacket{
+		writer:  w.writer,
+		header:  &hdr,
+		payload: append([]byte(nil), payload...),
+		size:    header.MarshalSize() + len(payload),
+	})
+}
+
+func (w *pacedWriter) Write(b []byte) (int, error) {
+	return w.pacer.enqueue(&pacedPacket{
+		writer: w.writer,
+		raw:    append([]byte(nil), b...),
+		size:   len(b),
+	})
+}
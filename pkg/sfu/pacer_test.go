@@ -0,0 +1,70 @@
+package sfu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacer(t *testing.T) {
+	// 10 kB/s with bursts of 1 kB
+	p := NewPacer(80_000, 1000)
+	defer p.Stop()
+
+	audio, video := &recordingWriter{}, &recordingWriter{}
+	audioWriter, videoWriter := p.Writer(audio), p.Writer(video)
+
+	start := time.Now()
+	hdr := &rtp.Header{}
+	payload := make([]byte, 488)
+	for sn := uint16(1); sn <= 4; sn++ {
+		hdr.SequenceNumber = sn
+		_, err := videoWriter.WriteRTP(hdr, payload)
+		require.NoError(t, err)
+	}
+	_, err := audioWriter.WriteRTP(hdr, payload[:88])
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return audio.numWritten() == 1
+	}, time.Second, 5*time.Millisecond)
+
+	video.mu.Lock()
+	defer video.mu.Unlock()
+	require.Equal(t, []uint16{1, 2, 3, 4}, video.written)
+	// the first two packets fit the burst, the others are sent at the rate
+	require.GreaterOrEqual(t, video.at[3].Sub(start), 90*time.Millisecond)
+}
+
+func TestPacerTake(t *testing.T) {
+	p := &Pacer{rate: 10_000, burst: 1000, tokens: 1000}
+	now := time.Now()
+	p.lastFill = now
+
+	require.Zero(t, p.take(1000, now))
+	require.Equal(t, 50*time.Millisecond, p.take(500, now))
+	// refilled at the rate, up to the burst
+	require.Zero(t, p.take(500, now.Add(100*time.Millisecond)))
+	require.Zero(t, p.take(1000, now.Add(time.Hour)))
+	require.Equal(t, 10*time.Millisecond, p.take(100, now.Add(time.Hour)))
+}
+
+func TestPacerStop(t *testing.T) {
+	p := NewPacer(80_000, 1000)
+	p.Stop()
+	_, err := p.Writer(&recordingWriter{}).WriteRTP(&rtp.Header{}, nil)
+	require.Error(t, err)
+}
+
+func TestPacerBacklog(t *testing.T) {
+	// without a worker, nothing is sent
+	p := &Pacer{queue: make(chan *pacedPacket, 2), done: make(chan struct{})}
+	w := p.Writer(&recordingWriter{})
+	for i := 0; i < 3; i++ {
+		_, err := w.WriteRTP(&rtp.Header{}, nil)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, p.Backlog())
+}
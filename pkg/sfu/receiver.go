@@ -25,7 +25,9 @@ type TrackReceiver interface {
 	AddDownTrack(track TrackSender)
 	DeleteDownTrack(peerID string)
 	SendPLI(layer int32)
-	GetSenderReportTime(layer int32) (rtpTS uint32, ntpTS uint64)
+	GetCachedKeyFrame(layer int32, sn uint16) []*buffer.ExtPacket
+	GetQueueDepth(peerID string) int
+	GetRTPTimeAt(layer int32, at time.Time) (uint32, bool)
 	Codec() webrtc.RTPCodecCapability
 }
 
@@ -42,13 +44,33 @@ type Receiver interface {
 	ReadRTP(buf []byte, layer uint8, sn uint16) (int, error)
 	DeleteDownTrack(ID string)
 	OnCloseHandler(fn func())
+	Close()
 	SendPLI(layer int32)
-	SetRTCPCh(ch chan []rtcp.Packet)
+	GetCachedKeyFrame(layer int32, sn uint16) []*buffer.ExtPacket
+	GetQueueDepth(peerID string) int
+	SetRTCPQueue(q *RTCPQueue)
 
-	GetSenderReportTime(layer int32) (rtpTS uint32, ntpTS uint64)
+	GetRTPTimeAt(layer int32, at time.Time) (uint32, bool)
+	GetLayerStats() []LayerStats
 	DebugInfo() map[string]interface{}
 }
 
+// LayerStats holds receive statistics of a single published layer
+type LayerStats struct {
+	Layer          int32   `json:"layer"`
+	SSRC           uint32  `json:"ssrc"`
+	Bitrate        int64   `json:"bitrate"`
+	Packets        uint32  `json:"packets"`
+	Bytes          uint64  `json:"bytes"`
+	PacketsLost    uint32  `json:"packets_lost"`
+	LossPercentage float32 `json:"loss_percentage"`
+	JitterMs       float64 `json:"jitter_ms"`
+	// video only
+	FrameRate uint32 `json:"frame_rate,omitempty"`
+	Width     uint32 `json:"width,omitempty"`
+	Height    uint32 `json:"height,omitempty"`
+}
+
 // WebRTCReceiver receives a video track
 type WebRTCReceiver struct {
 	peerID          string
@@ -66,12 +88,15 @@ type WebRTCReceiver struct {
 	useTrackers     bool
 
 	rtcpMu      sync.Mutex
-	rtcpCh      chan []rtcp.Packet
+	rtcpQueue   *RTCPQueue
 	lastPli     atomicInt64
 	pliThrottle int64
 
 	bufferMu sync.RWMutex
 	buffers  [3]*buffer.Buffer
+	// latest key frame of each layer, when enabled for video
+	keyFrameCaches       [3]*keyFrameCache
+	keyFrameCachePackets int
 
 	upTrackMu sync.RWMutex
 	upTracks  [3]*webrtc.TrackRemote
@@ -82,6 +107,10 @@ type WebRTCReceiver struct {
 	free        map[int]struct{}
 	numProcs    int
 	lbThreshold int
+
+	// when set, packets are written to down tracks by the pool, through the queue of each down track
+	forwardingPool *ForwardingPool
+	queues         []*ForwardingQueue
 }
 
 type ReceiverOpts func(w *WebRTCReceiver) *WebRTCReceiver
@@ -114,6 +143,24 @@ func WithLoadBalanceThreshold(downTracks int) ReceiverOpts {
 	}
 }
 
+// WithForwardingPool writes packets to down tracks with the workers of pool, instead of in the read
+// loop of the receiver. Down tracks that can't keep up drop packets, rather than holding back others
+func WithForwardingPool(pool *ForwardingPool) ReceiverOpts {
+	return func(w *WebRTCReceiver) *WebRTCReceiver {
+		w.forwardingPool = pool
+		return w
+	}
+}
+
+// WithKeyFrameCache keeps the latest key frame of each video layer, and up to maxPackets packets
+// from it on, to start down tracks on the layer without waiting for the publisher to answer a PLI
+func WithKeyFrameCache(maxPackets int) ReceiverOpts {
+	return func(w *WebRTCReceiver) *WebRTCReceiver {
+		w.keyFrameCachePackets = maxPackets
+		return w
+	}
+}
+
 // NewWebRTCReceiver creates a new webrtc track receivers
 func NewWebRTCReceiver(receiver *webrtc.RTPReceiver, track *webrtc.TrackRemote, pid string, opts ...ReceiverOpts) Receiver {
 	w := &WebRTCReceiver{
@@ -191,6 +238,9 @@ func (w *WebRTCReceiver) AddUpTrack(track *webrtc.TrackRemote, buff *buffer.Buff
 
 	w.bufferMu.Lock()
 	w.buffers[layer] = buff
+	if w.Kind() == webrtc.RTPCodecTypeVideo && w.keyFrameCachePackets > 0 {
+		w.keyFrameCaches[layer] = newKeyFrameCache(w.keyFrameCachePackets)
+	}
 	w.bufferMu.Unlock()
 
 	if w.Kind() == webrtc.RTPCodecTypeVideo && w.useTrackers {
@@ -356,6 +406,18 @@ func (w *WebRTCReceiver) OnCloseHandler(fn func()) {
 	w.onCloseHandler = fn
 }
 
+// Close stops receiving the track, its down tracks are closed and the close handler called once
+// the packets already received have been forwarded
+func (w *WebRTCReceiver) Close() {
+	w.bufferMu.RLock()
+	defer w.bufferMu.RUnlock()
+	for _, buff := range w.buffers {
+		if buff != nil {
+			_ = buff.Close()
+		}
+	}
+}
+
 // DeleteDownTrack removes a DownTrack from a Receiver
 func (w *WebRTCReceiver) DeleteDownTrack(peerID string) {
 	if w.closed.get() {
@@ -371,6 +433,10 @@ func (w *WebRTCReceiver) DeleteDownTrack(peerID string) {
 	}
 	delete(w.index, peerID)
 	w.downTracks[idx] = nil
+	if q := w.queues[idx]; q != nil {
+		q.Close()
+		w.queues[idx] = nil
+	}
 	w.free[idx] = struct{}{}
 }
 
@@ -385,7 +451,9 @@ func (w *WebRTCReceiver) SendRTCP(p []rtcp.Packet) {
 		w.lastPli.set(time.Now().UnixNano())
 	}
 
-	w.rtcpCh <- p
+	if w.rtcpQueue != nil {
+		w.rtcpQueue.Enqueue(p)
+	}
 }
 
 func (w *WebRTCReceiver) SendPLI(layer int32) {
@@ -396,17 +464,46 @@ func (w *WebRTCReceiver) SendPLI(layer int32) {
 	w.SendRTCP(pli)
 }
 
-func (w *WebRTCReceiver) SetRTCPCh(ch chan []rtcp.Packet) {
-	w.rtcpCh = ch
+// GetCachedKeyFrame returns the packets of layer from its latest key frame up to the packet with
+// sequence number sn, or nil when there's no key frame cached before it. The caller releases the
+// packets once they're written
+func (w *WebRTCReceiver) GetCachedKeyFrame(layer int32, sn uint16) []*buffer.ExtPacket {
+	w.bufferMu.RLock()
+	cache := w.keyFrameCaches[layer]
+	w.bufferMu.RUnlock()
+	if cache == nil {
+		return nil
+	}
+	return cache.packetsBefore(sn)
 }
 
-func (w *WebRTCReceiver) GetSenderReportTime(layer int32) (rtpTS uint32, ntpTS uint64) {
+// GetQueueDepth returns the number of packets waiting in the forwarding queue of the down track
+// of peerID, 0 when packets are written to it directly
+func (w *WebRTCReceiver) GetQueueDepth(peerID string) int {
+	w.downTrackMu.RLock()
+	defer w.downTrackMu.RUnlock()
+
+	idx, ok := w.index[peerID]
+	if !ok || w.queues[idx] == nil {
+		return 0
+	}
+	return w.queues[idx].Len()
+}
+
+// SetRTCPQueue sets the queue of the feedback written to the publisher
+func (w *WebRTCReceiver) SetRTCPQueue(q *RTCPQueue) {
+	w.rtcpQueue = q
+}
+
+// GetRTPTimeAt returns the RTP timestamp of a layer at the given time, mapped through the sender
+// reports of the publisher. False until the layer's first sender report
+func (w *WebRTCReceiver) GetRTPTimeAt(layer int32, at time.Time) (uint32, bool) {
 	w.bufferMu.RLock()
 	defer w.bufferMu.RUnlock()
-	if w.buffers[layer] != nil {
-		rtpTS, ntpTS, _ = w.buffers[layer].GetSenderReportData()
+	if w.buffers[layer] == nil {
+		return 0, false
 	}
-	return
+	return w.buffers[layer].RTPTimeAt(at)
 }
 
 func (w *WebRTCReceiver) ReadRTP(buf []byte, layer uint8, sn uint16) (int, error) {
@@ -418,6 +515,9 @@ func (w *WebRTCReceiver) ReadRTP(buf []byte, layer uint8, sn uint16) (int, error
 
 func (w *WebRTCReceiver) forwardRTP(layer int32) {
 	tracker := w.trackers[layer]
+	w.bufferMu.RLock()
+	cache := w.keyFrameCaches[layer]
+	w.bufferMu.RUnlock()
 
 	defer func() {
 		w.closeOnce.Do(func() {
@@ -427,6 +527,9 @@ func (w *WebRTCReceiver) forwardRTP(layer int32) {
 		if tracker != nil {
 			tracker.Stop()
 		}
+		if cache != nil {
+			cache.close()
+		}
 	}()
 
 	for {
@@ -443,9 +546,12 @@ func (w *WebRTCReceiver) forwardRTP(layer int32) {
 
 		w.downTrackMu.RLock()
 		downTracks := w.downTracks
+		queues := w.queues
 		free := w.free
 		w.downTrackMu.RUnlock()
-		if w.lbThreshold == 0 || len(downTracks)-len(free) < w.lbThreshold {
+		if w.forwardingPool != nil {
+			w.forwardingPool.Forward(pkt, layer, queues)
+		} else if w.lbThreshold == 0 || len(downTracks)-len(free) < w.lbThreshold {
 			// serial - not enough down tracks for parallelization to outweigh overhead
 			for _, dt := range downTracks {
 				if dt != nil {
@@ -482,6 +588,15 @@ func (w *WebRTCReceiver) forwardRTP(layer int32) {
 			}
 			wg.Wait()
 		}
+
+		// cached once written, down tracks starting on the layer are sent the packets before the
+		// one they start on
+		if cache != nil {
+			cache.add(pkt)
+		}
+
+		// down tracks don't keep the packet once written, queues and the cache hold references of their own
+		buffer.ReleaseExtPacket(pkt)
 	}
 }
 
@@ -494,12 +609,18 @@ func (w *WebRTCReceiver) writeRTP(layer int32, dt TrackSender, pkt *buffer.ExtPa
 // closeTracks close all tracks from Receiver
 func (w *WebRTCReceiver) closeTracks() {
 	w.downTrackMu.Lock()
+	for _, q := range w.queues {
+		if q != nil {
+			q.Close()
+		}
+	}
 	for _, dt := range w.downTracks {
 		if dt != nil {
 			dt.Close()
 		}
 	}
 	w.downTracks = make([]TrackSender, 0)
+	w.queues = nil
 	w.index = make(map[string]int)
 	w.free = make(map[int]struct{})
 	w.downTrackMu.Unlock()
@@ -513,15 +634,54 @@ func (w *WebRTCReceiver) storeDownTrack(track TrackSender) {
 	w.downTrackMu.Lock()
 	defer w.downTrackMu.Unlock()
 
+	var queue *ForwardingQueue
+	if w.forwardingPool != nil {
+		queue = w.forwardingPool.NewQueue(track)
+	}
+
 	for idx := range w.free {
 		w.index[track.PeerID()] = idx
 		w.downTracks[idx] = track
+		w.queues[idx] = queue
 		delete(w.free, idx)
 		return
 	}
 
 	w.index[track.PeerID()] = len(w.downTracks)
 	w.downTracks = append(w.downTracks, track)
+	w.queues = append(w.queues, queue)
+}
+
+func (w *WebRTCReceiver) GetLayerStats() []LayerStats {
+	w.bufferMu.RLock()
+	defer w.bufferMu.RUnlock()
+
+	var layerStats []LayerStats
+	for layer, buff := range w.buffers {
+		if buff == nil {
+			continue
+		}
+
+		stats := buff.GetStats()
+		ls := LayerStats{
+			Layer:          int32(layer),
+			SSRC:           buff.GetMediaSSRC(),
+			Bitrate:        buff.Bitrate(),
+			Packets:        stats.PacketCount,
+			Bytes:          stats.TotalByte,
+			PacketsLost:    stats.TotalLost,
+			LossPercentage: stats.LostRate * 100,
+		}
+		if w.kind == webrtc.RTPCodecTypeVideo {
+			ls.FrameRate = buff.FrameRate()
+			ls.Width, ls.Height = buff.Dimensions()
+		}
+		if clockRate := buff.GetClockRate(); clockRate != 0 {
+			ls.JitterMs = stats.Jitter * 1000 / float64(clockRate)
+		}
+		layerStats = append(layerStats, ls)
+	}
+	return layerStats
 }
 
 func (w *WebRTCReceiver) DebugInfo() map[string]interface{} {
@@ -545,5 +705,17 @@ func (w *WebRTCReceiver) DebugInfo() map[string]interface{} {
 	w.upTrackMu.RUnlock()
 	info["UpTracks"] = upTrackInfo
 
+	if w.forwardingPool != nil {
+		w.downTrackMu.RLock()
+		dropped := make(map[string]uint32)
+		for _, q := range w.queues {
+			if q != nil {
+				dropped[q.track.PeerID()] = q.Dropped()
+			}
+		}
+		w.downTrackMu.RUnlock()
+		info["ForwardingDropped"] = dropped
+	}
+
 	return info
 }
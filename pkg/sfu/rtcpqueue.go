@@ -0,0 +1,146 @@
+package sfu
+
+import (
+	"sync"
+
+	"github.com/pion/rtcp"
+)
+
+// RTCPQueue holds the RTCP feedback waiting to be written to a publisher. Receivers add to it
+// without blocking: when it's full the oldest feedback is dropped, so a burst of NACKs can't hold
+// back the receivers. Keyframe requests are kept apart from it and never dropped, a request for a
+// media SSRC replaces the one still queued for it, and they're written first
+type RTCPQueue struct {
+	size int
+
+	mu       sync.Mutex
+	feedback [][]rtcp.Packet
+	// keyframe requests by media SSRC, in the order of the SSRCs they were first queued for
+	keyFrameRequests map[uint32]rtcp.Packet
+	ssrcs            []uint32
+	closed           bool
+	wake             chan struct{}
+
+	onDropped func(pkts []rtcp.Packet)
+}
+
+// NewRTCPQueue creates a queue holding up to size batches of feedback besides keyframe requests
+func NewRTCPQueue(size int) *RTCPQueue {
+	return &RTCPQueue{
+		size:             size,
+		keyFrameRequests: make(map[uint32]rtcp.Packet),
+		wake:             make(chan struct{}, 1),
+	}
+}
+
+// OnDropped sets the function called with the feedback dropped because the queue was full. It
+// must be set before the queue is used
+func (q *RTCPQueue) OnDropped(fn func(pkts []rtcp.Packet)) {
+	q.onDropped = fn
+}
+
+// Enqueue adds feedback to write to the publisher, it's ignored once the queue is closed
+func (q *RTCPQueue) Enqueue(pkts []rtcp.Packet) {
+	feedback := make([]rtcp.Packet, 0, len(pkts))
+	var dropped []rtcp.Packet
+
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	for _, pkt := range pkts {
+		ssrc, ok := keyFrameRequestSSRC(pkt)
+		if !ok {
+			feedback = append(feedback, pkt)
+			continue
+		}
+		if _, queued := q.keyFrameRequests[ssrc]; !queued {
+			q.ssrcs = append(q.ssrcs, ssrc)
+		}
+		q.keyFrameRequests[ssrc] = pkt
+	}
+	if len(feedback) > 0 {
+		if len(q.feedback) >= q.size {
+			dropped = q.feedback[0]
+			q.feedback[0] = nil
+			q.feedback = q.feedback[1:]
+		}
+		q.feedback = append(q.feedback, feedback)
+	}
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	if dropped != nil && q.onDropped != nil {
+		q.onDropped(dropped)
+	}
+}
+
+// Dequeue returns the next feedback to write, the queued keyframe requests first. It waits until
+// there's some, false once the queue is closed
+func (q *RTCPQueue) Dequeue() ([]rtcp.Packet, bool) {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return nil, false
+		}
+		if len(q.ssrcs) > 0 {
+			pkts := make([]rtcp.Packet, 0, len(q.ssrcs))
+			for _, ssrc := range q.ssrcs {
+				pkts = append(pkts, q.keyFrameRequests[ssrc])
+				delete(q.keyFrameRequests, ssrc)
+			}
+			q.ssrcs = q.ssrcs[:0]
+			q.mu.Unlock()
+			return pkts, true
+		}
+		if len(q.feedback) > 0 {
+			pkts := q.feedback[0]
+			q.feedback[0] = nil
+			q.feedback = q.feedback[1:]
+			q.mu.Unlock()
+			return pkts, true
+		}
+		q.mu.Unlock()
+
+		<-q.wake
+	}
+}
+
+// Len returns the number of batches of feedback and keyframe requests queued
+func (q *RTCPQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.feedback) + len(q.ssrcs)
+}
+
+// Close drops the queued feedback, and wakes up Dequeue
+func (q *RTCPQueue) Close() {
+	q.mu.Lock()
+	q.closed = true
+	q.feedback = nil
+	q.keyFrameRequests = nil
+	q.ssrcs = nil
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// keyFrameRequestSSRC returns the media SSRC a PLI or FIR asks a keyframe of
+func keyFrameRequestSSRC(pkt rtcp.Packet) (uint32, bool) {
+	switch p := pkt.(type) {
+	case *rtcp.PictureLossIndication:
+		return p.MediaSSRC, true
+	case *rtcp.FullIntraRequest:
+		return p.MediaSSRC, true
+	default:
+		return 0, false
+	}
+}
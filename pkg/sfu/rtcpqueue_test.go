@@ -0,0 +1,96 @@
+package sfu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRTCPQueue(t *testing.T) {
+	nack := func(ssrc uint32) []rtcp.Packet {
+		return []rtcp.Packet{&rtcp.TransportLayerNack{MediaSSRC: ssrc}}
+	}
+
+	t.Run("drops the oldest feedback when full", func(t *testing.T) {
+		q := NewRTCPQueue(2)
+		var dropped []rtcp.Packet
+		q.OnDropped(func(pkts []rtcp.Packet) {
+			dropped = append(dropped, pkts...)
+		})
+
+		// doesn't block
+		for ssrc := uint32(1); ssrc <= 4; ssrc++ {
+			q.Enqueue(nack(ssrc))
+		}
+		require.Equal(t, 2, q.Len())
+		require.Equal(t, append(nack(1), nack(2)...), dropped)
+
+		pkts, ok := q.Dequeue()
+		require.True(t, ok)
+		require.Equal(t, nack(3), pkts)
+		pkts, ok = q.Dequeue()
+		require.True(t, ok)
+		require.Equal(t, nack(4), pkts)
+		require.Zero(t, q.Len())
+	})
+
+	t.Run("keyframe requests are kept and sent first", func(t *testing.T) {
+		q := NewRTCPQueue(1)
+		dropped := 0
+		q.OnDropped(func(pkts []rtcp.Packet) {
+			dropped += len(pkts)
+		})
+
+		pli := &rtcp.PictureLossIndication{MediaSSRC: 1}
+		q.Enqueue([]rtcp.Packet{&rtcp.TransportLayerNack{MediaSSRC: 1}, pli})
+		q.Enqueue(nack(2))
+		// the same SSRC's request replaces the queued one
+		laterPLI := &rtcp.PictureLossIndication{SenderSSRC: 5, MediaSSRC: 1}
+		fir := &rtcp.FullIntraRequest{MediaSSRC: 2}
+		q.Enqueue([]rtcp.Packet{laterPLI, fir})
+		require.Equal(t, 1, dropped)
+
+		pkts, ok := q.Dequeue()
+		require.True(t, ok)
+		require.Equal(t, []rtcp.Packet{laterPLI, fir}, pkts)
+		pkts, ok = q.Dequeue()
+		require.True(t, ok)
+		require.Equal(t, nack(2), pkts)
+	})
+
+	t.Run("dequeue waits until closed", func(t *testing.T) {
+		q := NewRTCPQueue(1)
+		received := make(chan bool)
+		go func() {
+			for {
+				_, ok := q.Dequeue()
+				received <- ok
+				if !ok {
+					return
+				}
+			}
+		}()
+
+		q.Enqueue(nack(1))
+		select {
+		case ok := <-received:
+			require.True(t, ok)
+		case <-time.After(time.Second):
+			require.Fail(t, "feedback not dequeued")
+		}
+
+		q.Close()
+		select {
+		case ok := <-received:
+			require.False(t, ok)
+		case <-time.After(time.Second):
+			require.Fail(t, "dequeue didn't return once closed")
+		}
+
+		// feedback of receivers still running is ignored
+		q.Enqueue(nack(2))
+		require.Zero(t, q.Len())
+	})
+}
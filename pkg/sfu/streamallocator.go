@@ -99,7 +99,6 @@ package sfu
 
 import (
 	"fmt"
-	"math"
 	"sort"
 	"sync"
 	"time"
@@ -194,21 +193,38 @@ func (s Signal) String() string {
 type StreamAllocatorParams struct {
 	ParticipantID string
 	Logger        logger.Logger
+	// defaults to gcc
+	CongestionController CongestionController
+	// called once a second, to evaluate congestion control algorithms against each other
+	OnCongestionControlStats func(stats CongestionControlStats)
+}
+
+// CongestionControlStats summarizes a sampling interval of a StreamAllocator
+type CongestionControlStats struct {
+	Algorithm string
+	State     State
+	// ChannelCapacityInfinity until a limit is detected
+	CommittedCapacity int64
+	// bandwidth requested by the allocated video tracks
+	ExpectedBandwidth int64
+	Sample            ChannelSample
 }
 
 type StreamAllocator struct {
 	participantID string
 	logger        logger.Logger
 
-	onStreamedTracksChange func(update *StreamedTracksUpdate) error
+	onStreamedTracksChange   func(update *StreamedTracksUpdate) error
+	onCongestionControlStats func(stats CongestionControlStats)
 
+	controller               CongestionController
 	trackingSSRC             uint32
-	committedChannelCapacity int64
-	lastCommitTime           time.Time
-	prevReceivedEstimate     int64
-	receivedEstimate         int64
 	lastEstimateDecreaseTime time.Time
 
+	// video bytes sent since the last channel sample
+	bytesSent      atomicInt64
+	lastSampleTime time.Time
+
 	lastBoostTime time.Time
 
 	lastGratuitousProbeTime time.Time
@@ -233,11 +249,19 @@ type Event struct {
 }
 
 func NewStreamAllocator(params StreamAllocatorParams) *StreamAllocator {
+	controller := params.CongestionController
+	if controller == nil {
+		controller = &gccController{}
+	}
+
 	s := &StreamAllocator{
-		participantID: params.ParticipantID,
-		logger:        params.Logger,
-		audioTracks:   make(map[string]*Track),
-		videoTracks:   make(map[string]*Track),
+		participantID:            params.ParticipantID,
+		logger:                   params.Logger,
+		onCongestionControlStats: params.OnCongestionControlStats,
+		controller:               controller,
+		lastSampleTime:           time.Now(),
+		audioTracks:              make(map[string]*Track),
+		videoTracks:              make(map[string]*Track),
 		prober: NewProber(ProberParams{
 			ParticipantID: params.ParticipantID,
 			Logger:        params.Logger,
@@ -294,9 +318,7 @@ func (s *StreamAllocator) RemoveTrack(downTrack *DownTrack) {
 }
 
 func (s *StreamAllocator) initializeEstimate() {
-	s.committedChannelCapacity = ChannelCapacityInfinity
-	s.lastCommitTime = time.Now().Add(-EstimateCommitMs)
-	s.receivedEstimate = ChannelCapacityInfinity
+	s.controller.Reset()
 	s.lastEstimateDecreaseTime = time.Now()
 
 	s.state = StateStable
@@ -347,6 +369,7 @@ func (s *StreamAllocator) onSubscribedLayersChanged(downTrack *DownTrack, layers
 
 // called when a video DownTrack sends a packet
 func (s *StreamAllocator) onPacketSent(downTrack *DownTrack, size int) {
+	s.bytesSent.add(int64(size))
 	s.prober.PacketSent(size)
 }
 
@@ -533,10 +556,10 @@ func (s *StreamAllocator) handleSignalEstimate(event *Event) {
 		return
 	}
 
-	s.prevReceivedEstimate = s.receivedEstimate
-	s.receivedEstimate = int64(remb.Bitrate)
-	if s.prevReceivedEstimate != s.receivedEstimate {
-		s.logger.Debugw("received new estimate", "participant", s.participantID, "old(bps)", s.prevReceivedEstimate, "new(bps)", s.receivedEstimate)
+	prevEstimate := s.controller.Estimate()
+	s.controller.HandleEstimate(int64(remb.Bitrate))
+	if estimate := s.controller.Estimate(); estimate != prevEstimate {
+		s.logger.Debugw("received new estimate", "participant", s.participantID, "old(bps)", prevEstimate, "new(bps)", estimate)
 	}
 
 	if s.maybeCommitEstimate() {
@@ -603,6 +626,9 @@ func (s *StreamAllocator) handleSignalSubscribedLayersChange(event *Event) {
 }
 
 func (s *StreamAllocator) handleSignalPeriodicPing(event *Event) {
+	sample := s.sampleChannel()
+	s.controller.HandleSample(sample)
+
 	if s.maybeCommitEstimate() {
 		s.allocateAllTracks()
 	}
@@ -613,6 +639,16 @@ func (s *StreamAllocator) handleSignalPeriodicPing(event *Event) {
 	if s.state == StateDeficient {
 		s.maybeProbe()
 	}
+
+	if s.onCongestionControlStats != nil {
+		s.onCongestionControlStats(CongestionControlStats{
+			Algorithm:         s.controller.Name(),
+			State:             s.state,
+			CommittedCapacity: s.controller.CommittedCapacity(),
+			ExpectedBandwidth: s.getExpectedBandwidthUsage(),
+			Sample:            sample,
+		})
+	}
 }
 
 func (s *StreamAllocator) handleSignalSendProbe(event *Event) {
@@ -632,6 +668,7 @@ func (s *StreamAllocator) handleSignalSendProbe(event *Event) {
 	}
 
 	if bytesSent != 0 {
+		s.bytesSent.add(int64(bytesSent))
 		s.prober.ProbeSent(bytesSent)
 	}
 }
@@ -656,42 +693,15 @@ func (s *StreamAllocator) adjustState() {
 }
 
 func (s *StreamAllocator) maybeCommitEstimate() (isDecreasing bool) {
-	// commit channel capacity estimate under following rules
-	//   1. Abs(receivedEstimate - prevReceivedEstimate) < EstimateEpsilon => estimate stable
-	//   2. time.Since(lastCommitTime) > EstimateCommitMs => to catch long oscillating estimate
-	if math.Abs(float64(s.receivedEstimate)-float64(s.prevReceivedEstimate)) > EstimateEpsilon {
-		// too large a change, wait for estimate to settle.
-		// Unless estimate has been oscillating for too long.
-		if time.Since(s.lastCommitTime) < EstimateCommitMs {
-			return
-		}
-	}
-
-	// don't commit too often even if the change is small.
-	// Small changes will also get picked up during periodic check.
-	if time.Since(s.lastCommitTime) < EstimateCommitMs {
-		return
-	}
-
-	if s.receivedEstimate == s.committedChannelCapacity {
-		// no change in estimate, no need to commit
+	committed, isDecreasing := s.controller.MaybeCommit()
+	if !committed {
 		return
 	}
 
-	if s.committedChannelCapacity > s.receivedEstimate && s.committedChannelCapacity != ChannelCapacityInfinity {
-		// this prevents declaring a decrease when coming out of init state.
-		// But, this bypasses the case where streaming starts on a bunch of
-		// tracks simultaneously (imagine a participant joining a large room
-		// with a lot of video tracks). In that case, it is possible that the
-		// channel is hitting congestion. It will caught on the next estimate
-		// decrease.
+	if isDecreasing {
 		s.lastEstimateDecreaseTime = time.Now()
-		isDecreasing = true
 	}
-	s.committedChannelCapacity = s.receivedEstimate
-	s.lastCommitTime = time.Now()
-
-	s.logger.Debugw("committing channel capacity", "participant", s.participantID, "capacity(bps)", s.committedChannelCapacity)
+	s.logger.Debugw("committing channel capacity", "participant", s.participantID, "algorithm", s.controller.Name(), "capacity(bps)", s.controller.CommittedCapacity())
 	return
 }
 
@@ -822,7 +832,7 @@ func (s *StreamAllocator) allocateAllTracks() {
 	//
 	update := NewStreamedTracksUpdate()
 
-	availableChannelCapacity := s.committedChannelCapacity
+	availableChannelCapacity := s.controller.CommittedCapacity()
 	for _, track := range s.videoTracksSorted {
 		//
 		// `video` tracks could do one of the following
@@ -890,7 +900,25 @@ func (s *StreamAllocator) getExpectedBandwidthUsage() int64 {
 	return expected
 }
 
-// LK-TODO: unused till loss based estimation is done, but just a sample impl of weighting audio higher
+// sampleChannel collects what was sent since the previous sample and the latest subscriber feedback
+func (s *StreamAllocator) sampleChannel() ChannelSample {
+	now := time.Now()
+	sample := ChannelSample{
+		Interval:       now.Sub(s.lastSampleTime),
+		BytesSent:      s.bytesSent.swap(0),
+		LossPercentage: s.calculateLoss(),
+	}
+	s.lastSampleTime = now
+
+	for _, track := range s.videoTracks {
+		if rtt := track.DownTrack().RTT(); rtt > sample.RTTMs {
+			sample.RTTMs = rtt
+		}
+	}
+	return sample
+}
+
+// weighs audio loss higher than video loss
 func (s *StreamAllocator) calculateLoss() float32 {
 	packetsAudio := uint32(0)
 	packetsLostAudio := uint32(0)
@@ -974,13 +1002,14 @@ func (s *StreamAllocator) maybeGratuitousProbe() bool {
 
 	// use last received estimate for gratuitous probing base as
 	// more updates may have been received since the last commit
+	estimate := s.controller.Estimate()
 	expectedRateBps := s.getExpectedBandwidthUsage()
-	headroomBps := s.receivedEstimate - expectedRateBps
+	headroomBps := estimate - expectedRateBps
 	if headroomBps > GratuitousProbeHeadroomBps {
 		return false
 	}
 
-	probeRateBps := (s.receivedEstimate * GratuitousProbePct) / 100
+	probeRateBps := (estimate * GratuitousProbePct) / 100
 	if probeRateBps < GratuitousProbeMinBps {
 		probeRateBps = GratuitousProbeMinBps
 	}
@@ -989,7 +1018,7 @@ func (s *StreamAllocator) maybeGratuitousProbe() bool {
 	}
 
 	s.prober.AddCluster(
-		int(s.receivedEstimate+probeRateBps),
+		int(estimate+probeRateBps),
 		int(expectedRateBps),
 		GratuitousProbeMinDurationMs,
 		GratuitousProbeMaxDurationMs,
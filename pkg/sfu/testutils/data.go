@@ -63,7 +63,8 @@ func GetTestExtPacketVP8(params *TestExtPacketParams, vp8 *buffer.VP8) (*buffer.
 		return nil, err
 	}
 
-	ep.Payload = *vp8
+	payload := *vp8
+	ep.Payload = &payload
 	return ep, nil
 }
 
@@ -49,6 +49,10 @@ type Responder struct {
 	chunk    uint16
 
 	onFeedback func(packet rtcp.RawPacket)
+
+	// packets reported as received and as not received, since the responder was created
+	packetsReceived uint64
+	packetsLost     uint64
 }
 
 func NewTransportWideCCResponder(ssrc uint32) *Responder {
@@ -90,6 +94,14 @@ func (t *Responder) OnFeedback(f func(p rtcp.RawPacket)) {
 	t.onFeedback = f
 }
 
+// Stats returns the number of packets reported to the sender as received and as not received.
+// Packets arriving after the feedback that covered them count as lost
+func (t *Responder) Stats() (received, lost uint64) {
+	t.Lock()
+	defer t.Unlock()
+	return t.packetsReceived, t.packetsLost
+}
+
 func (t *Responder) buildTransportCCPacket() rtcp.RawPacket {
 	if len(t.extInfo) == 0 {
 		return nil
@@ -105,10 +117,12 @@ func (t *Responder) buildTransportCCPacket() rtcp.RawPacket {
 		if t.lastExtSN != 0 {
 			for j := t.lastExtSN + 1; j < tccExtInfo.ExtTSN; j++ {
 				tccPkts = append(tccPkts, rtpExtInfo{ExtTSN: j})
+				t.packetsLost++
 			}
 		}
 		t.lastExtSN = tccExtInfo.ExtTSN
 		tccPkts = append(tccPkts, tccExtInfo)
+		t.packetsReceived++
 	}
 	t.extInfo = t.extInfo[:0]
 
@@ -344,3 +344,26 @@ func BenchmarkBuildPacket(b *testing.B) {
 		_ = twcc.buildTransportCCPacket()
 	}
 }
+
+func TestTransportWideCC_Stats(t1 *testing.T) {
+	t := NewTransportWideCCResponder(1234)
+	feedbacks := 0
+	t.OnFeedback(func(_ rtcp.RawPacket) {
+		feedbacks++
+	})
+
+	// every tenth packet is lost
+	now := time.Now().UnixNano()
+	for sn := uint16(1); sn <= 200; sn++ {
+		now += int64(time.Millisecond)
+		if sn%10 == 0 {
+			continue
+		}
+		t.Push(sn, now, false)
+	}
+	assert.Greater(t1, feedbacks, 0)
+
+	received, lost := t.Stats()
+	assert.Greater(t1, received, uint64(0))
+	assert.Equal(t1, received/9, lost)
+}
@@ -0,0 +1,140 @@
+package sfu
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+)
+
+const (
+	// reports older than this are left out, their subscriber or layer went away or stopped reporting
+	upstreamReportExpiry = 5 * time.Second
+	// lowest estimate sent to publishers, like the REMB of a buffer
+	minREMBBitrate = 100_000
+)
+
+// LossAggregator keeps the fraction lost each subscriber of a track last reported, to tell the
+// publisher about the loss of its typical subscriber rather than the worst one. A single subscriber on
+// a bad network would otherwise have the publisher spend its bitrate on redundancy for everyone
+type LossAggregator struct {
+	percentile uint8
+
+	lock    sync.Mutex
+	reports map[string]lossReport
+}
+
+type lossReport struct {
+	fractionLost uint8
+	at           time.Time
+}
+
+// NewLossAggregator creates an aggregator reporting the given percentile of subscriber loss
+func NewLossAggregator(percentile uint8) *LossAggregator {
+	if percentile > 100 {
+		percentile = 100
+	}
+	return &LossAggregator{
+		percentile: percentile,
+		reports:    make(map[string]lossReport),
+	}
+}
+
+// Report sets the fraction lost a subscriber last reported, out of 256
+func (a *LossAggregator) Report(subscriberID string, fractionLost uint8, at time.Time) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.reports[subscriberID] = lossReport{fractionLost: fractionLost, at: at}
+}
+
+// Remove forgets the reports of a subscriber that unsubscribed
+func (a *LossAggregator) Remove(subscriberID string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	delete(a.reports, subscriberID)
+}
+
+// FractionLost returns the percentile of the fraction lost subscribers reported recently, 0 when none
+// did
+func (a *LossAggregator) FractionLost(now time.Time) uint8 {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	losses := make([]int, 0, len(a.reports))
+	for id, r := range a.reports {
+		if now.Sub(r.at) > upstreamReportExpiry {
+			delete(a.reports, id)
+			continue
+		}
+		losses = append(losses, int(r.fractionLost))
+	}
+	if len(losses) == 0 {
+		return 0
+	}
+	sort.Ints(losses)
+	// nearest rank
+	rank := (int(a.percentile)*len(losses) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return uint8(losses[rank-1])
+}
+
+// REMBAggregator combines the REMB estimates the buffers of a publisher make for each of the streams
+// they receive into one for all of them. Browsers take a REMB as the estimate of their whole
+// connection, so the estimate of a single low simulcast layer would throttle everything else
+type REMBAggregator struct {
+	// cap of the combined estimate, 0 for none
+	maxBitrate uint64
+
+	lock      sync.Mutex
+	estimates map[uint32]rembEstimate
+}
+
+type rembEstimate struct {
+	bitrate float32
+	at      time.Time
+}
+
+// NewREMBAggregator creates an aggregator capping combined estimates at maxBitrate, 0 for no cap
+func NewREMBAggregator(maxBitrate uint64) *REMBAggregator {
+	return &REMBAggregator{
+		maxBitrate: maxBitrate,
+		estimates:  make(map[uint32]rembEstimate),
+	}
+}
+
+// Update records the estimate of a buffer and returns the REMB to send the publisher in its place,
+// covering every stream with a recent estimate
+func (a *REMBAggregator) Update(remb *rtcp.ReceiverEstimatedMaximumBitrate, at time.Time) *rtcp.ReceiverEstimatedMaximumBitrate {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	for _, ssrc := range remb.SSRCs {
+		// a REMB for several streams is shared between them
+		a.estimates[ssrc] = rembEstimate{bitrate: remb.Bitrate / float32(len(remb.SSRCs)), at: at}
+	}
+
+	combined := &rtcp.ReceiverEstimatedMaximumBitrate{
+		SenderSSRC: remb.SenderSSRC,
+		SSRCs:      make([]uint32, 0, len(a.estimates)),
+	}
+	for ssrc, e := range a.estimates {
+		if at.Sub(e.at) > upstreamReportExpiry {
+			delete(a.estimates, ssrc)
+			continue
+		}
+		combined.Bitrate += e.bitrate
+		combined.SSRCs = append(combined.SSRCs, ssrc)
+	}
+	sort.Slice(combined.SSRCs, func(i, j int) bool { return combined.SSRCs[i] < combined.SSRCs[j] })
+
+	if a.maxBitrate != 0 && combined.Bitrate > float32(a.maxBitrate) {
+		combined.Bitrate = float32(a.maxBitrate)
+	}
+	if combined.Bitrate < minREMBBitrate {
+		combined.Bitrate = minREMBBitrate
+	}
+	return combined
+}
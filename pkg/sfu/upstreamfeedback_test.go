@@ -0,0 +1,69 @@
+package sfu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLossAggregator(t *testing.T) {
+	t.Run("reports the percentile of subscriber loss", func(t *testing.T) {
+		now := time.Now()
+		a := NewLossAggregator(50)
+		require.Zero(t, a.FractionLost(now))
+
+		// one subscriber on a bad network doesn't decide for everyone
+		for i, lost := range []uint8{2, 5, 200} {
+			a.Report(string(rune('a'+i)), lost, now)
+		}
+		require.Equal(t, uint8(5), a.FractionLost(now))
+
+		a.Remove("b")
+		require.Equal(t, uint8(2), a.FractionLost(now))
+
+		worst := NewLossAggregator(100)
+		worst.Report("a", 2, now)
+		worst.Report("b", 200, now)
+		require.Equal(t, uint8(200), worst.FractionLost(now))
+	})
+
+	t.Run("stale reports are left out", func(t *testing.T) {
+		now := time.Now()
+		a := NewLossAggregator(100)
+		a.Report("a", 200, now.Add(-upstreamReportExpiry-time.Second))
+		a.Report("b", 10, now)
+		require.Equal(t, uint8(10), a.FractionLost(now))
+	})
+}
+
+func TestREMBAggregator(t *testing.T) {
+	remb := func(bitrate float32, ssrcs ...uint32) *rtcp.ReceiverEstimatedMaximumBitrate {
+		return &rtcp.ReceiverEstimatedMaximumBitrate{Bitrate: bitrate, SSRCs: ssrcs}
+	}
+
+	t.Run("combines the estimates of all streams", func(t *testing.T) {
+		now := time.Now()
+		a := NewREMBAggregator(0)
+		require.Equal(t, remb(200_000, 1), a.Update(remb(200_000, 1), now))
+		require.Equal(t, remb(1_200_000, 1, 2), a.Update(remb(1_000_000, 2), now))
+		// a newer estimate replaces the stream's
+		require.Equal(t, remb(1_500_000, 1, 2), a.Update(remb(500_000, 1), now))
+	})
+
+	t.Run("caps the combined estimate", func(t *testing.T) {
+		now := time.Now()
+		a := NewREMBAggregator(1_000_000)
+		a.Update(remb(800_000, 1), now)
+		require.Equal(t, remb(1_000_000, 1, 2), a.Update(remb(800_000, 2), now))
+		require.Equal(t, remb(minREMBBitrate, 3), NewREMBAggregator(0).Update(remb(10_000, 3), now))
+	})
+
+	t.Run("stale estimates are left out", func(t *testing.T) {
+		now := time.Now()
+		a := NewREMBAggregator(0)
+		a.Update(remb(800_000, 1), now.Add(-upstreamReportExpiry-time.Second))
+		require.Equal(t, remb(300_000, 2), a.Update(remb(300_000, 2), now))
+	})
+}
@@ -38,7 +38,7 @@ func NewVP8Munger() *VP8Munger {
 }
 
 func (v *VP8Munger) SetLast(extPkt *buffer.ExtPacket) {
-	vp8, ok := extPkt.Payload.(buffer.VP8)
+	vp8, ok := extPkt.Payload.(*buffer.VP8)
 	if !ok {
 		return
 	}
@@ -65,7 +65,7 @@ func (v *VP8Munger) SetLast(extPkt *buffer.ExtPacket) {
 }
 
 func (v *VP8Munger) UpdateOffsets(extPkt *buffer.ExtPacket) {
-	vp8, ok := extPkt.Payload.(buffer.VP8)
+	vp8, ok := extPkt.Payload.(*buffer.VP8)
 	if !ok {
 		return
 	}
@@ -90,7 +90,7 @@ func (v *VP8Munger) UpdateOffsets(extPkt *buffer.ExtPacket) {
 }
 
 func (v *VP8Munger) UpdateAndGet(extPkt *buffer.ExtPacket, ordering SequenceNumberOrdering, maxTemporalLayer int32) (*TranslationParamsVP8, error) {
-	vp8, ok := extPkt.Payload.(buffer.VP8)
+	vp8, ok := extPkt.Payload.(*buffer.VP8)
 	if !ok {
 		return nil, ErrNotVP8
 	}
@@ -13,6 +13,8 @@ import (
 type AnalyticsService interface {
 	SendStats(ctx context.Context, stats []*livekit.AnalyticsStat)
 	SendEvent(ctx context.Context, events *livekit.AnalyticsEvent)
+	// Stop publishes what's queued for the event bus, the event publisher can be closed afterwards
+	Stop()
 }
 
 type analyticsService struct {
@@ -21,17 +23,24 @@ type analyticsService struct {
 
 	events livekit.AnalyticsRecorderService_IngestEventsClient
 	stats  livekit.AnalyticsRecorderService_IngestStatsClient
+
+	// nil when no event bus is configured
+	eventBus *eventBus
 }
 
-func NewAnalyticsService(conf *config.Config, currentNode routing.LocalNode) AnalyticsService {
-	return &analyticsService{
+func NewAnalyticsService(conf *config.Config, currentNode routing.LocalNode, publisher EventPublisher) AnalyticsService {
+	a := &analyticsService{
 		analyticsKey: "", // TODO: conf.AnalyticsKey
 		nodeID:       currentNode.Id,
 	}
+	if publisher != nil {
+		a.eventBus = newEventBus(&conf.EventBus, publisher)
+	}
+	return a
 }
 
 func (a *analyticsService) SendStats(ctx context.Context, stats []*livekit.AnalyticsStat) {
-	if a.stats == nil {
+	if a.stats == nil && a.eventBus == nil {
 		return
 	}
 
@@ -39,20 +48,40 @@ func (a *analyticsService) SendStats(ctx context.Context, stats []*livekit.Analy
 		stat.AnalyticsKey = a.analyticsKey
 		stat.Node = a.nodeID
 	}
-	if err := a.stats.Send(&livekit.AnalyticsStats{Stats: stats}); err != nil {
+	msg := &livekit.AnalyticsStats{Stats: stats}
+	if a.eventBus != nil {
+		a.eventBus.enqueue(a.eventBus.statsTopic, msg)
+	}
+	if a.stats == nil {
+		return
+	}
+	if err := a.stats.Send(msg); err != nil {
 		logger.Errorw("failed to send stats", err)
 	}
 }
 
 func (a *analyticsService) SendEvent(ctx context.Context, event *livekit.AnalyticsEvent) {
-	if a.events == nil {
+	if a.events == nil && a.eventBus == nil {
 		return
 	}
 
 	event.AnalyticsKey = a.analyticsKey
-	if err := a.events.Send(&livekit.AnalyticsEvents{
+	msg := &livekit.AnalyticsEvents{
 		Events: []*livekit.AnalyticsEvent{event},
-	}); err != nil {
+	}
+	if a.eventBus != nil {
+		a.eventBus.enqueue(a.eventBus.eventsTopic, msg)
+	}
+	if a.events == nil {
+		return
+	}
+	if err := a.events.Send(msg); err != nil {
 		logger.Errorw("failed to send event", err, "eventType", event.Type.String())
 	}
 }
+
+func (a *analyticsService) Stop() {
+	if a.eventBus != nil {
+		a.eventBus.close()
+	}
+}
@@ -0,0 +1,267 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/livekit/protocol/logger"
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	EventBusRedis = "redis"
+	EventBusNATS  = "nats"
+	EventBusKafka = "kafka"
+
+	EventBusEncodingJSON     = "json"
+	EventBusEncodingProtobuf = "protobuf"
+
+	// messages waiting to be published, further messages are dropped while the bus is slow
+	eventBusQueueSize = 1024
+	// time queued messages have to be published when the server shuts down, the rest is dropped
+	eventBusDrainTimeout = 5 * time.Second
+	natsDialTimeout      = 5 * time.Second
+	kafkaBatchTimeout    = 100 * time.Millisecond
+)
+
+// EventPublisher publishes analytics to a message queue, so that large deployments can consume
+// them without webhook fan-out. Further message queues are supported by implementing it
+type EventPublisher interface {
+	Publish(ctx context.Context, topic string, data []byte) error
+	Close() error
+}
+
+// NewEventPublisher returns the publisher configured in conf, nil when the event bus is disabled.
+// rc is used by the redis publisher
+func NewEventPublisher(conf *config.EventBusConfig, rc redis.UniversalClient) (EventPublisher, error) {
+	switch conf.Kind {
+	case "":
+		return nil, nil
+	case EventBusRedis:
+		if rc == nil {
+			return nil, errors.New("redis event bus requires redis to be configured")
+		}
+		return NewRedisEventPublisher(rc, conf.RedisStreamMaxLen), nil
+	case EventBusNATS:
+		return NewNATSEventPublisher(conf.NATSURL)
+	case EventBusKafka:
+		if len(conf.KafkaBrokers) == 0 {
+			return nil, errors.New("kafka event bus requires brokers")
+		}
+		return NewKafkaEventPublisher(conf.KafkaBrokers), nil
+	default:
+		return nil, fmt.Errorf("unsupported event bus: %s", conf.Kind)
+	}
+}
+
+//------------------------------------------------
+
+// eventBus encodes messages and publishes them in the background, so that telemetry is never
+// held up by the message queue
+type eventBus struct {
+	publisher   EventPublisher
+	eventsTopic string
+	statsTopic  string
+	marshal     func(proto.Message) ([]byte, error)
+
+	// held to enqueue, so that the queue isn't closed meanwhile
+	lock   sync.RWMutex
+	closed bool
+	queue  chan *eventBusMessage
+	// canceled when queued messages didn't drain in time
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type eventBusMessage struct {
+	topic string
+	data  []byte
+}
+
+func newEventBus(conf *config.EventBusConfig, publisher EventPublisher) *eventBus {
+	b := &eventBus{
+		publisher:   publisher,
+		eventsTopic: conf.EventsTopic,
+		statsTopic:  conf.StatsTopic,
+		marshal:     protojson.Marshal,
+		queue:       make(chan *eventBusMessage, eventBusQueueSize),
+		done:        make(chan struct{}),
+	}
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	if conf.Encoding == EventBusEncodingProtobuf {
+		b.marshal = proto.Marshal
+	}
+	go b.publishWorker()
+	return b
+}
+
+func (b *eventBus) enqueue(topic string, msg proto.Message) {
+	data, err := b.marshal(msg)
+	if err != nil {
+		logger.Errorw("could not encode event bus message", err)
+		return
+	}
+
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	if b.closed {
+		return
+	}
+	select {
+	case b.queue <- &eventBusMessage{topic: topic, data: data}:
+	default:
+		logger.Warnw("event bus queue is full, dropping message", nil, "topic", topic)
+	}
+}
+
+// close stops taking messages, and returns once those queued are published, or were dropped after
+// eventBusDrainTimeout. The publisher is left open
+func (b *eventBus) close() {
+	b.lock.Lock()
+	if b.closed {
+		b.lock.Unlock()
+		return
+	}
+	b.closed = true
+	close(b.queue)
+	b.lock.Unlock()
+
+	select {
+	case <-b.done:
+	case <-time.After(eventBusDrainTimeout):
+		logger.Warnw("event bus did not drain in time, dropping queued messages", nil, "queued", len(b.queue))
+		b.cancel()
+		<-b.done
+	}
+	b.cancel()
+}
+
+func (b *eventBus) publishWorker() {
+	defer close(b.done)
+	for msg := range b.queue {
+		if b.ctx.Err() != nil {
+			// dropped, see close
+			continue
+		}
+		if err := b.publisher.Publish(b.ctx, msg.topic, msg.data); err != nil {
+			logger.Warnw("could not publish to event bus", err, "topic", msg.topic)
+		}
+	}
+}
+
+//------------------------------------------------
+
+type redisEventPublisher struct {
+	rc     redis.UniversalClient
+	maxLen int64
+}
+
+// NewRedisEventPublisher appends messages to redis streams named after their topic. Streams are
+// trimmed to roughly maxLen entries, 0 to keep them all
+func NewRedisEventPublisher(rc redis.UniversalClient, maxLen int64) EventPublisher {
+	return &redisEventPublisher{
+		rc:     rc,
+		maxLen: maxLen,
+	}
+}
+
+func (p *redisEventPublisher) Publish(ctx context.Context, topic string, data []byte) error {
+	return p.rc.XAdd(ctx, &redis.XAddArgs{
+		Stream: topic,
+		MaxLen: p.maxLen,
+		Approx: p.maxLen > 0,
+		Values: map[string]interface{}{"data": data},
+	}).Err()
+}
+
+func (p *redisEventPublisher) Close() error {
+	// the client is shared with the rest of the server
+	return nil
+}
+
+//------------------------------------------------
+
+type natsEventPublisher struct {
+	conn *nats.Conn
+}
+
+// NewNATSEventPublisher publishes to NATS subjects named after their topic. natsURL takes the
+// form nats://[user:pass@]host:port, or nats://token@host:port. The server doesn't have to be up
+// yet, messages are buffered while the client connects or reconnects
+func NewNATSEventPublisher(natsURL string) (EventPublisher, error) {
+	conn, err := nats.Connect(natsURL,
+		nats.Name("livekit-server"),
+		nats.Timeout(natsDialTimeout),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.ErrorHandler(func(_ *nats.Conn, _ *nats.Subscription, err error) {
+			logger.Warnw("NATS error", err)
+		}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect to NATS")
+	}
+	return &natsEventPublisher{conn: conn}, nil
+}
+
+func (p *natsEventPublisher) Publish(_ context.Context, topic string, data []byte) error {
+	if topic == "" || strings.ContainsAny(topic, " \t\r\n") {
+		return fmt.Errorf("invalid NATS subject: %q", topic)
+	}
+	return p.conn.Publish(topic, data)
+}
+
+func (p *natsEventPublisher) Close() error {
+	// messages still buffered are sent before closing
+	if p.conn.IsConnected() {
+		_ = p.conn.FlushTimeout(natsDialTimeout)
+	}
+	p.conn.Close()
+	return nil
+}
+
+//------------------------------------------------
+
+type kafkaEventPublisher struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaEventPublisher publishes to Kafka topics named after their topic. Messages are written
+// in batches in the background, errors are logged
+func NewKafkaEventPublisher(brokers []string) EventPublisher {
+	return &kafkaEventPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Balancer:     &kafka.LeastBytes{},
+			BatchTimeout: kafkaBatchTimeout,
+			Async:        true,
+			// when the brokers allow it
+			AllowAutoTopicCreation: true,
+			Completion: func(messages []kafka.Message, err error) {
+				if err != nil {
+					logger.Warnw("could not publish to Kafka", err, "messages", len(messages))
+				}
+			},
+		},
+	}
+}
+
+func (p *kafkaEventPublisher) Publish(ctx context.Context, topic string, data []byte) error {
+	return p.writer.WriteMessages(ctx, kafka.Message{Topic: topic, Value: data})
+}
+
+func (p *kafkaEventPublisher) Close() error {
+	// flushes the batches being written
+	return p.writer.Close()
+}
@@ -0,0 +1,168 @@
+package telemetry
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestNATSEventPublisher(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	lines := make(chan string, 10)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Write([]byte("INFO {\"server_id\":\"test\",\"max_payload\":1048576}\r\n"))
+		reader := bufio.NewReader(conn)
+		pinged := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			if line == "PING" {
+				// the client sends one after CONNECT, and waits for the answer to be connected
+				_, _ = conn.Write([]byte("PONG\r\n"))
+				if !pinged {
+					// server pings have to be answered to keep the connection
+					_, _ = conn.Write([]byte("PING\r\n"))
+					pinged = true
+				}
+				continue
+			}
+			lines <- line
+		}
+	}()
+
+	publisher, err := NewNATSEventPublisher("nats://user:pass@" + l.Addr().String())
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	require.NoError(t, publisher.Publish(context.Background(), "livekit.events", []byte("hello")))
+
+	next := func() string {
+		select {
+		case line := <-lines:
+			return line
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for NATS message")
+			return ""
+		}
+	}
+	connect := next()
+	require.True(t, strings.HasPrefix(connect, "CONNECT {"))
+	require.Contains(t, connect, `"user":"user"`)
+	require.Contains(t, connect, `"pass":"pass"`)
+
+	// PUB and PONG may arrive in either order
+	received := []string{next(), next(), next()}
+	require.Contains(t, received, "PUB livekit.events 5")
+	require.Contains(t, received, "hello")
+	require.Contains(t, received, "PONG")
+
+	require.Error(t, publisher.Publish(context.Background(), "invalid subject", nil))
+	require.NoError(t, publisher.Close())
+}
+
+func TestKafkaEventPublisher(t *testing.T) {
+	_, err := NewEventPublisher(&config.EventBusConfig{Kind: EventBusKafka}, nil)
+	require.Error(t, err)
+
+	// brokers are connected to once messages are written
+	publisher, err := NewEventPublisher(&config.EventBusConfig{Kind: EventBusKafka, KafkaBrokers: []string{"127.0.0.1:9092"}}, nil)
+	require.NoError(t, err)
+	require.NoError(t, publisher.Close())
+}
+
+func TestEventBusClose(t *testing.T) {
+	publisher := &recordingPublisher{}
+	bus := newEventBus(&config.EventBusConfig{EventsTopic: "livekit.events"}, publisher)
+	for i := 0; i < 10; i++ {
+		bus.enqueue(bus.eventsTopic, &livekit.AnalyticsEvents{})
+	}
+
+	// queued messages are published before it returns
+	bus.close()
+	require.Equal(t, 10, publisher.count())
+
+	bus.enqueue(bus.eventsTopic, &livekit.AnalyticsEvents{})
+	bus.close()
+	require.Equal(t, 10, publisher.count())
+}
+
+func TestAnalyticsEventBus(t *testing.T) {
+	conf, err := config.NewConfig("", nil)
+	require.NoError(t, err)
+	publisher := &recordingPublisher{}
+	analytics := NewAnalyticsService(conf, &livekit.Node{Id: "node"}, publisher)
+
+	analytics.SendEvent(context.Background(), &livekit.AnalyticsEvent{
+		Type:    livekit.AnalyticsEventType_ROOM_CREATED,
+		RoomSid: "RM_1",
+	})
+	analytics.SendStats(context.Background(), []*livekit.AnalyticsStat{{RoomId: "RM_1", TotalBytes: 100}})
+
+	require.Eventually(t, func() bool {
+		return publisher.count() == 2
+	}, time.Second, 10*time.Millisecond)
+
+	topic, data := publisher.get(0)
+	require.Equal(t, "livekit.events", topic)
+	events := &livekit.AnalyticsEvents{}
+	require.NoError(t, protojson.Unmarshal(data, events))
+	require.Equal(t, "RM_1", events.Events[0].RoomSid)
+
+	topic, data = publisher.get(1)
+	require.Equal(t, "livekit.stats", topic)
+	stats := &livekit.AnalyticsStats{}
+	require.NoError(t, protojson.Unmarshal(data, stats))
+	require.Equal(t, "node", stats.Stats[0].Node)
+	require.Equal(t, uint64(100), stats.Stats[0].TotalBytes)
+}
+
+type recordingPublisher struct {
+	lock     sync.Mutex
+	topics   []string
+	messages [][]byte
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, topic string, data []byte) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.topics = append(p.topics, topic)
+	p.messages = append(p.messages, data)
+	return nil
+}
+
+func (p *recordingPublisher) Close() error {
+	return nil
+}
+
+func (p *recordingPublisher) count() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.messages)
+}
+
+func (p *recordingPublisher) get(i int) (string, []byte) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.topics[i], p.messages[i]
+}
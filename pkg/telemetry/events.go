@@ -12,8 +12,25 @@ import (
 	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
+// webhook events the protocol doesn't define
+const (
+	EventTranscriptionReceived = "transcription_received"
+	EventNodeCapacityChanged   = "node_capacity_changed"
+	EventTrackStalled          = "track_stalled"
+	EventTrackResumed          = "track_resumed"
+	EventTrackInactive         = "track_inactive"
+	EventPendingTrackExpired   = "pending_track_expired"
+	EventTrackMetadataUpdated  = "track_metadata_updated"
+	EventDominantSpeaker       = "dominant_speaker_changed"
+	EventSpeakerStats          = "speaker_stats"
+	// participants of the waiting room, they have no sid until they join
+	EventParticipantPending     = "participant_pending"
+	EventParticipantDenied      = "participant_denied"
+	EventParticipantPendingLeft = "participant_pending_left"
+)
+
 func (t *telemetryService) RoomStarted(ctx context.Context, room *livekit.Room) {
-	prometheus.RoomStarted()
+	prometheus.RoomStarted(room.Name)
 
 	t.notifyEvent(ctx, &livekit.WebhookEvent{
 		Event: webhook.EventRoomStarted,
@@ -28,7 +45,7 @@ func (t *telemetryService) RoomStarted(ctx context.Context, room *livekit.Room)
 }
 
 func (t *telemetryService) RoomEnded(ctx context.Context, room *livekit.Room) {
-	prometheus.RoomEnded(time.Unix(room.CreationTime, 0))
+	prometheus.RoomEnded(room.Name, time.Unix(room.CreationTime, 0))
 
 	t.notifyEvent(ctx, &livekit.WebhookEvent{
 		Event: webhook.EventRoomFinished,
@@ -47,8 +64,9 @@ func (t *telemetryService) ParticipantJoined(ctx context.Context, room *livekit.
 	t.workers[participant.Sid] = newStatsWorker(ctx, t, room.Sid, room.Name, participant.Sid)
 	t.Unlock()
 
-	prometheus.AddParticipant()
+	prometheus.AddParticipant(room.Name, participant.Sid)
 
+	participant = t.masker.maskParticipant(participant)
 	t.notifyEvent(ctx, &livekit.WebhookEvent{
 		Event:       webhook.EventParticipantJoined,
 		Room:        room,
@@ -71,8 +89,9 @@ func (t *telemetryService) ParticipantLeft(ctx context.Context, room *livekit.Ro
 	}
 	t.Unlock()
 
-	prometheus.SubParticipant()
+	prometheus.SubParticipant(room.Name, participant.Sid)
 
+	participant = t.masker.maskParticipant(participant)
 	t.notifyEvent(ctx, &livekit.WebhookEvent{
 		Event:       webhook.EventParticipantLeft,
 		Room:        room,
@@ -87,34 +106,62 @@ func (t *telemetryService) ParticipantLeft(ctx context.Context, room *livekit.Ro
 	})
 }
 
-func (t *telemetryService) TrackPublished(ctx context.Context, participantID string, track *livekit.TrackInfo) {
-	prometheus.AddPublishedTrack(track.Type.String())
+func (t *telemetryService) ParticipantPending(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo) {
+	t.notifyPendingParticipant(ctx, EventParticipantPending, room, participant)
+}
+
+func (t *telemetryService) ParticipantDenied(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo) {
+	t.notifyPendingParticipant(ctx, EventParticipantDenied, room, participant)
+}
+
+func (t *telemetryService) ParticipantPendingLeft(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo) {
+	t.notifyPendingParticipant(ctx, EventParticipantPendingLeft, room, participant)
+}
+
+func (t *telemetryService) notifyPendingParticipant(ctx context.Context, name string, room *livekit.Room, participant *livekit.ParticipantInfo) {
+	t.notifyEvent(ctx, &livekit.WebhookEvent{
+		Event:       name,
+		Room:        room,
+		Participant: t.masker.maskParticipant(participant),
+	})
+}
+
+func (t *telemetryService) TrackPublished(ctx context.Context, participantID string, track *livekit.TrackInfo, mime string) {
+	room := trackRoom{}
+	t.Lock()
+	if w := t.workers[participantID]; w != nil {
+		room = trackRoom{roomID: w.roomID, roomName: w.roomName}
+	}
+	t.trackRooms[track.Sid] = room
+	t.Unlock()
+
+	prometheus.AddPublishedTrack(room.roomName, track.Type.String(), mime)
 
 	t.analytics.SendEvent(ctx, &livekit.AnalyticsEvent{
 		Type:          livekit.AnalyticsEventType_TRACK_PUBLISHED,
 		Timestamp:     timestamppb.Now(),
-		RoomSid:       t.getRoomID(participantID),
+		RoomSid:       room.roomID,
 		ParticipantId: participantID,
 		Track:         track,
 	})
 }
 
-func (t *telemetryService) TrackUnpublished(ctx context.Context, participantID string, track *livekit.TrackInfo, ssrc uint32) {
-	roomID := ""
-	t.RLock()
+func (t *telemetryService) TrackUnpublished(ctx context.Context, participantID string, track *livekit.TrackInfo, mime string, ssrc uint32) {
+	t.Lock()
 	w := t.workers[participantID]
-	t.RUnlock()
+	room := t.trackRooms[track.Sid]
+	delete(t.trackRooms, track.Sid)
+	t.Unlock()
 	if w != nil {
-		roomID = w.roomID
 		w.RemoveBuffer(ssrc)
 	}
 
-	prometheus.SubPublishedTrack(track.Type.String())
+	prometheus.SubPublishedTrack(room.roomName, track.Type.String(), mime)
 
 	t.analytics.SendEvent(ctx, &livekit.AnalyticsEvent{
 		Type:          livekit.AnalyticsEventType_TRACK_UNPUBLISHED,
 		Timestamp:     timestamppb.Now(),
-		RoomSid:       roomID,
+		RoomSid:       room.roomID,
 		ParticipantId: participantID,
 		TrackId:       track.Sid,
 	})
@@ -144,6 +191,32 @@ func (t *telemetryService) TrackUnsubscribed(ctx context.Context, participantID
 	})
 }
 
+func (t *telemetryService) TrackSubscriptionChanged(_ context.Context, event *SubscriptionEvent) {
+	if t.audit == nil {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	t.RLock()
+	w := t.workers[event.SubscriberID]
+	t.RUnlock()
+	if w != nil {
+		event.RoomID = w.roomID
+		event.RoomName = w.roomName
+	}
+	t.audit.Record(event)
+}
+
+func (t *telemetryService) TrackFirstFrame(ctx context.Context, kind livekit.TrackType, latency time.Duration, event *SubscriptionEvent) {
+	prometheus.RecordFirstFrameLatency(kind.String(), latency)
+
+	event.Type = SubscriptionEventFirstFrame
+	event.LatencyMs = latency.Milliseconds()
+	t.TrackSubscriptionChanged(ctx, event)
+}
+
 func (t *telemetryService) RecordingStarted(ctx context.Context, recordingID string, req *livekit.StartRecordingRequest) {
 	t.notifyEvent(ctx, &livekit.WebhookEvent{
 		Event: webhook.EventRecordingStarted,
@@ -173,6 +246,202 @@ func (t *telemetryService) RecordingEnded(ctx context.Context, res *livekit.Reco
 	})
 }
 
+// TranscriptionEvent is sent to webhooks when a participant's speech was transcribed. The protocol
+// has no webhook event for transcripts, it's sent as JSON
+type TranscriptionEvent struct {
+	Event               string `json:"event"`
+	RoomSid             string `json:"roomSid"`
+	RoomName            string `json:"roomName"`
+	ParticipantSid      string `json:"participantSid"`
+	ParticipantIdentity string `json:"participantIdentity"`
+	TrackSid            string `json:"trackSid"`
+	Text                string `json:"text"`
+	Language            string `json:"language,omitempty"`
+	// offsets of the speech from the start of the track, in milliseconds
+	StartTime int64 `json:"startTime"`
+	EndTime   int64 `json:"endTime"`
+}
+
+func (t *telemetryService) TranscriptionReceived(ctx context.Context, event *TranscriptionEvent) {
+	masked := *event
+	masked.Event = EventTranscriptionReceived
+	masked.ParticipantIdentity = t.masker.Mask(event.ParticipantIdentity)
+	t.notify(ctx, masked.Event, &masked)
+}
+
+// TrackStallEvent is sent to webhooks when an unmuted track stops receiving media while its
+// publisher is connected, when media resumes, and when the track is unpublished for being stalled
+// too long. It's sent as JSON
+type TrackStallEvent struct {
+	Event               string `json:"event"`
+	RoomSid             string `json:"roomSid"`
+	RoomName            string `json:"roomName"`
+	ParticipantSid      string `json:"participantSid"`
+	ParticipantIdentity string `json:"participantIdentity"`
+	TrackSid            string `json:"trackSid"`
+	// unix time of the last media received before the stall
+	StalledAt int64 `json:"stalledAt"`
+	// how long the track was stalled for, in milliseconds, once it resumed or was unpublished
+	Duration int64 `json:"duration,omitempty"`
+}
+
+func (t *telemetryService) TrackStalled(ctx context.Context, event *TrackStallEvent) {
+	t.notifyTrackStall(ctx, EventTrackStalled, event)
+}
+
+func (t *telemetryService) TrackResumed(ctx context.Context, event *TrackStallEvent) {
+	t.notifyTrackStall(ctx, EventTrackResumed, event)
+}
+
+func (t *telemetryService) TrackInactive(ctx context.Context, event *TrackStallEvent) {
+	t.notifyTrackStall(ctx, EventTrackInactive, event)
+}
+
+func (t *telemetryService) notifyTrackStall(ctx context.Context, name string, event *TrackStallEvent) {
+	prometheus.TrackStallChanged(name == EventTrackStalled)
+
+	masked := *event
+	masked.Event = name
+	masked.ParticipantIdentity = t.masker.Mask(event.ParticipantIdentity)
+	t.notify(ctx, name, &masked)
+}
+
+// PendingTrackExpiredEvent is sent to webhooks when a track a participant added is dropped,
+// because its media never arrived. It's sent as JSON
+type PendingTrackExpiredEvent struct {
+	Event               string `json:"event"`
+	RoomSid             string `json:"roomSid"`
+	RoomName            string `json:"roomName"`
+	ParticipantSid      string `json:"participantSid"`
+	ParticipantIdentity string `json:"participantIdentity"`
+	TrackSid            string `json:"trackSid"`
+	TrackType           string `json:"trackType"`
+	TrackSource         string `json:"trackSource"`
+	// how long the track was pending for, in milliseconds
+	PendingFor int64 `json:"pendingFor"`
+}
+
+func (t *telemetryService) PendingTrackExpired(ctx context.Context, event *PendingTrackExpiredEvent) {
+	masked := *event
+	masked.Event = EventPendingTrackExpired
+	masked.RoomSid = t.getRoomID(event.ParticipantSid)
+	masked.RoomName = t.getRoomName(event.ParticipantSid)
+	masked.ParticipantIdentity = t.masker.Mask(event.ParticipantIdentity)
+	t.notify(ctx, masked.Event, &masked)
+}
+
+// TrackMetadataEvent is sent to webhooks when a publisher updates the metadata of one of its
+// tracks. It's sent as JSON
+type TrackMetadataEvent struct {
+	Event               string `json:"event"`
+	RoomSid             string `json:"roomSid"`
+	RoomName            string `json:"roomName"`
+	ParticipantSid      string `json:"participantSid"`
+	ParticipantIdentity string `json:"participantIdentity"`
+	TrackSid            string `json:"trackSid"`
+	TrackType           string `json:"trackType"`
+	TrackSource         string `json:"trackSource"`
+	// empty when it was removed
+	Metadata string `json:"metadata"`
+}
+
+func (t *telemetryService) TrackMetadataUpdated(ctx context.Context, event *TrackMetadataEvent) {
+	masked := *event
+	masked.Event = EventTrackMetadataUpdated
+	masked.RoomSid = t.getRoomID(event.ParticipantSid)
+	masked.RoomName = t.getRoomName(event.ParticipantSid)
+	masked.ParticipantIdentity = t.masker.Mask(event.ParticipantIdentity)
+	t.notify(ctx, masked.Event, &masked)
+}
+
+// severities of a CapacityReport
+const (
+	CapacitySeverityNone = "none"
+	// the node is over capacity, subscribers still receive the quality they asked for
+	CapacitySeverityWarning = "warning"
+	// the node is over capacity, and quality is degraded across the node
+	CapacitySeverityCritical = "critical"
+)
+
+// CapacityReport is sent to webhooks when the node's capacity severity changes, so overloaded
+// nodes can be told apart from network issues of individual participants. It's sent as JSON
+type CapacityReport struct {
+	Event    string `json:"event"`
+	NodeID   string `json:"nodeId"`
+	Region   string `json:"region,omitempty"`
+	Severity string `json:"severity"`
+	// limits the node is over: cpu, rooms, participants, tracks, bandwidth or forwarded_bitrate
+	Reasons []string `json:"reasons,omitempty"`
+	// load average per cpu
+	CPULoad  float32 `json:"cpuLoad"`
+	NumRooms int32   `json:"numRooms"`
+	// rooms with at least one degraded subscription
+	AffectedRooms int `json:"affectedRooms"`
+	// subscribed video tracks forwarded below the quality subscribers asked for
+	DegradedTracks int   `json:"degradedTracks"`
+	VideoTracks    int   `json:"videoTracks"`
+	Time           int64 `json:"time"`
+}
+
+func (t *telemetryService) NodeCapacityChanged(ctx context.Context, report *CapacityReport) {
+	report.Event = EventNodeCapacityChanged
+	t.notify(ctx, report.Event, report)
+}
+
+// DominantSpeakerEvent is sent to webhooks when another participant becomes the dominant speaker
+// of a room, the one that spoke the most over the last seconds. It's sent as JSON
+type DominantSpeakerEvent struct {
+	Event               string `json:"event"`
+	RoomSid             string `json:"roomSid"`
+	RoomName            string `json:"roomName"`
+	ParticipantSid      string `json:"participantSid"`
+	ParticipantIdentity string `json:"participantIdentity"`
+	// the dominant speaker until now, empty for the first one
+	PreviousParticipantSid string `json:"previousParticipantSid,omitempty"`
+	Time                   int64  `json:"time"`
+}
+
+func (t *telemetryService) DominantSpeakerChanged(ctx context.Context, event *DominantSpeakerEvent) {
+	masked := *event
+	masked.Event = EventDominantSpeaker
+	masked.ParticipantIdentity = t.masker.Mask(event.ParticipantIdentity)
+	t.notify(ctx, masked.Event, &masked)
+}
+
+// SpeakerStatsEvent is sent to webhooks when a room closed, with the talk time of each participant
+// that spoke in it. It's sent as JSON
+type SpeakerStatsEvent struct {
+	Event           string `json:"event"`
+	RoomSid         string `json:"roomSid"`
+	RoomName        string `json:"roomName"`
+	DominantSpeaker string `json:"dominantSpeaker,omitempty"`
+	// most talk time first
+	Speakers []*SpeakerTalkTime `json:"speakers"`
+}
+
+// SpeakerTalkTime is how much a participant spoke in a room
+type SpeakerTalkTime struct {
+	ParticipantSid      string `json:"participantSid"`
+	ParticipantIdentity string `json:"participantIdentity"`
+	// in milliseconds
+	TalkTime int64 `json:"talkTime"`
+	// share of the room's talk time, 0 to 1
+	TalkShare   float64 `json:"talkShare"`
+	NumSegments int     `json:"numSegments"`
+}
+
+func (t *telemetryService) SpeakerStatsReported(ctx context.Context, event *SpeakerStatsEvent) {
+	masked := *event
+	masked.Event = EventSpeakerStats
+	masked.Speakers = make([]*SpeakerTalkTime, 0, len(event.Speakers))
+	for _, speaker := range event.Speakers {
+		s := *speaker
+		s.ParticipantIdentity = t.masker.Mask(speaker.ParticipantIdentity)
+		masked.Speakers = append(masked.Speakers, &s)
+	}
+	t.notify(ctx, masked.Event, &masked)
+}
+
 func (t *telemetryService) getRoomID(participantID string) string {
 	t.RLock()
 	w := t.workers[participantID]
@@ -183,14 +452,28 @@ func (t *telemetryService) getRoomID(participantID string) string {
 	return ""
 }
 
+func (t *telemetryService) getRoomName(participantID string) string {
+	t.RLock()
+	w := t.workers[participantID]
+	t.RUnlock()
+	if w != nil {
+		return w.roomName
+	}
+	return ""
+}
+
 func (t *telemetryService) notifyEvent(ctx context.Context, event *livekit.WebhookEvent) {
+	t.notify(ctx, event.Event, event)
+}
+
+func (t *telemetryService) notify(ctx context.Context, name string, payload interface{}) {
 	if t.notifier == nil {
 		return
 	}
 
 	t.webhookPool.Submit(func() {
-		if err := t.notifier.Notify(ctx, event); err != nil {
-			logger.Warnw("failed to notify webhook", err, "event", event.Event)
+		if err := t.notifier.Notify(ctx, payload); err != nil {
+			logger.Warnw("failed to notify webhook", err, "event", name)
 		}
 	})
 }
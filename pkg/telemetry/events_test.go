@@ -0,0 +1,54 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	livekit "github.com/livekit/protocol/proto"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+func TestRoomTrackPublishedGauge(t *testing.T) {
+	prometheus.SetLabelLimits(10, 10)
+	t.Cleanup(func() {
+		prometheus.SetLabelLimits(0, 0)
+	})
+
+	ts := NewTelemetryService(nil, &analyticsService{}, nil, nil)
+	ctx := context.Background()
+	room := &livekit.Room{Sid: "RM_gauge", Name: "gauge-room"}
+	participant := &livekit.ParticipantInfo{Sid: "PA_gauge", Identity: "alice"}
+	track := &livekit.TrackInfo{Sid: "TR_gauge", Type: livekit.TrackType_AUDIO}
+
+	ts.RoomStarted(ctx, room)
+	defer ts.RoomEnded(ctx, room)
+	ts.ParticipantJoined(ctx, room, participant)
+	ts.TrackPublished(ctx, participant.Sid, track, "audio/opus")
+	require.Equal(t, float64(1), roomTrackPublished(t, room.Name))
+
+	// tracks of a participant that left are closed after it was removed
+	ts.ParticipantLeft(ctx, room, participant)
+	ts.TrackUnpublished(ctx, participant.Sid, track, "audio/opus", 0)
+	require.Equal(t, float64(0), roomTrackPublished(t, room.Name))
+}
+
+func roomTrackPublished(t *testing.T, room string) float64 {
+	families, err := prom.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "livekit_room_track_published" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "room" && label.GetValue() == room {
+					return m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,53 @@
+package telemetry
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	livekit "github.com/livekit/protocol/proto"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	maskedIdentityPrefix = "masked_"
+	// bytes of the HMAC kept in masked identities
+	maskedIdentityLength = 16
+)
+
+// IdentityMasker replaces participant identities with pseudonyms, so that analytics can tell
+// participants apart without learning who they are. The same identity always gets the same
+// pseudonym for a salt. A nil IdentityMasker leaves identities as they are
+type IdentityMasker struct {
+	salt []byte
+}
+
+// NewIdentityMasker returns nil when identity masking is disabled
+func NewIdentityMasker(conf *config.IdentityMaskingConfig) *IdentityMasker {
+	if !conf.Enabled {
+		return nil
+	}
+	return &IdentityMasker{salt: []byte(conf.Salt)}
+}
+
+// Mask returns the pseudonym of identity
+func (m *IdentityMasker) Mask(identity string) string {
+	if m == nil || identity == "" {
+		return identity
+	}
+	mac := hmac.New(sha256.New, m.salt)
+	mac.Write([]byte(identity))
+	return maskedIdentityPrefix + hex.EncodeToString(mac.Sum(nil)[:maskedIdentityLength])
+}
+
+// maskParticipant returns a copy of participant with its identity masked
+func (m *IdentityMasker) maskParticipant(participant *livekit.ParticipantInfo) *livekit.ParticipantInfo {
+	if m == nil || participant == nil {
+		return participant
+	}
+	masked := proto.Clone(participant).(*livekit.ParticipantInfo)
+	masked.Identity = m.Mask(participant.Identity)
+	return masked
+}
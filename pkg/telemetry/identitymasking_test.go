@@ -0,0 +1,56 @@
+package telemetry
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestIdentityMasker(t *testing.T) {
+	require.Nil(t, NewIdentityMasker(&config.IdentityMaskingConfig{Salt: "salt"}))
+	require.Equal(t, "alice", (*IdentityMasker)(nil).Mask("alice"))
+
+	masker := NewIdentityMasker(&config.IdentityMaskingConfig{Enabled: true, Salt: "salt"})
+	masked := masker.Mask("alice")
+	require.True(t, strings.HasPrefix(masked, maskedIdentityPrefix))
+	require.NotContains(t, masked, "alice")
+	require.Equal(t, masked, masker.Mask("alice"))
+	require.NotEqual(t, masked, masker.Mask("bob"))
+
+	other := NewIdentityMasker(&config.IdentityMaskingConfig{Enabled: true, Salt: "other tenant"})
+	require.NotEqual(t, masked, other.Mask("alice"))
+}
+
+func TestMaskedWebhooks(t *testing.T) {
+	masker := NewIdentityMasker(&config.IdentityMaskingConfig{Enabled: true, Salt: "salt"})
+	notifier := &channelNotifier{events: make(chan *livekit.WebhookEvent, 1)}
+	ts := NewTelemetryService(notifier, &analyticsService{}, nil, masker)
+
+	participant := &livekit.ParticipantInfo{Sid: "PA_1", Identity: "alice"}
+	ts.ParticipantJoined(context.Background(), &livekit.Room{Sid: "RM_1", Name: "myroom"}, participant)
+
+	select {
+	case event := <-notifier.events:
+		require.Equal(t, masker.Mask("alice"), event.Participant.Identity)
+		require.Equal(t, "PA_1", event.Participant.Sid)
+	case <-time.After(time.Second):
+		t.Fatal("webhook wasn't sent")
+	}
+	// the participant itself keeps its identity
+	require.Equal(t, "alice", participant.Identity)
+}
+
+type channelNotifier struct {
+	events chan *livekit.WebhookEvent
+}
+
+func (n *channelNotifier) Notify(_ context.Context, payload interface{}) error {
+	n.events <- payload.(*livekit.WebhookEvent)
+	return nil
+}
@@ -0,0 +1,83 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const jsonPostTimeout = 10 * time.Second
+
+// jsonLinesFile appends values to a file, one JSON object per line
+type jsonLinesFile struct {
+	lock sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+func openJSONLinesFile(path string) (*jsonLinesFile, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonLinesFile{
+		file: f,
+		enc:  json.NewEncoder(f),
+	}, nil
+}
+
+func (f *jsonLinesFile) write(v interface{}) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.enc.Encode(v)
+}
+
+func (f *jsonLinesFile) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.file.Close()
+}
+
+// jsonPoster POSTs values as JSON to a URL
+type jsonPoster struct {
+	url    string
+	client *http.Client
+}
+
+func newJSONPoster(url string) *jsonPoster {
+	return &jsonPoster{
+		url:    url,
+		client: &http.Client{Timeout: jsonPostTimeout},
+	}
+}
+
+func (p *jsonPoster) post(ctx context.Context, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", p.url, res.Status)
+	}
+	return nil
+}
+
+func (p *jsonPoster) Close() error {
+	return nil
+}
@@ -0,0 +1,62 @@
+package prometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// subscriber congestion control metrics are labeled by algorithm, so that algorithms running
+// side by side can be compared
+var (
+	promCongestionControlStateSeconds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "congestion_control",
+		Name:      "state_seconds",
+	}, []string{"algorithm", "state"})
+	promCongestionControlBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "congestion_control",
+		Name:      "bytes",
+	}, []string{"algorithm"})
+	promCongestionControlCapacity = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "congestion_control",
+		Name:      "capacity_bps",
+		Buckets:   prometheus.ExponentialBuckets(100_000, 2, 8),
+	}, []string{"algorithm"})
+	promCongestionControlLoss = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "congestion_control",
+		Name:      "loss_percentage",
+		Buckets:   []float64{0.5, 1, 2, 5, 10, 20},
+	}, []string{"algorithm"})
+	promCongestionControlRTT = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "congestion_control",
+		Name:      "rtt_ms",
+		Buckets:   []float64{25, 50, 100, 200, 400, 800},
+	}, []string{"algorithm"})
+)
+
+func initCongestionControlStats() {
+	prometheus.MustRegister(promCongestionControlStateSeconds)
+	prometheus.MustRegister(promCongestionControlBytes)
+	prometheus.MustRegister(promCongestionControlCapacity)
+	prometheus.MustRegister(promCongestionControlLoss)
+	prometheus.MustRegister(promCongestionControlRTT)
+}
+
+// RecordCongestionControl records a sampling interval of a subscriber connection.
+// capacityBps is 0 while the channel has no known limit, and rttMs 0 when unknown
+func RecordCongestionControl(algorithm, state string, interval time.Duration, capacityBps, bytesSent int64, lossPercentage float32, rttMs uint32) {
+	promCongestionControlStateSeconds.WithLabelValues(algorithm, state).Add(interval.Seconds())
+	promCongestionControlBytes.WithLabelValues(algorithm).Add(float64(bytesSent))
+	promCongestionControlLoss.WithLabelValues(algorithm).Observe(float64(lossPercentage))
+	if capacityBps > 0 {
+		promCongestionControlCapacity.WithLabelValues(algorithm).Observe(float64(capacityBps))
+	}
+	if rttMs > 0 {
+		promCongestionControlRTT.WithLabelValues(algorithm).Observe(float64(rttMs))
+	}
+}
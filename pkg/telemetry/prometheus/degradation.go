@@ -0,0 +1,35 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// 1 while the node degrades the video it forwards, its CPU is overloaded
+	promNodeDegraded = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "node",
+		Name:      "degraded",
+	})
+	// times the node started degrading
+	promNodeDegradations = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "node",
+		Name:      "degradations_total",
+	})
+)
+
+func initDegradationStats() {
+	prometheus.MustRegister(promNodeDegraded)
+	prometheus.MustRegister(promNodeDegradations)
+}
+
+// SetNodeDegraded records whether the node is degrading the video it forwards
+func SetNodeDegraded(degraded bool) {
+	if degraded {
+		promNodeDegraded.Set(1)
+		promNodeDegradations.Inc()
+	} else {
+		promNodeDegraded.Set(0)
+	}
+}
@@ -0,0 +1,30 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	MDNSCandidateResolved   = "resolved"
+	MDNSCandidateUnresolved = "unresolved"
+	MDNSCandidateDropped    = "dropped"
+)
+
+var (
+	// mDNS candidates of clients by result: resolved, unresolved when the client didn't answer, or
+	// dropped when resolution is disabled or unavailable on the node
+	promMDNSCandidates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "ice",
+		Name:      "mdns_candidates_total",
+	}, []string{"result"})
+)
+
+func initICEStats() {
+	prometheus.MustRegister(promMDNSCandidates)
+}
+
+// IncrementMDNSCandidate counts a .local candidate received from a client
+func IncrementMDNSCandidate(result string) {
+	promMDNSCandidates.WithLabelValues(result).Inc()
+}
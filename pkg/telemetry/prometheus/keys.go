@@ -0,0 +1,27 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// results of periodic API key reloads
+const (
+	KeyRotationRotated = "rotated"
+	KeyRotationFailed  = "failed"
+)
+
+// API key reloads that changed the keys, or failed to load them
+var promKeyRotations = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: livekitNamespace,
+	Subsystem: "api_keys",
+	Name:      "rotations_total",
+}, []string{"result"})
+
+func initKeyStats() {
+	prometheus.MustRegister(promKeyRotations)
+}
+
+// IncrementKeyRotation counts a reload of the API keys with the given result
+func IncrementKeyRotation(result string) {
+	promKeyRotations.WithLabelValues(result).Inc()
+}
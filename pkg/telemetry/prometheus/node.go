@@ -36,6 +36,12 @@ func init() {
 
 	initPacketStats()
 	initRoomStats()
+	initRoomLabelStats()
+	initCongestionControlStats()
+	initICEStats()
+	initDegradationStats()
+	initSignalStats()
+	initKeyStats()
 }
 
 func UpdateCurrentNodeStats(nodeStats *livekit.NodeStats) error {
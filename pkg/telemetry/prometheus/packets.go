@@ -11,6 +11,11 @@ type Direction string
 const (
 	Incoming Direction = "incoming"
 	Outgoing Direction = "outgoing"
+
+	// reasons user data packets are dropped
+	DataDropReasonRateLimited = "rate_limited"
+	DataDropReasonBufferFull  = "buffer_full"
+	DataDropReasonCongested   = "congested"
 )
 
 var (
@@ -47,6 +52,59 @@ var (
 		Subsystem: "fir",
 		Name:      "total",
 	}, promPacketLabels)
+	// keyframe requests of subscribers that were coalesced into another request to the publisher
+	promPliSuppressed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "pli",
+		Name:      "suppressed_total",
+	}, []string{"quality"})
+	promPacketRecovered = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "packet",
+		Name:      "recovered_total",
+	})
+	// packets of published tracks put back in order, by the number of packets that arrived before them
+	promPacketReorderDepth = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "packet",
+		Name:      "reorder_depth",
+		Buckets:   []float64{1, 2, 4, 8, 16, 32},
+	})
+	// packets of published tracks that arrived after the reorder window, forwarded out of order
+	promPacketLate = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "packet",
+		Name:      "late_total",
+	})
+	// packets dropped because the forwarding queue of a subscriber was full
+	promForwardDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "packet",
+		Name:      "forward_dropped_total",
+	})
+	promDataPacketDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "data_packet",
+		Name:      "dropped_total",
+	}, []string{"kind", "reason"})
+	// RTCP feedback for publishers dropped because their queue was full
+	promRTCPDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "rtcp",
+		Name:      "dropped_total",
+	}, []string{"type"})
+	// data packets sent over the signal connection, to participants without working data channels
+	promDataPacketOverSignal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "data_packet",
+		Name:      "signal_total",
+	}, []string{"kind"})
+	// participants whose data moved off the data channels they were sent on, by the path it moved to
+	promDataPathFallback = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "data_packet",
+		Name:      "path_fallbacks_total",
+	}, []string{"path"})
 )
 
 func initPacketStats() {
@@ -55,6 +113,15 @@ func initPacketStats() {
 	prometheus.MustRegister(promNackTotal)
 	prometheus.MustRegister(promPliTotal)
 	prometheus.MustRegister(promFirTotal)
+	prometheus.MustRegister(promPliSuppressed)
+	prometheus.MustRegister(promPacketRecovered)
+	prometheus.MustRegister(promPacketReorderDepth)
+	prometheus.MustRegister(promPacketLate)
+	prometheus.MustRegister(promForwardDropped)
+	prometheus.MustRegister(promDataPacketDropped)
+	prometheus.MustRegister(promDataPacketOverSignal)
+	prometheus.MustRegister(promDataPathFallback)
+	prometheus.MustRegister(promRTCPDropped)
 }
 
 func IncrementPackets(direction Direction, count uint64) {
@@ -75,15 +142,76 @@ func IncrementBytes(direction Direction, count uint64) {
 	}
 }
 
-func IncrementRTCP(direction Direction, nack, pli, fir int32) {
+func IncrementRTCP(room string, direction Direction, nack, pli, fir int32) {
+	roomLabel := labels.roomLabel(room)
 	if nack > 0 {
 		promNackTotal.WithLabelValues(string(direction)).Add(float64(nack))
 		atomic.AddUint64(&atomicNackTotal, uint64(nack))
+		if roomLabel != "" {
+			promRoomRTCPTotal.WithLabelValues(roomLabel, string(direction), "nack").Add(float64(nack))
+		}
 	}
 	if pli > 0 {
 		promPliTotal.WithLabelValues(string(direction)).Add(float64(pli))
+		if roomLabel != "" {
+			promRoomRTCPTotal.WithLabelValues(roomLabel, string(direction), "pli").Add(float64(pli))
+		}
 	}
 	if fir > 0 {
 		promFirTotal.WithLabelValues(string(direction)).Add(float64(fir))
+		if roomLabel != "" {
+			promRoomRTCPTotal.WithLabelValues(roomLabel, string(direction), "fir").Add(float64(fir))
+		}
 	}
 }
+
+// IncrementKeyframeRequestSuppressed counts a PLI or FIR of a subscriber that wasn't sent to the
+// publisher, because a request for the same layer was already going to be sent
+func IncrementKeyframeRequestSuppressed(quality string) {
+	promPliSuppressed.WithLabelValues(quality).Inc()
+}
+
+// IncrementPacketRecovered counts a lost packet that was retransmitted by the publisher. Compared
+// to incoming PLIs, it shows how much loss is repaired without requesting keyframes
+func IncrementPacketRecovered() {
+	promPacketRecovered.Inc()
+}
+
+// ObservePacketReorderDepth records a packet that arrived after depth later ones, and was put back in
+// order before it was forwarded
+func ObservePacketReorderDepth(depth int) {
+	promPacketReorderDepth.Observe(float64(depth))
+}
+
+// IncrementPacketLate counts a packet that arrived too long after later ones to be put back in order
+func IncrementPacketLate() {
+	promPacketLate.Inc()
+}
+
+// IncrementForwardDropped counts a packet that wasn't forwarded to a subscriber, because too many
+// packets were already queued for it
+func IncrementForwardDropped() {
+	promForwardDropped.Inc()
+}
+
+func IncrementDataPacketDropped(kind string, reason string) {
+	promDataPacketDropped.WithLabelValues(kind, reason).Inc()
+}
+
+// IncrementRTCPDropped counts an RTCP packet that wasn't written to a publisher, because too much
+// feedback was already queued for it. Keyframe requests aren't dropped
+func IncrementRTCPDropped(packetType string) {
+	promRTCPDropped.WithLabelValues(packetType).Inc()
+}
+
+// IncrementDataPacketOverSignal counts a data packet sent to a participant over the signal
+// connection, it has no data channels or they failed
+func IncrementDataPacketOverSignal(kind string) {
+	promDataPacketOverSignal.WithLabelValues(kind).Inc()
+}
+
+// IncrementDataPathFallback counts a participant whose data packets are sent on path from now on,
+// publisher data channels or the signal connection, since the data channels before failed
+func IncrementDataPathFallback(path string) {
+	promDataPathFallback.WithLabelValues(path).Inc()
+}
@@ -0,0 +1,204 @@
+package prometheus
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// rooms beyond the configured limit are aggregated under this label
+const overflowRoomLabel = "_other"
+
+var (
+	rtcpTypes = []string{"nack", "pli", "fir"}
+
+	labels = newLabelRegistry()
+
+	promRoomParticipants = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "room",
+		Name:      "participants",
+	}, []string{"room"})
+	promRoomTrackPublished = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "room",
+		Name:      "track_published",
+	}, []string{"room", "kind", "codec"})
+	promRoomRTCPTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "room",
+		Name:      "rtcp_total",
+	}, []string{"room", "direction", "type"})
+	promParticipantBitrate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "participant",
+		Name:      "bitrate",
+	}, []string{"room", "participant", "direction"})
+	promParticipantPacketLost = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "participant",
+		Name:      "packet_lost",
+	}, []string{"room", "participant", "direction"})
+)
+
+func initRoomLabelStats() {
+	prometheus.MustRegister(promRoomParticipants)
+	prometheus.MustRegister(promRoomTrackPublished)
+	prometheus.MustRegister(promRoomRTCPTotal)
+	prometheus.MustRegister(promParticipantBitrate)
+	prometheus.MustRegister(promParticipantPacketLost)
+}
+
+// SetLabelLimits caps the number of distinct room and participant labels exported.
+// A limit of 0 disables the corresponding metrics
+func SetLabelLimits(roomLimit, participantLimit int) {
+	labels.setLimits(roomLimit, participantLimit)
+}
+
+type trackLabel struct {
+	kind  string
+	codec string
+}
+
+type roomLabel struct {
+	participants map[string]bool
+	tracks       map[trackLabel]int
+}
+
+// labelRegistry keeps track of the label values currently exported, so cardinality stays bounded
+// and series can be removed once a room or participant is gone
+type labelRegistry struct {
+	lock             sync.Mutex
+	roomLimit        int
+	participantLimit int
+	numParticipants  int
+	rooms            map[string]*roomLabel
+	// rooms exported under overflowRoomLabel
+	overflow map[string]bool
+}
+
+func newLabelRegistry() *labelRegistry {
+	return &labelRegistry{
+		rooms:    make(map[string]*roomLabel),
+		overflow: make(map[string]bool),
+	}
+}
+
+func (l *labelRegistry) setLimits(roomLimit, participantLimit int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.roomLimit = roomLimit
+	l.participantLimit = participantLimit
+}
+
+// addRoom starts exporting metrics for the room. Once the limit has been reached, the room is
+// aggregated under overflowRoomLabel
+func (l *labelRegistry) addRoom(room string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.roomLimit <= 0 {
+		return
+	}
+	if _, ok := l.rooms[room]; ok {
+		return
+	}
+	if len(l.rooms) >= l.roomLimit {
+		l.overflow[room] = true
+		return
+	}
+	l.rooms[room] = &roomLabel{
+		participants: make(map[string]bool),
+		tracks:       make(map[trackLabel]int),
+	}
+}
+
+// removeRoom stops exporting the room, deleting all of its series
+func (l *labelRegistry) removeRoom(room string) {
+	l.lock.Lock()
+	rl := l.rooms[room]
+	delete(l.rooms, room)
+	delete(l.overflow, room)
+	if rl != nil {
+		l.numParticipants -= len(rl.participants)
+	}
+	l.lock.Unlock()
+
+	if rl == nil {
+		return
+	}
+
+	promRoomParticipants.DeleteLabelValues(room)
+	for tl := range rl.tracks {
+		promRoomTrackPublished.DeleteLabelValues(room, tl.kind, tl.codec)
+	}
+	for _, direction := range []Direction{Incoming, Outgoing} {
+		for _, rtcpType := range rtcpTypes {
+			promRoomRTCPTotal.DeleteLabelValues(room, string(direction), rtcpType)
+		}
+		for participant := range rl.participants {
+			promParticipantBitrate.DeleteLabelValues(room, participant, string(direction))
+			promParticipantPacketLost.DeleteLabelValues(room, participant, string(direction))
+		}
+	}
+}
+
+// roomLabel returns the label to use for the room, or "" when the room isn't exported
+func (l *labelRegistry) roomLabel(room string) string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if _, ok := l.rooms[room]; ok {
+		return room
+	}
+	if l.overflow[room] {
+		return overflowRoomLabel
+	}
+	return ""
+}
+
+func (l *labelRegistry) addParticipant(room, participant string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	rl := l.rooms[room]
+	if rl == nil || rl.participants[participant] || l.numParticipants >= l.participantLimit {
+		return
+	}
+	rl.participants[participant] = true
+	l.numParticipants++
+}
+
+func (l *labelRegistry) removeParticipant(room, participant string) {
+	l.lock.Lock()
+	rl := l.rooms[room]
+	if rl == nil || !rl.participants[participant] {
+		l.lock.Unlock()
+		return
+	}
+	delete(rl.participants, participant)
+	l.numParticipants--
+	l.lock.Unlock()
+
+	for _, direction := range []Direction{Incoming, Outgoing} {
+		promParticipantBitrate.DeleteLabelValues(room, participant, string(direction))
+		promParticipantPacketLost.DeleteLabelValues(room, participant, string(direction))
+	}
+}
+
+func (l *labelRegistry) hasParticipant(room, participant string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	rl := l.rooms[room]
+	return rl != nil && rl.participants[participant]
+}
+
+func (l *labelRegistry) addTrack(room string, tl trackLabel) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if rl := l.rooms[room]; rl != nil {
+		rl.tracks[tl]++
+	}
+}
@@ -0,0 +1,52 @@
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelRegistry(t *testing.T) {
+	t.Run("rooms over the limit are aggregated", func(t *testing.T) {
+		l := newLabelRegistry()
+		l.setLimits(1, 10)
+		l.addRoom("room1")
+		l.addRoom("room2")
+
+		require.Equal(t, "room1", l.roomLabel("room1"))
+		require.Equal(t, overflowRoomLabel, l.roomLabel("room2"))
+		require.Equal(t, "", l.roomLabel("unknown"))
+
+		l.removeRoom("room1")
+		require.Equal(t, "", l.roomLabel("room1"))
+		l.addRoom("room3")
+		require.Equal(t, "room3", l.roomLabel("room3"))
+	})
+
+	t.Run("disabled when limit is zero", func(t *testing.T) {
+		l := newLabelRegistry()
+		l.addRoom("room1")
+		require.Equal(t, "", l.roomLabel("room1"))
+	})
+
+	t.Run("participants over the limit are not exported", func(t *testing.T) {
+		l := newLabelRegistry()
+		l.setLimits(10, 1)
+		l.addRoom("room1")
+		l.addParticipant("room1", "p1")
+		l.addParticipant("room1", "p2")
+
+		require.True(t, l.hasParticipant("room1", "p1"))
+		require.False(t, l.hasParticipant("room1", "p2"))
+
+		l.removeParticipant("room1", "p1")
+		l.addParticipant("room1", "p2")
+		require.True(t, l.hasParticipant("room1", "p2"))
+
+		// removing the room frees up its participants
+		l.removeRoom("room1")
+		l.addRoom("room2")
+		l.addParticipant("room2", "p3")
+		require.True(t, l.hasParticipant("room2", "p3"))
+	})
+}
@@ -26,6 +26,11 @@ var (
 			5, 10, 60, 5 * 60, 10 * 60, 30 * 60, 60 * 60, 2 * 60 * 60, 5 * 60 * 60, 10 * 60 * 60,
 		},
 	})
+	promRoomClosedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "room",
+		Name:      "closed_total",
+	}, []string{"reason"})
 	promParticipantTotal = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: livekitNamespace,
 		Subsystem: "participant",
@@ -41,47 +46,95 @@ var (
 		Subsystem: "track",
 		Name:      "subscribed_total",
 	}, []string{"kind"})
+	promTrackStalledTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "track",
+		Name:      "stalled_total",
+	})
+	promTrackPendingTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "track",
+		Name:      "pending_total",
+	})
+	promTrackFirstFrameLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "track",
+		Name:      "first_frame_latency_seconds",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10},
+	}, []string{"kind"})
 )
 
 func initRoomStats() {
 	prometheus.MustRegister(promRoomTotal)
 	prometheus.MustRegister(promRoomDuration)
+	prometheus.MustRegister(promRoomClosedTotal)
 	prometheus.MustRegister(promParticipantTotal)
 	prometheus.MustRegister(promTrackPublishedTotal)
 	prometheus.MustRegister(promTrackSubscribedTotal)
+	prometheus.MustRegister(promTrackStalledTotal)
+	prometheus.MustRegister(promTrackPendingTotal)
+	prometheus.MustRegister(promTrackFirstFrameLatency)
 }
 
-func RoomStarted() {
+func RoomStarted(room string) {
 	promRoomTotal.Add(1)
 	atomic.AddInt32(&atomicRoomTotal, 1)
+
+	labels.addRoom(room)
 }
 
-func RoomEnded(startedAt time.Time) {
+func RoomEnded(room string, startedAt time.Time) {
 	if !startedAt.IsZero() {
 		promRoomDuration.Observe(float64(time.Now().Sub(startedAt)) / float64(time.Second))
 	}
 	promRoomTotal.Sub(1)
 	atomic.AddInt32(&atomicRoomTotal, -1)
+
+	labels.removeRoom(room)
 }
 
-func AddParticipant() {
+// RoomClosed counts rooms closed by the server, because they were empty or exceeded a limit
+func RoomClosed(reason string) {
+	promRoomClosedTotal.WithLabelValues(reason).Add(1)
+}
+
+func AddParticipant(room, participantID string) {
 	promParticipantTotal.Add(1)
 	atomic.AddInt32(&atomicParticipantTotal, 1)
+
+	if roomLabel := labels.roomLabel(room); roomLabel != "" {
+		promRoomParticipants.WithLabelValues(roomLabel).Add(1)
+	}
+	labels.addParticipant(room, participantID)
 }
 
-func SubParticipant() {
+func SubParticipant(room, participantID string) {
 	promParticipantTotal.Sub(1)
 	atomic.AddInt32(&atomicParticipantTotal, -1)
+
+	if roomLabel := labels.roomLabel(room); roomLabel != "" {
+		promRoomParticipants.WithLabelValues(roomLabel).Sub(1)
+	}
+	labels.removeParticipant(room, participantID)
 }
 
-func AddPublishedTrack(kind string) {
+func AddPublishedTrack(room, kind, codec string) {
 	promTrackPublishedTotal.WithLabelValues(kind).Add(1)
 	atomic.AddInt32(&atomicTrackPublishedTotal, 1)
+
+	if roomLabel := labels.roomLabel(room); roomLabel != "" {
+		labels.addTrack(room, trackLabel{kind: kind, codec: codec})
+		promRoomTrackPublished.WithLabelValues(roomLabel, kind, codec).Add(1)
+	}
 }
 
-func SubPublishedTrack(kind string) {
+func SubPublishedTrack(room, kind, codec string) {
 	promTrackPublishedTotal.WithLabelValues(kind).Sub(1)
 	atomic.AddInt32(&atomicTrackPublishedTotal, -1)
+
+	if roomLabel := labels.roomLabel(room); roomLabel != "" {
+		promRoomTrackPublished.WithLabelValues(roomLabel, kind, codec).Sub(1)
+	}
 }
 
 func AddSubscribedTrack(kind string) {
@@ -93,3 +146,36 @@ func SubSubscribedTrack(kind string) {
 	promTrackSubscribedTotal.WithLabelValues(kind).Sub(1)
 	atomic.AddInt32(&atomicTrackSubscribedTotal, -1)
 }
+
+// UpdateParticipantStats records the bitrate (bps) and packets lost over the last stats interval
+func UpdateParticipantStats(room, participantID string, direction Direction, bitrate float64, packetLost uint64) {
+	if !labels.hasParticipant(room, participantID) {
+		return
+	}
+	promParticipantBitrate.WithLabelValues(room, participantID, string(direction)).Set(bitrate)
+	promParticipantPacketLost.WithLabelValues(room, participantID, string(direction)).Set(float64(packetLost))
+}
+
+// TrackStallChanged counts the published tracks that are currently stalled
+func TrackStallChanged(stalled bool) {
+	if stalled {
+		promTrackStalledTotal.Add(1)
+	} else {
+		promTrackStalledTotal.Sub(1)
+	}
+}
+
+// AddPendingTrack counts a track that a publisher added, and that hasn't received media yet
+func AddPendingTrack() {
+	promTrackPendingTotal.Add(1)
+}
+
+// SubPendingTrack counts a pending track that was published, dropped or expired
+func SubPendingTrack() {
+	promTrackPendingTotal.Sub(1)
+}
+
+// RecordFirstFrameLatency records the time a subscriber waited for the first frame of a track
+func RecordFirstFrameLatency(kind string, latency time.Duration) {
+	promTrackFirstFrameLatency.WithLabelValues(kind).Observe(latency.Seconds())
+}
@@ -0,0 +1,35 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// signal requests dropped over the rate limits of participant sessions, by kind
+	promSignalRequestsDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "signal",
+		Name:      "requests_dropped_total",
+	}, []string{"kind"})
+	// participants disconnected for sending too many requests over the limits
+	promSignalRateLimitDisconnects = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "signal",
+		Name:      "rate_limit_disconnects_total",
+	})
+)
+
+func initSignalStats() {
+	prometheus.MustRegister(promSignalRequestsDropped)
+	prometheus.MustRegister(promSignalRateLimitDisconnects)
+}
+
+// IncrementSignalRequestDropped counts a signal request dropped over the rate limit of its kind
+func IncrementSignalRequestDropped(kind string) {
+	promSignalRequestsDropped.WithLabelValues(kind).Inc()
+}
+
+// IncrementSignalRateLimitDisconnect counts a participant disconnected for flooding the node
+func IncrementSignalRateLimitDisconnect() {
+	promSignalRateLimitDisconnects.Inc()
+}
@@ -3,6 +3,7 @@ package telemetry
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/gammazero/workerpool"
 	livekit "github.com/livekit/protocol/proto"
@@ -26,12 +27,38 @@ type TelemetryService interface {
 	RoomEnded(ctx context.Context, room *livekit.Room)
 	ParticipantJoined(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo)
 	ParticipantLeft(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo)
-	TrackPublished(ctx context.Context, participantID string, track *livekit.TrackInfo)
-	TrackUnpublished(ctx context.Context, participantID string, track *livekit.TrackInfo, ssrc uint32)
+	// report to webhooks that a participant waits in the waiting room, was denied, or left while waiting
+	ParticipantPending(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo)
+	ParticipantDenied(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo)
+	ParticipantPendingLeft(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo)
+	TrackPublished(ctx context.Context, participantID string, track *livekit.TrackInfo, mime string)
+	TrackUnpublished(ctx context.Context, participantID string, track *livekit.TrackInfo, mime string, ssrc uint32)
 	TrackSubscribed(ctx context.Context, participantID string, track *livekit.TrackInfo)
 	TrackUnsubscribed(ctx context.Context, participantID string, track *livekit.TrackInfo)
+	// records a change of what a subscriber receives of a track in the subscription audit
+	TrackSubscriptionChanged(ctx context.Context, event *SubscriptionEvent)
+	// records the time from subscribing to a track until the first frame was sent, in prometheus
+	// and as a first_frame event of the subscription audit
+	TrackFirstFrame(ctx context.Context, kind livekit.TrackType, latency time.Duration, event *SubscriptionEvent)
 	RecordingStarted(ctx context.Context, recordingID string, req *livekit.StartRecordingRequest)
 	RecordingEnded(ctx context.Context, res *livekit.RecordingResult)
+	// sends a final transcript of a participant's speech to webhooks
+	TranscriptionReceived(ctx context.Context, event *TranscriptionEvent)
+	// reports to webhooks that a track stopped receiving media, resumed, or was unpublished after
+	// receiving no media for too long
+	TrackStalled(ctx context.Context, event *TrackStallEvent)
+	TrackResumed(ctx context.Context, event *TrackStallEvent)
+	TrackInactive(ctx context.Context, event *TrackStallEvent)
+	// reports to webhooks that a track was added, but never received media
+	PendingTrackExpired(ctx context.Context, event *PendingTrackExpiredEvent)
+	// reports to webhooks that a publisher updated the metadata of a track
+	TrackMetadataUpdated(ctx context.Context, event *TrackMetadataEvent)
+	// reports to webhooks that the node went over or back under capacity
+	NodeCapacityChanged(ctx context.Context, report *CapacityReport)
+	// reports to webhooks that another participant became the dominant speaker of a room, and how
+	// much each participant spoke once a room closed
+	DominantSpeakerChanged(ctx context.Context, event *DominantSpeakerEvent)
+	SpeakerStatsReported(ctx context.Context, event *SpeakerStatsEvent)
 }
 
 type telemetryService struct {
@@ -41,19 +68,34 @@ type telemetryService struct {
 	sync.RWMutex
 	// one worker per participant
 	workers map[string]*StatsWorker
+	// room of each published track by track sid. Tracks close after their participant left, once
+	// its worker is gone
+	trackRooms map[string]trackRoom
 
 	analytics AnalyticsService
+	// nil when the subscription audit is disabled
+	audit *SubscriptionAuditWorker
+	// masks identities in webhooks and analytics, nil when they aren't masked
+	masker *IdentityMasker
 }
 
-func NewTelemetryService(notifier webhook.Notifier, analytics AnalyticsService) TelemetryService {
+func NewTelemetryService(notifier webhook.Notifier, analytics AnalyticsService, audit *SubscriptionAuditWorker, masker *IdentityMasker) TelemetryService {
 	return &telemetryService{
 		notifier:    notifier,
 		webhookPool: workerpool.New(1),
 		workers:     make(map[string]*StatsWorker),
+		trackRooms:  make(map[string]trackRoom),
 		analytics:   analytics,
+		audit:       audit,
+		masker:      masker,
 	}
 }
 
+type trackRoom struct {
+	roomID   string
+	roomName string
+}
+
 func (t *telemetryService) AddUpTrack(participantID string, buff *buffer.Buffer) {
 	t.RLock()
 	w := t.workers[participantID]
@@ -100,7 +142,7 @@ func (t *telemetryService) HandleRTCP(streamType livekit.StreamType, participant
 		direction = prometheus.Outgoing
 	}
 
-	prometheus.IncrementRTCP(direction, stats.NackCount, stats.PliCount, stats.FirCount)
+	prometheus.IncrementRTCP(t.getRoomName(participantID), direction, stats.NackCount, stats.PliCount, stats.FirCount)
 
 	t.RLock()
 	w := t.workers[participantID]
@@ -119,6 +161,8 @@ func (t *telemetryService) Report(ctx context.Context, stats []*livekit.Analytic
 
 		prometheus.IncrementPackets(direction, stat.TotalPackets)
 		prometheus.IncrementBytes(direction, stat.TotalBytes)
+		prometheus.UpdateParticipantStats(stat.RoomName, stat.ParticipantId, direction,
+			float64(stat.TotalBytes*8)/updateFrequency.Seconds(), stat.PacketLost)
 	}
 
 	t.analytics.SendStats(ctx, stats)
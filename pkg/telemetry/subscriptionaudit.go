@@ -0,0 +1,217 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+	"github.com/pkg/errors"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	SubscriptionEventSubscribed   = "subscribed"
+	SubscriptionEventUnsubscribed = "unsubscribed"
+	SubscriptionEventPaused       = "paused"
+	SubscriptionEventResumed      = "resumed"
+	SubscriptionEventLayerChanged = "layer_changed"
+	SubscriptionEventFirstFrame   = "first_frame"
+
+	// why a subscription was paused
+	SubscriptionPausedPublisherMuted     = "publisher_muted"
+	SubscriptionPausedSubscriberDisabled = "subscriber_disabled"
+	SubscriptionPausedBandwidth          = "bandwidth"
+	SubscriptionPausedLowPower           = "low_power"
+
+	SubscriptionAuditSinkFile      = "file"
+	SubscriptionAuditSinkHTTP      = "http"
+	SubscriptionAuditSinkAnalytics = "analytics"
+
+	// events waiting to be exported, further events are dropped while the sink is slow
+	subscriptionAuditQueueSize = 100_000
+)
+
+// SubscriptionEvent is a change of what a subscriber receives of a track
+type SubscriptionEvent struct {
+	Type         string    `json:"type"`
+	Time         time.Time `json:"time"`
+	RoomID       string    `json:"room_id"`
+	RoomName     string    `json:"room_name"`
+	SubscriberID string    `json:"subscriber_id"`
+	PublisherID  string    `json:"publisher_id"`
+	TrackID      string    `json:"track_id"`
+	// why the subscription was paused
+	Reason string `json:"reason,omitempty"`
+	// spatial layer forwarded from now on, for layer_changed
+	Layer *int32 `json:"layer,omitempty"`
+	// time from subscribing until the first frame was sent, for first_frame
+	LatencyMs int64 `json:"latency_ms,omitempty"`
+}
+
+// SubscriptionEventBatch holds the events recorded since the previous export, in the order they
+// happened
+type SubscriptionEventBatch struct {
+	Node   string               `json:"node"`
+	Events []*SubscriptionEvent `json:"events"`
+}
+
+// SubscriptionAuditSink receives batches of subscription events from the SubscriptionAuditWorker
+type SubscriptionAuditSink interface {
+	Export(ctx context.Context, batch *SubscriptionEventBatch) error
+	Close() error
+}
+
+// NewSubscriptionAuditSink returns the sink configured in conf, nil when the audit is disabled.
+// publisher is used by the analytics sink
+func NewSubscriptionAuditSink(conf *config.SubscriptionAuditConfig, publisher EventPublisher) (SubscriptionAuditSink, error) {
+	switch conf.Sink {
+	case "":
+		return nil, nil
+	case SubscriptionAuditSinkFile:
+		f, err := openJSONLinesFile(conf.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		return &fileSubscriptionAuditSink{f}, nil
+	case SubscriptionAuditSinkHTTP:
+		return &httpSubscriptionAuditSink{newJSONPoster(conf.URL)}, nil
+	case SubscriptionAuditSinkAnalytics:
+		if publisher == nil {
+			return nil, errors.New("analytics subscription audit sink requires the event bus to be configured")
+		}
+		return &analyticsSubscriptionAuditSink{publisher: publisher, topic: conf.Topic}, nil
+	default:
+		return nil, fmt.Errorf("unsupported subscription audit sink: %s", conf.Sink)
+	}
+}
+
+type fileSubscriptionAuditSink struct {
+	*jsonLinesFile
+}
+
+func (s *fileSubscriptionAuditSink) Export(_ context.Context, batch *SubscriptionEventBatch) error {
+	return s.write(batch)
+}
+
+type httpSubscriptionAuditSink struct {
+	*jsonPoster
+}
+
+func (s *httpSubscriptionAuditSink) Export(ctx context.Context, batch *SubscriptionEventBatch) error {
+	return s.post(ctx, batch)
+}
+
+// analyticsSubscriptionAuditSink publishes batches as JSON to the event bus. Analytics events
+// have no type for pauses and layer changes, so they aren't sent through the analytics service
+type analyticsSubscriptionAuditSink struct {
+	publisher EventPublisher
+	topic     string
+}
+
+func (s *analyticsSubscriptionAuditSink) Export(ctx context.Context, batch *SubscriptionEventBatch) error {
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	return s.publisher.Publish(ctx, s.topic, data)
+}
+
+func (s *analyticsSubscriptionAuditSink) Close() error {
+	return nil
+}
+
+//------------------------------------------------
+
+// SubscriptionAuditWorker collects subscription events and exports them to a sink every flush
+// interval
+type SubscriptionAuditWorker struct {
+	nodeID   string
+	interval time.Duration
+	sink     SubscriptionAuditSink
+
+	lock    sync.Mutex
+	events  []*SubscriptionEvent
+	dropped int
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewSubscriptionAuditWorker(conf *config.SubscriptionAuditConfig, nodeID string, sink SubscriptionAuditSink) *SubscriptionAuditWorker {
+	return &SubscriptionAuditWorker{
+		nodeID:   nodeID,
+		interval: conf.FlushInterval,
+		sink:     sink,
+		done:     make(chan struct{}),
+	}
+}
+
+func (w *SubscriptionAuditWorker) Start() {
+	w.wg.Add(1)
+	go w.run()
+}
+
+// Stop exports the events recorded so far, and closes the sink
+func (w *SubscriptionAuditWorker) Stop() {
+	close(w.done)
+	w.wg.Wait()
+
+	w.export()
+	if err := w.sink.Close(); err != nil {
+		logger.Warnw("could not close subscription audit sink", err)
+	}
+}
+
+// Record queues an event for the next export
+func (w *SubscriptionAuditWorker) Record(event *SubscriptionEvent) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if len(w.events) >= subscriptionAuditQueueSize {
+		w.dropped++
+		return
+	}
+	w.events = append(w.events, event)
+}
+
+func (w *SubscriptionAuditWorker) run() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.export()
+		}
+	}
+}
+
+func (w *SubscriptionAuditWorker) export() {
+	w.lock.Lock()
+	events := w.events
+	dropped := w.dropped
+	w.events = nil
+	w.dropped = 0
+	w.lock.Unlock()
+
+	if dropped > 0 {
+		logger.Warnw("subscription audit queue is full, events were dropped", nil, "dropped", dropped)
+	}
+	if len(events) == 0 {
+		return
+	}
+	batch := &SubscriptionEventBatch{
+		Node:   w.nodeID,
+		Events: events,
+	}
+	if err := w.sink.Export(context.Background(), batch); err != nil {
+		logger.Warnw("could not export subscription events", err, "events", len(events))
+	}
+}
@@ -0,0 +1,85 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestSubscriptionAudit(t *testing.T) {
+	conf, err := config.NewConfig("", nil)
+	require.NoError(t, err)
+	conf.SubscriptionAudit.Sink = SubscriptionAuditSinkAnalytics
+
+	_, err = NewSubscriptionAuditSink(&conf.SubscriptionAudit, nil)
+	require.Error(t, err)
+
+	publisher := &recordingPublisher{}
+	sink, err := NewSubscriptionAuditSink(&conf.SubscriptionAudit, publisher)
+	require.NoError(t, err)
+	worker := NewSubscriptionAuditWorker(&conf.SubscriptionAudit, "node", sink)
+	ts := NewTelemetryService(nil, NewAnalyticsService(conf, &livekit.Node{Id: "node"}, nil), worker, nil)
+
+	ctx := context.Background()
+	room := &livekit.Room{Sid: "RM_1", Name: "myroom"}
+	subscriber := &livekit.ParticipantInfo{Sid: "PA_sub"}
+	ts.ParticipantJoined(ctx, room, subscriber)
+	defer ts.ParticipantLeft(ctx, room, subscriber)
+
+	layer := int32(2)
+	for _, event := range []*SubscriptionEvent{
+		{Type: SubscriptionEventSubscribed},
+		{Type: SubscriptionEventLayerChanged, Layer: &layer},
+		{Type: SubscriptionEventPaused, Reason: SubscriptionPausedBandwidth},
+		{Type: SubscriptionEventUnsubscribed},
+	} {
+		event.SubscriberID = subscriber.Sid
+		event.PublisherID = "PA_pub"
+		event.TrackID = "TR_1"
+		ts.TrackSubscriptionChanged(ctx, event)
+	}
+	ts.TrackFirstFrame(ctx, livekit.TrackType_VIDEO, 250*time.Millisecond, &SubscriptionEvent{
+		SubscriberID: subscriber.Sid,
+		PublisherID:  "PA_pub",
+		TrackID:      "TR_1",
+	})
+
+	// events recorded so far are exported when stopping
+	require.Zero(t, publisher.count())
+	worker.Stop()
+	require.Equal(t, 1, publisher.count())
+
+	topic, data := publisher.get(0)
+	require.Equal(t, "livekit.subscriptions", topic)
+	batch := &SubscriptionEventBatch{}
+	require.NoError(t, json.Unmarshal(data, batch))
+	require.Equal(t, "node", batch.Node)
+	require.Len(t, batch.Events, 5)
+
+	var types []string
+	for _, event := range batch.Events {
+		types = append(types, event.Type)
+		require.Equal(t, "RM_1", event.RoomID)
+		require.Equal(t, "myroom", event.RoomName)
+		require.Equal(t, "PA_pub", event.PublisherID)
+		require.False(t, event.Time.IsZero())
+	}
+	require.Equal(t, []string{
+		SubscriptionEventSubscribed,
+		SubscriptionEventLayerChanged,
+		SubscriptionEventPaused,
+		SubscriptionEventUnsubscribed,
+		SubscriptionEventFirstFrame,
+	}, types)
+	require.Equal(t, int32(2), *batch.Events[1].Layer)
+	require.Nil(t, batch.Events[0].Layer)
+	require.Equal(t, SubscriptionPausedBandwidth, batch.Events[2].Reason)
+	require.Equal(t, int64(250), batch.Events[4].LatencyMs)
+	require.Zero(t, batch.Events[0].LatencyMs)
+}
@@ -0,0 +1,192 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+	livekit "github.com/livekit/protocol/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// TrackSample is the state of a track's counters at the time it's sampled. Counters are
+// cumulative, the worker turns them into per interval stats
+type TrackSample struct {
+	RoomID        string
+	RoomName      string
+	ParticipantID string
+	TrackID       string
+	// UPSTREAM for published tracks, DOWNSTREAM for subscribed tracks
+	Direction livekit.StreamType
+
+	Packets       uint64
+	Bytes         uint64
+	PacketsLost   uint64
+	LayerSwitches uint64
+	JitterMs      float64
+}
+
+// TrackSampler returns samples of all tracks on this node
+type TrackSampler func() []*TrackSample
+
+// TrackStat holds the stats of a single track over an interval
+type TrackStat struct {
+	RoomID        string  `json:"room_id"`
+	RoomName      string  `json:"room_name"`
+	ParticipantID string  `json:"participant_id"`
+	TrackID       string  `json:"track_id"`
+	Direction     string  `json:"direction"`
+	Packets       uint64  `json:"packets"`
+	Bytes         uint64  `json:"bytes"`
+	PacketsLost   uint64  `json:"packets_lost"`
+	LayerSwitches uint64  `json:"layer_switches"`
+	JitterMs      float64 `json:"jitter_ms"`
+}
+
+// TrackStatsBatch holds the stats of all tracks that were active during an interval
+type TrackStatsBatch struct {
+	Node      string       `json:"node"`
+	StartTime time.Time    `json:"start_time"`
+	EndTime   time.Time    `json:"end_time"`
+	Stats     []*TrackStat `json:"stats"`
+}
+
+type trackKey struct {
+	participantID string
+	trackID       string
+	direction     livekit.StreamType
+}
+
+// TrackStatsWorker samples tracks every interval and exports their stats to a sink
+type TrackStatsWorker struct {
+	nodeID   string
+	interval time.Duration
+	sampler  TrackSampler
+	sink     TrackStatsSink
+
+	lock       sync.Mutex
+	prev       map[trackKey]*TrackSample
+	lastSample time.Time
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewTrackStatsWorker(conf *config.TrackStatsConfig, nodeID string, sampler TrackSampler, sink TrackStatsSink) *TrackStatsWorker {
+	return &TrackStatsWorker{
+		nodeID:   nodeID,
+		interval: conf.Interval,
+		sampler:  sampler,
+		sink:     sink,
+		prev:     make(map[trackKey]*TrackSample),
+		done:     make(chan struct{}),
+	}
+}
+
+func (w *TrackStatsWorker) Start() {
+	w.lock.Lock()
+	w.lastSample = time.Now()
+	w.lock.Unlock()
+
+	w.wg.Add(1)
+	go w.run()
+}
+
+// Stop exports the stats of the current interval, and closes the sink
+func (w *TrackStatsWorker) Stop() {
+	close(w.done)
+	w.wg.Wait()
+
+	w.export()
+	if err := w.sink.Close(); err != nil {
+		logger.Warnw("could not close track stats sink", err)
+	}
+}
+
+func (w *TrackStatsWorker) run() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.export()
+		}
+	}
+}
+
+func (w *TrackStatsWorker) export() {
+	batch := w.collect()
+	if len(batch.Stats) == 0 {
+		return
+	}
+	if err := w.sink.Export(context.Background(), batch); err != nil {
+		logger.Warnw("could not export track stats", err, "tracks", len(batch.Stats))
+	}
+}
+
+// collect samples all tracks, returning what changed since the previous sample. Tracks that
+// were idle are left out
+func (w *TrackStatsWorker) collect() *TrackStatsBatch {
+	samples := w.sampler()
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	now := time.Now()
+	batch := &TrackStatsBatch{
+		Node:      w.nodeID,
+		StartTime: w.lastSample,
+		EndTime:   now,
+	}
+	w.lastSample = now
+
+	next := make(map[trackKey]*TrackSample, len(samples))
+	for _, sample := range samples {
+		key := trackKey{
+			participantID: sample.ParticipantID,
+			trackID:       sample.TrackID,
+			direction:     sample.Direction,
+		}
+		next[key] = sample
+
+		prev := w.prev[key]
+		if prev == nil {
+			prev = &TrackSample{}
+		}
+		stat := &TrackStat{
+			RoomID:        sample.RoomID,
+			RoomName:      sample.RoomName,
+			ParticipantID: sample.ParticipantID,
+			TrackID:       sample.TrackID,
+			Direction:     sample.Direction.String(),
+			Packets:       counterDelta(prev.Packets, sample.Packets),
+			Bytes:         counterDelta(prev.Bytes, sample.Bytes),
+			PacketsLost:   counterDelta(prev.PacketsLost, sample.PacketsLost),
+			LayerSwitches: counterDelta(prev.LayerSwitches, sample.LayerSwitches),
+			JitterMs:      sample.JitterMs,
+		}
+		if stat.Packets == 0 && stat.PacketsLost == 0 {
+			continue
+		}
+		batch.Stats = append(batch.Stats, stat)
+	}
+	// tracks that are gone are forgotten
+	w.prev = next
+
+	return batch
+}
+
+// counterDelta returns how much a counter increased. Counters that went backwards have been reset
+// or wrapped around, and are counted from zero
+func counterDelta(prev, cur uint64) uint64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
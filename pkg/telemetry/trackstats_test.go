@@ -0,0 +1,181 @@
+package telemetry
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestTrackStatsWorker(t *testing.T) {
+	var samples []*TrackSample
+	sink := &recordingTrackStatsSink{}
+	w := NewTrackStatsWorker(&config.TrackStatsConfig{Interval: time.Second}, "node", func() []*TrackSample {
+		return samples
+	}, sink)
+
+	video := &TrackSample{
+		RoomID:        "RM_1",
+		ParticipantID: "PA_1",
+		TrackID:       "TR_1",
+		Direction:     livekit.StreamType_UPSTREAM,
+		Packets:       100,
+		Bytes:         100_000,
+		PacketsLost:   2,
+		JitterMs:      5,
+	}
+	audio := &TrackSample{
+		RoomID:        "RM_1",
+		ParticipantID: "PA_2",
+		TrackID:       "TR_2",
+		Direction:     livekit.StreamType_DOWNSTREAM,
+		Packets:       50,
+		Bytes:         5_000,
+	}
+	samples = []*TrackSample{video, audio}
+	batch := w.collect()
+	require.Equal(t, "node", batch.Node)
+	require.Len(t, batch.Stats, 2)
+	require.Equal(t, uint64(100), batch.Stats[0].Packets)
+	require.Equal(t, "UPSTREAM", batch.Stats[0].Direction)
+	require.Equal(t, "DOWNSTREAM", batch.Stats[1].Direction)
+
+	t.Run("stats cover the interval", func(t *testing.T) {
+		samples = []*TrackSample{
+			{
+				RoomID:        "RM_1",
+				ParticipantID: "PA_1",
+				TrackID:       "TR_1",
+				Direction:     livekit.StreamType_UPSTREAM,
+				Packets:       250,
+				Bytes:         300_000,
+				PacketsLost:   3,
+				LayerSwitches: 1,
+				JitterMs:      8,
+			},
+			audio,
+		}
+		batch := w.collect()
+		// the idle audio track is left out
+		require.Len(t, batch.Stats, 1)
+		stat := batch.Stats[0]
+		require.Equal(t, "TR_1", stat.TrackID)
+		require.Equal(t, uint64(150), stat.Packets)
+		require.Equal(t, uint64(200_000), stat.Bytes)
+		require.Equal(t, uint64(1), stat.PacketsLost)
+		require.Equal(t, uint64(1), stat.LayerSwitches)
+		require.Equal(t, float64(8), stat.JitterMs)
+	})
+
+	t.Run("republished tracks start over", func(t *testing.T) {
+		samples = nil
+		require.Empty(t, w.collect().Stats)
+
+		samples = []*TrackSample{video}
+		batch := w.collect()
+		require.Len(t, batch.Stats, 1)
+		require.Equal(t, uint64(100), batch.Stats[0].Packets)
+	})
+
+	t.Run("stop exports the last interval", func(t *testing.T) {
+		next := *video
+		next.Packets += 10
+		samples = []*TrackSample{&next}
+		w.Start()
+		w.Stop()
+		require.Len(t, sink.batches, 1)
+		require.Equal(t, uint64(10), sink.batches[0].Stats[0].Packets)
+		require.True(t, sink.closed)
+	})
+}
+
+func TestTrackStatsSinks(t *testing.T) {
+	batch := &TrackStatsBatch{
+		Node:  "node",
+		Stats: []*TrackStat{{TrackID: "TR_1", Direction: "UPSTREAM", Packets: 10}},
+	}
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "stats.jsonl")
+		sink, err := NewTrackStatsSink(&config.TrackStatsConfig{Sink: TrackStatsSinkFile, FilePath: path}, nil)
+		require.NoError(t, err)
+		require.NoError(t, sink.Export(context.Background(), batch))
+		require.NoError(t, sink.Export(context.Background(), batch))
+		require.NoError(t, sink.Close())
+
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		lines := 0
+		for scanner.Scan() {
+			decoded := &TrackStatsBatch{}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), decoded))
+			require.Equal(t, "TR_1", decoded.Stats[0].TrackID)
+			lines++
+		}
+		require.Equal(t, 2, lines)
+	})
+
+	t.Run("http", func(t *testing.T) {
+		received := make(chan *TrackStatsBatch, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/unavailable" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			decoded := &TrackStatsBatch{}
+			if err := json.NewDecoder(r.Body).Decode(decoded); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			received <- decoded
+		}))
+		defer server.Close()
+
+		sink := NewHTTPTrackStatsSink(server.URL)
+		require.NoError(t, sink.Export(context.Background(), batch))
+		require.Equal(t, uint64(10), (<-received).Stats[0].Packets)
+
+		require.Error(t, NewHTTPTrackStatsSink(server.URL+"/unavailable").Export(context.Background(), batch))
+	})
+
+	t.Run("analytics", func(t *testing.T) {
+		conf, err := config.NewConfig("", nil)
+		require.NoError(t, err)
+		publisher := &recordingPublisher{}
+		sink := NewAnalyticsTrackStatsSink(NewAnalyticsService(conf, &livekit.Node{Id: "node"}, publisher))
+		require.NoError(t, sink.Export(context.Background(), batch))
+
+		require.Eventually(t, func() bool {
+			return publisher.count() == 1
+		}, time.Second, 10*time.Millisecond)
+		topic, _ := publisher.get(0)
+		require.Equal(t, "livekit.stats", topic)
+	})
+}
+
+type recordingTrackStatsSink struct {
+	batches []*TrackStatsBatch
+	closed  bool
+}
+
+func (s *recordingTrackStatsSink) Export(_ context.Context, batch *TrackStatsBatch) error {
+	s.batches = append(s.batches, batch)
+	return nil
+}
+
+func (s *recordingTrackStatsSink) Close() error {
+	s.closed = true
+	return nil
+}
@@ -0,0 +1,111 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	livekit "github.com/livekit/protocol/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	TrackStatsSinkFile      = "file"
+	TrackStatsSinkHTTP      = "http"
+	TrackStatsSinkAnalytics = "analytics"
+)
+
+// TrackStatsSink receives batches of track stats from the TrackStatsWorker
+type TrackStatsSink interface {
+	Export(ctx context.Context, batch *TrackStatsBatch) error
+	Close() error
+}
+
+// NewTrackStatsSink returns the sink configured in conf, nil when track stats are disabled
+func NewTrackStatsSink(conf *config.TrackStatsConfig, analytics AnalyticsService) (TrackStatsSink, error) {
+	switch conf.Sink {
+	case "":
+		return nil, nil
+	case TrackStatsSinkFile:
+		return NewFileTrackStatsSink(conf.FilePath)
+	case TrackStatsSinkHTTP:
+		return NewHTTPTrackStatsSink(conf.URL), nil
+	case TrackStatsSinkAnalytics:
+		return NewAnalyticsTrackStatsSink(analytics), nil
+	default:
+		return nil, fmt.Errorf("unsupported track stats sink: %s", conf.Sink)
+	}
+}
+
+//------------------------------------------------
+
+type fileTrackStatsSink struct {
+	*jsonLinesFile
+}
+
+// NewFileTrackStatsSink appends batches to the file at path, one JSON object per line
+func NewFileTrackStatsSink(path string) (TrackStatsSink, error) {
+	f, err := openJSONLinesFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &fileTrackStatsSink{f}, nil
+}
+
+func (s *fileTrackStatsSink) Export(_ context.Context, batch *TrackStatsBatch) error {
+	return s.write(batch)
+}
+
+//------------------------------------------------
+
+type httpTrackStatsSink struct {
+	*jsonPoster
+}
+
+// NewHTTPTrackStatsSink POSTs each batch as JSON to url
+func NewHTTPTrackStatsSink(url string) TrackStatsSink {
+	return &httpTrackStatsSink{newJSONPoster(url)}
+}
+
+func (s *httpTrackStatsSink) Export(ctx context.Context, batch *TrackStatsBatch) error {
+	return s.post(ctx, batch)
+}
+
+//------------------------------------------------
+
+type analyticsTrackStatsSink struct {
+	analytics AnalyticsService
+}
+
+// NewAnalyticsTrackStatsSink sends stats through the analytics service, one AnalyticsStat per
+// track. AnalyticsStat has no track or layer fields, so those are left out
+func NewAnalyticsTrackStatsSink(analytics AnalyticsService) TrackStatsSink {
+	return &analyticsTrackStatsSink{
+		analytics: analytics,
+	}
+}
+
+func (s *analyticsTrackStatsSink) Export(ctx context.Context, batch *TrackStatsBatch) error {
+	ts := timestamppb.New(batch.EndTime)
+	stats := make([]*livekit.AnalyticsStat, 0, len(batch.Stats))
+	for _, stat := range batch.Stats {
+		stats = append(stats, &livekit.AnalyticsStat{
+			Kind:          livekit.StreamType(livekit.StreamType_value[stat.Direction]),
+			TimeStamp:     ts,
+			RoomId:        stat.RoomID,
+			RoomName:      stat.RoomName,
+			ParticipantId: stat.ParticipantID,
+			Jitter:        stat.JitterMs,
+			TotalPackets:  stat.Packets,
+			TotalBytes:    stat.Bytes,
+			PacketLost:    stat.PacketsLost,
+		})
+	}
+	s.analytics.SendStats(ctx, stats)
+	return nil
+}
+
+func (s *analyticsTrackStatsSink) Close() error {
+	return nil
+}
@@ -96,6 +96,16 @@ func contextWithCreateRoomToken() context.Context {
 	return tctx
 }
 
+func contextWithAdminToken(room string) context.Context {
+	header := make(http.Header)
+	testclient.SetAuthorizationToken(header, adminToken(room))
+	tctx, err := twirp.WithHTTPRequestHeaders(context.Background(), header)
+	if err != nil {
+		panic(err)
+	}
+	return tctx
+}
+
 func waitForServerToStart(s *service.LivekitServer) {
 	// wait till ready
 	ctx, cancel := context.WithTimeout(context.Background(), testutils.ConnectTimeout)
@@ -231,6 +241,17 @@ func createRoomToken() string {
 	return t
 }
 
+func adminToken(room string) string {
+	at := auth.NewAccessToken(testApiKey, testApiSecret).
+		AddGrant(&auth.VideoGrant{RoomAdmin: true, Room: room}).
+		SetIdentity("testadmin")
+	t, err := at.ToJWT()
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
 func stopWriters(writers ...*testclient.TrackWriter) {
 	for _, w := range writers {
 		w.Stop()
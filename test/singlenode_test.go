@@ -7,8 +7,11 @@ import (
 	"time"
 
 	"github.com/livekit/livekit-server/pkg/rtc"
+	livekit "github.com/livekit/protocol/proto"
+	"github.com/livekit/protocol/utils"
 	"github.com/pion/webrtc/v3"
 	"github.com/stretchr/testify/require"
+	"github.com/twitchtv/twirp"
 
 	"github.com/livekit/livekit-server/pkg/testutils"
 	testclient "github.com/livekit/livekit-server/test/client"
@@ -216,3 +219,149 @@ func Test_RenegotiationWithDifferentCodecs(t *testing.T) {
 	}
 
 }
+
+func TestServerSendData(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	_, finish := setupSingleNodeTest("TestServerSendData", testRoom)
+	defer finish()
+
+	c1 := createRTCClient("c1", defaultServerPort, nil)
+	c2 := createRTCClient("c2", defaultServerPort, nil)
+	waitUntilConnected(t, c1, c2)
+	defer stopClients(c1, c2)
+
+	c1Received := utils.AtomicFlag{}
+	c1.OnDataReceived = func(data []byte, sid string) {
+		c1Received.TrySet(true)
+	}
+	c2Received := utils.AtomicFlag{}
+	c2.OnDataReceived = func(data []byte, sid string) {
+		if string(data) == "from server" && sid == "" {
+			c2Received.TrySet(true)
+		}
+	}
+
+	ctx := contextWithAdminToken(testRoom)
+	// data channels might still be opening, keep sending until it gets through
+	testutils.WithTimeout(t, "c2 should receive data", func() bool {
+		if c2Received.Get() {
+			return true
+		}
+		_, err := roomClient.SendData(ctx, &livekit.SendDataRequest{
+			Room:            testRoom,
+			Data:            []byte("from server"),
+			Kind:            livekit.DataPacket_RELIABLE,
+			DestinationSids: []string{c2.ID()},
+		})
+		require.NoError(t, err)
+		return false
+	})
+	time.Sleep(syncDelay)
+	require.False(t, c1Received.Get(), "c1 was not a destination")
+
+	_, err := roomClient.SendData(ctx, &livekit.SendDataRequest{
+		Room: testRoom,
+		Data: make([]byte, 100*1024),
+		Kind: livekit.DataPacket_LOSSY,
+	})
+	require.Error(t, err)
+	require.Equal(t, twirp.InvalidArgument, err.(twirp.Error).Code())
+
+	notFoundCtx := contextWithAdminToken("nonexistent")
+	_, err = roomClient.SendData(notFoundCtx, &livekit.SendDataRequest{
+		Room: "nonexistent",
+		Data: []byte("hello"),
+	})
+	require.Error(t, err)
+	require.Equal(t, twirp.NotFound, err.(twirp.Error).Code())
+}
+
+func TestServerMuteAndRemoveParticipant(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	_, finish := setupSingleNodeTest("TestServerMuteAndRemoveParticipant", testRoom)
+	defer finish()
+
+	c1 := createRTCClient("c1", defaultServerPort, nil)
+	c2 := createRTCClient("c2", defaultServerPort, nil)
+	waitUntilConnected(t, c1, c2)
+	defer stopClients(c1, c2)
+
+	writer, err := c1.AddStaticTrack("audio/opus", "audio", "microphone")
+	require.NoError(t, err)
+	defer writer.Stop()
+
+	publishedTrack := func() *livekit.TrackInfo {
+		for _, p := range c2.RemoteParticipants() {
+			if p.Identity == "c1" && len(p.Tracks) == 1 {
+				return p.Tracks[0]
+			}
+		}
+		return nil
+	}
+	testutils.WithTimeout(t, "c2 should see c1's track", func() bool {
+		return publishedTrack() != nil
+	})
+	trackSid := publishedTrack().Sid
+
+	ctx := contextWithAdminToken(testRoom)
+	res, err := roomClient.MutePublishedTrack(ctx, &livekit.MuteRoomTrackRequest{
+		Room:     testRoom,
+		Identity: "c1",
+		TrackSid: trackSid,
+		Muted:    true,
+	})
+	require.NoError(t, err)
+	require.True(t, res.Track.Muted)
+	testutils.WithTimeout(t, "c2 should see the track muted", func() bool {
+		track := publishedTrack()
+		return track != nil && track.Muted
+	})
+
+	// remote unmute isn't enabled
+	_, err = roomClient.MutePublishedTrack(ctx, &livekit.MuteRoomTrackRequest{
+		Room:     testRoom,
+		Identity: "c1",
+		TrackSid: trackSid,
+		Muted:    false,
+	})
+	require.Error(t, err)
+	require.Equal(t, twirp.PermissionDenied, err.(twirp.Error).Code())
+
+	_, err = roomClient.MutePublishedTrack(ctx, &livekit.MuteRoomTrackRequest{
+		Room:     testRoom,
+		Identity: "c1",
+		TrackSid: "TR_unknown",
+		Muted:    true,
+	})
+	require.Error(t, err)
+	require.Equal(t, twirp.NotFound, err.(twirp.Error).Code())
+
+	_, err = roomClient.RemoveParticipant(ctx, &livekit.RoomParticipantIdentity{
+		Room:     testRoom,
+		Identity: "c1",
+	})
+	require.NoError(t, err)
+	testutils.WithTimeout(t, "c2 should see c1 leave", func() bool {
+		return len(c2.RemoteParticipants()) == 0
+	})
+	testutils.WithTimeout(t, "c1 should be removed from the room", func() bool {
+		res, err := roomClient.ListParticipants(ctx, &livekit.ListParticipantsRequest{Room: testRoom})
+		require.NoError(t, err)
+		return len(res.Participants) == 1
+	})
+
+	_, err = roomClient.RemoveParticipant(ctx, &livekit.RoomParticipantIdentity{
+		Room:     testRoom,
+		Identity: "c1",
+	})
+	require.Error(t, err)
+	require.Equal(t, twirp.NotFound, err.(twirp.Error).Code())
+}